@@ -0,0 +1,103 @@
+// Package main provides executable for FLUO agent helper, which owns privileged D-Bus connections
+// on behalf of the agent.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/coreos/pkg/flagutil"
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/dbus"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/helper"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logind"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/updateengine"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/version"
+)
+
+const readHeaderTimeout = 10 * time.Second
+
+var (
+	socket       = flag.String("socket", helper.DefaultSocketPath, "Path of Unix socket to listen on")
+	socketMode   = flag.String("socket-mode", "0660", "Permissions of created Unix socket in octal notation")
+	printVersion = flag.Bool("version", false, "Print version and exit")
+
+	dbusSocket = flag.String("dbus-socket", "",
+		"Path to Unix socket of the host system bus used to talk to update_engine and logind. By default, "+
+			"DBUS_SYSTEM_BUS_ADDRESS environment variable is used or the socket is detected out of well-known paths "+
+			"like '/run/dbus/system_bus_socket'")
+
+	statusPollInterval = flag.Duration("update-status-poll-interval", 0,
+		"Poll update_engine status with given interval instead of subscribing to D-Bus status signals, e.g. on "+
+			"hosts restricting D-Bus signals. E.g. '1m'. Disabled by default")
+)
+
+func main() {
+	klog.InitFlags(nil)
+
+	if err := flag.Set("logtostderr", "true"); err != nil {
+		klog.Fatalf("Failed to set %q flag value: %v", "logtostderr", err)
+	}
+
+	flag.Parse()
+
+	if err := flagutil.SetFlagsFromEnv(flag.CommandLine, "UPDATE_AGENT_HELPER"); err != nil {
+		klog.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if *printVersion {
+		fmt.Println(version.Format())
+		os.Exit(0)
+	}
+
+	mode, err := strconv.ParseUint(*socketMode, 8, 32)
+	if err != nil {
+		klog.Fatalf("Failed parsing %q flag: %v", "socket-mode", err)
+	}
+
+	systemBusConnector := dbus.SystemBusConnector(*dbusSocket, dbus.SystemBusSocketPaths...)
+
+	updateEngineClient, err := updateengine.NewWithConfig(&updateengine.Config{
+		Connector:          systemBusConnector,
+		StatusPollInterval: *statusPollInterval,
+	})
+	if err != nil {
+		klog.Fatalf("Failed establishing connection to update_engine dbus: %v", err)
+	}
+
+	defer func() {
+		if err := updateEngineClient.Close(); err != nil {
+			klog.Warningf("Failed gracefully closing update_engine client: %v", err)
+		}
+	}()
+
+	rebooter, err := logind.NewRebooter(systemBusConnector)
+	if err != nil {
+		klog.Fatalf("Failed establishing connection to logind dbus: %v", err)
+	}
+
+	server := helper.NewServer(updateEngineClient, rebooter)
+
+	go server.Run(make(chan struct{}))
+
+	listener, err := helper.Listen(*socket, os.FileMode(mode))
+	if err != nil {
+		klog.Fatalf("Failed creating socket %q: %v", *socket, err)
+	}
+
+	klog.Infof("%s listening on %q", os.Args[0], *socket)
+
+	httpServer := &http.Server{
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	if err := httpServer.Serve(listener); err != nil {
+		klog.Fatalf("Error serving helper: %v", err)
+	}
+}
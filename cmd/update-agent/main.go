@@ -5,31 +5,283 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/coreos/go-systemd/v22/login1"
 	"github.com/coreos/pkg/flagutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"k8s.io/klog/v2"
 
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/agent"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/cloudreboot"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/configfile"
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/dbus"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/healthz"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/helper"
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logging"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logind"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/standalone"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/sysupdate"
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/updateengine"
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/version"
 )
 
-const defaultGracePeriodSeconds = 600
+const (
+	defaultGracePeriodSeconds = 600
+
+	metricsReadHeaderTimeout = 10 * time.Second
+
+	// metricsNamespace is shared by metrics of the agent and its dependencies.
+	metricsNamespace = "flatcar_linux_update_agent"
+
+	// stateSocketMode allows any local user to read the agent state, as it is not sensitive.
+	stateSocketMode = 0o666
+
+	updateSourceUpdateEngine = "update-engine"
+	updateSourceSysupdate    = "systemd-sysupdate"
+
+	rebootMethodLogind  = "logind"
+	rebootMethodCommand = "command"
+	rebootMethodNone    = "none"
+)
 
 var (
+	configFile = flag.String("config", "",
+		"Path to a YAML configuration file with flag values keyed by flag names. Flags and environment "+
+			"variables take precedence over the configuration file. See doc/configuration-file.md")
 	node         = flag.String("node", "", "Kubernetes node name")
 	printVersion = flag.Bool("version", false, "Print version and exit")
+	logFormat    = flag.String("log-format", logging.FormatText,
+		fmt.Sprintf("Format of logs, either %q or %q", logging.FormatText, logging.FormatJSON))
 
 	reapTimeout = flag.Int("grace-period", defaultGracePeriodSeconds,
 		"Period of time in seconds given to a pod to terminate when rebooting for an update")
+	evictionTimeout = flag.Duration("eviction-timeout", 0,
+		"Period of time after which pods which could not be evicted, e.g. because of PodDisruptionBudgets, "+
+			"are deleted instead. Defaults to the value of --grace-period")
+	preDrainHookCommand = flag.String("pre-drain-hook", "",
+		"Command executed using /bin/sh before draining the node, e.g. to gracefully stop a service "+
+			"running on the host. Disabled by default")
+	preDrainHooksDirectory = flag.String("pre-drain-hooks-dir", "",
+		"Directory with executables, which are executed in lexical order before draining the node, "+
+			"after --pre-drain-hook. Disabled by default")
+	preDrainHookTimeout = flag.Duration("pre-drain-hook-timeout", 0,
+		"Period of time after which each pre-drain hook is killed and considered failed. Defaults to 5m")
+	preDrainHookFailurePolicy = flag.String("pre-drain-hook-failure-policy", agent.HookFailurePolicyFail,
+		fmt.Sprintf("Either %q to abort the reboot when pre-drain hook fails or %q to proceed with the reboot",
+			agent.HookFailurePolicyFail, agent.HookFailurePolicyIgnore))
+	postRebootReadyDuration = flag.Duration("post-reboot-ready-duration", 0,
+		"Period of time for which kubelet must report the node as Ready after the reboot, before the node is "+
+			"made schedulable again and the reboot is reported as finished. E.g. '2m'. Disabled by default")
+	postRebootNetworkCheck = flag.Bool("post-reboot-network-check", false,
+		"Wait after the reboot, before the node is made schedulable again and the reboot is reported as finished, "+
+			"until kubelet reports the node as Ready, node network is available and the Kubernetes API service "+
+			"can be reached using pod network")
+	rebootSentinelFile = flag.String("reboot-sentinel-file", "",
+		"Path to a file, which presence indicates that node needs a reboot, in addition to update_engine "+
+			"status. E.g. '/run/reboot-required'. Disabled by default")
+	updateSource = flag.String("update-source", updateSourceUpdateEngine,
+		fmt.Sprintf("Source of update status, either %q to watch update_engine over D-Bus or %q to periodically "+
+			"check for installed, but not booted OS version", updateSourceUpdateEngine, updateSourceSysupdate))
+	sysupdateCommand = flag.String("sysupdate-command", sysupdate.DefaultCommand,
+		"Command used to execute systemd-sysupdate when using systemd-sysupdate update source. "+
+			"E.g. 'chroot /host systemd-sysupdate'")
+	sysupdatePollInterval = flag.Duration("sysupdate-poll-interval", 0,
+		"How often systemd-sysupdate is checked for pending updates when using systemd-sysupdate update source. "+
+			"Defaults to 1m")
+	statusTransitionsOnly = flag.Bool("update-status-transitions-only", false,
+		"Process only update_engine statuses describing transitions between update operations, e.g. from "+
+			"downloading to verifying, dropping duplicate statuses and progress updates")
+	progressSamplingInterval = flag.Duration("update-progress-sampling-interval", 0,
+		"When --update-status-transitions-only is set, process update_engine progress updates at most once per "+
+			"given period of time. E.g. '30s'. Disabled by default")
+	statusPollInterval = flag.Duration("update-status-poll-interval", 0,
+		"Poll update_engine status with given interval instead of subscribing to D-Bus status signals, e.g. on "+
+			"hosts restricting D-Bus signals. E.g. '1m'. Disabled by default")
+	rebootMethod = flag.String("reboot-method", rebootMethodLogind,
+		fmt.Sprintf("How the host is rebooted, either %q to request the reboot from logind, %q to execute "+
+			"--reboot-command or %q to never reboot, e.g. for testing", rebootMethodLogind, rebootMethodCommand,
+			rebootMethodNone))
+	rebootCommand = flag.String("reboot-command", agent.DefaultRebootCommand,
+		"Command executed using /bin/sh to reboot the host when using command reboot method. "+
+			"E.g. 'chroot /host systemctl reboot'")
+	rebootWarningDelay = flag.Duration("reboot-warning-delay", 0,
+		"Schedule the reboot using logind after given period of time instead of rebooting immediately, warning "+
+			"users logged in on the host using wall messages. Scheduled reboot is cancelled when the reboot approval "+
+			"is revoked in the meantime. Only supported with logind reboot method without --helper-socket. "+
+			"E.g. '5m'. Disabled by default")
+	rebootWallMessage = flag.String("reboot-wall-message", "",
+		"Wall message sent to users logged in on the host before the delayed reboot. Defaults to a generic "+
+			"message about rebooting to apply an OS update")
+	rebootRequiredCondition = flag.Bool("reboot-required-condition", false,
+		"Maintain RebootRequired node condition in addition to annotations, so the pending reboot is visible to "+
+			"tools working with node conditions. Requires permissions to patch nodes/status")
+	heartbeatInterval = flag.Duration("heartbeat-interval", 0,
+		"Renew heartbeat lease of the node in POD_NAMESPACE namespace with given interval, so update-operator can "+
+			"detect nodes with agent which is not running. E.g. '1m'. Disabled by default")
+	helperSocket = flag.String("helper-socket", "",
+		"Path to Unix socket of update-agent-helper. When set, update_engine statuses are received and reboots "+
+			"are requested through the helper instead of host D-Bus, so the agent can run unprivileged. "+
+			"E.g. '"+helper.DefaultSocketPath+"'. Disabled by default")
+	dbusSocket = flag.String("dbus-socket", "",
+		"Path to Unix socket of the host system bus used to talk to update_engine and logind. By default, "+
+			"DBUS_SYSTEM_BUS_ADDRESS environment variable is used or the socket is detected out of well-known paths "+
+			"like '/run/dbus/system_bus_socket', depending on how the host bus is mounted into the container")
+	maxNodeUpdateFailureDuration = flag.Duration("max-node-update-failure-duration", 0,
+		"Period of time after which liveness probe fails when updating Node object keeps failing. Defaults to 5m")
+	securityFeedURL = flag.String("security-feed-url", "",
+		"URL of a JSON feed listing versions which carry security fixes, e.g. {\"3510.2.7\": [\"CVE-2023-1234\"]}. "+
+			"When set, node is annotated whether the pending reboot applies a security update. Disabled by default")
+	pauseFile = flag.String("pause-file", "/etc/flatcar/fluo-pause",
+		"Path to a file, which presence pauses reboots of the node. Set to empty value to disable")
+	drainRetryBudget = flag.Duration("drain-retry-budget", 0,
+		"Period of time during which draining the node is retried with exponential backoff when not all pods "+
+			"could be removed, e.g. because of stuck finalizers. E.g. '30m'. Disabled by default")
+	drainRetryInterval = flag.Duration("drain-retry-interval", 0,
+		"Initial period of time between drain attempts, doubled after each failed attempt. Defaults to 10s")
+	drainFailurePolicy = flag.String("drain-failure-policy", agent.DrainFailurePolicyProceed,
+		fmt.Sprintf("Either %q to reboot the node even when it could not be drained or %q to abort the reboot",
+			agent.DrainFailurePolicyProceed, agent.DrainFailurePolicyAbort))
+	volumeDetachTimeout = flag.Duration("volume-detach-timeout", 0,
+		"Maximum period of time to wait after draining the node for VolumeAttachments of the node to be removed "+
+			"before rebooting. E.g. '5m'. Disabled by default")
+	jobCompletionTimeout = flag.Duration("job-completion-timeout", 0,
+		"Maximum period of time to wait before draining the node for pods of Jobs annotated with "+
+			"wait-for-completion annotation to finish. E.g. '6h'. Disabled by default")
+	rebootWindowStart = flag.String("reboot-window-start", "",
+		"Day of week ('Sun', 'Mon', ...; optional) and time of day at which the reboot window starts. When set, "+
+			"the node is not drained outside of the window, even if the reboot has been approved. "+
+			"E.g. 'Mon 14:00', '11:00'")
+	rebootWindowLength       = flag.String("reboot-window-length", "", "Length of the reboot window. E.g. '1h30m'")
+	minRemainingRebootWindow = flag.Duration("min-remaining-reboot-window", 0,
+		"Minimum part of the reboot window, which must remain to start draining the node. Otherwise, the reboot "+
+			"is deferred to the next window. E.g. '30m'")
+	fallbackRebootProvider = flag.String("fallback-reboot-provider", "",
+		fmt.Sprintf("Cloud provider, either %q, %q or %q, which API is used to reboot the machine when requesting "+
+			"the reboot fails or the host does not reboot within --fallback-reboot-timeout. Credentials are read "+
+			"from environment variables used by the CLI of the provider, see doc/fallback-reboot.md. "+
+			"Disabled by default", cloudreboot.ProviderAWS, cloudreboot.ProviderGCP, cloudreboot.ProviderOpenStack))
+	fallbackRebootTimeout = flag.Duration("fallback-reboot-timeout", 0,
+		"Period of time after which the machine is rebooted using --fallback-reboot-provider. Defaults to 10m")
+	markBootSuccessfulCommand = flag.String("mark-boot-successful-command", "",
+		"Command executed using /bin/sh after the reboot, once the node passes post-reboot verification, to mark "+
+			"the booted partition as successfully booted. Booted USR partition is passed in FLUO_USR_PARTITION "+
+			"environment variable. Disabled by default")
+	uncordonAfterReboot = flag.Bool("uncordon-after-reboot", true,
+		"Make the node schedulable again after the reboot. When false, node is left unschedulable, so workloads "+
+			"are re-admitted manually. May be overridden per node using the uncordon-after-reboot annotation")
+	rebootTaint = flag.String("reboot-taint", "",
+		"Taint in 'key[=value]:effect' format applied to the node while it is drained and rebooted, in addition to "+
+			"making it unschedulable. E.g. 'example.com/rebooting:NoSchedule'. Disabled by default")
+	cordonNode = flag.Bool("cordon-node", true,
+		"Make the node unschedulable while it is drained and rebooted. When false, only --reboot-taint is applied, "+
+			"so pods tolerating the taint can still be scheduled on the node")
+	reconcileChannel = flag.Bool("reconcile-channel", false,
+		"Change the update channel used by update_engine to the one set in the channel node annotation. "+
+			"Only supported with the update-engine update source talking to update_engine directly")
+	inhibitUnapprovedReboots = flag.Bool("inhibit-unapproved-reboots", false,
+		"Take logind shutdown inhibitor lock while the update is waiting for the reboot approval, so the node is not "+
+			"rebooted by anything else in the meantime. Only supported with logind reboot method without "+
+			"--helper-socket")
 	forceNodeDrain = flag.Bool("force-drain", false, "Force removal of pods with custom or no owners while draining node")
+
+	postRebootProbes           flagutil.StringSliceFlag
+	drainPriorityGracePeriods  flagutil.StringSliceFlag
+	volumeDetachIgnoredDrivers flagutil.StringSliceFlag
+
+	nodeUpdateBackoff = k8sutil.DefaultBackoff()
+
+	kubernetesAPIProtobuf = flag.Bool("kubernetes-api-protobuf", true,
+		"Use protobuf instead of JSON for Kubernetes API requests for built-in resources. Disable if a proxy "+
+			"in front of the API server does not support protobuf")
+
+	metricsAddress = flag.String("metrics-address", ":8080",
+		"Address on which Prometheus metrics are served on /metrics path. Set to empty value to disable")
+
+	healthProbeAddress = flag.String("health-probe-address", ":8081",
+		"Address on which liveness and readiness probes are served on /healthz and /readyz paths. "+
+			"Set to empty value to disable")
+	standaloneSemaphoreDir = flag.String("standalone-semaphore-dir", "",
+		"Path to a directory shared by all machines, e.g. using NFS, used as a semaphore limiting number of "+
+			"simultaneously rebooting machines. When set, agent runs in standalone mode without Kubernetes. "+
+			"Disabled by default")
+	standaloneMaxRebootingMachines = flag.Int("standalone-max-rebooting-machines", 1,
+		"Maximum number of machines rebooting simultaneously in standalone mode")
+	stateSocket = flag.String("state-socket", "",
+		"Path of Unix socket on which agent state is served as JSON for node debugging tooling and hooks. "+
+			"E.g. '/run/update-agent/state.sock'. Disabled by default")
 )
 
+// configFileOptions lists options of the agent, which may be set in the configuration file.
+// Every flag except --config and --version has a field named after it, plus v for logging verbosity.
+type configFileOptions struct {
+	Node                           *string               `yaml:"node"`
+	LogFormat                      *string               `yaml:"log-format"`
+	GracePeriod                    *int                  `yaml:"grace-period"`
+	EvictionTimeout                *time.Duration        `yaml:"eviction-timeout"`
+	PreDrainHook                   *string               `yaml:"pre-drain-hook"`
+	PreDrainHooksDir               *string               `yaml:"pre-drain-hooks-dir"`
+	PreDrainHookTimeout            *time.Duration        `yaml:"pre-drain-hook-timeout"`
+	PreDrainHookFailurePolicy      *string               `yaml:"pre-drain-hook-failure-policy"`
+	PostRebootReadyDuration        *time.Duration        `yaml:"post-reboot-ready-duration"`
+	PostRebootNetworkCheck         *bool                 `yaml:"post-reboot-network-check"`
+	RebootSentinelFile             *string               `yaml:"reboot-sentinel-file"`
+	UpdateSource                   *string               `yaml:"update-source"`
+	SysupdateCommand               *string               `yaml:"sysupdate-command"`
+	SysupdatePollInterval          *time.Duration        `yaml:"sysupdate-poll-interval"`
+	UpdateStatusTransitionsOnly    *bool                 `yaml:"update-status-transitions-only"`
+	UpdateProgressSamplingInterval *time.Duration        `yaml:"update-progress-sampling-interval"`
+	UpdateStatusPollInterval       *time.Duration        `yaml:"update-status-poll-interval"`
+	RebootMethod                   *string               `yaml:"reboot-method"`
+	RebootCommand                  *string               `yaml:"reboot-command"`
+	RebootWarningDelay             *time.Duration        `yaml:"reboot-warning-delay"`
+	RebootWallMessage              *string               `yaml:"reboot-wall-message"`
+	RebootRequiredCondition        *bool                 `yaml:"reboot-required-condition"`
+	HeartbeatInterval              *time.Duration        `yaml:"heartbeat-interval"`
+	HelperSocket                   *string               `yaml:"helper-socket"`
+	DBusSocket                     *string               `yaml:"dbus-socket"`
+	MaxNodeUpdateFailureDuration   *time.Duration        `yaml:"max-node-update-failure-duration"`
+	SecurityFeedURL                *string               `yaml:"security-feed-url"`
+	PauseFile                      *string               `yaml:"pause-file"`
+	DrainRetryBudget               *time.Duration        `yaml:"drain-retry-budget"`
+	DrainRetryInterval             *time.Duration        `yaml:"drain-retry-interval"`
+	DrainFailurePolicy             *string               `yaml:"drain-failure-policy"`
+	VolumeDetachTimeout            *time.Duration        `yaml:"volume-detach-timeout"`
+	JobCompletionTimeout           *time.Duration        `yaml:"job-completion-timeout"`
+	RebootWindowStart              *string               `yaml:"reboot-window-start"`
+	RebootWindowLength             *string               `yaml:"reboot-window-length"`
+	MinRemainingRebootWindow       *time.Duration        `yaml:"min-remaining-reboot-window"`
+	FallbackRebootProvider         *string               `yaml:"fallback-reboot-provider"`
+	FallbackRebootTimeout          *time.Duration        `yaml:"fallback-reboot-timeout"`
+	MarkBootSuccessfulCommand      *string               `yaml:"mark-boot-successful-command"`
+	UncordonAfterReboot            *bool                 `yaml:"uncordon-after-reboot"`
+	RebootTaint                    *string               `yaml:"reboot-taint"`
+	CordonNode                     *bool                 `yaml:"cordon-node"`
+	ReconcileChannel               *bool                 `yaml:"reconcile-channel"`
+	InhibitUnapprovedReboots       *bool                 `yaml:"inhibit-unapproved-reboots"`
+	ForceDrain                     *bool                 `yaml:"force-drain"`
+	KubernetesAPIProtobuf          *bool                 `yaml:"kubernetes-api-protobuf"`
+	MetricsAddress                 *string               `yaml:"metrics-address"`
+	HealthProbeAddress             *string               `yaml:"health-probe-address"`
+	StandaloneSemaphoreDir         *string               `yaml:"standalone-semaphore-dir"`
+	StandaloneMaxRebootingMachines *int                  `yaml:"standalone-max-rebooting-machines"`
+	StateSocket                    *string               `yaml:"state-socket"`
+	PostRebootProbes               configfile.StringList `yaml:"post-reboot-probes"`
+	DrainPriorityGracePeriods      configfile.StringList `yaml:"drain-priority-grace-periods"`
+	VolumeDetachIgnoredDrivers     configfile.StringList `yaml:"volume-detach-ignored-drivers"`
+	NodeUpdateRetryInitialDelay    *time.Duration        `yaml:"node-update-retry-initial-delay"`
+	NodeUpdateRetryFactor          *float64              `yaml:"node-update-retry-factor"`
+	NodeUpdateRetryJitter          *float64              `yaml:"node-update-retry-jitter"`
+	NodeUpdateMaxRetries           *int                  `yaml:"node-update-max-retries"`
+	Verbosity                      *int                  `yaml:"v"`
+}
+
 func main() {
 	klog.InitFlags(nil)
 
@@ -37,45 +289,140 @@ func main() {
 		klog.Fatalf("Failed to set %q flag value: %v", "logtostderr", err)
 	}
 
+	flag.Var(&postRebootProbes, "post-reboot-probes",
+		"Comma-separated list of URLs of services, which must be healthy after the reboot, before the node is "+
+			"made schedulable again and the reboot is reported as finished. Supported schemes are http, https "+
+			"and tcp. E.g. 'http://127.0.0.1:10256/healthz,tcp://127.0.0.1:22'")
+
+	flag.Var(&drainPriorityGracePeriods, "drain-priority-grace-periods",
+		"Comma-separated list of priority=duration pairs. Pods with priority equal to or higher than given "+
+			"priority are removed after lower priority pods, using given termination grace period. "+
+			"E.g. '1000=2m,2000000000=5m'. Disabled by default")
+
+	flag.Var(&volumeDetachIgnoredDrivers, "volume-detach-ignored-drivers",
+		"Comma-separated list of CSI driver names, which volumes are not waited for to be detached when "+
+			"--volume-detach-timeout is set. E.g. 'local.csi.example.com'")
+
+	flag.DurationVar(&nodeUpdateBackoff.InitialDelay, "node-update-retry-initial-delay", nodeUpdateBackoff.InitialDelay,
+		"Delay before retrying update of Node object, which failed due to a conflict")
+
+	flag.Float64Var(&nodeUpdateBackoff.Factor, "node-update-retry-factor", nodeUpdateBackoff.Factor,
+		"Factor by which delay between retries of Node object updates grows. Must be at least 1")
+
+	flag.Float64Var(&nodeUpdateBackoff.Jitter, "node-update-retry-jitter", nodeUpdateBackoff.Jitter,
+		"Maximum random extension of delay between retries of Node object updates, as a fraction of the delay")
+
+	flag.IntVar(&nodeUpdateBackoff.MaxRetries, "node-update-max-retries", nodeUpdateBackoff.MaxRetries,
+		"Number of retries of Node object update failing due to conflicts before giving up")
+
 	flag.Parse()
 
 	if err := flagutil.SetFlagsFromEnv(flag.CommandLine, "UPDATE_AGENT"); err != nil {
 		klog.Fatalf("Failed to parse environment variables: %v", err)
 	}
 
+	if *configFile != "" {
+		if err := configfile.SetFlags(flag.CommandLine, *configFile, &configFileOptions{}); err != nil {
+			klog.Fatalf("Failed to parse configuration file: %v", err)
+		}
+	}
+
+	if err := logging.Configure(*logFormat, os.Stderr); err != nil {
+		klog.Fatalf("Failed configuring logging: %v", err)
+	}
+
 	if *printVersion {
 		fmt.Println(version.Format())
 		os.Exit(0)
 	}
 
-	clientset, err := k8sutil.GetClient("")
+	if *standaloneSemaphoreDir != "" {
+		runStandalone()
+
+		return
+	}
+
+	clientMetrics, err := k8sutil.NewClientMetrics(prometheus.DefaultRegisterer, metricsNamespace)
+	if err != nil {
+		klog.Fatalf("Failed creating Kubernetes client metrics: %v", err)
+	}
+
+	clientset, err := k8sutil.GetClientWithOptions("", k8sutil.ClientOptions{
+		Metrics:         clientMetrics,
+		DisableProtobuf: !*kubernetesAPIProtobuf,
+	})
 	if err != nil {
 		klog.Fatalf("Failed creating Kubernetes client: %v", err)
 	}
 
-	updateEngineClient, err := updateengine.New(dbus.SystemPrivateConnector)
+	statusReceiver, closeStatusReceiver, err := newStatusReceiver()
 	if err != nil {
-		klog.Fatalf("Failed establishing connection to update_engine dbus: %v", err)
+		klog.Fatalf("Failed creating update status receiver: %v", err)
 	}
 
-	defer func() {
-		if err := updateEngineClient.Close(); err != nil {
-			klog.Warningf("Failed gracefully closing update_engine client: %v", err)
-		}
-	}()
+	defer closeStatusReceiver()
+
+	priorityGracePeriods, err := parsePriorityGracePeriods(drainPriorityGracePeriods)
+	if err != nil {
+		klog.Fatalf("Failed parsing %q flag: %v", "drain-priority-grace-periods", err)
+	}
 
-	rebooter, err := login1.New()
+	rebooter, err := newRebooter()
 	if err != nil {
-		klog.Fatalf("Failed establishing connection to logind dbus: %v", err)
+		klog.Fatalf("Failed creating rebooter: %v", err)
+	}
+
+	fallbackRebooter, err := newFallbackRebooter()
+	if err != nil {
+		klog.Fatalf("Failed creating fallback rebooter: %v", err)
 	}
 
 	config := &agent.Config{
-		NodeName:               *node,
-		PodDeletionGracePeriod: time.Duration(*reapTimeout) * time.Second,
-		Clientset:              clientset,
-		StatusReceiver:         updateEngineClient,
-		Rebooter:               rebooter,
-		ForceNodeDrain:         *forceNodeDrain,
+		NodeName:                  *node,
+		PodDeletionGracePeriod:    time.Duration(*reapTimeout) * time.Second,
+		EvictionTimeout:           *evictionTimeout,
+		PreDrainHookCommand:       *preDrainHookCommand,
+		PreDrainHooksDirectory:    *preDrainHooksDirectory,
+		PreDrainHookTimeout:       *preDrainHookTimeout,
+		PreDrainHookFailurePolicy: *preDrainHookFailurePolicy,
+		PostRebootReadyDuration:   *postRebootReadyDuration,
+		PostRebootProbes:          postRebootProbes,
+		PostRebootNetworkCheck:    *postRebootNetworkCheck,
+		PodNetworkProbeAddress:    kubernetesServiceAddress(),
+		RebootSentinelFile:        *rebootSentinelFile,
+		Clientset:                 clientset,
+		StatusReceiver:            statusReceiver,
+		Rebooter:                  rebooter,
+		ForceNodeDrain:            *forceNodeDrain,
+
+		MaxNodeUpdateFailureDuration: *maxNodeUpdateFailureDuration,
+		NodeUpdateBackoff:            &nodeUpdateBackoff,
+		MetricsRegisterer:            prometheus.DefaultRegisterer,
+		SecurityFeedURL:              *securityFeedURL,
+		PauseFile:                    *pauseFile,
+		DrainPriorityGracePeriods:    priorityGracePeriods,
+		DrainRetryBudget:             *drainRetryBudget,
+		DrainRetryInterval:           *drainRetryInterval,
+		DrainFailurePolicy:           *drainFailurePolicy,
+		VolumeDetachTimeout:          *volumeDetachTimeout,
+		VolumeDetachIgnoredDrivers:   volumeDetachIgnoredDrivers,
+		JobCompletionTimeout:         *jobCompletionTimeout,
+		RebootWindowStart:            *rebootWindowStart,
+		RebootWindowLength:           *rebootWindowLength,
+		MinRemainingRebootWindow:     *minRemainingRebootWindow,
+		FallbackRebooter:             fallbackRebooter,
+		FallbackRebootTimeout:        *fallbackRebootTimeout,
+		MarkBootSuccessfulCommand:    *markBootSuccessfulCommand,
+		KeepNodeCordonedAfterReboot:  !*uncordonAfterReboot,
+		RebootTaint:                  *rebootTaint,
+		SkipCordon:                   !*cordonNode,
+		ReconcileChannel:             *reconcileChannel,
+		InhibitUnapprovedReboots:     *inhibitUnapprovedReboots,
+		RebootWarningDelay:           *rebootWarningDelay,
+		RebootWallMessage:            *rebootWallMessage,
+		HeartbeatInterval:            *heartbeatInterval,
+		HeartbeatNamespace:           os.Getenv("POD_NAMESPACE"),
+		RebootRequiredCondition:      *rebootRequiredCondition,
 	}
 
 	agent, err := agent.New(config)
@@ -83,6 +430,18 @@ func main() {
 		klog.Fatalf("Failed to initialize %s: %v", os.Args[0], err)
 	}
 
+	if *metricsAddress != "" {
+		go serveMetrics(*metricsAddress)
+	}
+
+	if *healthProbeAddress != "" {
+		go serveHealthProbes(*healthProbeAddress, agent)
+	}
+
+	if *stateSocket != "" {
+		go serveState(*stateSocket, agent)
+	}
+
 	klog.Infof("%s running", os.Args[0])
 
 	// Run agent until the context is cancelled.
@@ -90,3 +449,249 @@ func main() {
 		klog.Fatalf("Error running agent: %v", err)
 	}
 }
+
+// kubernetesServiceAddress returns address of the Kubernetes API service as seen from the pod,
+// based on environment variables set by kubelet. Empty value is returned when not running in a pod.
+func kubernetesServiceAddress() string {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return ""
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// systemBusConnector returns connector to the host system bus selected using the --dbus-socket flag,
+// DBUS_SYSTEM_BUS_ADDRESS environment variable or out of well-known socket paths.
+func systemBusConnector() dbus.Connector {
+	return dbus.SystemBusConnector(*dbusSocket, dbus.SystemBusSocketPaths...)
+}
+
+// runStandalone runs agent coordinating reboots using a semaphore directory instead of Kubernetes.
+func runStandalone() {
+	machineName := *node
+	if machineName == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			klog.Fatalf("Failed getting hostname: %v", err)
+		}
+
+		machineName = hostname
+	}
+
+	semaphore, err := standalone.NewFileSemaphore(*standaloneSemaphoreDir, *standaloneMaxRebootingMachines)
+	if err != nil {
+		klog.Fatalf("Failed creating semaphore: %v", err)
+	}
+
+	statusReceiver, closeStatusReceiver, err := newStatusReceiver()
+	if err != nil {
+		klog.Fatalf("Failed creating update status receiver: %v", err)
+	}
+
+	defer closeStatusReceiver()
+
+	rebooter, err := newRebooter()
+	if err != nil {
+		klog.Fatalf("Failed creating rebooter: %v", err)
+	}
+
+	standaloneAgent, err := standalone.New(&standalone.Config{
+		MachineName:    machineName,
+		Semaphore:      semaphore,
+		StatusReceiver: statusReceiver,
+		Rebooter:       rebooter,
+	})
+	if err != nil {
+		klog.Fatalf("Failed to initialize standalone agent: %v", err)
+	}
+
+	klog.Infof("%s running in standalone mode as %q", os.Args[0], machineName)
+
+	if err := standaloneAgent.Run(context.Background()); err != nil {
+		klog.Fatalf("Error running standalone agent: %v", err)
+	}
+
+	// Wait for the machine to go down, so the agent is not restarted and does not release the semaphore early.
+	select {}
+}
+
+// newStatusReceiver creates a receiver of update statuses from configured update source. Returned function
+// must be called to release resources used by the receiver.
+func newStatusReceiver() (agent.StatusReceiver, func(), error) {
+	switch *updateSource {
+	case updateSourceUpdateEngine:
+		if *helperSocket != "" {
+			return helper.NewClient(*helperSocket), func() {}, nil
+		}
+
+		monitor, err := dbus.NewMonitor(prometheus.DefaultRegisterer, metricsNamespace)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating D-Bus monitor: %w", err)
+		}
+
+		updateEngineClient, err := updateengine.NewWithConfig(&updateengine.Config{
+			Connector:                systemBusConnector(),
+			OnlyTransitions:          *statusTransitionsOnly,
+			ProgressSamplingInterval: *progressSamplingInterval,
+			StatusPollInterval:       *statusPollInterval,
+			Monitor:                  monitor,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("establishing connection to update_engine dbus: %w", err)
+		}
+
+		return updateEngineClient, func() {
+			if err := updateEngineClient.Close(); err != nil {
+				klog.Warningf("Failed gracefully closing update_engine client: %v", err)
+			}
+		}, nil
+	case updateSourceSysupdate:
+		sysupdateClient, err := sysupdate.New(&sysupdate.Config{
+			Command:      strings.Fields(*sysupdateCommand),
+			PollInterval: *sysupdatePollInterval,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating systemd-sysupdate client: %w", err)
+		}
+
+		return sysupdateClient, func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported update source %q, expected either %q or %q",
+			*updateSource, updateSourceUpdateEngine, updateSourceSysupdate)
+	}
+}
+
+// schedulingRebooter requests reboots from logind, allowing to delay them while warning logged in users.
+type schedulingRebooter struct {
+	*logind.Rebooter
+	*logind.Scheduler
+}
+
+// newRebooter creates rebooter using configured reboot method. With logind method, reboots are requested
+// either through the helper, when configured, or directly from logind.
+func newRebooter() (agent.Rebooter, error) {
+	switch *rebootMethod {
+	case rebootMethodLogind:
+		if *helperSocket != "" {
+			return helper.NewClient(*helperSocket), nil
+		}
+
+		rebooter, err := logind.NewRebooter(systemBusConnector())
+		if err != nil {
+			return nil, fmt.Errorf("establishing connection to logind dbus: %w", err)
+		}
+
+		if *rebootWarningDelay == 0 {
+			return rebooter, nil
+		}
+
+		scheduler, err := logind.NewScheduler(systemBusConnector())
+		if err != nil {
+			return nil, fmt.Errorf("creating logind reboot scheduler: %w", err)
+		}
+
+		return &schedulingRebooter{Rebooter: rebooter, Scheduler: scheduler}, nil
+	case rebootMethodCommand:
+		return agent.NewCommandRebooter(*rebootCommand), nil
+	case rebootMethodNone:
+		return agent.NoopRebooter{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported reboot method %q, expected one of %q, %q or %q",
+			*rebootMethod, rebootMethodLogind, rebootMethodCommand, rebootMethodNone)
+	}
+}
+
+// newFallbackRebooter creates rebooter using API of configured cloud provider, if any.
+func newFallbackRebooter() (agent.FallbackRebooter, error) {
+	if *fallbackRebootProvider == "" {
+		return nil, nil //nolint:nilnil // Fallback rebooter is optional.
+	}
+
+	rebooter, err := cloudreboot.New(*fallbackRebootProvider, os.Getenv)
+	if err != nil {
+		return nil, fmt.Errorf("creating %s rebooter: %w", *fallbackRebootProvider, err)
+	}
+
+	return rebooter, nil
+}
+
+// parsePriorityGracePeriods parses list of priority=duration pairs into a map.
+func parsePriorityGracePeriods(pairs []string) (map[int32]time.Duration, error) {
+	gracePeriods := map[int32]time.Duration{}
+
+	for _, pair := range pairs {
+		if pair == "" {
+			continue
+		}
+
+		//nolint:gomnd // Priority and duration.
+		priorityAndDuration := strings.SplitN(pair, "=", 2)
+		if len(priorityAndDuration) != 2 {
+			return nil, fmt.Errorf("invalid pair %q, expected format 'priority=duration'", pair)
+		}
+
+		priority, err := strconv.ParseInt(priorityAndDuration[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("parsing priority %q: %w", priorityAndDuration[0], err)
+		}
+
+		gracePeriod, err := time.ParseDuration(priorityAndDuration[1])
+		if err != nil {
+			return nil, fmt.Errorf("parsing grace period for priority %d: %w", priority, err)
+		}
+
+		gracePeriods[int32(priority)] = gracePeriod
+	}
+
+	return gracePeriods, nil
+}
+
+// serveMetrics serves Prometheus metrics on a given address until the process exits.
+func serveMetrics(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: metricsReadHeaderTimeout,
+	}
+
+	klog.Infof("Serving metrics on %q", address)
+
+	if err := server.ListenAndServe(); err != nil {
+		klog.Fatalf("Failed serving metrics: %v", err)
+	}
+}
+
+// serveHealthProbes serves liveness and readiness probes of a given agent on a given address
+// until the process exits.
+func serveHealthProbes(address string, agentInstance agent.Klocksmith) {
+	server := healthz.NewServer(address, agentInstance.Healthz, agentInstance.Ready)
+
+	klog.Infof("Serving health probes on %q", address)
+
+	if err := server.ListenAndServe(); err != nil {
+		klog.Fatalf("Failed serving health probes: %v", err)
+	}
+}
+
+// serveState serves state of a given agent on a Unix socket with a given path until the process exits.
+func serveState(socket string, agentInstance agent.Klocksmith) {
+	listener, err := helper.Listen(socket, stateSocketMode)
+	if err != nil {
+		klog.Fatalf("Failed creating state socket %q: %v", socket, err)
+	}
+
+	server := &http.Server{
+		Handler:           agent.StateHandler(agentInstance),
+		ReadHeaderTimeout: metricsReadHeaderTimeout,
+	}
+
+	klog.Infof("Serving agent state on %q", socket)
+
+	if err := server.Serve(listener); err != nil {
+		klog.Fatalf("Failed serving agent state: %v", err)
+	}
+}
@@ -2,29 +2,138 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/coreos/pkg/flagutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/klog/v2"
 
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/configfile"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/healthz"
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logging"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/notify"
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/operator"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/tracing"
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/version"
 )
 
+const (
+	metricsReadHeaderTimeout = 10 * time.Second
+
+	// metricsNamespace is shared by metrics of the operator and its dependencies.
+	metricsNamespace = "flatcar_linux_update_operator"
+)
+
 type flagsSet struct {
-	beforeRebootAnnotations flagutil.StringSliceFlag
-	afterRebootAnnotations  flagutil.StringSliceFlag
-	kubeconfig              *string
-	rebootWindowStart       *string
-	rebootWindowLength      *string
-	printVersion            *bool
+	beforeRebootAnnotations       flagutil.StringSliceFlag
+	afterRebootAnnotations        flagutil.StringSliceFlag
+	blackoutWindows               flagutil.StringSliceFlag
+	maxRebootingNodesPerPoolPairs flagutil.StringSliceFlag
+	maxRebootingPerPairs          flagutil.StringSliceFlag
+	excludedTaints                flagutil.StringSliceFlag
+	configFile                    *string
+	kubeconfig                    *string
+	rebootWindowStart             *string
+	rebootWindowLength            *string
+	poolLabel                     *string
+	nodeSelector                  *string
+	lockName                      *string
+	lockType                      *string
+	configName                    *string
+	notificationWebhookURL        *string
+	notificationWebhookFormat     *string
+	metricsAddress                *string
+	healthProbeAddress            *string
+	forceRebootDeadline           *time.Duration
+	securityRebootDeadline        *time.Duration
+	beforeRebootTimeout           *time.Duration
+	stuckRebootThreshold          *time.Duration
+	staleAgentThreshold           *time.Duration
+	rebootApprovalTimeout         *time.Duration
+	rebootHistoryConfigMap        *string
+	rebootHistoryLimit            *int
+	rebootDecisionsConfigMap      *string
+	rebootDecisionsLimit          *int
+	statusConfigMap               *string
+	respectPodDisruptionBudgets   *bool
+	deferRebootsDuringAutoscaling *bool
+	clusterAutoscalerStatus       *string
+	fastPathCordonedNodes         *bool
+	kuredCompatibility            *bool
+	kubernetesAPIProtobuf         *bool
+	otlpTracesEndpoint            *string
+	logFormat                     *string
+	nodeUpdateBackoff             k8sutil.Backoff
+	printVersion                  *bool
+}
+
+// configFileOptions is a schema of the configuration file. Fields are named after flags, except --config,
+// --version and --node-label-selector alias, which cannot be set in the file. Logging verbosity is set using v key.
+type configFileOptions struct {
+	Kubeconfig                       *string               `yaml:"kubeconfig"`
+	RebootWindowStart                *string               `yaml:"reboot-window-start"`
+	RebootWindowLength               *string               `yaml:"reboot-window-length"`
+	PoolLabel                        *string               `yaml:"pool-label"`
+	NodeSelector                     *string               `yaml:"node-selector"`
+	LockName                         *string               `yaml:"lock-name"`
+	LockType                         *string               `yaml:"lock-type"`
+	ConfigName                       *string               `yaml:"config-name"`
+	NotificationWebhookURL           *string               `yaml:"notification-webhook-url"`
+	NotificationWebhookFormat        *string               `yaml:"notification-webhook-format"`
+	ForceRebootDeadline              *time.Duration        `yaml:"force-reboot-deadline"`
+	SecurityRebootDeadline           *time.Duration        `yaml:"security-reboot-deadline"`
+	BeforeRebootTimeout              *time.Duration        `yaml:"before-reboot-timeout"`
+	StuckRebootThreshold             *time.Duration        `yaml:"stuck-reboot-threshold"`
+	StaleAgentThreshold              *time.Duration        `yaml:"stale-agent-threshold"`
+	RebootApprovalTimeout            *time.Duration        `yaml:"reboot-approval-timeout"`
+	RebootHistoryConfigMap           *string               `yaml:"reboot-history-configmap"`
+	RebootHistoryLimit               *int                  `yaml:"reboot-history-limit"`
+	RebootDecisionsConfigMap         *string               `yaml:"reboot-decisions-configmap"`
+	RebootDecisionsLimit             *int                  `yaml:"reboot-decisions-limit"`
+	StatusConfigMap                  *string               `yaml:"status-configmap"`
+	MetricsAddress                   *string               `yaml:"metrics-address"`
+	HealthProbeAddress               *string               `yaml:"health-probe-address"`
+	DeferRebootsDuringAutoscaling    *bool                 `yaml:"defer-reboots-during-autoscaling"`
+	ClusterAutoscalerStatusConfigMap *string               `yaml:"cluster-autoscaler-status-configmap"`
+	FastPathCordonedNodes            *bool                 `yaml:"fast-path-cordoned-nodes"`
+	KuredCompatibility               *bool                 `yaml:"kured-compatibility"`
+	RespectPodDisruptionBudgets      *bool                 `yaml:"respect-pod-disruption-budgets"`
+	KubernetesAPIProtobuf            *bool                 `yaml:"kubernetes-api-protobuf"`
+	OTLPTracesEndpoint               *string               `yaml:"otlp-traces-endpoint"`
+	LogFormat                        *string               `yaml:"log-format"`
+	BeforeRebootAnnotations          configfile.StringList `yaml:"before-reboot-annotations"`
+	AfterRebootAnnotations           configfile.StringList `yaml:"after-reboot-annotations"`
+	BlackoutWindows                  configfile.StringList `yaml:"blackout-windows"`
+	MaxRebootingNodesPerPool         configfile.StringList `yaml:"max-rebooting-nodes-per-pool"`
+	MaxRebootingPer                  configfile.StringList `yaml:"max-rebooting-per"`
+	ExcludedTaints                   configfile.StringList `yaml:"excluded-taints"`
+	NodeUpdateRetryInitialDelay      *time.Duration        `yaml:"node-update-retry-initial-delay"`
+	NodeUpdateRetryFactor            *float64              `yaml:"node-update-retry-factor"`
+	NodeUpdateRetryJitter            *float64              `yaml:"node-update-retry-jitter"`
+	NodeUpdateMaxRetries             *int                  `yaml:"node-update-max-retries"`
+	Verbosity                        *int                  `yaml:"v"`
 }
 
 func handleFlags() *flagsSet {
 	flags := &flagsSet{
+		nodeUpdateBackoff: k8sutil.DefaultBackoff(),
+
+		configFile: flag.String("config", "",
+			"Path to a YAML configuration file with flag values keyed by flag names. Flags and environment "+
+				"variables take precedence over the configuration file. See doc/configuration-file.md"),
+
 		kubeconfig: flag.String("kubeconfig", "",
 			"Path to a kubeconfig file. Default to the in-cluster config if not provided."),
 
@@ -33,9 +142,127 @@ func handleFlags() *flagsSet {
 				"E.g. 'Mon 14:00', '11:00'"),
 
 		rebootWindowLength: flag.String("reboot-window-length", "", "Length of the reboot window. E.g. '1h30m'"),
-		printVersion:       flag.Bool("version", false, "Print version and exit"),
+
+		poolLabel: flag.String("pool-label", "",
+			"Node label grouping nodes into pools. When set, maximum number of rebooting nodes is enforced "+
+				"separately for each pool. E.g. 'pool'"),
+
+		nodeSelector: flag.String("node-selector", "",
+			"Label selector limiting nodes managed by the operator. Allows running multiple operators, each "+
+				"managing a disjoint set of nodes. E.g. 'shard=a'"),
+
+		lockName: flag.String("lock-name", "",
+			"Name of the resource used for leader election. Operators managing disjoint sets of nodes must use "+
+				"distinct names. Defaults to 'flatcar-linux-update-operator-lock'"),
+
+		lockType: flag.String("lock-type", operator.LockTypeConfigMapsLeases,
+			fmt.Sprintf("Type of the lock used for leader election. Either %q or %q. "+
+				"See doc/leader-election.md for migration instructions",
+				operator.LockTypeConfigMapsLeases, operator.LockTypeLeases)),
+
+		configName: flag.String("config-name", "",
+			"Name of the FluoConfig object in operator namespace, which allows changing reboot window, maximum "+
+				"rebooting nodes, before and after reboot annotations and pausing the operator without a restart. "+
+				"Values set in FluoConfig take precedence over flags. Disabled by default"),
+
+		notificationWebhookURL: flag.String("notification-webhook-url", "",
+			"URL of the webhook to which notifications are posted when node is scheduled for rebooting, "+
+				"when reboot process is completed and when reboot process runs into problems. Disabled by default"),
+
+		notificationWebhookFormat: flag.String("notification-webhook-format", notify.FormatJSON,
+			fmt.Sprintf("Format of the notifications posted to the webhook. Either %q for generic JSON object or %q "+
+				"for Slack-compatible message", notify.FormatJSON, notify.FormatSlack)),
+
+		forceRebootDeadline: flag.Duration("force-reboot-deadline", 0,
+			"Duration after which node requesting a reboot is scheduled for rebooting even outside the reboot "+
+				"window. E.g. '336h'. Disabled by default"),
+
+		securityRebootDeadline: flag.Duration("security-reboot-deadline", 0,
+			"Same as --force-reboot-deadline, but applies only to nodes requesting a reboot to apply security "+
+				"update, as reported by update-agent configured with security feed. E.g. '48h'. Disabled by default"),
+
+		beforeRebootTimeout: flag.Duration("before-reboot-timeout", 0,
+			"Duration after which node waiting for before-reboot annotations is unscheduled from rebooting. "+
+				"Node is scheduled for rebooting again once the same duration passes. E.g. '1h'. Disabled by default"),
+
+		stuckRebootThreshold: flag.Duration("stuck-reboot-threshold", 30*time.Minute, //nolint:gomnd // Default value.
+			"Duration after which node which is still rebooting is reported as stuck using a metric and a Warning "+
+				"event. Set to 0 to disable"),
+
+		staleAgentThreshold: flag.Duration("stale-agent-threshold", 0,
+			"Duration after which node, which update-agent did not renew its heartbeat lease, is reported as having "+
+				"stale agent using a metric and a Warning event. Requires update-agent --heartbeat-interval. "+
+				"E.g. '10m'. Disabled by default"),
+
+		rebootApprovalTimeout: flag.Duration("reboot-approval-timeout", 0,
+			"Duration after which reboot approval is revoked if agent does not start rebooting the node, e.g. "+
+				"because it is not running. Node is scheduled for rebooting again once the same duration passes. "+
+				"E.g. '30m'. Disabled by default"),
+
+		rebootHistoryConfigMap: flag.String("reboot-history-configmap", operator.DefaultRebootHistoryConfigMap,
+			"Name of the ConfigMap in operator namespace where history of completed reboots is stored. "+
+				"Set to empty value to disable recording reboot history"),
+
+		rebootHistoryLimit: flag.Int("reboot-history-limit", 0,
+			"Number of most recent reboots kept in history for each node. Defaults to 10"),
+
+		rebootDecisionsConfigMap: flag.String("reboot-decisions-configmap", "",
+			"Name of the ConfigMap in operator namespace where decisions about nodes are recorded, e.g. why node "+
+				"has or has not been scheduled for rebooting. Disabled by default"),
+
+		rebootDecisionsLimit: flag.Int("reboot-decisions-limit", 0,
+			"Number of most recent decisions kept for each node. Defaults to 20"),
+
+		statusConfigMap: flag.String("status-configmap", operator.DefaultStatusConfigMap,
+			"Name of the ConfigMap in operator namespace where operator status is published after each "+
+				"reconciliation cycle. Set to empty value to disable publishing status"),
+
+		metricsAddress: flag.String("metrics-address", ":8080",
+			"Address on which Prometheus metrics are served on /metrics path. Set to empty value to disable"),
+
+		healthProbeAddress: flag.String("health-probe-address", ":8081",
+			"Address on which liveness and readiness probes are served on /healthz and /readyz paths. "+
+				"Set to empty value to disable"),
+
+		deferRebootsDuringAutoscaling: flag.Bool("defer-reboots-during-autoscaling", false,
+			"Do not schedule nodes for rebooting while cluster-autoscaler is actively scaling the cluster "+
+				"or while nodes are being registered or removed"),
+
+		clusterAutoscalerStatus: flag.String("cluster-autoscaler-status-configmap",
+			operator.DefaultClusterAutoscalerStatusConfigMap,
+			"Namespace and name of the ConfigMap where cluster-autoscaler publishes its status, in namespace/name "+
+				"format. Used when --defer-reboots-during-autoscaling is enabled"),
+
+		fastPathCordonedNodes: flag.Bool("fast-path-cordoned-nodes", false,
+			"Schedule nodes which are cordoned and run no pods other than DaemonSet and mirror pods for rebooting "+
+				"immediately, regardless of reboot window and without counting against maximum number of rebooting "+
+				"nodes"),
+
+		kuredCompatibility: flag.Bool("kured-compatibility", false,
+			"Mirror reboot state of nodes to annotations and the kured_reboot_required metric used by kured, so "+
+				"dashboards and alerts built for kured work with nodes managed by FLUO"),
+
+		respectPodDisruptionBudgets: flag.Bool("respect-pod-disruption-budgets", false,
+			"Do not schedule nodes for rebooting if draining them would violate any PodDisruptionBudget. "+
+				"Requires permissions to list pods and PodDisruptionBudgets in all namespaces"),
+
+		kubernetesAPIProtobuf: flag.Bool("kubernetes-api-protobuf", true,
+			"Use protobuf instead of JSON for Kubernetes API requests for built-in resources. Disable if a proxy "+
+				"in front of the API server does not support protobuf"),
+
+		otlpTracesEndpoint: flag.String("otlp-traces-endpoint", "",
+			"URL of OTLP/HTTP endpoint, e.g. 'http://otel-collector:4318', to which traces of reconciliation "+
+				"steps and Kubernetes API requests are exported. Disabled by default"),
+
+		logFormat: flag.String("log-format", logging.FormatText,
+			fmt.Sprintf("Format of logs, either %q or %q", logging.FormatText, logging.FormatJSON)),
+
+		printVersion: flag.Bool("version", false, "Print version and exit"),
 	}
 
+	flag.StringVar(flags.nodeSelector, "node-label-selector", "",
+		"Alias for --node-selector. E.g. 'fluo.managed=true'")
+
 	flag.Var(&flags.beforeRebootAnnotations, "before-reboot-annotations",
 		"List of comma-separated Kubernetes node annotations that must be set to 'true' before a reboot is allowed")
 
@@ -43,6 +270,36 @@ func handleFlags() *flagsSet {
 		"List of comma-separated Kubernetes node annotations that must be set to 'true' before a node is marked "+
 			"schedulable and the operator lock is released")
 
+	flag.Var(&flags.blackoutWindows, "blackout-windows",
+		"List of comma-separated time ranges during which nodes are never scheduled for rebooting. "+
+			"Range is specified as start and end dates or RFC 3339 timestamps separated by a slash. "+
+			"E.g. '2023-11-20/2023-11-27,2023-12-29T18:00:00Z/2024-01-02T08:00:00Z'")
+
+	flag.Var(&flags.maxRebootingNodesPerPoolPairs, "max-rebooting-nodes-per-pool",
+		"List of comma-separated pool=count pairs limiting number of nodes rebooting in parallel in a given pool. "+
+			"Requires --pool-label to be set. E.g. 'ingress=1,batch=5'")
+
+	flag.Var(&flags.maxRebootingPerPairs, "max-rebooting-per",
+		"List of comma-separated topologyKey=count pairs limiting number of nodes rebooting in parallel within "+
+			"each value of a given node label. E.g. 'topology.kubernetes.io/zone=1'")
+
+	flag.Var(&flags.excludedTaints, "excluded-taints",
+		"List of comma-separated taint keys. Nodes with any of these taints are never scheduled for rebooting. "+
+			"E.g. 'node.kubernetes.io/out-of-service'")
+
+	flag.DurationVar(&flags.nodeUpdateBackoff.InitialDelay, "node-update-retry-initial-delay",
+		flags.nodeUpdateBackoff.InitialDelay,
+		"Delay before retrying update of Node object, which failed due to a conflict")
+
+	flag.Float64Var(&flags.nodeUpdateBackoff.Factor, "node-update-retry-factor", flags.nodeUpdateBackoff.Factor,
+		"Factor by which delay between retries of Node object updates grows. Must be at least 1")
+
+	flag.Float64Var(&flags.nodeUpdateBackoff.Jitter, "node-update-retry-jitter", flags.nodeUpdateBackoff.Jitter,
+		"Maximum random extension of delay between retries of Node object updates, as a fraction of the delay")
+
+	flag.IntVar(&flags.nodeUpdateBackoff.MaxRetries, "node-update-max-retries", flags.nodeUpdateBackoff.MaxRetries,
+		"Number of retries of Node object update failing due to conflicts before giving up")
+
 	klog.InitFlags(nil)
 
 	if err := flag.Set("logtostderr", "true"); err != nil {
@@ -55,6 +312,16 @@ func handleFlags() *flagsSet {
 		klog.Fatalf("Failed to parse environment variables: %v", err)
 	}
 
+	if *flags.configFile != "" {
+		if err := configfile.SetFlags(flag.CommandLine, *flags.configFile, &configFileOptions{}); err != nil {
+			klog.Fatalf("Failed to parse configuration file: %v", err)
+		}
+	}
+
+	if err := logging.Configure(*flags.logFormat, os.Stderr); err != nil {
+		klog.Fatalf("Failed configuring logging: %v", err)
+	}
+
 	// Respect KUBECONFIG without the prefix as well.
 	if *flags.kubeconfig == "" {
 		*flags.kubeconfig = os.Getenv("KUBECONFIG")
@@ -71,12 +338,46 @@ func main() {
 		os.Exit(0)
 	}
 
+	maxRebootingNodesPerPool, err := parseMaxRebootingNodes(flags.maxRebootingNodesPerPoolPairs, "pool")
+	if err != nil {
+		klog.Fatalf("Failed parsing %q flag: %v", "max-rebooting-nodes-per-pool", err)
+	}
+
+	maxRebootingNodesPerTopology, err := parseMaxRebootingNodes(flags.maxRebootingPerPairs, "topologyKey")
+	if err != nil {
+		klog.Fatalf("Failed parsing %q flag: %v", "max-rebooting-per", err)
+	}
+
+	notifier, err := newNotifier(flags)
+	if err != nil {
+		klog.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	clientMetrics, err := k8sutil.NewClientMetrics(prometheus.DefaultRegisterer, metricsNamespace)
+	if err != nil {
+		klog.Fatalf("Failed to create Kubernetes client metrics: %v", err)
+	}
+
+	tracerProvider, shutdownTracing := newTracerProvider(*flags.otlpTracesEndpoint)
+	defer shutdownTracing()
+
+	clientOptions := k8sutil.ClientOptions{
+		Metrics:         clientMetrics,
+		TracerProvider:  tracerProvider,
+		DisableProtobuf: !*flags.kubernetesAPIProtobuf,
+	}
+
 	// Create Kubernetes client (clientset).
-	client, err := k8sutil.GetClient(*flags.kubeconfig)
+	client, err := k8sutil.GetClientWithOptions(*flags.kubeconfig, clientOptions)
 	if err != nil {
 		klog.Fatalf("Failed to create Kubernetes client: %v", err)
 	}
 
+	dynamicClient, err := k8sutil.GetDynamicClientWithOptions(*flags.kubeconfig, clientOptions)
+	if err != nil {
+		klog.Fatalf("Failed to create dynamic Kubernetes client: %v", err)
+	}
+
 	namespace := os.Getenv("POD_NAMESPACE")
 	if namespace == "" {
 		klog.Fatalf("Unable to determine operator namespace: please ensure POD_NAMESPACE environment variable is set")
@@ -93,25 +394,152 @@ func main() {
 
 	// Construct update-operator.
 	operatorInstance, err := operator.New(operator.Config{
-		Client:                  client,
-		BeforeRebootAnnotations: flags.beforeRebootAnnotations,
-		AfterRebootAnnotations:  flags.afterRebootAnnotations,
-		RebootWindowStart:       *flags.rebootWindowStart,
-		RebootWindowLength:      *flags.rebootWindowLength,
-		Namespace:               namespace,
-		LockID:                  hostname,
+		Client:                           client,
+		DynamicClient:                    dynamicClient,
+		ConfigName:                       *flags.configName,
+		Notifier:                         notifier,
+		RespectPodDisruptionBudgets:      *flags.respectPodDisruptionBudgets,
+		DeferRebootsDuringAutoscaling:    *flags.deferRebootsDuringAutoscaling,
+		ClusterAutoscalerStatusConfigMap: *flags.clusterAutoscalerStatus,
+		FastPathCordonedNodes:            *flags.fastPathCordonedNodes,
+		KuredCompatibility:               *flags.kuredCompatibility,
+		BeforeRebootAnnotations:          flags.beforeRebootAnnotations,
+		AfterRebootAnnotations:           flags.afterRebootAnnotations,
+		RebootWindowStart:                *flags.rebootWindowStart,
+		RebootWindowLength:               *flags.rebootWindowLength,
+		Namespace:                        namespace,
+		LockID:                           hostname,
+		LockName:                         *flags.lockName,
+		LockType:                         *flags.lockType,
+		NodeSelector:                     *flags.nodeSelector,
+		ExcludedTaints:                   flags.excludedTaints,
+		PoolLabel:                        *flags.poolLabel,
+		MaxRebootingNodesPerPool:         maxRebootingNodesPerPool,
+		MaxRebootingNodesPerTopology:     maxRebootingNodesPerTopology,
+		ForceRebootDeadline:              *flags.forceRebootDeadline,
+		SecurityRebootDeadline:           *flags.securityRebootDeadline,
+		BeforeRebootTimeout:              *flags.beforeRebootTimeout,
+		StuckRebootThreshold:             *flags.stuckRebootThreshold,
+		StaleAgentThreshold:              *flags.staleAgentThreshold,
+		RebootApprovalTimeout:            *flags.rebootApprovalTimeout,
+		MetricsRegisterer:                prometheus.DefaultRegisterer,
+		TracerProvider:                   tracerProvider,
+		RebootHistoryConfigMap:           *flags.rebootHistoryConfigMap,
+		RebootHistoryLimit:               *flags.rebootHistoryLimit,
+		RebootDecisionsConfigMap:         *flags.rebootDecisionsConfigMap,
+		RebootDecisionsLimit:             *flags.rebootDecisionsLimit,
+		StatusConfigMap:                  *flags.statusConfigMap,
+		NodeUpdateBackoff:                &flags.nodeUpdateBackoff,
 	})
 	if err != nil {
 		klog.Fatalf("Failed to initialize %s: %v", os.Args[0], err)
 	}
 
+	if *flags.metricsAddress != "" {
+		go serveMetrics(*flags.metricsAddress)
+	}
+
+	if *flags.healthProbeAddress != "" {
+		go serveHealthProbes(*flags.healthProbeAddress, operatorInstance)
+	}
+
 	klog.Infof("%s running", os.Args[0])
 
-	// Run operator until the stop channel is closed.
-	stop := make(chan struct{})
-	defer close(stop)
+	// Run operator until termination is requested, so the leader election lock gets released during
+	// rollouts and other instance can take over without waiting for the lease to expire.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
 
-	if err := operatorInstance.Run(stop); err != nil {
+	if err := operatorInstance.Run(ctx.Done()); err != nil {
 		klog.Fatalf("Error while running %s: %v", os.Args[0], err)
 	}
 }
+
+// newTracerProvider returns tracer provider exporting traces to given OTLP endpoint or nil, if endpoint is
+// empty. Returned function flushes pending traces.
+func newTracerProvider(endpoint string) (trace.TracerProvider, func()) {
+	if endpoint == "" {
+		return nil, func() {}
+	}
+
+	ctx := context.Background()
+
+	tracerProvider, err := tracing.NewProvider(ctx, endpoint, "update-operator", version.Version)
+	if err != nil {
+		klog.Fatalf("Failed to create tracer provider: %v", err)
+	}
+
+	return tracerProvider, func() {
+		if err := tracerProvider.Shutdown(ctx); err != nil {
+			klog.Errorf("Failed to flush traces: %v", err)
+		}
+	}
+}
+
+// serveMetrics serves Prometheus metrics on a given address until the process exits.
+func serveMetrics(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: metricsReadHeaderTimeout,
+	}
+
+	klog.Infof("Serving metrics on %q", address)
+
+	if err := server.ListenAndServe(); err != nil {
+		klog.Fatalf("Failed serving metrics: %v", err)
+	}
+}
+
+// serveHealthProbes serves liveness and readiness probes of a given operator on a given address
+// until the process exits.
+func serveHealthProbes(address string, operatorInstance *operator.Kontroller) {
+	server := healthz.NewServer(address, operatorInstance.Healthz, operatorInstance.Ready)
+
+	klog.Infof("Serving health probes on %q", address)
+
+	if err := server.ListenAndServe(); err != nil {
+		klog.Fatalf("Failed serving health probes: %v", err)
+	}
+}
+
+// newNotifier creates notifier based on given flags. If notifications are not configured, nil is returned.
+func newNotifier(flags *flagsSet) (notify.Notifier, error) {
+	if *flags.notificationWebhookURL == "" {
+		return nil, nil //nolint:nilnil // Notifications are optional.
+	}
+
+	return notify.NewWebhook(notify.WebhookConfig{
+		URL:    *flags.notificationWebhookURL,
+		Format: *flags.notificationWebhookFormat,
+	})
+}
+
+// parseMaxRebootingNodes parses list of key=count pairs into a map. Given key name is used in error messages.
+func parseMaxRebootingNodes(pairs []string, keyName string) (map[string]int, error) {
+	maxRebootingNodes := map[string]int{}
+
+	for _, pair := range pairs {
+		if pair == "" {
+			continue
+		}
+
+		//nolint:gomnd // Key and count.
+		keyAndCount := strings.SplitN(pair, "=", 2)
+		if len(keyAndCount) != 2 || keyAndCount[0] == "" {
+			return nil, fmt.Errorf("invalid pair %q, expected format '%s=count'", pair, keyName)
+		}
+
+		count, err := strconv.Atoi(keyAndCount[1])
+		if err != nil {
+			return nil, fmt.Errorf("parsing count for %s %q: %w", keyName, keyAndCount[0], err)
+		}
+
+		maxRebootingNodes[keyAndCount[0]] = count
+	}
+
+	return maxRebootingNodes, nil
+}
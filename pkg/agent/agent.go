@@ -5,14 +5,17 @@
 package agent
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
-	"strings"
+	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,12 +24,17 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
 	watchtools "k8s.io/client-go/tools/watch"
 	"k8s.io/klog/v2"
 	"k8s.io/kubectl/pkg/drain"
 
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/flatcar"
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logging"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/operator"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/rebootstate"
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/updateengine"
 )
 
@@ -41,44 +49,304 @@ type Config struct {
 	HostFilesPrefix         string
 	PollInterval            time.Duration
 	MaxOperatorResponseTime time.Duration
+	// Time after which pods which could not be evicted, e.g. because of PodDisruptionBudgets,
+	// are deleted instead. Defaults to PodDeletionGracePeriod.
+	EvictionTimeout time.Duration
+	// Command executed using /bin/sh before draining the node.
+	PreDrainHookCommand string
+	// Directory with executables, which are executed in lexical order before draining the node,
+	// after PreDrainHookCommand.
+	PreDrainHooksDirectory string
+	// Time after which each pre-drain hook is killed and considered failed. Defaults to 5 minutes.
+	PreDrainHookTimeout time.Duration
+	// Either HookFailurePolicyFail or HookFailurePolicyIgnore. Defaults to HookFailurePolicyFail,
+	// which aborts the reboot when any pre-drain hook fails.
+	PreDrainHookFailurePolicy string
+	// Duration for which kubelet must report the node as Ready after the reboot, before the node
+	// is made schedulable again and the reboot is reported as finished. Zero disables the check.
+	PostRebootReadyDuration time.Duration
+	// URLs of services which must be healthy after the reboot, before the node is made schedulable
+	// again and the reboot is reported as finished. Supported schemes are http, https and tcp.
+	PostRebootProbes []string
+	// Makes the agent wait after the reboot, before the node is made schedulable again and the reboot is
+	// reported as finished, until kubelet reports the node as Ready, the node does not report its network
+	// as unavailable and PodNetworkProbeAddress can be connected to.
+	PostRebootNetworkCheck bool
+	// Address in "host:port" format, which is connected to from the agent's pod when PostRebootNetworkCheck
+	// is enabled to verify that pod network is functional, e.g. address of the Kubernetes API service.
+	// Empty value disables the connection check.
+	PodNetworkProbeAddress string
+	// Path to a file, which presence indicates that node needs a reboot, regardless of
+	// update_engine status. Empty value disables the check.
+	RebootSentinelFile string
+	// Time after which agent is reported as not healthy when updating Node object keeps failing.
+	// Defaults to 5 minutes.
+	MaxNodeUpdateFailureDuration time.Duration
+	// Configures how updates of Node object failing due to conflicts are retried.
+	// Defaults to k8sutil.DefaultBackoff().
+	NodeUpdateBackoff *k8sutil.Backoff
+	// Registerer used to register agent metrics. If nil, metrics are not exposed.
+	MetricsRegisterer prometheus.Registerer
+	// URL of a feed listing versions which carry security fixes. When set, agent annotates the node
+	// whether the pending reboot applies a security update. See securityUpdate for the feed format.
+	SecurityFeedURL string
+	// Path to a file on the host, relative to HostFilesPrefix, which presence pauses reboots of the node,
+	// same as setting reboot-paused annotation to "true". Empty value disables the check.
+	PauseFile string
+	// Termination grace periods for pods with priority equal to or higher than given threshold.
+	// Pods are removed in tiers, starting with the lowest priority. Pods with priority below all
+	// thresholds use termination grace period from their spec.
+	DrainPriorityGracePeriods map[int32]time.Duration
+	// Time during which draining the node is retried with exponential backoff when not all pods
+	// could be removed. Zero disables retries.
+	DrainRetryBudget time.Duration
+	// Initial interval between drain attempts, doubled after each failed attempt. Defaults to 10 seconds.
+	DrainRetryInterval time.Duration
+	// Either DrainFailurePolicyProceed or DrainFailurePolicyAbort. Defaults to DrainFailurePolicyProceed,
+	// which reboots the node even when not all pods could be removed.
+	DrainFailurePolicy string
+	// Maximum time to wait after draining for VolumeAttachments of the node to be removed before rebooting.
+	// Zero disables waiting.
+	VolumeDetachTimeout time.Duration
+	// Names of CSI drivers, which volumes are not waited for to be detached, e.g. drivers of local volumes.
+	VolumeDetachIgnoredDrivers []string
+	// Maximum time to wait before draining for pods of Jobs annotated with wait-for-completion annotation
+	// to finish. Zero disables waiting.
+	JobCompletionTimeout time.Duration
+	// Reboot window in the same format as used by the operator. When configured, agent does not start
+	// draining the node outside of the window, even if the reboot has been approved by the operator.
+	RebootWindowStart  string
+	RebootWindowLength string
+	// Minimum part of the reboot window, which must remain to start draining the node. Otherwise,
+	// reboot is deferred to the next window.
+	MinRemainingRebootWindow time.Duration
+	// Optional rebooter used as a second attempt to reboot the machine, e.g. using cloud provider API,
+	// when requesting the reboot using Rebooter fails or the host does not reboot within FallbackRebootTimeout.
+	FallbackRebooter FallbackRebooter
+	// Time after which FallbackRebooter is used. Defaults to 10 minutes.
+	FallbackRebootTimeout time.Duration
+	// Optional command executed using /bin/sh after the reboot, once the node passes post-reboot
+	// verification, to mark the booted partition as successfully booted. Booted USR partition is
+	// passed in FLUO_USR_PARTITION environment variable.
+	MarkBootSuccessfulCommand string
+	// Keeps the node unschedulable after the reboot, so workloads are re-admitted manually by uncordoning
+	// the node. May be overridden per node using the uncordon-after-reboot annotation.
+	KeepNodeCordonedAfterReboot bool
+	// Changes the update channel used by the update source to the one set in the channel node annotation.
+	// Requires StatusReceiver to implement ChannelManager.
+	ReconcileChannel bool
+	// Taint in "key[=value]:effect" format applied to the node while it is drained and rebooted, in addition
+	// to making it unschedulable. Empty value disables tainting.
+	RebootTaint string
+	// Do not make the node unschedulable while it is drained and rebooted, relying only on RebootTaint, so pods
+	// tolerating the taint, e.g. system pods, can still be scheduled on the node. Requires RebootTaint to be set.
+	SkipCordon bool
+	// Take the shutdown inhibitor lock while the update is waiting for the reboot approval, so the node is not
+	// rebooted by anything else in the meantime. Requires Rebooter to implement Inhibitor.
+	InhibitUnapprovedReboots bool
+	// Delay the reboot by given period of time, warning users logged in on the host using wall messages.
+	// Requires Rebooter to implement RebootScheduler. Disabled by default.
+	RebootWarningDelay time.Duration
+	// Wall message sent to users logged in on the host before the delayed reboot. Defaults to a generic
+	// message about rebooting to apply an OS update.
+	RebootWallMessage string
+	// Interval in which the agent renews heartbeat lease of the node, so the operator can detect nodes with
+	// agent which is not running. Zero disables heartbeat.
+	HeartbeatInterval time.Duration
+	// Namespace of heartbeat lease. Required when HeartbeatInterval is set.
+	HeartbeatNamespace string
+	// Maintain RebootRequired node condition in addition to annotations. Requires permissions to patch
+	// node status.
+	RebootRequiredCondition bool
 }
 
-// StatusReceiver describe dependency of object providing status updates from update_engine.
+// StatusReceiver describe dependency of object providing status updates from update source, like update_engine
+// or systemd-sysupdate.
 type StatusReceiver interface {
 	ReceiveStatuses(rcvr chan<- updateengine.Status, stop <-chan struct{})
 }
 
-// Rebooter describes dependency of object providing capability of rebooting host machine.
+// HealthChecker may be optionally implemented by StatusReceiver to report when it is no longer
+// able to provide status updates, e.g. because of broken D-Bus connection.
+type HealthChecker interface {
+	Healthz() error
+}
+
+// ReconnectCounter may be optionally implemented by StatusReceiver to report how many times it had to
+// re-establish connection to the update source, e.g. after dbus-daemon restart.
+type ReconnectCounter interface {
+	Reconnects() uint64
+}
+
+// UpdateChecker may be optionally implemented by StatusReceiver to allow triggering an update check
+// on demand using the node annotation.
+type UpdateChecker interface {
+	AttemptUpdate() error
+}
+
+// LastAttemptErrorGetter may be optionally implemented by StatusReceiver to report error code of
+// the failed update attempt.
+type LastAttemptErrorGetter interface {
+	GetLastAttemptError() (int32, error)
+}
+
+// ChannelManager may be optionally implemented by StatusReceiver to allow changing the update channel
+// using the node annotation.
+type ChannelManager interface {
+	GetChannel() (string, error)
+	SetChannel(channel string) error
+}
+
+// StatusResetter may be optionally implemented by StatusReceiver to allow discarding the update pending
+// a reboot using the node annotation.
+type StatusResetter interface {
+	ResetStatus() error
+}
+
+// Rollbacker may be optionally implemented by StatusReceiver to allow rolling back to the previously
+// booted partition using the node annotation.
+type Rollbacker interface {
+	AttemptRollback() error
+}
+
+// Rebooter describes dependency of object providing capability of rebooting host machine. Returned error
+// means the reboot has not been requested, so the host will not reboot by itself.
 type Rebooter interface {
-	Reboot(bool)
+	Reboot(bool) error
+}
+
+// FallbackRebooter describes dependency of object rebooting the machine by other means than Rebooter,
+// e.g. using cloud provider API, when the host does not reboot by itself.
+type FallbackRebooter interface {
+	Reboot(ctx context.Context) error
+}
+
+// Inhibitor may be optionally implemented by Rebooter to allow taking inhibitor locks, so the host
+// is not rebooted while the reboot waits for an approval.
+type Inhibitor interface {
+	Inhibit(what, who, why, mode string) (*os.File, error)
+}
+
+// RebootScheduler may be optionally implemented by Rebooter to allow delaying the reboot, while users
+// logged in on the host are warned using wall messages. Scheduled reboot is cancelled when the reboot
+// approval is revoked during the delay.
+type RebootScheduler interface {
+	ScheduleReboot(message string, delay time.Duration) error
+	CancelScheduledReboot() (bool, error)
 }
 
 // Klocksmith represents capabilities of agent.
 type Klocksmith interface {
 	Run(ctx context.Context) error
+	// Ready returns an error until agent sets up node annotations and receives
+	// initial status from update_engine.
+	Ready() error
+	// Healthz returns an error when agent is not able to operate anymore and should be restarted.
+	Healthz() error
+	// State returns current state of the agent.
+	State() State
 }
 
 // Klocksmith implements agent part of FLUO.
 type klocksmith struct {
 	nodeName                string
+	logger                  klog.Logger
 	nc                      corev1client.NodeInterface
 	clientset               kubernetes.Interface
 	ue                      StatusReceiver
 	lc                      Rebooter
 	reapTimeout             time.Duration
+	evictionTimeout         time.Duration
 	forceNodeDrain          bool
 	hostFilesPrefix         string
 	pollInterval            time.Duration
 	maxOperatorResponseTime time.Duration
+
+	preDrainHookCommand       string
+	preDrainHooksDirectory    string
+	preDrainHookTimeout       time.Duration
+	preDrainHookFailurePolicy string
+
+	postRebootReadyDuration time.Duration
+	postRebootProbes        []*url.URL
+	postRebootNetworkCheck  bool
+	podNetworkProbeAddress  string
+
+	rebootSentinelFile string
+
+	maxNodeUpdateFailureDuration time.Duration
+	nodeUpdateBackoff            k8sutil.Backoff
+
+	metrics *metrics
+
+	securityFeedURL *url.URL
+
+	pauseFile string
+
+	drainPriorityGracePeriods map[int32]time.Duration
+
+	drainRetryBudget   time.Duration
+	drainRetryInterval time.Duration
+	drainFailurePolicy string
+
+	eventRecorder record.EventRecorder
+
+	volumeDetachTimeout        time.Duration
+	volumeDetachIgnoredDrivers map[string]struct{}
+
+	jobCompletionTimeout time.Duration
+
+	rebootWindow             *operator.Periodic
+	minRemainingRebootWindow time.Duration
+
+	fallbackRebooter      FallbackRebooter
+	fallbackRebootTimeout time.Duration
+
+	usrPartition string
+
+	bootID string
+
+	rebootStrategy string
+
+	markBootSuccessfulCommand string
+
+	keepNodeCordonedAfterReboot bool
+
+	rebootTaint *corev1.Taint
+	skipCordon  bool
+
+	reconcileChannel bool
+
+	inhibitor     Inhibitor
+	inhibitorLock sync.Mutex
+	inhibitorFile *os.File
+
+	rebootScheduler    RebootScheduler
+	rebootWarningDelay time.Duration
+	rebootWallMessage  string
+
+	heartbeatInterval  time.Duration
+	heartbeatNamespace string
+
+	rebootRequiredCondition bool
+
+	state *agentState
+
+	readinessLock          sync.RWMutex
+	nodeAnnotationsSetUp   bool
+	updateStatusReceived   bool
+	nodeUpdateFailingSince time.Time
 }
 
 const (
 	defaultPollInterval            = 10 * time.Second
 	defaultMaxOperatorResponseTime = 24 * time.Hour
 
-	updateConfPath         = "/usr/share/flatcar/update.conf"
-	updateConfOverridePath = "/etc/flatcar/update.conf"
-	osReleasePath          = "/etc/os-release"
+	defaultMaxNodeUpdateFailureDuration = 5 * time.Minute
+
+	// Field managers used for applying node labels and annotations, which are always applied together.
+	infoLabelsFieldManager        = k8sutil.FieldManagerPrefix + "agent/info-labels"
+	madeUnschedulableFieldManager = k8sutil.FieldManagerPrefix + "agent/made-unschedulable"
 )
 
 // New returns initialized klocksmith.
@@ -109,30 +377,246 @@ func New(config *Config) (Klocksmith, error) {
 		maxOperatorResponseTime = defaultMaxOperatorResponseTime
 	}
 
+	preDrainHookTimeout := config.PreDrainHookTimeout
+	if preDrainHookTimeout == 0 {
+		preDrainHookTimeout = defaultHookTimeout
+	}
+
+	preDrainHookFailurePolicy := config.PreDrainHookFailurePolicy
+
+	switch preDrainHookFailurePolicy {
+	case "":
+		preDrainHookFailurePolicy = HookFailurePolicyFail
+	case HookFailurePolicyFail, HookFailurePolicyIgnore:
+	default:
+		return nil, fmt.Errorf("unsupported pre-drain hook failure policy %q, expected either %q or %q",
+			preDrainHookFailurePolicy, HookFailurePolicyFail, HookFailurePolicyIgnore)
+	}
+
+	postRebootProbes, err := parseProbes(config.PostRebootProbes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing post-reboot probes: %w", err)
+	}
+
+	if config.PodNetworkProbeAddress != "" {
+		if _, _, err := net.SplitHostPort(config.PodNetworkProbeAddress); err != nil {
+			return nil, fmt.Errorf("parsing pod network probe address: %w", err)
+		}
+	}
+
+	maxNodeUpdateFailureDuration := config.MaxNodeUpdateFailureDuration
+	if maxNodeUpdateFailureDuration == 0 {
+		maxNodeUpdateFailureDuration = defaultMaxNodeUpdateFailureDuration
+	}
+
+	nodeUpdateBackoff := k8sutil.DefaultBackoff()
+	if config.NodeUpdateBackoff != nil {
+		if err := config.NodeUpdateBackoff.Validate(); err != nil {
+			return nil, fmt.Errorf("validating node update backoff: %w", err)
+		}
+
+		nodeUpdateBackoff = *config.NodeUpdateBackoff
+	}
+
+	metricsRegisterer := config.MetricsRegisterer
+	if metricsRegisterer == nil {
+		metricsRegisterer = prometheus.NewRegistry()
+	}
+
+	metrics, err := newMetrics(metricsRegisterer, config.StatusReceiver)
+	if err != nil {
+		return nil, fmt.Errorf("creating metrics: %w", err)
+	}
+
+	var securityFeedURL *url.URL
+
+	if config.SecurityFeedURL != "" {
+		if securityFeedURL, err = parseSecurityFeedURL(config.SecurityFeedURL); err != nil {
+			return nil, fmt.Errorf("parsing security feed URL: %w", err)
+		}
+	}
+
+	pauseFile := ""
+	if config.PauseFile != "" {
+		pauseFile = filepath.Join(config.HostFilesPrefix, config.PauseFile)
+	}
+
+	if err := checkPriorityGracePeriods(config.DrainPriorityGracePeriods); err != nil {
+		return nil, fmt.Errorf("checking drain priority grace periods: %w", err)
+	}
+
+	drainRetryInterval := config.DrainRetryInterval
+	if drainRetryInterval == 0 {
+		drainRetryInterval = defaultDrainRetryInterval
+	}
+
+	drainFailurePolicy := config.DrainFailurePolicy
+
+	switch drainFailurePolicy {
+	case "":
+		drainFailurePolicy = DrainFailurePolicyProceed
+	case DrainFailurePolicyProceed, DrainFailurePolicyAbort:
+	default:
+		return nil, fmt.Errorf("unsupported drain failure policy %q, expected either %q or %q",
+			drainFailurePolicy, DrainFailurePolicyProceed, DrainFailurePolicyAbort)
+	}
+
+	var rebootWindow *operator.Periodic
+
+	switch {
+	case config.RebootWindowStart != "" || config.RebootWindowLength != "":
+		rebootWindow, err = parseRebootWindow(
+			config.RebootWindowStart, config.RebootWindowLength, config.MinRemainingRebootWindow)
+		if err != nil {
+			return nil, fmt.Errorf("parsing reboot window: %w", err)
+		}
+	case config.MinRemainingRebootWindow != 0:
+		return nil, fmt.Errorf("minimum remaining reboot window requires reboot window to be configured")
+	}
+
+	fallbackRebootTimeout := config.FallbackRebootTimeout
+	if fallbackRebootTimeout == 0 {
+		fallbackRebootTimeout = defaultFallbackRebootTimeout
+	}
+
+	volumeDetachIgnoredDrivers := map[string]struct{}{}
+	for _, driver := range config.VolumeDetachIgnoredDrivers {
+		volumeDetachIgnoredDrivers[driver] = struct{}{}
+	}
+
+	evictionTimeout := config.EvictionTimeout
+	if evictionTimeout == 0 {
+		evictionTimeout = config.PodDeletionGracePeriod
+	}
+
+	var rebootTaint *corev1.Taint
+
+	switch {
+	case config.RebootTaint != "":
+		if rebootTaint, err = parseRebootTaint(config.RebootTaint); err != nil {
+			return nil, fmt.Errorf("parsing reboot taint: %w", err)
+		}
+	case config.SkipCordon:
+		return nil, fmt.Errorf("skipping cordon requires reboot taint to be configured")
+	}
+
+	var inhibitor Inhibitor
+
+	if config.InhibitUnapprovedReboots {
+		rebootInhibitor, ok := config.Rebooter.(Inhibitor)
+		if !ok {
+			return nil, fmt.Errorf("configured rebooter does not support inhibitor locks")
+		}
+
+		inhibitor = rebootInhibitor
+	}
+
+	rebootScheduler, rebootWallMessage, err := rebootWarning(config)
+	if err != nil {
+		return nil, fmt.Errorf("configuring reboot warning: %w", err)
+	}
+
+	if config.HeartbeatInterval < 0 {
+		return nil, fmt.Errorf("heartbeat interval must not be negative")
+	}
+
+	if config.HeartbeatInterval > 0 && config.HeartbeatNamespace == "" {
+		return nil, fmt.Errorf("heartbeat namespace must be set when heartbeat interval is configured")
+	}
+
+	logger := klog.Background().WithValues(logging.KeyNode, config.NodeName)
+
 	return &klocksmith{
+		state:                   newAgentState(logger),
 		nodeName:                config.NodeName,
+		logger:                  logger,
 		nc:                      config.Clientset.CoreV1().Nodes(),
 		clientset:               config.Clientset,
 		ue:                      config.StatusReceiver,
 		lc:                      config.Rebooter,
 		reapTimeout:             config.PodDeletionGracePeriod,
+		evictionTimeout:         evictionTimeout,
 		forceNodeDrain:          config.ForceNodeDrain,
 		hostFilesPrefix:         config.HostFilesPrefix,
 		pollInterval:            pollInterval,
 		maxOperatorResponseTime: maxOperatorResponseTime,
+
+		preDrainHookCommand:       config.PreDrainHookCommand,
+		preDrainHooksDirectory:    config.PreDrainHooksDirectory,
+		preDrainHookTimeout:       preDrainHookTimeout,
+		preDrainHookFailurePolicy: preDrainHookFailurePolicy,
+
+		postRebootReadyDuration: config.PostRebootReadyDuration,
+		postRebootProbes:        postRebootProbes,
+		postRebootNetworkCheck:  config.PostRebootNetworkCheck,
+		podNetworkProbeAddress:  config.PodNetworkProbeAddress,
+
+		rebootSentinelFile: config.RebootSentinelFile,
+
+		maxNodeUpdateFailureDuration: maxNodeUpdateFailureDuration,
+		nodeUpdateBackoff:            nodeUpdateBackoff,
+
+		metrics: metrics,
+
+		securityFeedURL: securityFeedURL,
+
+		pauseFile: pauseFile,
+
+		drainPriorityGracePeriods: config.DrainPriorityGracePeriods,
+
+		drainRetryBudget:   config.DrainRetryBudget,
+		drainRetryInterval: drainRetryInterval,
+		drainFailurePolicy: drainFailurePolicy,
+
+		eventRecorder: newEventRecorder(config.Clientset),
+
+		volumeDetachTimeout:        config.VolumeDetachTimeout,
+		volumeDetachIgnoredDrivers: volumeDetachIgnoredDrivers,
+
+		jobCompletionTimeout: config.JobCompletionTimeout,
+
+		rebootWindow:             rebootWindow,
+		minRemainingRebootWindow: config.MinRemainingRebootWindow,
+
+		fallbackRebooter:      config.FallbackRebooter,
+		fallbackRebootTimeout: fallbackRebootTimeout,
+
+		markBootSuccessfulCommand: config.MarkBootSuccessfulCommand,
+
+		keepNodeCordonedAfterReboot: config.KeepNodeCordonedAfterReboot,
+
+		rebootTaint: rebootTaint,
+		skipCordon:  config.SkipCordon,
+
+		reconcileChannel: config.ReconcileChannel,
+
+		inhibitor: inhibitor,
+
+		rebootScheduler:    rebootScheduler,
+		rebootWarningDelay: config.RebootWarningDelay,
+		rebootWallMessage:  rebootWallMessage,
+
+		heartbeatInterval:  config.HeartbeatInterval,
+		heartbeatNamespace: config.HeartbeatNamespace,
+
+		rebootRequiredCondition: config.RebootRequiredCondition,
 	}, nil
 }
 
 // Run starts the agent to listen for an update_engine reboot signal and react
 // by draining pods and rebooting. Runs until the stop channel is closed.
 func (k *klocksmith) Run(ctx context.Context) error {
-	klog.V(5).Info("Starting agent")
+	k.logger.V(5).Info("Starting agent")
+
+	defer k.logger.V(5).Info("Stopping agent")
 
-	defer klog.V(5).Info("Stopping agent")
+	defer k.releaseInhibitorLock()
+
+	go k.reportHeartbeat(ctx)
 
 	// Agent process should reboot the node, no need to loop.
-	if err := k.process(ctx); err != nil {
-		klog.Errorf("Error running agent process: %v", err)
+	if err := k.process(klog.NewContext(ctx, k.logger)); err != nil {
+		k.logger.Error(err, "Error running agent process")
 
 		return fmt.Errorf("processing: %w", err)
 	}
@@ -140,29 +624,113 @@ func (k *klocksmith) Run(ctx context.Context) error {
 	return nil
 }
 
+// Ready implements Klocksmith interface.
+func (k *klocksmith) Ready() error {
+	k.readinessLock.RLock()
+	defer k.readinessLock.RUnlock()
+
+	if !k.nodeAnnotationsSetUp {
+		return fmt.Errorf("node annotations not set up yet")
+	}
+
+	if !k.updateStatusReceived {
+		return fmt.Errorf("no status received from update_engine yet")
+	}
+
+	return nil
+}
+
+// Healthz implements Klocksmith interface.
+func (k *klocksmith) Healthz() error {
+	if healthChecker, ok := k.ue.(HealthChecker); ok {
+		if err := healthChecker.Healthz(); err != nil {
+			return fmt.Errorf("checking update status receiver: %w", err)
+		}
+	}
+
+	k.readinessLock.RLock()
+	defer k.readinessLock.RUnlock()
+
+	if !k.nodeUpdateFailingSince.IsZero() && time.Since(k.nodeUpdateFailingSince) > k.maxNodeUpdateFailureDuration {
+		return fmt.Errorf("updating node has been failing since %s",
+			k.nodeUpdateFailingSince.UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// recordNodeUpdateResult tracks for how long updating Node object keeps failing, which
+// is reported by Healthz.
+func (k *klocksmith) recordNodeUpdateResult(err error) {
+	k.readinessLock.Lock()
+	defer k.readinessLock.Unlock()
+
+	switch {
+	case err == nil:
+		k.nodeUpdateFailingSince = time.Time{}
+	case k.nodeUpdateFailingSince.IsZero():
+		k.nodeUpdateFailingSince = time.Now()
+	}
+}
+
+// updateNode updates agent's Node object using updateF, retrying conflicts according to configured backoff.
+func (k *klocksmith) updateNode(ctx context.Context, updateF k8sutil.UpdateNode) error {
+	return k8sutil.UpdateNodeRetryWithBackoff(ctx, k.nc, k.nodeName, k.nodeUpdateBackoff, updateF)
+}
+
 // process performs the agent reconciliation to reboot the node or stops when
 // the stop channel is closed.
 //
 //nolint:funlen,cyclop // TODO: This will be refactored once we have tests in place.
 func (k *klocksmith) process(ctx context.Context) error {
-	klog.Info("Setting info labels")
+	k.logger.Info("Setting info labels")
 
 	if err := k.setInfoLabels(ctx); err != nil {
 		return fmt.Errorf("setting node info: %w", err)
 	}
 
-	klog.Info("Checking annotations")
+	partition, err := bootedUsrPartition(k.hostFilesPrefix)
+	if err != nil {
+		k.logger.Error(err, "Failed determining booted USR partition, rollbacks will not be detected")
+	}
+
+	k.usrPartition = partition
+
+	bootID, err := readBootID(k.hostFilesPrefix)
+	if err != nil {
+		k.logger.Error(err, "Failed determining boot ID, reboots outside of agent will not be detected")
+	}
+
+	k.bootID = bootID
+
+	k.rebootStrategy = k.readRebootStrategy()
+
+	k.logger.Info("Checking annotations")
 
 	node, err := k8sutil.GetNodeRetry(ctx, k.nc, k.nodeName)
 	if err != nil {
 		return fmt.Errorf("getting node %q: %w", k.nodeName, err)
 	}
 
-	// Only make a node schedulable if a reboot was in progress. This prevents a node from being made schedulable
-	// if it was made unschedulable by something other than the agent.
-	annotation := constants.AnnotationAgentMadeUnschedulable
-	madeUnschedulableAnnotation, madeUnschedulableAnnotationExists := node.Annotations[annotation]
-	makeSchedulable := madeUnschedulableAnnotation == constants.True
+	rebootFinished := k.rebootFinished(node)
+
+	if k.rebootedManually(node) {
+		if err := k.resetStateAfterManualReboot(ctx); err != nil {
+			return err
+		}
+	}
+
+	if rebootFinished {
+		k.state.setPhase(PhaseVerifyingNodeHealth)
+
+		if err := k.waitForHealthyNode(ctx); err != nil {
+			return fmt.Errorf("verifying node health after reboot: %w", err)
+		}
+
+		if err := k.markBootSuccessful(ctx); err != nil {
+			return err
+		}
+	}
 
 	// Set flatcar-linux.net/update1/reboot-in-progress=false and
 	// flatcar-linux.net/update1/reboot-needed=false.
@@ -170,49 +738,115 @@ func (k *klocksmith) process(ctx context.Context) error {
 		constants.AnnotationRebootInProgress: constants.False,
 		constants.AnnotationRebootNeeded:     constants.False,
 	}
+
+	if k.securityFeedURL != nil {
+		anno[constants.AnnotationSecurityUpdate] = constants.False
+	}
+
+	if rebootFinished {
+		if err := k.clearEmergencyReboot(ctx, node); err != nil {
+			return err
+		}
+
+		anno[constants.AnnotationDrainFailed] = constants.False
+		anno[constants.AnnotationLastRebootTime] = time.Now().UTC().Format(time.RFC3339)
+		anno[constants.AnnotationLastRebootVersion] = node.Labels[constants.LabelVersion]
+	}
+
+	if k.bootID != "" {
+		anno[constants.AnnotationBootID] = k.bootID
+	}
+
+	anno[constants.AnnotationRebootStrategy] = k.rebootStrategy
+
+	k.recordBootedPartition(anno, node.Annotations, rebootFinished)
+
 	labels := map[string]string{
 		constants.LabelRebootNeeded: constants.False,
 	}
 
-	klog.Infof("Setting annotations %#v", anno)
+	k.logger.Info("Setting annotations", "annotations", anno)
 
-	if err := k8sutil.SetNodeAnnotationsLabels(ctx, k.nc, k.nodeName, anno, labels); err != nil {
+	patch := k8sutil.NodeMetadataPatch{
+		Annotations: anno,
+		Labels:      labels,
+		// Scheduled reboot is only tracked while the agent waits for it.
+		RemoveAnnotations: []string{constants.AnnotationRebootScheduledTime},
+	}
+
+	if err := k8sutil.PatchNodeAnnotationsLabels(ctx, k.nc, k.nodeName, patch); err != nil {
 		return fmt.Errorf("setting node %q labels and annotations: %w", k.nodeName, err)
 	}
 
+	k.setRebootRequiredCondition(ctx, corev1.ConditionFalse, ConditionReasonNoPendingUpdate,
+		"Node does not need a reboot")
+
 	// Since we set 'reboot-needed=false', 'ok-to-reboot' should clear.
 	// Wait for it to do so, else we might start reboot-looping.
 	if err := k.waitForNotOkToReboot(ctx); err != nil {
 		return fmt.Errorf("waiting for not ok to reboot signal from operator: %w", err)
 	}
 
-	if makeSchedulable {
-		// We are schedulable now.
-		klog.Info("Marking node as schedulable")
+	if err := k.restoreSchedulability(ctx, node, rebootFinished); err != nil {
+		return err
+	}
 
-		if err := k8sutil.Unschedulable(ctx, k.nc, k.nodeName, false); err != nil {
-			return fmt.Errorf("marking node %q as unschedulable: %w", k.nodeName, err)
-		}
+	k.readinessLock.Lock()
+	k.nodeAnnotationsSetUp = true
+	k.readinessLock.Unlock()
 
-		anno = map[string]string{
-			constants.AnnotationAgentMadeUnschedulable: constants.False,
-		}
+	// Watch update engine for status updates.
+	go k.watchUpdateStatus(ctx, k.updateStatusCallback)
 
-		klog.Infof("Setting annotations %#v", anno)
+	if k.rebootSentinelFile != "" {
+		go k.watchRebootSentinelFile(ctx)
+	}
+
+	if k.pauseFile != "" {
+		go k.watchPauseFile(ctx)
+	}
 
-		if err := k8sutil.SetNodeAnnotations(ctx, k.nc, k.nodeName, anno); err != nil {
-			return fmt.Errorf("setting node %q annotations: %w", k.nodeName, err)
+	if updateChecker, ok := k.ue.(UpdateChecker); ok {
+		go k.watchUpdateCheckRequests(ctx, updateChecker)
+	}
+
+	if statusResetter, ok := k.ue.(StatusResetter); ok {
+		go k.watchStatusResetRequests(ctx, statusResetter)
+	}
+
+	if rollbacker, ok := k.ue.(Rollbacker); ok {
+		go k.watchRollbackRequests(ctx, rollbacker)
+	}
+
+	if k.reconcileChannel {
+		k.startChannelReconciliation(ctx)
+	}
+
+	if k.rebootsDisabled() {
+		k.reportUpdateStatusOnly(ctx)
+
+		return nil
+	}
+
+	for {
+		cancelled, err := k.rebootWhenApproved(ctx)
+		if err != nil || !cancelled {
+			return err
 		}
-	} else if madeUnschedulableAnnotationExists { // Annotation exists so node was marked unschedulable by external source.
-		klog.Info("Skipping marking node as schedulable -- node was marked unschedulable by an external source")
 	}
+}
 
-	// Watch update engine for status updates.
-	go k.watchUpdateStatus(ctx, k.updateStatusCallback)
+// rebootWhenApproved waits for the reboot approval from the operator, prepares the node and reboots it.
+// It returns true when the scheduled reboot has been cancelled, as the approval was revoked during the
+// reboot warning delay, so the agent should wait for the approval again.
+//
+//nolint:funlen,cyclop // TODO: This will be refactored once we have tests in place.
+func (k *klocksmith) rebootWhenApproved(ctx context.Context) (bool, error) {
+	k.state.setPhase(PhaseWaitingForOkToReboot)
 
 	// Block until constants.AnnotationOkToReboot is set.
 	for okToReboot := false; !okToReboot; {
-		klog.Infof("Waiting for ok-to-reboot from controller...")
+		k.logger.Info("Waiting for ok-to-reboot from controller...")
 
 		errCh := make(chan error)
 
@@ -222,12 +856,12 @@ func (k *klocksmith) process(ctx context.Context) error {
 
 		select {
 		case <-ctx.Done():
-			klog.Infof("Got stop signal while waiting for ok-to-reboot from controller")
+			k.logger.Info("Got stop signal while waiting for ok-to-reboot from controller")
 
-			return nil
+			return false, nil
 		case err := <-errCh:
 			if err != nil {
-				klog.Warningf("Error waiting for an ok-to-reboot: %v", err)
+				k.logger.Error(err, "Error waiting for an ok-to-reboot")
 
 				// Break select statement to restart watching for ok to reboot.
 				break
@@ -238,17 +872,30 @@ func (k *klocksmith) process(ctx context.Context) error {
 		}
 	}
 
-	klog.Info("Checking if node is already unschedulable")
+	k.releaseInhibitorLock()
 
-	node, err = k8sutil.GetNodeRetry(ctx, k.nc, k.nodeName)
+	emergency, err := k.emergencyReboot(ctx)
 	if err != nil {
-		return fmt.Errorf("getting node %q: %w", k.nodeName, err)
+		return false, err
 	}
 
-	alreadyUnschedulable := node.Spec.Unschedulable
+	if !emergency {
+		if err := k.waitForRebootConditions(ctx); err != nil {
+			return false, err
+		}
+	}
+
+	k.logger.Info("Checking if node is already unschedulable")
+
+	node, err := k8sutil.GetNodeRetry(ctx, k.nc, k.nodeName)
+	if err != nil {
+		return false, fmt.Errorf("getting node %q: %w", k.nodeName, err)
+	}
+
+	alreadyUnschedulable := k.schedulingDisabled(node)
 
 	// Set constants.AnnotationRebootInProgress and drain self.
-	anno = map[string]string{
+	anno := map[string]string{
 		constants.AnnotationRebootInProgress: constants.True,
 	}
 
@@ -256,79 +903,131 @@ func (k *klocksmith) process(ctx context.Context) error {
 		anno[constants.AnnotationAgentMadeUnschedulable] = constants.True
 	}
 
-	klog.Infof("Setting annotations %#v", anno)
+	k.logger.Info("Setting annotations", "annotations", anno)
+
+	if err := k.updateNode(ctx, func(node *corev1.Node) {
+		for k, v := range anno {
+			node.Annotations[k] = v
+		}
+
+		// Reboot is about to happen, so the request is no longer pending.
+		delete(node.Annotations, constants.AnnotationRebootNeededSince)
 
-	if err := k8sutil.SetNodeAnnotations(ctx, k.nc, k.nodeName, anno); err != nil {
-		return fmt.Errorf("setting node %q annotations: %w", k.nodeName, err)
+		k.setUsrPartitionBeforeReboot(node)
+	}); err != nil {
+		return false, fmt.Errorf("setting node %q annotations: %w", k.nodeName, err)
 	}
 
 	if !alreadyUnschedulable {
-		klog.Info("Marking node as unschedulable")
+		k.logger.Info("Marking node as unschedulable")
 
-		if err := k8sutil.Unschedulable(ctx, k.nc, k.nodeName, true); err != nil {
-			return fmt.Errorf("marking node %q as unschedulable: %w", k.nodeName, err)
+		if err := k.setSchedulable(ctx, false); err != nil {
+			return false, fmt.Errorf("marking node %q as unschedulable: %w", k.nodeName, err)
 		}
 	} else {
-		klog.Info("Node already marked as unschedulable")
+		k.logger.Info("Node already marked as unschedulable")
 	}
 
-	drainer := newDrainer(ctx, k.clientset, k.reapTimeout, k.forceNodeDrain)
+	if !emergency {
+		k.state.setPhase(PhaseWaitingForJobs)
 
-	klog.Info("Getting pod list for deletion")
+		if err := k.waitForJobsCompletion(ctx); err != nil {
+			return false, err
+		}
+	}
 
-	pods, errs := drainer.GetPodsForDeletion(k.nodeName)
-	if len(errs) > 0 {
-		return fmt.Errorf("getting pods for deletion: %v", errs)
+	k.state.setPhase(PhaseRunningPreDrainHooks)
+
+	if err := k.runPreDrainHooks(ctx); err != nil {
+		return false, err
 	}
 
-	klog.Infof("Deleting/Evicting %d pods", len(pods.Pods()))
+	k.state.setPhase(PhaseDraining)
 
-	if err := drainer.DeleteOrEvictPods(pods.Pods()); err != nil {
-		if ctx.Err() != nil {
-			return fmt.Errorf("deleting/evicting pods: %w", ctx.Err())
-		}
+	if err := k.drainWithRetries(ctx); err != nil {
+		return false, err
+	}
+
+	k.state.setPhase(PhaseWaitingForVolumeDetach)
 
-		klog.Errorf("Ignoring node drain error and proceeding with reboot: %v", err)
+	if err := k.waitForVolumesDetached(ctx); err != nil {
+		return false, err
 	}
 
-	klog.Info("Node drained, rebooting")
+	k.logger.Info("Node drained, rebooting")
 
-	// Reboot.
-	k.lc.Reboot(false)
+	k.state.setPhase(PhaseRebooting)
 
-	// Cross fingers.
-	sleepOrDone(24*7*time.Hour, ctx.Done())
+	cancelled, err := k.reboot(ctx)
+	if err != nil && !errors.Is(err, errRebootRequestFailed) {
+		return false, err
+	}
 
-	return nil
+	if cancelled {
+		return true, nil
+	}
+
+	k.waitForReboot(ctx, err)
+
+	return false, nil
 }
 
 // updateStatusCallback receives Status messages from update engine. If the
 // status is UpdateStatusUpdatedNeedReboot, indicate that with a label on our
 // node.
 func (k *klocksmith) updateStatusCallback(ctx context.Context, status updateengine.Status) {
-	klog.Info("Updating status")
+	k.logger.Info("Updating status")
 
 	// update our status.
 	anno := map[string]string{
 		constants.AnnotationStatus:          status.CurrentOperation,
 		constants.AnnotationLastCheckedTime: fmt.Sprintf("%d", status.LastCheckedTime),
 		constants.AnnotationNewVersion:      status.NewVersion,
+		constants.AnnotationLastStatusTime:  time.Now().UTC().Format(time.RFC3339),
 	}
 
 	labels := map[string]string{}
 
+	rebootNeeded := status.CurrentOperation == updateengine.UpdateStatusUpdatedNeedReboot
+
 	// Indicate we need a reboot.
-	if status.CurrentOperation == updateengine.UpdateStatusUpdatedNeedReboot {
-		klog.Info("Indicating a reboot is needed")
+	if rebootNeeded {
+		k.logger.Info("Indicating a reboot is needed")
 
 		anno[constants.AnnotationRebootNeeded] = constants.True
 		labels[constants.LabelRebootNeeded] = constants.True
+
+		k.classifyUpdate(ctx, anno, status.NewVersion)
+
+		k.acquireInhibitorLock()
+	}
+
+	k.recordUpdateAttemptResult(anno, status)
+
+	rebootNeededSince := time.Now().UTC().Format(time.RFC3339)
+
+	updateF := func(node *corev1.Node) {
+		for k, v := range anno {
+			node.Annotations[k] = v
+		}
+
+		for k, v := range labels {
+			node.Labels[k] = v
+		}
+
+		if rebootNeeded {
+			setRebootNeededSince(node, rebootNeededSince)
+		}
 	}
 
 	//nolint:staticcheck // New equivalent is buggy: https://github.com/kubernetes/kubernetes/issues/119533.
 	err := wait.PollImmediateUntil(k.pollInterval, func() (bool, error) {
-		if err := k8sutil.SetNodeAnnotationsLabels(ctx, k.nc, k.nodeName, anno, labels); err != nil {
-			klog.Errorf("Failed to set annotation %q: %v", constants.AnnotationStatus, err)
+		err := k.updateNode(ctx, updateF)
+
+		k.recordNodeUpdateResult(err)
+
+		if err != nil {
+			k.logger.Error(err, "Failed to set annotation", "annotation", constants.AnnotationStatus)
 
 			return false, nil
 		}
@@ -336,24 +1035,48 @@ func (k *klocksmith) updateStatusCallback(ctx context.Context, status updateengi
 		return true, nil
 	}, ctx.Done())
 	if err != nil {
-		klog.Errorf("Failed updating node annotations and labels: %v", err)
+		k.logger.Error(err, "Failed updating node annotations and labels")
+
+		return
+	}
+
+	if rebootNeeded {
+		k.setRebootRequiredCondition(ctx, corev1.ConditionTrue, ConditionReasonUpdateDownloaded,
+			fmt.Sprintf("Update to version %s has been downloaded and requires a reboot", status.NewVersion))
+	}
+}
+
+// setRebootNeededSince annotates given node with the time when reboot has been requested.
+//
+// The time when reboot was requested for the first time is preserved, as agent may be
+// restarted while waiting for a reboot.
+func setRebootNeededSince(node *corev1.Node, rebootNeededSince string) {
+	if _, ok := node.Annotations[constants.AnnotationRebootNeededSince]; !ok {
+		node.Annotations[constants.AnnotationRebootNeededSince] = rebootNeededSince
 	}
 }
 
 // setInfoLabels labels our node with helpful info about Flatcar Container Linux.
 func (k *klocksmith) setInfoLabels(ctx context.Context) error {
-	versionInfo, err := getVersionInfo(k.hostFilesPrefix)
+	updateConfig, err := flatcar.ReadUpdateConfig(k.hostFilesPrefix)
 	if err != nil {
-		return fmt.Errorf("getting version info: %w", err)
+		return fmt.Errorf("getting update configuration: %w", err)
+	}
+
+	osRelease, err := flatcar.ReadOSRelease(k.hostFilesPrefix)
+	if err != nil {
+		return fmt.Errorf("getting OS release info: %w", err)
 	}
 
 	labels := map[string]string{
-		constants.LabelID:      versionInfo.id,
-		constants.LabelGroup:   versionInfo.group,
-		constants.LabelVersion: versionInfo.version,
+		constants.LabelID:      osRelease.ID,
+		constants.LabelGroup:   updateConfig.Group,
+		constants.LabelVersion: osRelease.Version,
+		constants.LabelBoard:   osRelease.Board,
 	}
 
-	if err := k8sutil.SetNodeLabels(ctx, k.nc, k.nodeName, labels); err != nil {
+	err = k8sutil.ApplyNodeMetadata(ctx, k.nc, k.nodeName, infoLabelsFieldManager, nil, labels)
+	if err != nil {
 		return fmt.Errorf("setting node %q labels: %w", k.nodeName, err)
 	}
 
@@ -363,7 +1086,7 @@ func (k *klocksmith) setInfoLabels(ctx context.Context) error {
 type statusUpdateF func(context.Context, updateengine.Status)
 
 func (k *klocksmith) watchUpdateStatus(ctx context.Context, update statusUpdateF) {
-	klog.Info("Beginning to watch update_engine status")
+	k.logger.Info("Beginning to watch update_engine status")
 
 	oldOperation := ""
 	ch := make(chan updateengine.Status, 1)
@@ -371,6 +1094,18 @@ func (k *klocksmith) watchUpdateStatus(ctx context.Context, update statusUpdateF
 	go k.ue.ReceiveStatuses(ch, ctx.Done())
 
 	for status := range ch {
+		k.readinessLock.Lock()
+		k.updateStatusReceived = true
+		k.readinessLock.Unlock()
+
+		k.state.setLastStatus(status)
+
+		k.metrics.lastStatusTimestamp.SetToCurrentTime()
+		k.metrics.lastUpdateCheckTimestamp.Set(float64(status.LastCheckedTime))
+		k.metrics.updateProgress.Set(status.Progress)
+		k.metrics.pendingUpdateSize.Set(float64(status.NewSize))
+		k.metrics.recordOperation(status.CurrentOperation)
+
 		if status.CurrentOperation != oldOperation && update != nil {
 			update(ctx, status)
 			oldOperation = status.CurrentOperation
@@ -378,20 +1113,25 @@ func (k *klocksmith) watchUpdateStatus(ctx context.Context, update statusUpdateF
 	}
 }
 
-// waitForOkToReboot waits for both 'ok-to-reboot' and 'needs-reboot' to be true.
+// waitForOkToReboot waits for both 'ok-to-reboot' and 'needs-reboot' to be true or for
+// 'emergency-reboot' to be true.
 func (k *klocksmith) waitForOkToReboot(ctx context.Context) error {
 	node, err := k.nc.Get(ctx, k.nodeName, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("getting self node (%q): %w", k.nodeName, err)
 	}
 
+	if emergencyRebootRequested(node.Annotations) {
+		return nil
+	}
+
 	shouldRebootSelector := fields.Set(map[string]string{
 		constants.AnnotationOkToReboot:   constants.True,
 		constants.AnnotationRebootNeeded: constants.True,
 	}).AsSelector()
 
 	return k.waitForNodeCondition(ctx, node, func(annotations map[string]string) bool {
-		return shouldRebootSelector.Matches(fields.Set(annotations))
+		return shouldRebootSelector.Matches(fields.Set(annotations)) || emergencyRebootRequested(annotations)
 	})
 }
 
@@ -401,7 +1141,7 @@ func (k *klocksmith) waitForNotOkToReboot(ctx context.Context) error {
 		return fmt.Errorf("getting self node (%q): %w", k.nodeName, err)
 	}
 
-	if node.Annotations[constants.AnnotationOkToReboot] != constants.True {
+	if !rebootstate.IsTrue(rebootstate.FromNode(node).OkToReboot) {
 		return nil
 	}
 
@@ -460,18 +1200,83 @@ func (k *klocksmith) waitForNodeCondition(ctx context.Context, node *corev1.Node
 	return nil
 }
 
+// drain evicts pods from the node using Eviction API, so PodDisruptionBudgets are respected.
+// Pods which could not be evicted within eviction timeout are deleted instead. It returns false
+// if not all pods could be removed.
+//
+// Errors from removing pods are logged and ignored, unless given context is cancelled.
+func (k *klocksmith) drain(ctx context.Context) (bool, error) {
+	evicted, err := k.removePods(ctx, func(gracePeriodSeconds int) drainer {
+		return newDrainer(ctx, k.clientset, k.evictionTimeout, gracePeriodSeconds, k.forceNodeDrain, false,
+			k.metrics.podRemoved)
+	})
+	if err != nil || evicted {
+		return evicted, err
+	}
+
+	k.logger.Info("Falling back to deleting pods which could not be evicted")
+
+	return k.removePods(ctx, func(gracePeriodSeconds int) drainer {
+		return newDrainer(ctx, k.clientset, k.reapTimeout, gracePeriodSeconds, k.forceNodeDrain, true,
+			k.metrics.podRemoved)
+	})
+}
+
+// removePods removes pods from the node using drainers created by given function, one priority tier
+// after another. It returns false if not all pods have been removed in time.
+func (k *klocksmith) removePods(ctx context.Context, newDrainerF func(gracePeriodSeconds int) drainer) (bool, error) {
+	k.logger.Info("Getting pod list for deletion")
+
+	pods, errs := newDrainerF(podGracePeriod).GetPodsForDeletion(k.nodeName)
+	if len(errs) > 0 {
+		return false, fmt.Errorf("getting pods for deletion: %v", errs)
+	}
+
+	for _, tier := range k.priorityTiers(pods.Pods()) {
+		k.logger.Info("Deleting/Evicting pods", "count", len(tier.pods), "minPriority", tier.minPriority)
+
+		if err := newDrainerF(tier.gracePeriodSeconds).DeleteOrEvictPods(tier.pods); err != nil {
+			if ctx.Err() != nil {
+				return false, fmt.Errorf("deleting/evicting pods: %w", ctx.Err())
+			}
+
+			k.logger.Error(err, "Failed deleting/evicting pods")
+
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
 type drainer interface {
 	GetPodsForDeletion(nodeName string) (*drain.PodDeleteList, []error)
 	DeleteOrEvictPods([]corev1.Pod) error
 }
 
-func newDrainer(ctx context.Context, cs kubernetes.Interface, timeout time.Duration, forceNodeDrain bool) drainer {
+// newDrainer creates drainer removing pods with a given termination grace period. When grace period
+// is longer than given timeout, timeout is extended, so pods have a chance to terminate gracefully.
+// Given function is called for every removed pod.
+func newDrainer(
+	ctx context.Context,
+	cs kubernetes.Interface,
+	timeout time.Duration,
+	gracePeriodSeconds int,
+	forceNodeDrain, disableEviction bool,
+	onPodRemovedF func(pod *corev1.Pod, usingEviction bool),
+) drainer {
+	if gracePeriod := time.Duration(gracePeriodSeconds) * time.Second; gracePeriod > timeout {
+		timeout = gracePeriod
+	}
+
 	return &drain.Helper{
-		Ctx:                ctx,
-		Client:             cs,
-		Force:              forceNodeDrain,
-		GracePeriodSeconds: -1,
-		Timeout:            timeout,
+		Ctx:                   ctx,
+		Client:                cs,
+		Force:                 forceNodeDrain,
+		GracePeriodSeconds:    gracePeriodSeconds,
+		Timeout:               timeout,
+		DisableEviction:       disableEviction,
+		OnPodDeletedOrEvicted: onPodRemovedF,
 		// Explicitly don't terminate self? we'll probably just be a
 		// Mirror pod or daemonset anyway..
 		IgnoreAllDaemonSets: true,
@@ -505,96 +1310,6 @@ func sleepOrDone(d time.Duration, done <-chan struct{}) {
 	}
 }
 
-// splitNewlineEnv splits newline-delimited KEY=VAL pairs and puts values into given map.
-func splitNewlineEnv(envVars map[string]string, envs string) {
-	sc := bufio.NewScanner(strings.NewReader(envs))
-	for sc.Scan() {
-		// Even if value contains the delimiter, we want to ignore it.
-		maxSubstrings := 2
-		spl := strings.SplitN(sc.Text(), "=", maxSubstrings)
-
-		// Just skip empty lines or lines without a value.
-		if len(spl) == 1 {
-			continue
-		}
-
-		envVars[spl[0]] = spl[1]
-	}
-}
-
-// versionInfo contains Flatcar version and update information.
-type versionInfo struct {
-	id      string
-	group   string
-	version string
-}
-
-func getUpdateMap(filesPathPrefix string) (map[string]string, error) {
-	infomap := map[string]string{}
-
-	updateConfPathWithPrefix := filepath.Join(filesPathPrefix, updateConfPath)
-
-	// This file should always be present on Flatcar.
-	b, err := os.ReadFile(updateConfPathWithPrefix)
-	if err != nil {
-		return nil, fmt.Errorf("reading file %q: %w", updateConfPathWithPrefix, err)
-	}
-
-	splitNewlineEnv(infomap, string(b))
-
-	updateConfOverridePathWithPrefix := filepath.Join(filesPathPrefix, updateConfOverridePath)
-
-	updateConfOverride, err := os.ReadFile(updateConfOverridePathWithPrefix)
-	if err != nil {
-		if !os.IsNotExist(err) {
-			return nil, fmt.Errorf("reading file %q: %w", updateConfOverridePathWithPrefix, err)
-		}
-
-		klog.Infof("Skipping missing update.conf: %v", err)
-	}
-
-	splitNewlineEnv(infomap, string(updateConfOverride))
-
-	return infomap, nil
-}
-
-func getReleaseMap(filesPathPrefix string) (map[string]string, error) {
-	infomap := map[string]string{}
-
-	osReleasePathWithPrefix := filepath.Join(filesPathPrefix, osReleasePath)
-
-	// This file should always be present on Flatcar.
-	b, err := os.ReadFile(osReleasePathWithPrefix)
-	if err != nil {
-		return nil, fmt.Errorf("reading file %q: %w", osReleasePathWithPrefix, err)
-	}
-
-	splitNewlineEnv(infomap, string(b))
-
-	return infomap, nil
-}
-
-// GetVersionInfo returns VersionInfo from the current Flatcar system.
-//
-// Should probably live in a different package.
-func getVersionInfo(filesPathPrefix string) (*versionInfo, error) {
-	updateconf, err := getUpdateMap(filesPathPrefix)
-	if err != nil {
-		return nil, fmt.Errorf("getting update configuration: %w", err)
-	}
-
-	osrelease, err := getReleaseMap(filesPathPrefix)
-	if err != nil {
-		return nil, fmt.Errorf("getting OS release info: %w", err)
-	}
-
-	return &versionInfo{
-		id:      osrelease["ID"],
-		group:   updateconf["GROUP"],
-		version: osrelease["VERSION"],
-	}, nil
-}
-
 type klogWriter struct {
 	wf func(args ...interface{})
 }
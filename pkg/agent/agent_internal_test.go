@@ -2,59 +2,10 @@ package agent
 
 import (
 	"context"
-	"reflect"
 	"testing"
 	"time"
 )
 
-func Test_splitNewlineEnv(t *testing.T) {
-	t.Parallel()
-
-	t.Run("retain_map_values_when_given_empty_input", func(t *testing.T) {
-		t.Parallel()
-
-		expected := map[string]string{"foo": "bar"}
-
-		input := map[string]string{}
-
-		splitNewlineEnv(input, "foo=bar")
-
-		splitNewlineEnv(input, "")
-
-		if !reflect.DeepEqual(expected, input) {
-			t.Fatalf("Should retain original map when given empty content")
-		}
-	})
-
-	t.Run("skips_lines_without_equal_sign", func(t *testing.T) {
-		t.Parallel()
-
-		expected := map[string]string{}
-
-		input := map[string]string{}
-
-		splitNewlineEnv(input, "foo")
-
-		if !reflect.DeepEqual(expected, input) {
-			t.Fatalf("Expected %q, got %q", expected, input)
-		}
-	})
-
-	t.Run("skips_empty_lines", func(t *testing.T) {
-		t.Parallel()
-
-		expected := map[string]string{}
-
-		input := map[string]string{}
-
-		splitNewlineEnv(input, "")
-
-		if !reflect.DeepEqual(expected, input) {
-			t.Fatalf("Expected %q, got %q", expected, input)
-		}
-	})
-}
-
 func Test_sleepOrDone_returns_when_given(t *testing.T) {
 	t.Parallel()
 
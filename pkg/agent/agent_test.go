@@ -2,9 +2,13 @@ package agent_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,8 +16,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
@@ -27,6 +35,7 @@ import (
 
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/agent"
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/updateengine"
 )
 
@@ -128,6 +137,63 @@ func Test_Creating_new_agent(t *testing.T) {
 			"no_status_receiver_is_configured": func(c *agent.Config) { c.StatusReceiver = nil },
 			"no_rebooter_is_configured":        func(c *agent.Config) { c.Rebooter = nil },
 			"empty_node_name_is_given":         func(c *agent.Config) { c.NodeName = "" },
+			"unsupported_pre_drain_hook_failure_policy_is_configured": func(c *agent.Config) {
+				c.PreDrainHookFailurePolicy = "foo"
+			},
+			"post_reboot_probe_with_unsupported_scheme_is_configured": func(c *agent.Config) {
+				c.PostRebootProbes = []string{"udp://127.0.0.1:53"}
+			},
+			"pod_network_probe_address_without_port_is_configured": func(c *agent.Config) {
+				c.PodNetworkProbeAddress = "127.0.0.1"
+			},
+			"security_feed_URL_with_unsupported_scheme_is_configured": func(c *agent.Config) {
+				c.SecurityFeedURL = "file:///etc/security-feed.json"
+			},
+			"unsupported_drain_failure_policy_is_configured": func(c *agent.Config) {
+				c.DrainFailurePolicy = "foo"
+			},
+			"invalid_reboot_window_is_configured": func(c *agent.Config) {
+				c.RebootWindowStart = "Foo 14:00"
+				c.RebootWindowLength = "1h"
+			},
+			"minimum_remaining_reboot_window_is_longer_than_reboot_window": func(c *agent.Config) {
+				c.RebootWindowStart = "14:00"
+				c.RebootWindowLength = "1h"
+				c.MinRemainingRebootWindow = 2 * time.Hour
+			},
+			"minimum_remaining_reboot_window_is_configured_without_reboot_window": func(c *agent.Config) {
+				c.MinRemainingRebootWindow = time.Hour
+			},
+			"negative_drain_priority_grace_period_is_configured": func(c *agent.Config) {
+				c.DrainPriorityGracePeriods = map[int32]time.Duration{1000: -time.Second}
+			},
+			"reboot_taint_with_unsupported_effect_is_configured": func(c *agent.Config) {
+				c.RebootTaint = "example.com/rebooting:NoReboot"
+			},
+			"invalid_node_update_backoff_is_configured": func(c *agent.Config) {
+				c.NodeUpdateBackoff = &k8sutil.Backoff{Factor: 0.5}
+			},
+			"reboot_taint_with_invalid_key_is_configured": func(c *agent.Config) {
+				c.RebootTaint = "example.com/foo/bar:NoSchedule"
+			},
+			"skipping_cordon_is_configured_without_reboot_taint": func(c *agent.Config) {
+				c.SkipCordon = true
+			},
+			"inhibiting_unapproved_reboots_is_configured_with_rebooter_not_supporting_inhibitor_locks": func(c *agent.Config) {
+				c.InhibitUnapprovedReboots = true
+			},
+			"negative_reboot_warning_delay_is_configured": func(c *agent.Config) {
+				c.RebootWarningDelay = -time.Second
+			},
+			"reboot_warning_delay_is_configured_with_rebooter_not_supporting_scheduling_reboots": func(c *agent.Config) {
+				c.RebootWarningDelay = time.Minute
+			},
+			"negative_heartbeat_interval_is_configured": func(c *agent.Config) {
+				c.HeartbeatInterval = -time.Second
+			},
+			"heartbeat_interval_is_configured_without_heartbeat_namespace": func(c *agent.Config) {
+				c.HeartbeatInterval = time.Minute
+			},
 		}
 
 		for n, mutateConfigF := range cases {
@@ -164,15 +230,21 @@ func Test_Running_agent(t *testing.T) {
 		expectedGroup := "configuredGroup"
 		expectedOSID := "testID"
 		expectedVersion := "testVersion"
+		expectedBoard := "testBoard"
+
+		osRelease := fmt.Sprintf("ID=%s\nVERSION=%s\nFLATCAR_BOARD=%q", expectedOSID, expectedVersion, expectedBoard)
 
 		files := map[string]string{
 			"/usr/share/flatcar/update.conf": "GROUP=" + expectedGroup,
-			"/etc/os-release":                fmt.Sprintf("ID=%s\nVERSION=%s", expectedOSID, expectedVersion),
+			"/etc/os-release":                osRelease,
 		}
 
 		createTestFiles(t, files, testConfig.HostFilesPrefix)
 
-		ctx := contextWithTimeout(t, agentRunTimeLimit)
+		// Agent runs until all subtests finish, so each subtest gets its own deadline, which starts only once
+		// the subtest gets to run in parallel with other tests.
+		ctx, cancel := context.WithCancel(contextWithDeadline(t))
+		t.Cleanup(cancel)
 
 		done := runAgent(ctx, t, testConfig)
 
@@ -180,7 +252,7 @@ func Test_Running_agent(t *testing.T) {
 			t.Parallel()
 
 			// This is currently the only way to check that agent has read /etc/os-release file.
-			assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			assertNodeProperty(contextWithTimeout(t, agentRunTimeLimit), t, &assertNodePropertyContext{
 				done:   done,
 				config: testConfig,
 				testF:  assertNodeLabelValue(constants.LabelID, expectedOSID),
@@ -191,19 +263,30 @@ func Test_Running_agent(t *testing.T) {
 			t.Parallel()
 
 			// This is currently the only way to check that agent has read /etc/os-release file.
-			assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			assertNodeProperty(contextWithTimeout(t, agentRunTimeLimit), t, &assertNodePropertyContext{
 				done:   done,
 				config: testConfig,
 				testF:  assertNodeLabelValue(constants.LabelVersion, expectedVersion),
 			})
 		})
 
+		t.Run("reading_quoted_Flatcar_board_from_etc_os_release_file", func(t *testing.T) {
+			t.Parallel()
+
+			// This is currently the only way to check that agent has read /etc/os-release file.
+			assertNodeProperty(contextWithTimeout(t, agentRunTimeLimit), t, &assertNodePropertyContext{
+				done:   done,
+				config: testConfig,
+				testF:  assertNodeLabelValue(constants.LabelBoard, expectedBoard),
+			})
+		})
+
 		t.Run("reading_Flatcar_group_from_update_configuration_file_in_usr_directory", func(t *testing.T) {
 			t.Parallel()
 
 			// This is currently the only way to check that agent
 			// read /etc/flatcar/update.conf or /usr/share/flatcar/update.conf.
-			assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			assertNodeProperty(contextWithTimeout(t, agentRunTimeLimit), t, &assertNodePropertyContext{
 				done:   done,
 				config: testConfig,
 				testF:  assertNodeLabelValue(constants.LabelGroup, expectedGroup),
@@ -302,6 +385,16 @@ func Test_Running_agent(t *testing.T) {
 					testF:  assertNodeLabelExists(constants.LabelVersion),
 				})
 			})
+
+			t.Run("setting_Flatcar_board_label", func(t *testing.T) {
+				t.Parallel()
+
+				assertNodeProperty(ctx, t, &assertNodePropertyContext{
+					done:   done,
+					config: testConfig,
+					testF:  assertNodeLabelExists(constants.LabelBoard),
+				})
+			})
 		})
 
 		t.Run("resets_reboot_state_indicators_to_default_values_by", func(t *testing.T) {
@@ -428,6 +521,261 @@ func Test_Running_agent(t *testing.T) {
 		}
 	})
 
+	t.Run("records_time_and_version_of_finished_reboot", func(t *testing.T) {
+		t.Parallel()
+
+		rebootedNode := nodeMadeUnschedulable()
+		rebootedNode.Annotations[constants.AnnotationRebootInProgress] = constants.True
+
+		testConfig, _, _ := validTestConfig(t, rebootedNode)
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		done := runAgent(ctx, t, testConfig)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationLastRebootVersion, "testVersion"),
+		})
+
+		node, err := testConfig.Clientset.CoreV1().Nodes().Get(ctx, rebootedNode.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed getting node %q: %v", rebootedNode.Name, err)
+		}
+
+		value := node.Annotations[constants.AnnotationLastRebootTime]
+
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			t.Fatalf("Expected annotation %q to be a valid RFC 3339 timestamp, got %q: %v",
+				constants.AnnotationLastRebootTime, value, err)
+		}
+	})
+
+	t.Run("does_not_record_finished_reboot_when_no_reboot_was_in_progress", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, _, _ := validTestConfig(t, testNode())
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		done := runAgent(ctx, t, testConfig)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF: func(t *testing.T, node *corev1.Node) bool {
+				t.Helper()
+
+				if _, ok := node.Annotations[constants.AnnotationLastRebootTime]; ok {
+					t.Fatalf("Unexpected annotation %q", constants.AnnotationLastRebootTime)
+				}
+
+				return node.Annotations[constants.AnnotationRebootInProgress] == constants.False
+			},
+		})
+	})
+
+	t.Run("waits_with_finishing_reboot_until_node_is_healthy_when_configured_with", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Ready_condition_duration", func(t *testing.T) {
+			t.Parallel()
+
+			rebootedNode := nodeMadeUnschedulable()
+			rebootedNode.Annotations[constants.AnnotationRebootInProgress] = constants.True
+
+			testConfig, node, fakeClient := validTestConfig(t, rebootedNode)
+			testConfig.PostRebootReadyDuration = time.Hour
+
+			rebootFinished := make(chan struct{}, 1)
+
+			fakeClient.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				node := patchActionToNode(t, action)
+				if node.Annotations[constants.AnnotationRebootInProgress] != constants.False {
+					return false, nil, nil
+				}
+
+				select {
+				case rebootFinished <- struct{}{}:
+				default:
+				}
+
+				return false, nil, nil
+			})
+
+			ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+			done := runAgent(ctx, t, testConfig)
+
+			select {
+			case <-rebootFinished:
+				t.Fatalf("Reboot finished before node became healthy")
+			case err := <-done:
+				t.Fatalf("Unexpected agent error: %v", err)
+			case <-time.After(time.Second):
+			}
+
+			nodesClient := testConfig.Clientset.CoreV1().Nodes()
+
+			node.Status.Conditions = []corev1.NodeCondition{
+				{
+					Type:               corev1.NodeReady,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+				},
+			}
+
+			if _, err := nodesClient.UpdateStatus(ctx, node, metav1.UpdateOptions{}); err != nil {
+				t.Fatalf("Failed updating node status: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				t.Fatal("Timed out waiting for reboot to finish")
+			case <-rebootFinished:
+			}
+		})
+
+		t.Run("network_check", func(t *testing.T) {
+			t.Parallel()
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Failed listening: %v", err)
+			}
+
+			t.Cleanup(func() {
+				if err := listener.Close(); err != nil {
+					t.Logf("Failed closing listener: %v", err)
+				}
+			})
+
+			rebootedNode := nodeMadeUnschedulable()
+			rebootedNode.Annotations[constants.AnnotationRebootInProgress] = constants.True
+			rebootedNode.Status.Conditions = []corev1.NodeCondition{
+				{
+					Type:               corev1.NodeReady,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.Now(),
+				},
+				{
+					Type:    corev1.NodeNetworkUnavailable,
+					Status:  corev1.ConditionTrue,
+					Message: "Routes not configured yet",
+				},
+			}
+
+			testConfig, node, fakeClient := validTestConfig(t, rebootedNode)
+			testConfig.PostRebootNetworkCheck = true
+			testConfig.PodNetworkProbeAddress = listener.Addr().String()
+
+			rebootFinished := make(chan struct{}, 1)
+
+			fakeClient.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				node := patchActionToNode(t, action)
+				if node.Annotations[constants.AnnotationRebootInProgress] != constants.False {
+					return false, nil, nil
+				}
+
+				select {
+				case rebootFinished <- struct{}{}:
+				default:
+				}
+
+				return false, nil, nil
+			})
+
+			ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+			done := runAgent(ctx, t, testConfig)
+
+			select {
+			case <-rebootFinished:
+				t.Fatalf("Reboot finished before node network became available")
+			case err := <-done:
+				t.Fatalf("Unexpected agent error: %v", err)
+			case <-time.After(time.Second):
+			}
+
+			node.Status.Conditions[1].Status = corev1.ConditionFalse
+
+			if _, err := testConfig.Clientset.CoreV1().Nodes().UpdateStatus(ctx, node, metav1.UpdateOptions{}); err != nil {
+				t.Fatalf("Failed updating node status: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				t.Fatal("Timed out waiting for reboot to finish")
+			case <-rebootFinished:
+			}
+		})
+
+		t.Run("probes", func(t *testing.T) {
+			t.Parallel()
+
+			healthyMutex := &sync.Mutex{}
+			healthy := false
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				healthyMutex.Lock()
+				defer healthyMutex.Unlock()
+
+				if !healthy {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			rebootedNode := nodeMadeUnschedulable()
+			rebootedNode.Annotations[constants.AnnotationRebootInProgress] = constants.True
+
+			testConfig, _, fakeClient := validTestConfig(t, rebootedNode)
+			testConfig.PostRebootProbes = []string{
+				server.URL,
+				"tcp://" + server.Listener.Addr().String(),
+			}
+
+			rebootFinished := make(chan struct{}, 1)
+
+			fakeClient.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				node := patchActionToNode(t, action)
+				if node.Annotations[constants.AnnotationRebootInProgress] != constants.False {
+					return false, nil, nil
+				}
+
+				select {
+				case rebootFinished <- struct{}{}:
+				default:
+				}
+
+				return false, nil, nil
+			})
+
+			ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+			done := runAgent(ctx, t, testConfig)
+
+			select {
+			case <-rebootFinished:
+				t.Fatalf("Reboot finished before node became healthy")
+			case err := <-done:
+				t.Fatalf("Unexpected agent error: %v", err)
+			case <-time.After(time.Second):
+			}
+
+			healthyMutex.Lock()
+			healthy = true
+			healthyMutex.Unlock()
+
+			select {
+			case <-ctx.Done():
+				t.Fatal("Timed out waiting for reboot to finish")
+			case <-rebootFinished:
+			}
+		})
+	})
+
 	t.Run("leaves_node_unschedulable_if_it_was_made_unschedulable_by_external_source", func(t *testing.T) {
 		t.Parallel()
 
@@ -476,6 +824,81 @@ func Test_Running_agent(t *testing.T) {
 		}
 	})
 
+	t.Run("leaves_node_unschedulable_after_reboot_when_configured_using", func(t *testing.T) {
+		t.Parallel()
+
+		cases := map[string]struct {
+			keepNodeCordoned    bool
+			uncordonAnnotations map[string]string
+		}{
+			"agent_configuration": {
+				keepNodeCordoned: true,
+			},
+			"node_annotation": {
+				uncordonAnnotations: map[string]string{constants.AnnotationUncordonAfterReboot: constants.False},
+			},
+		}
+
+		for name, testCase := range cases {
+			testCase := testCase
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				rebootedNode := nodeMadeUnschedulable()
+				rebootedNode.Annotations[constants.AnnotationRebootInProgress] = constants.True
+
+				for key, value := range testCase.uncordonAnnotations {
+					rebootedNode.Annotations[key] = value
+				}
+
+				testConfig, _, _ := validTestConfig(t, rebootedNode)
+				testConfig.KeepNodeCordonedAfterReboot = testCase.keepNodeCordoned
+
+				watchStatusStarted := make(chan struct{})
+
+				testConfig.StatusReceiver = &mockStatusReceiver{
+					receiveStatusesF: func(ch chan<- updateengine.Status, _ <-chan struct{}) {
+						watchStatusStarted <- struct{}{}
+					},
+				}
+
+				ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+				done := runAgent(ctx, t, testConfig)
+
+				assertNodeProperty(ctx, t, &assertNodePropertyContext{
+					done:   done,
+					config: testConfig,
+					testF:  assertNodeLabelValue(constants.LabelRebootNeeded, constants.False),
+				})
+
+				notOkToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), rebootedNode.Name)
+
+				select {
+				case <-ctx.Done():
+					t.Fatal("Timed out waiting for agent to start watching update status")
+				case <-watchStatusStarted:
+				}
+
+				node, err := testConfig.Clientset.CoreV1().Nodes().Get(ctx, rebootedNode.Name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("Failed getting node: %v", err)
+				}
+
+				if !node.Spec.Unschedulable {
+					t.Fatalf("Expected node to remain unschedulable")
+				}
+
+				// So node is not made schedulable when agent restarts.
+				if value := node.Annotations[constants.AnnotationAgentMadeUnschedulable]; value != constants.False {
+					t.Fatalf("Expected annotation %q to be %q, got %q",
+						constants.AnnotationAgentMadeUnschedulable, constants.False, value)
+				}
+			})
+		}
+	})
+
 	t.Run("after_getting_not_ok_to_reboot_annotation", func(t *testing.T) {
 		t.Parallel()
 
@@ -516,308 +939,2297 @@ func Test_Running_agent(t *testing.T) {
 		})
 	})
 
-	t.Run("retries_updating_node_status_from_update_engine_until_it_succeeds", func(t *testing.T) {
+	t.Run("records_time_when_reboot_was_requested", func(t *testing.T) {
 		t.Parallel()
 
-		testConfig, _, fakeClient := validTestConfig(t, testNode())
+		testConfig, node, _ := validTestConfig(t, testNode())
 
-		firstCall := true
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
 
-		fakeClient.PrependReactor("update", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
-			node := updateActionToNode(t, action)
+		done := runAgent(ctx, t, testConfig)
 
-			if _, ok := node.Annotations[constants.AnnotationStatus]; ok {
-				if firstCall {
-					firstCall = false
+		notOkToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
 
-					return true, nil, fmt.Errorf(t.Name())
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF: func(t *testing.T, node *corev1.Node) bool {
+				t.Helper()
+
+				value, ok := node.Annotations[constants.AnnotationRebootNeededSince]
+				if !ok {
+					return false
 				}
-			}
 
-			return false, nil, nil
+				if _, err := time.Parse(time.RFC3339, value); err != nil {
+					t.Fatalf("Expected annotation %q to be a valid RFC 3339 timestamp, got %q: %v",
+						constants.AnnotationRebootNeededSince, value, err)
+				}
+
+				return true
+			},
 		})
+	})
 
-		ctx := contextWithTimeout(t, agentRunTimeLimit)
+	t.Run("publishes_time_of_last_update_check_reported_by_update_engine_by", func(t *testing.T) {
+		t.Parallel()
 
-		assertNodeProperty(ctx, t, &assertNodePropertyContext{
-			done:   runAgent(ctx, t, testConfig),
-			config: testConfig,
-			testF:  assertNodeAnnotationValue(constants.AnnotationStatus, updateengine.UpdateStatusUpdatedNeedReboot),
-		})
-	})
-
-	t.Run("skips_node_status_update_when_update_engine_status_did_not_change", func(t *testing.T) {
-		t.Parallel()
+		testConfig, node, _ := validTestConfig(t, testNode())
 
-		testConfig, node, fakeClient := validTestConfig(t, testNode())
+		registry := prometheus.NewRegistry()
+		testConfig.MetricsRegisterer = registry
 
-		status := updateengine.Status{
-			CurrentOperation: updateengine.UpdateStatusUpdatedNeedReboot,
-		}
+		lastCheckedTime := int64(1501621307)
 
 		testConfig.StatusReceiver = &mockStatusReceiver{
 			receiveStatusesF: func(ch chan<- updateengine.Status, _ <-chan struct{}) {
-				status.NewVersion = "foo"
-				ch <- status
-				status.NewVersion = "bar"
-				ch <- status
+				ch <- updateengine.Status{
+					CurrentOperation: updateengine.UpdateStatusIdle,
+					LastCheckedTime:  lastCheckedTime,
+				}
 			},
 		}
 
-		newVersionReported := make(chan string, 2)
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
 
-		fakeClient.PrependReactor("update", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
-			node := updateActionToNode(t, action)
+		done := runAgent(ctx, t, testConfig)
 
-			if _, ok := node.Annotations[constants.AnnotationStatus]; ok {
-				newVersionReported <- node.Annotations[constants.AnnotationNewVersion]
-			}
+		notOkToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
 
-			return false, nil, nil
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationLastCheckedTime, fmt.Sprint(lastCheckedTime)),
 		})
 
-		ctx := contextWithTimeout(t, agentRunTimeLimit)
+		t.Run("recording_time_of_last_status_in_annotation", func(t *testing.T) {
+			t.Parallel()
 
-		runAgent(ctx, t, testConfig)
+			assertNodeProperty(ctx, t, &assertNodePropertyContext{
+				done:   done,
+				config: testConfig,
+				testF: func(t *testing.T, node *corev1.Node) bool {
+					t.Helper()
 
-		notOkToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+					value, ok := node.Annotations[constants.AnnotationLastStatusTime]
+					if !ok {
+						return false
+					}
 
-		lastNewVersion := ""
+					if _, err := time.Parse(time.RFC3339, value); err != nil {
+						t.Fatalf("Expected annotation %q to be a valid RFC 3339 timestamp, got %q: %v",
+							constants.AnnotationLastStatusTime, value, err)
+					}
 
-		select {
-		case <-ctx.Done():
-			t.Fatalf("Timed out waiting for first node status update")
-		case newVersion := <-newVersionReported:
-			lastNewVersion = newVersion
+					return true
+				},
+			})
+		})
+
+		t.Run("exposing_metrics", func(t *testing.T) {
+			t.Parallel()
+
+			metricName := "flatcar_linux_update_agent_last_update_check_timestamp_seconds"
+
+			if v := gaugeValue(t, registry, metricName); v != float64(lastCheckedTime) {
+				t.Fatalf("Expected metric %q to be %d, got %v", metricName, lastCheckedTime, v)
+			}
+
+			metricName = "flatcar_linux_update_agent_last_status_timestamp_seconds"
+
+			if v := gaugeValue(t, registry, metricName); v == 0 {
+				t.Fatalf("Expected metric %q to be set", metricName)
+			}
+		})
+	})
+
+	t.Run("changes_update_channel_to_one_requested_using_node_annotation_when_configured", func(t *testing.T) {
+		t.Parallel()
+
+		node := testNode()
+		node.Annotations[constants.AnnotationChannel] = "stable"
+
+		testConfig, _, _ := validTestConfig(t, node)
+		testConfig.ReconcileChannel = true
+
+		channelManager := &mockChannelManager{
+			mockStatusReceiver: rebootNeededStatusReceiver(),
+			channel:            "beta",
 		}
 
-		select {
-		case newVersion := <-newVersionReported:
-			if newVersion != lastNewVersion {
-				t.Fatalf("Unexpected node status update")
+		testConfig.StatusReceiver = channelManager
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		done := runAgent(ctx, t, testConfig)
+
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+
+		for channel, _ := channelManager.GetChannel(); channel != "stable"; channel, _ = channelManager.GetChannel() {
+			select {
+			case err := <-done:
+				t.Fatalf("Agent stopped prematurely: %v", err)
+			case <-ctx.Done():
+				t.Fatalf("Timed out waiting for update channel to be changed, got %q", channel)
+			case <-ticker.C:
 			}
-		case <-contextWithTimeout(t, 500*time.Millisecond).Done():
 		}
 	})
 
-	t.Run("after_getting_ok_to_reboot_annotation", func(t *testing.T) {
+	t.Run("maintains_reboot_required_node_condition_when_configured", func(t *testing.T) {
 		t.Parallel()
 
-		t.Run("marks_node_as_unschedulable_if_not_done_before_by", func(t *testing.T) {
+		t.Run("which_is_false_when_no_update_is_pending", func(t *testing.T) {
 			t.Parallel()
 
-			testConfig, node, fakeClient := validTestConfig(t, testNode())
-
-			nodeUpdatedAsUnschedulable := notifyOnNodeUnschedulableUpdate(t, fakeClient)
+			testConfig, _, _ := validTestConfig(t, testNode())
+			testConfig.RebootRequiredCondition = true
+			testConfig.StatusReceiver = &mockStatusReceiver{}
 
 			ctx := contextWithTimeout(t, agentRunTimeLimit)
 
-			done := runAgent(ctx, t, testConfig)
-
 			assertNodeProperty(ctx, t, &assertNodePropertyContext{
-				done:   done,
+				done:   runAgent(ctx, t, testConfig),
 				config: testConfig,
-				testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+				testF:  assertRebootRequiredCondition(corev1.ConditionFalse, agent.ConditionReasonNoPendingUpdate),
 			})
+		})
 
-			okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+		t.Run("which_is_true_when_downloaded_update_requires_reboot", func(t *testing.T) {
+			t.Parallel()
 
-			t.Run("setting_unschedulable_field_on_Node_object", func(t *testing.T) {
-				t.Parallel()
+			node := testNode()
+			node.Status.Conditions = []corev1.NodeCondition{
+				{
+					Type:   corev1.NodeReady,
+					Status: corev1.ConditionTrue,
+				},
+			}
 
-				select {
-				case <-ctx.Done():
-					t.Fatal("Timed out waiting for node being marked as unschedulable")
-				case <-nodeUpdatedAsUnschedulable:
-				}
-			})
+			testConfig, _, _ := validTestConfig(t, node)
+			testConfig.RebootRequiredCondition = true
 
-			t.Run("setting_reboot_in_progress_annotation_to_true", func(t *testing.T) {
-				t.Parallel()
+			ctx := contextWithTimeout(t, agentRunTimeLimit)
 
-				assertNodeProperty(ctx, t, &assertNodePropertyContext{
-					done:   done,
-					config: testConfig,
-					testF:  assertNodeAnnotationValue(constants.AnnotationRebootInProgress, constants.True),
-				})
+			assertNodeProperty(ctx, t, &assertNodePropertyContext{
+				done:   runAgent(ctx, t, testConfig),
+				config: testConfig,
+				testF:  assertRebootRequiredCondition(corev1.ConditionTrue, agent.ConditionReasonUpdateDownloaded),
 			})
 
-			t.Run("setting_agent_made_unschedulable_annotations_to_true", func(t *testing.T) {
-				t.Parallel()
+			updatedNode, err := testConfig.Clientset.CoreV1().Nodes().Get(ctx, node.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed getting Node object %q: %v", node.Name, err)
+			}
 
-				assertNodeProperty(ctx, t, &assertNodePropertyContext{
-					done:   done,
-					config: testConfig,
-					testF:  assertNodeAnnotationValue(constants.AnnotationAgentMadeUnschedulable, constants.True),
-				})
-			})
+			if c := len(updatedNode.Status.Conditions); c != 2 {
+				t.Fatalf("Expected other node conditions to be preserved, got %v", updatedNode.Status.Conditions)
+			}
 		})
 	})
 
-	t.Run("skips_marking_node_as_unschedulable_if_node_is_already_unschedulable", func(t *testing.T) {
+	t.Run("exposes_progress_and_size_of_pending_update_reported_by_update_engine", func(t *testing.T) {
 		t.Parallel()
 
-		nodeUpdatedAsUnschedulable := make(chan struct{})
-		podsListed := make(chan struct{})
+		testConfig, _, _ := validTestConfig(t, testNode())
 
-		nodeUnschedulable := testNode()
-		nodeUnschedulable.Spec.Unschedulable = true
+		registry := prometheus.NewRegistry()
+		testConfig.MetricsRegisterer = registry
 
-		testConfig, _, fakeClient := validTestConfig(t, nodeUnschedulable)
+		testConfig.StatusReceiver = &mockStatusReceiver{
+			receiveStatusesF: func(ch chan<- updateengine.Status, _ <-chan struct{}) {
+				ch <- updateengine.Status{
+					CurrentOperation: updateengine.UpdateStatusDownloading,
+					Progress:         0.5,
+					NewVersion:       "1.2.3",
+					NewSize:          1024,
+				}
+			},
+		}
 
-		fakeClient.PrependReactor("update", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
-			node := updateActionToNode(t, action)
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
 
-			if _, ok := node.Annotations[constants.AnnotationAgentMadeUnschedulable]; ok {
-				nodeUpdatedAsUnschedulable <- struct{}{}
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationNewVersion, "1.2.3"),
+		})
+
+		expectedValues := map[string]float64{
+			"flatcar_linux_update_agent_update_progress_ratio":     0.5,
+			"flatcar_linux_update_agent_pending_update_size_bytes": 1024,
+		}
+
+		for metricName, expectedValue := range expectedValues {
+			if v := gaugeValue(t, registry, metricName); v != expectedValue {
+				t.Fatalf("Expected metric %q to be %v, got %v", metricName, expectedValue, v)
 			}
+		}
+	})
 
-			return false, nil, nil
-		})
+	t.Run("exposes_current_operation_reported_by_update_engine", func(t *testing.T) {
+		t.Parallel()
 
-		fakeClient.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
-			podsListed <- struct{}{}
+		testConfig, _, _ := validTestConfig(t, testNode())
 
-			return false, nil, nil
-		})
+		registry := prometheus.NewRegistry()
+		testConfig.MetricsRegisterer = registry
+
+		testConfig.StatusReceiver = &mockStatusReceiver{
+			receiveStatusesF: func(ch chan<- updateengine.Status, _ <-chan struct{}) {
+				ch <- updateengine.Status{CurrentOperation: updateengine.UpdateStatusCheckingForUpdate}
+				ch <- updateengine.Status{CurrentOperation: updateengine.UpdateStatusDownloading, NewVersion: "1.2.3"}
+			},
+		}
 
 		ctx := contextWithTimeout(t, agentRunTimeLimit)
 
 		assertNodeProperty(ctx, t, &assertNodePropertyContext{
 			done:   runAgent(ctx, t, testConfig),
 			config: testConfig,
-			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+			testF:  assertNodeAnnotationValue(constants.AnnotationNewVersion, "1.2.3"),
 		})
 
-		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), nodeUnschedulable.Name)
+		metricName := "fluo_update_engine_status"
 
-		select {
-		case <-ctx.Done():
-			t.Fatalf("Timed out waiting for pods listing operation")
-		case <-nodeUpdatedAsUnschedulable:
-			t.Fatalf("Expected node to not be marked as unschedulable")
-		case <-podsListed:
+		metricFamilies, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("Failed gathering metrics: %v", err)
+		}
+
+		for _, metricFamily := range metricFamilies {
+			if metricFamily.GetName() != metricName {
+				continue
+			}
+
+			if c := len(metricFamily.GetMetric()); c != 1 {
+				t.Fatalf("Expected only current operation to be exposed, got %d operations", c)
+			}
+		}
+
+		metric := metricValue(t, registry, metricName)
+
+		if v := metric.GetGauge().GetValue(); v != 1 {
+			t.Fatalf("Expected metric %q to be 1, got %v", metricName, v)
+		}
+
+		if operation := metric.GetLabel()[0].GetValue(); operation != updateengine.UpdateStatusDownloading {
+			t.Fatalf("Expected operation %q, got %q", updateengine.UpdateStatusDownloading, operation)
 		}
 	})
 
-	t.Run("after_marking_node_as_unschedulable", func(t *testing.T) {
+	t.Run("exposes_number_of_reconnects_to_update_source_when_supported_by_status_receiver", func(t *testing.T) {
 		t.Parallel()
 
-		t.Run("without_exceeding_pod_deletion_grace_period", func(t *testing.T) {
-			t.Parallel()
+		testConfig, _, _ := validTestConfig(t, testNode())
 
-			expectedPodsRemovedNames := map[string]struct{}{"pod-to-be-removed": {}}
-			podsToCreate := []*corev1.Pod{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:            "pod-to-be-removed",
-						Namespace:       "default",
-						OwnerReferences: testPodControllerReference(),
-					},
-					Spec: corev1.PodSpec{
-						NodeName: testNode().Name,
-					},
-				},
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:            "pod-filtered-from-removal-because-of-namespace",
-						Namespace:       "kube-system",
-						OwnerReferences: testPodControllerReference(),
-					},
-					Spec: corev1.PodSpec{
-						NodeName: testNode().Name,
-					},
-				},
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:            "pod-on-another-node",
-						Namespace:       "another",
-						OwnerReferences: testPodControllerReference(),
-					},
-					Spec: corev1.PodSpec{
-						NodeName: "baz",
-					},
-				},
-			}
+		registry := prometheus.NewRegistry()
+		testConfig.MetricsRegisterer = registry
 
-			fakeClient := fake.NewSimpleClientset(podsToCreate[0], podsToCreate[1], podsToCreate[2], testNode())
-			addEvictionSupport(t, fakeClient)
+		testConfig.StatusReceiver = &mockReconnectCounter{
+			mockStatusReceiver: &mockStatusReceiver{},
+			reconnects:         3,
+		}
 
-			testConfig, node, _ := validTestConfig(t, testNode())
-			testConfig.Clientset = fakeClient
-			testConfig.PodDeletionGracePeriod = time.Hour
+		if _, err := agent.New(testConfig); err != nil {
+			t.Fatalf("Unexpected error creating new agent: %v", err)
+		}
 
-			expectedPodRemovedMutex := &sync.Mutex{}
-			expectedPodRemoved := len(expectedPodsRemovedNames)
-			rebootTriggerred := make(chan bool, 1)
+		metricName := "flatcar_linux_update_agent_dbus_reconnects_total"
 
-			testConfig.Rebooter = &mockRebooter{
-				rebootF: func(auth bool) {
-					expectedPodRemovedMutex.Lock()
-					rebootTriggerred <- expectedPodRemoved < 0
-					expectedPodRemovedMutex.Unlock()
-				},
-			}
+		if v := metricValue(t, registry, metricName).GetCounter().GetValue(); v != 3 {
+			t.Fatalf("Expected metric %q to be 3, got %v", metricName, v)
+		}
+	})
 
-			nodeUpdatedAsUnschedulable := notifyOnNodeUnschedulableUpdate(t, &fakeClient.Fake)
+	t.Run("annotates_whether_pending_reboot_applies_security_update_according_to_configured_feed", func(t *testing.T) {
+		t.Parallel()
 
-			fakeClient.PrependReactor("list", "pods", listPodsWithFieldSelector(podsToCreate))
+		cases := map[string]string{
+			"2.0.0": constants.True,
+			"1.0.0": constants.False,
+		}
 
-			allExpectedPodsScheduledForRemoval := make(chan struct{}, 2)
+		for version, expectedValue := range cases {
+			version, expectedValue := version, expectedValue
 
-			testPodRemoval := func(podName string) bool {
-				t.Helper()
+			t.Run(version, func(t *testing.T) {
+				t.Parallel()
 
-				if _, ok := expectedPodsRemovedNames[podName]; !ok {
-					t.Fatalf("Unexpected pod %q removed", podName)
-				}
+				feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					fmt.Fprint(w, `{"2.0.0": ["CVE-2023-1234"]}`)
+				}))
+				t.Cleanup(feed.Close)
 
-				expectedPodRemovedMutex.Lock()
-				defer expectedPodRemovedMutex.Unlock()
-				expectedPodRemoved--
+				testConfig, node, _ := validTestConfig(t, testNode())
+				testConfig.SecurityFeedURL = feed.URL
+				testConfig.StatusReceiver = &mockStatusReceiver{
+					receiveStatusesF: func(ch chan<- updateengine.Status, _ <-chan struct{}) {
+						ch <- updateengine.Status{
+							CurrentOperation: updateengine.UpdateStatusUpdatedNeedReboot,
+							NewVersion:       version,
+						}
+					},
+				}
 
-				if expectedPodRemoved == 0 {
-					allExpectedPodsScheduledForRemoval <- struct{}{}
-					allExpectedPodsScheduledForRemoval <- struct{}{}
+				ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+				done := runAgent(ctx, t, testConfig)
+
+				notOkToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+				assertNodeProperty(ctx, t, &assertNodePropertyContext{
+					done:   done,
+					config: testConfig,
+					testF: func(t *testing.T, node *corev1.Node) bool {
+						t.Helper()
+
+						if node.Annotations[constants.AnnotationRebootNeeded] != constants.True {
+							return false
+						}
+
+						if value := node.Annotations[constants.AnnotationSecurityUpdate]; value != expectedValue {
+							t.Fatalf("Expected annotation %q to be %q, got %q",
+								constants.AnnotationSecurityUpdate, expectedValue, value)
+						}
+
+						return true
+					},
+				})
+			})
+		}
+	})
+
+	t.Run("annotates_node_as_paused_while_pause_file_exists", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.PauseFile = "/etc/flatcar/fluo-pause"
+
+		pauseFile := filepath.Join(testConfig.HostFilesPrefix, testConfig.PauseFile)
+
+		createTestFiles(t, map[string]string{testConfig.PauseFile: ""}, testConfig.HostFilesPrefix)
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		done := runAgent(ctx, t, testConfig)
+
+		notOkToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationAgentRebootPaused, constants.True),
+		})
+
+		if err := os.Remove(pauseFile); err != nil {
+			t.Fatalf("Failed removing pause file: %v", err)
+		}
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF: func(t *testing.T, node *corev1.Node) bool {
+				t.Helper()
+
+				return node.Annotations[constants.AnnotationAgentRebootPaused] == constants.False
+			},
+		})
+	})
+
+	t.Run("triggers_update_check_and_removes_annotation_when_update_check_is_requested", func(t *testing.T) {
+		t.Parallel()
+
+		node := testNode()
+		node.Annotations[constants.AnnotationCheckUpdateNow] = constants.True
+
+		testConfig, _, _ := validTestConfig(t, node)
+
+		updateCheckTriggered := make(chan struct{}, 1)
+
+		testConfig.StatusReceiver = &mockUpdateChecker{
+			mockStatusReceiver: rebootNeededStatusReceiver(),
+			attemptUpdateF: func() error {
+				select {
+				case updateCheckTriggered <- struct{}{}:
+				default:
 				}
 
-				return expectedPodRemoved >= 0
-			}
+				return nil
+			},
+		}
 
-			fakeClient.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
-				deleteAction, ok := action.(k8stesting.DeleteActionImpl)
-				if !ok {
-					del := k8stesting.DeleteActionImpl{}
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
 
-					return true, nil, fmt.Errorf("unexpected action, expected %T, got %T", del, action)
+		done := runAgent(ctx, t, testConfig)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for update check to be triggered")
+		case <-updateCheckTriggered:
+		}
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF: func(t *testing.T, node *corev1.Node) bool {
+				t.Helper()
+
+				_, ok := node.Annotations[constants.AnnotationCheckUpdateNow]
+
+				return !ok
+			},
+		})
+	})
+
+	t.Run("triggers_update_check_without_waiting_for_poll_interval_when_update_check_is_requested", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.PollInterval = time.Hour
+
+		updateCheckTriggered := make(chan struct{}, 1)
+
+		testConfig.StatusReceiver = &mockUpdateChecker{
+			mockStatusReceiver: rebootNeededStatusReceiver(),
+			attemptUpdateF: func() error {
+				select {
+				case updateCheckTriggered <- struct{}{}:
+				default:
 				}
 
-				return testPodRemoval(deleteAction.Name), nil, nil
+				return nil
+			},
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		runAgent(ctx, t, testConfig)
+
+		err := k8sutil.UpdateNodeRetry(ctx, testConfig.Clientset.CoreV1().Nodes(), node.Name, func(node *corev1.Node) {
+			node.Annotations[constants.AnnotationCheckUpdateNow] = constants.True
+		})
+		if err != nil {
+			t.Fatalf("Failed requesting update check: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for update check to be triggered")
+		case <-updateCheckTriggered:
+		}
+	})
+
+	t.Run("resets_update_status_and_removes_annotation_when_status_reset_is_requested", func(t *testing.T) {
+		t.Parallel()
+
+		node := testNode()
+		node.Annotations[constants.AnnotationResetUpdateStatus] = constants.True
+		node.Annotations[constants.AnnotationRebootNeededSince] = time.Now().UTC().Format(time.RFC3339)
+
+		testConfig, _, _ := validTestConfig(t, node)
+
+		statusReset := make(chan struct{}, 1)
+
+		testConfig.StatusReceiver = &mockStatusResetter{
+			mockStatusReceiver: &mockStatusReceiver{},
+			resetStatusF: func() error {
+				select {
+				case statusReset <- struct{}{}:
+				default:
+				}
+
+				return nil
+			},
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		done := runAgent(ctx, t, testConfig)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for update status to be reset")
+		case <-statusReset:
+		}
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF: func(t *testing.T, node *corev1.Node) bool {
+				t.Helper()
+
+				_, requested := node.Annotations[constants.AnnotationResetUpdateStatus]
+				_, rebootNeededSince := node.Annotations[constants.AnnotationRebootNeededSince]
+
+				return !requested && !rebootNeededSince &&
+					node.Annotations[constants.AnnotationRebootNeeded] == constants.False &&
+					node.Labels[constants.LabelRebootNeeded] == constants.False
+			},
+		})
+	})
+
+	t.Run("rolls_back_and_indicates_reboot_is_needed_when_rollback_is_requested", func(t *testing.T) {
+		t.Parallel()
+
+		node := testNode()
+		node.Annotations[constants.AnnotationRollback] = constants.True
+
+		testConfig, _, _ := validTestConfig(t, node)
+
+		rollbackAttempted := make(chan struct{}, 1)
+
+		testConfig.StatusReceiver = &mockRollbacker{
+			mockStatusReceiver: &mockStatusReceiver{},
+			attemptRollbackF: func() error {
+				select {
+				case rollbackAttempted <- struct{}{}:
+				default:
+				}
+
+				return nil
+			},
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		done := runAgent(ctx, t, testConfig)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for rollback to be attempted")
+		case <-rollbackAttempted:
+		}
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF: func(t *testing.T, node *corev1.Node) bool {
+				t.Helper()
+
+				_, requested := node.Annotations[constants.AnnotationRollback]
+
+				return !requested && node.Annotations[constants.AnnotationRebootNeeded] == constants.True &&
+					node.Labels[constants.LabelRebootNeeded] == constants.True
+			},
+		})
+	})
+
+	t.Run("indicates_failed_update_attempt_when_update_engine_reports_error", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, _, _ := validTestConfig(t, testNode())
+		testConfig.StatusReceiver = &mockStatusReceiver{
+			receiveStatusesF: func(ch chan<- updateengine.Status, _ <-chan struct{}) {
+				ch <- updateengine.Status{
+					CurrentOperation: updateengine.UpdateStatusReportingErrorEvent,
+				}
+			},
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF: func(t *testing.T, node *corev1.Node) bool {
+				t.Helper()
+
+				if node.Annotations[constants.AnnotationUpdateFailed] != constants.True {
+					return false
+				}
+
+				if _, ok := node.Annotations[constants.AnnotationLastUpdateFailureTime]; !ok {
+					t.Fatalf("Expected annotation %q to be set", constants.AnnotationLastUpdateFailureTime)
+				}
+
+				return true
+			},
+		})
+	})
+
+	t.Run("records_error_code_of_failed_update_attempt_when_reported_by_update_source", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, _, _ := validTestConfig(t, testNode())
+
+		registry := prometheus.NewRegistry()
+		testConfig.MetricsRegisterer = registry
+
+		testConfig.StatusReceiver = &mockLastAttemptErrorGetter{
+			mockStatusReceiver: &mockStatusReceiver{
+				receiveStatusesF: func(ch chan<- updateengine.Status, _ <-chan struct{}) {
+					ch <- updateengine.Status{
+						CurrentOperation: updateengine.UpdateStatusReportingErrorEvent,
+					}
+				},
+			},
+			code: 10,
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationLastUpdateErrorCode, "10"),
+		})
+
+		metric := metricValue(t, registry, "flatcar_linux_update_agent_update_attempts_failed_total")
+
+		if v := metric.GetCounter().GetValue(); v != 1 {
+			t.Fatalf("Expected 1 failed update attempt, got %v", v)
+		}
+
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == "error_code" && label.GetValue() != "10" {
+				t.Fatalf("Expected error code %q, got %q", "10", label.GetValue())
+			}
+		}
+	})
+
+	t.Run("after_rebooting_to_apply_update_indicates_whether_node_booted_from_the_same_partition", func(t *testing.T) {
+		t.Parallel()
+
+		cases := map[string]struct {
+			partitionBeforeReboot string
+			expectedRolledBack    string
+		}{
+			"as_rollback_when_partition_did_not_change": {
+				partitionBeforeReboot: agent.UsrPartitionA,
+				expectedRolledBack:    constants.True,
+			},
+			"as_no_rollback_when_partition_changed": {
+				partitionBeforeReboot: agent.UsrPartitionB,
+				expectedRolledBack:    constants.False,
+			},
+		}
+
+		for name, testCase := range cases {
+			testCase := testCase
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				node := testNode()
+				node.Annotations[constants.AnnotationRebootInProgress] = constants.True
+				node.Annotations[constants.AnnotationUsrPartitionBeforeReboot] = testCase.partitionBeforeReboot
+
+				testConfig, _, _ := validTestConfig(t, node)
+
+				createTestFiles(t, map[string]string{
+					"/proc/cmdline": "BOOT_IMAGE=/flatcar/vmlinuz-a mount.usr=/dev/mapper/usr " +
+						"verity.usr=PARTUUID=7130c94a-213a-4e5a-8e26-6cce9662f132 rootflags=rw",
+				}, testConfig.HostFilesPrefix)
+
+				ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+				assertNodeProperty(ctx, t, &assertNodePropertyContext{
+					done:   runAgent(ctx, t, testConfig),
+					config: testConfig,
+					testF: func(t *testing.T, node *corev1.Node) bool {
+						t.Helper()
+
+						if node.Annotations[constants.AnnotationUsrPartition] != agent.UsrPartitionA {
+							return false
+						}
+
+						return assertNodeAnnotationValue(constants.AnnotationRolledBack, testCase.expectedRolledBack)(t, node)
+					},
+				})
 			})
+		}
+	})
 
-			fakeClient.PrependReactor("create", "pods/eviction",
-				func(action k8stesting.Action) (bool, runtime.Object, error) {
-					createAction, ok := action.(k8stesting.CreateActionImpl)
-					if !ok {
-						del := k8stesting.CreateActionImpl{}
+	t.Run("marks_boot_as_successful_using_configured_command_after_reboot", func(t *testing.T) {
+		t.Parallel()
+
+		outputFile := filepath.Join(t.TempDir(), "output")
+
+		node := testNode()
+		node.Annotations[constants.AnnotationRebootInProgress] = constants.True
+
+		testConfig, _, _ := validTestConfig(t, node)
+		testConfig.MarkBootSuccessfulCommand = "echo $FLUO_USR_PARTITION > " + outputFile
+
+		createTestFiles(t, map[string]string{
+			"/proc/cmdline": "verity.usr=PARTUUID=e03dd35c-7c2d-4a47-b3fe-27f15780a57c",
+		}, testConfig.HostFilesPrefix)
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootInProgress, constants.False),
+		})
+
+		output, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatalf("Failed reading output file: %v", err)
+		}
+
+		if expectedOutput := agent.UsrPartitionB + "\n"; string(output) != expectedOutput {
+			t.Fatalf("Expected command output %q, got %q", expectedOutput, string(output))
+		}
+	})
+
+	t.Run("does_not_finish_reboot_when_marking_boot_as_successful_fails", func(t *testing.T) {
+		t.Parallel()
+
+		node := testNode()
+		node.Annotations[constants.AnnotationRebootInProgress] = constants.True
+
+		testConfig, _, _ := validTestConfig(t, node)
+		testConfig.MarkBootSuccessfulCommand = "exit 1"
+
+		if err := getAgentRunningError(t, testConfig); err == nil {
+			t.Fatalf("Expected agent to return an error")
+		}
+
+		updatedNode, err := testConfig.Clientset.CoreV1().Nodes().Get(contextWithDeadline(t), node.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed getting node: %v", err)
+		}
+
+		if v := updatedNode.Annotations[constants.AnnotationRebootInProgress]; v != constants.True {
+			t.Fatalf("Expected annotation %q to remain %q, got %q", constants.AnnotationRebootInProgress, constants.True, v)
+		}
+	})
+
+	t.Run("records_booted_partition_when_rebooting_to_apply_update", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+
+		createTestFiles(t, map[string]string{
+			"/proc/cmdline": "verity.usr=PARTUUID=e03dd35c-7c2d-4a47-b3fe-27f15780a57c",
+		}, testConfig.HostFilesPrefix)
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		done := runAgent(ctx, t, testConfig)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationUsrPartitionBeforeReboot, agent.UsrPartitionB),
+		})
+	})
+
+	t.Run("reboots_when_enough_time_remains_in_configured_reboot_window", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.RebootWindowStart = time.Now().Add(-time.Minute).Format("15:04")
+		testConfig.RebootWindowLength = "1h"
+		testConfig.MinRemainingRebootWindow = 30 * time.Minute
+
+		rebootTriggerred := make(chan bool, 1)
+
+		testConfig.Rebooter = &mockRebooter{
+			rebootF: func(auth bool) {
+				rebootTriggerred <- auth
+			},
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for reboot to be triggered")
+		case <-rebootTriggerred:
+		}
+	})
+
+	t.Run("only_reports_update_status_when_reboots_are_disabled_by_reboot_strategy", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+
+		createTestFiles(t, map[string]string{
+			"/etc/flatcar/update.conf": "GROUP=configuredGroup\nREBOOT_STRATEGY=off",
+		}, testConfig.HostFilesPrefix)
+
+		rebootTriggerred := make(chan bool, 1)
+
+		testConfig.Rebooter = &mockRebooter{
+			rebootF: func(auth bool) {
+				rebootTriggerred <- auth
+			},
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF: func(t *testing.T, node *corev1.Node) bool {
+				t.Helper()
+
+				return node.Annotations[constants.AnnotationRebootStrategy] == "off" &&
+					node.Annotations[constants.AnnotationRebootNeeded] == constants.True
+			},
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-rebootTriggerred:
+			t.Fatalf("Node should not be rebooted when reboots are disabled by reboot strategy")
+		case <-time.After(testConfig.PollInterval * 5):
+		}
+	})
+
+	t.Run("holds_shutdown_inhibitor_lock_until_reboot_is_approved", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.InhibitUnapprovedReboots = true
+
+		lockFiles := make(chan *os.File, 1)
+		rebootTriggerred := make(chan error, 1)
+
+		testConfig.Rebooter = &mockInhibitingRebooter{
+			mockRebooter: &mockRebooter{
+				rebootF: func(bool) {
+					lockFile := <-lockFiles
+
+					_, err := lockFile.Stat()
+					rebootTriggerred <- err
+				},
+			},
+			inhibitF: func(what, who, why, mode string) (*os.File, error) {
+				if what != "shutdown" || mode != "block" {
+					t.Errorf("Unexpected inhibitor lock %q with mode %q requested", what, mode)
+				}
+
+				lockFile, err := os.Open(testConfig.HostFilesPrefix)
+				if err != nil {
+					t.Errorf("Opening lock file: %v", err)
+				}
+
+				lockFiles <- lockFile
+
+				return lockFile, err
+			},
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for reboot to be triggered")
+		case err := <-rebootTriggerred:
+			if !errors.Is(err, os.ErrClosed) {
+				t.Fatalf("Expected inhibitor lock to be released before reboot, got: %v", err)
+			}
+		}
+	})
+
+	t.Run("schedules_reboot_with_configured_warning_delay_and_wall_message", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.RebootWarningDelay = 5 * time.Minute
+		testConfig.RebootWallMessage = "Test reboot"
+
+		rebootScheduled := make(chan string, 1)
+
+		testConfig.Rebooter = &mockSchedulingRebooter{
+			mockRebooter: &mockRebooter{
+				rebootF: func(bool) {
+					t.Errorf("Reboot should be scheduled instead of rebooting immediately")
+				},
+			},
+			scheduleRebootF: func(message string, delay time.Duration) error {
+				if delay != testConfig.RebootWarningDelay {
+					t.Errorf("Expected reboot to be delayed by %v, got %v", testConfig.RebootWarningDelay, delay)
+				}
+
+				rebootScheduled <- message
+
+				return nil
+			},
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for reboot to be scheduled")
+		case message := <-rebootScheduled:
+			if message != testConfig.RebootWallMessage {
+				t.Fatalf("Expected wall message %q, got %q", testConfig.RebootWallMessage, message)
+			}
+		}
+	})
+
+	t.Run("cancels_scheduled_reboot_and_waits_for_approval_again_when_approval_is_revoked", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.RebootWarningDelay = time.Hour
+
+		rebootScheduled := make(chan struct{}, 2)
+		rebootCancelled := make(chan struct{}, 1)
+
+		testConfig.Rebooter = &mockSchedulingRebooter{
+			mockRebooter: &mockRebooter{},
+			scheduleRebootF: func(string, time.Duration) error {
+				rebootScheduled <- struct{}{}
+
+				return nil
+			},
+			cancelScheduledRebootF: func() (bool, error) {
+				rebootCancelled <- struct{}{}
+
+				return true, nil
+			},
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		done := runAgent(ctx, t, testConfig)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for reboot to be scheduled")
+		case <-rebootScheduled:
+		}
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF: func(t *testing.T, node *corev1.Node) bool {
+				t.Helper()
+
+				_, ok := node.Annotations[constants.AnnotationRebootScheduledTime]
+
+				return ok
+			},
+		})
+
+		notOkToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for scheduled reboot to be cancelled")
+		case <-rebootCancelled:
+		}
+
+		t.Log("Waiting for reboot state of the node to be reset")
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF: func(t *testing.T, node *corev1.Node) bool {
+				t.Helper()
+
+				_, scheduled := node.Annotations[constants.AnnotationRebootScheduledTime]
+
+				return node.Annotations[constants.AnnotationRebootInProgress] == constants.False &&
+					!scheduled && !node.Spec.Unschedulable
+			},
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for reboot to be scheduled again")
+		case err := <-done:
+			t.Fatalf("Agent stopped prematurely: %v", err)
+		case <-rebootScheduled:
+		}
+	})
+
+	t.Run("reboots_immediately_when_scheduling_reboot_fails", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.RebootWarningDelay = 5 * time.Minute
+
+		rebootTriggerred := make(chan bool, 1)
+
+		testConfig.Rebooter = &mockSchedulingRebooter{
+			mockRebooter: &mockRebooter{
+				rebootF: func(auth bool) {
+					rebootTriggerred <- auth
+				},
+			},
+			scheduleRebootF: func(string, time.Duration) error {
+				return fmt.Errorf("test error")
+			},
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for reboot to be triggered")
+		case <-rebootTriggerred:
+		}
+	})
+
+	t.Run("defers_draining_node_when_remaining_reboot_window_is_shorter_than_configured_minimum", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, node, fakeClient := validTestConfig(t, testNode())
+		testConfig.RebootWindowStart = time.Now().Add(-45 * time.Minute).Format("15:04")
+		testConfig.RebootWindowLength = "1h"
+		testConfig.MinRemainingRebootWindow = 30 * time.Minute
+
+		nodeUpdatedAsUnschedulable := notifyOnNodeUnschedulableUpdate(t, fakeClient)
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-nodeUpdatedAsUnschedulable:
+			t.Fatalf("Node should not be drained when remaining reboot window is too short")
+		case <-time.After(testConfig.PollInterval * 5):
+		}
+	})
+
+	t.Run("waits_with_draining_node_until_pause_file_is_removed", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, node, fakeClient := validTestConfig(t, testNode())
+		testConfig.PauseFile = "/etc/flatcar/fluo-pause"
+
+		pauseFile := filepath.Join(testConfig.HostFilesPrefix, testConfig.PauseFile)
+
+		createTestFiles(t, map[string]string{testConfig.PauseFile: ""}, testConfig.HostFilesPrefix)
+
+		nodeUpdatedAsUnschedulable := notifyOnNodeUnschedulableUpdate(t, fakeClient)
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		done := runAgent(ctx, t, testConfig)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		// Simulate operator approving the reboot before pause file has been observed.
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-nodeUpdatedAsUnschedulable:
+			t.Fatalf("Unexpected node marked as unschedulable while pause file exists")
+		case <-time.After(3 * testConfig.PollInterval):
+		}
+
+		if err := os.Remove(pauseFile); err != nil {
+			t.Fatalf("Failed removing pause file: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for node to be marked as unschedulable")
+		case <-nodeUpdatedAsUnschedulable:
+		}
+	})
+
+	t.Run("reboots_without_approval_when_emergency_reboot_is_requested", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.PauseFile = "/etc/flatcar/fluo-pause"
+
+		// Pause file is ignored for emergency reboots.
+		createTestFiles(t, map[string]string{testConfig.PauseFile: ""}, testConfig.HostFilesPrefix)
+
+		rebootTriggerred := make(chan bool, 1)
+
+		testConfig.Rebooter = &mockRebooter{
+			rebootF: func(auth bool) {
+				rebootTriggerred <- auth
+			},
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		done := runAgent(ctx, t, testConfig)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		err := k8sutil.UpdateNodeRetry(ctx, testConfig.Clientset.CoreV1().Nodes(), node.Name, func(node *corev1.Node) {
+			node.Annotations[constants.AnnotationEmergencyReboot] = constants.True
+		})
+		if err != nil {
+			t.Fatalf("Failed requesting emergency reboot: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for reboot to be triggered")
+		case <-rebootTriggerred:
+		}
+	})
+
+	t.Run("removes_emergency_reboot_annotation_after_reboot", func(t *testing.T) {
+		t.Parallel()
+
+		rebootedNode := testNode()
+		rebootedNode.Annotations[constants.AnnotationRebootInProgress] = constants.True
+		rebootedNode.Annotations[constants.AnnotationEmergencyReboot] = constants.True
+
+		testConfig, _, _ := validTestConfig(t, rebootedNode)
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF: func(t *testing.T, node *corev1.Node) bool {
+				t.Helper()
+
+				_, ok := node.Annotations[constants.AnnotationEmergencyReboot]
+
+				return !ok
+			},
+		})
+	})
+
+	t.Run("records_boot_id", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, _, _ := validTestConfig(t, testNode())
+
+		createTestFiles(t, map[string]string{
+			"/proc/sys/kernel/random/boot_id": testBootID + "\n",
+		}, testConfig.HostFilesPrefix)
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationBootID, testBootID),
+		})
+	})
+
+	t.Run("resets_stale_reboot_state_after_node_has_been_rebooted_manually", func(t *testing.T) {
+		t.Parallel()
+
+		manuallyRebootedNode := testNode()
+		manuallyRebootedNode.Annotations[constants.AnnotationBootID] = "previous-boot-id"
+		manuallyRebootedNode.Annotations[constants.AnnotationDrainFailed] = constants.True
+		manuallyRebootedNode.Annotations[constants.AnnotationEmergencyReboot] = constants.True
+
+		testConfig, _, _ := validTestConfig(t, manuallyRebootedNode)
+		testConfig.StatusReceiver = &mockStatusReceiver{}
+
+		createTestFiles(t, map[string]string{
+			"/proc/sys/kernel/random/boot_id": testBootID,
+		}, testConfig.HostFilesPrefix)
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF: func(t *testing.T, node *corev1.Node) bool {
+				t.Helper()
+
+				if !assertNodeAnnotationValue(constants.AnnotationBootID, testBootID)(t, node) {
+					return false
+				}
+
+				if _, ok := node.Annotations[constants.AnnotationEmergencyReboot]; ok {
+					t.Fatalf("Expected annotation %q to be removed", constants.AnnotationEmergencyReboot)
+				}
+
+				if _, ok := node.Annotations[constants.AnnotationLastRebootTime]; ok {
+					t.Fatalf("Expected manual reboot not to be recorded as finished reboot")
+				}
+
+				return assertNodeAnnotationValue(constants.AnnotationDrainFailed, constants.False)(t, node)
+			},
+		})
+	})
+
+	t.Run("resets_reboot_in_progress_when_node_has_not_been_rebooted_since_reboot_has_been_initiated", func(t *testing.T) {
+		t.Parallel()
+
+		notRebootedNode := testNode()
+		notRebootedNode.Annotations[constants.AnnotationBootID] = testBootID
+		notRebootedNode.Annotations[constants.AnnotationRebootInProgress] = constants.True
+		notRebootedNode.Annotations[constants.AnnotationAgentMadeUnschedulable] = constants.True
+		notRebootedNode.Spec.Unschedulable = true
+
+		testConfig, _, _ := validTestConfig(t, notRebootedNode)
+		// Agent would fail when finishing the reboot.
+		testConfig.MarkBootSuccessfulCommand = "exit 1"
+
+		createTestFiles(t, map[string]string{
+			"/proc/sys/kernel/random/boot_id": testBootID,
+		}, testConfig.HostFilesPrefix)
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF: func(t *testing.T, node *corev1.Node) bool {
+				t.Helper()
+
+				if node.Spec.Unschedulable {
+					return false
+				}
+
+				if _, ok := node.Annotations[constants.AnnotationLastRebootTime]; ok {
+					t.Fatalf("Expected reboot not to be recorded as finished")
+				}
+
+				return assertNodeAnnotationValue(constants.AnnotationRebootInProgress, constants.False)(t, node)
+			},
+		})
+	})
+
+	t.Run("indicates_reboot_is_needed_when_reboot_sentinel_file_is_created", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+
+		testConfig.RebootSentinelFile = filepath.Join(t.TempDir(), "reboot-required")
+		testConfig.StatusReceiver = &mockStatusReceiver{
+			receiveStatusesF: func(ch chan<- updateengine.Status, _ <-chan struct{}) {
+				ch <- updateengine.Status{CurrentOperation: updateengine.UpdateStatusIdle}
+			},
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		done := runAgent(ctx, t, testConfig)
+
+		notOkToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationStatus, updateengine.UpdateStatusIdle),
+		})
+
+		if err := os.WriteFile(testConfig.RebootSentinelFile, nil, 0o600); err != nil {
+			t.Fatalf("Failed creating reboot sentinel file: %v", err)
+		}
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF: func(t *testing.T, node *corev1.Node) bool {
+				t.Helper()
+
+				return node.Labels[constants.LabelRebootNeeded] == constants.True &&
+					node.Annotations[constants.AnnotationRebootNeeded] == constants.True
+			},
+		})
+	})
+
+	t.Run("renews_heartbeat_lease_of_the_node_when_heartbeat_is_configured", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.HeartbeatInterval = time.Second
+		testConfig.HeartbeatNamespace = "reboot-coordinator"
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		runAgent(ctx, t, testConfig)
+
+		leases := testConfig.Clientset.CoordinationV1().Leases(testConfig.HeartbeatNamespace)
+
+		for {
+			lease, err := k8sutil.GetHeartbeatLease(ctx, leases, node.Name)
+			if err == nil {
+				if k8sutil.HeartbeatExpired(lease, time.Now()) {
+					t.Fatalf("Expected renewed heartbeat lease, got expired lease: %v", lease.Spec)
+				}
+
+				return
+			}
+
+			if !apierrors.IsNotFound(err) {
+				t.Fatalf("Unexpected error getting heartbeat lease: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				t.Fatalf("Timed out waiting for heartbeat lease")
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	})
+
+	t.Run("retries_updating_node_status_from_update_engine_until_it_succeeds", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, _, fakeClient := validTestConfig(t, testNode())
+
+		firstCall := true
+
+		fakeClient.PrependReactor("update", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			node := updateActionToNode(t, action)
+
+			if _, ok := node.Annotations[constants.AnnotationStatus]; ok {
+				if firstCall {
+					firstCall = false
+
+					return true, nil, fmt.Errorf(t.Name())
+				}
+			}
+
+			return false, nil, nil
+		})
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationStatus, updateengine.UpdateStatusUpdatedNeedReboot),
+		})
+	})
+
+	t.Run("skips_node_status_update_when_update_engine_status_did_not_change", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, node, fakeClient := validTestConfig(t, testNode())
+
+		status := updateengine.Status{
+			CurrentOperation: updateengine.UpdateStatusUpdatedNeedReboot,
+		}
+
+		testConfig.StatusReceiver = &mockStatusReceiver{
+			receiveStatusesF: func(ch chan<- updateengine.Status, _ <-chan struct{}) {
+				status.NewVersion = "foo"
+				ch <- status
+				status.NewVersion = "bar"
+				ch <- status
+			},
+		}
+
+		newVersionReported := make(chan string, 2)
+
+		fakeClient.PrependReactor("update", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			node := updateActionToNode(t, action)
+
+			if _, ok := node.Annotations[constants.AnnotationStatus]; ok {
+				newVersionReported <- node.Annotations[constants.AnnotationNewVersion]
+			}
+
+			return false, nil, nil
+		})
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		runAgent(ctx, t, testConfig)
+
+		notOkToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		lastNewVersion := ""
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for first node status update")
+		case newVersion := <-newVersionReported:
+			lastNewVersion = newVersion
+		}
+
+		select {
+		case newVersion := <-newVersionReported:
+			if newVersion != lastNewVersion {
+				t.Fatalf("Unexpected node status update")
+			}
+		case <-contextWithTimeout(t, 500*time.Millisecond).Done():
+		}
+	})
+
+	t.Run("after_getting_ok_to_reboot_annotation", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("marks_node_as_unschedulable_if_not_done_before_by", func(t *testing.T) {
+			t.Parallel()
+
+			testConfig, node, fakeClient := validTestConfig(t, testNode())
+
+			nodeUpdatedAsUnschedulable := notifyOnNodeUnschedulableUpdate(t, fakeClient)
+
+			ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+			done := runAgent(ctx, t, testConfig)
+
+			assertNodeProperty(ctx, t, &assertNodePropertyContext{
+				done:   done,
+				config: testConfig,
+				testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+			})
+
+			okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+			t.Run("setting_unschedulable_field_on_Node_object", func(t *testing.T) {
+				t.Parallel()
+
+				select {
+				case <-ctx.Done():
+					t.Fatal("Timed out waiting for node being marked as unschedulable")
+				case <-nodeUpdatedAsUnschedulable:
+				}
+			})
+
+			t.Run("setting_reboot_in_progress_annotation_to_true", func(t *testing.T) {
+				t.Parallel()
+
+				assertNodeProperty(ctx, t, &assertNodePropertyContext{
+					done:   done,
+					config: testConfig,
+					testF:  assertNodeAnnotationValue(constants.AnnotationRebootInProgress, constants.True),
+				})
+			})
+
+			t.Run("setting_agent_made_unschedulable_annotations_to_true", func(t *testing.T) {
+				t.Parallel()
+
+				assertNodeProperty(ctx, t, &assertNodePropertyContext{
+					done:   done,
+					config: testConfig,
+					testF:  assertNodeAnnotationValue(constants.AnnotationAgentMadeUnschedulable, constants.True),
+				})
+			})
+		})
+	})
+
+	t.Run("applies_configured_reboot_taint_before_draining", func(t *testing.T) {
+		t.Parallel()
+
+		cases := map[string]struct {
+			skipCordon            bool
+			expectedUnschedulable bool
+		}{
+			"in_addition_to_marking_node_as_unschedulable": {
+				expectedUnschedulable: true,
+			},
+			"instead_of_marking_node_as_unschedulable_when_configured": {
+				skipCordon: true,
+			},
+		}
+
+		for name, testCase := range cases {
+			testCase := testCase
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				testConfig, node, _ := validTestConfig(t, testNode())
+				testConfig.RebootTaint = "example.com/rebooting=true:NoSchedule"
+				testConfig.SkipCordon = testCase.skipCordon
+
+				ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+				done := runAgent(ctx, t, testConfig)
+
+				assertNodeProperty(ctx, t, &assertNodePropertyContext{
+					done:   done,
+					config: testConfig,
+					testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+				})
+
+				okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+				assertNodeProperty(ctx, t, &assertNodePropertyContext{
+					done:   done,
+					config: testConfig,
+					testF: func(t *testing.T, node *corev1.Node) bool {
+						t.Helper()
+
+						if !hasRebootTaint(node) {
+							return false
+						}
+
+						if node.Spec.Unschedulable != testCase.expectedUnschedulable {
+							t.Fatalf("Expected node unschedulable to be %t", testCase.expectedUnschedulable)
+						}
+
+						return assertNodeAnnotationValue(constants.AnnotationAgentMadeUnschedulable, constants.True)(t, node)
+					},
+				})
+			})
+		}
+	})
+
+	t.Run("removes_reboot_taint_after_reboot_without_making_node_schedulable_when_cordon_is_skipped", func(t *testing.T) {
+		t.Parallel()
+
+		rebootedNode := testNode()
+		rebootedNode.Annotations[constants.AnnotationRebootInProgress] = constants.True
+		rebootedNode.Annotations[constants.AnnotationAgentMadeUnschedulable] = constants.True
+		// Made unschedulable by the administrator.
+		rebootedNode.Spec.Unschedulable = true
+		rebootedNode.Spec.Taints = []corev1.Taint{
+			{Key: "example.com/rebooting", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+			{Key: "example.com/other", Effect: corev1.TaintEffectNoSchedule},
+		}
+
+		testConfig, _, _ := validTestConfig(t, rebootedNode)
+		testConfig.RebootTaint = "example.com/rebooting=true:NoSchedule"
+		testConfig.SkipCordon = true
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF: func(t *testing.T, node *corev1.Node) bool {
+				t.Helper()
+
+				if hasRebootTaint(node) {
+					return false
+				}
+
+				if len(node.Spec.Taints) != 1 {
+					t.Fatalf("Expected other taints to be preserved, got %v", node.Spec.Taints)
+				}
+
+				if !node.Spec.Unschedulable {
+					t.Fatalf("Expected node to remain unschedulable")
+				}
+
+				return true
+			},
+		})
+	})
+
+	t.Run("skips_marking_node_as_unschedulable_if_node_is_already_unschedulable", func(t *testing.T) {
+		t.Parallel()
+
+		nodeUpdatedAsUnschedulable := make(chan struct{})
+		podsListed := make(chan struct{})
+
+		nodeUnschedulable := testNode()
+		nodeUnschedulable.Spec.Unschedulable = true
+
+		testConfig, _, fakeClient := validTestConfig(t, nodeUnschedulable)
+
+		fakeClient.PrependReactor("update", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			node := updateActionToNode(t, action)
+
+			if _, ok := node.Annotations[constants.AnnotationAgentMadeUnschedulable]; ok {
+				nodeUpdatedAsUnschedulable <- struct{}{}
+			}
+
+			return false, nil, nil
+		})
+
+		fakeClient.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			podsListed <- struct{}{}
+
+			return false, nil, nil
+		})
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), nodeUnschedulable.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for pods listing operation")
+		case <-nodeUpdatedAsUnschedulable:
+			t.Fatalf("Expected node to not be marked as unschedulable")
+		case <-podsListed:
+		}
+	})
+
+	t.Run("after_marking_node_as_unschedulable", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("without_exceeding_pod_deletion_grace_period", func(t *testing.T) {
+			t.Parallel()
+
+			expectedPodsRemovedNames := map[string]struct{}{"pod-to-be-removed": {}}
+			podsToCreate := []*corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "pod-to-be-removed",
+						Namespace:       "default",
+						OwnerReferences: testPodControllerReference(),
+					},
+					Spec: corev1.PodSpec{
+						NodeName: testNode().Name,
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "pod-filtered-from-removal-because-of-namespace",
+						Namespace:       "kube-system",
+						OwnerReferences: testPodControllerReference(),
+					},
+					Spec: corev1.PodSpec{
+						NodeName: testNode().Name,
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "pod-on-another-node",
+						Namespace:       "another",
+						OwnerReferences: testPodControllerReference(),
+					},
+					Spec: corev1.PodSpec{
+						NodeName: "baz",
+					},
+				},
+			}
+
+			fakeClient := fake.NewSimpleClientset(podsToCreate[0], podsToCreate[1], podsToCreate[2], testNode())
+			addEvictionSupport(t, fakeClient)
+
+			testConfig, node, _ := validTestConfig(t, testNode())
+			testConfig.Clientset = fakeClient
+			testConfig.PodDeletionGracePeriod = time.Hour
+
+			expectedPodRemovedMutex := &sync.Mutex{}
+			expectedPodRemoved := len(expectedPodsRemovedNames)
+			rebootTriggerred := make(chan bool, 1)
+
+			testConfig.Rebooter = &mockRebooter{
+				rebootF: func(auth bool) {
+					expectedPodRemovedMutex.Lock()
+					rebootTriggerred <- expectedPodRemoved < 0
+					expectedPodRemovedMutex.Unlock()
+				},
+			}
+
+			nodeUpdatedAsUnschedulable := notifyOnNodeUnschedulableUpdate(t, &fakeClient.Fake)
+
+			fakeClient.PrependReactor("list", "pods", listPodsWithFieldSelector(podsToCreate))
+
+			allExpectedPodsScheduledForRemoval := make(chan struct{}, 2)
+
+			testPodRemoval := func(podName string) bool {
+				t.Helper()
+
+				if _, ok := expectedPodsRemovedNames[podName]; !ok {
+					t.Fatalf("Unexpected pod %q removed", podName)
+				}
+
+				expectedPodRemovedMutex.Lock()
+				defer expectedPodRemovedMutex.Unlock()
+				expectedPodRemoved--
+
+				if expectedPodRemoved == 0 {
+					allExpectedPodsScheduledForRemoval <- struct{}{}
+					allExpectedPodsScheduledForRemoval <- struct{}{}
+				}
+
+				return expectedPodRemoved >= 0
+			}
+
+			fakeClient.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				deleteAction, ok := action.(k8stesting.DeleteActionImpl)
+				if !ok {
+					del := k8stesting.DeleteActionImpl{}
+
+					return true, nil, fmt.Errorf("unexpected action, expected %T, got %T", del, action)
+				}
+
+				return testPodRemoval(deleteAction.Name), nil, nil
+			})
+
+			fakeClient.PrependReactor("create", "pods/eviction",
+				func(action k8stesting.Action) (bool, runtime.Object, error) {
+					createAction, ok := action.(k8stesting.CreateActionImpl)
+					if !ok {
+						del := k8stesting.CreateActionImpl{}
+
+						return true, nil, fmt.Errorf("unexpected action, expected %T, got %T", del, action)
+					}
+
+					eviction, ok := createAction.Object.(*policyv1.Eviction)
+					if !ok {
+						expectedEviction := &policyv1.Eviction{}
+
+						return true, nil, fmt.Errorf("unexpected eviction type, expected %T, got %T", expectedEviction, eviction)
+					}
+
+					return testPodRemoval(eviction.Name), nil, nil
+				})
+
+			podGetRequest := make(chan struct{}, 1)
+
+			fakeClient.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				podGetRequest <- struct{}{}
+
+				return false, nil, nil
+			})
+
+			ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+			assertNodeProperty(ctx, t, &assertNodePropertyContext{
+				done:   runAgent(ctx, t, testConfig),
+				config: testConfig,
+				testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+			})
+
+			okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+			select {
+			case <-ctx.Done():
+				t.Fatal("Timed out waiting for node being marked as unschedulable")
+			case <-nodeUpdatedAsUnschedulable:
+			}
+
+			t.Run("remove_pods_scheduled_on_running_node_without_pods_from_kube-system_namespace", func(t *testing.T) {
+				t.Parallel()
+
+				select {
+				case <-ctx.Done():
+					t.Fatal("Timed out waiting for expected number of pods to be removed")
+				case <-allExpectedPodsScheduledForRemoval:
+				}
+			})
+
+			t.Run("waits_for_removed_pods_to_terminate_before_rebooting", func(t *testing.T) {
+				select {
+				case <-ctx.Done():
+					t.Fatalf("Timed out waiting for all pods to be scheduled for removal")
+				case <-allExpectedPodsScheduledForRemoval:
+				}
+
+				select {
+				case <-ctx.Done():
+					t.Fatalf("Timed out waiting pod GET request")
+				case <-podGetRequest:
+				}
+
+				client := testConfig.Clientset.CoreV1().Pods(podsToCreate[0].Namespace)
+
+				if err := client.Delete(ctx, podsToCreate[0].Name, metav1.DeleteOptions{}); err != nil {
+					t.Fatalf("Failed removing test pod: %v", err)
+				}
+
+				select {
+				case <-ctx.Done():
+					t.Fatalf("Timed out waiting for reboot to be triggered")
+				case expected := <-rebootTriggerred:
+					if !expected {
+						t.Fatalf("Got unexpected reboot call")
+					}
+				}
+			})
+		})
+
+		t.Run("ignores_pods_which_has_not_terminated_when_pod_deletion_grace_period_is_reached", func(t *testing.T) {
+			t.Parallel()
+
+			rebootTriggerred := make(chan bool)
+
+			podsToCreate := []*corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "foo",
+						Namespace:       "default",
+						OwnerReferences: testPodControllerReference(),
+					},
+					Spec: corev1.PodSpec{
+						NodeName: testNode().Name,
+					},
+				},
+			}
+
+			fakeClient := fake.NewSimpleClientset(podsToCreate[0], testNode())
+			addEvictionSupport(t, fakeClient)
+
+			testConfig, node, _ := validTestConfig(t, testNode())
+			testConfig.Clientset = fakeClient
+			testConfig.Rebooter = &mockRebooter{
+				rebootF: func(auth bool) {
+					rebootTriggerred <- auth
+				},
+			}
+
+			// We only handle the creating of the eviction and not the delete which will block and cause the timeout
+			// condition we're looking for.
+			fakeClient.PrependReactor("create", "pods/eviction", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, nil
+			})
+
+			ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+			assertNodeProperty(ctx, t, &assertNodePropertyContext{
+				done:   runAgent(ctx, t, testConfig),
+				config: testConfig,
+				testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+			})
+
+			okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+			select {
+			case <-ctx.Done():
+				t.Fatal("Timed out waiting for reboot to be triggered")
+			case <-rebootTriggerred:
+			}
+		})
+	})
+
+	t.Run("deletes_pods_which_could_not_be_evicted_when_eviction_timeout_is_reached", func(t *testing.T) {
+		t.Parallel()
+
+		rebootTriggerred := make(chan bool)
+
+		podsToCreate := []*corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "foo",
+					Namespace:       "default",
+					OwnerReferences: testPodControllerReference(),
+				},
+				Spec: corev1.PodSpec{
+					NodeName: testNode().Name,
+				},
+			},
+		}
+
+		fakeClient := fake.NewSimpleClientset(podsToCreate[0], testNode())
+		addEvictionSupport(t, fakeClient)
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.Clientset = fakeClient
+		testConfig.PodDeletionGracePeriod = time.Hour
+		testConfig.EvictionTimeout = time.Second
+
+		registry := prometheus.NewRegistry()
+		testConfig.MetricsRegisterer = registry
+		testConfig.Rebooter = &mockRebooter{
+			rebootF: func(auth bool) {
+				rebootTriggerred <- auth
+			},
+		}
+
+		// Simulate eviction being blocked by PodDisruptionBudget.
+		fakeClient.PrependReactor("create", "pods/eviction", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 1)
+		})
+
+		podDeleted := make(chan struct{}, 1)
+
+		fakeClient.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			podDeleted <- struct{}{}
+
+			return false, nil, nil
+		})
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for pod to be deleted")
+		case <-podDeleted:
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for reboot to be triggered")
+		case <-rebootTriggerred:
+		}
+
+		t.Run("exposing_metrics", func(t *testing.T) {
+			t.Parallel()
+
+			metricName := "flatcar_linux_update_agent_drain_pods_deleted_total"
+
+			if v := metricValue(t, registry, metricName).GetCounter().GetValue(); v != 1 {
+				t.Fatalf("Expected metric %q to be 1, got %v", metricName, v)
+			}
+
+			assertDrainDurationObserved(t, registry, "success")
+		})
+	})
+
+	t.Run("evicts_pods_in_order_of_priority_using_configured_grace_periods", func(t *testing.T) {
+		t.Parallel()
+
+		podsToCreate := []runtime.Object{testNode()}
+
+		podPriorities := map[string]*int32{
+			"system":  pointer.Int32(2000000000),
+			"default": nil,
+			"high":    pointer.Int32(1000),
+		}
+
+		for name, priority := range podPriorities {
+			podsToCreate = append(podsToCreate, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            name,
+					Namespace:       "default",
+					OwnerReferences: testPodControllerReference(),
+				},
+				Spec: corev1.PodSpec{
+					NodeName: testNode().Name,
+					Priority: priority,
+				},
+			})
+		}
+
+		fakeClient := fake.NewSimpleClientset(podsToCreate...)
+		addEvictionSupport(t, fakeClient)
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.Clientset = fakeClient
+		testConfig.DrainPriorityGracePeriods = map[int32]time.Duration{
+			1000:       time.Minute,
+			2000000000: 2 * time.Minute,
+		}
+
+		rebootTriggerred := make(chan bool, 1)
+
+		testConfig.Rebooter = &mockRebooter{
+			rebootF: func(auth bool) {
+				rebootTriggerred <- auth
+			},
+		}
+
+		evictions := make(chan *policyv1.Eviction, len(podPriorities))
+
+		fakeClient.PrependReactor("create", "pods/eviction", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			eviction, ok := action.(k8stesting.CreateActionImpl).Object.(*policyv1.Eviction)
+			if !ok {
+				return true, nil, fmt.Errorf("unexpected eviction type %T", action.(k8stesting.CreateActionImpl).Object)
+			}
+
+			evictions <- eviction
+
+			podsResource := corev1.SchemeGroupVersion.WithResource("pods")
+
+			return true, nil, fakeClient.Tracker().Delete(podsResource, eviction.Namespace, eviction.Name)
+		})
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for reboot to be triggered")
+		case <-rebootTriggerred:
+		}
+
+		expectedGracePeriods := []struct {
+			podName            string
+			gracePeriodSeconds int64
+		}{
+			{podName: "default", gracePeriodSeconds: -1},
+			{podName: "high", gracePeriodSeconds: 60},
+			{podName: "system", gracePeriodSeconds: 120},
+		}
+
+		for _, expected := range expectedGracePeriods {
+			eviction := <-evictions
+
+			if eviction.Name != expected.podName {
+				t.Fatalf("Expected pod %q to be evicted, got %q", expected.podName, eviction.Name)
+			}
+
+			gracePeriodSeconds := int64(-1)
+			if eviction.DeleteOptions != nil && eviction.DeleteOptions.GracePeriodSeconds != nil {
+				gracePeriodSeconds = *eviction.DeleteOptions.GracePeriodSeconds
+			}
+
+			if gracePeriodSeconds != expected.gracePeriodSeconds {
+				t.Fatalf("Expected pod %q to be evicted with grace period %d, got %d",
+					eviction.Name, expected.gracePeriodSeconds, gracePeriodSeconds)
+			}
+		}
+	})
+
+	t.Run("retries_draining_node_when_removing_pods_fails_and_drain_retry_budget_is_configured", func(t *testing.T) {
+		t.Parallel()
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "foo",
+				Namespace:       "default",
+				OwnerReferences: testPodControllerReference(),
+			},
+			Spec: corev1.PodSpec{
+				NodeName: testNode().Name,
+			},
+		}
+
+		fakeClient := fake.NewSimpleClientset(pod, testNode())
+		addEvictionSupport(t, fakeClient)
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.Clientset = fakeClient
+		testConfig.DrainRetryBudget = time.Minute
+		testConfig.DrainRetryInterval = 100 * time.Millisecond
+
+		rebootTriggerred := make(chan bool, 1)
+
+		testConfig.Rebooter = &mockRebooter{
+			rebootF: func(auth bool) {
+				rebootTriggerred <- auth
+			},
+		}
+
+		removalAttempts := 0
+
+		removePodF := func(action k8stesting.Action) (bool, runtime.Object, error) {
+			removalAttempts++
+
+			// Fail both eviction and deletion during the first drain attempt.
+			if removalAttempts <= 2 {
+				return true, nil, apierrors.NewInternalError(fmt.Errorf("stuck finalizer"))
+			}
+
+			podsResource := corev1.SchemeGroupVersion.WithResource("pods")
+
+			return true, nil, fakeClient.Tracker().Delete(podsResource, pod.Namespace, pod.Name)
+		}
+
+		fakeClient.PrependReactor("create", "pods/eviction", removePodF)
+		fakeClient.PrependReactor("delete", "pods", removePodF)
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for reboot to be triggered")
+		case <-rebootTriggerred:
+		}
+	})
+
+	t.Run("aborts_reboot_when_node_can_not_be_drained_and_drain_failure_policy_is_abort", func(t *testing.T) {
+		t.Parallel()
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "foo",
+				Namespace:       "default",
+				OwnerReferences: testPodControllerReference(),
+			},
+			Spec: corev1.PodSpec{
+				NodeName: testNode().Name,
+			},
+		}
+
+		fakeClient := fake.NewSimpleClientset(pod, testNode())
+		addEvictionSupport(t, fakeClient)
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.Clientset = fakeClient
+		testConfig.DrainFailurePolicy = agent.DrainFailurePolicyAbort
+
+		registry := prometheus.NewRegistry()
+		testConfig.MetricsRegisterer = registry
+
+		rebootTriggerred := make(chan bool, 1)
+
+		testConfig.Rebooter = &mockRebooter{
+			rebootF: func(auth bool) {
+				rebootTriggerred <- auth
+			},
+		}
+
+		failPodRemovalF := func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewInternalError(fmt.Errorf("stuck finalizer"))
+		}
+
+		fakeClient.PrependReactor("create", "pods/eviction", failPodRemovalF)
+		fakeClient.PrependReactor("delete", "pods", failPodRemovalF)
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		done := runAgent(ctx, t, testConfig)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   done,
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for agent to stop")
+		case err := <-done:
+			if err == nil {
+				t.Fatalf("Expected agent to return an error")
+			}
+		}
+
+		select {
+		case <-rebootTriggerred:
+			t.Fatalf("Unexpected reboot triggered")
+		default:
+		}
+
+		updatedNode, err := fakeClient.CoreV1().Nodes().Get(ctx, node.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed getting node: %v", err)
+		}
+
+		expectedAnnotations := map[string]string{
+			constants.AnnotationDrainFailed:      constants.True,
+			constants.AnnotationRebootInProgress: constants.False,
+		}
+
+		for key, expectedValue := range expectedAnnotations {
+			if value := updatedNode.Annotations[key]; value != expectedValue {
+				t.Fatalf("Expected annotation %q to be %q, got %q", key, expectedValue, value)
+			}
+		}
+
+		assertDrainDurationObserved(t, registry, "failure")
+	})
+
+	t.Run("waits_with_draining_node_until_annotated_jobs_complete_when_job_completion_timeout_is_configured",
+		func(t *testing.T) {
+			t.Parallel()
+
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "backup",
+					Namespace: "default",
+					Annotations: map[string]string{
+						constants.AnnotationWaitForCompletion: constants.True,
+					},
+				},
+			}
 
-						return true, nil, fmt.Errorf("unexpected action, expected %T, got %T", del, action)
-					}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "backup-abcde",
+					Namespace: "default",
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							Kind:       "Job",
+							Name:       job.Name,
+							Controller: pointer.Bool(true),
+						},
+					},
+				},
+				Spec: corev1.PodSpec{
+					NodeName: testNode().Name,
+				},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodRunning,
+				},
+			}
 
-					eviction, ok := createAction.Object.(*policyv1.Eviction)
-					if !ok {
-						expectedEviction := &policyv1.Eviction{}
+			fakeClient := fake.NewSimpleClientset(testNode(), job, pod)
 
-						return true, nil, fmt.Errorf("unexpected eviction type, expected %T, got %T", expectedEviction, eviction)
-					}
+			testConfig, node, _ := validTestConfig(t, testNode())
+			testConfig.Clientset = fakeClient
+			testConfig.JobCompletionTimeout = time.Hour
 
-					return testPodRemoval(eviction.Name), nil, nil
-				})
+			rebootTriggerred := make(chan bool, 1)
 
-			podGetRequest := make(chan struct{}, 1)
+			testConfig.Rebooter = &mockRebooter{
+				rebootF: func(auth bool) {
+					rebootTriggerred <- auth
+				},
+			}
 
-			fakeClient.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
-				podGetRequest <- struct{}{}
+			jobChecked := make(chan struct{}, 1)
+
+			fakeClient.PrependReactor("get", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				select {
+				case jobChecked <- struct{}{}:
+				default:
+				}
 
 				return false, nil, nil
 			})
@@ -834,84 +3246,79 @@ func Test_Running_agent(t *testing.T) {
 
 			select {
 			case <-ctx.Done():
-				t.Fatal("Timed out waiting for node being marked as unschedulable")
-			case <-nodeUpdatedAsUnschedulable:
+				t.Fatal("Timed out waiting for Job to be checked")
+			case <-jobChecked:
 			}
 
-			t.Run("remove_pods_scheduled_on_running_node_without_pods_from_kube-system_namespace", func(t *testing.T) {
-				t.Parallel()
-
-				select {
-				case <-ctx.Done():
-					t.Fatal("Timed out waiting for expected number of pods to be removed")
-				case <-allExpectedPodsScheduledForRemoval:
-				}
-			})
-
-			t.Run("waits_for_removed_pods_to_terminate_before_rebooting", func(t *testing.T) {
-				select {
-				case <-ctx.Done():
-					t.Fatalf("Timed out waiting for all pods to be scheduled for removal")
-				case <-allExpectedPodsScheduledForRemoval:
-				}
-
-				select {
-				case <-ctx.Done():
-					t.Fatalf("Timed out waiting pod GET request")
-				case <-podGetRequest:
-				}
+			select {
+			case <-rebootTriggerred:
+				t.Fatalf("Unexpected reboot triggered while Job is running")
+			case <-time.After(testConfig.PollInterval * 2):
+			}
 
-				client := testConfig.Clientset.CoreV1().Pods(podsToCreate[0].Namespace)
+			pod.Status.Phase = corev1.PodSucceeded
 
-				if err := client.Delete(ctx, podsToCreate[0].Name, metav1.DeleteOptions{}); err != nil {
-					t.Fatalf("Failed removing test pod: %v", err)
-				}
+			if _, err := fakeClient.CoreV1().Pods(pod.Namespace).Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+				t.Fatalf("Failed updating pod: %v", err)
+			}
 
-				select {
-				case <-ctx.Done():
-					t.Fatalf("Timed out waiting for reboot to be triggered")
-				case expected := <-rebootTriggerred:
-					if !expected {
-						t.Fatalf("Got unexpected reboot call")
-					}
-				}
-			})
+			select {
+			case <-ctx.Done():
+				t.Fatal("Timed out waiting for reboot to be triggered")
+			case <-rebootTriggerred:
+			}
 		})
 
-		t.Run("ignores_pods_which_has_not_terminated_when_pod_deletion_grace_period_is_reached", func(t *testing.T) {
+	t.Run("waits_with_rebooting_until_volumes_are_detached_when_volume_detach_timeout_is_configured",
+		func(t *testing.T) {
 			t.Parallel()
 
-			rebootTriggerred := make(chan bool)
-
-			podsToCreate := []*corev1.Pod{
-				{
+			volumeAttachment := func(name, attacher, nodeName string) *storagev1.VolumeAttachment {
+				return &storagev1.VolumeAttachment{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:            "foo",
-						Namespace:       "default",
-						OwnerReferences: testPodControllerReference(),
+						Name: name,
 					},
-					Spec: corev1.PodSpec{
-						NodeName: testNode().Name,
+					Spec: storagev1.VolumeAttachmentSpec{
+						Attacher: attacher,
+						NodeName: nodeName,
 					},
-				},
+					Status: storagev1.VolumeAttachmentStatus{
+						Attached: true,
+					},
+				}
 			}
 
-			fakeClient := fake.NewSimpleClientset(podsToCreate[0], testNode())
-			addEvictionSupport(t, fakeClient)
+			fakeClient := fake.NewSimpleClientset(
+				testNode(),
+				volumeAttachment("attached", "ebs.csi.aws.com", testNode().Name),
+				volumeAttachment("local", "local.csi.example.com", testNode().Name),
+				volumeAttachment("another-node", "ebs.csi.aws.com", "bar"),
+			)
 
 			testConfig, node, _ := validTestConfig(t, testNode())
 			testConfig.Clientset = fakeClient
+			testConfig.VolumeDetachTimeout = time.Hour
+			testConfig.VolumeDetachIgnoredDrivers = []string{"local.csi.example.com"}
+
+			rebootTriggerred := make(chan bool, 1)
+
 			testConfig.Rebooter = &mockRebooter{
 				rebootF: func(auth bool) {
 					rebootTriggerred <- auth
 				},
 			}
 
-			// We only handle the creating of the eviction and not the delete which will block and cause the timeout
-			// condition we're looking for.
-			fakeClient.PrependReactor("create", "pods/eviction", func(action k8stesting.Action) (bool, runtime.Object, error) {
-				return true, nil, nil
-			})
+			volumeAttachmentsListed := make(chan struct{}, 1)
+
+			fakeClient.PrependReactor("list", "volumeattachments",
+				func(action k8stesting.Action) (bool, runtime.Object, error) {
+					select {
+					case volumeAttachmentsListed <- struct{}{}:
+					default:
+					}
+
+					return false, nil, nil
+				})
 
 			ctx := contextWithTimeout(t, agentRunTimeLimit)
 
@@ -923,12 +3330,171 @@ func Test_Running_agent(t *testing.T) {
 
 			okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
 
+			select {
+			case <-ctx.Done():
+				t.Fatal("Timed out waiting for volume attachments to be checked")
+			case <-volumeAttachmentsListed:
+			}
+
+			select {
+			case <-rebootTriggerred:
+				t.Fatalf("Unexpected reboot triggered while volume is attached")
+			case <-time.After(testConfig.PollInterval * 2):
+			}
+
+			if err := fakeClient.StorageV1().VolumeAttachments().Delete(ctx, "attached", metav1.DeleteOptions{}); err != nil {
+				t.Fatalf("Failed deleting volume attachment: %v", err)
+			}
+
 			select {
 			case <-ctx.Done():
 				t.Fatal("Timed out waiting for reboot to be triggered")
 			case <-rebootTriggerred:
 			}
 		})
+
+	t.Run("uses_fallback_rebooter_when_host_does_not_reboot_within_timeout", func(t *testing.T) {
+		t.Parallel()
+
+		fallbackRebooter := &mockFallbackRebooter{rebooted: make(chan struct{}, 1)}
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.FallbackRebooter = fallbackRebooter
+		testConfig.FallbackRebootTimeout = 100 * time.Millisecond
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for fallback rebooter to be used")
+		case <-fallbackRebooter.rebooted:
+		}
+	})
+
+	t.Run("uses_fallback_rebooter_immediately_when_requesting_reboot_fails", func(t *testing.T) {
+		t.Parallel()
+
+		fallbackRebooter := &mockFallbackRebooter{rebooted: make(chan struct{}, 1)}
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.Rebooter = &mockRebooter{rebootErr: errors.New("logind not available")}
+		testConfig.FallbackRebooter = fallbackRebooter
+		// Timeout longer than the test, so only immediate fallback can pass the test.
+		testConfig.FallbackRebootTimeout = time.Hour
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for fallback rebooter to be used")
+		case <-fallbackRebooter.rebooted:
+		}
+	})
+
+	t.Run("runs_pre_drain_hooks_in_order_before_rebooting", func(t *testing.T) {
+		t.Parallel()
+
+		rebootTriggerred := make(chan bool)
+
+		outputDir := t.TempDir()
+		outputFile := filepath.Join(outputDir, "output")
+		hooksDir := t.TempDir()
+
+		hooks := map[string]string{
+			"10-first":  "#!/bin/sh\necho second >> " + outputFile,
+			"20-second": "#!/bin/sh\necho third >> " + outputFile,
+		}
+
+		for name, content := range hooks {
+			if err := os.WriteFile(filepath.Join(hooksDir, name), []byte(content), 0o700); err != nil {
+				t.Fatalf("Failed writing hook %q: %v", name, err)
+			}
+		}
+
+		if err := os.WriteFile(filepath.Join(hooksDir, "not-executable"), []byte("#!/bin/sh\nexit 1"), 0o600); err != nil {
+			t.Fatalf("Failed writing not executable file: %v", err)
+		}
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.PreDrainHookCommand = "echo first >> " + outputFile
+		testConfig.PreDrainHooksDirectory = hooksDir
+		testConfig.Rebooter = &mockRebooter{
+			rebootF: func(auth bool) {
+				rebootTriggerred <- auth
+			},
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for reboot to be triggered")
+		case <-rebootTriggerred:
+		}
+
+		output, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatalf("Failed reading hooks output: %v", err)
+		}
+
+		if expectedOutput := "first\nsecond\nthird\n"; string(output) != expectedOutput {
+			t.Fatalf("Expected hooks output %q, got %q", expectedOutput, string(output))
+		}
+	})
+
+	t.Run("reboots_when_pre_drain_hook_fails_and_failure_policy_is_ignore", func(t *testing.T) {
+		t.Parallel()
+
+		rebootTriggerred := make(chan bool)
+
+		testConfig, node, _ := validTestConfig(t, testNode())
+		testConfig.PreDrainHookCommand = "exit 1"
+		testConfig.PreDrainHookFailurePolicy = agent.HookFailurePolicyIgnore
+		testConfig.Rebooter = &mockRebooter{
+			rebootF: func(auth bool) {
+				rebootTriggerred <- auth
+			},
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		assertNodeProperty(ctx, t, &assertNodePropertyContext{
+			done:   runAgent(ctx, t, testConfig),
+			config: testConfig,
+			testF:  assertNodeAnnotationValue(constants.AnnotationRebootNeeded, constants.True),
+		})
+
+		okToReboot(ctx, t, testConfig.Clientset.CoreV1().Nodes(), node.Name)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for reboot to be triggered")
+		case <-rebootTriggerred:
+		}
 	})
 
 	t.Run("removes_pod_without_owner_when_force_drain_is_configured", func(t *testing.T) {
@@ -1050,11 +3616,9 @@ func Test_Running_agent(t *testing.T) {
 
 				testConfig, _, fakeClient := validTestConfig(t, testNode())
 
-				errorReached, failOnSettingNodeAnnotations := failOnNthCall(4, fmt.Errorf(t.Name()))
-				// 1. Updating info labels. TODO: Could be done with patch instead.
-				// 2. Checking made unschedulable.
-				// 3. Updating annotations and labels.
-				// 4. Get initial set of annotations.
+				errorReached, failOnSettingNodeAnnotations := failOnNthCall(2, fmt.Errorf(t.Name()))
+				// 1. Checking made unschedulable.
+				// 2. Get initial set of annotations.
 				fakeClient.PrependReactor("get", "nodes", failOnSettingNodeAnnotations)
 
 				ctx, cancel := context.WithTimeout(contextWithDeadline(t), agentRunTimeLimit)
@@ -1306,21 +3870,25 @@ func Test_Running_agent(t *testing.T) {
 			})
 		})
 
-		for name, method := range map[string]string{
-			"getting_existing_Node_annotations_fails":                 "get",
-			"setting_initial_set_of_Node_annotation_and_labels_fails": "update",
+		for name, c := range map[string]struct {
+			method      string
+			failingCall int
+		}{
+			"getting_existing_Node_annotations_fails": {method: "get", failingCall: 0},
+			// 1. Applying info labels.
+			"setting_initial_set_of_Node_annotation_and_labels_fails": {method: "patch", failingCall: 1},
 		} {
-			method := method
+			c := c
 
 			t.Run(name, func(t *testing.T) {
 				t.Parallel()
 
 				testConfig, _, fakeClient := validTestConfig(t, okToRebootNode())
 
-				expectedError := errors.New("Error node operation " + method)
+				expectedError := errors.New("Error node operation " + c.method)
 
-				_, f := failOnNthCall(1, expectedError)
-				fakeClient.PrependReactor(method, "nodes", f)
+				_, f := failOnNthCall(c.failingCall, expectedError)
+				fakeClient.PrependReactor(c.method, "nodes", f)
 
 				err := getAgentRunningError(t, testConfig)
 				if !errors.Is(err, expectedError) {
@@ -1339,10 +3907,8 @@ func Test_Running_agent(t *testing.T) {
 
 				expectedError := errors.New("Error getting node")
 
-				// 1. Updating info labels. TODO: Could be done with patch instead.
-				// 2. Checking made unschedulable.
-				// 3. Updating annotations and labels.
-				_, f := failOnNthCall(3, expectedError)
+				// 1. Checking made unschedulable.
+				_, f := failOnNthCall(1, expectedError)
 				fakeClient.PrependReactor("get", "*", f)
 
 				err := getAgentRunningError(t, testConfig)
@@ -1481,17 +4047,17 @@ func Test_Running_agent(t *testing.T) {
 			expectedError := errors.New("Error marking node as schedulable")
 
 			errorOnNodeSchedulable := func(action k8stesting.Action) (bool, runtime.Object, error) {
-				node := updateActionToNode(t, action)
+				node := patchActionToNode(t, action)
 
 				if node.Annotations[constants.AnnotationAgentMadeUnschedulable] != constants.False {
-					return true, node, nil
+					return false, nil, nil
 				}
 
 				// If node is about to be annotated as no longer made unschedulable by agent, make error occur.
 				return true, nil, expectedError
 			}
 
-			fakeClient.PrependReactor("update", "nodes", errorOnNodeSchedulable)
+			fakeClient.PrependReactor("patch", "nodes", errorOnNodeSchedulable)
 
 			err := getAgentRunningError(t, testConfig)
 			if !errors.Is(err, expectedError) {
@@ -1509,12 +4075,10 @@ func Test_Running_agent(t *testing.T) {
 
 			expectedError := errors.New("Error getting node")
 
-			// 1. Updating info labels. TODO: Could be done with patch instead.
-			// 2. Checking made unschedulable.
-			// 3. Updating annotations and labels.
-			// 4. Getting initial state while waiting for ok-to-reboot.
-			// 5. Getting node object to mark it unschedulable etc.
-			_, f := failOnNthCall(5, expectedError)
+			// 1. Checking made unschedulable.
+			// 2. Getting initial state while waiting for ok-to-reboot.
+			// 3. Getting node object to mark it unschedulable etc.
+			_, f := failOnNthCall(3, expectedError)
 			fakeClient.PrependReactor("get", "*", f)
 
 			err := getAgentRunningError(t, testConfig)
@@ -1581,14 +4145,62 @@ func Test_Running_agent(t *testing.T) {
 
 			withOkToRebootTrueUpdate(fakeClient, node)
 
-			expectedError := errors.New("Error getting pods for deletion")
+			expectedError := errors.New("Error getting pods for deletion")
+
+			_, f := failOnNthCall(0, expectedError)
+			fakeClient.PrependReactor("list", "pods", f)
+
+			expectedErrorWrapped := fmt.Errorf("processing: getting pods for deletion: %v", []error{expectedError})
+			if err := getAgentRunningError(t, testConfig); err.Error() != expectedErrorWrapped.Error() {
+				t.Fatalf("Expected error %q, got %q", expectedErrorWrapped, err)
+			}
+		})
+
+		t.Run("pre_drain_hook", func(t *testing.T) {
+			t.Parallel()
+
+			cases := map[string]func(*agent.Config){
+				"fails": func(c *agent.Config) {
+					c.PreDrainHookCommand = "exit 1"
+				},
+				"times_out": func(c *agent.Config) {
+					c.PreDrainHookCommand = "sleep 10"
+					c.PreDrainHookTimeout = 100 * time.Millisecond
+				},
+				"directory_does_not_exist": func(c *agent.Config) {
+					c.PreDrainHooksDirectory = filepath.Join(t.TempDir(), "foo")
+				},
+			}
+
+			for name, mutateConfigF := range cases {
+				mutateConfigF := mutateConfigF
+
+				t.Run(name, func(t *testing.T) {
+					t.Parallel()
+
+					testConfig, node, fakeClient := validTestConfig(t, testNode())
+					mutateConfigF(testConfig)
+
+					rebootTriggerred := make(chan bool, 1)
+
+					testConfig.Rebooter = &mockRebooter{
+						rebootF: func(auth bool) {
+							rebootTriggerred <- auth
+						},
+					}
+
+					withOkToRebootTrueUpdate(fakeClient, node)
 
-			_, f := failOnNthCall(0, expectedError)
-			fakeClient.PrependReactor("list", "pods", f)
+					if err := getAgentRunningError(t, testConfig); err == nil {
+						t.Fatalf("Expected agent to return an error")
+					}
 
-			expectedErrorWrapped := fmt.Errorf("processing: getting pods for deletion: %v", []error{expectedError})
-			if err := getAgentRunningError(t, testConfig); err.Error() != expectedErrorWrapped.Error() {
-				t.Fatalf("Expected error %q, got %q", expectedErrorWrapped, err)
+					select {
+					case <-rebootTriggerred:
+						t.Fatalf("Unexpected reboot triggered")
+					default:
+					}
+				})
 			}
 		})
 
@@ -1664,6 +4276,212 @@ func Test_Running_agent(t *testing.T) {
 }
 
 // Expose klog flags to be able to increase verbosity for agent logs.
+func Test_Agent_is_ready(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not_before_it_is_running", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, _, _ := validTestConfig(t, testNode())
+
+		client, err := agent.New(testConfig)
+		if err != nil {
+			t.Fatalf("Unexpected error creating new agent: %v", err)
+		}
+
+		if err := client.Ready(); err == nil {
+			t.Fatalf("Expected agent to not be ready")
+		}
+	})
+
+	t.Run("after_setting_up_node_annotations_and_receiving_update_engine_status", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, _, _ := validTestConfig(t, testNode())
+
+		client, err := agent.New(testConfig)
+		if err != nil {
+			t.Fatalf("Unexpected error creating new agent: %v", err)
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		go func() {
+			if err := client.Run(ctx); err != nil {
+				t.Logf("Running agent: %v", err)
+			}
+		}()
+
+		for err := client.Ready(); err != nil; err = client.Ready() {
+			select {
+			case <-ctx.Done():
+				t.Fatalf("Expected agent to become ready, got: %v", err)
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+	})
+}
+
+func Test_Agent_is_healthy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("when_update_status_receiver_is_healthy_and_node_updates_succeed", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, _, _ := validTestConfig(t, testNode())
+
+		client, err := agent.New(testConfig)
+		if err != nil {
+			t.Fatalf("Unexpected error creating new agent: %v", err)
+		}
+
+		if err := client.Healthz(); err != nil {
+			t.Fatalf("Expected agent to be healthy, got: %v", err)
+		}
+	})
+
+	t.Run("not_when", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("update_status_receiver_is_not_healthy", func(t *testing.T) {
+			t.Parallel()
+
+			testConfig, _, _ := validTestConfig(t, testNode())
+
+			expectedErr := errors.New(t.Name())
+
+			testConfig.StatusReceiver = &mockStatusReceiver{
+				healthzF: func() error {
+					return expectedErr
+				},
+			}
+
+			client, err := agent.New(testConfig)
+			if err != nil {
+				t.Fatalf("Unexpected error creating new agent: %v", err)
+			}
+
+			if err := client.Healthz(); !errors.Is(err, expectedErr) {
+				t.Fatalf("Expected error %q, got %q", expectedErr, err)
+			}
+		})
+
+		t.Run("updating_node_keeps_failing_for_longer_than_configured", func(t *testing.T) {
+			t.Parallel()
+
+			testConfig, _, fakeClient := validTestConfig(t, testNode())
+
+			testConfig.MaxNodeUpdateFailureDuration = time.Millisecond
+
+			fakeClient.PrependReactor("update", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if _, ok := updateActionToNode(t, action).Annotations[constants.AnnotationStatus]; ok {
+					return true, nil, fmt.Errorf(t.Name())
+				}
+
+				return false, nil, nil
+			})
+
+			client, err := agent.New(testConfig)
+			if err != nil {
+				t.Fatalf("Unexpected error creating new agent: %v", err)
+			}
+
+			ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+			go func() {
+				if err := client.Run(ctx); err != nil {
+					t.Logf("Running agent: %v", err)
+				}
+			}()
+
+			for client.Healthz() == nil {
+				select {
+				case <-ctx.Done():
+					t.Fatalf("Expected agent to become unhealthy")
+				case <-time.After(100 * time.Millisecond):
+				}
+			}
+		})
+	})
+}
+
+func Test_Agent_reports_state(t *testing.T) {
+	t.Parallel()
+
+	t.Run("starting_phase_before_it_is_running", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, _, _ := validTestConfig(t, testNode())
+
+		client, err := agent.New(testConfig)
+		if err != nil {
+			t.Fatalf("Unexpected error creating new agent: %v", err)
+		}
+
+		state := client.State()
+
+		if state.Phase != agent.PhaseStarting {
+			t.Fatalf("Expected phase %q, got %q", agent.PhaseStarting, state.Phase)
+		}
+
+		if state.LastStatus != nil {
+			t.Fatalf("Expected no last status, got %v", state.LastStatus)
+		}
+	})
+
+	t.Run("last_status_and_pending_reboot_while_waiting_for_ok_to_reboot", func(t *testing.T) {
+		t.Parallel()
+
+		testConfig, _, _ := validTestConfig(t, testNode())
+		testConfig.StatusReceiver = rebootNeededStatusReceiver()
+
+		client, err := agent.New(testConfig)
+		if err != nil {
+			t.Fatalf("Unexpected error creating new agent: %v", err)
+		}
+
+		ctx := contextWithTimeout(t, agentRunTimeLimit)
+
+		go func() {
+			if err := client.Run(ctx); err != nil {
+				t.Logf("Running agent: %v", err)
+			}
+		}()
+
+		reportsPendingReboot := func(state agent.State) bool {
+			return state.Phase == agent.PhaseWaitingForOkToReboot && state.LastStatus != nil &&
+				len(state.PendingOperations) == 1 && state.PendingOperations[0] == agent.OperationReboot
+		}
+
+		for state := client.State(); !reportsPendingReboot(state); state = client.State() {
+			select {
+			case <-ctx.Done():
+				t.Fatalf("Timed out waiting for agent to report pending reboot, last state: %+v", state)
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+
+		recorder := httptest.NewRecorder()
+
+		agent.StateHandler(client).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		state := agent.State{}
+
+		if err := json.NewDecoder(recorder.Body).Decode(&state); err != nil {
+			t.Fatalf("Decoding state served over HTTP: %v", err)
+		}
+
+		if !reportsPendingReboot(state) {
+			t.Fatalf("Expected state served over HTTP to report pending reboot, got %+v", state)
+		}
+
+		expectedOperation := updateengine.UpdateStatusUpdatedNeedReboot
+		if state.LastStatus.CurrentOperation != expectedOperation {
+			t.Fatalf("Expected last status operation %q, got %q", expectedOperation, state.LastStatus.CurrentOperation)
+		}
+	})
+}
+
 func TestMain(m *testing.M) {
 	testFlags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
 	klog.InitFlags(testFlags)
@@ -1682,7 +4500,7 @@ func validTestConfig(t *testing.T, node *corev1.Node) (*agent.Config, *corev1.No
 	files := map[string]string{
 		"/usr/share/flatcar/update.conf": "GROUP=imageGroup",
 		"/etc/flatcar/update.conf":       "GROUP=configuredGroup",
-		"/etc/os-release":                "ID=testID\nVERSION=testVersion",
+		"/etc/os-release":                "ID=testID\nVERSION=testVersion\nFLATCAR_BOARD=testBoard",
 	}
 
 	hostFilesPrefix := t.TempDir()
@@ -1702,8 +4520,50 @@ func validTestConfig(t *testing.T, node *corev1.Node) (*agent.Config, *corev1.No
 	}, node, &fakeClient.Fake
 }
 
+func gaugeValue(t *testing.T, registry *prometheus.Registry, name string) float64 {
+	t.Helper()
+
+	return metricValue(t, registry, name).GetGauge().GetValue()
+}
+
+func assertDrainDurationObserved(t *testing.T, registry *prometheus.Registry, expectedResult string) {
+	t.Helper()
+
+	metric := metricValue(t, registry, "flatcar_linux_update_agent_drain_duration_seconds")
+
+	if count := metric.GetHistogram().GetSampleCount(); count != 1 {
+		t.Fatalf("Expected 1 drain to be observed, got %d", count)
+	}
+
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == "result" && label.GetValue() != expectedResult {
+			t.Fatalf("Expected drain result %q, got %q", expectedResult, label.GetValue())
+		}
+	}
+}
+
+func metricValue(t *testing.T, registry *prometheus.Registry, name string) *dto.Metric {
+	t.Helper()
+
+	metricFamilies, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed gathering metrics: %v", err)
+	}
+
+	for _, metricFamily := range metricFamilies {
+		if metricFamily.GetName() == name && len(metricFamily.GetMetric()) > 0 {
+			return metricFamily.GetMetric()[0]
+		}
+	}
+
+	t.Fatalf("Metric %q not found", name)
+
+	return nil
+}
+
 type mockStatusReceiver struct {
 	receiveStatusesF func(chan<- updateengine.Status, <-chan struct{})
+	healthzF         func() error
 }
 
 func (m *mockStatusReceiver) ReceiveStatuses(rcvr chan<- updateengine.Status, stop <-chan struct{}) {
@@ -1712,14 +4572,143 @@ func (m *mockStatusReceiver) ReceiveStatuses(rcvr chan<- updateengine.Status, st
 	}
 }
 
+func (m *mockStatusReceiver) Healthz() error {
+	if m.healthzF == nil {
+		return nil
+	}
+
+	return m.healthzF()
+}
+
+type mockReconnectCounter struct {
+	*mockStatusReceiver
+	reconnects uint64
+}
+
+func (m *mockReconnectCounter) Reconnects() uint64 {
+	return m.reconnects
+}
+
+type mockUpdateChecker struct {
+	*mockStatusReceiver
+	attemptUpdateF func() error
+}
+
+func (m *mockUpdateChecker) AttemptUpdate() error {
+	if m.attemptUpdateF == nil {
+		return nil
+	}
+
+	return m.attemptUpdateF()
+}
+
+type mockStatusResetter struct {
+	*mockStatusReceiver
+	resetStatusF func() error
+}
+
+func (m *mockStatusResetter) ResetStatus() error {
+	if m.resetStatusF == nil {
+		return nil
+	}
+
+	return m.resetStatusF()
+}
+
+type mockRollbacker struct {
+	*mockStatusReceiver
+	attemptRollbackF func() error
+}
+
+func (m *mockRollbacker) AttemptRollback() error {
+	if m.attemptRollbackF == nil {
+		return nil
+	}
+
+	return m.attemptRollbackF()
+}
+
+type mockLastAttemptErrorGetter struct {
+	*mockStatusReceiver
+	code int32
+}
+
+func (m *mockLastAttemptErrorGetter) GetLastAttemptError() (int32, error) {
+	return m.code, nil
+}
+
+type mockChannelManager struct {
+	*mockStatusReceiver
+	lock    sync.Mutex
+	channel string
+}
+
+func (m *mockChannelManager) GetChannel() (string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.channel, nil
+}
+
+func (m *mockChannelManager) SetChannel(channel string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.channel = channel
+
+	return nil
+}
+
 type mockRebooter struct {
-	rebootF func(bool)
+	rebootF   func(bool)
+	rebootErr error
 }
 
-func (m *mockRebooter) Reboot(auth bool) {
+func (m *mockRebooter) Reboot(auth bool) error {
 	if m.rebootF != nil {
 		m.rebootF(auth)
 	}
+
+	return m.rebootErr
+}
+
+type mockFallbackRebooter struct {
+	rebooted chan struct{}
+}
+
+func (m *mockFallbackRebooter) Reboot(context.Context) error {
+	m.rebooted <- struct{}{}
+
+	return nil
+}
+
+type mockInhibitingRebooter struct {
+	*mockRebooter
+
+	inhibitF func(what, who, why, mode string) (*os.File, error)
+}
+
+func (m *mockInhibitingRebooter) Inhibit(what, who, why, mode string) (*os.File, error) {
+	return m.inhibitF(what, who, why, mode)
+}
+
+type mockSchedulingRebooter struct {
+	*mockRebooter
+
+	scheduleRebootF        func(message string, delay time.Duration) error
+	cancelScheduledRebootF func() (bool, error)
+}
+
+func (m *mockSchedulingRebooter) ScheduleReboot(message string, delay time.Duration) error {
+	return m.scheduleRebootF(message, delay)
+}
+
+func (m *mockSchedulingRebooter) CancelScheduledReboot() (bool, error) {
+	if m.cancelScheduledRebootF == nil {
+		return true, nil
+	}
+
+	return m.cancelScheduledRebootF()
 }
 
 func contextWithDeadline(t *testing.T) context.Context {
@@ -1796,6 +4785,20 @@ func assertNodeProperty(ctx context.Context, t *testing.T, properties *assertNod
 	}
 }
 
+func assertRebootRequiredCondition(status corev1.ConditionStatus, reason string) nodeAssertF {
+	return func(t *testing.T, node *corev1.Node) bool {
+		t.Helper()
+
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == agent.NodeConditionRebootRequired {
+				return condition.Status == status && condition.Reason == reason
+			}
+		}
+
+		return false
+	}
+}
+
 func assertNodeLabelExists(key string) nodeAssertF {
 	return func(t *testing.T, node *corev1.Node) bool {
 		t.Helper()
@@ -1895,6 +4898,18 @@ func nodeMadeUnschedulable() *corev1.Node {
 	return node
 }
 
+func hasRebootTaint(node *corev1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == "example.com/rebooting" && taint.Effect == corev1.TaintEffectNoSchedule {
+			return true
+		}
+	}
+
+	return false
+}
+
+const testBootID = "4e5f6a2b-1c3d-4e5f-8a9b-0c1d2e3f4a5b"
+
 func testNode() *corev1.Node {
 	return &corev1.Node{
 		ObjectMeta: metav1.ObjectMeta{
@@ -2058,6 +5073,23 @@ func updateActionToNode(t *testing.T, action k8stesting.Action) *corev1.Node {
 	return node
 }
 
+func patchActionToNode(t *testing.T, action k8stesting.Action) *corev1.Node {
+	t.Helper()
+
+	patchAction, ok := action.(k8stesting.PatchActionImpl)
+	if !ok {
+		t.Fatalf("Expected action %T, got %T", k8stesting.PatchActionImpl{}, action)
+	}
+
+	node := &corev1.Node{}
+
+	if err := json.Unmarshal(patchAction.GetPatch(), node); err != nil {
+		t.Fatalf("Decoding node patch: %v", err)
+	}
+
+	return node
+}
+
 // Lifted from https://github.com/kubernetes/kubectl/blob/master/pkg/drain/drain_test.go.
 func addEvictionSupport(t *testing.T, clientset *fake.Clientset) {
 	t.Helper()
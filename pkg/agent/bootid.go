@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/rebootstate"
+)
+
+const bootIDPath = "/proc/sys/kernel/random/boot_id"
+
+// readBootID returns ID of the current boot of the host. Boot ID changes with every reboot, regardless of
+// whether the reboot has been initiated by the agent or not.
+func readBootID(hostFilesPrefix string) (string, error) {
+	bootID, err := os.ReadFile(filepath.Join(hostFilesPrefix, bootIDPath))
+	if err != nil {
+		return "", fmt.Errorf("reading boot ID: %w", err)
+	}
+
+	return strings.TrimSpace(string(bootID)), nil
+}
+
+// rebootFinished returns true when the node has been rebooted by the agent, which is indicated by
+// the reboot-in-progress annotation.
+//
+// When boot ID is known, it must also differ from the one recorded on the node. Otherwise the agent
+// has been restarted before the node actually rebooted and the annotation is stale.
+func (k *klocksmith) rebootFinished(node *corev1.Node) bool {
+	if !rebootstate.IsTrue(rebootstate.FromNode(node).RebootInProgress) {
+		return false
+	}
+
+	if k.bootID == "" || node.Annotations[constants.AnnotationBootID] != k.bootID {
+		return true
+	}
+
+	k.logger.Info("Reboot has been initiated, but node has not been rebooted since, resetting reboot state")
+
+	return false
+}
+
+// rebootedManually returns true when the node has been rebooted without the agent initiating the reboot,
+// e.g. by the administrator or because of a power loss.
+func (k *klocksmith) rebootedManually(node *corev1.Node) bool {
+	previousBootID, ok := node.Annotations[constants.AnnotationBootID]
+
+	return k.bootID != "" && ok && previousBootID != k.bootID &&
+		!rebootstate.IsTrue(rebootstate.FromNode(node).RebootInProgress)
+}
+
+// resetStateAfterManualReboot removes leftovers of the reboot process, which became stale because
+// the node has been rebooted outside of the agent, so the node starts from an idle state.
+func (k *klocksmith) resetStateAfterManualReboot(ctx context.Context) error {
+	k.logger.Info("Node has been rebooted without agent initiating the reboot, resetting reboot state")
+
+	err := k.updateNode(ctx, func(node *corev1.Node) {
+		node.Annotations[constants.AnnotationDrainFailed] = constants.False
+
+		delete(node.Annotations, constants.AnnotationRebootNeededSince)
+		delete(node.Annotations, constants.AnnotationUsrPartitionBeforeReboot)
+		delete(node.Annotations, constants.AnnotationEmergencyReboot)
+	})
+
+	k.recordNodeUpdateResult(err)
+
+	if err != nil {
+		return fmt.Errorf("resetting reboot state of node %q: %w", k.nodeName, err)
+	}
+
+	k.nodeEventf(corev1.EventTypeWarning, EventReasonManualRebootDetected,
+		"Node has been rebooted outside of the update-agent, reboot state has been reset")
+
+	return nil
+}
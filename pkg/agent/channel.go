@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+// startChannelReconciliation starts reconciling the update channel, if supported by the update source.
+func (k *klocksmith) startChannelReconciliation(ctx context.Context) {
+	channelManager, ok := k.ue.(ChannelManager)
+	if !ok {
+		k.logger.Info("Update source does not support changing update channel, channel will not be reconciled")
+
+		return
+	}
+
+	go k.watchChannelRequests(ctx, channelManager)
+}
+
+// watchChannelRequests watches the node object for the annotation with desired update channel and
+// when it differs from the channel used by the update source, changes the channel.
+func (k *klocksmith) watchChannelRequests(ctx context.Context, channelManager ChannelManager) {
+	k.logger.Info("Beginning to reconcile update channel", "annotation", constants.AnnotationChannel)
+
+	requests := make(chan struct{}, 1)
+
+	requestedF := func(_, node *corev1.Node) {
+		if node.Name != k.nodeName || node.Annotations[constants.AnnotationChannel] == "" {
+			return
+		}
+
+		// Requests are coalesced while previous one is being handled.
+		select {
+		case requests <- struct{}{}:
+		default:
+		}
+	}
+
+	if err := k.watchOwnNode(ctx, requestedF); err != nil {
+		k.logger.Error(err, "Failed watching for update channel changes")
+
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-requests:
+			if err := k.reconcileChannelOnce(ctx, channelManager); err != nil {
+				k.logger.Error(err, "Failed reconciling update channel")
+			}
+		}
+	}
+}
+
+// reconcileChannelOnce changes the update channel to the one set in the node annotation, unless the
+// update source already uses it. Failed changes are retried on next node update.
+func (k *klocksmith) reconcileChannelOnce(ctx context.Context, channelManager ChannelManager) error {
+	node, err := k8sutil.GetNodeRetry(ctx, k.nc, k.nodeName)
+	if err != nil {
+		return fmt.Errorf("getting node %q: %w", k.nodeName, err)
+	}
+
+	desiredChannel := node.Annotations[constants.AnnotationChannel]
+	if desiredChannel == "" {
+		return nil
+	}
+
+	currentChannel, err := channelManager.GetChannel()
+	if err != nil {
+		return fmt.Errorf("getting current update channel: %w", err)
+	}
+
+	if currentChannel == desiredChannel {
+		k.logger.V(4).Info("Update channel is already set", "channel", desiredChannel)
+
+		return nil
+	}
+
+	k.logger.Info("Changing update channel", "from", currentChannel, "to", desiredChannel)
+
+	if err := channelManager.SetChannel(desiredChannel); err != nil {
+		return fmt.Errorf("changing update channel to %q: %w", desiredChannel, err)
+	}
+
+	k.nodeEventf(corev1.EventTypeNormal, EventReasonChannelChanged,
+		"Update channel changed from %q to %q", currentChannel, desiredChannel)
+
+	return nil
+}
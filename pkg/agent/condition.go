@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NodeConditionRebootRequired is a type of node condition maintained by the agent when configured, which
+// is true when the node needs a reboot to apply a pending update or rollback.
+const NodeConditionRebootRequired corev1.NodeConditionType = "RebootRequired"
+
+// Reasons of the RebootRequired node condition.
+const (
+	ConditionReasonUpdateDownloaded  = "UpdateDownloaded"
+	ConditionReasonRollbackPrepared  = "RollbackPrepared"
+	ConditionReasonUpdateStatusReset = "UpdateStatusReset"
+	ConditionReasonNoPendingUpdate   = "NoPendingUpdate"
+)
+
+// setRebootRequiredCondition sets the RebootRequired condition of the node to a given status, reason and
+// message. Transition time is preserved when status of the condition does not change.
+//
+// Condition is informational only, so failures are logged. If maintaining the condition is not configured,
+// nothing is done.
+func (k *klocksmith) setRebootRequiredCondition(
+	ctx context.Context, status corev1.ConditionStatus, reason, message string,
+) {
+	if !k.rebootRequiredCondition {
+		return
+	}
+
+	if err := k.patchRebootRequiredCondition(ctx, status, reason, message); err != nil {
+		k.logger.Error(err, "Failed setting node condition", "type", NodeConditionRebootRequired)
+	}
+}
+
+func (k *klocksmith) patchRebootRequiredCondition(
+	ctx context.Context, status corev1.ConditionStatus, reason, message string,
+) error {
+	node, err := k.nc.Get(ctx, k.nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("getting node %q: %w", k.nodeName, err)
+	}
+
+	now := metav1.Now()
+
+	condition := corev1.NodeCondition{
+		Type:               NodeConditionRebootRequired,
+		Status:             status,
+		LastHeartbeatTime:  now,
+		LastTransitionTime: now,
+		Reason:             reason,
+		Message:            message,
+	}
+
+	for _, existing := range node.Status.Conditions {
+		if existing.Type == NodeConditionRebootRequired && existing.Status == status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+	}
+
+	// Conditions are merged by type using strategic merge patch, so conditions managed by kubelet
+	// and other components are not affected.
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []corev1.NodeCondition{condition},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("encoding node condition patch: %w", err)
+	}
+
+	if _, err := k.nc.PatchStatus(ctx, k.nodeName, patch); err != nil {
+		return fmt.Errorf("patching status of node %q: %w", k.nodeName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/rebootstate"
+)
+
+const (
+	// DrainFailurePolicyProceed reboots the node even when not all pods could be removed.
+	DrainFailurePolicyProceed = "Proceed"
+
+	// DrainFailurePolicyAbort aborts the reboot when not all pods could be removed, so the node
+	// gets back into service and reboot is attempted again later.
+	DrainFailurePolicyAbort = "Abort"
+
+	defaultDrainRetryInterval = 10 * time.Second
+
+	// maxDrainRetryInterval caps the exponential backoff between drain attempts.
+	maxDrainRetryInterval = 5 * time.Minute
+
+	// Values of result label of drain duration metric.
+	drainResultSuccess = "success"
+	drainResultFailure = "failure"
+)
+
+// drainWithRetries drains the node, retrying with exponential backoff until configured retry budget
+// is exhausted. When node could not be drained, reboot either proceeds or gets aborted according to
+// configured drain failure policy.
+//
+// Time spent draining is recorded as a metric, labeled by whether node has been drained or not.
+func (k *klocksmith) drainWithRetries(ctx context.Context) error {
+	start := time.Now()
+	deadline := start.Add(k.drainRetryBudget)
+	result := drainResultFailure
+
+	defer func() {
+		k.metrics.drainDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	}()
+
+	backoff := wait.Backoff{
+		Duration: k.drainRetryInterval,
+		Factor:   2, //nolint:gomnd // Double the interval after each attempt.
+		Steps:    math.MaxInt32,
+		Cap:      maxDrainRetryInterval,
+	}
+
+	for attempt := 1; ; attempt++ {
+		drained, err := k.drain(ctx)
+		if err != nil {
+			return err
+		}
+
+		if drained {
+			result = drainResultSuccess
+
+			return nil
+		}
+
+		delay := backoff.Step()
+		if time.Now().Add(delay).After(deadline) {
+			break
+		}
+
+		k.logger.Info("Draining node failed, retrying", "attempt", attempt, "delay", delay)
+
+		sleepOrDone(delay, ctx.Done())
+
+		if ctx.Err() != nil {
+			return fmt.Errorf("waiting to retry draining node: %w", ctx.Err())
+		}
+	}
+
+	if k.drainFailurePolicy == DrainFailurePolicyProceed {
+		k.nodeEventf(corev1.EventTypeWarning, EventReasonDrainFailed,
+			"Node could not be drained, proceeding with reboot")
+
+		k.logger.Error(nil, "Ignoring node drain error and proceeding with reboot")
+
+		return nil
+	}
+
+	return k.abortReboot(ctx)
+}
+
+// abortReboot reports that node could not be drained and resets reboot in progress annotation, so
+// after agent restarts, node is made schedulable again and operator may schedule the reboot again.
+func (k *klocksmith) abortReboot(ctx context.Context) error {
+	k.nodeEventf(corev1.EventTypeWarning, EventReasonRebootAborted, "Node could not be drained, aborting reboot")
+
+	err := k.updateNode(ctx, func(node *corev1.Node) {
+		node.Annotations[constants.AnnotationDrainFailed] = constants.True
+
+		rebootstate.NodeRebootState{RebootInProgress: rebootstate.Bool(false)}.ApplyTo(node)
+	})
+	if err != nil {
+		return fmt.Errorf("setting node %q annotations: %w", k.nodeName, err)
+	}
+
+	return fmt.Errorf("node could not be drained, reboot aborted")
+}
@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+// emergencyRebootRequested returns true when given node annotations request an emergency reboot.
+func emergencyRebootRequested(annotations map[string]string) bool {
+	return annotations[constants.AnnotationEmergencyReboot] == constants.True
+}
+
+// emergencyReboot returns true when an emergency reboot of the node has been requested. In such case,
+// the node is drained and rebooted without waiting for pause file removal, reboot window or jobs completion.
+func (k *klocksmith) emergencyReboot(ctx context.Context) (bool, error) {
+	node, err := k8sutil.GetNodeRetry(ctx, k.nc, k.nodeName)
+	if err != nil {
+		return false, fmt.Errorf("getting node %q: %w", k.nodeName, err)
+	}
+
+	if !emergencyRebootRequested(node.Annotations) {
+		return false, nil
+	}
+
+	k.logger.Info("Emergency reboot requested, rebooting without waiting for reboot window and jobs")
+
+	k.nodeEventf(corev1.EventTypeWarning, EventReasonEmergencyReboot,
+		"Emergency reboot requested using %q annotation, rebooting immediately", constants.AnnotationEmergencyReboot)
+
+	return true, nil
+}
+
+// waitForRebootConditions blocks until the node may be rebooted according to the pause file and
+// the reboot window.
+func (k *klocksmith) waitForRebootConditions(ctx context.Context) error {
+	k.state.setPhase(PhaseWaitingForPauseFileRemoval)
+
+	if err := k.waitForPauseFileRemoval(ctx); err != nil {
+		return fmt.Errorf("waiting for pause file removal: %w", err)
+	}
+
+	k.state.setPhase(PhaseWaitingForRebootWindow)
+
+	return k.waitForRebootWindow(ctx)
+}
+
+// clearEmergencyReboot removes the emergency reboot annotation from given node once it has been rebooted,
+// so it does not get rebooted again.
+func (k *klocksmith) clearEmergencyReboot(ctx context.Context, node *corev1.Node) error {
+	if _, ok := node.Annotations[constants.AnnotationEmergencyReboot]; !ok {
+		return nil
+	}
+
+	k.logger.Info("Removing annotation after reboot", "annotation", constants.AnnotationEmergencyReboot)
+
+	err := k.updateNode(ctx, func(node *corev1.Node) {
+		delete(node.Annotations, constants.AnnotationEmergencyReboot)
+	})
+
+	k.recordNodeUpdateResult(err)
+
+	if err != nil {
+		return fmt.Errorf("removing %q annotation from node %q: %w", constants.AnnotationEmergencyReboot, k.nodeName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	eventSourceComponent = "update-agent"
+
+	// EventReasonRebootAborted is a reason of the event emitted when node could not be drained
+	// within configured retry budget and reboot is aborted.
+	EventReasonRebootAborted = "RebootAborted"
+
+	// EventReasonDrainFailed is a reason of the event emitted when node could not be drained
+	// within configured retry budget, but reboot proceeds anyway.
+	EventReasonDrainFailed = "DrainFailed"
+
+	// EventReasonUpdateFailed is a reason of the event emitted when update source reports
+	// a failed update attempt.
+	EventReasonUpdateFailed = "UpdateFailed"
+
+	// EventReasonLeftUnschedulable is a reason of the event emitted when node is left unschedulable
+	// after the reboot as configured, so workloads must be re-admitted manually.
+	EventReasonLeftUnschedulable = "LeftUnschedulable"
+
+	// EventReasonEmergencyReboot is a reason of the event emitted when node is rebooted because of
+	// the emergency reboot annotation, without waiting for the operator approval.
+	EventReasonEmergencyReboot = "EmergencyReboot"
+
+	// EventReasonManualRebootDetected is a reason of the event emitted when node has been rebooted
+	// without the agent initiating the reboot and stale reboot state has been reset.
+	EventReasonManualRebootDetected = "ManualRebootDetected"
+
+	// EventReasonChannelChanged is a reason of the event emitted when update channel has been changed
+	// according to the channel annotation.
+	EventReasonChannelChanged = "ChannelChanged"
+
+	// EventReasonUpdateStatusReset is a reason of the event emitted when update source status has been
+	// reset according to the reset-update-status annotation.
+	EventReasonUpdateStatusReset = "UpdateStatusReset"
+
+	// EventReasonRollbackPrepared is a reason of the event emitted when rollback to the previously booted
+	// partition has been prepared according to the rollback annotation.
+	EventReasonRollbackPrepared = "RollbackPrepared"
+
+	// EventReasonRebootCancelled is a reason of the event emitted when the scheduled reboot has been
+	// cancelled, as the reboot approval was revoked during the reboot warning delay.
+	EventReasonRebootCancelled = "RebootCancelled"
+)
+
+// newEventRecorder creates event recorder publishing events using given client.
+func newEventRecorder(client kubernetes.Interface) record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&corev1client.EventSinkImpl{
+		// Events for cluster-scoped objects like Nodes are stored in the default namespace.
+		Interface: client.CoreV1().Events(""),
+	})
+
+	return broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{
+		Component: eventSourceComponent,
+	})
+}
+
+// nodeEventf records an event for the agent's node.
+//
+// UID is set to node name, as this is what kubelet and kubectl use for Node events.
+func (k *klocksmith) nodeEventf(eventType, reason, messageFmt string, args ...interface{}) {
+	node := &corev1.ObjectReference{
+		Kind: "Node",
+		Name: k.nodeName,
+		UID:  types.UID(k.nodeName),
+	}
+
+	k.eventRecorder.Eventf(node, eventType, reason, messageFmt, args...)
+}
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	defaultFallbackRebootTimeout = 10 * time.Minute
+
+	// fallbackRebootRequestTimeout limits how long requesting the reboot using fallback rebooter may take.
+	fallbackRebootRequestTimeout = 5 * time.Minute
+)
+
+// errRebootRequestFailed is wrapped by errors returned when configured Rebooter fails to request the reboot.
+var errRebootRequestFailed = errors.New("requesting reboot failed")
+
+// waitForReboot blocks until the host reboots and the agent gets terminated. If requesting the reboot failed,
+// as indicated by given error, configured fallback rebooter is used immediately. Otherwise, if the host does
+// not reboot within configured timeout, e.g. because of firmware hanging on shutdown, fallback rebooter is
+// used once the timeout passes. Fallback rebooter, e.g. rebooting the machine using cloud provider API,
+// is used at most once.
+func (k *klocksmith) waitForReboot(ctx context.Context, rebootErr error) {
+	if rebootErr != nil {
+		k.logger.Error(rebootErr, "Failed rebooting the host")
+	}
+
+	if k.fallbackRebooter == nil {
+		// Cross fingers.
+		sleepOrDone(24*7*time.Hour, ctx.Done())
+
+		return
+	}
+
+	if rebootErr == nil {
+		// Scheduled reboot is only requested once the warning delay passes.
+		sleepOrDone(k.rebootWarningDelay+k.fallbackRebootTimeout, ctx.Done())
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		k.logger.Info("Host did not reboot in time, rebooting using fallback rebooter", "timeout", k.fallbackRebootTimeout)
+	} else {
+		k.logger.Info("Rebooting using fallback rebooter")
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, fallbackRebootRequestTimeout)
+	defer cancel()
+
+	if err := k.fallbackRebooter.Reboot(requestCtx); err != nil {
+		k.logger.Error(err, "Failed rebooting using fallback rebooter")
+	}
+
+	sleepOrDone(24*7*time.Hour, ctx.Done())
+}
@@ -0,0 +1,33 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+)
+
+// usrPartitionEnv is a name of environment variable with the booted USR partition passed
+// to the mark boot successful command.
+const usrPartitionEnv = "FLUO_USR_PARTITION"
+
+// markBootSuccessful runs configured command marking the booted partition as successfully booted. It is
+// executed once the node passes post-reboot verification, so when update_engine is configured not to mark
+// the boot as successful on its own, a bad update rolls back on the next reboot.
+func (k *klocksmith) markBootSuccessful(ctx context.Context) error {
+	if k.markBootSuccessfulCommand == "" {
+		return nil
+	}
+
+	k.logger.Info("Marking boot from USR partition as successful", "partition", k.usrPartition)
+
+	h := hook{
+		name: k.markBootSuccessfulCommand,
+		args: []string{hookShell, "-c", k.markBootSuccessfulCommand},
+		env:  []string{fmt.Sprintf("%s=%s", usrPartitionEnv, k.usrPartition)},
+	}
+
+	if err := runHook(ctx, h, defaultHookTimeout); err != nil {
+		return fmt.Errorf("running mark boot successful command: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package agent
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+// heartbeatLeaseDurationFactor is a number of heartbeat intervals for which heartbeat lease is held,
+// so a single failed renewal does not make the agent look dead.
+const heartbeatLeaseDurationFactor = 3
+
+// reportHeartbeat renews heartbeat lease of the node in configured interval until given context
+// is cancelled. Failed renewals are only logged, as they are retried with the next heartbeat.
+//
+// If heartbeat is not configured, nothing is done.
+func (k *klocksmith) reportHeartbeat(ctx context.Context) {
+	if k.heartbeatInterval == 0 {
+		return
+	}
+
+	leases := k.clientset.CoordinationV1().Leases(k.heartbeatNamespace)
+	leaseDuration := heartbeatLeaseDurationFactor * k.heartbeatInterval
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := k8sutil.RenewHeartbeatLease(ctx, leases, k.nodeName, k.nodeName, leaseDuration); err != nil {
+			k.logger.Error(err, "Failed renewing heartbeat lease")
+		}
+	}, k.heartbeatInterval)
+}
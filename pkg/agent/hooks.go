@@ -0,0 +1,175 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// HookFailurePolicyFail aborts the reboot when a hook fails.
+	HookFailurePolicyFail = "Fail"
+
+	// HookFailurePolicyIgnore logs hook failures and proceeds with the reboot.
+	HookFailurePolicyIgnore = "Ignore"
+
+	defaultHookTimeout = 5 * time.Minute
+
+	// hookShell is used to execute hook commands.
+	hookShell = "/bin/sh"
+)
+
+// hook is a command executed by the agent at a certain point of the reboot process.
+type hook struct {
+	name string
+	args []string
+	// Additional environment variables in key=value form.
+	env []string
+}
+
+// preDrainHooks returns hooks to run before draining the node. Configured command runs first,
+// followed by executables from configured directory in lexical order.
+func (k *klocksmith) preDrainHooks() ([]hook, error) {
+	hooks := []hook{}
+
+	if k.preDrainHookCommand != "" {
+		hooks = append(hooks, hook{
+			name: k.preDrainHookCommand,
+			args: []string{hookShell, "-c", k.preDrainHookCommand},
+		})
+	}
+
+	if k.preDrainHooksDirectory == "" {
+		return hooks, nil
+	}
+
+	entries, err := os.ReadDir(k.preDrainHooksDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("reading hooks directory %q: %w", k.preDrainHooksDirectory, err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	for _, entry := range entries {
+		path := filepath.Join(k.preDrainHooksDirectory, entry.Name())
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("checking hook %q: %w", path, err)
+		}
+
+		if !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			k.logger.V(4).Info("Skipping file in hooks directory, as it is not executable", "path", path)
+
+			continue
+		}
+
+		hooks = append(hooks, hook{
+			name: path,
+			args: []string{path},
+		})
+	}
+
+	return hooks, nil
+}
+
+// runPreDrainHooks runs configured pre-drain hooks one by one, each limited by configured timeout.
+//
+// When hook fails and failure policy is HookFailurePolicyFail, error is returned and remaining hooks
+// are not executed. Otherwise, failure is logged and remaining hooks are executed.
+func (k *klocksmith) runPreDrainHooks(ctx context.Context) error {
+	hooks, err := k.preDrainHooks()
+	if err != nil {
+		if k.preDrainHookFailurePolicy == HookFailurePolicyIgnore {
+			k.logger.Error(err, "Ignoring error getting pre-drain hooks")
+
+			return nil
+		}
+
+		return fmt.Errorf("getting pre-drain hooks: %w", err)
+	}
+
+	for _, h := range hooks {
+		k.logger.Info("Running pre-drain hook", "hook", h.name)
+
+		if err := runHook(ctx, h, k.preDrainHookTimeout); err != nil {
+			if k.preDrainHookFailurePolicy == HookFailurePolicyIgnore {
+				k.logger.Error(err, "Ignoring failed pre-drain hook", "hook", h.name)
+
+				continue
+			}
+
+			return fmt.Errorf("running pre-drain hook %q: %w", h.name, err)
+		}
+	}
+
+	return nil
+}
+
+// runHook executes given hook, killing it together with all processes it spawned when given timeout
+// is exceeded. Hook output is logged.
+func runHook(ctx context.Context, h hook, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output := &bytes.Buffer{}
+
+	cmd := exec.Command(h.args[0], h.args[1:]...) //nolint:gosec // Hooks are configured by the administrator.
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	if len(h.env) > 0 {
+		cmd.Env = append(os.Environ(), h.env...)
+	}
+
+	// Run hook in a separate process group, so processes spawned by the hook can be killed as well.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting: %w", err)
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var err error
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		if killErr := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); killErr != nil {
+			klog.FromContext(ctx).Error(killErr, "Failed killing hook", "hook", h.name)
+		}
+
+		<-done
+
+		err = ctx.Err()
+	}
+
+	if output.Len() > 0 {
+		klog.FromContext(ctx).Info("Output of hook", "hook", h.name, "output", output.String())
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("timed out after %v", timeout)
+	case err != nil:
+		return fmt.Errorf("executing: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package agent
+
+const (
+	inhibitWhat = "shutdown"
+	inhibitWho  = "flatcar-linux-update-agent"
+	inhibitWhy  = "Waiting for update-operator to approve the reboot"
+	inhibitMode = "block"
+)
+
+// acquireInhibitorLock takes the shutdown inhibitor lock, if configured, so the node is not rebooted
+// by anything else while the reboot waits for an approval from the operator. Calling it while the lock
+// is already held is a no-op.
+//
+// Failing to take the lock does not prevent the update, it only gets logged.
+func (k *klocksmith) acquireInhibitorLock() {
+	if k.inhibitor == nil || k.rebootsDisabled() {
+		return
+	}
+
+	k.inhibitorLock.Lock()
+	defer k.inhibitorLock.Unlock()
+
+	if k.inhibitorFile != nil {
+		return
+	}
+
+	file, err := k.inhibitor.Inhibit(inhibitWhat, inhibitWho, inhibitWhy, inhibitMode)
+	if err != nil {
+		k.logger.Error(err, "Failed taking inhibitor lock", "what", inhibitWhat)
+
+		return
+	}
+
+	k.logger.Info("Took inhibitor lock until the reboot is approved", "what", inhibitWhat)
+
+	k.inhibitorFile = file
+}
+
+// releaseInhibitorLock releases the shutdown inhibitor lock, if held, so the node can be rebooted.
+func (k *klocksmith) releaseInhibitorLock() {
+	k.inhibitorLock.Lock()
+	defer k.inhibitorLock.Unlock()
+
+	if k.inhibitorFile == nil {
+		return
+	}
+
+	if err := k.inhibitorFile.Close(); err != nil {
+		k.logger.Error(err, "Failed releasing inhibitor lock", "what", inhibitWhat)
+	} else {
+		k.logger.Info("Released inhibitor lock", "what", inhibitWhat)
+	}
+
+	k.inhibitorFile = nil
+}
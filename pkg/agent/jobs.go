@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+)
+
+// waitForJobsCompletion blocks before draining until pods of Jobs annotated with
+// constants.AnnotationWaitForCompletion finish running on the node or until configured
+// timeout is reached, so long-running batch workloads are not interrupted by reboots.
+//
+// Reaching the timeout is logged and ignored, so the node can still be drained and rebooted.
+func (k *klocksmith) waitForJobsCompletion(ctx context.Context) error {
+	if k.jobCompletionTimeout == 0 {
+		return nil
+	}
+
+	k.logger.Info("Waiting for Jobs running on the node to complete")
+
+	pollCtx, cancel := context.WithTimeout(ctx, k.jobCompletionTimeout)
+	defer cancel()
+
+	completedF := func(ctx context.Context) (bool, error) {
+		pods, err := k.runningJobPods(ctx)
+		if err != nil {
+			k.logger.Error(err, "Failed getting running Job pods")
+
+			return false, nil
+		}
+
+		if len(pods) > 0 {
+			k.logger.Info("Waiting for Job pods to complete", "pods", pods)
+
+			return false, nil
+		}
+
+		return true, nil
+	}
+
+	//nolint:staticcheck // New equivalent is buggy: https://github.com/kubernetes/kubernetes/issues/119533.
+	err := wait.PollImmediateUntilWithContext(pollCtx, k.pollInterval, completedF)
+
+	switch {
+	case err == nil:
+		k.logger.Info("No Jobs to wait for running on the node")
+	case ctx.Err() != nil:
+		return fmt.Errorf("waiting for Jobs to complete: %w", ctx.Err())
+	case errors.Is(err, wait.ErrWaitTimeout):
+		k.logger.Error(nil, "Jobs not completed in time, proceeding with draining node", "timeout", k.jobCompletionTimeout)
+	default:
+		return fmt.Errorf("waiting for Jobs to complete: %w", err)
+	}
+
+	return nil
+}
+
+// runningJobPods returns names of pods running on the node, which are owned by Jobs annotated with
+// constants.AnnotationWaitForCompletion.
+func (k *klocksmith) runningJobPods(ctx context.Context) ([]string, error) {
+	pods, err := k.clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", k.nodeName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+
+	jobPods := []string{}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		owner := metav1.GetControllerOf(pod)
+		if owner == nil || owner.Kind != "Job" {
+			continue
+		}
+
+		job, err := k.clientset.BatchV1().Jobs(pod.Namespace).Get(ctx, owner.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("getting Job %s/%s: %w", pod.Namespace, owner.Name, err)
+		}
+
+		if job.Annotations[constants.AnnotationWaitForCompletion] != constants.True {
+			continue
+		}
+
+		jobPods = append(jobPods, pod.Namespace+"/"+pod.Name)
+	}
+
+	return jobPods, nil
+}
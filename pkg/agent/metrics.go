@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	metricsNamespace = "flatcar_linux_update_agent"
+
+	// fluoMetricsNamespace is a prefix of metrics describing state of the fleet, which are meant
+	// to be used on dashboards and in alerts together with update-operator metrics.
+	fluoMetricsNamespace = "fluo"
+)
+
+// metrics holds Prometheus metrics exposed by the agent.
+type metrics struct {
+	lastStatusTimestamp      prometheus.Gauge
+	lastUpdateCheckTimestamp prometheus.Gauge
+	updateProgress           prometheus.Gauge
+	pendingUpdateSize        prometheus.Gauge
+	updateOperation          *prometheus.GaugeVec
+	updateAttemptsFailed     *prometheus.CounterVec
+	drainDuration            *prometheus.HistogramVec
+	drainPodsEvicted         *prometheus.CounterVec
+	drainPodsDeleted         *prometheus.CounterVec
+}
+
+// newMetrics creates agent metrics and registers them using given registerer. Reconnects are only
+// reported when given status receiver implements ReconnectCounter.
+func newMetrics(registerer prometheus.Registerer, statusReceiver StatusReceiver) (*metrics, error) {
+	m := &metrics{
+		lastStatusTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "last_status_timestamp_seconds",
+			Help:      "Unix time when the last status has been received from the update source.",
+		}),
+		lastUpdateCheckTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "last_update_check_timestamp_seconds",
+			Help:      "Unix time of the last update check reported by the update source.",
+		}),
+		updateProgress: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "update_progress_ratio",
+			Help:      "Progress of the current operation of the update source, e.g. downloading an update, from 0 to 1.",
+		}),
+		pendingUpdateSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "pending_update_size_bytes",
+			Help:      "Size of the available or downloaded update reported by the update source.",
+		}),
+		updateOperation: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: fluoMetricsNamespace,
+			Name:      "update_engine_status",
+			Help:      "Current operation of the update source, e.g. UPDATE_STATUS_DOWNLOADING, always set to 1.",
+		}, []string{"operation"}),
+		updateAttemptsFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "update_attempts_failed_total",
+			Help:      "Number of failed update attempts reported by the update source, by error code.",
+		}, []string{"error_code"}),
+		drainDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "drain_duration_seconds",
+			Help:      "Time it took to drain the node, including retries, by result.",
+			//nolint:gomnd // From 10 seconds to roughly 1.5 hours.
+			Buckets: prometheus.ExponentialBuckets(10, 2, 10),
+		}, []string{"result"}),
+		drainPodsEvicted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "drain_pods_evicted_total",
+			Help:      "Number of pods evicted while draining the node, by namespace.",
+		}, []string{"namespace"}),
+		drainPodsDeleted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "drain_pods_deleted_total",
+			Help:      "Number of pods deleted instead of evicted while draining the node, by namespace.",
+		}, []string{"namespace"}),
+	}
+
+	collectors := []prometheus.Collector{
+		m.lastStatusTimestamp,
+		m.lastUpdateCheckTimestamp,
+		m.updateProgress,
+		m.pendingUpdateSize,
+		m.updateOperation,
+		m.updateAttemptsFailed,
+		m.drainDuration,
+		m.drainPodsEvicted,
+		m.drainPodsDeleted,
+	}
+
+	if reconnectCounter, ok := statusReceiver.(ReconnectCounter); ok {
+		collectors = append(collectors, prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "dbus_reconnects_total",
+			Help:      "Number of times the connection to the update source has been re-established.",
+		}, func() float64 {
+			return float64(reconnectCounter.Reconnects())
+		}))
+	}
+
+	for _, collector := range collectors {
+		if err := registerer.Register(collector); err != nil {
+			return nil, fmt.Errorf("registering metric: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+// recordOperation records current operation of the update source, so only the current operation
+// is exposed.
+func (m *metrics) recordOperation(operation string) {
+	m.updateOperation.Reset()
+	m.updateOperation.WithLabelValues(operation).Set(1)
+}
+
+// podRemoved records pod removed while draining the node.
+func (m *metrics) podRemoved(pod *corev1.Pod, usingEviction bool) {
+	if usingEviction {
+		m.drainPodsEvicted.WithLabelValues(pod.Namespace).Inc()
+
+		return
+	}
+
+	m.drainPodsDeleted.WithLabelValues(pod.Namespace).Inc()
+}
@@ -0,0 +1,21 @@
+package agent
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/fields"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+// watchOwnNode calls given handler each time the agent's own Node object gets added or updated, so changes
+// to it are noticed as soon as they happen, without periodically getting the node from the API server.
+//
+// Watch is re-established when it gets closed by the API server.
+func (k *klocksmith) watchOwnNode(ctx context.Context, handler k8sutil.NodeHandler) error {
+	config := k8sutil.WatchNodesConfig{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", k.nodeName),
+	}
+
+	return k8sutil.WatchNodes(ctx, k.nc, config, handler)
+}
@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/updateengine"
+)
+
+const (
+	kernelCmdlinePath = "/proc/cmdline"
+	partUUIDPrefix    = "PARTUUID="
+
+	// Unique partition GUIDs of Flatcar USR partitions, as defined by the disk layout.
+	usrPartitionAUUID = "7130c94a-213a-4e5a-8e26-6cce9662f132"
+	usrPartitionBUUID = "e03dd35c-7c2d-4a47-b3fe-27f15780a57c"
+
+	// UsrPartitionA is a name of the first Flatcar USR partition.
+	UsrPartitionA = "USR-A"
+	// UsrPartitionB is a name of the second Flatcar USR partition.
+	UsrPartitionB = "USR-B"
+)
+
+// usrPartitionParameters are kernel command line parameters, which may point to the booted USR partition.
+//
+//nolint:gochecknoglobals // Read-only list.
+var usrPartitionParameters = []string{"verity.usr", "mount.usr", "usr"}
+
+// bootedUsrPartition returns name of the USR partition the host has been booted from, based on the
+// kernel command line. Unknown partitions are identified by their PARTUUID.
+func bootedUsrPartition(hostFilesPrefix string) (string, error) {
+	cmdline, err := os.ReadFile(filepath.Join(hostFilesPrefix, kernelCmdlinePath))
+	if err != nil {
+		return "", fmt.Errorf("reading kernel command line: %w", err)
+	}
+
+	parameters := map[string]string{}
+
+	for _, field := range strings.Fields(string(cmdline)) {
+		if keyValue := strings.SplitN(field, "=", 2); len(keyValue) == 2 { //nolint:gomnd // Key and value.
+			parameters[keyValue[0]] = keyValue[1]
+		}
+	}
+
+	for _, parameter := range usrPartitionParameters {
+		value := parameters[parameter]
+		if !strings.HasPrefix(strings.ToUpper(value), partUUIDPrefix) {
+			continue
+		}
+
+		switch partUUID := strings.ToLower(value[len(partUUIDPrefix):]); partUUID {
+		case usrPartitionAUUID:
+			return UsrPartitionA, nil
+		case usrPartitionBUUID:
+			return UsrPartitionB, nil
+		default:
+			return partUUID, nil
+		}
+	}
+
+	return "", fmt.Errorf("no USR partition found in kernel command line %q", strings.TrimSpace(string(cmdline)))
+}
+
+// recordBootedPartition adds annotations describing USR partition the node has been booted from into
+// given annotations map. When the node has just been rebooted to apply an update, the partition is compared
+// with the partition booted before the reboot. Booting the same partition means that the new partition
+// failed to boot and the node rolled back to the previous version.
+//
+// Partition is not reported when it can't be determined, e.g. when not running on Flatcar.
+func (k *klocksmith) recordBootedPartition(anno, nodeAnnotations map[string]string, rebootFinished bool) {
+	if k.usrPartition == "" {
+		return
+	}
+
+	anno[constants.AnnotationUsrPartition] = k.usrPartition
+
+	if !rebootFinished {
+		return
+	}
+
+	partitionBeforeReboot, ok := nodeAnnotations[constants.AnnotationUsrPartitionBeforeReboot]
+	if !ok || partitionBeforeReboot != k.usrPartition {
+		anno[constants.AnnotationRolledBack] = constants.False
+
+		return
+	}
+
+	k.logger.Info("Node booted from the same USR partition as before rebooting to apply an update, "+
+		"update has been rolled back", "partition", k.usrPartition)
+
+	anno[constants.AnnotationRolledBack] = constants.True
+}
+
+// setUsrPartitionBeforeReboot remembers booted USR partition on given node when it is about to be rebooted
+// to apply an update, so rollback can be detected after the reboot.
+func (k *klocksmith) setUsrPartitionBeforeReboot(node *corev1.Node) {
+	if k.usrPartition == "" || node.Annotations[constants.AnnotationStatus] != updateengine.UpdateStatusUpdatedNeedReboot {
+		delete(node.Annotations, constants.AnnotationUsrPartitionBeforeReboot)
+
+		return
+	}
+
+	node.Annotations[constants.AnnotationUsrPartitionBeforeReboot] = k.usrPartition
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+)
+
+// watchPauseFile periodically checks if configured pause file exists and reflects it in the node
+// annotation, so operator does not consider the node for rebooting while the file exists.
+func (k *klocksmith) watchPauseFile(ctx context.Context) {
+	k.logger.Info("Beginning to watch pause file", "path", k.pauseFile)
+
+	paused, reported := false, false
+
+	//nolint:staticcheck // New equivalent is buggy: https://github.com/kubernetes/kubernetes/issues/119533.
+	err := wait.PollImmediateUntilWithContext(ctx, k.pollInterval, func(ctx context.Context) (bool, error) {
+		exists, err := fileExists(k.pauseFile)
+		if err != nil {
+			k.logger.Error(err, "Failed checking pause file")
+
+			return false, nil
+		}
+
+		if reported && exists == paused {
+			return false, nil
+		}
+
+		if err := k.setRebootPaused(ctx, exists); err != nil {
+			k.logger.Error(err, "Failed updating reboot paused annotation")
+
+			return false, nil
+		}
+
+		paused, reported = exists, true
+
+		return false, nil
+	})
+	if err != nil && !errors.Is(err, wait.ErrWaitTimeout) {
+		k.logger.Error(err, "Failed watching pause file")
+	}
+}
+
+// setRebootPaused sets annotation on the node indicating whether reboots are paused using the pause file.
+func (k *klocksmith) setRebootPaused(ctx context.Context, paused bool) error {
+	if paused {
+		k.logger.Info("Pause file found, pausing reboots", "path", k.pauseFile)
+	} else {
+		k.logger.Info("Pause file not found, reboots are not paused", "path", k.pauseFile)
+	}
+
+	err := k.updateNode(ctx, func(node *corev1.Node) {
+		node.Annotations[constants.AnnotationAgentRebootPaused] = strconv.FormatBool(paused)
+	})
+
+	k.recordNodeUpdateResult(err)
+
+	if err != nil {
+		return fmt.Errorf("updating node %q: %w", k.nodeName, err)
+	}
+
+	return nil
+}
+
+// waitForPauseFileRemoval blocks until configured pause file does not exist. It allows to block
+// the reboot process when pause file gets created after operator already approved the reboot.
+func (k *klocksmith) waitForPauseFileRemoval(ctx context.Context) error {
+	if k.pauseFile == "" {
+		return nil
+	}
+
+	//nolint:staticcheck // New equivalent is buggy: https://github.com/kubernetes/kubernetes/issues/119533.
+	return wait.PollImmediateUntilWithContext(ctx, k.pollInterval, func(ctx context.Context) (bool, error) {
+		exists, err := fileExists(k.pauseFile)
+		if err != nil {
+			return false, fmt.Errorf("checking pause file: %w", err)
+		}
+
+		if exists {
+			k.logger.Info("Pause file exists, waiting with reboot until it is removed", "path", k.pauseFile)
+		}
+
+		return !exists, nil
+	})
+}
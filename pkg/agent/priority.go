@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// podGracePeriod instructs drainer to respect termination grace period configured in pod spec.
+const podGracePeriod = -1
+
+// priorityTier is a group of pods removed together while draining the node.
+type priorityTier struct {
+	// Minimum priority of pods in the tier.
+	minPriority int32
+	// Termination grace period in seconds used for pods in the tier.
+	gracePeriodSeconds int
+	pods               []corev1.Pod
+}
+
+// checkPriorityGracePeriods validates termination grace periods configured for pod priority thresholds.
+func checkPriorityGracePeriods(gracePeriods map[int32]time.Duration) error {
+	for priority, gracePeriod := range gracePeriods {
+		if gracePeriod < 0 {
+			return fmt.Errorf("termination grace period %v for priority %d can't be negative", gracePeriod, priority)
+		}
+	}
+
+	return nil
+}
+
+// priorityTiers splits given pods into tiers based on configured priority thresholds. Tiers are returned
+// ordered from the lowest to the highest priority, so less important pods are removed first.
+//
+// Pods with priority below all configured thresholds use their own termination grace period.
+func (k *klocksmith) priorityTiers(pods []corev1.Pod) []priorityTier {
+	thresholds := make([]int32, 0, len(k.drainPriorityGracePeriods))
+	for priority := range k.drainPriorityGracePeriods {
+		thresholds = append(thresholds, priority)
+	}
+
+	sort.Slice(thresholds, func(i, j int) bool {
+		return thresholds[i] < thresholds[j]
+	})
+
+	tiers := []priorityTier{{minPriority: math.MinInt32, gracePeriodSeconds: podGracePeriod}}
+
+	for _, priority := range thresholds {
+		tiers = append(tiers, priorityTier{
+			minPriority:        priority,
+			gracePeriodSeconds: int(k.drainPriorityGracePeriods[priority].Seconds()),
+		})
+	}
+
+	for _, pod := range pods {
+		priority := podPriority(pod)
+
+		i := sort.Search(len(tiers), func(i int) bool {
+			return tiers[i].minPriority > priority
+		})
+
+		tiers[i-1].pods = append(tiers[i-1].pods, pod)
+	}
+
+	nonEmptyTiers := []priorityTier{}
+
+	for _, tier := range tiers {
+		if len(tier.pods) > 0 {
+			nonEmptyTiers = append(nonEmptyTiers, tier)
+		}
+	}
+
+	return nonEmptyTiers
+}
+
+// podPriority returns priority of a given pod. Pods without priority set are treated as having
+// priority 0, same as kube-scheduler does.
+func podPriority(pod corev1.Pod) int32 {
+	if pod.Spec.Priority == nil {
+		return 0
+	}
+
+	return *pod.Spec.Priority
+}
@@ -0,0 +1,189 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/rebootstate"
+)
+
+const (
+	// DefaultRebootCommand is executed by CommandRebooter when no command is configured.
+	DefaultRebootCommand = "systemctl reboot"
+
+	defaultRebootWallMessage = "Rebooting to apply OS update approved by update-operator"
+)
+
+// CommandRebooter implements Rebooter by executing a command using /bin/sh, e.g. "systemctl reboot" or
+// a custom reboot flow.
+type CommandRebooter struct {
+	command string
+}
+
+// NewCommandRebooter returns rebooter executing given command. DefaultRebootCommand is used when given
+// command is empty.
+func NewCommandRebooter(command string) *CommandRebooter {
+	if command == "" {
+		command = DefaultRebootCommand
+	}
+
+	return &CommandRebooter{command: command}
+}
+
+// Reboot implements Rebooter interface.
+func (r *CommandRebooter) Reboot(bool) error {
+	klog.InfoS("Rebooting using command", "command", r.command)
+
+	rebootHook := hook{
+		name: r.command,
+		args: []string{hookShell, "-c", r.command},
+	}
+
+	if err := runHook(context.Background(), rebootHook, defaultHookTimeout); err != nil {
+		return fmt.Errorf("running reboot command: %w", err)
+	}
+
+	return nil
+}
+
+// NoopRebooter implements Rebooter which never reboots the host, e.g. for testing the update flow.
+type NoopRebooter struct{}
+
+// Reboot implements Rebooter interface.
+func (NoopRebooter) Reboot(bool) error {
+	klog.InfoS("Reboot requested, but rebooting is disabled, reboot the host manually to finish the update")
+
+	return nil
+}
+
+// rebootWarning validates configuration of the delayed reboot and returns the scheduler to use, if configured,
+// together with the wall message.
+func rebootWarning(config *Config) (RebootScheduler, string, error) {
+	if config.RebootWarningDelay < 0 {
+		return nil, "", fmt.Errorf("reboot warning delay must not be negative, got %v", config.RebootWarningDelay)
+	}
+
+	if config.RebootWarningDelay == 0 {
+		return nil, "", nil
+	}
+
+	rebootScheduler, ok := config.Rebooter.(RebootScheduler)
+	if !ok {
+		return nil, "", fmt.Errorf("configured rebooter does not support delaying the reboot")
+	}
+
+	message := config.RebootWallMessage
+	if message == "" {
+		message = defaultRebootWallMessage
+	}
+
+	return rebootScheduler, message, nil
+}
+
+// reboot reboots the host. When reboot warning delay is configured, the reboot is scheduled instead, so users
+// logged in on the host are warned before. If scheduling fails, the host is rebooted immediately.
+//
+// Scheduled reboot is cancelled when the reboot approval is revoked during the delay, in which case true is
+// returned. When requesting immediate reboot fails, returned error wraps errRebootRequestFailed.
+func (k *klocksmith) reboot(ctx context.Context) (bool, error) {
+	if k.rebootScheduler == nil {
+		return false, k.requestReboot()
+	}
+
+	k.logger.Info("Scheduling reboot", "delay", k.rebootWarningDelay)
+
+	scheduledTime := time.Now().Add(k.rebootWarningDelay)
+
+	if err := k.rebootScheduler.ScheduleReboot(k.rebootWallMessage, k.rebootWarningDelay); err != nil {
+		k.logger.Error(err, "Failed scheduling reboot, rebooting immediately")
+
+		return false, k.requestReboot()
+	}
+
+	// Let the operator know the reboot is pending, so it may revoke the approval if rebooting is
+	// no longer allowed, e.g. when blackout window starts.
+	err := k.updateNode(ctx, func(node *corev1.Node) {
+		node.Annotations[constants.AnnotationRebootScheduledTime] = scheduledTime.UTC().Format(time.RFC3339)
+	})
+	if err != nil {
+		k.logger.Error(err, "Failed setting scheduled reboot time")
+	}
+
+	delayCtx, cancel := context.WithTimeout(ctx, k.rebootWarningDelay)
+	defer cancel()
+
+	if err := k.waitForNotOkToReboot(delayCtx); err != nil {
+		if delayCtx.Err() == nil {
+			k.logger.Error(err, "Failed watching for revoked reboot approval, reboot will not be cancelled")
+		}
+
+		return false, nil
+	}
+
+	if err := k.cancelScheduledReboot(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// requestReboot requests immediate reboot of the host.
+func (k *klocksmith) requestReboot() error {
+	if err := k.lc.Reboot(false); err != nil {
+		return fmt.Errorf("%w: %v", errRebootRequestFailed, err)
+	}
+
+	return nil
+}
+
+// cancelScheduledReboot cancels the scheduled reboot and resets the reboot state of the node, so it is made
+// schedulable again and the operator may approve the reboot again later.
+func (k *klocksmith) cancelScheduledReboot(ctx context.Context) error {
+	k.logger.Info("Reboot approval has been revoked, cancelling scheduled reboot")
+
+	cancelled, err := k.rebootScheduler.CancelScheduledReboot()
+	if err != nil {
+		return fmt.Errorf("cancelling scheduled reboot: %w", err)
+	}
+
+	if !cancelled {
+		k.logger.Info("No scheduled reboot found, it has been cancelled on the host already")
+	}
+
+	k.nodeEventf(corev1.EventTypeNormal, EventReasonRebootCancelled,
+		"Reboot approval has been revoked, scheduled reboot has been cancelled")
+
+	rebootNeededSince := time.Now().UTC().Format(time.RFC3339)
+
+	err = k.updateNode(ctx, func(node *corev1.Node) {
+		rebootstate.NodeRebootState{RebootInProgress: rebootstate.Bool(false)}.ApplyTo(node)
+
+		delete(node.Annotations, constants.AnnotationRebootScheduledTime)
+
+		// Reboot is pending again.
+		setRebootNeededSince(node, rebootNeededSince)
+	})
+	if err != nil {
+		return fmt.Errorf("setting node %q annotations: %w", k.nodeName, err)
+	}
+
+	node, err := k8sutil.GetNodeRetry(ctx, k.nc, k.nodeName)
+	if err != nil {
+		return fmt.Errorf("getting node %q: %w", k.nodeName, err)
+	}
+
+	if err := k.restoreSchedulability(ctx, node, false); err != nil {
+		return err
+	}
+
+	// Update is still waiting for the reboot.
+	k.acquireInhibitorLock()
+
+	return nil
+}
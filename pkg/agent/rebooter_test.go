@@ -0,0 +1,31 @@
+package agent_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/agent"
+)
+
+func Test_Command_rebooter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("executes_configured_command_on_reboot", func(t *testing.T) {
+		t.Parallel()
+
+		rebootedFile := filepath.Join(t.TempDir(), "rebooted")
+
+		agent.NewCommandRebooter("touch " + rebootedFile).Reboot(false)
+
+		if _, err := os.Stat(rebootedFile); err != nil {
+			t.Fatalf("Expected reboot command to create file: %v", err)
+		}
+	})
+
+	t.Run("does_not_panic_when_command_fails", func(t *testing.T) {
+		t.Parallel()
+
+		agent.NewCommandRebooter("exit 1").Reboot(false)
+	})
+}
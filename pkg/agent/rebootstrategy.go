@@ -0,0 +1,35 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/flatcar"
+)
+
+// readRebootStrategy returns reboot strategy configured in update.conf on the host. Empty strategy is
+// returned when reading the configuration fails.
+func (k *klocksmith) readRebootStrategy() string {
+	updateConfig, err := flatcar.ReadUpdateConfig(k.hostFilesPrefix)
+	if err != nil {
+		k.logger.Error(err, "Failed reading update configuration, assuming default reboot strategy")
+
+		return ""
+	}
+
+	return updateConfig.RebootStrategy
+}
+
+// rebootsDisabled returns true when automatic reboots are disabled on the host.
+func (k *klocksmith) rebootsDisabled() bool {
+	return k.rebootStrategy == flatcar.RebootStrategyOff
+}
+
+// reportUpdateStatusOnly blocks until given context is cancelled, so the agent keeps reporting
+// the update status, but never reboots the node.
+func (k *klocksmith) reportUpdateStatusOnly(ctx context.Context) {
+	k.logger.Info("Reboot strategy in update.conf only allows reporting update status", "strategy", k.rebootStrategy)
+
+	k.state.setPhase(PhaseReportingOnly)
+
+	<-ctx.Done()
+}
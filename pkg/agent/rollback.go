@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/rebootstate"
+)
+
+// watchRollbackRequests watches the node object for the annotation requesting rollback and when it is set,
+// prepares the rollback, indicates that the node needs a reboot and removes the annotation.
+func (k *klocksmith) watchRollbackRequests(ctx context.Context, rollbacker Rollbacker) {
+	k.watchAnnotationRequests(ctx, constants.AnnotationRollback, func(ctx context.Context) error {
+		return k.rollback(ctx, rollbacker)
+	})
+}
+
+// rollback asks the update source to make the previously booted partition active and indicates that
+// the node needs a reboot, so the reboot is coordinated by the operator like for regular updates.
+// Annotation is removed even if the rollback fails, so failing requests are not retried forever.
+//
+// Requests are ignored once the reboot is in progress, as the node is already being drained.
+func (k *klocksmith) rollback(ctx context.Context, rollbacker Rollbacker) error {
+	node, err := k.nc.Get(ctx, k.nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("getting node %q: %w", k.nodeName, err)
+	}
+
+	prepared := false
+
+	if rebootstate.IsTrue(rebootstate.FromNode(node).RebootInProgress) {
+		k.logger.Info("Ignoring rollback request, reboot is already in progress")
+	} else {
+		k.logger.Info("Rollback requested, rolling back to the previously booted partition")
+
+		if err := rollbacker.AttemptRollback(); err != nil {
+			k.logger.Error(err, "Failed attempting rollback")
+		} else {
+			prepared = true
+		}
+	}
+
+	rebootNeededSince := time.Now().UTC().Format(time.RFC3339)
+
+	err = k.updateNode(ctx, func(node *corev1.Node) {
+		delete(node.Annotations, constants.AnnotationRollback)
+
+		if !prepared {
+			return
+		}
+
+		rebootstate.NodeRebootState{RebootNeeded: rebootstate.Bool(true)}.ApplyTo(node)
+
+		setRebootNeededSince(node, rebootNeededSince)
+	})
+
+	k.recordNodeUpdateResult(err)
+
+	if err != nil {
+		return fmt.Errorf("removing %q annotation from node %q: %w", constants.AnnotationRollback, k.nodeName, err)
+	}
+
+	if prepared {
+		k.state.setRebootPending()
+		k.acquireInhibitorLock()
+
+		k.nodeEventf(corev1.EventTypeNormal, EventReasonRollbackPrepared,
+			"Rollback to the previously booted partition has been prepared, node will be rebooted once approved")
+
+		k.setRebootRequiredCondition(ctx, corev1.ConditionTrue, ConditionReasonRollbackPrepared,
+			"Rollback to the previously booted partition has been prepared and requires a reboot")
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+// restoreSchedulability makes given node schedulable again, if it has been made unschedulable by the agent.
+// This prevents a node from being made schedulable if it was made unschedulable by something other than
+// the agent.
+//
+// When configured, node which has just been rebooted is left unschedulable instead, so workloads are
+// re-admitted manually.
+func (k *klocksmith) restoreSchedulability(ctx context.Context, node *corev1.Node, rebootFinished bool) error {
+	madeUnschedulable, madeUnschedulableExists := node.Annotations[constants.AnnotationAgentMadeUnschedulable]
+
+	switch {
+	case madeUnschedulable == constants.True && rebootFinished && !k.uncordonAfterReboot(node):
+		return k.leaveUnschedulable(ctx)
+	case madeUnschedulable == constants.True:
+		// We are schedulable now.
+		k.logger.Info("Marking node as schedulable")
+
+		if err := k.setSchedulable(ctx, true); err != nil {
+			return fmt.Errorf("marking node %q as schedulable: %w", k.nodeName, err)
+		}
+
+		return k.setAgentMadeUnschedulableFalse(ctx)
+	case madeUnschedulableExists: // Annotation exists so node was marked unschedulable by external source.
+		k.logger.Info("Skipping marking node as schedulable -- node was marked unschedulable by an external source")
+	}
+
+	return nil
+}
+
+// schedulingDisabled returns true when pods are already prevented from being scheduled on given node,
+// either by the node being unschedulable or, when agent is configured not to cordon the node, by
+// the reboot taint.
+func (k *klocksmith) schedulingDisabled(node *corev1.Node) bool {
+	if k.skipCordon {
+		return hasTaint(node, k.rebootTaint)
+	}
+
+	return node.Spec.Unschedulable
+}
+
+// setSchedulable marks the node as schedulable or unschedulable and applies or removes the reboot taint,
+// if configured.
+func (k *klocksmith) setSchedulable(ctx context.Context, schedulable bool) error {
+	return k.updateNode(ctx, func(node *corev1.Node) {
+		if !k.skipCordon {
+			node.Spec.Unschedulable = !schedulable
+		}
+
+		switch {
+		case k.rebootTaint == nil:
+		case schedulable:
+			removeTaint(node, k.rebootTaint)
+		default:
+			addTaint(node, k.rebootTaint)
+		}
+	})
+}
+
+// uncordonAfterReboot returns whether node should be made schedulable after the reboot. Configured behavior
+// may be overridden using the node annotation.
+func (k *klocksmith) uncordonAfterReboot(node *corev1.Node) bool {
+	switch node.Annotations[constants.AnnotationUncordonAfterReboot] {
+	case constants.True:
+		return true
+	case constants.False:
+		return false
+	default:
+		return !k.keepNodeCordonedAfterReboot
+	}
+}
+
+// leaveUnschedulable hands the node made unschedulable by the agent over to the administrator, who is
+// expected to make it schedulable again. From now on, agent treats the node as made unschedulable by
+// an external source.
+func (k *klocksmith) leaveUnschedulable(ctx context.Context) error {
+	k.logger.Info("Leaving node unschedulable after reboot as configured")
+
+	if err := k.setAgentMadeUnschedulableFalse(ctx); err != nil {
+		return err
+	}
+
+	k.nodeEventf(corev1.EventTypeNormal, EventReasonLeftUnschedulable,
+		"Node left unschedulable after reboot, uncordon it to re-admit workloads")
+
+	return nil
+}
+
+func (k *klocksmith) setAgentMadeUnschedulableFalse(ctx context.Context) error {
+	anno := map[string]string{
+		constants.AnnotationAgentMadeUnschedulable: constants.False,
+	}
+
+	k.logger.Info("Setting annotations", "annotations", anno)
+
+	err := k8sutil.ApplyNodeMetadata(ctx, k.nc, k.nodeName, madeUnschedulableFieldManager, anno, nil)
+	if err != nil {
+		return fmt.Errorf("setting node %q annotations: %w", k.nodeName, err)
+	}
+
+	return nil
+}
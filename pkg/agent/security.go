@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+)
+
+// securityFeedTimeout is a time after which fetching security feed is considered failed.
+const securityFeedTimeout = 30 * time.Second
+
+// parseSecurityFeedURL parses given security feed URL. Supported schemes are http and https.
+func parseSecurityFeedURL(feedURL string) (*url.URL, error) {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %q: %w", feedURL, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme of %q, expected either http or https", feedURL)
+	}
+
+	return u, nil
+}
+
+// classifyUpdate sets annotation on given annotations indicating if given version carries security
+// fixes. If security feed is not configured, version is unknown or checking it fails, annotation
+// is not set.
+func (k *klocksmith) classifyUpdate(ctx context.Context, annotations map[string]string, version string) {
+	if k.securityFeedURL == nil || version == "" {
+		return
+	}
+
+	securityUpdate, err := k.securityUpdate(ctx, version)
+	if err != nil {
+		k.logger.Error(err, "Failed checking if version carries security fixes", "version", version)
+
+		return
+	}
+
+	k.logger.Info("Checked if version carries security fixes", "version", version, "securityUpdate", securityUpdate)
+
+	annotations[constants.AnnotationSecurityUpdate] = strconv.FormatBool(securityUpdate)
+}
+
+// securityUpdate checks in configured security feed if given version carries security fixes.
+//
+// Security feed is a JSON object, where keys are versions carrying security fixes and values
+// are lists of fixed vulnerabilities, e.g. {"3510.2.7": ["CVE-2023-1234"]}.
+func (k *klocksmith) securityUpdate(ctx context.Context, version string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, securityFeedTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.securityFeedURL.String(), nil)
+	if err != nil {
+		return false, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("sending request: %w", err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck // Nothing to do with the error.
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	feed := map[string][]string{}
+
+	if err := json.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return false, fmt.Errorf("decoding feed: %w", err)
+	}
+
+	_, ok := feed[version]
+
+	return ok, nil
+}
@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/rebootstate"
+)
+
+// watchRebootSentinelFile periodically checks if configured reboot sentinel file exists and once
+// it does, indicates on the node that a reboot is needed, same as when update_engine reports
+// that the update has been applied.
+func (k *klocksmith) watchRebootSentinelFile(ctx context.Context) {
+	k.logger.Info("Beginning to watch reboot sentinel file", "path", k.rebootSentinelFile)
+
+	//nolint:staticcheck // New equivalent is buggy: https://github.com/kubernetes/kubernetes/issues/119533.
+	err := wait.PollImmediateUntilWithContext(ctx, k.pollInterval, func(ctx context.Context) (bool, error) {
+		exists, err := fileExists(k.rebootSentinelFile)
+		if err != nil {
+			k.logger.Error(err, "Failed checking reboot sentinel file")
+
+			return false, nil
+		}
+
+		if !exists {
+			return false, nil
+		}
+
+		if err := k.indicateRebootNeeded(ctx); err != nil {
+			k.logger.Error(err, "Failed indicating a reboot is needed")
+
+			return false, nil
+		}
+
+		return true, nil
+	})
+	if err != nil && !errors.Is(err, wait.ErrWaitTimeout) {
+		k.logger.Error(err, "Failed watching reboot sentinel file")
+	}
+}
+
+// fileExists checks if file with given path exists.
+func fileExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("checking file %q: %w", path, err)
+	}
+
+	return true, nil
+}
+
+// indicateRebootNeeded sets reboot needed annotation and label on the node.
+func (k *klocksmith) indicateRebootNeeded(ctx context.Context) error {
+	k.logger.Info("Reboot sentinel file found, indicating a reboot is needed", "path", k.rebootSentinelFile)
+
+	k.state.setRebootPending()
+
+	rebootNeededSince := time.Now().UTC().Format(time.RFC3339)
+
+	updateF := func(node *corev1.Node) {
+		rebootstate.NodeRebootState{RebootNeeded: rebootstate.Bool(true)}.ApplyTo(node)
+
+		setRebootNeededSince(node, rebootNeededSince)
+	}
+
+	err := k.updateNode(ctx, updateF)
+
+	k.recordNodeUpdateResult(err)
+
+	if err != nil {
+		return fmt.Errorf("updating node %q: %w", k.nodeName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logging"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/updateengine"
+)
+
+// Phases of the agent reboot process reported in State.
+const (
+	PhaseStarting                   = "Starting"
+	PhaseVerifyingNodeHealth        = "VerifyingNodeHealth"
+	PhaseWaitingForOkToReboot       = "WaitingForOkToReboot"
+	PhaseWaitingForPauseFileRemoval = "WaitingForPauseFileRemoval"
+	PhaseWaitingForRebootWindow     = "WaitingForRebootWindow"
+	PhaseWaitingForJobs             = "WaitingForJobs"
+	PhaseRunningPreDrainHooks       = "RunningPreDrainHooks"
+	PhaseDraining                   = "Draining"
+	PhaseWaitingForVolumeDetach     = "WaitingForVolumeDetach"
+	PhaseRebooting                  = "Rebooting"
+	PhaseReportingOnly              = "ReportingOnly"
+)
+
+// Operations, which agent may report as pending in State.
+const (
+	// OperationReboot is pending when update source or reboot sentinel file indicates that
+	// the node needs a reboot.
+	OperationReboot = "Reboot"
+	// OperationUpdateCheck is pending from the moment update check is requested using the node annotation
+	// until update source reports an update check performed after the request.
+	OperationUpdateCheck = "UpdateCheck"
+)
+
+// State describes what the agent is currently doing. It is meant for introspection by node
+// debugging tooling and hooks.
+type State struct {
+	// Phase of the reboot process agent is currently in.
+	Phase string `json:"phase"`
+	// Time when agent entered the current phase.
+	PhaseSince time.Time `json:"phaseSince"`
+	// Last status received from the update source, if any.
+	LastStatus *updateengine.Status `json:"lastStatus,omitempty"`
+	// Time when the last status has been received.
+	LastStatusTime *time.Time `json:"lastStatusTime,omitempty"`
+	// Operations requested from the agent, which have not been completed yet, sorted by name.
+	PendingOperations []string `json:"pendingOperations"`
+}
+
+// agentState tracks State of the agent.
+type agentState struct {
+	lock              sync.RWMutex
+	logger            klog.Logger
+	phase             string
+	phaseSince        time.Time
+	lastStatus        *updateengine.Status
+	lastStatusTime    time.Time
+	pendingOperations map[string]struct{}
+
+	updateCheckRequestedAt time.Time
+}
+
+func newAgentState(logger klog.Logger) *agentState {
+	return &agentState{
+		logger:            logger,
+		phase:             PhaseStarting,
+		phaseSince:        time.Now(),
+		pendingOperations: map[string]struct{}{},
+	}
+}
+
+func (s *agentState) setPhase(phase string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.phase == phase {
+		return
+	}
+
+	s.logger.V(4).Info("Entering phase", logging.KeyPhase, phase)
+
+	s.phase = phase
+	s.phaseSince = time.Now()
+}
+
+func (s *agentState) setLastStatus(status updateengine.Status) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.lastStatus = &status
+	s.lastStatusTime = time.Now()
+
+	if status.CurrentOperation == updateengine.UpdateStatusUpdatedNeedReboot {
+		s.pendingOperations[OperationReboot] = struct{}{}
+	}
+
+	if status.LastCheckedTime >= s.updateCheckRequestedAt.Unix() {
+		delete(s.pendingOperations, OperationUpdateCheck)
+	}
+}
+
+func (s *agentState) setUpdateCheckRequested() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.updateCheckRequestedAt = time.Now()
+	s.pendingOperations[OperationUpdateCheck] = struct{}{}
+}
+
+func (s *agentState) setRebootPending() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.pendingOperations[OperationReboot] = struct{}{}
+}
+
+func (s *agentState) snapshot() State {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	state := State{
+		Phase:             s.phase,
+		PhaseSince:        s.phaseSince,
+		PendingOperations: []string{},
+	}
+
+	if s.lastStatus != nil {
+		lastStatus := *s.lastStatus
+		lastStatusTime := s.lastStatusTime
+
+		state.LastStatus = &lastStatus
+		state.LastStatusTime = &lastStatusTime
+	}
+
+	for operation := range s.pendingOperations {
+		state.PendingOperations = append(state.PendingOperations, operation)
+	}
+
+	sort.Strings(state.PendingOperations)
+
+	return state
+}
+
+// State implements Klocksmith interface.
+func (k *klocksmith) State() State {
+	return k.state.snapshot()
+}
+
+// StateHandler returns HTTP handler responding with State of a given agent encoded as JSON.
+func StateHandler(agent Klocksmith) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(agent.State()); err != nil {
+			klog.FromContext(r.Context()).Error(err, "Failed writing agent state")
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/rebootstate"
+)
+
+// watchStatusResetRequests watches the node object for the annotation requesting update status reset and
+// when it is set, resets the status and removes the annotation.
+func (k *klocksmith) watchStatusResetRequests(ctx context.Context, statusResetter StatusResetter) {
+	k.watchAnnotationRequests(ctx, constants.AnnotationResetUpdateStatus, func(ctx context.Context) error {
+		return k.resetUpdateStatus(ctx, statusResetter)
+	})
+}
+
+// resetUpdateStatus resets the update source status, marks the node as no longer needing a reboot and
+// removes the annotation requesting the reset. Annotation is removed even if resetting the status fails,
+// so failing requests are not retried forever.
+//
+// Requests are ignored once the reboot is in progress, as the node is already being drained.
+func (k *klocksmith) resetUpdateStatus(ctx context.Context, statusResetter StatusResetter) error {
+	node, err := k.nc.Get(ctx, k.nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("getting node %q: %w", k.nodeName, err)
+	}
+
+	reset := false
+
+	if rebootstate.IsTrue(rebootstate.FromNode(node).RebootInProgress) {
+		k.logger.Info("Ignoring update status reset request, reboot is already in progress")
+	} else {
+		k.logger.Info("Update status reset requested, resetting update status")
+
+		if err := statusResetter.ResetStatus(); err != nil {
+			k.logger.Error(err, "Failed resetting update status")
+		} else {
+			reset = true
+		}
+	}
+
+	err = k.updateNode(ctx, func(node *corev1.Node) {
+		delete(node.Annotations, constants.AnnotationResetUpdateStatus)
+
+		if !reset {
+			return
+		}
+
+		rebootstate.NodeRebootState{RebootNeeded: rebootstate.Bool(false)}.ApplyTo(node)
+
+		delete(node.Annotations, constants.AnnotationRebootNeededSince)
+	})
+
+	k.recordNodeUpdateResult(err)
+
+	if err != nil {
+		return fmt.Errorf("removing %q annotation from node %q: %w", constants.AnnotationResetUpdateStatus, k.nodeName, err)
+	}
+
+	if reset {
+		k.releaseInhibitorLock()
+
+		k.nodeEventf(corev1.EventTypeNormal, EventReasonUpdateStatusReset,
+			"Update status has been reset, pending update has been discarded")
+
+		k.setRebootRequiredCondition(ctx, corev1.ConditionFalse, ConditionReasonUpdateStatusReset,
+			"Update status has been reset and pending update has been discarded")
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// parseRebootTaint parses taint in "key[=value]:effect" format, the same as used by kubectl taint.
+func parseRebootTaint(taint string) (*corev1.Taint, error) {
+	keyValue, effect := taint, ""
+
+	if i := strings.LastIndex(taint, ":"); i >= 0 {
+		keyValue, effect = taint[:i], taint[i+1:]
+	}
+
+	switch corev1.TaintEffect(effect) {
+	case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+	default:
+		return nil, fmt.Errorf("unsupported taint effect %q, expected one of %q, %q or %q", effect,
+			corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute)
+	}
+
+	key, value := keyValue, ""
+
+	if i := strings.Index(keyValue, "="); i >= 0 {
+		key, value = keyValue[:i], keyValue[i+1:]
+	}
+
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid taint key %q: %s", key, strings.Join(errs, "; "))
+	}
+
+	if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid taint value %q: %s", value, strings.Join(errs, "; "))
+	}
+
+	return &corev1.Taint{
+		Key:    key,
+		Value:  value,
+		Effect: corev1.TaintEffect(effect),
+	}, nil
+}
+
+// hasTaint returns true when given node has a taint with the same key and effect as given taint.
+func hasTaint(node *corev1.Node, taint *corev1.Taint) bool {
+	for i := range node.Spec.Taints {
+		if node.Spec.Taints[i].MatchTaint(taint) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addTaint adds given taint to given node, unless the node already has it.
+func addTaint(node *corev1.Node, taint *corev1.Taint) {
+	if hasTaint(node, taint) {
+		return
+	}
+
+	newTaint := *taint
+
+	// Time is used by Kubernetes to evict pods tolerating the taint only for limited time.
+	if newTaint.Effect == corev1.TaintEffectNoExecute {
+		now := metav1.Now()
+		newTaint.TimeAdded = &now
+	}
+
+	node.Spec.Taints = append(node.Spec.Taints, newTaint)
+}
+
+// removeTaint removes taint with the same key and effect as given taint from given node.
+func removeTaint(node *corev1.Node, taint *corev1.Taint) {
+	taints := []corev1.Taint{}
+
+	for i := range node.Spec.Taints {
+		if !node.Spec.Taints[i].MatchTaint(taint) {
+			taints = append(taints, node.Spec.Taints[i])
+		}
+	}
+
+	node.Spec.Taints = taints
+}
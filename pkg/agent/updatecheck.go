@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+)
+
+// watchUpdateCheckRequests watches the node object for the annotation requesting an update check and
+// when it is set, triggers the update check and removes the annotation.
+func (k *klocksmith) watchUpdateCheckRequests(ctx context.Context, updateChecker UpdateChecker) {
+	k.watchAnnotationRequests(ctx, constants.AnnotationCheckUpdateNow, func(ctx context.Context) error {
+		return k.checkUpdateNow(ctx, updateChecker)
+	})
+}
+
+// watchAnnotationRequests watches the node object for given annotation set to "true" by the administrator
+// and calls handleF for each request until given context is cancelled. handleF must remove the annotation.
+func (k *klocksmith) watchAnnotationRequests(
+	ctx context.Context, annotation string, handleF func(ctx context.Context) error,
+) {
+	k.logger.Info("Beginning to watch for requests", "annotation", annotation)
+
+	requests := make(chan struct{}, 1)
+
+	requestedF := func(_, node *corev1.Node) {
+		if node.Name != k.nodeName || node.Annotations[annotation] != constants.True {
+			return
+		}
+
+		// Requests are coalesced while previous one is being handled.
+		select {
+		case requests <- struct{}{}:
+		default:
+		}
+	}
+
+	if err := k.watchOwnNode(ctx, requestedF); err != nil {
+		k.logger.Error(err, "Failed watching for requests", "annotation", annotation)
+
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-requests:
+			if err := handleF(ctx); err != nil {
+				k.logger.Error(err, "Failed handling request", "annotation", annotation)
+			}
+		}
+	}
+}
+
+// checkUpdateNow triggers an update check and removes the annotation requesting it. Annotation is
+// removed even if triggering the update check fails, so failing requests are not retried forever.
+func (k *klocksmith) checkUpdateNow(ctx context.Context, updateChecker UpdateChecker) error {
+	k.logger.Info("Update check requested, triggering update check")
+
+	k.state.setUpdateCheckRequested()
+
+	checkErr := updateChecker.AttemptUpdate()
+	if checkErr != nil {
+		k.logger.Error(checkErr, "Failed triggering update check")
+	}
+
+	err := k.updateNode(ctx, func(node *corev1.Node) {
+		delete(node.Annotations, constants.AnnotationCheckUpdateNow)
+	})
+
+	k.recordNodeUpdateResult(err)
+
+	if err != nil {
+		return fmt.Errorf("removing %q annotation from node %q: %w", constants.AnnotationCheckUpdateNow, k.nodeName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/updateengine"
+)
+
+// unknownErrorCode is reported when update source does not provide error code of the failed update attempt.
+const unknownErrorCode = "unknown"
+
+// recordUpdateAttemptResult adds annotations indicating whether the last update attempt failed into
+// given annotations map, based on given status. Failed attempts are also reported using an event, so
+// nodes which fail to update are visible to fleet operators.
+//
+// update_engine goes back to idle after reporting the error, so the failure is only cleared once
+// an update is successfully applied.
+func (k *klocksmith) recordUpdateAttemptResult(anno map[string]string, status updateengine.Status) {
+	switch status.CurrentOperation {
+	case updateengine.UpdateStatusReportingErrorEvent:
+		errorCode := k.lastAttemptErrorCode()
+
+		k.logger.Info("Update source reported failed update attempt", "errorCode", errorCode, "status", status.String())
+
+		anno[constants.AnnotationUpdateFailed] = constants.True
+		anno[constants.AnnotationLastUpdateFailureTime] = time.Now().UTC().Format(time.RFC3339)
+
+		if errorCode != unknownErrorCode {
+			anno[constants.AnnotationLastUpdateErrorCode] = errorCode
+		}
+
+		k.metrics.updateAttemptsFailed.WithLabelValues(errorCode).Inc()
+
+		k.nodeEventf(corev1.EventTypeWarning, EventReasonUpdateFailed,
+			"Update attempt failed, update_engine reported %s with error code %s", status.CurrentOperation, errorCode)
+	case updateengine.UpdateStatusUpdatedNeedReboot:
+		anno[constants.AnnotationUpdateFailed] = constants.False
+	}
+}
+
+// lastAttemptErrorCode returns error code of the last update attempt, if the update source supports
+// reporting it. Otherwise unknownErrorCode is returned.
+func (k *klocksmith) lastAttemptErrorCode() string {
+	errorGetter, ok := k.ue.(LastAttemptErrorGetter)
+	if !ok {
+		return unknownErrorCode
+	}
+
+	code, err := errorGetter.GetLastAttemptError()
+	if err != nil {
+		k.logger.Error(err, "Failed getting error code of the last update attempt")
+
+		return unknownErrorCode
+	}
+
+	return strconv.Itoa(int(code))
+}
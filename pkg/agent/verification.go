@@ -0,0 +1,185 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// probeTimeout is a time after which single post-reboot probe is considered failed.
+const probeTimeout = 5 * time.Second
+
+// parseProbes parses given post-reboot probe URLs. Supported schemes are http, https and tcp.
+func parseProbes(probes []string) ([]*url.URL, error) {
+	urls := []*url.URL{}
+
+	for _, probe := range probes {
+		u, err := url.Parse(probe)
+		if err != nil {
+			return nil, fmt.Errorf("parsing probe %q: %w", probe, err)
+		}
+
+		switch u.Scheme {
+		case "http", "https", "tcp":
+		default:
+			return nil, fmt.Errorf("unsupported scheme of probe %q, expected one of http, https or tcp", probe)
+		}
+
+		if u.Host == "" {
+			return nil, fmt.Errorf("probe %q has no host", probe)
+		}
+
+		urls = append(urls, u)
+	}
+
+	return urls, nil
+}
+
+// waitForHealthyNode blocks until the node passes configured post-reboot health verification.
+func (k *klocksmith) waitForHealthyNode(ctx context.Context) error {
+	if k.postRebootReadyDuration == 0 && len(k.postRebootProbes) == 0 && !k.postRebootNetworkCheck {
+		return nil
+	}
+
+	k.logger.Info("Verifying node health after reboot")
+
+	verifyF := func(ctx context.Context) (bool, error) {
+		if err := k.verifyNodeHealth(ctx); err != nil {
+			k.logger.Info("Node is not healthy yet", "reason", err.Error())
+
+			return false, nil
+		}
+
+		return true, nil
+	}
+
+	//nolint:staticcheck // New equivalent is buggy: https://github.com/kubernetes/kubernetes/issues/119533.
+	if err := wait.PollImmediateUntilWithContext(ctx, k.pollInterval, verifyF); err != nil {
+		return fmt.Errorf("waiting for node to become healthy: %w", err)
+	}
+
+	k.logger.Info("Node is healthy")
+
+	return nil
+}
+
+// verifyNodeHealth checks if kubelet reports the node as Ready for at least configured duration,
+// if node and pod network are functional when configured and if all configured probes succeed.
+func (k *klocksmith) verifyNodeHealth(ctx context.Context) error {
+	if k.postRebootReadyDuration > 0 || k.postRebootNetworkCheck {
+		node, err := k.nc.Get(ctx, k.nodeName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("getting node %q: %w", k.nodeName, err)
+		}
+
+		if err := k.verifyNodeConditions(node); err != nil {
+			return err
+		}
+	}
+
+	if k.postRebootNetworkCheck && k.podNetworkProbeAddress != "" {
+		probe := &url.URL{Scheme: "tcp", Host: k.podNetworkProbeAddress}
+
+		if err := runProbe(ctx, probe); err != nil {
+			return fmt.Errorf("pod network is not functional, connecting to %q failed: %w", probe.Host, err)
+		}
+	}
+
+	for _, probe := range k.postRebootProbes {
+		if err := runProbe(ctx, probe); err != nil {
+			return fmt.Errorf("probe %q failed: %w", probe, err)
+		}
+	}
+
+	return nil
+}
+
+// verifyNodeConditions checks if given node has been Ready for at least configured duration. When network
+// check is configured, node must also be Ready and must not report its network as unavailable, which
+// happens e.g. until CNI plugin configures routes for the node.
+func (k *klocksmith) verifyNodeConditions(node *corev1.Node) error {
+	readyFor := nodeReadyFor(node)
+
+	if k.postRebootNetworkCheck && readyFor == 0 {
+		return fmt.Errorf("node is not Ready")
+	}
+
+	if readyFor < k.postRebootReadyDuration {
+		return fmt.Errorf("node has been Ready for %v, expected at least %v",
+			readyFor.Truncate(time.Second), k.postRebootReadyDuration)
+	}
+
+	if !k.postRebootNetworkCheck {
+		return nil
+	}
+
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeNetworkUnavailable && condition.Status == corev1.ConditionTrue {
+			return fmt.Errorf("node network is unavailable: %s", condition.Message)
+		}
+	}
+
+	return nil
+}
+
+// nodeReadyFor returns for how long given node has been reporting Ready condition. If node is
+// not Ready, zero is returned.
+func nodeReadyFor(node *corev1.Node) time.Duration {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
+			return time.Since(condition.LastTransitionTime.Time)
+		}
+	}
+
+	return 0
+}
+
+// runProbe checks if a service behind given URL is healthy. HTTP probes succeed on responses
+// with status code lower than 400. TCP probes succeed when connection can be established.
+func runProbe(ctx context.Context, probe *url.URL) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	if probe.Scheme == "tcp" {
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", probe.Host)
+		if err != nil {
+			return fmt.Errorf("connecting: %w", err)
+		}
+
+		if err := conn.Close(); err != nil {
+			klog.FromContext(ctx).Error(err, "Failed closing probe connection", "probe", probe.String())
+		}
+
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probe.String(), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			klog.FromContext(ctx).Error(err, "Failed closing probe response body", "probe", probe.String())
+		}
+	}()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
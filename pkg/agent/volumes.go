@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// waitForVolumesDetached blocks after draining until there are no volumes attached to the node
+// or until configured timeout is reached, so volumes of evicted pods can be cleanly detached before
+// rebooting. Volumes of ignored drivers are not waited for.
+//
+// Reaching the timeout is logged and ignored, so the node can still be rebooted.
+func (k *klocksmith) waitForVolumesDetached(ctx context.Context) error {
+	if k.volumeDetachTimeout == 0 {
+		return nil
+	}
+
+	k.logger.Info("Waiting for volumes to be detached")
+
+	pollCtx, cancel := context.WithTimeout(ctx, k.volumeDetachTimeout)
+	defer cancel()
+
+	detachedF := func(ctx context.Context) (bool, error) {
+		volumes, err := k.attachedVolumes(ctx)
+		if err != nil {
+			k.logger.Error(err, "Failed getting attached volumes")
+
+			return false, nil
+		}
+
+		if len(volumes) > 0 {
+			k.logger.Info("Waiting for volumes to be detached", "volumes", volumes)
+
+			return false, nil
+		}
+
+		return true, nil
+	}
+
+	//nolint:staticcheck // New equivalent is buggy: https://github.com/kubernetes/kubernetes/issues/119533.
+	err := wait.PollImmediateUntilWithContext(pollCtx, k.pollInterval, detachedF)
+
+	switch {
+	case err == nil:
+		k.logger.Info("All volumes detached")
+	case ctx.Err() != nil:
+		return fmt.Errorf("waiting for volumes to be detached: %w", ctx.Err())
+	case errors.Is(err, wait.ErrWaitTimeout):
+		k.logger.Error(nil, "Volumes not detached in time, proceeding with reboot", "timeout", k.volumeDetachTimeout)
+	default:
+		return fmt.Errorf("waiting for volumes to be detached: %w", err)
+	}
+
+	return nil
+}
+
+// attachedVolumes returns names of VolumeAttachments of volumes attached to the node,
+// excluding volumes of ignored drivers.
+func (k *klocksmith) attachedVolumes(ctx context.Context) ([]string, error) {
+	volumeAttachments, err := k.clientset.StorageV1().VolumeAttachments().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing volume attachments: %w", err)
+	}
+
+	volumes := []string{}
+
+	for _, volumeAttachment := range volumeAttachments.Items {
+		if volumeAttachment.Spec.NodeName != k.nodeName || !volumeAttachment.Status.Attached {
+			continue
+		}
+
+		if _, ignored := k.volumeDetachIgnoredDrivers[volumeAttachment.Spec.Attacher]; ignored {
+			continue
+		}
+
+		volumes = append(volumes, volumeAttachment.Name)
+	}
+
+	return volumes, nil
+}
@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/operator"
+)
+
+// parseRebootWindow parses reboot window configured for the agent and checks that minimum remaining
+// reboot window fits into it.
+func parseRebootWindow(start, length string, minRemaining time.Duration) (*operator.Periodic, error) {
+	rebootWindow, err := operator.ParsePeriodic(start, length)
+	if err != nil {
+		return nil, fmt.Errorf("parsing reboot window: %w", err)
+	}
+
+	period := rebootWindow.Previous(time.Now())
+
+	if minRemaining < 0 || minRemaining >= period.End.Sub(period.Start) {
+		return nil, fmt.Errorf("minimum remaining reboot window must not be negative and must be shorter than "+
+			"reboot window, got %v", minRemaining)
+	}
+
+	return rebootWindow, nil
+}
+
+// waitForRebootWindow blocks until the node is inside configured reboot window and remaining part of
+// the window is at least configured minimum, so draining and rebooting is not started just before
+// the window closes.
+func (k *klocksmith) waitForRebootWindow(ctx context.Context) error {
+	if k.rebootWindow == nil {
+		return nil
+	}
+
+	for {
+		delay := k.durationToRebootWindow(time.Now())
+		if delay <= 0 {
+			return nil
+		}
+
+		k.logger.Info("Not enough time remaining in reboot window, deferring reboot", "delay", delay.Truncate(time.Second))
+
+		sleepOrDone(delay, ctx.Done())
+
+		if ctx.Err() != nil {
+			return fmt.Errorf("waiting for reboot window: %w", ctx.Err())
+		}
+	}
+}
+
+// durationToRebootWindow returns for how long rebooting must be deferred to fit into configured reboot window.
+// If at least minimum remaining reboot window is left in the current window, zero is returned.
+func (k *klocksmith) durationToRebootWindow(now time.Time) time.Duration {
+	period := k.rebootWindow.Previous(now)
+
+	if remaining := period.End.Sub(now); remaining > 0 && remaining >= k.minRemainingRebootWindow {
+		return 0
+	}
+
+	return k.rebootWindow.Next(now).Start.Sub(now)
+}
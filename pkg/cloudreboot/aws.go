@@ -0,0 +1,275 @@
+package cloudreboot
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAWSMetadataURL is a URL of EC2 instance metadata service.
+	DefaultAWSMetadataURL = "http://169.254.169.254"
+
+	awsEC2APIVersion       = "2016-11-15"
+	awsEC2Service          = "ec2"
+	awsSigningAlgorithm    = "AWS4-HMAC-SHA256"
+	awsDateFormat          = "20060102"
+	awsTimeFormat          = "20060102T150405Z"
+	awsMetadataTokenTTL    = "60"
+	awsMetadataTokenHeader = "X-aws-ec2-metadata-token"
+)
+
+// AWSConfig represents configurable options for rebooting EC2 instances.
+type AWSConfig struct {
+	// Region of the instance. Defaults to the region from instance metadata.
+	Region string
+	// Static credentials. When not set, credentials of the instance profile are read from instance metadata.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// URL of instance metadata service. Defaults to DefaultAWSMetadataURL.
+	MetadataURL string
+	// URL of EC2 API. Defaults to regional EC2 endpoint, e.g. https://ec2.eu-central-1.amazonaws.com.
+	EndpointURL string
+	// Client used for requests. Defaults to client with 30 seconds timeout.
+	HTTPClient *http.Client
+}
+
+// AWSConfigFromEnv creates AWS configuration from AWS_REGION or AWS_DEFAULT_REGION, AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and AWS_EC2_METADATA_SERVICE_ENDPOINT environment variables.
+func AWSConfigFromEnv(getenv func(string) string) *AWSConfig {
+	region := getenv("AWS_REGION")
+	if region == "" {
+		region = getenv("AWS_DEFAULT_REGION")
+	}
+
+	return &AWSConfig{
+		Region:          region,
+		AccessKeyID:     getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    getenv("AWS_SESSION_TOKEN"),
+		MetadataURL:     getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"),
+	}
+}
+
+// AWS reboots EC2 instance it runs on. Instance ID is read from instance metadata using IMDSv2.
+type AWS struct {
+	config      AWSConfig
+	metadataURL string
+	client      *http.Client
+}
+
+// NewAWS creates new rebooter for EC2 instances.
+func NewAWS(config *AWSConfig) *AWS {
+	metadataURL := config.MetadataURL
+	if metadataURL == "" {
+		metadataURL = DefaultAWSMetadataURL
+	}
+
+	return &AWS{
+		config:      *config,
+		metadataURL: strings.TrimSuffix(metadataURL, "/"),
+		client:      httpClient(config.HTTPClient),
+	}
+}
+
+type awsCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token"`
+}
+
+// Reboot implements Rebooter interface.
+func (a *AWS) Reboot(ctx context.Context) error {
+	metadataToken, err := a.metadataToken(ctx)
+	if err != nil {
+		return fmt.Errorf("getting instance metadata token: %w", err)
+	}
+
+	instanceID, err := a.metadata(ctx, metadataToken, "instance-id")
+	if err != nil {
+		return fmt.Errorf("getting instance ID: %w", err)
+	}
+
+	region := a.config.Region
+	if region == "" {
+		if region, err = a.metadata(ctx, metadataToken, "placement/region"); err != nil {
+			return fmt.Errorf("getting region: %w", err)
+		}
+	}
+
+	credentials, err := a.credentials(ctx, metadataToken)
+	if err != nil {
+		return fmt.Errorf("getting credentials: %w", err)
+	}
+
+	endpoint := a.config.EndpointURL
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://ec2.%s.amazonaws.com", region)
+	}
+
+	body := url.Values{
+		"Action":       {"RebootInstances"},
+		"Version":      {awsEC2APIVersion},
+		"InstanceId.1": {instanceID},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/",
+		strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	signAWSRequest(req, []byte(body), credentials, region, awsEC2Service, time.Now())
+
+	if _, _, err := do(a.client, req); err != nil {
+		return fmt.Errorf("rebooting instance %q: %w", instanceID, err)
+	}
+
+	return nil
+}
+
+// metadataToken obtains session token for instance metadata service, as required by IMDSv2.
+func (a *AWS) metadataToken(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, a.metadataURL+"/latest/api/token", nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", awsMetadataTokenTTL)
+
+	token, _, err := do(a.client, req)
+
+	return string(token), err
+}
+
+// metadata reads given instance metadata category.
+func (a *AWS) metadata(ctx context.Context, token, category string) (string, error) {
+	value, err := get(ctx, a.client, a.metadataURL+"/latest/meta-data/"+category, map[string]string{
+		awsMetadataTokenHeader: token,
+	})
+
+	return strings.TrimSpace(string(value)), err
+}
+
+// credentials returns configured static credentials or credentials of the instance profile.
+func (a *AWS) credentials(ctx context.Context, token string) (*awsCredentials, error) {
+	if a.config.AccessKeyID != "" {
+		return &awsCredentials{
+			AccessKeyID:     a.config.AccessKeyID,
+			SecretAccessKey: a.config.SecretAccessKey,
+			Token:           a.config.SessionToken,
+		}, nil
+	}
+
+	roles, err := a.metadata(ctx, token, "iam/security-credentials/")
+	if err != nil {
+		return nil, fmt.Errorf("getting instance profile role: %w", err)
+	}
+
+	role := strings.Split(roles, "\n")[0]
+	if role == "" {
+		return nil, fmt.Errorf("no credentials configured and no instance profile attached to the instance")
+	}
+
+	rawCredentials, err := a.metadata(ctx, token, "iam/security-credentials/"+role)
+	if err != nil {
+		return nil, fmt.Errorf("getting credentials of role %q: %w", role, err)
+	}
+
+	credentials := &awsCredentials{}
+
+	if err := json.Unmarshal([]byte(rawCredentials), credentials); err != nil {
+		return nil, fmt.Errorf("decoding credentials of role %q: %w", role, err)
+	}
+
+	return credentials, nil
+}
+
+// signAWSRequest signs given request with given body using AWS Signature Version 4.
+func signAWSRequest(req *http.Request, body []byte, credentials *awsCredentials, region, service string,
+	now time.Time,
+) {
+	now = now.UTC()
+	scope := strings.Join([]string{now.Format(awsDateFormat), region, service, "aws4_request"}, "/")
+
+	req.Header.Set("X-Amz-Date", now.Format(awsTimeFormat))
+
+	if credentials.Token != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.Token)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	canonicalHeaders := &bytes.Buffer{}
+	for _, name := range names {
+		fmt.Fprintf(canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		awsSigningAlgorithm,
+		now.Format(awsTimeFormat),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := []byte("AWS4" + credentials.SecretAccessKey)
+	for _, part := range []string{now.Format(awsDateFormat), region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsSigningAlgorithm, credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data)) //nolint:errcheck // Writing to hash never fails.
+
+	return mac.Sum(nil)
+}
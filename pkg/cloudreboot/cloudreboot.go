@@ -0,0 +1,128 @@
+package cloudreboot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// ProviderAWS reboots EC2 instances using RebootInstances API.
+	ProviderAWS = "aws"
+	// ProviderGCP resets Compute Engine instances using instances.reset API.
+	ProviderGCP = "gcp"
+	// ProviderOpenStack hard reboots Nova servers using server reboot action.
+	ProviderOpenStack = "openstack"
+
+	defaultRequestTimeout = 30 * time.Second
+
+	// maxErrorBodyLength limits how much of the response body is included in returned errors.
+	maxErrorBodyLength = 512
+)
+
+// Rebooter reboots the machine it runs on using cloud provider API.
+type Rebooter interface {
+	// Reboot requests the cloud provider to reboot the machine. The machine and the credentials are
+	// discovered on first use, so errors may also originate from the metadata service of the provider.
+	Reboot(ctx context.Context) error
+}
+
+// New creates rebooter for given provider, configured using environment variables looked up using given
+// function, which are named the same way as for the CLI of the provider, e.g. AWS_ACCESS_KEY_ID or OS_AUTH_URL.
+// See AWSConfigFromEnv, GCPConfigFromEnv and OpenStackConfigFromEnv for details.
+func New(provider string, getenv func(string) string) (Rebooter, error) {
+	switch provider {
+	case ProviderAWS:
+		return NewAWS(AWSConfigFromEnv(getenv)), nil
+	case ProviderGCP:
+		return newGCPFromEnv(getenv)
+	case ProviderOpenStack:
+		return newOpenStackFromEnv(getenv)
+	default:
+		return nil, fmt.Errorf("unsupported provider %q, expected one of %q, %q or %q",
+			provider, ProviderAWS, ProviderGCP, ProviderOpenStack)
+	}
+}
+
+func newGCPFromEnv(getenv func(string) string) (Rebooter, error) {
+	config, err := GCPConfigFromEnv(getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	gcp, err := NewGCP(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcp, nil
+}
+
+func newOpenStackFromEnv(getenv func(string) string) (Rebooter, error) {
+	config, err := OpenStackConfigFromEnv(getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	openStack, err := NewOpenStack(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return openStack, nil
+}
+
+// do sends given request and returns the response body. Responses with status code other than 2xx are
+// returned as errors.
+func do(client *http.Client, req *http.Request) ([]byte, *http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sending request: %w", err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck // Body is fully read already.
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		message := strings.TrimSpace(string(body))
+		if len(message) > maxErrorBodyLength {
+			message = message[:maxErrorBodyLength] + "..."
+		}
+
+		return nil, nil, fmt.Errorf("%s %s returned status %d: %s", req.Method, req.URL.Path, resp.StatusCode, message)
+	}
+
+	return body, resp, nil
+}
+
+// get sends GET request to given URL with given headers and returns the response body.
+func get(ctx context.Context, client *http.Client, url string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	body, _, err := do(client, req)
+
+	return body, err
+}
+
+// httpClient returns given client or a client with default timeout, if nil is given.
+func httpClient(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+
+	return &http.Client{Timeout: defaultRequestTimeout}
+}
@@ -0,0 +1,539 @@
+package cloudreboot_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/cloudreboot"
+)
+
+const testTimeout = 10 * time.Second
+
+//nolint:funlen // Just many sub-tests.
+func Test_Rebooting_using_AWS(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reboots_instance_from_metadata_using_instance_profile_credentials", func(t *testing.T) {
+		t.Parallel()
+
+		rebooted := make(chan string, 1)
+
+		server := newAWSServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("Parsing form: %v", err)
+			}
+
+			authorization := r.Header.Get("Authorization")
+			if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=ROLEKEY/") ||
+				!strings.Contains(authorization, "/eu-central-1/ec2/aws4_request") {
+				t.Errorf("Unexpected Authorization header %q", authorization)
+			}
+
+			if token := r.Header.Get("X-Amz-Security-Token"); token != "role-session-token" {
+				t.Errorf("Expected role session token, got %q", token)
+			}
+
+			if action := r.Form.Get("Action"); action != "RebootInstances" {
+				t.Errorf("Expected RebootInstances action, got %q", action)
+			}
+
+			rebooted <- r.Form.Get("InstanceId.1")
+		})
+
+		rebooter := cloudreboot.NewAWS(&cloudreboot.AWSConfig{
+			MetadataURL: server.URL,
+			EndpointURL: server.URL,
+		})
+
+		if err := rebooter.Reboot(contextWithTimeout(t)); err != nil {
+			t.Fatalf("Unexpected error rebooting: %v", err)
+		}
+
+		if instanceID := <-rebooted; instanceID != "i-0123456789" {
+			t.Fatalf("Expected instance %q to be rebooted, got %q", "i-0123456789", instanceID)
+		}
+	})
+
+	t.Run("uses_configured_credentials_and_region", func(t *testing.T) {
+		t.Parallel()
+
+		server := newAWSServer(t, func(w http.ResponseWriter, r *http.Request) {
+			authorization := r.Header.Get("Authorization")
+			if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=STATICKEY/") ||
+				!strings.Contains(authorization, "/us-west-2/ec2/aws4_request") {
+				t.Errorf("Unexpected Authorization header %q", authorization)
+			}
+
+			if token := r.Header.Get("X-Amz-Security-Token"); token != "" {
+				t.Errorf("Expected no session token, got %q", token)
+			}
+		})
+
+		rebooter := cloudreboot.NewAWS(&cloudreboot.AWSConfig{
+			Region:          "us-west-2",
+			AccessKeyID:     "STATICKEY",
+			SecretAccessKey: "secret",
+			MetadataURL:     server.URL,
+			EndpointURL:     server.URL,
+		})
+
+		if err := rebooter.Reboot(contextWithTimeout(t)); err != nil {
+			t.Fatalf("Unexpected error rebooting: %v", err)
+		}
+	})
+
+	t.Run("returns_error_when_API_rejects_request", func(t *testing.T) {
+		t.Parallel()
+
+		server := newAWSServer(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "<Response><Errors><Error><Code>UnauthorizedOperation</Code></Error></Errors></Response>",
+				http.StatusForbidden)
+		})
+
+		rebooter := cloudreboot.NewAWS(&cloudreboot.AWSConfig{MetadataURL: server.URL, EndpointURL: server.URL})
+
+		err := rebooter.Reboot(contextWithTimeout(t))
+		if err == nil || !strings.Contains(err.Error(), "UnauthorizedOperation") {
+			t.Fatalf("Expected error with API error code, got %v", err)
+		}
+	})
+
+	t.Run("returns_error_when_instance_metadata_is_not_available", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.NotFoundHandler())
+		t.Cleanup(server.Close)
+
+		rebooter := cloudreboot.NewAWS(&cloudreboot.AWSConfig{MetadataURL: server.URL, EndpointURL: server.URL})
+
+		if err := rebooter.Reboot(contextWithTimeout(t)); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Rebooting_using_GCP(t *testing.T) {
+	t.Parallel()
+
+	t.Run("resets_instance_from_metadata_using_token_of_attached_service_account", func(t *testing.T) {
+		t.Parallel()
+
+		reset := make(chan string, 1)
+
+		server := newGCPServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if authorization := r.Header.Get("Authorization"); authorization != "Bearer metadata-token" {
+				t.Errorf("Unexpected Authorization header %q", authorization)
+			}
+
+			reset <- r.URL.Path
+		})
+
+		rebooter, err := cloudreboot.NewGCP(&cloudreboot.GCPConfig{MetadataURL: server.URL, ComputeURL: server.URL})
+		if err != nil {
+			t.Fatalf("Unexpected error creating rebooter: %v", err)
+		}
+
+		if err := rebooter.Reboot(contextWithTimeout(t)); err != nil {
+			t.Fatalf("Unexpected error rebooting: %v", err)
+		}
+
+		if path := <-reset; path != "/projects/test-project/zones/europe-west1-b/instances/test-instance/reset" {
+			t.Fatalf("Unexpected reset path %q", path)
+		}
+	})
+
+	t.Run("uses_configured_service_account_key", func(t *testing.T) {
+		t.Parallel()
+
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("Generating key: %v", err)
+		}
+
+		server := newGCPServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if authorization := r.Header.Get("Authorization"); authorization != "Bearer key-token" {
+				t.Errorf("Unexpected Authorization header %q", authorization)
+			}
+		})
+
+		server.mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("Parsing form: %v", err)
+			}
+
+			if err := verifyJWT(&privateKey.PublicKey, r.Form.Get("assertion")); err != nil {
+				t.Errorf("Invalid assertion: %v", err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+
+				return
+			}
+
+			fmt.Fprint(w, `{"access_token":"key-token","expires_in":3600,"token_type":"Bearer"}`)
+		})
+
+		keyJSON, err := json.Marshal(map[string]string{
+			"type":         "service_account",
+			"client_email": "rebooter@test-project.iam.gserviceaccount.com",
+			"private_key": string(pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+			})),
+			"token_uri": server.URL + "/token",
+		})
+		if err != nil {
+			t.Fatalf("Encoding key: %v", err)
+		}
+
+		rebooter, err := cloudreboot.NewGCP(&cloudreboot.GCPConfig{
+			CredentialsJSON: keyJSON,
+			MetadataURL:     server.URL,
+			ComputeURL:      server.URL,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error creating rebooter: %v", err)
+		}
+
+		if err := rebooter.Reboot(contextWithTimeout(t)); err != nil {
+			t.Fatalf("Unexpected error rebooting: %v", err)
+		}
+	})
+
+	t.Run("returns_error_when_configured_credentials_are_not_service_account_key", func(t *testing.T) {
+		t.Parallel()
+
+		credentials := []byte(`{"type":"authorized_user","client_id":"foo"}`)
+
+		if _, err := cloudreboot.NewGCP(&cloudreboot.GCPConfig{CredentialsJSON: credentials}); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Rebooting_using_OpenStack(t *testing.T) {
+	t.Parallel()
+
+	t.Run("hard_reboots_server_from_metadata_using_compute_endpoint_of_configured_region", func(t *testing.T) {
+		t.Parallel()
+
+		actions := make(chan string, 1)
+
+		server := newOpenStackServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if token := r.Header.Get("X-Auth-Token"); token != "test-token" {
+				t.Errorf("Unexpected token %q", token)
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("Reading body: %v", err)
+			}
+
+			actions <- r.URL.Path + " " + string(body)
+
+			w.WriteHeader(http.StatusAccepted)
+		})
+
+		rebooter, err := cloudreboot.NewOpenStack(&cloudreboot.OpenStackConfig{
+			AuthURL:     server.URL + "/v3",
+			Username:    "rebooter",
+			Password:    "secret",
+			ProjectName: "test-project",
+			RegionName:  "region-two",
+			MetadataURL: server.URL,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error creating rebooter: %v", err)
+		}
+
+		if err := rebooter.Reboot(contextWithTimeout(t)); err != nil {
+			t.Fatalf("Unexpected error rebooting: %v", err)
+		}
+
+		expectedAction := `/region-two/compute/servers/11111111-2222-3333-4444-555555555555/action {"reboot":{"type":"HARD"}}`
+		if action := <-actions; action != expectedAction {
+			t.Fatalf("Expected action %q, got %q", expectedAction, action)
+		}
+
+		authRequest := <-server.authRequests
+		if !strings.Contains(authRequest, `"name":"rebooter"`) || !strings.Contains(authRequest, `"name":"test-project"`) {
+			t.Fatalf("Expected password authentication scoped to project, got %s", authRequest)
+		}
+	})
+
+	t.Run("authenticates_using_application_credential", func(t *testing.T) {
+		t.Parallel()
+
+		server := newOpenStackServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+		})
+
+		rebooter, err := cloudreboot.NewOpenStack(&cloudreboot.OpenStackConfig{
+			AuthURL:                     server.URL + "/v3",
+			ApplicationCredentialID:     "credential-id",
+			ApplicationCredentialSecret: "credential-secret",
+			MetadataURL:                 server.URL,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error creating rebooter: %v", err)
+		}
+
+		if err := rebooter.Reboot(contextWithTimeout(t)); err != nil {
+			t.Fatalf("Unexpected error rebooting: %v", err)
+		}
+
+		if authRequest := <-server.authRequests; !strings.Contains(authRequest, `"secret":"credential-secret"`) {
+			t.Fatalf("Expected application credential authentication, got %s", authRequest)
+		}
+	})
+
+	t.Run("returns_error_when_no_compute_endpoint_matches_configured_region", func(t *testing.T) {
+		t.Parallel()
+
+		server := newOpenStackServer(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("Unexpected reboot request")
+		})
+
+		rebooter, err := cloudreboot.NewOpenStack(&cloudreboot.OpenStackConfig{
+			AuthURL:     server.URL + "/v3",
+			Username:    "rebooter",
+			Password:    "secret",
+			RegionName:  "region-three",
+			MetadataURL: server.URL,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error creating rebooter: %v", err)
+		}
+
+		if err := rebooter.Reboot(contextWithTimeout(t)); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+
+	t.Run("returns_error_when_no_credentials_are_configured", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := cloudreboot.NewOpenStack(&cloudreboot.OpenStackConfig{AuthURL: "http://127.0.0.1/v3"}); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+}
+
+func Test_Creating_rebooter_from_environment(t *testing.T) {
+	t.Parallel()
+
+	env := func(vars map[string]string) func(string) string {
+		return func(name string) string {
+			return vars[name]
+		}
+	}
+
+	t.Run("returns_error_for_unsupported_provider", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := cloudreboot.New("azure", env(nil)); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+
+	t.Run("returns_error_when_OpenStack_auth_URL_is_not_set", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := cloudreboot.New(cloudreboot.ProviderOpenStack, env(map[string]string{
+			"OS_USERNAME": "rebooter",
+			"OS_PASSWORD": "secret",
+		})); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+
+	t.Run("returns_error_when_GCP_credentials_file_does_not_exist", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := cloudreboot.New(cloudreboot.ProviderGCP, env(map[string]string{
+			"GOOGLE_APPLICATION_CREDENTIALS": "/nonexistent/credentials.json",
+		})); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+
+	t.Run("creates_AWS_rebooter_without_any_configuration", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := cloudreboot.New(cloudreboot.ProviderAWS, env(nil)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+}
+
+type testServer struct {
+	*httptest.Server
+	mux          *http.ServeMux
+	authRequests chan string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return &testServer{Server: server, mux: mux, authRequests: make(chan string, 1)}
+}
+
+// newAWSServer serves instance metadata with instance profile credentials and calls given handler
+// for EC2 API requests.
+func newAWSServer(t *testing.T, apiHandler http.HandlerFunc) *testServer {
+	t.Helper()
+
+	server := newTestServer(t)
+
+	server.mux.HandleFunc("/latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		fmt.Fprint(w, "metadata-token")
+	})
+
+	roleCredentials := `{"AccessKeyId":"ROLEKEY","SecretAccessKey":"secret","Token":"role-session-token"}`
+
+	metadata := map[string]string{
+		"instance-id":                        "i-0123456789",
+		"placement/region":                   "eu-central-1",
+		"iam/security-credentials/":          "test-role",
+		"iam/security-credentials/test-role": roleCredentials,
+	}
+
+	server.mux.HandleFunc("/latest/meta-data/", func(w http.ResponseWriter, r *http.Request) {
+		value, ok := metadata[strings.TrimPrefix(r.URL.Path, "/latest/meta-data/")]
+		if !ok || r.Header.Get("X-aws-ec2-metadata-token") != "metadata-token" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		fmt.Fprint(w, value)
+	})
+
+	server.mux.HandleFunc("/", apiHandler)
+
+	return server
+}
+
+// newGCPServer serves instance metadata and calls given handler for Compute Engine API requests.
+func newGCPServer(t *testing.T, apiHandler http.HandlerFunc) *testServer {
+	t.Helper()
+
+	server := newTestServer(t)
+
+	metadata := map[string]string{
+		"/project/project-id":                      "test-project",
+		"/instance/zone":                           "projects/123456/zones/europe-west1-b",
+		"/instance/name":                           "test-instance",
+		"/instance/service-accounts/default/token": `{"access_token":"metadata-token","token_type":"Bearer"}`,
+	}
+
+	for path, value := range metadata {
+		value := value
+
+		server.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				http.Error(w, "missing Metadata-Flavor header", http.StatusForbidden)
+
+				return
+			}
+
+			fmt.Fprint(w, value)
+		})
+	}
+
+	server.mux.HandleFunc("/projects/", apiHandler)
+
+	return server
+}
+
+// newOpenStackServer serves server metadata and Keystone API with compute endpoints in two regions pointing
+// to the server. Given handler is called for compute API requests.
+func newOpenStackServer(t *testing.T, apiHandler http.HandlerFunc) *testServer {
+	t.Helper()
+
+	server := newTestServer(t)
+
+	server.mux.HandleFunc("/openstack/latest/meta_data.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"uuid":"11111111-2222-3333-4444-555555555555","name":"test-server"}`)
+	})
+
+	server.mux.HandleFunc("/v3/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("Reading body: %v", err)
+		}
+
+		server.authRequests <- string(body)
+
+		w.Header().Set("X-Subject-Token", "test-token")
+		w.WriteHeader(http.StatusCreated)
+
+		fmt.Fprintf(w, `{"token":{"catalog":[
+			{"type":"identity","endpoints":[{"interface":"public","region":"region-two","url":"%[1]s/v3"}]},
+			{"type":"compute","endpoints":[
+				{"interface":"internal","region":"region-two","url":"%[1]s/internal/compute"},
+				{"interface":"public","region":"region-one","url":"%[1]s/region-one/compute"},
+				{"interface":"public","region":"region-two","url":"%[1]s/region-two/compute"}
+			]}
+		]}}`, server.URL)
+	})
+
+	server.mux.HandleFunc("/region-one/compute/", apiHandler)
+	server.mux.HandleFunc("/region-two/compute/", apiHandler)
+
+	return server
+}
+
+// verifyJWT verifies RS256 signature of given JWT using given key.
+func verifyJWT(key *rsa.PublicKey, token string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("expected 3 parts, got %d", len(parts))
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("decoding signature: %w", err)
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return fmt.Errorf("verifying signature: %w", err)
+	}
+
+	return nil
+}
+
+func contextWithTimeout(t *testing.T) context.Context {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	t.Cleanup(cancel)
+
+	return ctx
+}
@@ -0,0 +1,3 @@
+// Package cloudreboot reboots machines using cloud provider APIs, so hosts which fail to reboot by
+// themselves, e.g. because of firmware hanging on shutdown, can still be rebooted.
+package cloudreboot
@@ -0,0 +1,284 @@
+package cloudreboot
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultGCPMetadataURL is a URL of Compute Engine metadata server.
+	DefaultGCPMetadataURL = "http://metadata.google.internal/computeMetadata/v1"
+	// DefaultGCPComputeURL is a URL of Compute Engine API.
+	DefaultGCPComputeURL = "https://compute.googleapis.com/compute/v1"
+
+	gcpComputeScope   = "https://www.googleapis.com/auth/compute"
+	gcpJWTBearerGrant = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	gcpTokenLifetime  = time.Hour
+	gcpServiceAccount = "service_account"
+	gcpMetadataHeader = "Metadata-Flavor"
+	gcpMetadataFlavor = "Google"
+)
+
+// GCPConfig represents configurable options for resetting Compute Engine instances.
+type GCPConfig struct {
+	// Service account key in JSON format. When not set, access token of the service account attached
+	// to the instance is obtained from the metadata server.
+	CredentialsJSON []byte
+	// URL of metadata server. Defaults to DefaultGCPMetadataURL.
+	MetadataURL string
+	// URL of Compute Engine API. Defaults to DefaultGCPComputeURL.
+	ComputeURL string
+	// Client used for requests. Defaults to client with 30 seconds timeout.
+	HTTPClient *http.Client
+}
+
+// GCPConfigFromEnv creates GCP configuration from GOOGLE_APPLICATION_CREDENTIALS and GCE_METADATA_HOST
+// environment variables. Service account key is read from the file pointed to by GOOGLE_APPLICATION_CREDENTIALS.
+func GCPConfigFromEnv(getenv func(string) string) (*GCPConfig, error) {
+	config := &GCPConfig{}
+
+	if host := getenv("GCE_METADATA_HOST"); host != "" {
+		config.MetadataURL = "http://" + host + "/computeMetadata/v1"
+	}
+
+	if credentialsFile := getenv("GOOGLE_APPLICATION_CREDENTIALS"); credentialsFile != "" {
+		credentials, err := os.ReadFile(credentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading credentials file: %w", err)
+		}
+
+		config.CredentialsJSON = credentials
+	}
+
+	return config, nil
+}
+
+type gcpServiceAccountKey struct {
+	Type        string `json:"type"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+type gcpToken struct {
+	AccessToken string `json:"access_token"`
+}
+
+// GCP resets Compute Engine instance it runs on. Project, zone and name of the instance are read from
+// the metadata server.
+type GCP struct {
+	key         *gcpServiceAccountKey
+	privateKey  *rsa.PrivateKey
+	metadataURL string
+	computeURL  string
+	client      *http.Client
+}
+
+// NewGCP creates new rebooter for Compute Engine instances.
+func NewGCP(config *GCPConfig) (*GCP, error) {
+	gcp := &GCP{
+		metadataURL: strings.TrimSuffix(config.MetadataURL, "/"),
+		computeURL:  strings.TrimSuffix(config.ComputeURL, "/"),
+		client:      httpClient(config.HTTPClient),
+	}
+
+	if gcp.metadataURL == "" {
+		gcp.metadataURL = DefaultGCPMetadataURL
+	}
+
+	if gcp.computeURL == "" {
+		gcp.computeURL = DefaultGCPComputeURL
+	}
+
+	if len(config.CredentialsJSON) == 0 {
+		return gcp, nil
+	}
+
+	key := &gcpServiceAccountKey{}
+
+	if err := json.Unmarshal(config.CredentialsJSON, key); err != nil {
+		return nil, fmt.Errorf("decoding service account key: %w", err)
+	}
+
+	if key.Type != gcpServiceAccount {
+		return nil, fmt.Errorf("unsupported credentials type %q, expected %q", key.Type, gcpServiceAccount)
+	}
+
+	privateKey, err := parseRSAPrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key of service account key: %w", err)
+	}
+
+	gcp.key = key
+	gcp.privateKey = privateKey
+
+	return gcp, nil
+}
+
+// Reboot implements Rebooter interface.
+func (g *GCP) Reboot(ctx context.Context) error {
+	project, err := g.metadata(ctx, "project/project-id")
+	if err != nil {
+		return fmt.Errorf("getting project: %w", err)
+	}
+
+	// Zone is returned in projects/<project number>/zones/<zone> format.
+	zone, err := g.metadata(ctx, "instance/zone")
+	if err != nil {
+		return fmt.Errorf("getting zone: %w", err)
+	}
+
+	instance, err := g.metadata(ctx, "instance/name")
+	if err != nil {
+		return fmt.Errorf("getting instance name: %w", err)
+	}
+
+	token, err := g.accessToken(ctx)
+	if err != nil {
+		return fmt.Errorf("getting access token: %w", err)
+	}
+
+	resetURL := fmt.Sprintf("%s/projects/%s/zones/%s/instances/%s/reset", g.computeURL,
+		url.PathEscape(project), url.PathEscape(path.Base(zone)), url.PathEscape(instance))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, resetURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	if _, _, err := do(g.client, req); err != nil {
+		return fmt.Errorf("resetting instance %q: %w", instance, err)
+	}
+
+	return nil
+}
+
+// metadata reads given metadata key of the instance.
+func (g *GCP) metadata(ctx context.Context, key string) (string, error) {
+	value, err := get(ctx, g.client, g.metadataURL+"/"+key, map[string]string{gcpMetadataHeader: gcpMetadataFlavor})
+
+	return strings.TrimSpace(string(value)), err
+}
+
+// accessToken obtains access token using configured service account key or from the metadata server.
+func (g *GCP) accessToken(ctx context.Context) (string, error) {
+	var rawToken []byte
+
+	var err error
+
+	if g.key == nil {
+		rawToken, err = get(ctx, g.client, g.metadataURL+"/instance/service-accounts/default/token",
+			map[string]string{gcpMetadataHeader: gcpMetadataFlavor})
+	} else {
+		rawToken, err = g.exchangeServiceAccountKey(ctx)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	token := &gcpToken{}
+
+	if err := json.Unmarshal(rawToken, token); err != nil {
+		return "", fmt.Errorf("decoding token: %w", err)
+	}
+
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("received empty access token")
+	}
+
+	return token.AccessToken, nil
+}
+
+// exchangeServiceAccountKey exchanges self-signed JWT for an access token as described in
+// https://developers.google.com/identity/protocols/oauth2/service-account#httprest.
+func (g *GCP) exchangeServiceAccountKey(ctx context.Context) ([]byte, error) {
+	now := time.Now()
+
+	assertion, err := signJWT(g.privateKey, map[string]interface{}{
+		"iss":   g.key.ClientEmail,
+		"scope": gcpComputeScope,
+		"aud":   g.key.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(gcpTokenLifetime).Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("signing token request: %w", err)
+	}
+
+	body := url.Values{"grant_type": {gcpJWTBearerGrant}, "assertion": {assertion}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.key.TokenURI, strings.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	token, _, err := do(g.client, req)
+
+	return token, err
+}
+
+// signJWT creates JWT with given claims signed with given key using RS256 algorithm.
+func signJWT(key *rsa.PrivateKey, claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", fmt.Errorf("encoding header: %w", err)
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("encoding claims: %w", err)
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(unsigned))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("signing: %w", err)
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parseRSAPrivateKey parses PEM encoded RSA private key in PKCS #8 or PKCS #1 format.
+func parseRSAPrivateKey(encoded string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing key: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T, expected RSA key", key)
+	}
+
+	return rsaKey, nil
+}
@@ -0,0 +1,275 @@
+package cloudreboot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// DefaultOpenStackMetadataURL is a URL of OpenStack metadata service.
+	DefaultOpenStackMetadataURL = "http://169.254.169.254"
+
+	openStackComputeService = "compute"
+	openStackTokenHeader    = "X-Auth-Token"
+	openStackDefaultIface   = "public"
+)
+
+// OpenStackConfig represents configurable options for rebooting OpenStack servers. Either user name and
+// password or application credential must be configured.
+type OpenStackConfig struct {
+	// Keystone v3 endpoint, e.g. https://keystone.example.com/v3.
+	AuthURL string
+
+	// Password credentials. Domains default to the default domain. Project ID takes precedence over
+	// project name.
+	Username          string
+	UserDomainName    string
+	UserDomainID      string
+	Password          string
+	ProjectName       string
+	ProjectID         string
+	ProjectDomainName string
+	ProjectDomainID   string
+
+	// Application credential. Takes precedence over password credentials.
+	ApplicationCredentialID     string
+	ApplicationCredentialSecret string
+
+	// Region of the compute endpoint. When not set, the first compute endpoint is used.
+	RegionName string
+	// Interface of the compute endpoint. Defaults to public.
+	Interface string
+	// URL of metadata service. Defaults to DefaultOpenStackMetadataURL.
+	MetadataURL string
+	// Client used for requests. Defaults to client with 30 seconds timeout.
+	HTTPClient *http.Client
+}
+
+// OpenStackConfigFromEnv creates OpenStack configuration from OS_AUTH_URL, OS_USERNAME, OS_USER_DOMAIN_NAME,
+// OS_USER_DOMAIN_ID, OS_PASSWORD, OS_PROJECT_NAME, OS_PROJECT_ID, OS_PROJECT_DOMAIN_NAME, OS_PROJECT_DOMAIN_ID,
+// OS_APPLICATION_CREDENTIAL_ID, OS_APPLICATION_CREDENTIAL_SECRET, OS_REGION_NAME and OS_INTERFACE environment
+// variables, as used by the openstack CLI.
+func OpenStackConfigFromEnv(getenv func(string) string) (*OpenStackConfig, error) {
+	config := &OpenStackConfig{
+		AuthURL:                     getenv("OS_AUTH_URL"),
+		Username:                    getenv("OS_USERNAME"),
+		UserDomainName:              getenv("OS_USER_DOMAIN_NAME"),
+		UserDomainID:                getenv("OS_USER_DOMAIN_ID"),
+		Password:                    getenv("OS_PASSWORD"),
+		ProjectName:                 getenv("OS_PROJECT_NAME"),
+		ProjectID:                   getenv("OS_PROJECT_ID"),
+		ProjectDomainName:           getenv("OS_PROJECT_DOMAIN_NAME"),
+		ProjectDomainID:             getenv("OS_PROJECT_DOMAIN_ID"),
+		ApplicationCredentialID:     getenv("OS_APPLICATION_CREDENTIAL_ID"),
+		ApplicationCredentialSecret: getenv("OS_APPLICATION_CREDENTIAL_SECRET"),
+		RegionName:                  getenv("OS_REGION_NAME"),
+		Interface:                   getenv("OS_INTERFACE"),
+	}
+
+	if config.AuthURL == "" {
+		return nil, fmt.Errorf("OS_AUTH_URL environment variable must be set")
+	}
+
+	return config, nil
+}
+
+// OpenStack hard reboots OpenStack server it runs on. Server ID is read from the metadata service.
+type OpenStack struct {
+	config      OpenStackConfig
+	metadataURL string
+	client      *http.Client
+}
+
+// NewOpenStack creates new rebooter for OpenStack servers.
+func NewOpenStack(config *OpenStackConfig) (*OpenStack, error) {
+	if config.AuthURL == "" {
+		return nil, fmt.Errorf("auth URL must be set")
+	}
+
+	if config.ApplicationCredentialID == "" && (config.Username == "" || config.Password == "") {
+		return nil, fmt.Errorf("either user name and password or application credential must be set")
+	}
+
+	metadataURL := config.MetadataURL
+	if metadataURL == "" {
+		metadataURL = DefaultOpenStackMetadataURL
+	}
+
+	openStack := &OpenStack{
+		config:      *config,
+		metadataURL: strings.TrimSuffix(metadataURL, "/"),
+		client:      httpClient(config.HTTPClient),
+	}
+
+	if openStack.config.Interface == "" {
+		openStack.config.Interface = openStackDefaultIface
+	}
+
+	return openStack, nil
+}
+
+type openStackName struct {
+	Name   string         `json:"name,omitempty"`
+	ID     string         `json:"id,omitempty"`
+	Domain *openStackName `json:"domain,omitempty"`
+}
+
+type openStackTokenResponse struct {
+	Token struct {
+		Catalog []struct {
+			Type      string `json:"type"`
+			Endpoints []struct {
+				Interface string `json:"interface"`
+				Region    string `json:"region"`
+				URL       string `json:"url"`
+			} `json:"endpoints"`
+		} `json:"catalog"`
+	} `json:"token"`
+}
+
+// Reboot implements Rebooter interface.
+func (o *OpenStack) Reboot(ctx context.Context) error {
+	rawMetadata, err := get(ctx, o.client, o.metadataURL+"/openstack/latest/meta_data.json", nil)
+	if err != nil {
+		return fmt.Errorf("getting server metadata: %w", err)
+	}
+
+	metadata := &struct {
+		UUID string `json:"uuid"`
+	}{}
+
+	if err := json.Unmarshal(rawMetadata, metadata); err != nil {
+		return fmt.Errorf("decoding server metadata: %w", err)
+	}
+
+	if metadata.UUID == "" {
+		return fmt.Errorf("no server ID found in metadata")
+	}
+
+	token, computeURL, err := o.authenticate(ctx)
+	if err != nil {
+		return fmt.Errorf("authenticating: %w", err)
+	}
+
+	actionURL := fmt.Sprintf("%s/servers/%s/action", strings.TrimSuffix(computeURL, "/"),
+		url.PathEscape(metadata.UUID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, actionURL,
+		strings.NewReader(`{"reboot":{"type":"HARD"}}`))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(openStackTokenHeader, token)
+
+	if _, _, err := do(o.client, req); err != nil {
+		return fmt.Errorf("rebooting server %q: %w", metadata.UUID, err)
+	}
+
+	return nil
+}
+
+// authenticate obtains token from Keystone and returns it together with URL of compute endpoint
+// from the service catalog.
+func (o *OpenStack) authenticate(ctx context.Context) (string, string, error) {
+	requestBody, err := json.Marshal(map[string]interface{}{"auth": o.authRequest()})
+	if err != nil {
+		return "", "", fmt.Errorf("encoding authentication request: %w", err)
+	}
+
+	tokensURL := strings.TrimSuffix(o.config.AuthURL, "/") + "/auth/tokens"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokensURL, bytes.NewReader(requestBody))
+	if err != nil {
+		return "", "", fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	body, resp, err := do(o.client, req)
+	if err != nil {
+		return "", "", err
+	}
+
+	token := resp.Header.Get("X-Subject-Token")
+	if token == "" {
+		return "", "", fmt.Errorf("no token returned")
+	}
+
+	tokenResponse := &openStackTokenResponse{}
+
+	if err := json.Unmarshal(body, tokenResponse); err != nil {
+		return "", "", fmt.Errorf("decoding token: %w", err)
+	}
+
+	for _, service := range tokenResponse.Token.Catalog {
+		if service.Type != openStackComputeService {
+			continue
+		}
+
+		for _, endpoint := range service.Endpoints {
+			if endpoint.Interface == o.config.Interface &&
+				(o.config.RegionName == "" || endpoint.Region == o.config.RegionName) {
+				return token, endpoint.URL, nil
+			}
+		}
+	}
+
+	return "", "", fmt.Errorf("no %s compute endpoint found in service catalog for region %q",
+		o.config.Interface, o.config.RegionName)
+}
+
+// authRequest builds Keystone v3 authentication request using configured credentials.
+func (o *OpenStack) authRequest() map[string]interface{} {
+	if o.config.ApplicationCredentialID != "" {
+		return map[string]interface{}{
+			"identity": map[string]interface{}{
+				"methods": []string{"application_credential"},
+				"application_credential": map[string]string{
+					"id":     o.config.ApplicationCredentialID,
+					"secret": o.config.ApplicationCredentialSecret,
+				},
+			},
+		}
+	}
+
+	user := map[string]interface{}{
+		"name":     o.config.Username,
+		"password": o.config.Password,
+		"domain":   openStackDomain(o.config.UserDomainName, o.config.UserDomainID),
+	}
+
+	project := &openStackName{
+		Name:   o.config.ProjectName,
+		ID:     o.config.ProjectID,
+		Domain: openStackDomain(o.config.ProjectDomainName, o.config.ProjectDomainID),
+	}
+
+	// Project ID identifies project unambiguously, so domain is not needed.
+	if project.ID != "" {
+		project = &openStackName{ID: project.ID}
+	}
+
+	return map[string]interface{}{
+		"identity": map[string]interface{}{
+			"methods":  []string{"password"},
+			"password": map[string]interface{}{"user": user},
+		},
+		"scope": map[string]interface{}{"project": project},
+	}
+}
+
+// openStackDomain returns domain reference with given name and ID. Default domain is used, if neither is set.
+func openStackDomain(name, id string) *openStackName {
+	if name == "" && id == "" {
+		return &openStackName{ID: "default"}
+	}
+
+	return &openStackName{Name: name, ID: id}
+}
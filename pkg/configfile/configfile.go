@@ -0,0 +1,155 @@
+// Package configfile sets flags of FLUO components from YAML configuration files.
+package configfile
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StringList is a list option, which can be given either as a YAML list of strings or as a comma-separated
+// string, like list flags accept.
+type StringList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler interface.
+func (l *StringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		var list string
+
+		if err := value.Decode(&list); err != nil {
+			return err //nolint:wrapcheck // Decoding errors are already descriptive.
+		}
+
+		*l = strings.Split(list, ",")
+
+		return nil
+	}
+
+	items := []string{}
+
+	if err := value.Decode(&items); err != nil {
+		return err //nolint:wrapcheck // Decoding errors are already descriptive.
+	}
+
+	*l = items
+
+	return nil
+}
+
+// SetFlags decodes the YAML configuration file at given path into given configuration struct and sets flags
+// in given flag set from its fields.
+//
+// Configuration struct must be a pointer to a struct, which fields are named after flags using the yaml tag
+// and are either pointers to string, bool, int, float64 or time.Duration, or StringList, e.g.:
+//
+//	type config struct {
+//		RebootWindowStart       *string              `yaml:"reboot-window-start"`
+//		RebootHistoryLimit      *int                 `yaml:"reboot-history-limit"`
+//		BeforeRebootAnnotations configfile.StringList `yaml:"before-reboot-annotations"`
+//	}
+//
+// Keys not matching any field and values not matching field types are reported as errors. Fields missing
+// in the configuration file are left nil and their flags are not changed. Flags which are already set, e.g.
+// from command line or environment variables, are not changed either, so they take precedence over the
+// configuration file.
+func SetFlags(fs *flag.FlagSet, path string, config interface{}) error {
+	if err := decode(path, config); err != nil {
+		return err
+	}
+
+	alreadySet := map[string]struct{}{}
+
+	fs.Visit(func(f *flag.Flag) {
+		alreadySet[f.Name] = struct{}{}
+	})
+
+	configValue := reflect.ValueOf(config).Elem()
+
+	for i := 0; i < configValue.NumField(); i++ {
+		name := strings.Split(configValue.Type().Field(i).Tag.Get("yaml"), ",")[0]
+
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("configuration field %q does not match any flag", name)
+		}
+
+		value, ok, err := flagValue(configValue.Field(i))
+		if err != nil {
+			return fmt.Errorf("configuration field %q: %w", name, err)
+		}
+
+		if !ok {
+			continue
+		}
+
+		if _, ok := alreadySet[name]; ok {
+			continue
+		}
+
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value %q of option %q in configuration file %q: %w", value, name, path, err)
+		}
+	}
+
+	return nil
+}
+
+// decode decodes configuration file at given path into given configuration struct, rejecting unknown keys.
+func decode(path string, config interface{}) error {
+	if configValue := reflect.ValueOf(config); configValue.Kind() != reflect.Ptr ||
+		configValue.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("configuration must be a pointer to a struct, got %T", config)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading configuration file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	// Empty configuration file sets no options.
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("decoding configuration file %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// flagValue formats value of given configuration field as a flag value. If field is not set in the
+// configuration file, false is returned.
+func flagValue(field reflect.Value) (string, bool, error) {
+	if field.Kind() != reflect.Ptr && field.Kind() != reflect.Slice {
+		return "", false, fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	if field.IsNil() {
+		return "", false, nil
+	}
+
+	switch value := field.Interface().(type) {
+	case StringList:
+		return strings.Join(value, ","), true, nil
+	case *string:
+		return *value, true, nil
+	case *bool:
+		return strconv.FormatBool(*value), true, nil
+	case *int:
+		return strconv.Itoa(*value), true, nil
+	case *float64:
+		return strconv.FormatFloat(*value, 'f', -1, 64), true, nil
+	case *time.Duration:
+		return value.String(), true, nil
+	default:
+		return "", false, fmt.Errorf("unsupported type %T", value)
+	}
+}
@@ -0,0 +1,216 @@
+package configfile_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coreos/pkg/flagutil"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/configfile"
+)
+
+type testFlags struct {
+	fs                 *flag.FlagSet
+	rebootWindowStart  *string
+	maxRebootingNodes  *int
+	forceRebootTimeout *time.Duration
+	nodeUpdateFactor   *float64
+	kuredCompatibility *bool
+	annotations        flagutil.StringSliceFlag
+}
+
+type testConfig struct {
+	RebootWindowStart       *string               `yaml:"reboot-window-start"`
+	MaxRebootingNodes       *int                  `yaml:"max-rebooting-nodes"`
+	ForceRebootTimeout      *time.Duration        `yaml:"force-reboot-timeout"`
+	NodeUpdateFactor        *float64              `yaml:"node-update-retry-factor"`
+	KuredCompatibility      *bool                 `yaml:"kured-compatibility"`
+	BeforeRebootAnnotations configfile.StringList `yaml:"before-reboot-annotations"`
+}
+
+func newTestFlags() *testFlags {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+
+	flags := &testFlags{
+		fs:                 fs,
+		rebootWindowStart:  fs.String("reboot-window-start", "", ""),
+		maxRebootingNodes:  fs.Int("max-rebooting-nodes", 1, ""),
+		forceRebootTimeout: fs.Duration("force-reboot-timeout", 0, ""),
+		nodeUpdateFactor:   fs.Float64("node-update-retry-factor", 1, ""),
+		kuredCompatibility: fs.Bool("kured-compatibility", false, ""),
+	}
+
+	fs.Var(&flags.annotations, "before-reboot-annotations", "")
+
+	return flags
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Writing configuration file: %v", err)
+	}
+
+	return path
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Setting_flags_from_configuration_file(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sets_flags_of_all_supported_types", func(t *testing.T) {
+		t.Parallel()
+
+		flags := newTestFlags()
+
+		path := writeConfigFile(t, `
+reboot-window-start: Mon 14:00
+max-rebooting-nodes: 3
+force-reboot-timeout: 1h30m
+node-update-retry-factor: 1.5
+kured-compatibility: true
+before-reboot-annotations:
+  - example.com/foo
+  - example.com/bar
+`)
+
+		if err := configfile.SetFlags(flags.fs, path, &testConfig{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if *flags.rebootWindowStart != "Mon 14:00" {
+			t.Errorf("Expected reboot window start %q, got %q", "Mon 14:00", *flags.rebootWindowStart)
+		}
+
+		if *flags.maxRebootingNodes != 3 {
+			t.Errorf("Expected max rebooting nodes 3, got %d", *flags.maxRebootingNodes)
+		}
+
+		if *flags.forceRebootTimeout != 90*time.Minute {
+			t.Errorf("Expected force reboot timeout %v, got %v", 90*time.Minute, *flags.forceRebootTimeout)
+		}
+
+		if *flags.nodeUpdateFactor != 1.5 {
+			t.Errorf("Expected node update retry factor 1.5, got %v", *flags.nodeUpdateFactor)
+		}
+
+		if !*flags.kuredCompatibility {
+			t.Errorf("Expected kured compatibility to be enabled")
+		}
+
+		if annotations := strings.Join(flags.annotations, ","); annotations != "example.com/foo,example.com/bar" {
+			t.Errorf("Expected annotations %q, got %q", "example.com/foo,example.com/bar", annotations)
+		}
+	})
+
+	t.Run("does_not_override_flags_which_are_already_set", func(t *testing.T) {
+		t.Parallel()
+
+		flags := newTestFlags()
+
+		if err := flags.fs.Parse([]string{"--max-rebooting-nodes=5"}); err != nil {
+			t.Fatalf("Parsing flags: %v", err)
+		}
+
+		path := writeConfigFile(t, "max-rebooting-nodes: 3\nreboot-window-start: '11:00'\n")
+
+		if err := configfile.SetFlags(flags.fs, path, &testConfig{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if *flags.maxRebootingNodes != 5 {
+			t.Errorf("Expected max rebooting nodes from flag to take precedence, got %d", *flags.maxRebootingNodes)
+		}
+
+		if *flags.rebootWindowStart != "11:00" {
+			t.Errorf("Expected reboot window start %q, got %q", "11:00", *flags.rebootWindowStart)
+		}
+	})
+
+	t.Run("accepts_empty_configuration_file", func(t *testing.T) {
+		t.Parallel()
+
+		if err := configfile.SetFlags(newTestFlags().fs, writeConfigFile(t, ""), &testConfig{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	for name, content := range map[string]string{
+		"unknown_option":         "reboot-window-length: 1h\n",
+		"misspelled_option":      "max-rebooting-node: 3\n",
+		"invalid_integer_value":  "max-rebooting-nodes: many\n",
+		"invalid_duration_value": "force-reboot-timeout: 90\n",
+		"invalid_boolean_value":  "kured-compatibility: maybe\n",
+		"nested_object_value":    "max-rebooting-nodes:\n  default: 1\n",
+		"nested_list_value":      "before-reboot-annotations:\n  - [foo]\n",
+		"non_object_document":    "- max-rebooting-nodes\n",
+		"malformed_document":     "max-rebooting-nodes: [1\n",
+	} {
+		content := content
+
+		t.Run("returns_error_on_"+name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := configfile.SetFlags(newTestFlags().fs, writeConfigFile(t, content), &testConfig{}); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+	}
+
+	t.Run("accepts_comma_separated_string_for_list_options", func(t *testing.T) {
+		t.Parallel()
+
+		flags := newTestFlags()
+
+		path := writeConfigFile(t, "before-reboot-annotations: example.com/foo,example.com/bar\n")
+
+		if err := configfile.SetFlags(flags.fs, path, &testConfig{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if annotations := strings.Join(flags.annotations, ","); annotations != "example.com/foo,example.com/bar" {
+			t.Errorf("Expected annotations %q, got %q", "example.com/foo,example.com/bar", annotations)
+		}
+	})
+
+	t.Run("returns_error_when_configuration_field_does_not_match_any_flag", func(t *testing.T) {
+		t.Parallel()
+
+		config := &struct {
+			RebootWindowLength *string `yaml:"reboot-window-length"`
+		}{}
+
+		if err := configfile.SetFlags(newTestFlags().fs, writeConfigFile(t, ""), config); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+
+	t.Run("returns_error_when_configuration_field_has_unsupported_type", func(t *testing.T) {
+		t.Parallel()
+
+		config := &struct {
+			MaxRebootingNodes *uint `yaml:"max-rebooting-nodes"`
+		}{}
+
+		if err := configfile.SetFlags(newTestFlags().fs, writeConfigFile(t, "max-rebooting-nodes: 3\n"), config); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+
+	t.Run("returns_error_when_configuration_file_does_not_exist", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "config.yaml")
+
+		if err := configfile.SetFlags(newTestFlags().fs, path, &testConfig{}); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+}
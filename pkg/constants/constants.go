@@ -18,6 +18,12 @@ const (
 	// LabelRebootNeeded is an label name set to "true" by the update-agent when a reboot is requested.
 	LabelRebootNeeded = Prefix + "reboot-needed"
 
+	// AnnotationRebootNeededSince is a key set by the update-agent to the time in RFC 3339 format
+	// when it first requested a reboot by setting constants.AnnotationRebootNeeded to "true".
+	//
+	// It is removed by the update-agent when the reboot is initiated.
+	AnnotationRebootNeededSince = Prefix + "reboot-needed-since"
+
 	// AnnotationRebootInProgress is a key set to "true" by the update-agent when node-drain and reboot is
 	// initiated.
 	AnnotationRebootInProgress = Prefix + "reboot-in-progress"
@@ -31,6 +37,64 @@ const (
 	// the update-agent or update-operator.
 	AnnotationRebootPaused = Prefix + "reboot-paused"
 
+	// AnnotationAgentRebootPaused is a key set by the update-agent to "true" when pause file exists
+	// on the host, which prevents update-operator from considering a node for rebooting, same as
+	// constants.AnnotationRebootPaused.
+	AnnotationAgentRebootPaused = Prefix + "agent-reboot-paused"
+
+	// AnnotationRebootStrategy is a key set by the update-agent to the value of "REBOOT_STRATEGY" in
+	// update.conf. When set to "off", update-operator does not consider a node for rebooting.
+	AnnotationRebootStrategy = Prefix + "reboot-strategy"
+
+	// AnnotationDrainFailed is a key set to "true" by the update-agent when it aborted the reboot,
+	// because the node could not be drained. It is set to "false" once the node finishes a reboot.
+	AnnotationDrainFailed = Prefix + "drain-failed"
+
+	// AnnotationCheckUpdateNow is a key that may be set by the administrator to "true" to make the
+	// update-agent trigger an update check immediately. It is removed by the update-agent once the
+	// update check is triggered.
+	AnnotationCheckUpdateNow = Prefix + "check-update-now"
+
+	// AnnotationResetUpdateStatus is a key that may be set by the administrator to "true" to make the
+	// update-agent reset the update source status back to idle, discarding the update pending a reboot.
+	// It is removed by the update-agent once the status is reset.
+	AnnotationResetUpdateStatus = Prefix + "reset-update-status"
+
+	// AnnotationRollback is a key that may be set by the administrator to "true" to make the update-agent
+	// roll back to the previously booted partition and request a reboot. It is removed by the update-agent
+	// once the rollback is prepared.
+	AnnotationRollback = Prefix + "rollback"
+
+	// AnnotationUpdateFailed is a key set to "true" by the update-agent when update source reports
+	// a failed update attempt. It is set to "false" once an update is successfully applied.
+	AnnotationUpdateFailed = Prefix + "update-failed"
+
+	// AnnotationLastUpdateFailureTime is a key set by the update-agent to the time in RFC3339 format
+	// when update source reported a failed update attempt for the last time.
+	AnnotationLastUpdateFailureTime = Prefix + "last-update-failure-time"
+
+	// AnnotationLastUpdateErrorCode is a key set by the update-agent to the error code of the last failed
+	// update attempt, when reported by the update source.
+	AnnotationLastUpdateErrorCode = Prefix + "last-update-error-code"
+
+	// AnnotationUsrPartition is a key set by the update-agent to the name of the USR partition
+	// the node has been booted from, e.g. "USR-A".
+	AnnotationUsrPartition = Prefix + "usr-partition"
+
+	// AnnotationUsrPartitionBeforeReboot is a key set by the update-agent to the name of the booted
+	// USR partition when it starts rebooting the node to apply an update.
+	AnnotationUsrPartitionBeforeReboot = Prefix + "usr-partition-before-reboot"
+
+	// AnnotationRolledBack is a key set to "true" by the update-agent when the node has been rebooted
+	// to apply an update, but booted from the same USR partition as before, which means the update
+	// has been rolled back. It is set to "false" after other reboots.
+	AnnotationRolledBack = Prefix + "rolled-back"
+
+	// AnnotationWaitForCompletion is a key that may be set on a Job to "true" to make the update-agent
+	// wait with draining the node until pods of the Job running on the node finish, up to the configured
+	// timeout.
+	AnnotationWaitForCompletion = Prefix + "wait-for-completion"
+
 	// AnnotationStatus is a key set by the update-agent to the current operator status of update_agent.
 	//
 	// Possible values are:
@@ -57,10 +121,45 @@ const (
 	// It is an opaque string, but might be semver.
 	AnnotationNewVersion = Prefix + "new-version"
 
+	// AnnotationLastStatusTime is a key set by the update-agent to the time in RFC 3339 format when
+	// it last received a changed status from the update source, e.g. update_engine.
+	AnnotationLastStatusTime = Prefix + "last-status-time"
+
+	// AnnotationSecurityUpdate is a key set by the update-agent to "true" when the pending reboot applies
+	// an update which carries security fixes according to configured security feed and to "false" otherwise.
+	AnnotationSecurityUpdate = Prefix + "security-update"
+
+	// AnnotationBootID is a key set by the update-agent to the boot ID of the host when it starts.
+	// Change of the boot ID without constants.AnnotationRebootInProgress set to "true" means that the node
+	// has been rebooted outside of the update-agent.
+	AnnotationBootID = Prefix + "boot-id"
+
+	// AnnotationLastRebootTime is a key set by the update-agent to the time in RFC 3339 format when
+	// it finished the last reboot process after the node came back.
+	AnnotationLastRebootTime = Prefix + "last-reboot-time"
+
+	// AnnotationLastRebootVersion is a key set by the update-agent to the value of constants.LabelVersion
+	// the node was running when it finished the last reboot process.
+	AnnotationLastRebootVersion = Prefix + "last-reboot-version"
+
 	// AnnotationAgentMadeUnschedulable is a key set by update-agent to indicate
 	// it was responsible for making node unschedulable.
 	AnnotationAgentMadeUnschedulable = Prefix + "agent-made-unschedulable"
 
+	// AnnotationUncordonAfterReboot is a key that may be set by the administrator to "false" to make the
+	// update-agent leave the node unschedulable after the reboot, or to "true" to make it schedulable,
+	// overriding the update-agent configuration.
+	AnnotationUncordonAfterReboot = Prefix + "uncordon-after-reboot"
+
+	// AnnotationChannel is a key that may be set by the administrator to the name of the update channel,
+	// e.g. "stable", to make the update-agent switch the update source to it, when configured to do so.
+	AnnotationChannel = Prefix + "channel"
+
+	// AnnotationEmergencyReboot is a key that may be set by the administrator to "true" to make the
+	// update-agent drain and reboot the node immediately, without waiting for the update-operator
+	// approval or the reboot window. It is removed by the update-agent once the node is rebooted.
+	AnnotationEmergencyReboot = Prefix + "emergency-reboot"
+
 	// LabelBeforeReboot is a key set to true when the operator is waiting for configured annotation
 	// before and after the reboot respectively.
 	LabelBeforeReboot = Prefix + "before-reboot"
@@ -69,6 +168,57 @@ const (
 	// before and after the reboot respectively.
 	LabelAfterReboot = Prefix + "after-reboot"
 
+	// AnnotationRebootStartedTime is a key set by the update-operator to the time in RFC 3339 format
+	// when the node has been scheduled for rebooting.
+	//
+	// It is removed by the update-operator when the reboot process is finished.
+	AnnotationRebootStartedTime = Prefix + "reboot-started-time"
+
+	// AnnotationVersionBeforeReboot is a key set by the update-operator to the value of constants.LabelVersion
+	// label when the node has been scheduled for rebooting.
+	//
+	// It is removed by the update-operator when the reboot process is finished.
+	AnnotationVersionBeforeReboot = Prefix + "version-before-reboot"
+
+	// AnnotationRebootNeededSinceBeforeReboot is a key set by the update-operator to the value of
+	// constants.AnnotationRebootNeededSince annotation when the node has been scheduled for rebooting,
+	// as the update-agent removes it once it starts rebooting the node.
+	//
+	// It is removed by the update-operator when the reboot process is finished.
+	AnnotationRebootNeededSinceBeforeReboot = Prefix + "reboot-needed-since-before-reboot"
+
+	// AnnotationBeforeRebootTimedOutTime is a key set by the update-operator to the time in RFC 3339 format
+	// when before-reboot annotations were not set in time and the node has been unscheduled from rebooting.
+	//
+	// It is removed by the update-operator when the node is scheduled for rebooting again.
+	AnnotationBeforeRebootTimedOutTime = Prefix + "before-reboot-timed-out-time"
+
+	// AnnotationRebootApprovedTime is a key set by the update-operator to the time in RFC 3339 format
+	// when constants.AnnotationOkToReboot has been set to "true".
+	//
+	// It is removed by the update-operator when the reboot process is finished or the approval is revoked.
+	AnnotationRebootApprovedTime = Prefix + "reboot-approved-time"
+
+	// AnnotationRebootApprovalRevokedTime is a key set by the update-operator to the time in RFC 3339 format
+	// when the reboot approval has been revoked, either because the update-agent did not start rebooting
+	// the node in time or because rebooting is no longer allowed while the reboot is scheduled.
+	//
+	// It is removed by the update-operator when the node is scheduled for rebooting again.
+	AnnotationRebootApprovalRevokedTime = Prefix + "reboot-approval-revoked-time"
+
+	// AnnotationRebootScheduledTime is a key set by the update-agent to the time in RFC 3339 format
+	// when the host is scheduled to reboot, if the reboot is delayed to warn logged in users.
+	//
+	// It is removed by the update-agent when the scheduled reboot is cancelled or the agent starts.
+	AnnotationRebootScheduledTime = Prefix + "reboot-scheduled-time"
+
+	// AnnotationRebootFastPath is a key set to "true" by the update-operator when the node has been
+	// scheduled for rebooting using the fast path for cordoned nodes without workload. Such nodes do
+	// not count against the maximum number of rebooting nodes.
+	//
+	// It is removed by the update-operator when the reboot process is finished.
+	AnnotationRebootFastPath = Prefix + "reboot-fast-path"
+
 	// LabelID is a key set by the update-agent to the value of "ID" in /etc/os-release.
 	LabelID = Prefix + "id"
 
@@ -80,6 +230,9 @@ const (
 	// LabelVersion is a key set by the update-agent to the value of "VERSION" in /etc/os-release.
 	LabelVersion = Prefix + "version"
 
+	// LabelBoard is a key set by the update-agent to the value of "FLATCAR_BOARD" in /etc/os-release.
+	LabelBoard = Prefix + "board"
+
 	// AgentVersion is the key used to indicate the
 	// flatcar-linux-update-operator's agent's version.
 	// The value is a semver-parseable string. It should be present on each agent
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	godbus "github.com/godbus/dbus/v5"
+	"k8s.io/klog/v2"
 )
 
 // Client is an interface describing capabilities of internal D-Bus client.
@@ -28,12 +30,94 @@ type Connection interface {
 // Connector is a constructor function providing D-Bus connection.
 type Connector func() (Connection, error)
 
+// SystemBusAddressEnv is an environment variable, which overrides address of the system bus.
+const SystemBusAddressEnv = "DBUS_SYSTEM_BUS_ADDRESS"
+
+// SystemBusSocketPaths are well-known paths of the system bus socket. Containers may have only one of them
+// mounted from the host, e.g. when /run/dbus is mounted without /var/run symlink.
+//
+//nolint:gochecknoglobals // Read-only list.
+var SystemBusSocketPaths = []string{"/var/run/dbus/system_bus_socket", "/run/dbus/system_bus_socket"}
+
 // SystemPrivateConnector is a standard connector using system bus.
 func SystemPrivateConnector() (Connection, error) {
 	return godbus.SystemBusPrivate()
 }
 
-// New creates new D-Bus client using given connector.
+// SocketAddress returns D-Bus address of Unix socket with given path.
+func SocketAddress(path string) string {
+	return "unix:path=" + path
+}
+
+// SystemBusAddress returns address of the system bus to connect to. Address is selected in the following order:
+//
+//   - Unix socket with given path, if not empty.
+//   - Address set in SystemBusAddressEnv environment variable.
+//   - First existing Unix socket out of given candidate paths.
+//
+// Empty address is returned when none of the above is available, so default system bus address should be used.
+func SystemBusAddress(socketPath string, candidatePaths ...string) string {
+	if socketPath != "" {
+		return SocketAddress(socketPath)
+	}
+
+	if address := os.Getenv(SystemBusAddressEnv); address != "" {
+		return address
+	}
+
+	if sockets := existingSockets(candidatePaths); len(sockets) > 0 {
+		return SocketAddress(sockets[0])
+	}
+
+	return ""
+}
+
+// SystemBusConnector returns connector connecting to the system bus at address selected the same way as
+// SystemBusAddress does. When the address is selected out of given candidate paths and connecting to the
+// socket fails, e.g. because it has been left behind by a stopped bus daemon, next existing candidate is tried.
+func SystemBusConnector(socketPath string, candidatePaths ...string) Connector {
+	return func() (Connection, error) {
+		if address := SystemBusAddress(socketPath); address != "" {
+			return godbus.Dial(address)
+		}
+
+		sockets := existingSockets(candidatePaths)
+		if len(sockets) == 0 {
+			return godbus.SystemBusPrivate()
+		}
+
+		errs := make([]string, 0, len(sockets))
+
+		for _, path := range sockets {
+			conn, err := godbus.Dial(SocketAddress(path))
+			if err == nil {
+				return conn, nil
+			}
+
+			klog.Warningf("Failed connecting to system bus socket %q, trying next one: %v", path, err)
+
+			errs = append(errs, err.Error())
+		}
+
+		return nil, fmt.Errorf("connecting to any of system bus sockets %q: %s", sockets, strings.Join(errs, "; "))
+	}
+}
+
+// existingSockets returns given paths which point to Unix sockets, preserving the order.
+func existingSockets(paths []string) []string {
+	sockets := []string{}
+
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			sockets = append(sockets, path)
+		}
+	}
+
+	return sockets
+}
+
+// New creates new D-Bus client using given connector. Failures wrap ErrNotConnected when connecting fails
+// and ErrPermissionDenied when authentication fails.
 func New(connector Connector) (Client, error) {
 	if connector == nil {
 		return nil, fmt.Errorf("no connection creator given")
@@ -41,7 +125,7 @@ func New(connector Connector) (Client, error) {
 
 	conn, err := connector()
 	if err != nil {
-		return nil, fmt.Errorf("connecting to D-Bus: %w", err)
+		return nil, withKind(ErrNotConnected, fmt.Errorf("connecting to D-Bus: %w", err))
 	}
 
 	methods := []godbus.Auth{godbus.AuthExternal(strconv.Itoa(os.Getuid()))}
@@ -52,7 +136,7 @@ func New(connector Connector) (Client, error) {
 		//nolint:errcheck // TODO: We will add logger as a dependencty to client to fix it.
 		_ = conn.Close()
 
-		return nil, fmt.Errorf("authenticating to D-Bus: %w", err)
+		return nil, withKind(ErrPermissionDenied, fmt.Errorf("authenticating to D-Bus: %w", err))
 	}
 
 	if err := conn.Hello(); err != nil {
@@ -61,7 +145,7 @@ func New(connector Connector) (Client, error) {
 		//nolint:errcheck // TODO: We will add logger as a dependencty to client to fix it.
 		_ = conn.Close()
 
-		return nil, fmt.Errorf("sending hello to D-Bus: %w", err)
+		return nil, fmt.Errorf("sending hello to D-Bus: %w", ClassifyError(err))
 	}
 
 	return conn, nil
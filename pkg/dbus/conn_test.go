@@ -4,9 +4,12 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
+	"time"
 
 	godbus "github.com/godbus/dbus/v5"
 
@@ -74,6 +77,7 @@ func Test_Creating_client_returns_error_when(t *testing.T) {
 		failingConnectionConnector := func() (dbus.Connection, error) { return nil, expectedErr }
 
 		testNewError(t, failingConnectionConnector, expectedErr)
+		testNewError(t, failingConnectionConnector, dbus.ErrNotConnected)
 	})
 
 	t.Run("authenticating_to_D-Bus_fails", func(t *testing.T) {
@@ -95,6 +99,7 @@ func Test_Creating_client_returns_error_when(t *testing.T) {
 		}
 
 		testNewError(t, func() (dbus.Connection, error) { return failingAuthConnection, nil }, expectedErr)
+		testNewError(t, func() (dbus.Connection, error) { return failingAuthConnection, nil }, dbus.ErrPermissionDenied)
 
 		t.Run("and_tries_to_close_the_client_while_ignoring_closing_error", func(t *testing.T) {
 			if !closeCalled {
@@ -187,3 +192,195 @@ func (m *mockConnection) Close() error {
 
 	return m.closeF()
 }
+
+//nolint:paralleltest // This test use environment variables.
+func Test_System_bus_address(t *testing.T) {
+	t.Run("is_built_from_given_socket_path_when_set", func(t *testing.T) {
+		t.Setenv(dbus.SystemBusAddressEnv, "unix:path=/from/env")
+
+		if address := dbus.SystemBusAddress("/foo", testSocket(t)); address != "unix:path=/foo" {
+			t.Fatalf("Expected address of given socket, got %q", address)
+		}
+	})
+
+	t.Run("is_read_from_environment_variable_when_no_socket_path_is_given", func(t *testing.T) {
+		t.Setenv(dbus.SystemBusAddressEnv, "unix:path=/from/env")
+
+		if address := dbus.SystemBusAddress("", testSocket(t)); address != "unix:path=/from/env" {
+			t.Fatalf("Expected address from environment variable, got %q", address)
+		}
+	})
+
+	t.Run("points_to_first_existing_socket_from_given_candidates_when_environment_variable_is_not_set",
+		func(t *testing.T) {
+			t.Setenv(dbus.SystemBusAddressEnv, "")
+
+			socketPath := testSocket(t)
+
+			address := dbus.SystemBusAddress("", "/non/existing", socketPath, testSocket(t))
+			if expectedAddress := dbus.SocketAddress(socketPath); address != expectedAddress {
+				t.Fatalf("Expected address %q, got %q", expectedAddress, address)
+			}
+		})
+
+	t.Run("is_empty_when_none_of_given_candidates_is_a_socket", func(t *testing.T) {
+		t.Setenv(dbus.SystemBusAddressEnv, "")
+
+		regularFile := filepath.Join(t.TempDir(), "system_bus_socket")
+
+		if err := os.WriteFile(regularFile, nil, 0o600); err != nil {
+			t.Fatalf("Creating test file: %v", err)
+		}
+
+		if address := dbus.SystemBusAddress("", "/non/existing", regularFile); address != "" {
+			t.Fatalf("Expected empty address, got %q", address)
+		}
+	})
+}
+
+//nolint:paralleltest,funlen // This test use environment variables.
+func Test_System_bus_connector(t *testing.T) {
+	t.Run("falls_back_to_next_candidate_when_connecting_to_socket_fails", func(t *testing.T) {
+		t.Setenv(dbus.SystemBusAddressEnv, "")
+
+		accepted := make(chan struct{}, 1)
+
+		connector := dbus.SystemBusConnector("", staleTestSocket(t), acceptingTestSocket(t, accepted))
+
+		conn, err := connector()
+		if err != nil {
+			t.Fatalf("Unexpected error connecting: %v", err)
+		}
+
+		t.Cleanup(func() {
+			if err := conn.Close(); err != nil {
+				t.Logf("Closing connection: %v", err)
+			}
+		})
+
+		select {
+		case <-accepted:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected connection to second candidate socket")
+		}
+	})
+
+	t.Run("returns_error_when_connecting_to_all_candidates_fails", func(t *testing.T) {
+		t.Setenv(dbus.SystemBusAddressEnv, "")
+
+		connector := dbus.SystemBusConnector("", "/non/existing", staleTestSocket(t), staleTestSocket(t))
+
+		if _, err := connector(); err == nil {
+			t.Fatalf("Expected error connecting")
+		}
+	})
+
+	t.Run("does_not_fall_back_to_candidates_when_connecting_to_given_socket_fails", func(t *testing.T) {
+		t.Setenv(dbus.SystemBusAddressEnv, "")
+
+		accepted := make(chan struct{}, 1)
+
+		connector := dbus.SystemBusConnector(staleTestSocket(t), acceptingTestSocket(t, accepted))
+
+		if _, err := connector(); err == nil {
+			t.Fatalf("Expected error connecting")
+		}
+	})
+
+	t.Run("does_not_modify_environment", func(t *testing.T) {
+		t.Setenv(dbus.SystemBusAddressEnv, "")
+
+		conn, err := dbus.SystemBusConnector("", acceptingTestSocket(t, make(chan struct{}, 1)))()
+		if err != nil {
+			t.Fatalf("Unexpected error connecting: %v", err)
+		}
+
+		if err := conn.Close(); err != nil {
+			t.Logf("Closing connection: %v", err)
+		}
+
+		if address := os.Getenv(dbus.SystemBusAddressEnv); address != "" {
+			t.Fatalf("Expected environment variable to remain empty, got %q", address)
+		}
+	})
+}
+
+// staleTestSocket creates Unix socket file nobody listens on and returns its path.
+func staleTestSocket(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "bus")
+
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatalf("Creating test socket: %v", err)
+	}
+
+	listener.SetUnlinkOnClose(false)
+
+	if err := listener.Close(); err != nil {
+		t.Fatalf("Closing test socket: %v", err)
+	}
+
+	return path
+}
+
+// acceptingTestSocket creates listening Unix socket, which notifies given channel about accepted
+// connections, and returns its path.
+func acceptingTestSocket(t *testing.T, accepted chan<- struct{}) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "bus")
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Creating test socket: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := listener.Close(); err != nil {
+			t.Logf("Closing test socket: %v", err)
+		}
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			select {
+			case accepted <- struct{}{}:
+			default:
+			}
+
+			// Test only checks that the connection has been made.
+			//
+			//nolint:errcheck // Best effort closing the connection.
+			_ = conn.Close()
+		}
+	}()
+
+	return path
+}
+
+// testSocket creates listening Unix socket and returns its path.
+func testSocket(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "bus")
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Creating test socket: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := listener.Close(); err != nil {
+			t.Logf("Closing test socket: %v", err)
+		}
+	})
+
+	return path
+}
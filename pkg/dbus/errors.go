@@ -0,0 +1,98 @@
+package dbus
+
+import (
+	"errors"
+
+	godbus "github.com/godbus/dbus/v5"
+)
+
+var (
+	// ErrNotConnected is wrapped by errors caused by D-Bus connection which could not be established
+	// or has been closed. Such failures are transient, as the connection can be re-established.
+	ErrNotConnected = errors.New("not connected to D-Bus")
+	// ErrServiceUnavailable is wrapped by errors caused by the called service not running or not replying
+	// in time. Such failures are transient, e.g. while the service is being restarted.
+	ErrServiceUnavailable = errors.New("D-Bus service unavailable")
+	// ErrPermissionDenied is wrapped by errors caused by D-Bus policy or authentication denying the access.
+	// Such failures are fatal, as retrying does not help without changing the configuration.
+	ErrPermissionDenied = errors.New("D-Bus permission denied")
+)
+
+// Names of D-Bus errors, as defined by the D-Bus specification, mapped to the error kinds.
+//
+//nolint:gochecknoglobals // Read-only map.
+var errorKinds = map[string]error{
+	"org.freedesktop.DBus.Error.Disconnected":                     ErrNotConnected,
+	"org.freedesktop.DBus.Error.NoServer":                         ErrNotConnected,
+	"org.freedesktop.DBus.Error.ServiceUnknown":                   ErrServiceUnavailable,
+	"org.freedesktop.DBus.Error.NameHasNoOwner":                   ErrServiceUnavailable,
+	"org.freedesktop.DBus.Error.NoReply":                          ErrServiceUnavailable,
+	"org.freedesktop.DBus.Error.Timeout":                          ErrServiceUnavailable,
+	"org.freedesktop.DBus.Error.TimedOut":                         ErrServiceUnavailable,
+	"org.freedesktop.DBus.Error.AccessDenied":                     ErrPermissionDenied,
+	"org.freedesktop.DBus.Error.AuthFailed":                       ErrPermissionDenied,
+	"org.freedesktop.DBus.Error.InteractiveAuthorizationRequired": ErrPermissionDenied,
+}
+
+// classifiedError is an error of known kind. It matches both the kind and the original error
+// using errors.Is.
+type classifiedError struct {
+	kind error
+	err  error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+func (e *classifiedError) Is(target error) bool {
+	return target == e.kind
+}
+
+// ClassifyError wraps given error returned by D-Bus, e.g. by a method call, with ErrNotConnected,
+// ErrServiceUnavailable or ErrPermissionDenied, based on its cause. Unknown errors are returned as is.
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	kind := errorKind(err)
+	if kind == nil || errors.Is(err, kind) {
+		return err
+	}
+
+	return withKind(kind, err)
+}
+
+// withKind returns given error matching also given kind using errors.Is.
+func withKind(kind, err error) error {
+	return &classifiedError{kind: kind, err: err}
+}
+
+// IsTransient returns true when given error has been classified as transient, so the failed operation
+// may be retried.
+func IsTransient(err error) bool {
+	return errors.Is(err, ErrNotConnected) || errors.Is(err, ErrServiceUnavailable)
+}
+
+func errorKind(err error) error {
+	if errors.Is(err, godbus.ErrClosed) {
+		return ErrNotConnected
+	}
+
+	var dbusErr godbus.Error
+	if errors.As(err, &dbusErr) {
+		return errorKinds[dbusErr.Name]
+	}
+
+	var dbusErrPtr *godbus.Error
+	if errors.As(err, &dbusErrPtr) {
+		return errorKinds[dbusErrPtr.Name]
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package dbus_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	godbus "github.com/godbus/dbus/v5"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/dbus"
+)
+
+func Test_Classifying_error(t *testing.T) {
+	t.Parallel()
+
+	for name, testCase := range map[string]struct {
+		err          error
+		expectedKind error
+		transient    bool
+	}{
+		"closed_connection_as_not_connected": {
+			err:          godbus.ErrClosed,
+			expectedKind: dbus.ErrNotConnected,
+			transient:    true,
+		},
+		"unknown_service_as_unavailable_service": {
+			err:          godbus.Error{Name: "org.freedesktop.DBus.Error.ServiceUnknown"},
+			expectedKind: dbus.ErrServiceUnavailable,
+			transient:    true,
+		},
+		"wrapped_missing_reply_as_unavailable_service": {
+			err:          fmt.Errorf("calling method: %w", godbus.NewError("org.freedesktop.DBus.Error.NoReply", nil)),
+			expectedKind: dbus.ErrServiceUnavailable,
+			transient:    true,
+		},
+		"denied_access_as_denied_permission": {
+			err:          godbus.Error{Name: "org.freedesktop.DBus.Error.AccessDenied"},
+			expectedKind: dbus.ErrPermissionDenied,
+		},
+	} {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := dbus.ClassifyError(testCase.err)
+
+			if !errors.Is(err, testCase.expectedKind) {
+				t.Fatalf("Expected error %q to be classified as %q", err, testCase.expectedKind)
+			}
+
+			if err.Error() != testCase.err.Error() {
+				t.Fatalf("Expected error message %q to be preserved, got %q", testCase.err, err)
+			}
+
+			if transient := dbus.IsTransient(err); transient != testCase.transient {
+				t.Fatalf("Expected transient to be %v, got %v", testCase.transient, transient)
+			}
+		})
+	}
+
+	t.Run("returns_unknown_errors_as_is", func(t *testing.T) {
+		t.Parallel()
+
+		err := fmt.Errorf("unknown")
+
+		if classified := dbus.ClassifyError(err); classified != err { //nolint:errorlint // Identity is expected.
+			t.Fatalf("Expected unknown error to be returned as is, got %q", classified)
+		}
+
+		if dbus.IsTransient(err) {
+			t.Fatalf("Expected unknown error not to be transient")
+		}
+	})
+}
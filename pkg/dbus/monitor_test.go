@@ -0,0 +1,147 @@
+package dbus_test
+
+import (
+	"fmt"
+	"testing"
+
+	godbus "github.com/godbus/dbus/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/dbus"
+)
+
+//nolint:funlen // Just many subtests.
+func Test_Monitor(t *testing.T) {
+	t.Parallel()
+
+	t.Run("records_duration_and_errors_of_method_calls_by_method", func(t *testing.T) {
+		t.Parallel()
+
+		registry := prometheus.NewRegistry()
+
+		monitor, err := dbus.NewMonitor(registry, "test")
+		if err != nil {
+			t.Fatalf("Unexpected error creating monitor: %v", err)
+		}
+
+		object := monitor.InstrumentObject(&dbus.MockObject{
+			CallF: func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+				if method == "Failing" {
+					return &godbus.Call{Err: fmt.Errorf("failed")}
+				}
+
+				return &godbus.Call{}
+			},
+		})
+
+		object.Call("Failing", 0)
+		object.Call("Succeeding", 0)
+		object.Call("Succeeding", 0)
+
+		durations := metric(t, registry, "test_dbus_call_duration_seconds", "Succeeding").GetHistogram()
+		if count := durations.GetSampleCount(); count != 2 {
+			t.Fatalf("Expected 2 observed calls, got %d", count)
+		}
+
+		if errors := metric(t, registry, "test_dbus_call_errors_total", "Failing").GetCounter().GetValue(); errors != 1 {
+			t.Fatalf("Expected 1 call error, got %v", errors)
+		}
+	})
+
+	t.Run("reports_connection_state", func(t *testing.T) {
+		t.Parallel()
+
+		registry := prometheus.NewRegistry()
+
+		monitor, err := dbus.NewMonitor(registry, "test")
+		if err != nil {
+			t.Fatalf("Unexpected error creating monitor: %v", err)
+		}
+
+		if err := monitor.Healthz(); err != nil {
+			t.Fatalf("Expected new monitor to be healthy, got: %v", err)
+		}
+
+		monitor.SetConnected(false)
+
+		if err := monitor.Healthz(); err == nil {
+			t.Fatalf("Expected monitor to be unhealthy when disconnected")
+		}
+
+		if connected := metric(t, registry, "test_dbus_connected", "").GetGauge().GetValue(); connected != 0 {
+			t.Fatalf("Expected connected metric to be 0 when disconnected, got %v", connected)
+		}
+
+		monitor.SetConnected(true)
+
+		if err := monitor.Healthz(); err != nil {
+			t.Fatalf("Expected monitor to be healthy after reconnecting, got: %v", err)
+		}
+	})
+
+	t.Run("is_optional", func(t *testing.T) {
+		t.Parallel()
+
+		var monitor *dbus.Monitor
+
+		monitor.SetConnected(false)
+
+		if err := monitor.Healthz(); err != nil {
+			t.Fatalf("Expected nil monitor to be healthy, got: %v", err)
+		}
+
+		object := &dbus.MockObject{}
+
+		if instrumented := monitor.InstrumentObject(object); instrumented != object {
+			t.Fatalf("Expected nil monitor to return object as is")
+		}
+	})
+
+	t.Run("fails_to_be_created_when_registering_metrics_fails", func(t *testing.T) {
+		t.Parallel()
+
+		registry := prometheus.NewRegistry()
+
+		if _, err := dbus.NewMonitor(registry, "test"); err != nil {
+			t.Fatalf("Unexpected error creating monitor: %v", err)
+		}
+
+		if _, err := dbus.NewMonitor(registry, "test"); err == nil {
+			t.Fatalf("Expected error registering metrics twice")
+		}
+	})
+}
+
+// metric returns metric with given name and method label value, or the first metric with given name
+// when method is empty.
+func metric(t *testing.T, registry *prometheus.Registry, name, method string) *dto.Metric {
+	t.Helper()
+
+	metricFamilies, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed gathering metrics: %v", err)
+	}
+
+	for _, metricFamily := range metricFamilies {
+		if metricFamily.GetName() != name {
+			continue
+		}
+
+		for _, metric := range metricFamily.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "method" && label.GetValue() == method {
+					return metric
+				}
+			}
+
+			if method == "" {
+				return metric
+			}
+		}
+	}
+
+	t.Fatalf("Metric %q with method %q not found", name, method)
+
+	return nil
+}
@@ -0,0 +1,135 @@
+// Package flatcar provides functions for reading Flatcar Container Linux release and update configuration
+// from host files.
+package flatcar
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// OSReleasePath is a path of the file describing the running OS release.
+	OSReleasePath = "/etc/os-release"
+	// UpdateConfPath is a path of the update configuration shipped with the OS image.
+	UpdateConfPath = "/usr/share/flatcar/update.conf"
+	// UpdateConfOverridePath is a path of the update configuration overriding values shipped with the OS image.
+	UpdateConfOverridePath = "/etc/flatcar/update.conf"
+
+	// RebootStrategyOff is a reboot strategy configured on hosts, which should never be rebooted
+	// automatically.
+	RebootStrategyOff = "off"
+)
+
+// OSRelease contains information about the running OS release.
+type OSRelease struct {
+	// Value of "ID", e.g. "flatcar".
+	ID string
+	// Value of "VERSION", e.g. "3510.2.1".
+	Version string
+	// Value of "VERSION_ID", e.g. "3510.2.1".
+	VersionID string
+	// Value of "FLATCAR_BOARD", e.g. "amd64-usr".
+	Board string
+}
+
+// UpdateConfig contains update configuration used by update_engine.
+type UpdateConfig struct {
+	// Value of "GROUP", i.e. the update channel, e.g. "stable".
+	Group string
+	// Value of "SERVER", i.e. the URL of the update server.
+	Server string
+	// Value of "REBOOT_STRATEGY", e.g. "reboot" or "off".
+	RebootStrategy string
+}
+
+// ReadOSRelease reads release information from os-release file under given host files prefix.
+func ReadOSRelease(hostFilesPrefix string) (*OSRelease, error) {
+	values := map[string]string{}
+
+	// This file should always be present on Flatcar.
+	if err := readEnvFile(values, filepath.Join(hostFilesPrefix, OSReleasePath)); err != nil {
+		return nil, err
+	}
+
+	return &OSRelease{
+		ID:        values["ID"],
+		Version:   values["VERSION"],
+		VersionID: values["VERSION_ID"],
+		Board:     values["FLATCAR_BOARD"],
+	}, nil
+}
+
+// ReadUpdateConfig reads update configuration under given host files prefix. Values from the update
+// configuration shipped with the OS image are overridden by values from the optional override file.
+func ReadUpdateConfig(hostFilesPrefix string) (*UpdateConfig, error) {
+	values := map[string]string{}
+
+	// This file should always be present on Flatcar.
+	if err := readEnvFile(values, filepath.Join(hostFilesPrefix, UpdateConfPath)); err != nil {
+		return nil, err
+	}
+
+	err := readEnvFile(values, filepath.Join(hostFilesPrefix, UpdateConfOverridePath))
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		klog.Infof("Skipping missing update.conf: %v", err)
+	case err != nil:
+		return nil, err
+	}
+
+	return &UpdateConfig{
+		Group:          values["GROUP"],
+		Server:         values["SERVER"],
+		RebootStrategy: values["REBOOT_STRATEGY"],
+	}, nil
+}
+
+// readEnvFile reads newline-delimited KEY=VAL pairs from given file and puts values into given map.
+func readEnvFile(envVars map[string]string, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading file %q: %w", path, err)
+	}
+
+	splitNewlineEnv(envVars, string(content))
+
+	return nil
+}
+
+// splitNewlineEnv splits newline-delimited KEY=VAL pairs and puts values into given map.
+func splitNewlineEnv(envVars map[string]string, envs string) {
+	sc := bufio.NewScanner(strings.NewReader(envs))
+	for sc.Scan() {
+		// Even if value contains the delimiter, we want to ignore it.
+		maxSubstrings := 2
+		spl := strings.SplitN(sc.Text(), "=", maxSubstrings)
+
+		// Just skip empty lines or lines without a value.
+		if len(spl) == 1 {
+			continue
+		}
+
+		envVars[spl[0]] = unquote(spl[1])
+	}
+}
+
+// unquote removes matching single or double quotes surrounding given value, which are allowed
+// in os-release files.
+func unquote(value string) string {
+	if len(value) < 2 { //nolint:gomnd // Opening and closing quote.
+		return value
+	}
+
+	if first := value[0]; (first == '"' || first == '\'') && value[len(value)-1] == first {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
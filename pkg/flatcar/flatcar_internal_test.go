@@ -0,0 +1,68 @@
+package flatcar
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_splitNewlineEnv(t *testing.T) {
+	t.Parallel()
+
+	t.Run("retain_map_values_when_given_empty_input", func(t *testing.T) {
+		t.Parallel()
+
+		expected := map[string]string{"foo": "bar"}
+
+		input := map[string]string{}
+
+		splitNewlineEnv(input, "foo=bar")
+
+		splitNewlineEnv(input, "")
+
+		if !reflect.DeepEqual(expected, input) {
+			t.Fatalf("Should retain original map when given empty content")
+		}
+	})
+
+	t.Run("skips_lines_without_equal_sign", func(t *testing.T) {
+		t.Parallel()
+
+		expected := map[string]string{}
+
+		input := map[string]string{}
+
+		splitNewlineEnv(input, "foo")
+
+		if !reflect.DeepEqual(expected, input) {
+			t.Fatalf("Expected %q, got %q", expected, input)
+		}
+	})
+
+	t.Run("skips_empty_lines", func(t *testing.T) {
+		t.Parallel()
+
+		expected := map[string]string{}
+
+		input := map[string]string{}
+
+		splitNewlineEnv(input, "")
+
+		if !reflect.DeepEqual(expected, input) {
+			t.Fatalf("Expected %q, got %q", expected, input)
+		}
+	})
+
+	t.Run("removes_quotes_surrounding_values", func(t *testing.T) {
+		t.Parallel()
+
+		expected := map[string]string{"foo": "bar", "baz": "doh", "empty": "", "unmatched": `"quote`}
+
+		input := map[string]string{}
+
+		splitNewlineEnv(input, "foo=\"bar\"\nbaz='doh'\nempty=\"\"\nunmatched=\"quote")
+
+		if !reflect.DeepEqual(expected, input) {
+			t.Fatalf("Expected %q, got %q", expected, input)
+		}
+	})
+}
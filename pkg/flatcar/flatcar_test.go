@@ -0,0 +1,120 @@
+package flatcar_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/flatcar"
+)
+
+func Test_Reading_OS_release(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns_values_from_os_release_file", func(t *testing.T) {
+		t.Parallel()
+
+		hostFilesPrefix := t.TempDir()
+
+		createFile(t, hostFilesPrefix, flatcar.OSReleasePath,
+			"ID=flatcar\nVERSION=3510.2.1\nVERSION_ID=\"3510.2.1\"\nFLATCAR_BOARD=amd64-usr\nNAME=\"Flatcar\"")
+
+		osRelease, err := flatcar.ReadOSRelease(hostFilesPrefix)
+		if err != nil {
+			t.Fatalf("Unexpected error reading OS release: %v", err)
+		}
+
+		expected := &flatcar.OSRelease{
+			ID:        "flatcar",
+			Version:   "3510.2.1",
+			VersionID: "3510.2.1",
+			Board:     "amd64-usr",
+		}
+
+		if diff := cmp.Diff(expected, osRelease); diff != "" {
+			t.Fatalf("Unexpected OS release: %s", diff)
+		}
+	})
+
+	t.Run("returns_error_when_os_release_file_is_missing", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := flatcar.ReadOSRelease(t.TempDir()); err == nil {
+			t.Fatalf("Expected error reading missing OS release file")
+		}
+	})
+}
+
+func Test_Reading_update_configuration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns_values_from_image_configuration_overridden_by_override_file", func(t *testing.T) {
+		t.Parallel()
+
+		hostFilesPrefix := t.TempDir()
+
+		createFile(t, hostFilesPrefix, flatcar.UpdateConfPath,
+			"GROUP=stable\nSERVER=https://public.update.flatcar-linux.net/v1/update/")
+		createFile(t, hostFilesPrefix, flatcar.UpdateConfOverridePath, "GROUP=beta\nREBOOT_STRATEGY=off")
+
+		updateConfig, err := flatcar.ReadUpdateConfig(hostFilesPrefix)
+		if err != nil {
+			t.Fatalf("Unexpected error reading update configuration: %v", err)
+		}
+
+		expected := &flatcar.UpdateConfig{
+			Group:          "beta",
+			Server:         "https://public.update.flatcar-linux.net/v1/update/",
+			RebootStrategy: flatcar.RebootStrategyOff,
+		}
+
+		if diff := cmp.Diff(expected, updateConfig); diff != "" {
+			t.Fatalf("Unexpected update configuration: %s", diff)
+		}
+	})
+
+	t.Run("returns_values_from_image_configuration_when_override_file_is_missing", func(t *testing.T) {
+		t.Parallel()
+
+		hostFilesPrefix := t.TempDir()
+
+		createFile(t, hostFilesPrefix, flatcar.UpdateConfPath, "GROUP=stable")
+
+		updateConfig, err := flatcar.ReadUpdateConfig(hostFilesPrefix)
+		if err != nil {
+			t.Fatalf("Unexpected error reading update configuration: %v", err)
+		}
+
+		if updateConfig.Group != "stable" {
+			t.Fatalf("Expected group %q, got %q", "stable", updateConfig.Group)
+		}
+	})
+
+	t.Run("returns_error_when_image_configuration_is_missing", func(t *testing.T) {
+		t.Parallel()
+
+		hostFilesPrefix := t.TempDir()
+
+		createFile(t, hostFilesPrefix, flatcar.UpdateConfOverridePath, "GROUP=beta")
+
+		if _, err := flatcar.ReadUpdateConfig(hostFilesPrefix); err == nil {
+			t.Fatalf("Expected error reading missing update configuration")
+		}
+	})
+}
+
+func createFile(t *testing.T, hostFilesPrefix, path, content string) {
+	t.Helper()
+
+	fullPath := filepath.Join(hostFilesPrefix, path)
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o700); err != nil {
+		t.Fatalf("Creating directory for %q: %v", fullPath, err)
+	}
+
+	if err := os.WriteFile(fullPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("Writing file %q: %v", fullPath, err)
+	}
+}
@@ -0,0 +1,63 @@
+package flatcar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// versionParts is a number of dot-separated parts of Flatcar version, e.g. 3510.2.1.
+const versionParts = 3
+
+// Version is a Flatcar version, which consists of major (build), minor (branch) and patch numbers.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseVersion parses Flatcar version in "major.minor.patch" format, e.g. "3510.2.1".
+func ParseVersion(version string) (Version, error) {
+	parts := strings.Split(version, ".")
+	if len(parts) != versionParts {
+		return Version{}, fmt.Errorf("invalid version %q, expected format 'major.minor.patch'", version)
+	}
+
+	numbers := make([]int, 0, versionParts)
+
+	for _, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return Version{}, fmt.Errorf("invalid version %q, part %q is not a non-negative number", version, part)
+		}
+
+		numbers = append(numbers, number)
+	}
+
+	return Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, nil
+}
+
+// String returns version in "major.minor.patch" format.
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1 when the version is lower than given version, 1 when it is higher and 0 when
+// versions are equal.
+func (v Version) Compare(other Version) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		switch {
+		case pair[0] < pair[1]:
+			return -1
+		case pair[0] > pair[1]:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// Less returns true when the version is lower than given version.
+func (v Version) Less(other Version) bool {
+	return v.Compare(other) < 0
+}
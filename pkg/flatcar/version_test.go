@@ -0,0 +1,91 @@
+package flatcar_test
+
+import (
+	"testing"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/flatcar"
+)
+
+func Test_Parsing_version(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns_version_numbers", func(t *testing.T) {
+		t.Parallel()
+
+		version, err := flatcar.ParseVersion("3510.2.1")
+		if err != nil {
+			t.Fatalf("Unexpected error parsing version: %v", err)
+		}
+
+		if expected := (flatcar.Version{Major: 3510, Minor: 2, Patch: 1}); version != expected {
+			t.Fatalf("Expected version %v, got %v", expected, version)
+		}
+
+		if version.String() != "3510.2.1" {
+			t.Fatalf("Expected version string %q, got %q", "3510.2.1", version.String())
+		}
+	})
+
+	t.Run("returns_error_when_version", func(t *testing.T) {
+		t.Parallel()
+
+		for n, version := range map[string]string{
+			"is_empty":                         "",
+			"has_too_few_parts":                "3510.2",
+			"has_too_many_parts":               "3510.2.1.0",
+			"has_non_numeric_part":             "3510.2.x",
+			"has_negative_part":                "3510.-2.1",
+			"has_build_metadata_in_patch_part": "3510.2.1+dev",
+		} {
+			version := version
+
+			t.Run(n, func(t *testing.T) {
+				t.Parallel()
+
+				if _, err := flatcar.ParseVersion(version); err == nil {
+					t.Fatalf("Expected error parsing version %q", version)
+				}
+			})
+		}
+	})
+}
+
+func Test_Comparing_versions(t *testing.T) {
+	t.Parallel()
+
+	for n, c := range map[string]struct {
+		a, b     string
+		expected int
+	}{
+		"returns_zero_for_equal_versions":         {a: "3510.2.1", b: "3510.2.1", expected: 0},
+		"returns_negative_for_lower_major":        {a: "3374.2.5", b: "3510.2.1", expected: -1},
+		"returns_negative_for_lower_minor":        {a: "3510.1.9", b: "3510.2.1", expected: -1},
+		"returns_negative_for_lower_patch":        {a: "3510.2.0", b: "3510.2.1", expected: -1},
+		"returns_positive_for_higher_version":     {a: "3602.0.0", b: "3510.2.1", expected: 1},
+		"compares_numbers_not_strings_of_numbers": {a: "3510.10.0", b: "3510.9.0", expected: 1},
+	} {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			t.Parallel()
+
+			a, err := flatcar.ParseVersion(c.a)
+			if err != nil {
+				t.Fatalf("Parsing version %q: %v", c.a, err)
+			}
+
+			b, err := flatcar.ParseVersion(c.b)
+			if err != nil {
+				t.Fatalf("Parsing version %q: %v", c.b, err)
+			}
+
+			if result := a.Compare(b); result != c.expected {
+				t.Fatalf("Expected comparing %q with %q to return %d, got %d", c.a, c.b, c.expected, result)
+			}
+
+			if a.Less(b) != (c.expected < 0) {
+				t.Fatalf("Expected %q less than %q to be %t", c.a, c.b, c.expected < 0)
+			}
+		})
+	}
+}
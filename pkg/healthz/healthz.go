@@ -0,0 +1,55 @@
+// Package healthz provides HTTP server for liveness and readiness probes.
+package healthz
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// LivenessPath is a path on which liveness check is served.
+	LivenessPath = "/healthz"
+	// ReadinessPath is a path on which readiness check is served.
+	ReadinessPath = "/readyz"
+
+	readHeaderTimeout = 10 * time.Second
+)
+
+// Check returns an error when checked component is not healthy.
+type Check func() error
+
+// NewServer creates HTTP server serving given liveness and readiness checks on a given address.
+//
+// Nil checks always pass.
+func NewServer(address string, liveness, readiness Check) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle(LivenessPath, Handler(liveness))
+	mux.Handle(ReadinessPath, Handler(readiness))
+
+	return &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+}
+
+// Handler returns HTTP handler responding with status 200 when given check passes and with
+// status 503 with an error message otherwise.
+func Handler(check Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if check != nil {
+			if err := check(); err != nil {
+				klog.V(4).Infof("Check on %q failed: %v", r.URL.Path, err)
+
+				http.Error(w, fmt.Sprintf("check failed: %v", err), http.StatusServiceUnavailable)
+
+				return
+			}
+		}
+
+		fmt.Fprint(w, "ok")
+	})
+}
@@ -0,0 +1,85 @@
+package healthz_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/healthz"
+)
+
+func Test_Handler_responds_with(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		check              healthz.Check
+		expectedStatusCode int
+	}{
+		"status_OK_when_check_passes": {
+			check:              func() error { return nil },
+			expectedStatusCode: http.StatusOK,
+		},
+		"status_OK_when_no_check_is_given": {
+			expectedStatusCode: http.StatusOK,
+		},
+		"status_service_unavailable_when_check_fails": {
+			check:              func() error { return fmt.Errorf("not ready") },
+			expectedStatusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for name, testCase := range cases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := httptest.NewRecorder()
+
+			healthz.Handler(testCase.check).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if recorder.Code != testCase.expectedStatusCode {
+				t.Fatalf("Expected status code %d, got %d", testCase.expectedStatusCode, recorder.Code)
+			}
+		})
+	}
+}
+
+func Test_Server_serves(t *testing.T) {
+	t.Parallel()
+
+	notReady := func() error { return fmt.Errorf("not ready") }
+
+	handler := healthz.NewServer("", nil, notReady).Handler
+
+	cases := map[string]struct {
+		path               string
+		expectedStatusCode int
+	}{
+		"liveness_check": {
+			path:               healthz.LivenessPath,
+			expectedStatusCode: http.StatusOK,
+		},
+		"readiness_check": {
+			path:               healthz.ReadinessPath,
+			expectedStatusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for name, testCase := range cases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, testCase.path, nil))
+
+			if recorder.Code != testCase.expectedStatusCode {
+				t.Fatalf("Expected status code %d, got %d", testCase.expectedStatusCode, recorder.Code)
+			}
+		})
+	}
+}
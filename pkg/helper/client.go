@@ -0,0 +1,213 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/updateengine"
+)
+
+const (
+	// baseURL is used for requests to the helper. Host part is ignored, as requests are sent over Unix socket.
+	baseURL = "http://helper"
+
+	defaultRetryInterval = 5 * time.Second
+	requestTimeout       = 30 * time.Second
+)
+
+// Client communicates with the helper over Unix socket. It can be used by the agent as a status receiver
+// and rebooter in place of direct D-Bus connections.
+type Client struct {
+	httpClient    *http.Client
+	retryInterval time.Duration
+
+	lock      sync.Mutex
+	streamErr error
+}
+
+// NewClient creates new client of the helper listening on a given Unix socket path.
+func NewClient(socketPath string) *Client {
+	dialer := &net.Dialer{}
+
+	return &Client{
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+		retryInterval: defaultRetryInterval,
+	}
+}
+
+// ReceiveStatuses streams update_engine statuses from the helper into a given channel until the stop
+// channel is closed. When the stream breaks, e.g. because the helper is restarted, it is re-established
+// after a short interval.
+func (c *Client) ReceiveStatuses(rcvr chan<- updateengine.Status, stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		err := c.streamStatuses(ctx, rcvr)
+		if ctx.Err() != nil {
+			return
+		}
+
+		klog.Errorf("Failed receiving statuses from helper, retrying in %v: %v", c.retryInterval, err)
+
+		c.setStreamErr(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.retryInterval):
+		}
+	}
+}
+
+// Healthz returns an error when statuses can't be received from the helper or when the helper
+// reports itself as not healthy.
+func (c *Client) Healthz() error {
+	c.lock.Lock()
+	streamErr := c.streamErr
+	c.lock.Unlock()
+
+	if streamErr != nil {
+		return fmt.Errorf("receiving statuses: %w", streamErr)
+	}
+
+	return c.do(http.MethodGet, HealthzPath)
+}
+
+// AttemptUpdate asks update_engine to check for an update immediately through the helper.
+func (c *Client) AttemptUpdate() error {
+	return c.do(http.MethodPost, AttemptUpdatePath)
+}
+
+// ResetStatus asks update_engine to reset its status back to idle through the helper.
+func (c *Client) ResetStatus() error {
+	return c.do(http.MethodPost, ResetStatusPath)
+}
+
+// AttemptRollback asks update_engine to roll back to the previously booted partition through the helper.
+func (c *Client) AttemptRollback() error {
+	return c.do(http.MethodPost, AttemptRollbackPath)
+}
+
+// Reboot requests rebooting the host through the helper. The helper responds once logind accepts
+// the reboot request, so an error means the reboot has not been requested.
+func (c *Client) Reboot(auth bool) error {
+	path := RebootPath
+	if auth {
+		path += "?auth=true"
+	}
+
+	if err := c.do(http.MethodPost, path); err != nil {
+		return fmt.Errorf("requesting reboot from helper: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) setStreamErr(err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.streamErr = err
+}
+
+// streamStatuses sends statuses received from the helper on the rcvr channel until the stream breaks
+// or given context is cancelled.
+func (c *Client) streamStatuses(ctx context.Context, rcvr chan<- updateengine.Status) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+StatusesPath, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+
+	defer closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	c.setStreamErr(nil)
+
+	decoder := json.NewDecoder(resp.Body)
+
+	for {
+		var status updateengine.Status
+
+		if err := decoder.Decode(&status); err != nil {
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("stream closed by helper")
+			}
+
+			return fmt.Errorf("decoding status: %w", err)
+		}
+
+		select {
+		case rcvr <- status:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// do sends request with given method to given path, expecting successful response.
+func (c *Client) do(method, path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+
+	defer closeBody(resp.Body)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+func closeBody(body io.Closer) {
+	if err := body.Close(); err != nil {
+		klog.Warningf("Failed closing helper response body: %v", err)
+	}
+}
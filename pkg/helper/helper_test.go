@@ -0,0 +1,302 @@
+package helper_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/helper"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/updateengine"
+)
+
+const testTimeout = 5 * time.Second
+
+//nolint:funlen // Just many subtests.
+func Test_Client(t *testing.T) {
+	t.Parallel()
+
+	t.Run("receives_statuses_from_update_engine_through_helper", func(t *testing.T) {
+		t.Parallel()
+
+		ue := newMockUpdateEngine()
+		client := runHelper(t, ue, &mockRebooter{})
+
+		rcvr := make(chan updateengine.Status)
+		stop := make(chan struct{})
+
+		t.Cleanup(func() { close(stop) })
+
+		go client.ReceiveStatuses(rcvr, stop)
+
+		expectedStatus := updateengine.Status{
+			CurrentOperation: updateengine.UpdateStatusUpdatedNeedReboot,
+			NewVersion:       "1.2.3",
+		}
+
+		// Retry sending, as client may not be subscribed yet.
+		for {
+			select {
+			case ue.statuses <- expectedStatus:
+				continue
+			case status := <-rcvr:
+				if status != expectedStatus {
+					t.Fatalf("Expected status %v, got %v", expectedStatus, status)
+				}
+
+				return
+			case <-time.After(testTimeout):
+				t.Fatalf("Timed out waiting for status")
+			}
+		}
+	})
+
+	t.Run("forwards_reboot_request_with_authorization_flag", func(t *testing.T) {
+		t.Parallel()
+
+		rebooter := &mockRebooter{requests: make(chan bool, 1)}
+		client := runHelper(t, newMockUpdateEngine(), rebooter)
+
+		if err := client.Reboot(true); err != nil {
+			t.Fatalf("Unexpected error requesting reboot: %v", err)
+		}
+
+		select {
+		case auth := <-rebooter.requests:
+			if !auth {
+				t.Fatalf("Expected reboot to be requested with authorization")
+			}
+		case <-time.After(testTimeout):
+			t.Fatalf("Timed out waiting for reboot request")
+		}
+	})
+
+	t.Run("returns_error_when_rebooting_fails", func(t *testing.T) {
+		t.Parallel()
+
+		client := runHelper(t, newMockUpdateEngine(), &mockRebooter{rebootErr: fmt.Errorf("logind not available")})
+
+		if err := client.Reboot(false); err == nil {
+			t.Fatalf("Expected error requesting reboot")
+		}
+	})
+
+	t.Run("returns_error_when_attempting_update_fails", func(t *testing.T) {
+		t.Parallel()
+
+		ue := newMockUpdateEngine()
+		ue.attemptUpdateErr = fmt.Errorf("update_engine is busy")
+
+		client := runHelper(t, ue, &mockRebooter{})
+
+		if err := client.AttemptUpdate(); err == nil {
+			t.Fatalf("Expected error attempting update")
+		}
+	})
+
+	t.Run("resets_update_engine_status", func(t *testing.T) {
+		t.Parallel()
+
+		ue := newMockUpdateEngine()
+		client := runHelper(t, ue, &mockRebooter{})
+
+		if err := client.ResetStatus(); err != nil {
+			t.Fatalf("Unexpected error resetting status: %v", err)
+		}
+
+		if ue.resets != 1 {
+			t.Fatalf("Expected status to be reset once, got %d", ue.resets)
+		}
+	})
+
+	t.Run("returns_error_when_resetting_status_fails", func(t *testing.T) {
+		t.Parallel()
+
+		ue := newMockUpdateEngine()
+		ue.resetStatusErr = fmt.Errorf("access denied")
+
+		client := runHelper(t, ue, &mockRebooter{})
+
+		if err := client.ResetStatus(); err == nil {
+			t.Fatalf("Expected error resetting status")
+		}
+	})
+
+	t.Run("returns_error_when_attempting_rollback_fails", func(t *testing.T) {
+		t.Parallel()
+
+		ue := newMockUpdateEngine()
+		ue.rollbackErr = fmt.Errorf("no partition to roll back to")
+
+		client := runHelper(t, ue, &mockRebooter{})
+
+		if err := client.AttemptRollback(); err == nil {
+			t.Fatalf("Expected error attempting rollback")
+		}
+	})
+
+	t.Run("reports_update_engine_health", func(t *testing.T) {
+		t.Parallel()
+
+		ue := newMockUpdateEngine()
+		ue.healthzErr = fmt.Errorf("connection closed")
+
+		client := runHelper(t, ue, &mockRebooter{})
+
+		if err := client.Healthz(); err == nil {
+			t.Fatalf("Expected health check to fail")
+		}
+	})
+
+	t.Run("reports_itself_as_not_healthy_when_helper_is_not_reachable", func(t *testing.T) {
+		t.Parallel()
+
+		client := helper.NewClient(filepath.Join(t.TempDir(), "missing.sock"))
+
+		if err := client.Healthz(); err == nil {
+			t.Fatalf("Expected health check to fail")
+		}
+	})
+}
+
+func Test_Listen_replaces_stale_socket_and_sets_permissions(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "helper.sock")
+
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatalf("Creating stale socket: %v", err)
+	}
+
+	listener, err := helper.Listen(path, 0o660)
+	if err != nil {
+		t.Fatalf("Unexpected error listening: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := listener.Close(); err != nil {
+			t.Logf("Failed closing listener: %v", err)
+		}
+	})
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Checking socket: %v", err)
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		t.Fatalf("Expected %q to be a socket", path)
+	}
+
+	if perm := info.Mode().Perm(); perm != 0o660 {
+		t.Fatalf("Expected socket permissions %o, got %o", 0o660, perm)
+	}
+}
+
+// runHelper runs helper server with given dependencies and returns client connected to it.
+func runHelper(t *testing.T, ue *mockUpdateEngine, rebooter *mockRebooter) *helper.Client {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "helper")
+	if err != nil {
+		t.Fatalf("Creating temporary directory: %v", err)
+	}
+
+	path := filepath.Join(dir, "helper.sock")
+
+	listener, err := helper.Listen(path, 0o600)
+	if err != nil {
+		t.Fatalf("Listening: %v", err)
+	}
+
+	server := helper.NewServer(ue, rebooter)
+	stop := make(chan struct{})
+
+	go server.Run(stop)
+
+	httpServer := &http.Server{Handler: server.Handler(), ReadHeaderTimeout: testTimeout}
+
+	go func() {
+		if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			t.Logf("Serving helper: %v", err)
+		}
+	}()
+
+	t.Cleanup(func() {
+		close(stop)
+
+		if err := httpServer.Close(); err != nil {
+			t.Logf("Failed closing server: %v", err)
+		}
+
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("Failed removing temporary directory: %v", err)
+		}
+	})
+
+	return helper.NewClient(path)
+}
+
+type mockUpdateEngine struct {
+	statuses         chan updateengine.Status
+	healthzErr       error
+	attemptUpdateErr error
+	resetStatusErr   error
+	resets           int
+	rollbackErr      error
+}
+
+func newMockUpdateEngine() *mockUpdateEngine {
+	return &mockUpdateEngine{
+		statuses: make(chan updateengine.Status),
+	}
+}
+
+func (m *mockUpdateEngine) ReceiveStatuses(rcvr chan<- updateengine.Status, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case status := <-m.statuses:
+			select {
+			case rcvr <- status:
+			case <-stop:
+				return
+			}
+		}
+	}
+}
+
+func (m *mockUpdateEngine) Healthz() error {
+	return m.healthzErr
+}
+
+func (m *mockUpdateEngine) AttemptUpdate() error {
+	return m.attemptUpdateErr
+}
+
+func (m *mockUpdateEngine) AttemptRollback() error {
+	return m.rollbackErr
+}
+
+func (m *mockUpdateEngine) ResetStatus() error {
+	m.resets++
+
+	return m.resetStatusErr
+}
+
+type mockRebooter struct {
+	requests  chan bool
+	rebootErr error
+}
+
+func (m *mockRebooter) Reboot(auth bool) error {
+	if m.requests != nil {
+		m.requests <- auth
+	}
+
+	return m.rebootErr
+}
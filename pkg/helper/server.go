@@ -0,0 +1,278 @@
+// Package helper provides a privileged helper, which owns D-Bus connections to update_engine and logind
+// on the host and exposes them over a Unix socket, so the update-agent can run without access to host
+// D-Bus and without root privileges.
+package helper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/healthz"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/updateengine"
+)
+
+const (
+	// StatusesPath is a path on which update_engine statuses are streamed as newline-delimited JSON.
+	StatusesPath = "/statuses"
+	// RebootPath is a path on which reboot of the host can be requested.
+	RebootPath = "/reboot"
+	// AttemptUpdatePath is a path on which update check can be requested.
+	AttemptUpdatePath = "/attempt-update"
+	// ResetStatusPath is a path on which resetting update_engine status can be requested.
+	ResetStatusPath = "/reset-status"
+	// AttemptRollbackPath is a path on which rollback to the previously booted partition can be requested.
+	AttemptRollbackPath = "/attempt-rollback"
+	// HealthzPath is a path on which health of the helper is served.
+	HealthzPath = "/healthz"
+
+	// DefaultSocketPath is a default path of the helper Unix socket.
+	DefaultSocketPath = "/run/update-agent/helper.sock"
+
+	// subscriberBuffer is a number of statuses buffered for each client before statuses start to be dropped.
+	subscriberBuffer = 32
+)
+
+// UpdateEngine describes update_engine capabilities exposed by the helper.
+type UpdateEngine interface {
+	ReceiveStatuses(rcvr chan<- updateengine.Status, stop <-chan struct{})
+	Healthz() error
+	AttemptUpdate() error
+	ResetStatus() error
+	AttemptRollback() error
+}
+
+// Rebooter describes dependency of object providing capability of rebooting host machine.
+type Rebooter interface {
+	Reboot(bool) error
+}
+
+// Server exposes update_engine and logind capabilities to helper clients.
+type Server struct {
+	ue       UpdateEngine
+	rebooter Rebooter
+
+	lock        sync.Mutex
+	lastStatus  *updateengine.Status
+	subscribers map[chan updateengine.Status]struct{}
+}
+
+// NewServer creates new helper server using given update_engine client and rebooter.
+func NewServer(ue UpdateEngine, rebooter Rebooter) *Server {
+	return &Server{
+		ue:          ue,
+		rebooter:    rebooter,
+		subscribers: map[chan updateengine.Status]struct{}{},
+	}
+}
+
+// Run receives statuses from update_engine and forwards them to connected clients until the stop
+// channel is closed.
+func (s *Server) Run(stop <-chan struct{}) {
+	statuses := make(chan updateengine.Status)
+
+	go s.ue.ReceiveStatuses(statuses, stop)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case status := <-statuses:
+			s.broadcast(status)
+		}
+	}
+}
+
+// Handler returns HTTP handler serving helper API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(StatusesPath, s.serveStatuses)
+	mux.HandleFunc(RebootPath, s.serveReboot)
+	mux.HandleFunc(AttemptUpdatePath, s.serveAttemptUpdate)
+	mux.HandleFunc(ResetStatusPath, s.serveResetStatus)
+	mux.HandleFunc(AttemptRollbackPath, s.serveAttemptRollback)
+	mux.Handle(HealthzPath, healthz.Handler(s.ue.Healthz))
+
+	return mux
+}
+
+// Listen creates Unix socket on a given path with given permissions. Stale socket left by the previous
+// helper instance is removed.
+func Listen(path string, mode os.FileMode) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("removing stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listening: %w", err)
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		if closeErr := listener.Close(); closeErr != nil {
+			klog.Warningf("Failed closing listener: %v", closeErr)
+		}
+
+		return nil, fmt.Errorf("setting socket permissions: %w", err)
+	}
+
+	return listener, nil
+}
+
+// broadcast sends given status to all subscribers. Subscribers which are not keeping up miss the status.
+func (s *Server) broadcast(status updateengine.Status) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.lastStatus = &status
+
+	for subscriber := range s.subscribers {
+		select {
+		case subscriber <- status:
+		default:
+			klog.Warningf("Dropping status for slow client: %s", status.String())
+		}
+	}
+}
+
+// subscribe registers new subscriber of statuses. Last known status, if any, is sent to the subscriber
+// immediately, so clients do not have to wait for update_engine to change its status.
+func (s *Server) subscribe() chan updateengine.Status {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	subscriber := make(chan updateengine.Status, subscriberBuffer)
+
+	if s.lastStatus != nil {
+		subscriber <- *s.lastStatus
+	}
+
+	s.subscribers[subscriber] = struct{}{}
+
+	return subscriber
+}
+
+func (s *Server) unsubscribe(subscriber chan updateengine.Status) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.subscribers, subscriber)
+}
+
+func (s *Server) serveStatuses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+
+		return
+	}
+
+	subscriber := s.subscribe()
+	defer s.unsubscribe(subscriber)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	encoder := json.NewEncoder(w)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case status := <-subscriber:
+			if err := encoder.Encode(status); err != nil {
+				klog.Warningf("Failed sending status to client: %v", err)
+
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) serveReboot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	klog.Info("Rebooting host on client request")
+
+	if err := s.rebooter.Reboot(r.URL.Query().Get("auth") == "true"); err != nil {
+		klog.Errorf("Failed rebooting host: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) serveAttemptUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	klog.Info("Attempting update on client request")
+
+	if err := s.ue.AttemptUpdate(); err != nil {
+		http.Error(w, fmt.Sprintf("attempting update: %v", err), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) serveResetStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	klog.Info("Resetting update_engine status on client request")
+
+	if err := s.ue.ResetStatus(); err != nil {
+		http.Error(w, fmt.Sprintf("resetting status: %v", err), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) serveAttemptRollback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	klog.Info("Attempting rollback on client request")
+
+	if err := s.ue.AttemptRollback(); err != nil {
+		http.Error(w, fmt.Sprintf("attempting rollback: %v", err), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,137 @@
+// Package k8stest provides helpers for testing code talking to Kubernetes API using fake clientset,
+// like injecting errors into requests to exercise retry logic.
+package k8stest
+
+import (
+	"fmt"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const (
+	// AnyResource matches all resources in Fault.
+	AnyResource = "*"
+
+	nodesResource = "nodes"
+
+	throttlingRetryAfterSeconds = 1
+)
+
+// Fault describes requests which should fail with injected error.
+type Fault struct {
+	// Verbs of failing requests, e.g. "update" or "patch". Empty matches all verbs.
+	Verbs []string
+	// Resource of failing requests, e.g. "nodes". AnyResource matches all resources.
+	Resource string
+	// Error returned for failing requests.
+	Err error
+	// Number of matching requests which fail before requests start succeeding again. Negative value makes all
+	// matching requests fail.
+	Times int
+}
+
+// Injected reports requests which failed because of injected fault.
+type Injected struct {
+	lock   sync.Mutex
+	failed int
+	calls  int
+}
+
+// Failed returns number of requests which failed because of injected fault.
+func (i *Injected) Failed() int {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	return i.failed
+}
+
+// Calls returns number of requests matching injected fault, including the ones which succeeded.
+func (i *Injected) Calls() int {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	return i.calls
+}
+
+// react fails request if fault has not been injected given number of times yet.
+func (i *Injected) react(fault Fault) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if !fault.matchesVerb(action.GetVerb()) {
+			return false, nil, nil
+		}
+
+		i.lock.Lock()
+		defer i.lock.Unlock()
+
+		i.calls++
+
+		if fault.Times >= 0 && i.failed >= fault.Times {
+			return false, nil, nil
+		}
+
+		i.failed++
+
+		return true, nil, fault.Err
+	}
+}
+
+func (f Fault) matchesVerb(verb string) bool {
+	if len(f.Verbs) == 0 {
+		return true
+	}
+
+	for _, v := range f.Verbs {
+		if v == verb {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Inject makes requests handled by given fake client fail as described by given fault. Fault takes
+// precedence over previously added reactors.
+func Inject(fakeClient *k8stesting.Fake, fault Fault) *Injected {
+	injected := &Injected{}
+
+	fakeClient.PrependReactor("*", fault.Resource, injected.react(fault))
+
+	return injected
+}
+
+// Clientset is a fake clientset with helpers for injecting errors into node updates.
+type Clientset struct {
+	*fake.Clientset
+}
+
+// NewClientset returns fake clientset populated with given objects.
+func NewClientset(objects ...runtime.Object) *Clientset {
+	return &Clientset{Clientset: fake.NewSimpleClientset(objects...)}
+}
+
+// FailNodeUpdates makes given number of node updates and patches fail with given error. Negative number makes
+// all of them fail.
+func (c *Clientset) FailNodeUpdates(times int, err error) *Injected {
+	return Inject(&c.Fake, Fault{Verbs: []string{"update", "patch"}, Resource: nodesResource, Err: err, Times: times})
+}
+
+// ConflictError returns error returned by API server when object has been modified since it was read.
+func ConflictError(resource, name string) error {
+	return apierrors.NewConflict(schema.GroupResource{Resource: resource}, name,
+		fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
+}
+
+// ThrottlingError returns error returned by API server when client sends too many requests.
+func ThrottlingError() error {
+	return apierrors.NewTooManyRequests("too many requests, please try again later", throttlingRetryAfterSeconds)
+}
+
+// TransientError returns error returned by API server when it is temporarily unable to handle requests.
+func TransientError() error {
+	return apierrors.NewServiceUnavailable("the server is currently unable to handle the request")
+}
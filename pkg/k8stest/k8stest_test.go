@@ -0,0 +1,50 @@
+package k8stest_test
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8stest"
+)
+
+func Test_Injected_fault_fails_given_number_of_matching_requests(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "testNodeName"}}
+
+	client := k8stest.NewClientset(node)
+
+	injected := k8stest.Inject(&client.Fake, k8stest.Fault{
+		Verbs:    []string{"update"},
+		Resource: "nodes",
+		Err:      k8stest.ConflictError("nodes", node.Name),
+		Times:    1,
+	})
+
+	nc := client.CoreV1().Nodes()
+
+	if _, err := nc.Get(ctx, node.Name, metav1.GetOptions{}); err != nil {
+		t.Fatalf("Expected not matching request to succeed, got: %v", err)
+	}
+
+	if _, err := nc.Update(ctx, node, metav1.UpdateOptions{}); !apierrors.IsConflict(err) {
+		t.Fatalf("Expected first update to fail with conflict, got: %v", err)
+	}
+
+	if _, err := nc.Update(ctx, node, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Expected second update to succeed, got: %v", err)
+	}
+
+	if failed := injected.Failed(); failed != 1 {
+		t.Fatalf("Expected 1 failed request, got %d", failed)
+	}
+
+	if calls := injected.Calls(); calls != 2 {
+		t.Fatalf("Expected 2 matching requests, got %d", calls)
+	}
+}
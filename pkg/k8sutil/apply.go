@@ -0,0 +1,61 @@
+package k8sutil
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// FieldManagerPrefix prefixes names of field managers used by FLUO components when applying node
+// labels and annotations using server-side apply, so FLUO's ownership of the fields is visible in
+// node's managed fields, e.g. "flatcar-linux-update-operator/agent/info-labels".
+const FieldManagerPrefix = "flatcar-linux-update-operator/"
+
+// NodeApplier is a subset of corev1client.NodeInterface used by this package for applying node
+// configuration using server-side apply.
+type NodeApplier interface {
+	Apply(ctx context.Context, node *applycorev1.NodeApplyConfiguration, opts metav1.ApplyOptions) (*corev1.Node, error)
+}
+
+// ApplyNodeMetadata sets given annotations and labels on a node using server-side apply with given
+// field manager, retrying up to DefaultBackoff number of times if it fails. Ownership of the fields
+// is forced, so applying never fails on conflicts with other field managers.
+//
+// Annotations and labels applied previously using the same field manager, which are not given anymore,
+// are removed from the node. Each set of fields should therefore use its own field manager, which always
+// applies the same keys.
+func ApplyNodeMetadata(
+	ctx context.Context, nodeApplier NodeApplier, nodeName, fieldManager string, annotations, labels map[string]string,
+) error {
+	node := applycorev1.Node(nodeName)
+
+	if len(annotations) > 0 {
+		node.WithAnnotations(annotations)
+	}
+
+	if len(labels) > 0 {
+		node.WithLabels(labels)
+	}
+
+	opts := metav1.ApplyOptions{
+		FieldManager: fieldManager,
+		Force:        true,
+	}
+
+	err := retry.OnError(retry.DefaultBackoff, func(error) bool { return true }, func() error {
+		if _, err := nodeApplier.Apply(ctx, node, opts); err != nil {
+			return fmt.Errorf("applying node %q: %w", nodeName, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("applying node metadata: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package k8sutil_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+//nolint:funlen // Just subtests.
+func Test_Applying_node_metadata(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sets_given_annotations_and_labels_preserving_other_ones", func(t *testing.T) {
+		t.Parallel()
+
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "testNodeName",
+				Annotations: map[string]string{"foo": "bar"},
+				Labels:      map[string]string{"baz": "doh"},
+			},
+		}
+
+		fakeClient := fake.NewSimpleClientset(node)
+		nc := fakeClient.CoreV1().Nodes()
+		ctx := context.TODO()
+
+		err := k8sutil.ApplyNodeMetadata(ctx, nc, node.Name, "test-manager",
+			map[string]string{"annotation": "value"}, map[string]string{"label": "value"})
+		if err != nil {
+			t.Fatalf("Unexpected error applying node metadata: %v", err)
+		}
+
+		updatedNode, err := nc.Get(ctx, node.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Getting node: %v", err)
+		}
+
+		for key, expected := range map[string]string{"foo": "bar", "annotation": "value"} {
+			if v := updatedNode.Annotations[key]; v != expected {
+				t.Errorf("Expected annotation %q to be %q, got %q", key, expected, v)
+			}
+		}
+
+		for key, expected := range map[string]string{"baz": "doh", "label": "value"} {
+			if v := updatedNode.Labels[key]; v != expected {
+				t.Errorf("Expected label %q to be %q, got %q", key, expected, v)
+			}
+		}
+	})
+
+	t.Run("uses_server_side_apply", func(t *testing.T) {
+		t.Parallel()
+
+		fakeClient := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "testNodeName"}})
+
+		patchTypes := make(chan types.PatchType, 1)
+
+		fakeClient.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if patchAction, ok := action.(k8stesting.PatchAction); ok {
+				patchTypes <- patchAction.GetPatchType()
+			}
+
+			return false, nil, nil
+		})
+
+		err := k8sutil.ApplyNodeMetadata(context.TODO(), fakeClient.CoreV1().Nodes(), "testNodeName",
+			k8sutil.FieldManagerPrefix+"test", nil, map[string]string{"label": "value"})
+		if err != nil {
+			t.Fatalf("Unexpected error applying node metadata: %v", err)
+		}
+
+		if patchType := <-patchTypes; patchType != types.ApplyPatchType {
+			t.Fatalf("Expected patch type %q, got %q", types.ApplyPatchType, patchType)
+		}
+	})
+
+	t.Run("retries_on_error", func(t *testing.T) {
+		t.Parallel()
+
+		fakeClient := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "testNodeName"}})
+
+		failed := false
+
+		fakeClient.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if failed {
+				return false, nil, nil
+			}
+
+			failed = true
+
+			return true, nil, errors.NewInternalError(fmt.Errorf("test error"))
+		})
+
+		err := k8sutil.ApplyNodeMetadata(context.TODO(), fakeClient.CoreV1().Nodes(), "testNodeName", "test-manager",
+			nil, map[string]string{"label": "value"})
+		if err != nil {
+			t.Fatalf("Unexpected error applying node metadata: %v", err)
+		}
+
+		if !failed {
+			t.Fatalf("Expected apply to be retried")
+		}
+	})
+
+	t.Run("returns_error_when_node_does_not_exist", func(t *testing.T) {
+		t.Parallel()
+
+		fakeClient := fake.NewSimpleClientset()
+
+		fakeClient.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.NewNotFound(schema.GroupResource{Resource: "nodes"}, "testNodeName")
+		})
+
+		err := k8sutil.ApplyNodeMetadata(context.TODO(), fakeClient.CoreV1().Nodes(), "testNodeName", "test-manager",
+			nil, map[string]string{"label": "value"})
+		if err == nil {
+			t.Fatalf("Expected error applying metadata to non-existing node")
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package k8sutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	defaultBackoffInitialDelay = 10 * time.Millisecond
+	defaultBackoffFactor       = 5.0
+	defaultBackoffJitter       = 0.1
+	defaultBackoffMaxRetries   = 3
+)
+
+// Backoff configures how failed node updates are retried.
+type Backoff struct {
+	// Delay before the first retry.
+	InitialDelay time.Duration
+	// Delay is multiplied by factor after each retry. Must not be lower than 1.
+	Factor float64
+	// Each delay is extended by a random duration up to jitter multiplied by the delay.
+	Jitter float64
+	// Number of retries before giving up. Zero disables retrying.
+	MaxRetries int
+}
+
+// DefaultBackoff returns retry configuration used when none is given, which matches
+// retry.DefaultBackoff from client-go.
+func DefaultBackoff() Backoff {
+	return Backoff{
+		InitialDelay: defaultBackoffInitialDelay,
+		Factor:       defaultBackoffFactor,
+		Jitter:       defaultBackoffJitter,
+		MaxRetries:   defaultBackoffMaxRetries,
+	}
+}
+
+// Validate returns error if backoff configuration is invalid.
+func (b Backoff) Validate() error {
+	switch {
+	case b.InitialDelay < 0:
+		return fmt.Errorf("initial delay must not be negative, got %v", b.InitialDelay)
+	case b.Factor < 1:
+		return fmt.Errorf("factor must be at least 1, got %v", b.Factor)
+	case b.Jitter < 0:
+		return fmt.Errorf("jitter must not be negative, got %v", b.Jitter)
+	case b.MaxRetries < 0:
+		return fmt.Errorf("max retries must not be negative, got %d", b.MaxRetries)
+	}
+
+	return nil
+}
+
+func (b Backoff) waitBackoff() wait.Backoff {
+	return wait.Backoff{
+		Duration: b.InitialDelay,
+		Factor:   b.Factor,
+		Jitter:   b.Jitter,
+		Steps:    b.MaxRetries + 1,
+	}
+}
+
+// retryOnError calls f until it succeeds, returns an error which is not retriable, backoff runs out of retries
+// or given context gets cancelled. Last error returned by f is returned.
+func retryOnError(ctx context.Context, backoff Backoff, retriable func(error) bool, f func() error) error {
+	var lastErr error
+
+	err := wait.ExponentialBackoffWithContext(ctx, backoff.waitBackoff(), func(context.Context) (bool, error) {
+		err := f()
+
+		switch {
+		case err == nil:
+			return true, nil
+		case retriable(err):
+			lastErr = err
+
+			return false, nil
+		default:
+			return false, err
+		}
+	})
+
+	switch {
+	case errors.Is(err, wait.ErrWaitTimeout) && lastErr != nil:
+		return lastErr
+	case err != nil && lastErr != nil && ctx.Err() != nil:
+		return fmt.Errorf("%v: %w", lastErr, ctx.Err())
+	}
+
+	return err
+}
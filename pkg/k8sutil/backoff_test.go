@@ -0,0 +1,133 @@
+package k8sutil_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+func Test_Validating_backoff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("accepts_default_backoff", func(t *testing.T) {
+		t.Parallel()
+
+		if err := k8sutil.DefaultBackoff().Validate(); err != nil {
+			t.Fatalf("Unexpected error validating default backoff: %v", err)
+		}
+	})
+
+	t.Run("rejects_backoff_with", func(t *testing.T) {
+		t.Parallel()
+
+		for name, mutateF := range map[string]func(*k8sutil.Backoff){
+			"negative_initial_delay": func(b *k8sutil.Backoff) { b.InitialDelay = -time.Second },
+			"factor_lower_than_one":  func(b *k8sutil.Backoff) { b.Factor = 0.5 },
+			"negative_jitter":        func(b *k8sutil.Backoff) { b.Jitter = -1 },
+			"negative_max_retries":   func(b *k8sutil.Backoff) { b.MaxRetries = -1 },
+		} {
+			mutateF := mutateF
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				backoff := k8sutil.DefaultBackoff()
+				mutateF(&backoff)
+
+				if err := backoff.Validate(); err == nil {
+					t.Fatalf("Expected error validating backoff %+v", backoff)
+				}
+			})
+		}
+	})
+}
+
+//nolint:funlen // Just subtests.
+func Test_Updating_node_with_backoff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("retries_conflicts_configured_number_of_times", func(t *testing.T) {
+		t.Parallel()
+
+		fakeClient := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "testNodeName"}})
+
+		updateCalls := 0
+
+		fakeClient.PrependReactor("update", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updateCalls++
+
+			return true, nil, apierrors.NewConflict(schema.GroupResource{}, "testNodeName", fmt.Errorf("test error"))
+		})
+
+		backoff := k8sutil.Backoff{InitialDelay: time.Millisecond, Factor: 1, MaxRetries: 5}
+
+		err := k8sutil.UpdateNodeRetryWithBackoff(context.TODO(), fakeClient.CoreV1().Nodes(), "testNodeName",
+			backoff, func(*corev1.Node) {})
+		if !apierrors.IsConflict(err) {
+			t.Fatalf("Expected conflict error, got: %v", err)
+		}
+
+		if expectedCalls := backoff.MaxRetries + 1; updateCalls != expectedCalls {
+			t.Fatalf("Expected %d update calls, got %d", expectedCalls, updateCalls)
+		}
+	})
+
+	t.Run("does_not_retry_when_max_retries_is_zero", func(t *testing.T) {
+		t.Parallel()
+
+		fakeClient := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "testNodeName"}})
+
+		updateCalls := 0
+
+		fakeClient.PrependReactor("update", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updateCalls++
+
+			return true, nil, apierrors.NewConflict(schema.GroupResource{}, "testNodeName", fmt.Errorf("test error"))
+		})
+
+		err := k8sutil.UpdateNodeRetryWithBackoff(context.TODO(), fakeClient.CoreV1().Nodes(), "testNodeName",
+			k8sutil.Backoff{Factor: 1}, func(*corev1.Node) {})
+		if err == nil {
+			t.Fatalf("Expected error updating node")
+		}
+
+		if updateCalls != 1 {
+			t.Fatalf("Expected exactly one update call, got %d", updateCalls)
+		}
+	})
+
+	t.Run("stops_retrying_when_context_is_cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		fakeClient := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "testNodeName"}})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+
+		fakeClient.PrependReactor("update", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			cancel()
+
+			return true, nil, apierrors.NewConflict(schema.GroupResource{}, "testNodeName", fmt.Errorf("test error"))
+		})
+
+		backoff := k8sutil.Backoff{InitialDelay: time.Hour, Factor: 1, MaxRetries: 1}
+
+		err := k8sutil.UpdateNodeRetryWithBackoff(ctx, fakeClient.CoreV1().Nodes(), "testNodeName",
+			backoff, func(*corev1.Node) {})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context cancelled error, got: %v", err)
+		}
+	})
+}
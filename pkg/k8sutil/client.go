@@ -2,25 +2,87 @@ package k8sutil
 
 import (
 	"fmt"
+	"net/http"
 
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+// ClientOptions configures Kubernetes clients.
+type ClientOptions struct {
+	// Records requests made by the client. If nil, requests are not recorded.
+	Metrics *ClientMetrics
+	// Records requests made by the client as spans. If nil, requests are not traced.
+	TracerProvider trace.TracerProvider
+	// Makes clientset use JSON instead of protobuf for built-in resources. Protobuf is considerably cheaper
+	// to encode and decode, e.g. when listing many nodes, but may not be supported by proxies in front of
+	// the API server. Dynamic clients always use JSON.
+	DisableProtobuf bool
+}
+
 // GetClient returns a Kubernetes client (clientset) from the kubeconfig path
 // or from the in-cluster service account environment.
 func GetClient(path string) (*kubernetes.Clientset, error) {
-	conf, err := getClientConfig(path)
+	return GetClientWithOptions(path, ClientOptions{})
+}
+
+// GetClientWithOptions works like GetClient, but configures the client using given options.
+func GetClientWithOptions(path string, opts ClientOptions) (*kubernetes.Clientset, error) {
+	conf, err := getClientConfig(path, opts)
 	if err != nil {
 		return nil, fmt.Errorf("getting Kubernetes client config: %w", err)
 	}
 
+	if !opts.DisableProtobuf {
+		conf.ContentType = runtime.ContentTypeProtobuf
+		conf.AcceptContentTypes = runtime.ContentTypeProtobuf + "," + runtime.ContentTypeJSON
+	}
+
 	return kubernetes.NewForConfig(conf)
 }
 
+// GetDynamicClient returns a dynamic Kubernetes client from the kubeconfig path
+// or from the in-cluster service account environment.
+func GetDynamicClient(path string) (dynamic.Interface, error) {
+	return GetDynamicClientWithOptions(path, ClientOptions{})
+}
+
+// GetDynamicClientWithOptions works like GetDynamicClient, but configures the client using given options.
+func GetDynamicClientWithOptions(path string, opts ClientOptions) (dynamic.Interface, error) {
+	conf, err := getClientConfig(path, opts)
+	if err != nil {
+		return nil, fmt.Errorf("getting Kubernetes client config: %w", err)
+	}
+
+	return dynamic.NewForConfig(conf)
+}
+
 // getClientConfig returns a Kubernetes client Config.
-func getClientConfig(path string) (*rest.Config, error) {
+func getClientConfig(path string, opts ClientOptions) (*rest.Config, error) {
+	conf, err := getBaseClientConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Metrics != nil {
+		conf.Wrap(opts.Metrics.WrapTransport)
+	}
+
+	if opts.TracerProvider != nil {
+		conf.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return TraceTransport(opts.TracerProvider, rt)
+		})
+	}
+
+	return conf, nil
+}
+
+// getBaseClientConfig returns a Kubernetes client Config without any instrumentation.
+func getBaseClientConfig(path string) (*rest.Config, error) {
 	if path != "" {
 		// Build Config from a kubeconfig filepath.
 		return clientcmd.BuildConfigFromFlags("", path)
@@ -0,0 +1,92 @@
+package k8sutil_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+func Test_Client_requests_built_in_resources_using(t *testing.T) {
+	t.Parallel()
+
+	for name, testCase := range map[string]struct {
+		disableProtobuf bool
+		expectedAccept  string
+	}{
+		"protobuf_by_default": {
+			expectedAccept: "application/vnd.kubernetes.protobuf",
+		},
+		"JSON_when_protobuf_is_disabled": {
+			disableProtobuf: true,
+			expectedAccept:  "application/json",
+		},
+	} {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			accept := make(chan string, 1)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case accept <- r.Header.Get("Accept"):
+				default:
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`)
+			}))
+
+			t.Cleanup(server.Close)
+
+			client, err := k8sutil.GetClientWithOptions(kubeconfig(t, server.URL), k8sutil.ClientOptions{
+				DisableProtobuf: testCase.disableProtobuf,
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error creating client: %v", err)
+			}
+
+			_, _ = client.CoreV1().Nodes().Get(context.TODO(), "foo", metav1.GetOptions{})
+
+			if a := <-accept; !strings.HasPrefix(a, testCase.expectedAccept) {
+				t.Fatalf("Expected Accept header to start with %q, got %q", testCase.expectedAccept, a)
+			}
+		})
+	}
+}
+
+func kubeconfig(t *testing.T, server string) string {
+	t.Helper()
+
+	content := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+current-context: test
+`, server)
+
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Writing kubeconfig: %v", err)
+	}
+
+	return path
+}
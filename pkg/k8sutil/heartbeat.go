@@ -0,0 +1,127 @@
+package k8sutil
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// HeartbeatLeasePrefix prefixes names of leases used by update-agent to report it is alive.
+const HeartbeatLeasePrefix = "flatcar-linux-update-agent-"
+
+// LeaseGetter is a subset of coordinationv1client.LeaseInterface used by this package for getting leases.
+type LeaseGetter interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*coordinationv1.Lease, error)
+}
+
+// LeaseClient is a subset of coordinationv1client.LeaseInterface used by this package for maintaining
+// heartbeat leases.
+type LeaseClient interface {
+	LeaseGetter
+
+	Create(ctx context.Context, lease *coordinationv1.Lease, opts metav1.CreateOptions) (*coordinationv1.Lease, error)
+	Update(ctx context.Context, lease *coordinationv1.Lease, opts metav1.UpdateOptions) (*coordinationv1.Lease, error)
+}
+
+// HeartbeatLeaseName returns name of heartbeat lease for given node.
+func HeartbeatLeaseName(nodeName string) string {
+	return HeartbeatLeasePrefix + nodeName
+}
+
+// RenewHeartbeatLease creates or renews heartbeat lease of given node, marking it as held by given identity
+// for given duration from now. Conflicts with concurrent changes are retried up to DefaultBackoff number
+// of times.
+func RenewHeartbeatLease(
+	ctx context.Context, leases LeaseClient, nodeName, holderIdentity string, duration time.Duration,
+) error {
+	name := HeartbeatLeaseName(nodeName)
+
+	retriable := func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}
+
+	err := retry.OnError(retry.DefaultBackoff, retriable, func() error {
+		lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+
+		switch {
+		case apierrors.IsNotFound(err):
+			lease = &coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+				},
+			}
+
+			renewHeartbeat(lease, holderIdentity, duration)
+
+			_, err = leases.Create(ctx, lease, metav1.CreateOptions{})
+
+			return err
+		case err != nil:
+			return err
+		}
+
+		renewHeartbeat(lease, holderIdentity, duration)
+
+		_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("renewing heartbeat lease %q: %w", name, err)
+	}
+
+	return nil
+}
+
+func renewHeartbeat(lease *coordinationv1.Lease, holderIdentity string, duration time.Duration) {
+	now := metav1.NowMicro()
+	durationSeconds := int32(duration.Seconds())
+
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != holderIdentity {
+		lease.Spec.AcquireTime = &now
+	}
+
+	lease.Spec.HolderIdentity = &holderIdentity
+	lease.Spec.LeaseDurationSeconds = &durationSeconds
+	lease.Spec.RenewTime = &now
+}
+
+// GetHeartbeatLease returns heartbeat lease of given node. If the update-agent has never reported
+// heartbeat, returned error satisfies apierrors.IsNotFound.
+func GetHeartbeatLease(ctx context.Context, leases LeaseGetter, nodeName string) (*coordinationv1.Lease, error) {
+	name := HeartbeatLeaseName(nodeName)
+
+	lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting heartbeat lease %q: %w", name, err)
+	}
+
+	return lease, nil
+}
+
+// HeartbeatAge returns how long ago given heartbeat lease has been renewed at given time. Lease which
+// has never been renewed is reported with false.
+func HeartbeatAge(lease *coordinationv1.Lease, now time.Time) (time.Duration, bool) {
+	if lease.Spec.RenewTime == nil {
+		return 0, false
+	}
+
+	return now.Sub(lease.Spec.RenewTime.Time), true
+}
+
+// HeartbeatExpired returns true if given heartbeat lease has not been renewed within its duration at given
+// time, meaning the update-agent holding it is most likely not running anymore. Leases without renew time
+// or duration are considered expired.
+func HeartbeatExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	age, ok := HeartbeatAge(lease, now)
+	if !ok || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+
+	return age > time.Duration(*lease.Spec.LeaseDurationSeconds)*time.Second
+}
@@ -0,0 +1,204 @@
+package k8sutil_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+//nolint:funlen // Just subtests.
+func Test_Renewing_heartbeat_lease(t *testing.T) {
+	t.Parallel()
+
+	const (
+		nodeName  = "testNodeName"
+		namespace = "testNamespace"
+		holder    = "testHolder"
+		duration  = 30 * time.Second
+	)
+
+	t.Run("creates_lease_when_it_does_not_exist", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.TODO()
+		leases := fake.NewSimpleClientset().CoordinationV1().Leases(namespace)
+
+		if err := k8sutil.RenewHeartbeatLease(ctx, leases, nodeName, holder, duration); err != nil {
+			t.Fatalf("Unexpected error renewing lease: %v", err)
+		}
+
+		lease, err := k8sutil.GetHeartbeatLease(ctx, leases, nodeName)
+		if err != nil {
+			t.Fatalf("Getting lease: %v", err)
+		}
+
+		if lease.Name != k8sutil.HeartbeatLeaseName(nodeName) {
+			t.Errorf("Expected lease name %q, got %q", k8sutil.HeartbeatLeaseName(nodeName), lease.Name)
+		}
+
+		if h := lease.Spec.HolderIdentity; h == nil || *h != holder {
+			t.Errorf("Expected holder identity %q, got %v", holder, h)
+		}
+
+		if d := lease.Spec.LeaseDurationSeconds; d == nil || *d != int32(duration.Seconds()) {
+			t.Errorf("Expected lease duration %v, got %v", duration, d)
+		}
+
+		if k8sutil.HeartbeatExpired(lease, time.Now()) {
+			t.Errorf("Expected freshly created lease not to be expired")
+		}
+	})
+
+	t.Run("updates_renew_time_of_existing_lease", func(t *testing.T) {
+		t.Parallel()
+
+		renewTime := metav1.NewMicroTime(time.Now().Add(-time.Hour))
+		acquireTime := metav1.NewMicroTime(time.Now().Add(-2 * time.Hour))
+		existingHolder := holder
+
+		existingLease := &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      k8sutil.HeartbeatLeaseName(nodeName),
+				Namespace: namespace,
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity: &existingHolder,
+				AcquireTime:    &acquireTime,
+				RenewTime:      &renewTime,
+			},
+		}
+
+		ctx := context.TODO()
+		leases := fake.NewSimpleClientset(existingLease).CoordinationV1().Leases(namespace)
+
+		if err := k8sutil.RenewHeartbeatLease(ctx, leases, nodeName, holder, duration); err != nil {
+			t.Fatalf("Unexpected error renewing lease: %v", err)
+		}
+
+		lease, err := k8sutil.GetHeartbeatLease(ctx, leases, nodeName)
+		if err != nil {
+			t.Fatalf("Getting lease: %v", err)
+		}
+
+		if !lease.Spec.RenewTime.After(renewTime.Time) {
+			t.Errorf("Expected renew time to be updated, got %v", lease.Spec.RenewTime)
+		}
+
+		if !lease.Spec.AcquireTime.Equal(&acquireTime) {
+			t.Errorf("Expected acquire time to be preserved for the same holder, got %v", lease.Spec.AcquireTime)
+		}
+	})
+
+	t.Run("retries_when_lease_is_created_concurrently", func(t *testing.T) {
+		t.Parallel()
+
+		fakeClient := fake.NewSimpleClientset()
+
+		failed := false
+
+		fakeClient.PrependReactor("create", "leases", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if failed {
+				return false, nil, nil
+			}
+
+			failed = true
+
+			return true, nil, errors.NewAlreadyExists(schema.GroupResource{Resource: "leases"}, nodeName)
+		})
+
+		leases := fakeClient.CoordinationV1().Leases(namespace)
+
+		if err := k8sutil.RenewHeartbeatLease(context.TODO(), leases, nodeName, holder, duration); err != nil {
+			t.Fatalf("Unexpected error renewing lease: %v", err)
+		}
+
+		if !failed {
+			t.Fatalf("Expected lease creation to be retried")
+		}
+	})
+
+	t.Run("returns_error_when_getting_lease_fails", func(t *testing.T) {
+		t.Parallel()
+
+		fakeClient := fake.NewSimpleClientset()
+
+		expectedErr := fmt.Errorf("test error")
+
+		fakeClient.PrependReactor("get", "leases", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, expectedErr
+		})
+
+		leases := fakeClient.CoordinationV1().Leases(namespace)
+
+		if err := k8sutil.RenewHeartbeatLease(context.TODO(), leases, nodeName, holder, duration); err == nil {
+			t.Fatalf("Expected error renewing lease")
+		}
+	})
+}
+
+func Test_Getting_heartbeat_lease_returns_not_found_error_when_lease_does_not_exist(t *testing.T) {
+	t.Parallel()
+
+	leases := fake.NewSimpleClientset().CoordinationV1().Leases("testNamespace")
+
+	if _, err := k8sutil.GetHeartbeatLease(context.TODO(), leases, "testNodeName"); !errors.IsNotFound(err) {
+		t.Fatalf("Expected not found error, got: %v", err)
+	}
+}
+
+func Test_Heartbeat_lease_is_expired(t *testing.T) {
+	t.Parallel()
+
+	durationSeconds := int32(30)
+	renewTime := metav1.NewMicroTime(time.Now())
+	expiredTime := renewTime.Add(time.Minute)
+
+	for name, testCase := range map[string]struct {
+		spec    coordinationv1.LeaseSpec
+		at      time.Time
+		expired bool
+	}{
+		"when_not_renewed_within_lease_duration": {
+			spec:    coordinationv1.LeaseSpec{RenewTime: &renewTime, LeaseDurationSeconds: &durationSeconds},
+			at:      expiredTime,
+			expired: true,
+		},
+		"when_renew_time_is_not_set": {
+			spec:    coordinationv1.LeaseSpec{LeaseDurationSeconds: &durationSeconds},
+			at:      renewTime.Time,
+			expired: true,
+		},
+		"when_lease_duration_is_not_set": {
+			spec:    coordinationv1.LeaseSpec{RenewTime: &renewTime},
+			at:      renewTime.Time,
+			expired: true,
+		},
+		"not_when_renewed_within_lease_duration": {
+			spec: coordinationv1.LeaseSpec{RenewTime: &renewTime, LeaseDurationSeconds: &durationSeconds},
+			at:   renewTime.Add(time.Second),
+		},
+	} {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lease := &coordinationv1.Lease{Spec: testCase.spec}
+
+			if expired := k8sutil.HeartbeatExpired(lease, testCase.at); expired != testCase.expired {
+				t.Fatalf("Expected expired to be %t, got %t", testCase.expired, expired)
+			}
+		})
+	}
+}
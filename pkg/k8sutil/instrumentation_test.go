@@ -0,0 +1,203 @@
+package k8sutil_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+//nolint:funlen // Just many test cases.
+func Test_Client_metrics_record_requests_by_verb_resource_and_code(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v1/nodes" && r.URL.Query().Get("watch") == "" {
+			fmt.Fprint(w, `{"kind":"NodeList","apiVersion":"v1","items":[]}`)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`)
+	}))
+
+	t.Cleanup(server.Close)
+
+	for name, testCase := range map[string]struct {
+		request  func(context.Context, kubernetes.Interface) error
+		verb     string
+		resource string
+		code     string
+	}{
+		"listing_nodes": {
+			request: func(ctx context.Context, c kubernetes.Interface) error {
+				_, err := c.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+
+				return err
+			},
+			verb:     "list",
+			resource: "nodes",
+			code:     "200",
+		},
+		"getting_node": {
+			request: func(ctx context.Context, c kubernetes.Interface) error {
+				_, err := c.CoreV1().Nodes().Get(ctx, "foo", metav1.GetOptions{})
+
+				return err
+			},
+			verb:     "get",
+			resource: "nodes",
+			code:     "404",
+		},
+		"watching_nodes": {
+			request: func(ctx context.Context, c kubernetes.Interface) error {
+				_, err := c.CoreV1().Nodes().Watch(ctx, metav1.ListOptions{})
+
+				return err
+			},
+			verb:     "watch",
+			resource: "nodes",
+			code:     "404",
+		},
+		"patching_node": {
+			request: func(ctx context.Context, c kubernetes.Interface) error {
+				_, err := c.CoreV1().Nodes().Patch(ctx, "foo", types.MergePatchType, []byte("{}"), metav1.PatchOptions{})
+
+				return err
+			},
+			verb:     "patch",
+			resource: "nodes",
+			code:     "404",
+		},
+		"evicting_namespaced_pod": {
+			request: func(ctx context.Context, c kubernetes.Interface) error {
+				eviction := &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"}}
+
+				return c.CoreV1().Pods("bar").EvictV1(ctx, eviction)
+			},
+			verb:     "create",
+			resource: "pods/eviction",
+			code:     "404",
+		},
+		"deleting_lease_from_group": {
+			request: func(ctx context.Context, c kubernetes.Interface) error {
+				return c.CoordinationV1().Leases("bar").Delete(ctx, "foo", metav1.DeleteOptions{})
+			},
+			verb:     "delete",
+			resource: "leases",
+			code:     "404",
+		},
+	} {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			registry := prometheus.NewRegistry()
+
+			clientMetrics, err := k8sutil.NewClientMetrics(registry, "test")
+			if err != nil {
+				t.Fatalf("Unexpected error creating client metrics: %v", err)
+			}
+
+			client := kubernetes.NewForConfigOrDie(&rest.Config{
+				Host:          server.URL,
+				WrapTransport: clientMetrics.WrapTransport,
+			})
+
+			_ = testCase.request(context.TODO(), client)
+
+			labels := map[string]string{"verb": testCase.verb, "resource": testCase.resource, "code": testCase.code}
+
+			if requests := clientMetric(t, registry, "test_kubernetes_api_requests_total", labels); requests == nil {
+				t.Fatalf("Expected request with labels %v to be recorded", labels)
+			}
+
+			delete(labels, "code")
+
+			duration := clientMetric(t, registry, "test_kubernetes_api_request_duration_seconds", labels)
+			if duration == nil || duration.GetHistogram().GetSampleCount() != 1 {
+				t.Fatalf("Expected request duration with labels %v to be recorded once, got %v", labels, duration)
+			}
+		})
+	}
+}
+
+func Test_Client_metrics_record_requests_failing_without_response_with_error_code(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+
+	clientMetrics, err := k8sutil.NewClientMetrics(registry, "test")
+	if err != nil {
+		t.Fatalf("Unexpected error creating client metrics: %v", err)
+	}
+
+	failingRoundTripper := roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, fmt.Errorf("connection refused")
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "https://example.com/api/v1/nodes/foo", nil)
+
+	//nolint:bodyclose // No response is returned on error.
+	if _, err := clientMetrics.WrapTransport(failingRoundTripper).RoundTrip(req); err == nil {
+		t.Fatalf("Expected error from round tripper")
+	}
+
+	labels := map[string]string{"verb": "update", "resource": "nodes", "code": "error"}
+
+	if requests := clientMetric(t, registry, "test_kubernetes_api_requests_total", labels); requests == nil {
+		t.Fatalf("Expected request with labels %v to be recorded", labels)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func clientMetric(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) *dto.Metric {
+	t.Helper()
+
+	metricFamilies, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed gathering metrics: %v", err)
+	}
+
+	for _, metricFamily := range metricFamilies {
+		if metricFamily.GetName() != name {
+			continue
+		}
+
+		for _, metric := range metricFamily.GetMetric() {
+			matching := 0
+
+			for _, label := range metric.GetLabel() {
+				if value, ok := labels[label.GetName()]; ok && value == label.GetValue() {
+					matching++
+				}
+			}
+
+			if matching == len(labels) {
+				return metric
+			}
+		}
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/util/retry"
 )
@@ -51,24 +52,113 @@ type NodeUpdater interface {
 // Given update function will be called each time since the node object will likely have changed if
 // a retry is necessary.
 func UpdateNodeRetry(ctx context.Context, nodeUpdater NodeUpdater, nodeName string, updateF UpdateNode) error {
-	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+	return UpdateNodeRetryWithBackoff(ctx, nodeUpdater, nodeName, DefaultBackoff(), updateF)
+}
+
+// UpdateNodeRetryWithBackoff works like UpdateNodeRetry, but retries conflicts according to given backoff.
+// Retrying stops when given context gets cancelled.
+func UpdateNodeRetryWithBackoff(
+	ctx context.Context, nodeUpdater NodeUpdater, nodeName string, backoff Backoff, updateF UpdateNode,
+) error {
+	_, err := UpdateNodeIfWithBackoff(ctx, nodeUpdater, nodeName, backoff, func(node *corev1.Node) bool {
+		updateF(node)
+
+		return true
+	})
+
+	return err
+}
+
+// UpdateNodeIfF is a function updating properties of received node object only if the node is in
+// expected state. It returns false if node should not be updated.
+type UpdateNodeIfF func(*corev1.Node) bool
+
+// UpdateNodeIf works like UpdateNodeRetry, except that the node is only updated if given function returns
+// true. As update fails on conflicts when node object changes after it was read, the decision is re-evaluated
+// on fresh node object on each retry, so the update is never based on stale state.
+//
+// It returns true if node has been updated.
+func UpdateNodeIf(ctx context.Context, nodeUpdater NodeUpdater, nodeName string, updateF UpdateNodeIfF) (bool, error) {
+	return UpdateNodeIfWithBackoff(ctx, nodeUpdater, nodeName, DefaultBackoff(), updateF)
+}
+
+// UpdateNodeIfWithBackoff works like UpdateNodeIf, but retries conflicts according to given backoff.
+// Retrying stops when given context gets cancelled.
+func UpdateNodeIfWithBackoff(
+	ctx context.Context, nodeUpdater NodeUpdater, nodeName string, backoff Backoff, updateF UpdateNodeIfF,
+) (bool, error) {
+	updated := false
+
+	err := retryOnError(ctx, backoff, apierrors.IsConflict, func() error {
+		updated = false
+
 		node, getErr := nodeUpdater.Get(ctx, nodeName, metav1.GetOptions{})
 		if getErr != nil {
 			return fmt.Errorf("getting node %q: %w", nodeName, getErr)
 		}
 
-		updateF(node)
+		// Empty maps are omitted by the API, e.g. after applying node metadata using server-side apply.
+		if node.Annotations == nil {
+			node.Annotations = map[string]string{}
+		}
+
+		if node.Labels == nil {
+			node.Labels = map[string]string{}
+		}
 
-		_, err := nodeUpdater.Update(ctx, node, metav1.UpdateOptions{})
+		if !updateF(node) {
+			return nil
+		}
+
+		if _, err := nodeUpdater.Update(ctx, node, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
 
-		return err
+		updated = true
+
+		return nil
 	})
 	if err != nil {
 		// May be conflict if max retries were hit.
-		return fmt.Errorf("updating node %q: %w", nodeName, err)
+		return false, fmt.Errorf("updating node %q: %w", nodeName, err)
 	}
 
-	return nil
+	return updated, nil
+}
+
+// CompareAndSwapAnnotation sets node annotation key to newValue only if its current value is oldValue.
+// Missing annotation never matches, use SetNodeAnnotationIfAbsent to handle that case.
+//
+// It returns true if annotation has been swapped.
+func CompareAndSwapAnnotation(
+	ctx context.Context, nodeUpdater NodeUpdater, nodeName, key, oldValue, newValue string,
+) (bool, error) {
+	return UpdateNodeIf(ctx, nodeUpdater, nodeName, func(node *corev1.Node) bool {
+		if value, ok := node.Annotations[key]; !ok || value != oldValue {
+			return false
+		}
+
+		node.Annotations[key] = newValue
+
+		return true
+	})
+}
+
+// SetNodeAnnotationIfAbsent sets node annotation key to value only if node does not have such annotation yet.
+//
+// It returns true if annotation has been set.
+func SetNodeAnnotationIfAbsent(
+	ctx context.Context, nodeUpdater NodeUpdater, nodeName, key, value string,
+) (bool, error) {
+	return UpdateNodeIf(ctx, nodeUpdater, nodeName, func(node *corev1.Node) bool {
+		if _, ok := node.Annotations[key]; ok {
+			return false
+		}
+
+		node.Annotations[key] = value
+
+		return true
+	})
 }
 
 // SetNodeLabels sets all keys in m to their respective values in
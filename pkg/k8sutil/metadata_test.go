@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -14,6 +15,7 @@ import (
 	"k8s.io/client-go/kubernetes/fake"
 	k8stesting "k8s.io/client-go/testing"
 
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8stest"
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
 )
 
@@ -108,6 +110,172 @@ func Test_Updating_node(t *testing.T) {
 	})
 }
 
+//nolint:funlen // Just subtests.
+func Test_Compare_and_swap_annotation(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sets_annotation_when_current_value_matches_expected_one", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.TODO()
+		nc := fake.NewSimpleClientset(testNodeWithAnnotation("key", "old")).CoreV1().Nodes()
+
+		swapped, err := k8sutil.CompareAndSwapAnnotation(ctx, nc, "testNodeName", "key", "old", "new")
+		if err != nil {
+			t.Fatalf("Unexpected error swapping annotation: %v", err)
+		}
+
+		if !swapped {
+			t.Fatalf("Expected annotation to be swapped")
+		}
+
+		assertAnnotationValue(ctx, t, nc, "key", "new")
+	})
+
+	t.Run("does_not_update_node_when", func(t *testing.T) {
+		t.Parallel()
+
+		for name, node := range map[string]*corev1.Node{
+			"current_value_differs_from_expected_one": testNodeWithAnnotation("key", "other"),
+			"annotation_is_missing":                   testNodeWithAnnotation("otherKey", "old"),
+		} {
+			node := node
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				ctx := context.TODO()
+				fakeClient := fake.NewSimpleClientset(node)
+
+				fakeClient.PrependReactor("update", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					t.Errorf("Unexpected node update")
+
+					return false, nil, nil
+				})
+
+				swapped, err := k8sutil.CompareAndSwapAnnotation(ctx, fakeClient.CoreV1().Nodes(),
+					"testNodeName", "key", "old", "new")
+				if err != nil {
+					t.Fatalf("Unexpected error swapping annotation: %v", err)
+				}
+
+				if swapped {
+					t.Fatalf("Expected annotation not to be swapped")
+				}
+			})
+		}
+	})
+
+	t.Run("re_evaluates_current_value_on_conflict", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.TODO()
+		fakeClient := fake.NewSimpleClientset(testNodeWithAnnotation("key", "old"))
+		nc := fakeClient.CoreV1().Nodes()
+
+		sentConflict := false
+
+		fakeClient.PrependReactor("update", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if sentConflict {
+				return false, nil, nil
+			}
+
+			sentConflict = true
+
+			// Simulate concurrent change of the annotation.
+			if err := fakeClient.Tracker().Update(schema.GroupVersionResource{Version: "v1", Resource: "nodes"},
+				testNodeWithAnnotation("key", "concurrent"), ""); err != nil {
+				t.Errorf("Updating node: %v", err)
+			}
+
+			return true, nil, errors.NewConflict(schema.GroupResource{}, "testNodeName", fmt.Errorf("test error"))
+		})
+
+		swapped, err := k8sutil.CompareAndSwapAnnotation(ctx, nc, "testNodeName", "key", "old", "new")
+		if err != nil {
+			t.Fatalf("Unexpected error swapping annotation: %v", err)
+		}
+
+		if swapped {
+			t.Fatalf("Expected annotation not to be swapped after concurrent change")
+		}
+
+		assertAnnotationValue(ctx, t, nc, "key", "concurrent")
+	})
+
+	t.Run("returns_error_when_node_does_not_exist", func(t *testing.T) {
+		t.Parallel()
+
+		nc := fake.NewSimpleClientset().CoreV1().Nodes()
+
+		if _, err := k8sutil.CompareAndSwapAnnotation(context.TODO(), nc, "testNodeName", "key", "", "new"); err == nil {
+			t.Fatalf("Expected error swapping annotation of non existing node")
+		}
+	})
+}
+
+func Test_Setting_node_annotation_if_absent(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sets_annotation_when_it_is_missing", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.TODO()
+		nc := fake.NewSimpleClientset(testNodeWithAnnotation("otherKey", "value")).CoreV1().Nodes()
+
+		set, err := k8sutil.SetNodeAnnotationIfAbsent(ctx, nc, "testNodeName", "key", "new")
+		if err != nil {
+			t.Fatalf("Unexpected error setting annotation: %v", err)
+		}
+
+		if !set {
+			t.Fatalf("Expected annotation to be set")
+		}
+
+		assertAnnotationValue(ctx, t, nc, "key", "new")
+	})
+
+	t.Run("keeps_existing_annotation_value", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.TODO()
+		nc := fake.NewSimpleClientset(testNodeWithAnnotation("key", "")).CoreV1().Nodes()
+
+		set, err := k8sutil.SetNodeAnnotationIfAbsent(ctx, nc, "testNodeName", "key", "new")
+		if err != nil {
+			t.Fatalf("Unexpected error setting annotation: %v", err)
+		}
+
+		if set {
+			t.Fatalf("Expected annotation not to be set")
+		}
+
+		assertAnnotationValue(ctx, t, nc, "key", "")
+	})
+}
+
+func testNodeWithAnnotation(key, value string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "testNodeName",
+			Annotations: map[string]string{key: value},
+		},
+	}
+}
+
+func assertAnnotationValue(ctx context.Context, t *testing.T, nc k8sutil.NodeGetter, key, expectedValue string) {
+	t.Helper()
+
+	node, err := nc.Get(ctx, "testNodeName", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Getting node: %v", err)
+	}
+
+	if v, ok := node.Annotations[key]; !ok || v != expectedValue {
+		t.Fatalf("Expected annotation %q to be %q, got %q", key, expectedValue, v)
+	}
+}
+
 func atomicCounterIncrement(t *testing.T, annotationKey string) func(n *corev1.Node) {
 	t.Helper()
 
@@ -122,3 +290,81 @@ func atomicCounterIncrement(t *testing.T, annotationKey string) func(n *corev1.N
 		node.Annotations[annotationKey] = strconv.Itoa(i + 1)
 	}
 }
+
+//nolint:funlen // Just subtests.
+func Test_Updating_node_with_injected_faults(t *testing.T) {
+	t.Parallel()
+
+	const nodeName = "testNodeName"
+
+	backoff := k8sutil.Backoff{InitialDelay: time.Millisecond, Factor: 1, MaxRetries: 3}
+
+	setAnnotation := func(node *corev1.Node) {
+		if node.Annotations == nil {
+			node.Annotations = map[string]string{}
+		}
+
+		node.Annotations["foo"] = "bar"
+	}
+
+	t.Run("retries_conflicts_until_update_succeeds", func(t *testing.T) {
+		t.Parallel()
+
+		client := k8stest.NewClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+		injected := client.FailNodeUpdates(backoff.MaxRetries, k8stest.ConflictError("nodes", nodeName))
+
+		ctx := context.TODO()
+		nc := client.CoreV1().Nodes()
+
+		if err := k8sutil.UpdateNodeRetryWithBackoff(ctx, nc, nodeName, backoff, setAnnotation); err != nil {
+			t.Fatalf("Unexpected error updating node: %v", err)
+		}
+
+		if failed := injected.Failed(); failed != backoff.MaxRetries {
+			t.Fatalf("Expected %d failed updates, got %d", backoff.MaxRetries, failed)
+		}
+
+		assertAnnotationValue(ctx, t, nc, "foo", "bar")
+	})
+
+	t.Run("gives_up_on_persistent_conflicts_after_max_retries", func(t *testing.T) {
+		t.Parallel()
+
+		client := k8stest.NewClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+		injected := client.FailNodeUpdates(-1, k8stest.ConflictError("nodes", nodeName))
+
+		err := k8sutil.UpdateNodeRetryWithBackoff(context.TODO(), client.CoreV1().Nodes(), nodeName, backoff,
+			setAnnotation)
+		if !errors.IsConflict(err) {
+			t.Fatalf("Expected conflict error, got: %v", err)
+		}
+
+		if calls := injected.Calls(); calls != backoff.MaxRetries+1 {
+			t.Fatalf("Expected %d update attempts, got %d", backoff.MaxRetries+1, calls)
+		}
+	})
+
+	for name, injectedErr := range map[string]error{
+		"throttling":      k8stest.ThrottlingError(),
+		"transient_error": k8stest.TransientError(),
+	} {
+		injectedErr := injectedErr
+
+		t.Run(fmt.Sprintf("does_not_retry_%s", name), func(t *testing.T) {
+			t.Parallel()
+
+			client := k8stest.NewClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+			injected := client.FailNodeUpdates(1, injectedErr)
+
+			err := k8sutil.UpdateNodeRetryWithBackoff(context.TODO(), client.CoreV1().Nodes(), nodeName, backoff,
+				setAnnotation)
+			if err == nil {
+				t.Fatalf("Expected error updating node")
+			}
+
+			if calls := injected.Calls(); calls != 1 {
+				t.Fatalf("Expected exactly one update attempt, got %d", calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package k8sutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// NodePatcher is a subset of corev1client.NodeInterface used by this package for patching nodes.
+type NodePatcher interface {
+	Patch(
+		ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string,
+	) (*corev1.Node, error)
+}
+
+// NodeMetadataPatch describes changes to node annotations and labels. Keys which are not mentioned
+// are left untouched.
+type NodeMetadataPatch struct {
+	// Annotations to set.
+	Annotations map[string]string
+	// Labels to set.
+	Labels map[string]string
+	// Annotations to remove. Annotations which are also set take precedence.
+	RemoveAnnotations []string
+	// Labels to remove. Labels which are also set take precedence.
+	RemoveLabels []string
+}
+
+// empty returns true if patch does not change anything.
+func (p NodeMetadataPatch) empty() bool {
+	return len(p.Annotations) == 0 && len(p.Labels) == 0 && len(p.RemoveAnnotations) == 0 && len(p.RemoveLabels) == 0
+}
+
+// data returns strategic merge patch for node object carrying described changes.
+func (p NodeMetadataPatch) data() ([]byte, error) {
+	metadata := map[string]map[string]*string{}
+
+	if annotations := patchValues(p.Annotations, p.RemoveAnnotations); len(annotations) > 0 {
+		metadata["annotations"] = annotations
+	}
+
+	if labels := patchValues(p.Labels, p.RemoveLabels); len(labels) > 0 {
+		metadata["labels"] = labels
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"metadata": metadata})
+	if err != nil {
+		return nil, fmt.Errorf("encoding patch: %w", err)
+	}
+
+	return data, nil
+}
+
+// patchValues merges values to set and keys to remove, which are represented by null values.
+func patchValues(set map[string]string, remove []string) map[string]*string {
+	values := make(map[string]*string, len(set)+len(remove))
+
+	for _, key := range remove {
+		values[key] = nil
+	}
+
+	for key, value := range set {
+		value := value
+		values[key] = &value
+	}
+
+	return values
+}
+
+// PatchNodeAnnotationsLabels changes node annotations and labels as described by given patch using
+// strategic merge patch, retrying up to DefaultBackoff number of times on conflicts.
+//
+// Unlike UpdateNodeRetry, it does not read the node first and only sends given keys, so it does not conflict
+// with concurrent changes of other node fields.
+func PatchNodeAnnotationsLabels(
+	ctx context.Context, nodePatcher NodePatcher, nodeName string, patch NodeMetadataPatch,
+) error {
+	if patch.empty() {
+		return nil
+	}
+
+	data, err := patch.data()
+	if err != nil {
+		return fmt.Errorf("building patch for node %q: %w", nodeName, err)
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		_, patchErr := nodePatcher.Patch(ctx, nodeName, types.StrategicMergePatchType, data, metav1.PatchOptions{})
+
+		return patchErr
+	})
+	if err != nil {
+		return fmt.Errorf("patching node %q: %w", nodeName, err)
+	}
+
+	return nil
+}
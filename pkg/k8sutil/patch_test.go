@@ -0,0 +1,181 @@
+package k8sutil_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+//nolint:funlen // Just subtests.
+func Test_Patching_node_annotations_and_labels(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sets_and_removes_given_keys_preserving_other_ones", func(t *testing.T) {
+		t.Parallel()
+
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "testNodeName",
+				Annotations: map[string]string{"foo": "bar", "removed": "value", "overridden": "old"},
+				Labels:      map[string]string{"baz": "doh", "removed": "value"},
+			},
+		}
+
+		fakeClient := fake.NewSimpleClientset(node)
+		nc := fakeClient.CoreV1().Nodes()
+		ctx := context.TODO()
+
+		patch := k8sutil.NodeMetadataPatch{
+			Annotations:       map[string]string{"annotation": "value", "overridden": "new"},
+			Labels:            map[string]string{"label": "value"},
+			RemoveAnnotations: []string{"removed", "overridden", "not-existing"},
+			RemoveLabels:      []string{"removed"},
+		}
+
+		if err := k8sutil.PatchNodeAnnotationsLabels(ctx, nc, node.Name, patch); err != nil {
+			t.Fatalf("Unexpected error patching node: %v", err)
+		}
+
+		updatedNode, err := nc.Get(ctx, node.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Getting node: %v", err)
+		}
+
+		for key, expected := range map[string]string{"foo": "bar", "annotation": "value", "overridden": "new"} {
+			if v := updatedNode.Annotations[key]; v != expected {
+				t.Errorf("Expected annotation %q to be %q, got %q", key, expected, v)
+			}
+		}
+
+		for key, expected := range map[string]string{"baz": "doh", "label": "value"} {
+			if v := updatedNode.Labels[key]; v != expected {
+				t.Errorf("Expected label %q to be %q, got %q", key, expected, v)
+			}
+		}
+
+		if _, ok := updatedNode.Annotations["removed"]; ok {
+			t.Errorf("Expected annotation %q to be removed", "removed")
+		}
+
+		if _, ok := updatedNode.Labels["removed"]; ok {
+			t.Errorf("Expected label %q to be removed", "removed")
+		}
+	})
+
+	t.Run("sends_only_given_keys_without_reading_node", func(t *testing.T) {
+		t.Parallel()
+
+		fakeClient := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "testNodeName"}})
+
+		patch := k8sutil.NodeMetadataPatch{
+			Annotations:  map[string]string{"annotation": "value"},
+			RemoveLabels: []string{"label"},
+		}
+
+		err := k8sutil.PatchNodeAnnotationsLabels(context.TODO(), fakeClient.CoreV1().Nodes(), "testNodeName", patch)
+		if err != nil {
+			t.Fatalf("Unexpected error patching node: %v", err)
+		}
+
+		actions := fakeClient.Actions()
+		if len(actions) != 1 {
+			t.Fatalf("Expected exactly one action, got %v", actions)
+		}
+
+		patchAction, ok := actions[0].(k8stesting.PatchAction)
+		if !ok {
+			t.Fatalf("Expected patch action, got %T", actions[0])
+		}
+
+		expectedPatch := `{"metadata":{"annotations":{"annotation":"value"},"labels":{"label":null}}}`
+
+		if !jsonEqual(t, expectedPatch, string(patchAction.GetPatch())) {
+			t.Fatalf("Expected patch %s, got %s", expectedPatch, patchAction.GetPatch())
+		}
+	})
+
+	t.Run("does_nothing_when_patch_is_empty", func(t *testing.T) {
+		t.Parallel()
+
+		fakeClient := fake.NewSimpleClientset()
+
+		err := k8sutil.PatchNodeAnnotationsLabels(context.TODO(), fakeClient.CoreV1().Nodes(), "testNodeName",
+			k8sutil.NodeMetadataPatch{})
+		if err != nil {
+			t.Fatalf("Unexpected error patching node: %v", err)
+		}
+
+		if actions := fakeClient.Actions(); len(actions) != 0 {
+			t.Fatalf("Expected no actions, got %v", actions)
+		}
+	})
+
+	t.Run("retries_on_conflict", func(t *testing.T) {
+		t.Parallel()
+
+		fakeClient := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "testNodeName"}})
+
+		failed := false
+
+		fakeClient.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if failed {
+				return false, nil, nil
+			}
+
+			failed = true
+
+			return true, nil, errors.NewConflict(schema.GroupResource{Resource: "nodes"}, "testNodeName",
+				fmt.Errorf("test error"))
+		})
+
+		err := k8sutil.PatchNodeAnnotationsLabels(context.TODO(), fakeClient.CoreV1().Nodes(), "testNodeName",
+			k8sutil.NodeMetadataPatch{Labels: map[string]string{"label": "value"}})
+		if err != nil {
+			t.Fatalf("Unexpected error patching node: %v", err)
+		}
+
+		if !failed {
+			t.Fatalf("Expected patch to be retried")
+		}
+	})
+
+	t.Run("returns_error_when_patching_fails", func(t *testing.T) {
+		t.Parallel()
+
+		fakeClient := fake.NewSimpleClientset()
+
+		err := k8sutil.PatchNodeAnnotationsLabels(context.TODO(), fakeClient.CoreV1().Nodes(), "testNodeName",
+			k8sutil.NodeMetadataPatch{Labels: map[string]string{"label": "value"}})
+		if !errors.IsNotFound(err) {
+			t.Fatalf("Expected not found error patching non-existing node, got: %v", err)
+		}
+	})
+}
+
+func jsonEqual(t *testing.T, a, b string) bool {
+	t.Helper()
+
+	var aValue, bValue interface{}
+
+	if err := json.Unmarshal([]byte(a), &aValue); err != nil {
+		t.Fatalf("Decoding JSON %q: %v", a, err)
+	}
+
+	if err := json.Unmarshal([]byte(b), &bValue); err != nil {
+		t.Fatalf("Decoding JSON %q: %v", b, err)
+	}
+
+	return cmp.Equal(aValue, bValue)
+}
@@ -48,6 +48,36 @@ func FilterNodesByRequirement(nodes []corev1.Node, req *labels.Requirement) []co
 	return matches
 }
 
+// NodeSelector selects nodes by their labels and annotations. Nil selectors match all nodes.
+type NodeSelector struct {
+	// Selector matched against node labels.
+	Labels labels.Selector
+	// Selector matched against node annotations.
+	Annotations fields.Selector
+}
+
+// Matches returns true if given node matches both label and annotation selectors.
+func (s NodeSelector) Matches(node *corev1.Node) bool {
+	if s.Labels != nil && !s.Labels.Matches(labels.Set(node.Labels)) {
+		return false
+	}
+
+	return s.Annotations == nil || s.Annotations.Matches(fields.Set(node.Annotations))
+}
+
+// FilterNodes filters a list of nodes in a single pass and returns nodes matching the given selector.
+func FilterNodes(nodes []corev1.Node, sel NodeSelector) []corev1.Node {
+	var matches []corev1.Node
+
+	for i := range nodes {
+		if sel.Matches(&nodes[i]) {
+			matches = append(matches, nodes[i])
+		}
+	}
+
+	return matches
+}
+
 // FilterContainerLinuxNodes filters a list of nodes and returns nodes with a
 // Flatcar Container Linux OSImage, as reported by the node's /etc/os-release.
 func FilterContainerLinuxNodes(nodes []corev1.Node) []corev1.Node {
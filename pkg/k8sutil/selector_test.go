@@ -0,0 +1,78 @@
+package k8sutil_test
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+func Test_Filtering_nodes(t *testing.T) {
+	t.Parallel()
+
+	newNode := func(name string, nodeLabels, annotations map[string]string) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      nodeLabels,
+				Annotations: annotations,
+			},
+		}
+	}
+
+	nodes := []corev1.Node{
+		newNode("matching", map[string]string{"pool": "a"}, map[string]string{"reboot-needed": "true"}),
+		newNode("matching_labels_only", map[string]string{"pool": "a"}, map[string]string{"reboot-needed": "false"}),
+		newNode("matching_annotations_only", map[string]string{"pool": "b"}, map[string]string{"reboot-needed": "true"}),
+		newNode("without_labels_and_annotations", nil, nil),
+	}
+
+	labelSelector := labels.NewSelector().Add(*k8sutil.NewRequirementOrDie("pool", selection.In, []string{"a"}))
+	annotationSelector := fields.ParseSelectorOrDie("reboot-needed==true")
+
+	for name, c := range map[string]struct {
+		selector      k8sutil.NodeSelector
+		expectedNodes []string
+	}{
+		"returns_nodes_matching_both_label_and_annotation_selectors": {
+			selector:      k8sutil.NodeSelector{Labels: labelSelector, Annotations: annotationSelector},
+			expectedNodes: []string{"matching"},
+		},
+		"returns_nodes_matching_label_selector_when_no_annotation_selector_is_given": {
+			selector:      k8sutil.NodeSelector{Labels: labelSelector},
+			expectedNodes: []string{"matching", "matching_labels_only"},
+		},
+		"returns_nodes_matching_annotation_selector_when_no_label_selector_is_given": {
+			selector:      k8sutil.NodeSelector{Annotations: annotationSelector},
+			expectedNodes: []string{"matching", "matching_annotations_only"},
+		},
+		"returns_all_nodes_when_no_selectors_are_given": {
+			expectedNodes: []string{
+				"matching", "matching_labels_only", "matching_annotations_only", "without_labels_and_annotations",
+			},
+		},
+	} {
+		c := c
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			matches := k8sutil.FilterNodes(nodes, c.selector)
+
+			if len(matches) != len(c.expectedNodes) {
+				t.Fatalf("Expected %d nodes, got %d: %v", len(c.expectedNodes), len(matches), matches)
+			}
+
+			for i, node := range matches {
+				if node.Name != c.expectedNodes[i] {
+					t.Errorf("Expected node %q at position %d, got %q", c.expectedNodes[i], i, node.Name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package k8sutil
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+
+// TraceTransport returns given round tripper recording each Kubernetes API request made through it as
+// a span created by given tracer provider. Spans are children of spans carried by request contexts, so
+// API calls show up within traced operations. If tracer provider is nil, round tripper is returned as is.
+func TraceTransport(tracerProvider trace.TracerProvider, rt http.RoundTripper) http.RoundTripper {
+	if tracerProvider == nil {
+		return rt
+	}
+
+	return &tracingRoundTripper{RoundTripper: rt, tracer: tracerProvider.Tracer(tracerName)}
+}
+
+type tracingRoundTripper struct {
+	http.RoundTripper
+
+	tracer trace.Tracer
+}
+
+// RoundTrip implements http.RoundTripper interface.
+func (rt *tracingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	verb, resource := requestVerbResource(req)
+
+	ctx, span := rt.tracer.Start(req.Context(), verb+" "+resource,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("kubernetes.verb", verb),
+			attribute.String("kubernetes.resource", resource),
+			attribute.String("http.method", req.Method),
+			attribute.String("http.url", req.URL.String()),
+		),
+	)
+	defer span.End()
+
+	resp, err := rt.RoundTripper.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return resp, err
+	}
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+
+	return resp, nil
+}
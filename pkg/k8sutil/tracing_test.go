@@ -0,0 +1,64 @@
+package k8sutil_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+func Test_Traced_transport_records_Kubernetes_API_requests_as_child_spans(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	}))
+
+	t.Cleanup(server.Close)
+
+	ctx, parent := tracerProvider.Tracer("test").Start(context.Background(), "parent")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, server.URL+"/api/v1/nodes/foo", nil)
+	if err != nil {
+		t.Fatalf("Creating request: %v", err)
+	}
+
+	resp, err := k8sutil.TraceTransport(tracerProvider, http.DefaultTransport).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Unexpected error sending request: %v", err)
+	}
+
+	if err := resp.Body.Close(); err != nil {
+		t.Fatalf("Closing response body: %v", err)
+	}
+
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	span := spans[0]
+
+	if expectedName := "patch nodes"; span.Name() != expectedName {
+		t.Errorf("Expected span name %q, got %q", expectedName, span.Name())
+	}
+
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("Expected request span to be a child of span from request context")
+	}
+
+	if span.Status().Code != codes.Error {
+		t.Errorf("Expected span of failed request to have error status, got %v", span.Status())
+	}
+}
@@ -0,0 +1,93 @@
+package k8sutil
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NodeListWatcher is a subset of corev1client.NodeInterface used by this package for watching nodes.
+type NodeListWatcher interface {
+	List(ctx context.Context, opts metav1.ListOptions) (*corev1.NodeList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+}
+
+// WatchNodesConfig configures which nodes are watched by WatchNodes.
+type WatchNodesConfig struct {
+	// Only nodes matching the selector are watched. Selection is done by the API server, so only
+	// fields supported by it for nodes can be used, e.g. "metadata.name". Nil selects all nodes.
+	FieldSelector fields.Selector
+	// Only nodes matching the selector are watched. Nil selects all nodes.
+	LabelSelector labels.Selector
+	// Interval in which all watched nodes are delivered again, even if they have not changed, so
+	// missed or failed handling eventually gets retried. Zero disables resyncing.
+	ResyncPeriod time.Duration
+}
+
+// NodeHandler handles watched node. Old node is nil when node is delivered for the first time. On resync,
+// old and new node are the same.
+type NodeHandler func(oldNode, newNode *corev1.Node)
+
+// WatchNodes calls given handler for each watched node when it gets added or updated, until given context
+// gets cancelled. Watching happens in the background, WatchNodes only returns error when watching could
+// not be started.
+//
+// Watch is re-established automatically when it expires or gets closed by the API server, without
+// delivering unchanged nodes again. Deleted nodes are not delivered.
+func WatchNodes(ctx context.Context, nodes NodeListWatcher, config WatchNodesConfig, handler NodeHandler) error {
+	setSelectors := func(options *metav1.ListOptions) {
+		if config.FieldSelector != nil {
+			options.FieldSelector = config.FieldSelector.String()
+		}
+
+		if config.LabelSelector != nil {
+			options.LabelSelector = config.LabelSelector.String()
+		}
+	}
+
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			setSelectors(&options)
+
+			return nodes.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			setSelectors(&options)
+
+			return nodes.Watch(ctx, options)
+		},
+	}
+
+	informer := cache.NewSharedInformer(listWatch, &corev1.Node{}, config.ResyncPeriod)
+
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if node, ok := obj.(*corev1.Node); ok {
+				handler(nil, node)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNode, oldOK := oldObj.(*corev1.Node)
+			newNode, newOK := newObj.(*corev1.Node)
+
+			if oldOK && newOK {
+				handler(oldNode, newNode)
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("adding node event handler: %w", err)
+	}
+
+	go informer.Run(ctx.Done())
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package k8sutil_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+const watchTimeout = 10 * time.Second
+
+//nolint:funlen // Just subtests.
+func Test_Watching_nodes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("delivers_added_and_updated_nodes", func(t *testing.T) {
+		t.Parallel()
+
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "testNodeName"}}
+		fakeClient := fake.NewSimpleClientset(node)
+		nc := fakeClient.CoreV1().Nodes()
+
+		ctx, cancel := context.WithTimeout(context.Background(), watchTimeout)
+		t.Cleanup(cancel)
+
+		events := make(chan [2]*corev1.Node, 10)
+
+		err := k8sutil.WatchNodes(ctx, nc, k8sutil.WatchNodesConfig{}, func(oldNode, newNode *corev1.Node) {
+			events <- [2]*corev1.Node{oldNode, newNode}
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error watching nodes: %v", err)
+		}
+
+		added := receiveNodeEvent(ctx, t, events)
+		if added[0] != nil || added[1].Name != node.Name {
+			t.Fatalf("Expected node %q to be delivered as added, got %v", node.Name, added)
+		}
+
+		updatedNode := node.DeepCopy()
+		updatedNode.Annotations = map[string]string{"foo": "bar"}
+
+		if _, err := nc.Update(ctx, updatedNode, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("Updating node: %v", err)
+		}
+
+		updated := receiveNodeEvent(ctx, t, events)
+		if updated[0] == nil || updated[1].Annotations["foo"] != "bar" {
+			t.Fatalf("Expected node %q to be delivered as updated, got %v", node.Name, updated)
+		}
+	})
+
+	t.Run("selects_nodes_using_given_selectors", func(t *testing.T) {
+		t.Parallel()
+
+		fakeClient := fake.NewSimpleClientset()
+
+		restrictions := make(chan k8stesting.ListRestrictions, 10)
+
+		fakeClient.PrependReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if listAction, ok := action.(k8stesting.ListAction); ok {
+				restrictions <- listAction.GetListRestrictions()
+			}
+
+			return false, nil, nil
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), watchTimeout)
+		t.Cleanup(cancel)
+
+		config := k8sutil.WatchNodesConfig{
+			FieldSelector: fields.OneTermEqualSelector("metadata.name", "foo"),
+			LabelSelector: labels.SelectorFromSet(labels.Set{"bar": "baz"}),
+		}
+
+		if err := k8sutil.WatchNodes(ctx, fakeClient.CoreV1().Nodes(), config, func(_, _ *corev1.Node) {}); err != nil {
+			t.Fatalf("Unexpected error watching nodes: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for nodes to be listed")
+		case r := <-restrictions:
+			if r.Fields.String() != config.FieldSelector.String() {
+				t.Errorf("Expected field selector %q, got %q", config.FieldSelector, r.Fields)
+			}
+
+			if r.Labels.String() != config.LabelSelector.String() {
+				t.Errorf("Expected label selector %q, got %q", config.LabelSelector, r.Labels)
+			}
+		}
+	})
+
+	t.Run("restarts_watch_when_it_expires", func(t *testing.T) {
+		t.Parallel()
+
+		fakeClient := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "testNodeName"}})
+
+		var lock sync.Mutex
+
+		watches := 0
+		rewatched := make(chan struct{})
+
+		fakeClient.PrependWatchReactor("nodes", func(action k8stesting.Action) (bool, watch.Interface, error) {
+			lock.Lock()
+			defer lock.Unlock()
+
+			watches++
+
+			switch watches {
+			case 1:
+				// Simulate watch expiring immediately.
+				watcher := watch.NewFake()
+				watcher.Stop()
+
+				return true, watcher, nil
+			case 2:
+				close(rewatched)
+			}
+
+			return false, nil, nil
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), watchTimeout)
+		t.Cleanup(cancel)
+
+		err := k8sutil.WatchNodes(ctx, fakeClient.CoreV1().Nodes(), k8sutil.WatchNodesConfig{}, func(_, _ *corev1.Node) {})
+		if err != nil {
+			t.Fatalf("Unexpected error watching nodes: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for watch to be restarted")
+		case <-rewatched:
+		}
+	})
+}
+
+func receiveNodeEvent(ctx context.Context, t *testing.T, events <-chan [2]*corev1.Node) [2]*corev1.Node {
+	t.Helper()
+
+	select {
+	case <-ctx.Done():
+		t.Fatalf("Timed out waiting for node event")
+	case event := <-events:
+		return event
+	}
+
+	return [2]*corev1.Node{}
+}
@@ -0,0 +1,96 @@
+// Package logging provides log formats for FLUO components, which can be set as klog backend.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap/zapcore"
+	logsjson "k8s.io/component-base/logs/json"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// FormatText is a default klog text format.
+	FormatText = "text"
+
+	// FormatJSON formats every log entry as a single line JSON object.
+	FormatJSON = "json"
+
+	// KeyNode is a key of structured log entries holding name of the node the entry relates to.
+	KeyNode = "node"
+
+	// KeyPhase is a key of structured log entries holding reconciliation step of the operator or
+	// reboot process phase of the agent.
+	KeyPhase = "phase"
+
+	// KeyReconcileID is a key of structured log entries identifying reconciliation of the operator
+	// during which the entry has been logged.
+	KeyReconcileID = "reconcileID"
+
+	// maxVerbosity lets all entries through JSON logger, as verbosity is already checked by klog.
+	maxVerbosity = math.MaxInt8
+)
+
+// Configure sets klog backend producing logs in given format, writing them into given writer. Text format
+// keeps klog default backend.
+//
+// Verbosity of logs is still controlled by klog, also for loggers obtained using klog.FromContext
+// and klog.Background.
+func Configure(format string, w io.Writer) error {
+	switch format {
+	case FormatText:
+	case FormatJSON:
+		klog.SetLoggerWithOptions(NewJSONLogger(w), klog.ContextualLogger(false))
+	default:
+		return fmt.Errorf("unsupported log format %q, expected either %q or %q", format, FormatText, FormatJSON)
+	}
+
+	return nil
+}
+
+// NewJSONLogger creates logger writing log entries as JSON objects, one per line, into a given writer, using
+// the JSON log format of Kubernetes components.
+//
+// Each entry contains time, level, caller and message, followed by given key and value pairs.
+func NewJSONLogger(w io.Writer) logr.Logger {
+	logger, _ := logsjson.NewJSONLogger(maxVerbosity, zapcore.Lock(logsjson.AddNopSync(w)), nil, &zapcore.EncoderConfig{
+		TimeKey:        "ts",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "msg",
+		EncodeTime:     encodeTime,
+		EncodeLevel:    encodeLevel,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   encodeCaller,
+	})
+
+	return logger
+}
+
+// encodeTime encodes time of log entry in UTC using RFC 3339 format.
+func encodeTime(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(t.UTC().Format(time.RFC3339Nano))
+}
+
+// encodeLevel encodes level of log entry as either "info" or "error". Verbosity of info entries
+// is reported separately.
+func encodeLevel(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if level >= zapcore.ErrorLevel {
+		enc.AppendString("error")
+
+		return
+	}
+
+	enc.AppendString("info")
+}
+
+// encodeCaller encodes caller of log entry as file name and line.
+func encodeCaller(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(fmt.Sprintf("%s:%d", filepath.Base(caller.File), caller.Line))
+}
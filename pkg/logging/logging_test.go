@@ -0,0 +1,122 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logging"
+)
+
+//nolint:funlen // Just many sub-tests.
+func Test_JSON_logger(t *testing.T) {
+	t.Parallel()
+
+	t.Run("writes_each_entry_as_single_line_JSON_object", func(t *testing.T) {
+		t.Parallel()
+
+		output := &bytes.Buffer{}
+
+		logger := logging.NewJSONLogger(output).WithName("agent").WithValues("node", "foo")
+
+		logger.Info("Rebooting", "version", "3510.2.6")
+		logger.Error(errors.New("timed out"), "Draining failed")
+
+		lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected 2 lines to be logged, got %d: %q", len(lines), output.String())
+		}
+
+		expectedEntries := []map[string]string{
+			{"level": "info", "logger": "agent", "msg": "Rebooting", "node": "foo", "version": "3510.2.6"},
+			{"level": "error", "logger": "agent", "msg": "Draining failed", "node": "foo", "err": "timed out"},
+		}
+
+		for i, line := range lines {
+			entry := map[string]interface{}{}
+
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("Failed decoding entry %q: %v", line, err)
+			}
+
+			for key, expectedValue := range expectedEntries[i] {
+				if value := entry[key]; value != expectedValue {
+					t.Errorf("Expected key %q of entry %d to be %q, got %q", key, i, expectedValue, value)
+				}
+			}
+
+			if _, ok := entry["ts"]; !ok {
+				t.Errorf("Expected entry %d to have a timestamp", i)
+			}
+
+			if caller, ok := entry["caller"].(string); !ok || !strings.HasPrefix(caller, "logging_test.go:") {
+				t.Errorf("Expected entry %d caller to point to the test file, got %q", i, entry["caller"])
+			}
+		}
+	})
+
+	t.Run("reports_values_which_can_not_be_encoded_as_JSON_as_errors", func(t *testing.T) {
+		t.Parallel()
+
+		output := &bytes.Buffer{}
+
+		logging.NewJSONLogger(output).Info("Message", "channel", make(chan struct{}))
+
+		entry := map[string]interface{}{}
+
+		if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed decoding entry %q: %v", output.String(), err)
+		}
+
+		if _, ok := entry["channelError"].(string); !ok {
+			t.Errorf("Expected encoding error of channel to be reported, got %v", entry)
+		}
+	})
+}
+
+//nolint:paralleltest // Configures global klog backend.
+func Test_Configuring_logging(t *testing.T) {
+	t.Run("with_JSON_format_routes_structured_klog_entries_to_JSON_logger", func(t *testing.T) {
+		output := &bytes.Buffer{}
+
+		if err := logging.Configure(logging.FormatJSON, output); err != nil {
+			t.Fatalf("Unexpected error configuring logging: %v", err)
+		}
+
+		t.Cleanup(klog.ClearLogger)
+
+		logger := klog.FromContext(context.Background()).WithValues(logging.KeyReconcileID, 1)
+		logger.Info("Waiting for annotations", logging.KeyNode, "foo", logging.KeyPhase, "mark_before_reboot")
+		logger.V(10).Info("Not logged with default verbosity")
+
+		entry := map[string]interface{}{}
+
+		if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+			t.Fatalf("Expected exactly one JSON entry, got %q: %v", output.String(), err)
+		}
+
+		expectedEntry := map[string]interface{}{
+			"msg":                  "Waiting for annotations",
+			logging.KeyNode:        "foo",
+			logging.KeyPhase:       "mark_before_reboot",
+			logging.KeyReconcileID: float64(1),
+		}
+
+		for key, expectedValue := range expectedEntry {
+			if value := entry[key]; value != expectedValue {
+				t.Errorf("Expected key %q to be %v, got %v", key, expectedValue, value)
+			}
+		}
+	})
+
+	t.Run("with_unsupported_format_returns_error", func(t *testing.T) {
+		if err := logging.Configure("xml", &bytes.Buffer{}); err == nil {
+			t.Fatalf("Expected error configuring unsupported log format")
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package logind
+
+import (
+	"fmt"
+	"os"
+
+	godbus "github.com/godbus/dbus/v5"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/dbus"
+)
+
+const (
+	// DBusMethodNameReboot is a name of the method to reboot the host immediately.
+	DBusMethodNameReboot = "Reboot"
+	// DBusMethodNameInhibit is a name of the method to take an inhibitor lock.
+	DBusMethodNameInhibit = "Inhibit"
+)
+
+// Rebooter reboots the host and takes inhibitor locks using logind. Unlike login1.Conn from go-systemd,
+// it reports failed reboot requests, so the caller can fall back to other means of rebooting the host.
+type Rebooter struct {
+	conn    dbus.Client
+	manager godbus.BusObject
+}
+
+// NewRebooter creates new rebooter using D-Bus connection created by given connector.
+func NewRebooter(connector dbus.Connector) (*Rebooter, error) {
+	conn, err := dbus.New(connector)
+	if err != nil {
+		return nil, fmt.Errorf("creating D-Bus client: %w", err)
+	}
+
+	return &Rebooter{
+		conn:    conn,
+		manager: conn.Object(DBusDestination, godbus.ObjectPath(DBusPath)),
+	}, nil
+}
+
+// Reboot asks logind to reboot the host immediately.
+func (r *Rebooter) Reboot(askForAuth bool) error {
+	call := r.manager.Call(DBusInterface+"."+DBusMethodNameReboot, 0, askForAuth)
+	if call.Err != nil {
+		return fmt.Errorf("calling %s: %w", DBusMethodNameReboot, dbus.ClassifyError(call.Err))
+	}
+
+	return nil
+}
+
+// Inhibit takes an inhibitor lock. The lock is held until returned file is closed.
+func (r *Rebooter) Inhibit(what, who, why, mode string) (*os.File, error) {
+	var fd godbus.UnixFD
+
+	call := r.manager.Call(DBusInterface+"."+DBusMethodNameInhibit, 0, what, who, why, mode)
+	if call.Err != nil {
+		return nil, fmt.Errorf("calling %s: %w", DBusMethodNameInhibit, dbus.ClassifyError(call.Err))
+	}
+
+	if err := call.Store(&fd); err != nil {
+		return nil, fmt.Errorf("decoding %s reply: %w", DBusMethodNameInhibit, err)
+	}
+
+	return os.NewFile(uintptr(fd), "inhibit"), nil
+}
+
+// Close closes the D-Bus connection used by the rebooter.
+func (r *Rebooter) Close() error {
+	if err := r.conn.Close(); err != nil {
+		return fmt.Errorf("closing D-Bus connection: %w", err)
+	}
+
+	return nil
+}
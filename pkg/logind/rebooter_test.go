@@ -0,0 +1,158 @@
+package logind_test
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	godbus "github.com/godbus/dbus/v5"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/dbus"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logind"
+)
+
+func Test_Rebooting(t *testing.T) {
+	t.Parallel()
+
+	t.Run("calls_logind_reboot_method_with_given_authorization_flag", func(t *testing.T) {
+		t.Parallel()
+
+		calls := map[string][]interface{}{}
+
+		rebooter := rebooterWithCallF(t, func(method string, _ godbus.Flags, args ...interface{}) *godbus.Call {
+			calls[method] = args
+
+			return &godbus.Call{}
+		})
+
+		if err := rebooter.Reboot(true); err != nil {
+			t.Fatalf("Unexpected error rebooting: %v", err)
+		}
+
+		rebootArgs, ok := calls[logind.DBusInterface+"."+logind.DBusMethodNameReboot]
+		if !ok || len(rebootArgs) != 1 || rebootArgs[0] != true {
+			t.Fatalf("Expected reboot to be requested with authorization, got calls: %v", calls)
+		}
+	})
+
+	t.Run("returns_error_when_call_fails", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("test error")
+
+		rebooter := rebooterWithCallF(t, func(string, godbus.Flags, ...interface{}) *godbus.Call {
+			return &godbus.Call{Err: expectedErr}
+		})
+
+		if err := rebooter.Reboot(false); !errors.Is(err, expectedErr) {
+			t.Fatalf("Expected error %q, got %v", expectedErr, err)
+		}
+	})
+}
+
+func Test_Taking_inhibitor_lock(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns_file_descriptor_received_from_logind", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("Creating pipe: %v", err)
+		}
+
+		t.Cleanup(func() {
+			for _, file := range []*os.File{reader, writer} {
+				if err := file.Close(); err != nil {
+					t.Errorf("Closing pipe: %v", err)
+				}
+			}
+		})
+
+		// Returned file takes ownership of the descriptor, so pass a duplicate.
+		fd, err := syscall.Dup(int(writer.Fd()))
+		if err != nil {
+			t.Fatalf("Duplicating file descriptor: %v", err)
+		}
+
+		rebooter := rebooterWithCallF(t, func(method string, _ godbus.Flags, args ...interface{}) *godbus.Call {
+			if method != logind.DBusInterface+"."+logind.DBusMethodNameInhibit {
+				t.Errorf("Unexpected method %q called", method)
+			}
+
+			if len(args) != 4 || args[0] != "shutdown" || args[3] != "block" {
+				t.Errorf("Unexpected arguments: %v", args)
+			}
+
+			return &godbus.Call{Body: []interface{}{godbus.UnixFD(fd)}}
+		})
+
+		lockFile, err := rebooter.Inhibit("shutdown", "test", "testing", "block")
+		if err != nil {
+			t.Fatalf("Unexpected error taking inhibitor lock: %v", err)
+		}
+
+		if lockFile.Fd() != uintptr(fd) {
+			t.Fatalf("Expected file descriptor %d, got %d", fd, lockFile.Fd())
+		}
+
+		if err := lockFile.Close(); err != nil {
+			t.Fatalf("Closing lock file: %v", err)
+		}
+	})
+
+	t.Run("returns_error_when_call_fails", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("test error")
+
+		rebooter := rebooterWithCallF(t, func(string, godbus.Flags, ...interface{}) *godbus.Call {
+			return &godbus.Call{Err: expectedErr}
+		})
+
+		if _, err := rebooter.Inhibit("shutdown", "test", "testing", "block"); !errors.Is(err, expectedErr) {
+			t.Fatalf("Expected error %q, got %v", expectedErr, err)
+		}
+	})
+}
+
+func Test_Creating_rebooter_returns_error_when_connecting_fails(t *testing.T) {
+	t.Parallel()
+
+	_, err := logind.NewRebooter(func() (dbus.Connection, error) {
+		return nil, errors.New("test error")
+	})
+	if !errors.Is(err, dbus.ErrNotConnected) {
+		t.Fatalf("Expected error wrapping %q, got %v", dbus.ErrNotConnected, err)
+	}
+}
+
+func rebooterWithCallF(
+	t *testing.T, callF func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call,
+) *logind.Rebooter {
+	t.Helper()
+
+	mockConnection := &dbus.MockConnection{
+		ObjectF: func(dest string, path godbus.ObjectPath) godbus.BusObject {
+			if dest != logind.DBusDestination || path != logind.DBusPath {
+				t.Errorf("Unexpected object %q at %q requested", dest, path)
+			}
+
+			return &dbus.MockObject{CallF: callF}
+		},
+	}
+
+	rebooter, err := logind.NewRebooter(func() (dbus.Connection, error) { return mockConnection, nil })
+	if err != nil {
+		t.Fatalf("Unexpected error creating rebooter: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := rebooter.Close(); err != nil {
+			t.Errorf("Closing rebooter: %v", err)
+		}
+	})
+
+	return rebooter
+}
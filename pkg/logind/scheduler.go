@@ -0,0 +1,92 @@
+// Package logind provides clients for rebooting the host and for scheduling reboots using logind, which warns
+// logged in users using wall messages before the scheduled reboot.
+package logind
+
+import (
+	"fmt"
+	"time"
+
+	godbus "github.com/godbus/dbus/v5"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/dbus"
+)
+
+const (
+	// DBusPath is an object path used by logind.
+	DBusPath = "/org/freedesktop/login1"
+	// DBusDestination is a bus name of logind service.
+	DBusDestination = "org.freedesktop.login1"
+	// DBusInterface is a logind manager interface name.
+	DBusInterface = DBusDestination + ".Manager"
+	// DBusMethodNameSetWallMessage is a name of the method to configure wall message sent before shutdown.
+	DBusMethodNameSetWallMessage = "SetWallMessage"
+	// DBusMethodNameScheduleShutdown is a name of the method to schedule shutdown at given time.
+	DBusMethodNameScheduleShutdown = "ScheduleShutdown"
+	// DBusMethodNameCancelScheduledShutdown is a name of the method to cancel scheduled shutdown.
+	DBusMethodNameCancelScheduledShutdown = "CancelScheduledShutdown"
+
+	shutdownTypeReboot = "reboot"
+)
+
+// Scheduler schedules reboots using logind.
+type Scheduler struct {
+	conn    dbus.Client
+	manager godbus.BusObject
+}
+
+// NewScheduler creates new scheduler using D-Bus connection created by given connector.
+func NewScheduler(connector dbus.Connector) (*Scheduler, error) {
+	conn, err := dbus.New(connector)
+	if err != nil {
+		return nil, fmt.Errorf("creating D-Bus client: %w", err)
+	}
+
+	return &Scheduler{
+		conn:    conn,
+		manager: conn.Object(DBusDestination, godbus.ObjectPath(DBusPath)),
+	}, nil
+}
+
+// ScheduleReboot schedules the reboot after given delay. Until then, logind periodically sends given
+// message to logged in users using wall.
+func (s *Scheduler) ScheduleReboot(message string, delay time.Duration) error {
+	call := s.manager.Call(DBusInterface+"."+DBusMethodNameSetWallMessage, 0, message, true)
+	if call.Err != nil {
+		return fmt.Errorf("calling %s: %w", DBusMethodNameSetWallMessage, dbus.ClassifyError(call.Err))
+	}
+
+	usec := uint64(time.Now().Add(delay).UnixMicro())
+
+	call = s.manager.Call(DBusInterface+"."+DBusMethodNameScheduleShutdown, 0, shutdownTypeReboot, usec)
+	if call.Err != nil {
+		return fmt.Errorf("calling %s: %w", DBusMethodNameScheduleShutdown, dbus.ClassifyError(call.Err))
+	}
+
+	return nil
+}
+
+// CancelScheduledReboot cancels the scheduled reboot. It returns false when there was no scheduled
+// reboot to cancel, e.g. when it has already been cancelled on the host.
+func (s *Scheduler) CancelScheduledReboot() (bool, error) {
+	call := s.manager.Call(DBusInterface+"."+DBusMethodNameCancelScheduledShutdown, 0)
+	if call.Err != nil {
+		return false, fmt.Errorf("calling %s: %w", DBusMethodNameCancelScheduledShutdown, dbus.ClassifyError(call.Err))
+	}
+
+	cancelled := false
+
+	if err := godbus.Store(call.Body, &cancelled); err != nil {
+		return false, fmt.Errorf("decoding %s reply: %w", DBusMethodNameCancelScheduledShutdown, err)
+	}
+
+	return cancelled, nil
+}
+
+// Close closes the D-Bus connection used by the scheduler.
+func (s *Scheduler) Close() error {
+	if err := s.conn.Close(); err != nil {
+		return fmt.Errorf("closing D-Bus connection: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package logind_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	godbus "github.com/godbus/dbus/v5"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/dbus"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logind"
+)
+
+//nolint:funlen // Just many test cases.
+func Test_Scheduling_reboot(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sets_wall_message_and_schedules_reboot_after_given_delay", func(t *testing.T) {
+		t.Parallel()
+
+		calls := map[string][]interface{}{}
+
+		scheduler := schedulerWithCallF(t, func(method string, _ godbus.Flags, args ...interface{}) *godbus.Call {
+			calls[method] = args
+
+			return &godbus.Call{}
+		})
+
+		delay := 5 * time.Minute
+		before := time.Now().Add(delay)
+
+		if err := scheduler.ScheduleReboot("test message", delay); err != nil {
+			t.Fatalf("Unexpected error scheduling reboot: %v", err)
+		}
+
+		wallMessageArgs := calls[logind.DBusInterface+"."+logind.DBusMethodNameSetWallMessage]
+		if len(wallMessageArgs) != 2 || wallMessageArgs[0] != "test message" || wallMessageArgs[1] != true {
+			t.Fatalf("Expected wall message to be set and enabled, got arguments: %v", wallMessageArgs)
+		}
+
+		shutdownArgs := calls[logind.DBusInterface+"."+logind.DBusMethodNameScheduleShutdown]
+		if len(shutdownArgs) != 2 || shutdownArgs[0] != "reboot" {
+			t.Fatalf("Expected reboot to be scheduled, got arguments: %v", shutdownArgs)
+		}
+
+		usec, ok := shutdownArgs[1].(uint64)
+		if !ok {
+			t.Fatalf("Expected time of the reboot as uint64, got %T", shutdownArgs[1])
+		}
+
+		if scheduledAt := time.UnixMicro(int64(usec)); scheduledAt.Before(before) {
+			t.Fatalf("Expected reboot to be scheduled after %v, got %v", before, scheduledAt)
+		}
+	})
+
+	t.Run("returns_error_when", func(t *testing.T) {
+		t.Parallel()
+
+		for n, failingMethod := range map[string]string{
+			"setting_wall_message_fails": logind.DBusMethodNameSetWallMessage,
+			"scheduling_shutdown_fails":  logind.DBusMethodNameScheduleShutdown,
+		} {
+			failingMethod := failingMethod
+
+			t.Run(n, func(t *testing.T) {
+				t.Parallel()
+
+				expectedErr := errors.New("test error")
+
+				scheduler := schedulerWithCallF(t, func(method string, _ godbus.Flags, _ ...interface{}) *godbus.Call {
+					if method == logind.DBusInterface+"."+failingMethod {
+						return &godbus.Call{Err: expectedErr}
+					}
+
+					return &godbus.Call{}
+				})
+
+				if err := scheduler.ScheduleReboot("", time.Minute); !errors.Is(err, expectedErr) {
+					t.Fatalf("Expected error %q, got %v", expectedErr, err)
+				}
+			})
+		}
+	})
+}
+
+func Test_Creating_scheduler_returns_error_when_connecting_fails(t *testing.T) {
+	t.Parallel()
+
+	_, err := logind.NewScheduler(func() (dbus.Connection, error) {
+		return nil, errors.New("test error")
+	})
+	if !errors.Is(err, dbus.ErrNotConnected) {
+		t.Fatalf("Expected error wrapping %q, got %v", dbus.ErrNotConnected, err)
+	}
+}
+
+func Test_Cancelling_scheduled_reboot(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns_whether_scheduled_reboot_has_been_cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		for _, expected := range []bool{true, false} {
+			expected := expected
+
+			scheduler := schedulerWithCallF(t, func(method string, _ godbus.Flags, _ ...interface{}) *godbus.Call {
+				if method != logind.DBusInterface+"."+logind.DBusMethodNameCancelScheduledShutdown {
+					t.Errorf("Unexpected method %q called", method)
+				}
+
+				return &godbus.Call{Body: []interface{}{expected}}
+			})
+
+			cancelled, err := scheduler.CancelScheduledReboot()
+			if err != nil {
+				t.Fatalf("Unexpected error cancelling scheduled reboot: %v", err)
+			}
+
+			if cancelled != expected {
+				t.Fatalf("Expected cancelled to be %v, got %v", expected, cancelled)
+			}
+		}
+	})
+
+	t.Run("returns_error_when_call_fails", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("test error")
+
+		scheduler := schedulerWithCallF(t, func(string, godbus.Flags, ...interface{}) *godbus.Call {
+			return &godbus.Call{Err: expectedErr}
+		})
+
+		if _, err := scheduler.CancelScheduledReboot(); !errors.Is(err, expectedErr) {
+			t.Fatalf("Expected error %q, got %v", expectedErr, err)
+		}
+	})
+}
+
+func schedulerWithCallF(
+	t *testing.T, callF func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call,
+) *logind.Scheduler {
+	t.Helper()
+
+	mockConnection := &dbus.MockConnection{
+		ObjectF: func(dest string, path godbus.ObjectPath) godbus.BusObject {
+			if dest != logind.DBusDestination || path != logind.DBusPath {
+				t.Errorf("Unexpected object %q at %q requested", dest, path)
+			}
+
+			return &dbus.MockObject{CallF: callF}
+		},
+	}
+
+	scheduler, err := logind.NewScheduler(func() (dbus.Connection, error) { return mockConnection, nil })
+	if err != nil {
+		t.Fatalf("Unexpected error creating scheduler: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := scheduler.Close(); err != nil {
+			t.Errorf("Closing scheduler: %v", err)
+		}
+	})
+
+	return scheduler
+}
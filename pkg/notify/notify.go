@@ -0,0 +1,130 @@
+// Package notify provides notifications about node reboot process.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Type describes stage of the reboot process given notification is about.
+type Type string
+
+const (
+	// TypeRebootScheduled is a type of notification sent when node gets scheduled for rebooting.
+	TypeRebootScheduled Type = "RebootScheduled"
+	// TypeRebootCompleted is a type of notification sent when node finishes reboot process.
+	TypeRebootCompleted Type = "RebootCompleted"
+	// TypeRebootFailed is a type of notification sent when reboot process of a node runs into problems.
+	TypeRebootFailed Type = "RebootFailed"
+)
+
+const (
+	// FormatJSON sends notification as generic JSON object.
+	FormatJSON = "json"
+	// FormatSlack sends notification as Slack-compatible message payload.
+	FormatSlack = "slack"
+
+	defaultTimeout = 10 * time.Second
+)
+
+// Notification describes an event in the reboot process of a node.
+type Notification struct {
+	Type    Type      `json:"type"`
+	Node    string    `json:"node"`
+	Reason  string    `json:"reason"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// Notifier sends notifications about node reboot process.
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// WebhookConfig configures a webhook notifier.
+type WebhookConfig struct {
+	// URL to which notifications are posted.
+	URL string
+	// Payload format, either FormatJSON or FormatSlack. Defaults to FormatJSON.
+	Format string
+	// Timeout for a single request. Defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+type webhook struct {
+	url    string
+	format string
+	client *http.Client
+}
+
+// NewWebhook creates a notifier posting notifications to a given webhook URL.
+func NewWebhook(config WebhookConfig) (Notifier, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("webhook URL must not be empty")
+	}
+
+	format := config.Format
+	if format == "" {
+		format = FormatJSON
+	}
+
+	if format != FormatJSON && format != FormatSlack {
+		return nil, fmt.Errorf("unsupported format %q, expected either %q or %q", format, FormatJSON, FormatSlack)
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	return &webhook{
+		url:    config.URL,
+		format: format,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// slackMessage is a minimal Slack-compatible message payload.
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// Notify posts given notification to the webhook.
+func (w *webhook) Notify(ctx context.Context, notification Notification) error {
+	var payload interface{} = notification
+
+	if w.format == FormatSlack {
+		payload = slackMessage{
+			Text: fmt.Sprintf("[%s] Node %q: %s", notification.Type, notification.Node, notification.Message),
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting notification: %w", err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck // Nothing to do with the error.
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected response status %q", resp.Status)
+	}
+
+	return nil
+}
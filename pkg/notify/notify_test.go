@@ -0,0 +1,133 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/notify"
+)
+
+func Test_Creating_new_webhook_notifier_fails_when(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]notify.WebhookConfig{
+		"URL_is_not_set":              {Format: notify.FormatJSON},
+		"unsupported_format_is_given": {URL: "http://example.com", Format: "xml"},
+	}
+
+	for name, config := range cases {
+		config := config
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := notify.NewWebhook(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+	}
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Webhook_notifier(t *testing.T) {
+	t.Parallel()
+
+	notification := notify.Notification{
+		Type:    notify.TypeRebootScheduled,
+		Node:    "foo",
+		Reason:  "ScheduledForReboot",
+		Message: "Node scheduled for rebooting",
+		Time:    time.Now().UTC().Truncate(time.Second),
+	}
+
+	t.Run("posts_notification_as_JSON_object_by_default", func(t *testing.T) {
+		t.Parallel()
+
+		body := make(chan []byte, 1)
+
+		notifier, err := notify.NewWebhook(notify.WebhookConfig{URL: testServer(t, http.StatusOK, body).URL})
+		if err != nil {
+			t.Fatalf("Unexpected error creating notifier: %v", err)
+		}
+
+		if err := notifier.Notify(context.Background(), notification); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		received := notify.Notification{}
+		if err := json.Unmarshal(<-body, &received); err != nil {
+			t.Fatalf("Failed decoding received payload: %v", err)
+		}
+
+		if received != notification {
+			t.Fatalf("Expected notification %+v, got %+v", notification, received)
+		}
+	})
+
+	t.Run("posts_notification_as_Slack_message_when_configured", func(t *testing.T) {
+		t.Parallel()
+
+		body := make(chan []byte, 1)
+
+		notifier, err := notify.NewWebhook(notify.WebhookConfig{
+			URL:    testServer(t, http.StatusOK, body).URL,
+			Format: notify.FormatSlack,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error creating notifier: %v", err)
+		}
+
+		if err := notifier.Notify(context.Background(), notification); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		received := map[string]string{}
+		if err := json.Unmarshal(<-body, &received); err != nil {
+			t.Fatalf("Failed decoding received payload: %v", err)
+		}
+
+		if !strings.Contains(received["text"], notification.Message) {
+			t.Fatalf("Expected message text to contain %q, got %q", notification.Message, received["text"])
+		}
+	})
+
+	t.Run("returns_error_when_webhook_responds_with_error", func(t *testing.T) {
+		t.Parallel()
+
+		notifier, err := notify.NewWebhook(notify.WebhookConfig{
+			URL: testServer(t, http.StatusInternalServerError, make(chan []byte, 1)).URL,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error creating notifier: %v", err)
+		}
+
+		if err := notifier.Notify(context.Background(), notification); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+}
+
+func testServer(t *testing.T, statusCode int, body chan<- []byte) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("Failed reading request body: %v", err)
+		}
+
+		body <- data
+
+		w.WriteHeader(statusCode)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
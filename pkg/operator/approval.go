@@ -0,0 +1,158 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logging"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/rebootstate"
+)
+
+//nolint:godot // TODO: Complaining about not capitalized comments for variables. We should get rid of those completely.
+var (
+	// approvedSelector is a selector for the annotations expected to be on a node, which has been
+	// approved for rebooting, but update-agent has not started rebooting it yet.
+	approvedSelector = fields.ParseSelectorOrDie(constants.AnnotationOkToReboot + "==" + constants.True +
+		"," + constants.AnnotationRebootNeeded + "==" + constants.True +
+		"," + constants.AnnotationRebootInProgress + "!=" + constants.True)
+
+	// scheduledRebootSelector is a selector for the annotations expected to be on a node, which is
+	// being rebooted by update-agent, possibly with the reboot scheduled to warn logged in users.
+	scheduledRebootSelector = fields.ParseSelectorOrDie(constants.AnnotationOkToReboot + "==" + constants.True +
+		"," + constants.AnnotationRebootInProgress + "==" + constants.True +
+		"," + constants.AnnotationEmergencyReboot + "!=" + constants.True)
+)
+
+// approvalTimeoutExceeded checks if given node has been approved for rebooting for longer than
+// configured reboot approval timeout, without update-agent starting the reboot.
+//
+// If reboot approval timeout is not configured, false is always returned.
+func (k *Kontroller) approvalTimeoutExceeded(ctx context.Context, node *corev1.Node) bool {
+	if k.rebootApprovalTimeout == 0 || !approvedSelector.Matches(fields.Set(node.Annotations)) {
+		return false
+	}
+
+	return k.timeSinceAnnotation(ctx, node, constants.AnnotationRebootApprovedTime) > k.rebootApprovalTimeout
+}
+
+// waitingForApprovalRetry checks if reboot approval of given node has been recently revoked
+// and node should not be scheduled for rebooting yet.
+func (k *Kontroller) waitingForApprovalRetry(ctx context.Context, node *corev1.Node) bool {
+	if _, ok := node.Annotations[constants.AnnotationRebootApprovalRevokedTime]; !ok {
+		return false
+	}
+
+	return k.timeSinceAnnotation(ctx, node, constants.AnnotationRebootApprovalRevokedTime) <= k.rebootApprovalTimeout
+}
+
+// revokeStaleApprovals revokes reboot approvals of given nodes, which update-agent has not acted upon
+// within configured reboot approval timeout, so they no longer count towards the rebooting capacity.
+//
+// If there is an error updating any of the nodes, an error is immediately returned.
+func (k *Kontroller) revokeStaleApprovals(ctx context.Context, nodelist *corev1.NodeList) error {
+	for i := range nodelist.Items {
+		node := &nodelist.Items[i]
+
+		if !k.approvalTimeoutExceeded(ctx, node) {
+			continue
+		}
+
+		klog.FromContext(ctx).Info("Node did not start rebooting in time since approval, revoking reboot approval",
+			logging.KeyNode, node.Name, "timeout", k.rebootApprovalTimeout)
+
+		err := k.updateNode(ctx, node.Name, func(node *corev1.Node) {
+			rebootstate.NodeRebootState{OkToReboot: rebootstate.Bool(false)}.ApplyTo(node)
+
+			node.Annotations[constants.AnnotationRebootApprovalRevokedTime] = time.Now().UTC().Format(time.RFC3339)
+
+			delete(node.Annotations, constants.AnnotationRebootApprovedTime)
+			delete(node.Annotations, constants.AnnotationRebootStartedTime)
+			delete(node.Annotations, constants.AnnotationVersionBeforeReboot)
+			delete(node.Annotations, constants.AnnotationRebootNeededSinceBeforeReboot)
+		})
+		if err != nil {
+			return fmt.Errorf("revoking reboot approval of node %q: %w", node.Name, err)
+		}
+
+		// Reflect the update in given list, so the node no longer counts as rebooting.
+		node.Annotations[constants.AnnotationOkToReboot] = constants.False
+
+		k.nodeEventf(node.Name, corev1.EventTypeWarning, EventReasonRebootApprovalRevoked,
+			"Agent did not start rebooting within %v since approval, revoking reboot approval", k.rebootApprovalTimeout)
+	}
+
+	return nil
+}
+
+// scheduledRebootNotAllowed returns the reason why given node, which has the reboot scheduled by
+// update-agent, should no longer reboot, e.g. because blackout window has started.
+//
+// If node has no pending scheduled reboot or rebooting it is still allowed, empty string is returned.
+func (k *Kontroller) scheduledRebootNotAllowed(ctx context.Context, node *corev1.Node) string {
+	if _, ok := node.Annotations[constants.AnnotationRebootScheduledTime]; !ok {
+		return ""
+	}
+
+	if !scheduledRebootSelector.Matches(fields.Set(node.Annotations)) ||
+		k.timeSinceAnnotation(ctx, node, constants.AnnotationRebootScheduledTime) >= 0 {
+		return ""
+	}
+
+	switch {
+	case k.insideBlackoutWindow():
+		return "blackout window is active"
+	case k.paused:
+		return "operator is paused"
+	case node.Annotations[constants.AnnotationRebootPaused] == constants.True,
+		node.Annotations[constants.AnnotationAgentRebootPaused] == constants.True:
+		return "rebooting the node is paused"
+	default:
+		return ""
+	}
+}
+
+// revokeScheduledReboots revokes reboot approvals of given nodes, which have the reboot scheduled by
+// update-agent, when rebooting them is no longer allowed. Update-agent then cancels the scheduled reboot
+// and waits for the approval again.
+//
+// If there is an error updating any of the nodes, an error is immediately returned.
+func (k *Kontroller) revokeScheduledReboots(ctx context.Context, nodelist *corev1.NodeList) error {
+	for i := range nodelist.Items {
+		node := &nodelist.Items[i]
+
+		reason := k.scheduledRebootNotAllowed(ctx, node)
+		if reason == "" {
+			continue
+		}
+
+		klog.FromContext(ctx).Info("Rebooting node is no longer allowed, revoking approval of scheduled reboot",
+			logging.KeyNode, node.Name, "reason", reason)
+
+		err := k.updateNode(ctx, node.Name, func(node *corev1.Node) {
+			rebootstate.NodeRebootState{OkToReboot: rebootstate.Bool(false)}.ApplyTo(node)
+
+			node.Annotations[constants.AnnotationRebootApprovalRevokedTime] = time.Now().UTC().Format(time.RFC3339)
+
+			delete(node.Annotations, constants.AnnotationRebootApprovedTime)
+			delete(node.Annotations, constants.AnnotationRebootStartedTime)
+			delete(node.Annotations, constants.AnnotationVersionBeforeReboot)
+			delete(node.Annotations, constants.AnnotationRebootNeededSinceBeforeReboot)
+		})
+		if err != nil {
+			return fmt.Errorf("revoking reboot approval of node %q: %w", node.Name, err)
+		}
+
+		node.Annotations[constants.AnnotationOkToReboot] = constants.False
+
+		k.nodeEventf(node.Name, corev1.EventTypeNormal, EventReasonRebootApprovalRevoked,
+			"Rebooting is no longer allowed, as %s, revoking approval of scheduled reboot", reason)
+	}
+
+	return nil
+}
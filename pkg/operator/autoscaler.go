@@ -0,0 +1,103 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultClusterAutoscalerStatusConfigMap is a default namespace and name of the ConfigMap
+	// where cluster-autoscaler publishes its status.
+	DefaultClusterAutoscalerStatusConfigMap = "kube-system/cluster-autoscaler-status"
+
+	// ClusterAutoscalerStatusConfigMapKey is a key in cluster-autoscaler status ConfigMap
+	// under which the status is stored.
+	ClusterAutoscalerStatusConfigMapKey = "status"
+
+	// nodeRegistrationSettlePeriod is a time since node registration during which node is considered
+	// to be added by an ongoing scale-up.
+	nodeRegistrationSettlePeriod = 10 * time.Minute
+)
+
+// Taints used by cluster-autoscaler to mark nodes selected for scale-down.
+const (
+	// toBeDeletedTaint is added to nodes which are being removed by cluster-autoscaler.
+	toBeDeletedTaint = "ToBeDeletedByClusterAutoscaler"
+
+	// deletionCandidateTaint is added to nodes which are candidates for removal by cluster-autoscaler.
+	deletionCandidateTaint = "DeletionCandidateOfClusterAutoscaler"
+)
+
+// activeScalingRegexp matches scale-up in progress or scale-down candidates in cluster-autoscaler status,
+// both in the human-readable format and in the YAML format used by newer cluster-autoscaler versions.
+var activeScalingRegexp = regexp.MustCompile(
+	`(?i)(scaleUp:\s*(status:\s*)?InProgress|scaleDown:\s*(status:\s*)?CandidatesPresent)`)
+
+// parseNamespacedName parses object reference in namespace/name format.
+func parseNamespacedName(value string) (types.NamespacedName, error) {
+	parts := strings.Split(value, "/")
+
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" { //nolint:gomnd // Namespace and name.
+		return types.NamespacedName{}, fmt.Errorf("expected namespace/name format, got %q", value)
+	}
+
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, nil
+}
+
+// clusterScaling returns reason why cluster is considered to be actively scaling. If cluster
+// is not scaling, empty reason is returned.
+//
+// Cluster is considered to be scaling when cluster-autoscaler status reports scale-up in
+// progress or scale-down candidates, or when given nodes are being registered or removed.
+func (k *Kontroller) clusterScaling(ctx context.Context, nodelist *corev1.NodeList) (string, error) {
+	for _, node := range nodelist.Items {
+		if node.DeletionTimestamp != nil {
+			return fmt.Sprintf("node %q is being removed", node.Name), nil
+		}
+
+		if time.Since(node.CreationTimestamp.Time) < nodeRegistrationSettlePeriod {
+			return fmt.Sprintf("node %q has been registered recently", node.Name), nil
+		}
+	}
+
+	configMap, err := k.kc.CoreV1().ConfigMaps(k.clusterAutoscalerStatus.Namespace).Get(
+		ctx, k.clusterAutoscalerStatus.Name, metav1.GetOptions{})
+
+	switch {
+	case apierrors.IsNotFound(err):
+		klog.FromContext(ctx).V(4).Info("Cluster-autoscaler status ConfigMap not found, assuming cluster is not scaling",
+			"configMap", k.clusterAutoscalerStatus.String())
+
+		return "", nil
+	case err != nil:
+		return "", fmt.Errorf("getting cluster-autoscaler status ConfigMap %q: %w", k.clusterAutoscalerStatus, err)
+	}
+
+	if match := activeScalingRegexp.FindString(configMap.Data[ClusterAutoscalerStatusConfigMapKey]); match != "" {
+		return fmt.Sprintf("cluster-autoscaler reports %q", strings.Join(strings.Fields(match), " ")), nil
+	}
+
+	return "", nil
+}
+
+// scaleDownTaint returns key of the cluster-autoscaler taint marking a given node for scale-down.
+//
+// If node is not marked for scale-down, empty string is returned.
+func scaleDownTaint(node *corev1.Node) string {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == toBeDeletedTaint || taint.Key == deletionCandidateTaint {
+			return taint.Key
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,74 @@
+package operator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	blackoutWindowDateLayout = "2006-01-02"
+	blackoutWindowSeparator  = "/"
+)
+
+// ParseBlackoutWindow parses blackout window specified as start and end separated by a slash.
+//
+// Start and end can be either RFC 3339 timestamps or dates in YYYY-MM-DD format. Dates are
+// interpreted in UTC. If end is a date, the whole day is included in the window.
+//
+// E.g. '2023-11-20/2023-11-27' or '2023-12-29T18:00:00Z/2024-01-02T08:00:00Z'.
+func ParseBlackoutWindow(window string) (*Period, error) {
+	//nolint:gomnd // Start and end.
+	startAndEnd := strings.SplitN(window, blackoutWindowSeparator, 2)
+
+	//nolint:gomnd // Start and end.
+	if len(startAndEnd) != 2 {
+		return nil, fmt.Errorf("expected start and end separated by %q, got %q", blackoutWindowSeparator, window)
+	}
+
+	start, _, err := parseBlackoutWindowTime(startAndEnd[0])
+	if err != nil {
+		return nil, fmt.Errorf("parsing start: %w", err)
+	}
+
+	end, isDate, err := parseBlackoutWindowTime(startAndEnd[1])
+	if err != nil {
+		return nil, fmt.Errorf("parsing end: %w", err)
+	}
+
+	if isDate {
+		end = end.AddDate(0, 0, 1)
+	}
+
+	if !end.After(start) {
+		return nil, fmt.Errorf("end must be after start")
+	}
+
+	return &Period{
+		Start: start,
+		End:   end,
+	}, nil
+}
+
+// parseBlackoutWindowTime parses either RFC 3339 timestamp or a date. It also returns true if
+// given value was a date.
+func parseBlackoutWindowTime(value string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+
+	if t, err := time.Parse(blackoutWindowDateLayout, value); err == nil {
+		return t, true, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("expected date in %q format or RFC 3339 timestamp, got %q",
+			blackoutWindowDateLayout, value)
+	}
+
+	return t, false, nil
+}
+
+// Contains checks if given time is within the period.
+func (p *Period) Contains(ref time.Time) bool {
+	return !ref.Before(p.Start) && ref.Before(p.End)
+}
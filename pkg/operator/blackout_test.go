@@ -0,0 +1,114 @@
+package operator_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/operator"
+)
+
+//nolint:funlen // Just many test cases.
+func Test_Parsing_blackout_window(t *testing.T) {
+	t.Parallel()
+
+	t.Run("succeeds_with", func(t *testing.T) {
+		t.Parallel()
+
+		for name, testCase := range map[string]struct {
+			window        string
+			expectedStart time.Time
+			expectedEnd   time.Time
+		}{
+			"dates_including_whole_end_day": {
+				window:        "2023-11-20/2023-11-26",
+				expectedStart: time.Date(2023, time.November, 20, 0, 0, 0, 0, time.UTC),
+				expectedEnd:   time.Date(2023, time.November, 27, 0, 0, 0, 0, time.UTC),
+			},
+			"RFC_3339_timestamps": {
+				window:        "2023-12-29T18:00:00Z/2024-01-02T08:00:00Z",
+				expectedStart: time.Date(2023, time.December, 29, 18, 0, 0, 0, time.UTC),
+				expectedEnd:   time.Date(2024, time.January, 2, 8, 0, 0, 0, time.UTC),
+			},
+			"date_and_RFC_3339_timestamp": {
+				window:        "2023-12-29/2024-01-02T08:00:00Z",
+				expectedStart: time.Date(2023, time.December, 29, 0, 0, 0, 0, time.UTC),
+				expectedEnd:   time.Date(2024, time.January, 2, 8, 0, 0, 0, time.UTC),
+			},
+			"single_day": {
+				window:        "2023-12-24/2023-12-24",
+				expectedStart: time.Date(2023, time.December, 24, 0, 0, 0, 0, time.UTC),
+				expectedEnd:   time.Date(2023, time.December, 25, 0, 0, 0, 0, time.UTC),
+			},
+		} {
+			testCase := testCase
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				window, err := operator.ParseBlackoutWindow(testCase.window)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				if !window.Start.Equal(testCase.expectedStart) {
+					t.Fatalf("Expected start %v, got %v", testCase.expectedStart, window.Start)
+				}
+
+				if !window.End.Equal(testCase.expectedEnd) {
+					t.Fatalf("Expected end %v, got %v", testCase.expectedEnd, window.End)
+				}
+			})
+		}
+	})
+
+	t.Run("fails_when", func(t *testing.T) {
+		t.Parallel()
+
+		for name, window := range map[string]string{
+			"end_is_missing":         "2023-11-20",
+			"end_is_before_start":    "2023-11-20T00:00:00Z/2023-11-19T00:00:00Z",
+			"end_is_equal_to_start":  "2023-11-20T00:00:00Z/2023-11-20T00:00:00Z",
+			"start_has_invalid_date": "2023-13-20/2023-11-27",
+			"end_has_invalid_format": "2023-11-20/next week",
+		} {
+			window := window
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				if _, err := operator.ParseBlackoutWindow(window); err == nil {
+					t.Fatalf("Expected error parsing %q", window)
+				}
+			})
+		}
+	})
+}
+
+func Test_Blackout_window_contains(t *testing.T) {
+	t.Parallel()
+
+	window, err := operator.ParseBlackoutWindow("2023-11-20/2023-11-26")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for name, testCase := range map[string]struct {
+		ref      time.Time
+		expected bool
+	}{
+		"start":              {ref: window.Start, expected: true},
+		"time_inside_window": {ref: window.Start.Add(time.Hour), expected: true},
+		"end":                {ref: window.End, expected: false},
+		"time_before_start":  {ref: window.Start.Add(-time.Second), expected: false},
+	} {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if contains := window.Contains(testCase.ref); contains != testCase.expected {
+				t.Fatalf("Expected %v, got %v", testCase.expected, contains)
+			}
+		})
+	}
+}
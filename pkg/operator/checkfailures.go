@@ -0,0 +1,58 @@
+package operator
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logging"
+)
+
+// failedCheck identifies annotation of a node, which has been set to false by a reboot check.
+type failedCheck struct {
+	checksType string
+	node       string
+	annotation string
+}
+
+// reportFailedChecks reports annotations waited for by given reboot checks, which are set to false on given
+// nodes, indicating that the check failed. Failures are reported using a metric and a Warning event.
+//
+// Each failure is reported once, until the annotation changes its value or gets removed, e.g. when node
+// gets scheduled for rebooting again.
+func (k *Kontroller) reportFailedChecks(ctx context.Context, opt checkRebootOptions, nodes []corev1.Node) {
+	stillFailed := map[failedCheck]struct{}{}
+
+	for _, node := range nodes {
+		for _, annotation := range opt.annotations {
+			if node.Annotations[annotation] != constants.False {
+				continue
+			}
+
+			check := failedCheck{checksType: opt.checksType, node: node.Name, annotation: annotation}
+			stillFailed[check] = struct{}{}
+
+			if _, reported := k.failedChecks[check]; reported {
+				continue
+			}
+
+			k.failedChecks[check] = struct{}{}
+
+			k.metrics.rebootCheckFailures.WithLabelValues(opt.checksType, annotation).Inc()
+
+			klog.FromContext(ctx).Info("Reboot check failed", logging.KeyNode, node.Name,
+				"type", opt.checksType, "annotation", annotation)
+
+			k.nodeEventf(node.Name, corev1.EventTypeWarning, opt.checkFailedReason,
+				"Annotation %q of %s checks has been set to %q, check failed", annotation, opt.checksType, constants.False)
+		}
+	}
+
+	for check := range k.failedChecks {
+		if _, ok := stillFailed[check]; !ok && check.checksType == opt.checksType {
+			delete(k.failedChecks, check)
+		}
+	}
+}
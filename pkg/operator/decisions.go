@@ -0,0 +1,159 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logging"
+)
+
+const (
+	defaultRebootDecisionsLimit = 20
+
+	// DecisionNotScheduled is recorded when node needs a reboot, but it is not scheduled for rebooting.
+	// Other decisions are recorded using reasons of node events, e.g. EventReasonScheduledForReboot.
+	DecisionNotScheduled = "NotScheduled"
+)
+
+// Reasons of DecisionNotScheduled decisions.
+const (
+	DecisionReasonBlackoutWindow      = "BlackoutWindow"
+	DecisionReasonPaused              = "Paused"
+	DecisionReasonOutsideRebootWindow = "OutsideRebootWindow"
+	DecisionReasonClusterScaling      = "ClusterScaling"
+	DecisionReasonExcludedTaint       = "ExcludedTaint"
+	DecisionReasonScaleDown           = "ScaleDown"
+	DecisionReasonBeforeRebootRetry   = "WaitingForBeforeRebootRetry"
+	DecisionReasonApprovalRetry       = "WaitingForApprovalRetry"
+	DecisionReasonNoRebootingCapacity = "NoRebootingCapacity"
+	DecisionReasonNoTopologyCapacity  = "NoTopologyCapacity"
+	DecisionReasonPodDisruptionBudget = "PodDisruptionBudget"
+)
+
+// RebootDecision describes a single decision the operator made about a node.
+type RebootDecision struct {
+	Time time.Time `json:"time"`
+	// Either reason of the node event emitted for the decision, e.g. ScheduledForReboot, or NotScheduled.
+	Decision string `json:"decision"`
+	// Why node has not been scheduled for rebooting. Only set for NotScheduled decisions.
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message"`
+}
+
+// recordDecision queues given decision about a node with a given name to be stored in the reboot decisions
+// ConfigMap at the end of the reconciliation. Decision which repeats previous decision about the node,
+// e.g. when node keeps being skipped for the same reason, is not recorded again.
+//
+// If reboot decisions ConfigMap is not configured, nothing is done.
+func (k *Kontroller) recordDecision(nodeName, decision, reason, message string) {
+	if k.rebootDecisionsConfigMap == "" {
+		return
+	}
+
+	key := decision + "/" + reason
+	if k.lastDecisions[nodeName] == key {
+		return
+	}
+
+	k.lastDecisions[nodeName] = key
+
+	k.pendingDecisions[nodeName] = append(k.pendingDecisions[nodeName], RebootDecision{
+		Time:     time.Now().UTC(),
+		Decision: decision,
+		Reason:   reason,
+		Message:  message,
+	})
+}
+
+// notScheduled records that given nodes are not scheduled for rebooting for a given reason.
+func (k *Kontroller) notScheduled(nodes []corev1.Node, reason, message string) {
+	for i := range nodes {
+		k.recordDecision(nodes[i].Name, DecisionNotScheduled, reason, message)
+	}
+}
+
+// storeDecisions appends decisions recorded during the reconciliation to the decisions of each node stored
+// in a ConfigMap. Only configured number of most recent decisions is kept for each node.
+//
+// Decisions which could not be stored are kept and stored with decisions of the next reconciliation.
+func (k *Kontroller) storeDecisions(ctx context.Context) {
+	if len(k.pendingDecisions) == 0 {
+		return
+	}
+
+	if err := k.appendDecisions(ctx); err != nil {
+		klog.FromContext(ctx).Error(err, "Failed storing reboot decisions")
+
+		return
+	}
+
+	k.pendingDecisions = map[string][]RebootDecision{}
+}
+
+func (k *Kontroller) appendDecisions(ctx context.Context) error {
+	configMaps := k.kc.CoreV1().ConfigMaps(k.namespace)
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		configMap, err := configMaps.Get(ctx, k.rebootDecisionsConfigMap, metav1.GetOptions{})
+
+		exists := true
+
+		switch {
+		case apierrors.IsNotFound(err):
+			exists = false
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      k.rebootDecisionsConfigMap,
+					Namespace: k.namespace,
+				},
+			}
+		case err != nil:
+			return fmt.Errorf("getting ConfigMap %q: %w", k.rebootDecisionsConfigMap, err)
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+
+		for nodeName, pending := range k.pendingDecisions {
+			decisions := []RebootDecision{}
+
+			if value, ok := configMap.Data[nodeName]; ok {
+				if err := json.Unmarshal([]byte(value), &decisions); err != nil {
+					klog.FromContext(ctx).Error(err, "Discarding malformed reboot decisions", logging.KeyNode, nodeName)
+
+					decisions = []RebootDecision{}
+				}
+			}
+
+			decisions = append(decisions, pending...)
+
+			if len(decisions) > k.rebootDecisionsLimit {
+				decisions = decisions[len(decisions)-k.rebootDecisionsLimit:]
+			}
+
+			value, err := json.Marshal(decisions)
+			if err != nil {
+				return fmt.Errorf("encoding reboot decisions: %w", err)
+			}
+
+			configMap.Data[nodeName] = string(value)
+		}
+
+		if exists {
+			_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+		} else {
+			_, err = configMaps.Create(ctx, configMap, metav1.CreateOptions{})
+		}
+
+		return err
+	})
+}
@@ -0,0 +1,144 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logging"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/notify"
+)
+
+const (
+	eventSourceComponent = "update-operator"
+
+	// EventReasonScheduledForReboot is a reason of the event emitted when node gets scheduled for
+	// rebooting and before-reboot checks are started.
+	EventReasonScheduledForReboot = "ScheduledForReboot"
+
+	// EventReasonRebootCancelled is a reason of the event emitted when node scheduled for rebooting
+	// no longer needs a reboot.
+	EventReasonRebootCancelled = "RebootCancelled"
+
+	// EventReasonBeforeRebootTimedOut is a reason of the event emitted when node scheduled for rebooting
+	// did not get all before-reboot annotations within configured timeout and it gets unscheduled.
+	EventReasonBeforeRebootTimedOut = "BeforeRebootTimedOut"
+
+	// EventReasonBeforeRebootCheckFailed is a reason of the event emitted when any of before-reboot
+	// annotations of node scheduled for rebooting gets set to false, indicating the check failed.
+	EventReasonBeforeRebootCheckFailed = "BeforeRebootCheckFailed"
+
+	// EventReasonRebootApproved is a reason of the event emitted when node passed before-reboot checks
+	// and agent is allowed to reboot it.
+	EventReasonRebootApproved = "RebootApproved"
+
+	// EventReasonRebootApprovalRevoked is a reason of the event emitted when node has been approved for rebooting,
+	// but agent did not start rebooting it within configured timeout or rebooting is no longer allowed while
+	// the reboot is scheduled.
+	EventReasonRebootApprovalRevoked = "RebootApprovalRevoked"
+
+	// EventReasonRebootStuck is a reason of the event emitted when node has been rebooting for longer than
+	// configured threshold.
+	EventReasonRebootStuck = "RebootStuck"
+
+	// EventReasonAgentStale is a reason of the event emitted when agent of the node did not report heartbeat
+	// for longer than configured threshold.
+	EventReasonAgentStale = "AgentStale"
+
+	// EventReasonRebooted is a reason of the event emitted when node finished rebooting and after-reboot
+	// checks are started.
+	EventReasonRebooted = "Rebooted"
+
+	// EventReasonAfterRebootCheckFailed is a reason of the event emitted when any of after-reboot
+	// annotations of rebooted node gets set to false, indicating the check failed.
+	EventReasonAfterRebootCheckFailed = "AfterRebootCheckFailed"
+
+	// EventReasonRebootCompleted is a reason of the event emitted when node passed after-reboot checks
+	// and reboot process is finished.
+	EventReasonRebootCompleted = "RebootCompleted"
+
+	// EventReasonUpdateRolledBack is a reason of the event emitted instead of EventReasonRebootCompleted
+	// when node passed after-reboot checks, but booted the previous OS version, as the update has been
+	// rolled back.
+	EventReasonUpdateRolledBack = "UpdateRolledBack"
+)
+
+// newEventRecorder creates event recorder publishing events using given client.
+func newEventRecorder(client kubernetes.Interface) record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&corev1client.EventSinkImpl{
+		// Events for cluster-scoped objects like Nodes are stored in the default namespace.
+		Interface: client.CoreV1().Events(""),
+	})
+
+	return broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{
+		Component: eventSourceComponent,
+	})
+}
+
+// nodeReference returns reference to a node with a given name.
+//
+// UID is set to node name, as this is what kubelet and kubectl use for Node events.
+func nodeReference(nodeName string) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		Kind: "Node",
+		Name: nodeName,
+		UID:  types.UID(nodeName),
+	}
+}
+
+// nodeEventf records an event for a node with a given name and records it as a decision about the node.
+// If notifier is configured, it also sends a notification for events relevant to on-call.
+func (k *Kontroller) nodeEventf(nodeName, eventType, reason, messageFmt string, args ...interface{}) {
+	k.eventRecorder.Eventf(nodeReference(nodeName), eventType, reason, messageFmt, args...)
+
+	k.recordDecision(nodeName, reason, "", fmt.Sprintf(messageFmt, args...))
+
+	notificationType, ok := notificationType(eventType, reason)
+	if k.notifier == nil || !ok {
+		return
+	}
+
+	k.notify(notify.Notification{
+		Type:    notificationType,
+		Node:    nodeName,
+		Reason:  reason,
+		Message: fmt.Sprintf(messageFmt, args...),
+		Time:    time.Now().UTC(),
+	})
+}
+
+// notificationType returns type of the notification sent for the event with a given type and reason.
+//
+// Notifications are sent when node gets scheduled for rebooting, when reboot process is completed
+// and for all warning events.
+func notificationType(eventType, reason string) (notify.Type, bool) {
+	switch {
+	case reason == EventReasonScheduledForReboot:
+		return notify.TypeRebootScheduled, true
+	case reason == EventReasonRebootCompleted:
+		return notify.TypeRebootCompleted, true
+	case eventType == corev1.EventTypeWarning:
+		return notify.TypeRebootFailed, true
+	default:
+		return "", false
+	}
+}
+
+// notify sends a given notification in the background, so slow receivers do not block reconciliation.
+func (k *Kontroller) notify(notification notify.Notification) {
+	go func() {
+		if err := k.notifier.Notify(context.Background(), notification); err != nil {
+			klog.Background().Error(err, "Failed sending notification",
+				logging.KeyNode, notification.Node, "type", notification.Type)
+		}
+	}()
+}
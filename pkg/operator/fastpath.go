@@ -0,0 +1,141 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logging"
+)
+
+// fastPathNodes returns nodes requiring a reboot, which are cordoned and run no workload, so they
+// can be scheduled for rebooting regardless of reboot window and remaining rebooting capacity.
+//
+// Pods owned by DaemonSets, mirror pods and pods which already terminated are not considered a workload.
+func (k *Kontroller) fastPathNodes(ctx context.Context, nodelist *corev1.NodeList) ([]*corev1.Node, error) {
+	nodesRequiringReboot := k.nodesRequiringReboot(nodelist)
+
+	candidates := []*corev1.Node{}
+
+	for i := range nodesRequiringReboot {
+		node := &nodesRequiringReboot[i]
+
+		if !node.Spec.Unschedulable || k.excludedTaint(node) != "" || scaleDownTaint(node) != "" {
+			continue
+		}
+
+		candidates = append(candidates, node)
+	}
+
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	pods, err := k.kc.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+
+	workloadNodes := map[string]struct{}{}
+
+	for i := range pods.Items {
+		if isWorkloadPod(&pods.Items[i]) {
+			workloadNodes[pods.Items[i].Spec.NodeName] = struct{}{}
+		}
+	}
+
+	nodes := []*corev1.Node{}
+
+	for _, node := range candidates {
+		if _, ok := workloadNodes[node.Name]; ok {
+			continue
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+// isWorkloadPod checks if given pod is a workload, which would be disrupted by rebooting the node it runs on.
+func isWorkloadPod(pod *corev1.Pod) bool {
+	if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return false
+	}
+
+	if owner := metav1.GetControllerOf(pod); owner != nil && owner.Kind == "DaemonSet" {
+		return false
+	}
+
+	return true
+}
+
+// markFastPathNodes schedules cordoned nodes without workload for rebooting and returns given list of nodes
+// with scheduled nodes removed.
+func (k *Kontroller) markFastPathNodes(ctx context.Context, nodelist *corev1.NodeList) (*corev1.NodeList, error) {
+	fastPathNodes, err := k.fastPathNodes(ctx, nodelist)
+	if err != nil {
+		return nil, fmt.Errorf("finding cordoned nodes without workload: %w", err)
+	}
+
+	scheduledNodes := map[string]struct{}{}
+
+	for _, n := range fastPathNodes {
+		klog.FromContext(ctx).Info("Node is cordoned and runs no workload, scheduling it for rebooting immediately",
+			logging.KeyNode, n.Name)
+
+		details := rebootDetails(n)
+		details[constants.AnnotationRebootFastPath] = constants.True
+
+		err = k.mark(ctx, n.Name, markOptions{
+			label:             constants.LabelBeforeReboot,
+			annotationsType:   "before-reboot",
+			annotations:       k.beforeRebootAnnotations,
+			removeAnnotations: previousAttemptAnnotations,
+			extraAnnotations:  details,
+			eventReason:       EventReasonScheduledForReboot,
+			eventMessage:      "Node is cordoned and runs no workload, scheduled for rebooting immediately",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("labeling node for before reboot checks: %w", err)
+		}
+
+		scheduledNodes[n.Name] = struct{}{}
+	}
+
+	remainingNodes := &corev1.NodeList{}
+
+	for _, n := range nodelist.Items {
+		if _, ok := scheduledNodes[n.Name]; !ok {
+			remainingNodes.Items = append(remainingNodes.Items, n)
+		}
+	}
+
+	return remainingNodes, nil
+}
+
+// fastPathNode checks if given node has been scheduled for rebooting using the fast path.
+func fastPathNode(node *corev1.Node) bool {
+	return node.Annotations[constants.AnnotationRebootFastPath] == constants.True
+}
+
+// capacityConsumingNodes returns rebooting nodes, which count against maximum number of rebooting nodes.
+func capacityConsumingNodes(nodelist *corev1.NodeList) []corev1.Node {
+	nodes := []corev1.Node{}
+
+	for _, n := range rebootingNodes(nodelist) {
+		if !fastPathNode(&n) {
+			nodes = append(nodes, n)
+		}
+	}
+
+	return nodes
+}
@@ -0,0 +1,141 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+// FluoConfigResource identifies FluoConfig custom resource, which allows changing
+// operator tunables at runtime.
+var FluoConfigResource = schema.GroupVersionResource{
+	Group:    "fluo.flatcar-linux.net",
+	Version:  "v1alpha1",
+	Resource: "fluoconfigs",
+}
+
+// FluoConfigSpec holds operator tunables, which can be changed at runtime using FluoConfig object.
+//
+// Fields which are not set fall back to values given in operator Config.
+type FluoConfigSpec struct {
+	// Reboot window. Both start and length must be set together.
+	RebootWindowStart  string `json:"rebootWindowStart,omitempty"`
+	RebootWindowLength string `json:"rebootWindowLength,omitempty"`
+	// Maximum number of nodes rebooting in parallel.
+	MaxRebootingNodes int `json:"maxRebootingNodes,omitempty"`
+	// Annotations to look for before and after reboots. Setting an empty list
+	// disables the checks configured in operator Config.
+	BeforeRebootAnnotations []string `json:"beforeRebootAnnotations,omitempty"`
+	AfterRebootAnnotations  []string `json:"afterRebootAnnotations,omitempty"`
+	// When true, no new nodes are scheduled for rebooting. Nodes which are
+	// already rebooting finish the reboot process.
+	Paused bool `json:"paused,omitempty"`
+}
+
+// tunables are settings which can be changed at runtime using FluoConfig object.
+type tunables struct {
+	// Annotations to look for before and after reboots.
+	beforeRebootAnnotations []string
+	afterRebootAnnotations  []string
+
+	// Reboot window.
+	rebootWindow *Periodic
+
+	maxRebootingNodes int
+
+	paused bool
+}
+
+// withSpec returns tunables with values from a given FluoConfig spec applied on top.
+func (t tunables) withSpec(spec FluoConfigSpec) (tunables, error) {
+	result := t
+
+	switch {
+	case spec.RebootWindowStart != "" && spec.RebootWindowLength != "":
+		rebootWindow, err := ParsePeriodic(spec.RebootWindowStart, spec.RebootWindowLength)
+		if err != nil {
+			return tunables{}, fmt.Errorf("parsing reboot window: %w", err)
+		}
+
+		result.rebootWindow = rebootWindow
+	case spec.RebootWindowStart != "" || spec.RebootWindowLength != "":
+		return tunables{}, fmt.Errorf("reboot window start and length must be set together")
+	}
+
+	if spec.MaxRebootingNodes < 0 {
+		return tunables{}, fmt.Errorf("maximum rebooting nodes must not be negative, got %d", spec.MaxRebootingNodes)
+	}
+
+	if spec.MaxRebootingNodes > 0 {
+		result.maxRebootingNodes = spec.MaxRebootingNodes
+	}
+
+	if spec.BeforeRebootAnnotations != nil {
+		result.beforeRebootAnnotations = spec.BeforeRebootAnnotations
+	}
+
+	if spec.AfterRebootAnnotations != nil {
+		result.afterRebootAnnotations = spec.AfterRebootAnnotations
+	}
+
+	result.paused = spec.Paused
+
+	return result, nil
+}
+
+// reloadConfig applies tunables from configured FluoConfig object. If object does not exist,
+// tunables from operator Config are used.
+//
+// If object cannot be read or contains invalid values, previously applied tunables are kept
+// and an error is returned.
+func (k *Kontroller) reloadConfig(ctx context.Context) error {
+	if k.configName == "" {
+		return nil
+	}
+
+	object, err := k.dynamicClient.Resource(FluoConfigResource).Namespace(k.namespace).Get(
+		ctx, k.configName, metav1.GetOptions{})
+
+	switch {
+	case apierrors.IsNotFound(err):
+		if k.appliedConfig != nil {
+			klog.FromContext(ctx).Info("FluoConfig removed, restoring default configuration", "fluoConfig", k.configName)
+		}
+
+		k.tunables = k.defaultTunables
+		k.appliedConfig = nil
+
+		return nil
+	case err != nil:
+		return fmt.Errorf("getting FluoConfig %q: %w", k.configName, err)
+	}
+
+	spec := FluoConfigSpec{}
+
+	rawSpec, _ := object.Object["spec"].(map[string]interface{})
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawSpec, &spec); err != nil {
+		return fmt.Errorf("decoding FluoConfig %q spec: %w", k.configName, err)
+	}
+
+	if k.appliedConfig != nil && reflect.DeepEqual(*k.appliedConfig, spec) {
+		return nil
+	}
+
+	newTunables, err := k.defaultTunables.withSpec(spec)
+	if err != nil {
+		return fmt.Errorf("applying FluoConfig %q: %w", k.configName, err)
+	}
+
+	klog.FromContext(ctx).Info("Applying configuration from FluoConfig", "fluoConfig", k.configName, "spec", spec)
+
+	k.tunables = newTunables
+	k.appliedConfig = &spec
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logging"
+)
+
+const (
+	// DefaultRebootHistoryConfigMap is a default name of ConfigMap where reboot history is stored.
+	DefaultRebootHistoryConfigMap = "flatcar-linux-update-operator-reboot-history"
+
+	defaultRebootHistoryLimit = 10
+)
+
+// RebootRecord describes a single completed reboot of a node.
+type RebootRecord struct {
+	Node          string    `json:"node"`
+	Started       time.Time `json:"started"`
+	Finished      time.Time `json:"finished"`
+	VersionBefore string    `json:"versionBefore,omitempty"`
+	VersionAfter  string    `json:"versionAfter,omitempty"`
+	// Whether node booted the previous OS version, as the update has been rolled back.
+	RolledBack bool `json:"rolledBack,omitempty"`
+}
+
+// newRebootRecord creates a reboot record for a node which just finished rebooting.
+func newRebootRecord(ctx context.Context, node *corev1.Node, finished time.Time) RebootRecord {
+	record := RebootRecord{
+		Node:          node.Name,
+		Finished:      finished,
+		VersionBefore: node.Annotations[constants.AnnotationVersionBeforeReboot],
+		VersionAfter:  node.Labels[constants.LabelVersion],
+		RolledBack:    rolledBack(node),
+	}
+
+	if value, ok := node.Annotations[constants.AnnotationRebootStartedTime]; ok {
+		started, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			klog.FromContext(ctx).Error(err, "Failed parsing annotation", logging.KeyNode, node.Name,
+				"annotation", constants.AnnotationRebootStartedTime)
+		}
+
+		record.Started = started
+	}
+
+	return record
+}
+
+// recordReboot appends given reboot record to the history of reboots of the node stored in a ConfigMap.
+//
+// Only configured number of most recent records is kept for each node.
+func (k *Kontroller) recordReboot(ctx context.Context, record RebootRecord) error {
+	configMaps := k.kc.CoreV1().ConfigMaps(k.namespace)
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		configMap, err := configMaps.Get(ctx, k.rebootHistoryConfigMap, metav1.GetOptions{})
+
+		exists := true
+
+		switch {
+		case apierrors.IsNotFound(err):
+			exists = false
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      k.rebootHistoryConfigMap,
+					Namespace: k.namespace,
+				},
+			}
+		case err != nil:
+			return fmt.Errorf("getting ConfigMap %q: %w", k.rebootHistoryConfigMap, err)
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+
+		records := []RebootRecord{}
+
+		if value, ok := configMap.Data[record.Node]; ok {
+			if err := json.Unmarshal([]byte(value), &records); err != nil {
+				klog.FromContext(ctx).Error(err, "Discarding malformed reboot history", logging.KeyNode, record.Node)
+
+				records = []RebootRecord{}
+			}
+		}
+
+		records = append(records, record)
+
+		if len(records) > k.rebootHistoryLimit {
+			records = records[len(records)-k.rebootHistoryLimit:]
+		}
+
+		value, err := json.Marshal(records)
+		if err != nil {
+			return fmt.Errorf("encoding reboot history: %w", err)
+		}
+
+		configMap.Data[record.Node] = string(value)
+
+		if exists {
+			_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+		} else {
+			_, err = configMaps.Create(ctx, configMap, metav1.CreateOptions{})
+		}
+
+		return err
+	})
+}
+
+// recordLastReboots sets the last reboot metric of given nodes to the time when their most recent reboot
+// recorded in the reboot history finished. Nodes without recorded reboots are not reported.
+//
+// If reboot history is not recorded, nothing is done.
+func (k *Kontroller) recordLastReboots(ctx context.Context, nodelist *corev1.NodeList) error {
+	if k.rebootHistoryConfigMap == "" {
+		return nil
+	}
+
+	configMap, err := k.kc.CoreV1().ConfigMaps(k.namespace).Get(ctx, k.rebootHistoryConfigMap, metav1.GetOptions{})
+
+	switch {
+	case apierrors.IsNotFound(err):
+		configMap = &corev1.ConfigMap{}
+	case err != nil:
+		return fmt.Errorf("getting ConfigMap %q: %w", k.rebootHistoryConfigMap, err)
+	}
+
+	k.metrics.nodeLastReboot.Reset()
+
+	for _, node := range nodelist.Items {
+		value, ok := configMap.Data[node.Name]
+		if !ok {
+			continue
+		}
+
+		records := []RebootRecord{}
+
+		if err := json.Unmarshal([]byte(value), &records); err != nil || len(records) == 0 {
+			continue
+		}
+
+		if finished := records[len(records)-1].Finished; !finished.IsZero() {
+			k.metrics.nodeLastReboot.WithLabelValues(node.Name).Set(float64(finished.Unix()))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+// Annotations set by kured when started with --annotate-nodes, mirrored when kured compatibility is enabled.
+const (
+	// kuredRebootInProgressAnnotation is set to the time when node has been approved for rebooting and
+	// removed once the reboot process is finished.
+	kuredRebootInProgressAnnotation = "weave.works/kured-reboot-in-progress"
+	// kuredMostRecentRebootNeededAnnotation is set to the time since which node needs a reboot. Like kured,
+	// it is kept once the node is rebooted.
+	kuredMostRecentRebootNeededAnnotation = "weave.works/kured-most-recent-reboot-needed"
+)
+
+// newKuredRebootRequiredMetric creates and registers kured_reboot_required metric using given registerer.
+func newKuredRebootRequiredMetric(registerer prometheus.Registerer) (*prometheus.GaugeVec, error) {
+	rebootRequired := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: "kured",
+		Name:      "reboot_required",
+		Help:      "OS requires reboot due to software updates.",
+	}, []string{"node"})
+
+	if err := registerer.Register(rebootRequired); err != nil {
+		return nil, fmt.Errorf("registering metric: %w", err)
+	}
+
+	return rebootRequired, nil
+}
+
+// mirrorKuredState mirrors reboot state of given nodes to annotations and metrics used by kured, so
+// dashboards and alerts built for kured work with nodes managed by FLUO.
+//
+// If kured compatibility is not enabled, nothing is done.
+func (k *Kontroller) mirrorKuredState(ctx context.Context, nodelist *corev1.NodeList) error {
+	if k.kuredRebootRequired == nil {
+		return nil
+	}
+
+	// Reset to remove metrics of deleted nodes.
+	k.kuredRebootRequired.Reset()
+
+	for i := range nodelist.Items {
+		node := &nodelist.Items[i]
+
+		rebootNeeded := node.Annotations[constants.AnnotationRebootNeeded] == constants.True
+		k.kuredRebootRequired.WithLabelValues(node.Name).Set(boolToFloat64(rebootNeeded))
+
+		if err := k8sutil.PatchNodeAnnotationsLabels(ctx, k.nc, node.Name, kuredAnnotationsPatch(node)); err != nil {
+			return fmt.Errorf("mirroring kured annotations of node %q: %w", node.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// kuredAnnotationsPatch returns patch updating kured annotations of given node according to its reboot state.
+// Patch is empty when annotations are up to date.
+func kuredAnnotationsPatch(node *corev1.Node) k8sutil.NodeMetadataPatch {
+	patch := k8sutil.NodeMetadataPatch{Annotations: map[string]string{}}
+
+	approvedTime, approved := node.Annotations[constants.AnnotationRebootApprovedTime]
+	inProgress, inProgressSet := node.Annotations[kuredRebootInProgressAnnotation]
+
+	switch {
+	case approved && inProgress != approvedTime:
+		patch.Annotations[kuredRebootInProgressAnnotation] = approvedTime
+	case !approved && inProgressSet:
+		patch.RemoveAnnotations = append(patch.RemoveAnnotations, kuredRebootInProgressAnnotation)
+	}
+
+	neededSince, needed := node.Annotations[constants.AnnotationRebootNeededSince]
+	if needed && node.Annotations[kuredMostRecentRebootNeededAnnotation] != neededSince {
+		patch.Annotations[kuredMostRecentRebootNeededAnnotation] = neededSince
+	}
+
+	return patch
+}
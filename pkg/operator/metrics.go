@@ -0,0 +1,245 @@
+package operator
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+const (
+	metricsNamespace = "flatcar_linux_update_operator"
+
+	// fluoMetricsNamespace is a prefix of metrics describing state of the fleet, which are meant
+	// to be used on dashboards and in alerts together with update-agent metrics.
+	fluoMetricsNamespace = "fluo"
+)
+
+// metrics holds Prometheus metrics exposed by the operator.
+type metrics struct {
+	stuckRebootingNodes  *prometheus.GaugeVec
+	staleAgentNodes      *prometheus.GaugeVec
+	nodeLastReboot       *prometheus.GaugeVec
+	nodesRebootNeeded    *prometheus.GaugeVec
+	nodesRebooting       *prometheus.GaugeVec
+	nodesBeforeReboot    *prometheus.GaugeVec
+	nodesAfterReboot     *prometheus.GaugeVec
+	rebootsCompleted     prometheus.Counter
+	updatesRolledBack    prometheus.Counter
+	rebootLatency        prometheus.Histogram
+	reconciliationErrors *prometheus.CounterVec
+	reconciliationTime   prometheus.Histogram
+	reconciliationSteps  *prometheus.HistogramVec
+	rebootCheckFailures  *prometheus.CounterVec
+	rebootWindowOpen     prometheus.Gauge
+	blackoutWindowActive prometheus.Gauge
+	leaderAcquisitions   prometheus.Counter
+	leaderLosses         prometheus.Counter
+	leaderChanges        prometheus.Counter
+	leader               *prometheus.GaugeVec
+}
+
+// newMetrics creates operator metrics and registers them using given registerer.
+//
+//nolint:funlen // Just many metrics.
+func newMetrics(registerer prometheus.Registerer) (*metrics, error) {
+	m := &metrics{
+		stuckRebootingNodes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "node_stuck_rebooting",
+			Help:      "Whether node has been rebooting for longer than configured threshold.",
+		}, []string{"node"}),
+		staleAgentNodes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "node_agent_stale",
+			Help:      "Whether agent of node did not report heartbeat for longer than configured threshold.",
+		}, []string{"node"}),
+		nodeLastReboot: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: fluoMetricsNamespace,
+			Name:      "node_last_reboot_timestamp_seconds",
+			Help:      "Time when the most recent reboot process of the node recorded in reboot history finished.",
+		}, []string{"node"}),
+		nodesRebootNeeded: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: fluoMetricsNamespace,
+			Name:      "nodes_reboot_needed",
+			Help:      "Number of nodes which need a reboot, but are not rebooting yet, by node pool.",
+		}, []string{"pool"}),
+		nodesRebooting: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: fluoMetricsNamespace,
+			Name:      "nodes_rebooting",
+			Help:      "Number of nodes in the process of rebooting, including before and after reboot checks, by node pool.",
+		}, []string{"pool"}),
+		nodesBeforeReboot: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: fluoMetricsNamespace,
+			Name:      "nodes_before_reboot_hooks",
+			Help:      "Number of nodes waiting for before reboot checks, by node pool.",
+		}, []string{"pool"}),
+		nodesAfterReboot: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: fluoMetricsNamespace,
+			Name:      "nodes_after_reboot_hooks",
+			Help:      "Number of nodes waiting for after reboot checks, by node pool.",
+		}, []string{"pool"}),
+		rebootsCompleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "reboots_completed_total",
+			Help:      "Number of completed reboot processes.",
+		}),
+		updatesRolledBack: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "updates_rolled_back_total",
+			Help:      "Number of completed reboot processes, after which node booted the previous OS version.",
+		}),
+		rebootLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "reboot_latency_seconds",
+			Help:      "Time from node requesting a reboot to finishing the reboot process, including after-reboot checks.",
+			//nolint:gomnd // From 1 minute to roughly 11 days.
+			Buckets: prometheus.ExponentialBuckets(60, 2, 15),
+		}),
+		reconciliationErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "reconciliation_errors_total",
+			Help:      "Number of failed reconciliations by reconciliation step.",
+		}, []string{"step"}),
+		reconciliationTime: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "reconciliation_duration_seconds",
+			Help:      "Time it took to perform reconciliation, including failed reconciliations.",
+			//nolint:gomnd // From 10 milliseconds to roughly 80 seconds.
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 14),
+		}),
+		reconciliationSteps: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "reconciliation_step_duration_seconds",
+			Help:      "Time it took to perform reconciliation step, including failed steps, by reconciliation step.",
+			//nolint:gomnd // From 10 milliseconds to roughly 80 seconds.
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 14),
+		}, []string{"step"}),
+		rebootCheckFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "reboot_check_failures_total",
+			Help:      "Number of failed before-reboot and after-reboot checks by checks type and annotation.",
+		}, []string{"type", "annotation"}),
+		rebootWindowOpen: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "reboot_window_open",
+			Help:      "Whether reboot window is currently open. Always 1 if reboot window is not configured.",
+		}),
+		blackoutWindowActive: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "blackout_window_active",
+			Help:      "Whether any of configured blackout windows is currently active.",
+		}),
+		leaderAcquisitions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "leader_election_acquisitions_total",
+			Help:      "Number of times this instance acquired leadership.",
+		}),
+		leaderLosses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "leader_election_losses_total",
+			Help:      "Number of times this instance lost leadership, not including releasing it on shutdown.",
+		}),
+		leaderChanges: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "leader_election_leader_changes_total",
+			Help:      "Number of observed leader changes, including the first observed leader.",
+		}),
+		leader: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "leader_election_leader",
+			Help:      "Identity of the currently observed leader, always set to 1.",
+		}, []string{"identity"}),
+	}
+
+	for _, collector := range []prometheus.Collector{
+		m.stuckRebootingNodes,
+		m.staleAgentNodes,
+		m.nodeLastReboot,
+		m.nodesRebootNeeded,
+		m.nodesRebooting,
+		m.nodesBeforeReboot,
+		m.nodesAfterReboot,
+		m.rebootsCompleted,
+		m.updatesRolledBack,
+		m.rebootLatency,
+		m.reconciliationErrors,
+		m.reconciliationTime,
+		m.reconciliationSteps,
+		m.rebootCheckFailures,
+		m.rebootWindowOpen,
+		m.blackoutWindowActive,
+		m.leaderAcquisitions,
+		m.leaderLosses,
+		m.leaderChanges,
+		m.leader,
+	} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, fmt.Errorf("registering metric: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+// recordMetrics updates metrics describing the state of given nodes and reboot windows.
+func (k *Kontroller) recordMetrics(nodelist *corev1.NodeList) {
+	k.recordNodesPerPool(k.metrics.nodesRebootNeeded, nodelist, k.nodesRequiringReboot(nodelist))
+	k.recordNodesPerPool(k.metrics.nodesRebooting, nodelist, rebootingNodes(nodelist))
+	k.recordNodesPerPool(k.metrics.nodesBeforeReboot, nodelist,
+		k8sutil.FilterNodesByRequirement(nodelist.Items, beforeRebootReq))
+	k.recordNodesPerPool(k.metrics.nodesAfterReboot, nodelist,
+		k8sutil.FilterNodesByRequirement(nodelist.Items, afterRebootReq))
+	k.metrics.rebootWindowOpen.Set(boolToFloat64(k.insideRebootWindow()))
+	k.metrics.blackoutWindowActive.Set(boolToFloat64(k.insideBlackoutWindow()))
+}
+
+// recordNodesPerPool sets given gauge to number of given nodes in each pool. Pools of all nodes in the
+// node list are reported, so pools without matching nodes report zero instead of disappearing.
+func (k *Kontroller) recordNodesPerPool(gauge *prometheus.GaugeVec, nodelist *corev1.NodeList, nodes []corev1.Node) {
+	counts := map[string]int{}
+
+	// Without configured pool label, all nodes belong to a single pool, which is always reported.
+	if k.poolLabel == "" {
+		counts[""] = 0
+	}
+
+	for i := range nodelist.Items {
+		counts[k.pool(&nodelist.Items[i])] = 0
+	}
+
+	for i := range nodes {
+		counts[k.pool(&nodes[i])]++
+	}
+
+	gauge.Reset()
+
+	for pool, count := range counts {
+		gauge.WithLabelValues(pool).Set(float64(count))
+	}
+}
+
+// rebootingNodes returns nodes which are considered to be in the process of rebooting,
+// including nodes running before and after reboot checks and nodes rebooted in emergency mode.
+func rebootingNodes(nodelist *corev1.NodeList) []corev1.Node {
+	rebootingNodes := k8sutil.FilterNodesByAnnotation(nodelist.Items, stillRebootingSelector)
+
+	// Nodes rebooted in emergency mode are not coordinated by us, but must not be rebooted together with other nodes.
+	rebootingNodes = append(rebootingNodes, k8sutil.FilterNodesByAnnotation(nodelist.Items, emergencyRebootSelector)...)
+
+	// Nodes running before and after reboot checks are still considered to be "rebooting" to us.
+	beforeRebootNodes := k8sutil.FilterNodesByRequirement(nodelist.Items, beforeRebootReq)
+	afterRebootNodes := k8sutil.FilterNodesByRequirement(nodelist.Items, afterRebootReq)
+
+	return append(append(rebootingNodes, beforeRebootNodes...), afterRebootNodes...)
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -4,14 +4,21 @@ package operator
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -21,20 +28,40 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/flatcar"
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logging"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/notify"
+)
+
+const (
+	// LockTypeLeases configures operator to use only Lease objects for leader election.
+	LockTypeLeases = resourcelock.LeasesResourceLock
+
+	// LockTypeConfigMapsLeases configures operator to use both ConfigMap and Lease objects for leader election.
+	//
+	// It is the default lock type, which allows migrating from operator versions using only ConfigMap lock
+	// without running multiple leaders at the same time. Once all operator instances use this lock type,
+	// it is safe to switch to LockTypeLeases.
+	LockTypeConfigMapsLeases = resourcelock.ConfigMapsLeasesResourceLock
 )
 
 const (
 	leaderElectionEventSourceComponent = "update-operator-leader-election"
 	defaultMaxRebootingNodes           = 1
-	defaultLockType                    = resourcelock.ConfigMapsLeasesResourceLock
+	defaultLockType                    = LockTypeConfigMapsLeases
 
 	leaderElectionResourceName = "flatcar-linux-update-operator-lock"
 
+	tracerName = "github.com/flatcar/flatcar-linux-update-operator/pkg/operator"
+
 	// Arbitrarily copied from KVO.
 	defaultLeaderElectionLease = 90 * time.Second
 	// ReconciliationPeriod.
 	defaultReconciliationPeriod = 30 * time.Second
+
+	// Time for which lease may stay expired while still leading before liveness check fails.
+	leaderElectionHealthzTimeout = 20 * time.Second
 )
 
 //nolint:godot // TODO: Complaining about not capitalized comments for variables. We should get rid of those completely.
@@ -57,9 +84,16 @@ var (
 	// The update-agent sets constants.AnnotationRebootNeeded to true when
 	// it would like to reboot, and false when it starts up.
 	//
-	// If constants.AnnotationRebootPaused is set to "true", the update-agent will not consider it for rebooting.
+	// If constants.AnnotationRebootPaused or constants.AnnotationAgentRebootPaused is set to "true",
+	// the update-agent will not consider it for rebooting. Nodes with automatic reboots disabled
+	// using constants.AnnotationRebootStrategy are not considered either.
+	//
+	// Nodes being rebooted in emergency mode are not rebooted by the update-operator either.
 	rebootableSelector = fields.ParseSelectorOrDie(constants.AnnotationRebootNeeded + "==" + constants.True +
 		"," + constants.AnnotationRebootPaused + "!=" + constants.True +
+		"," + constants.AnnotationAgentRebootPaused + "!=" + constants.True +
+		"," + constants.AnnotationRebootStrategy + "!=" + flatcar.RebootStrategyOff +
+		"," + constants.AnnotationEmergencyReboot + "!=" + constants.True +
 		"," + constants.AnnotationOkToReboot + "!=" + constants.True +
 		"," + constants.AnnotationRebootInProgress + "!=" + constants.True)
 
@@ -70,6 +104,11 @@ var (
 		constants.AnnotationRebootNeeded: constants.True,
 	}).AsSelector()
 
+	// emergencyRebootSelector is a selector for the annotation set expected to be on a node rebooted
+	// by the update-agent in emergency mode, without the update-operator approval.
+	emergencyRebootSelector = fields.ParseSelectorOrDie(constants.AnnotationEmergencyReboot + "==" + constants.True +
+		"," + constants.AnnotationOkToReboot + "!=" + constants.True)
+
 	// beforeRebootReq requires a node to be waiting for before reboot checks to complete.
 	beforeRebootReq = k8sutil.NewRequirementOrDie(constants.LabelBeforeReboot, selection.In, []string{constants.True})
 
@@ -79,6 +118,16 @@ var (
 	// notBeforeRebootReq is the inverse of the above checks.
 	notBeforeRebootReq = k8sutil.NewRequirementOrDie(
 		constants.LabelBeforeReboot, selection.NotIn, []string{constants.True})
+
+	// notAfterRebootReq requires a node to not be waiting for after reboot checks to complete.
+	notAfterRebootReq = k8sutil.NewRequirementOrDie(
+		constants.LabelAfterReboot, selection.NotEquals, []string{constants.True})
+
+	// rebootableNodeSelector selects nodes which need a reboot and are not scheduled for rebooting yet.
+	rebootableNodeSelector = k8sutil.NodeSelector{
+		Labels:      labels.NewSelector().Add(*notBeforeRebootReq),
+		Annotations: rebootableSelector,
+	}
 )
 
 // Config configures a Kontroller.
@@ -89,14 +138,100 @@ type Config struct {
 	BeforeRebootAnnotations []string
 	AfterRebootAnnotations  []string
 	// Reboot window.
-	RebootWindowStart    string
-	RebootWindowLength   string
-	Namespace            string
-	LockID               string
-	LockType             string
+	RebootWindowStart  string
+	RebootWindowLength string
+	// Blackout windows during which nodes are never scheduled for rebooting.
+	// See ParseBlackoutWindow for supported format.
+	BlackoutWindows []string
+	Namespace       string
+	LockID          string
+	// Type of the lock used for leader election. Either LockTypeConfigMapsLeases or LockTypeLeases.
+	// Defaults to LockTypeConfigMapsLeases.
+	LockType string
+	// Name of the resource used for leader election. Operators managing disjoint sets of nodes
+	// must use distinct names.
+	LockName             string
 	ReconciliationPeriod time.Duration
 	LeaderElectionLease  time.Duration
 	MaxRebootingNodes    int
+	// Label used to group nodes into pools. When set, the rebooting capacity is
+	// evaluated separately for each value of this label.
+	PoolLabel string
+	// Maximum number of rebooting nodes per pool, keyed by pool label value.
+	// Pools not listed here use MaxRebootingNodes.
+	MaxRebootingNodesPerPool map[string]int
+	// Maximum number of rebooting nodes in each topology domain, keyed by topology label key,
+	// e.g. topology.kubernetes.io/zone. Applies in addition to pool limits.
+	MaxRebootingNodesPerTopology map[string]int
+	// Time after which node requesting a reboot will be scheduled for rebooting
+	// even outside the reboot window. Zero disables the deadline.
+	ForceRebootDeadline time.Duration
+	// Same as ForceRebootDeadline, but applies only to nodes which requested a reboot to apply
+	// security update, as reported by the agent. Zero disables the deadline.
+	SecurityRebootDeadline time.Duration
+	// Name of the ConfigMap where history of completed reboots is stored.
+	// If empty, reboot history is not recorded.
+	RebootHistoryConfigMap string
+	// Number of most recent reboots to keep in history for each node.
+	RebootHistoryLimit int
+	// Name of the ConfigMap where decisions about nodes are stored, e.g. why node has or has not been
+	// scheduled for rebooting. If empty, decisions are not recorded.
+	RebootDecisionsConfigMap string
+	// Number of most recent decisions to keep for each node.
+	RebootDecisionsLimit int
+	// Name of the ConfigMap where operator status is published after each reconciliation cycle.
+	// If empty, status is not published.
+	StatusConfigMap string
+	// Time after which node waiting for before-reboot annotations gets unscheduled
+	// from rebooting. Node is scheduled again once the same amount of time passes.
+	// Zero disables the timeout.
+	BeforeRebootTimeout time.Duration
+	// Time after which node which is still rebooting is reported as stuck.
+	// Zero disables stuck reboots detection.
+	StuckRebootThreshold time.Duration
+	// Time after which node, which agent did not renew its heartbeat lease in operator namespace,
+	// is reported as having stale agent. Requires agents to report heartbeat.
+	// Zero disables stale agents detection.
+	StaleAgentThreshold time.Duration
+	// Time after which reboot approval of a node gets revoked, if update-agent does not start
+	// rebooting the node. Node is scheduled again once the same amount of time passes.
+	// Zero disables revoking approvals.
+	RebootApprovalTimeout time.Duration
+	// Keys of taints, which exclude node from being scheduled for rebooting.
+	ExcludedTaints []string
+	// Label selector limiting nodes managed by the operator. If empty, all nodes are managed.
+	NodeSelector string
+	// Registerer used to register operator metrics. If nil, metrics are not exposed.
+	MetricsRegisterer prometheus.Registerer
+	// Provider of tracers used to record reconciliation steps as spans. If nil, reconciliation is not traced.
+	TracerProvider trace.TracerProvider
+	// Dynamic Kubernetes client used to read FluoConfig object.
+	DynamicClient dynamic.Interface
+	// Name of the FluoConfig object in operator namespace, which overrides reboot window,
+	// maximum rebooting nodes, before and after reboot annotations and allows pausing
+	// the operator at runtime. If empty, FluoConfig is not used.
+	ConfigName string
+	// Notifier used to send notifications about reboot process. If nil, notifications are not sent.
+	Notifier notify.Notifier
+	// When true, nodes are not scheduled for rebooting if draining them would violate
+	// any PodDisruptionBudget.
+	RespectPodDisruptionBudgets bool
+	// When true, nodes are not scheduled for rebooting while cluster-autoscaler is actively
+	// scaling the cluster or while nodes are being registered or removed.
+	DeferRebootsDuringAutoscaling bool
+	// When true, nodes which are cordoned and run no pods other than DaemonSet and mirror pods are
+	// scheduled for rebooting regardless of reboot window and without counting against maximum
+	// number of rebooting nodes.
+	FastPathCordonedNodes bool
+	// When true, reboot state of nodes is mirrored to annotations and the reboot_required metric
+	// used by kured, so dashboards and alerts built for kured work with nodes managed by the operator.
+	KuredCompatibility bool
+	// Namespace and name of the cluster-autoscaler status ConfigMap in namespace/name format.
+	// Defaults to DefaultClusterAutoscalerStatusConfigMap.
+	ClusterAutoscalerStatusConfigMap string
+	// Configures how updates of Node objects failing due to conflicts are retried.
+	// Defaults to k8sutil.DefaultBackoff().
+	NodeUpdateBackoff *k8sutil.Backoff
 }
 
 // Kontroller implement operator part of FLUO.
@@ -104,25 +239,91 @@ type Kontroller struct {
 	kc kubernetes.Interface
 	nc corev1client.NodeInterface
 
-	// Annotations to look for before and after reboots.
-	beforeRebootAnnotations []string
-	afterRebootAnnotations  []string
+	nodeUpdateBackoff k8sutil.Backoff
+
+	// Settings which may be changed at runtime using FluoConfig object.
+	tunables
+	defaultTunables tunables
+
+	dynamicClient dynamic.Interface
+	configName    string
+	// Most recently applied FluoConfig spec. Nil when FluoConfig does not exist.
+	appliedConfig *FluoConfigSpec
 
 	// Namespace is the kubernetes namespace any resources (e.g. locks,
 	// configmaps, agents) should be created and read under.
 	// It will be set to the namespace the operator is running in automatically.
 	namespace string
 
-	// Reboot window.
-	rebootWindow *Periodic
+	blackoutWindows []*Period
+
+	poolLabel                string
+	maxRebootingNodesPerPool map[string]int
+
+	maxRebootingNodesPerTopology map[string]int
+
+	forceRebootDeadline time.Duration
+
+	securityRebootDeadline time.Duration
+
+	rebootHistoryConfigMap string
+	rebootHistoryLimit     int
+
+	rebootDecisionsConfigMap string
+	rebootDecisionsLimit     int
+	// Last recorded decision about each node and decisions waiting to be stored.
+	lastDecisions    map[string]string
+	pendingDecisions map[string][]RebootDecision
+
+	statusConfigMap string
+	status          Status
+
+	beforeRebootTimeout time.Duration
+
+	stuckRebootThreshold time.Duration
+	rebootingNodes       map[string]*rebootingNode
+	failedChecks         map[failedCheck]struct{}
 
-	maxRebootingNodes int
+	staleAgentThreshold time.Duration
+	staleAgents         map[string]struct{}
+
+	rebootApprovalTimeout time.Duration
+
+	metrics *metrics
+
+	tracer trace.Tracer
+
+	// Number of started reconciliations, identifying reconciliation in logs.
+	reconciliations uint64
+
+	nodeSelector labels.Selector
+
+	excludedTaints []string
+
+	respectPodDisruptionBudgets bool
+
+	deferRebootsDuringAutoscaling bool
+	clusterAutoscalerStatus       types.NamespacedName
+
+	fastPathCordonedNodes bool
+
+	// Only set when kured compatibility is enabled.
+	kuredRebootRequired *prometheus.GaugeVec
+
+	leaderElectionHealthz *leaderelection.HealthzAdaptor
+
+	leaderLock sync.RWMutex
+	leader     string
 
 	reconciliationPeriod time.Duration
 
 	leaderElectionLease time.Duration
 
 	resourceLock resourcelock.Interface
+
+	eventRecorder record.EventRecorder
+
+	notifier notify.Notifier
 }
 
 // New initializes a new Kontroller.
@@ -147,6 +348,17 @@ func New(config Config) (*Kontroller, error) {
 		rebootWindow = rw
 	}
 
+	blackoutWindows := make([]*Period, 0, len(config.BlackoutWindows))
+
+	for _, window := range config.BlackoutWindows {
+		bw, err := ParseBlackoutWindow(window)
+		if err != nil {
+			return nil, fmt.Errorf("parsing blackout window %q: %w", window, err)
+		}
+
+		blackoutWindows = append(blackoutWindows, bw)
+	}
+
 	reconciliationPeriod := config.ReconciliationPeriod
 	if reconciliationPeriod == 0 {
 		reconciliationPeriod = defaultReconciliationPeriod
@@ -162,17 +374,110 @@ func New(config Config) (*Kontroller, error) {
 		maxRebootingNodes = defaultMaxRebootingNodes
 	}
 
-	return &Kontroller{
-		kc:                      config.Client,
-		nc:                      config.Client.CoreV1().Nodes(),
+	nodeSelector, err := labels.Parse(config.NodeSelector)
+	if err != nil {
+		return nil, fmt.Errorf("parsing node selector: %w", err)
+	}
+
+	metricsRegisterer := config.MetricsRegisterer
+	if metricsRegisterer == nil {
+		metricsRegisterer = prometheus.NewRegistry()
+	}
+
+	metrics, err := newMetrics(metricsRegisterer)
+	if err != nil {
+		return nil, fmt.Errorf("creating metrics: %w", err)
+	}
+
+	var kuredRebootRequired *prometheus.GaugeVec
+
+	if config.KuredCompatibility {
+		if kuredRebootRequired, err = newKuredRebootRequiredMetric(metricsRegisterer); err != nil {
+			return nil, fmt.Errorf("creating kured metrics: %w", err)
+		}
+	}
+
+	tracerProvider := config.TracerProvider
+	if tracerProvider == nil {
+		tracerProvider = trace.NewNoopTracerProvider()
+	}
+
+	rebootHistoryLimit := config.RebootHistoryLimit
+	if rebootHistoryLimit == 0 {
+		rebootHistoryLimit = defaultRebootHistoryLimit
+	}
+
+	rebootDecisionsLimit := config.RebootDecisionsLimit
+	if rebootDecisionsLimit == 0 {
+		rebootDecisionsLimit = defaultRebootDecisionsLimit
+	}
+
+	nodeUpdateBackoff := k8sutil.DefaultBackoff()
+	if config.NodeUpdateBackoff != nil {
+		nodeUpdateBackoff = *config.NodeUpdateBackoff
+	}
+
+	clusterAutoscalerStatusConfigMap := config.ClusterAutoscalerStatusConfigMap
+	if clusterAutoscalerStatusConfigMap == "" {
+		clusterAutoscalerStatusConfigMap = DefaultClusterAutoscalerStatusConfigMap
+	}
+
+	clusterAutoscalerStatus, err := parseNamespacedName(clusterAutoscalerStatusConfigMap)
+	if err != nil {
+		return nil, fmt.Errorf("parsing cluster-autoscaler status ConfigMap: %w", err)
+	}
+
+	defaultTunables := tunables{
 		beforeRebootAnnotations: config.BeforeRebootAnnotations,
 		afterRebootAnnotations:  config.AfterRebootAnnotations,
-		namespace:               config.Namespace,
 		rebootWindow:            rebootWindow,
 		maxRebootingNodes:       maxRebootingNodes,
-		reconciliationPeriod:    reconciliationPeriod,
-		leaderElectionLease:     leaderElectionLeaseDuration,
-		resourceLock:            resourceLock,
+	}
+
+	return &Kontroller{
+		kc:                            config.Client,
+		nc:                            config.Client.CoreV1().Nodes(),
+		nodeUpdateBackoff:             nodeUpdateBackoff,
+		tunables:                      defaultTunables,
+		defaultTunables:               defaultTunables,
+		dynamicClient:                 config.DynamicClient,
+		configName:                    config.ConfigName,
+		namespace:                     config.Namespace,
+		blackoutWindows:               blackoutWindows,
+		poolLabel:                     config.PoolLabel,
+		maxRebootingNodesPerPool:      config.MaxRebootingNodesPerPool,
+		maxRebootingNodesPerTopology:  config.MaxRebootingNodesPerTopology,
+		forceRebootDeadline:           config.ForceRebootDeadline,
+		securityRebootDeadline:        config.SecurityRebootDeadline,
+		rebootHistoryConfigMap:        config.RebootHistoryConfigMap,
+		rebootHistoryLimit:            rebootHistoryLimit,
+		rebootDecisionsConfigMap:      config.RebootDecisionsConfigMap,
+		rebootDecisionsLimit:          rebootDecisionsLimit,
+		lastDecisions:                 map[string]string{},
+		pendingDecisions:              map[string][]RebootDecision{},
+		statusConfigMap:               config.StatusConfigMap,
+		beforeRebootTimeout:           config.BeforeRebootTimeout,
+		stuckRebootThreshold:          config.StuckRebootThreshold,
+		rebootApprovalTimeout:         config.RebootApprovalTimeout,
+		rebootingNodes:                map[string]*rebootingNode{},
+		failedChecks:                  map[failedCheck]struct{}{},
+		staleAgentThreshold:           config.StaleAgentThreshold,
+		staleAgents:                   map[string]struct{}{},
+		metrics:                       metrics,
+		tracer:                        tracerProvider.Tracer(tracerName),
+		nodeSelector:                  nodeSelector,
+		excludedTaints:                config.ExcludedTaints,
+		respectPodDisruptionBudgets:   config.RespectPodDisruptionBudgets,
+		deferRebootsDuringAutoscaling: config.DeferRebootsDuringAutoscaling,
+		clusterAutoscalerStatus:       clusterAutoscalerStatus,
+		fastPathCordonedNodes:         config.FastPathCordonedNodes,
+		kuredRebootRequired:           kuredRebootRequired,
+		leaderElectionHealthz:         leaderelection.NewLeaderHealthzAdaptor(leaderElectionHealthzTimeout),
+		reconciliationPeriod:          reconciliationPeriod,
+		leaderElectionLease:           leaderElectionLeaseDuration,
+		resourceLock:                  resourceLock,
+		eventRecorder:                 newEventRecorder(config.Client),
+		notifier:                      config.Notifier,
 	}, nil
 }
 
@@ -191,6 +496,77 @@ func checkConfig(config Config) error {
 		return fmt.Errorf("lockID must not be empty")
 	}
 
+	switch config.LockType {
+	case "", LockTypeLeases, LockTypeConfigMapsLeases:
+	default:
+		return fmt.Errorf("unsupported lock type %q, expected either %q or %q",
+			config.LockType, LockTypeConfigMapsLeases, LockTypeLeases)
+	}
+
+	if config.ConfigName != "" && config.DynamicClient == nil {
+		return fmt.Errorf("dynamic client must not be nil when FluoConfig name is configured")
+	}
+
+	if len(config.MaxRebootingNodesPerPool) > 0 && config.PoolLabel == "" {
+		return fmt.Errorf("pool label must be set when maximum rebooting nodes per pool is configured")
+	}
+
+	if config.RebootHistoryLimit < 0 {
+		return fmt.Errorf("reboot history limit must not be negative")
+	}
+
+	if config.RebootDecisionsLimit < 0 {
+		return fmt.Errorf("reboot decisions limit must not be negative")
+	}
+
+	if config.ForceRebootDeadline < 0 {
+		return fmt.Errorf("force reboot deadline must not be negative")
+	}
+
+	if config.SecurityRebootDeadline < 0 {
+		return fmt.Errorf("security reboot deadline must not be negative")
+	}
+
+	if config.BeforeRebootTimeout < 0 {
+		return fmt.Errorf("before-reboot timeout must not be negative")
+	}
+
+	if config.StuckRebootThreshold < 0 {
+		return fmt.Errorf("stuck reboot threshold must not be negative")
+	}
+
+	if config.StaleAgentThreshold < 0 {
+		return fmt.Errorf("stale agent threshold must not be negative")
+	}
+
+	if config.RebootApprovalTimeout < 0 {
+		return fmt.Errorf("reboot approval timeout must not be negative")
+	}
+
+	if config.NodeUpdateBackoff != nil {
+		if err := config.NodeUpdateBackoff.Validate(); err != nil {
+			return fmt.Errorf("validating node update backoff: %w", err)
+		}
+	}
+
+	if config.ClusterAutoscalerStatusConfigMap != "" {
+		if _, err := parseNamespacedName(config.ClusterAutoscalerStatusConfigMap); err != nil {
+			return fmt.Errorf("parsing cluster-autoscaler status ConfigMap: %w", err)
+		}
+	}
+
+	for pool, maxNodes := range config.MaxRebootingNodesPerPool {
+		if maxNodes < 0 {
+			return fmt.Errorf("maximum rebooting nodes for pool %q must not be negative, got %d", pool, maxNodes)
+		}
+	}
+
+	for key, maxNodes := range config.MaxRebootingNodesPerTopology {
+		if maxNodes < 0 {
+			return fmt.Errorf("maximum rebooting nodes for topology key %q must not be negative, got %d", key, maxNodes)
+		}
+	}
+
 	return nil
 }
 
@@ -202,6 +578,11 @@ func newResourceLock(config Config) (resourcelock.Interface, error) {
 		lockType = defaultLockType
 	}
 
+	lockName := config.LockName
+	if lockName == "" {
+		lockName = leaderElectionResourceName
+	}
+
 	leaderElectionBroadcaster := record.NewBroadcaster()
 	leaderElectionBroadcaster.StartRecordingToSink(&corev1client.EventSinkImpl{
 		Interface: config.Client.CoreV1().Events(config.Namespace),
@@ -210,7 +591,7 @@ func newResourceLock(config Config) (resourcelock.Interface, error) {
 	return resourcelock.New(
 		lockType,
 		config.Namespace,
-		leaderElectionResourceName,
+		lockName,
 		config.Client.CoreV1(),
 		config.Client.CoordinationV1(),
 		resourcelock.ResourceLockConfig{
@@ -229,7 +610,7 @@ func (k *Kontroller) Run(stop <-chan struct{}) error {
 
 	// Leader election is responsible for shutting down the controller, so when leader election
 	// is lost, controller is immediately stopped, as shared context will be cancelled.
-	ctx := k.withLeaderElection(stop, errCh)
+	ctx, leaderElectionDone := k.withLeaderElection(stop, errCh)
 
 	klog.V(5).Info("Starting controller")
 
@@ -238,25 +619,65 @@ func (k *Kontroller) Run(stop <-chan struct{}) error {
 
 	klog.V(5).Info("Stopping controller")
 
-	return <-errCh
+	// Wait for leader election to finish, so the lock is released before returning, as the caller is likely
+	// to exit right after.
+	<-leaderElectionDone
+
+	select {
+	case err := <-errCh:
+		return err
+	default:
+		return nil
+	}
+}
+
+// Healthz checks if operator is alive. It fails when operator is leading, but
+// it was not able to renew the leader election lease in time.
+func (k *Kontroller) Healthz() error {
+	if err := k.leaderElectionHealthz.Check(nil); err != nil {
+		return fmt.Errorf("checking leader election: %w", err)
+	}
+
+	return nil
+}
+
+// Ready checks if operator is ready. Operator is ready once it observes an elected
+// leader, which may be either this or other instance of the operator.
+func (k *Kontroller) Ready() error {
+	k.leaderLock.RLock()
+	defer k.leaderLock.RUnlock()
+
+	if k.leader == "" {
+		return fmt.Errorf("no leader observed yet")
+	}
+
+	return nil
 }
 
 // withLeaderElection creates a new context which is cancelled when this
-// operator does not hold a lock to operate on the cluster.
-func (k *Kontroller) withLeaderElection(stop <-chan struct{}, errCh chan<- error) context.Context {
+// operator does not hold a lock to operate on the cluster. Returned channel
+// is closed once leader election finishes, including releasing the lock.
+func (k *Kontroller) withLeaderElection(stop <-chan struct{}, errCh chan<- error) (context.Context, <-chan struct{}) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	go func() {
-		// When user requests to stop the controller, cancel context to interrupt any ongoing operation.
-		<-stop
-		errCh <- nil
+		// When user requests to stop the controller, cancel context to interrupt any ongoing operation
+		// and release the lock.
+		select {
+		case <-stop:
+		case <-ctx.Done():
+		}
 
 		cancel()
 	}()
 
-	waitLeading := make(chan struct{})
+	// Buffered, as leading may start after user requested to stop the controller.
+	waitLeading := make(chan struct{}, 1)
+	done := make(chan struct{})
 
 	go func() {
+		defer close(done)
+
 		// Lease values inspired by a combination of
 		// https://github.com/kubernetes/kubernetes/blob/f7c07a121d2afadde7aa15b12a9d02858b30a0a9/pkg/apis/componentconfig/v1alpha1/defaults.go#L163-L174
 		// and the KVO values
@@ -271,36 +692,86 @@ func (k *Kontroller) withLeaderElection(stop <-chan struct{}, errCh chan<- error
 			//nolint:gomnd // Retry duration is usually around 1/10th of lease duration,
 			//             // but given low dynamics of FLUO, 1/3rd should also be fine.
 			RetryPeriod: k.leaderElectionLease / 3,
+			WatchDog:    k.leaderElectionHealthz,
+			// Release the lock when user requests shutdown, so other instance can take over
+			// without waiting for the lease to expire. Shared context is also used by reconciliation
+			// process, so it gets interrupted before lock is released.
+			ReleaseOnCancel: true,
 			Callbacks: leaderelection.LeaderCallbacks{
 				OnStartedLeading: func(ctx context.Context) { // was: func(stop <-chan struct{
 					klog.V(5).Info("Started leading")
+					k.metrics.leaderAcquisitions.Inc()
 					waitLeading <- struct{}{}
 				},
 				OnStoppedLeading: func() {
-					errCh <- fmt.Errorf("leaderelection lost")
+					// Releasing the lock on shutdown is not a loss of leadership.
+					select {
+					case <-stop:
+					default:
+						k.metrics.leaderLosses.Inc()
+
+						errCh <- fmt.Errorf("leaderelection lost")
+					}
+
 					cancel()
 				},
+				OnNewLeader: func(identity string) {
+					klog.V(5).InfoS("Observed new leader", "identity", identity)
+
+					k.metrics.leaderChanges.Inc()
+					k.metrics.leader.Reset()
+					k.metrics.leader.WithLabelValues(identity).Set(1)
+
+					k.leaderLock.Lock()
+					defer k.leaderLock.Unlock()
+
+					k.leader = identity
+				},
 			},
 		})
 	}()
 
-	<-waitLeading
+	select {
+	case <-waitLeading:
+	case <-done:
+	}
 
-	return ctx
+	return ctx, done
 }
 
 // process performs the reconcilitation to coordinate reboots.
 func (k *Kontroller) process(ctx context.Context) {
-	klog.V(4).Info("Going through a loop cycle")
+	k.reconciliations++
+
+	logger := klog.Background().WithValues(logging.KeyReconcileID, k.reconciliations)
+	ctx = klog.NewContext(ctx, logger)
+
+	logger.V(4).Info("Going through a loop cycle")
+
+	ctx, span := k.tracer.Start(ctx, "reconcile")
+	defer span.End()
+
+	start := time.Now()
+
+	defer func() {
+		k.metrics.reconciliationTime.Observe(time.Since(start).Seconds())
+	}()
+
+	// Publish status also when reconciliation fails, so the failure is visible.
+	defer k.publishStatus(ctx)
+
+	// Store decisions made before reconciliation failed as well.
+	defer k.storeDecisions(ctx)
+
+	// Pick up configuration changes. On failure, keep using previously applied configuration.
+	_ = k.runStep(ctx, "reload_config", k.reloadConfig)
 
 	// First make sure that all of our nodes are in a well-defined state with
 	// respect to our annotations and labels, and if they are not, then try to
 	// fix them.
-	klog.V(4).Info("Cleaning up node state")
-
-	if err := k.cleanupState(ctx); err != nil {
-		klog.Errorf("Failed to cleanup node state: %v", err)
+	logger.V(4).Info("Cleaning up node state")
 
+	if err := k.runStep(ctx, "cleanup_state", k.cleanupState); err != nil {
 		return
 	}
 
@@ -308,21 +779,17 @@ func (k *Kontroller) process(ctx context.Context) {
 	// annotations are set. if all annotations are set to true then remove the
 	// after-reboot=true label and set reboot-ok=false, telling the agent that
 	// the reboot has completed.
-	klog.V(4).Info("Checking if configured after-reboot annotations are set to true")
-
-	if err := k.checkAfterReboot(ctx); err != nil {
-		klog.Errorf("Failed to check after reboot: %v", err)
+	logger.V(4).Info("Checking if configured after-reboot annotations are set to true")
 
+	if err := k.runStep(ctx, "check_after_reboot", k.checkAfterReboot); err != nil {
 		return
 	}
 
 	// Find nodes which just rebooted but haven't run after-reboot checks.
 	// remove after-reboot annotations and add the after-reboot=true label.
-	klog.V(4).Info("Labeling rebooted nodes with after-reboot label")
-
-	if err := k.markAfterReboot(ctx); err != nil {
-		klog.Errorf("Failed to update recently rebooted nodes: %v", err)
+	logger.V(4).Info("Labeling rebooted nodes with after-reboot label")
 
+	if err := k.runStep(ctx, "mark_after_reboot", k.markAfterReboot); err != nil {
 		return
 	}
 
@@ -330,50 +797,97 @@ func (k *Kontroller) process(ctx context.Context) {
 	// annotations are set. if all annotations are set to true then remove the
 	// before-reboot=true label and set reboot=ok=true, telling the agent it's
 	// time to reboot.
-	klog.V(4).Info("Checking if configured before-reboot annotations are set to true")
-
-	if err := k.checkBeforeReboot(ctx); err != nil {
-		klog.Errorf("Failed to check before reboot: %v", err)
+	logger.V(4).Info("Checking if configured before-reboot annotations are set to true")
 
+	if err := k.runStep(ctx, "check_before_reboot", k.checkBeforeReboot); err != nil {
 		return
 	}
 
 	// Take some number of the rebootable nodes. remove before-reboot
 	// annotations and add the before-reboot=true label.
-	klog.V(4).Info("Labeling rebootable nodes with before-reboot label")
-
-	if err := k.markBeforeReboot(ctx); err != nil {
-		klog.Errorf("Failed to update rebootable nodes: %v", err)
+	logger.V(4).Info("Labeling rebootable nodes with before-reboot label")
 
+	if err := k.runStep(ctx, "mark_before_reboot", k.markBeforeReboot); err != nil {
 		return
 	}
 }
 
+// runStep runs given reconciliation step in its own span and with logger carrying the step as phase.
+// Duration of the step is recorded in metrics. Returned error is logged, recorded in the span and reported
+// as reconciliation failure.
+func (k *Kontroller) runStep(ctx context.Context, step string, stepF func(context.Context) error) error {
+	ctx, span := k.tracer.Start(ctx, step)
+	defer span.End()
+
+	logger := klog.FromContext(ctx).WithValues(logging.KeyPhase, step)
+
+	start := time.Now()
+
+	err := stepF(klog.NewContext(ctx, logger))
+
+	k.metrics.reconciliationSteps.WithLabelValues(step).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		logger.Error(err, "Reconciliation step failed")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		k.reconciliationFailed(step, err)
+	}
+
+	return err
+}
+
 // cleanupState attempts to make sure nodes are in a well-defined state before
-// performing state changes on them.
+// performing state changes on them. It also revokes reboot approvals which agents
+// did not act upon, reports nodes which are stuck rebooting or have stale agents and updates metrics.
 // If there is an error getting the list of nodes or updating any of them, an
 // error is immediately returned.
 func (k *Kontroller) cleanupState(ctx context.Context) error {
-	nodelist, err := k.nc.List(ctx, metav1.ListOptions{})
+	logger := klog.FromContext(ctx)
+
+	nodelist, err := k.listNodes(ctx)
 	if err != nil {
-		return fmt.Errorf("listing nodes: %w", err)
+		return err
 	}
 
 	for _, node := range nodelist.Items {
-		err = k8sutil.UpdateNodeRetry(ctx, k.nc, node.Name, func(node *corev1.Node) {
+		rebootCancelled := false
+		beforeRebootTimedOut := false
+
+		err = k.updateNode(ctx, node.Name, func(node *corev1.Node) {
+			rebootCancelled = false
+			beforeRebootTimedOut = false
+
 			// Make sure that nodes with the before-reboot label actually
 			// still wants to reboot.
 			if _, exists := node.Labels[constants.LabelBeforeReboot]; !exists {
 				return
 			}
 
-			if rebootableSelector.Matches(fields.Set(node.Annotations)) {
+			switch {
+			case emergencyRebootSelector.Matches(fields.Set(node.Annotations)):
+				logger.Info("Node is being rebooted in emergency mode, unscheduling it from rebooting",
+					logging.KeyNode, node.Name)
+			case !rebootableSelector.Matches(fields.Set(node.Annotations)):
+				rebootCancelled = true
+
+				logger.Info("Node no longer wanted to reboot while we were trying to label it so",
+					logging.KeyNode, node.Name, "annotations", node.Annotations)
+			case k.beforeRebootTimeoutExceeded(ctx, node):
+				beforeRebootTimedOut = true
+
+				logger.Info("Node did not get all before-reboot annotations in time, unscheduling it from rebooting",
+					logging.KeyNode, node.Name, "timeout", k.beforeRebootTimeout)
+
+				node.Annotations[constants.AnnotationBeforeRebootTimedOutTime] = time.Now().UTC().Format(time.RFC3339)
+			default:
 				return
 			}
 
-			klog.Warningf("Node %q no longer wanted to reboot while we were trying to label it so: %v",
-				node.Name, node.Annotations)
 			delete(node.Labels, constants.LabelBeforeReboot)
+			delete(node.Annotations, constants.AnnotationRebootStartedTime)
+			delete(node.Annotations, constants.AnnotationVersionBeforeReboot)
+			delete(node.Annotations, constants.AnnotationRebootNeededSinceBeforeReboot)
 			for _, annotation := range k.beforeRebootAnnotations {
 				delete(node.Annotations, annotation)
 			}
@@ -381,16 +895,71 @@ func (k *Kontroller) cleanupState(ctx context.Context) error {
 		if err != nil {
 			return fmt.Errorf("cleaning up node %q: %w", node.Name, err)
 		}
+
+		if rebootCancelled {
+			k.nodeEventf(node.Name, corev1.EventTypeNormal, EventReasonRebootCancelled,
+				"Node no longer needs a reboot, cancelling reboot process")
+		}
+
+		if beforeRebootTimedOut {
+			k.nodeEventf(node.Name, corev1.EventTypeWarning, EventReasonBeforeRebootTimedOut,
+				"Before-reboot annotations %v not set within %v, will retry later",
+				k.beforeRebootAnnotations, k.beforeRebootTimeout)
+		}
+	}
+
+	if err := k.revokeStaleApprovals(ctx, nodelist); err != nil {
+		return err
 	}
 
+	if err := k.revokeScheduledReboots(ctx, nodelist); err != nil {
+		return err
+	}
+
+	k.detectStuckReboots(ctx, nodelist)
+	k.recordMetrics(nodelist)
+
+	// Reboot history is not required for reconciliation, so do not fail it when metric can't be updated.
+	if err := k.recordLastReboots(ctx, nodelist); err != nil {
+		logger.Error(err, "Failed recording last reboots of nodes")
+	}
+
+	// Stale agents do not affect reconciliation either.
+	if err := k.detectStaleAgents(ctx, nodelist); err != nil {
+		logger.Error(err, "Failed detecting stale agents")
+	}
+
+	if err := k.mirrorKuredState(ctx, nodelist); err != nil {
+		logger.Error(err, "Failed mirroring kured state")
+	}
+
+	k.status.Nodes = nodePhases(nodelist)
+
 	return nil
 }
 
 type checkRebootOptions struct {
 	req         *labels.Requirement
 	annotations []string
-	label       string
-	okToReboot  string
+	// Type of checks, either "before-reboot" or "after-reboot", and reason of the event emitted when
+	// any of checks fails by setting its annotation to false.
+	checksType        string
+	checkFailedReason string
+	label             string
+	okToReboot        string
+	// Reason and message of the event emitted for each updated node.
+	eventReason  string
+	eventMessage string
+	// Optional function returning type, reason and message of the event emitted for a given node,
+	// overriding eventReason and eventMessage.
+	eventF func(*corev1.Node) (string, string, string)
+	// Additional annotations to remove once all annotations are set.
+	cleanupAnnotations []string
+	// Additional annotations to set once all annotations are set.
+	extraAnnotations map[string]string
+	// Optional function called for each node which has been successfully updated.
+	// Given node object reflects the state before the update.
+	updatedF func(context.Context, *corev1.Node)
 }
 
 // checkReboot gets all nodes with a given requirement and checks if all of the given annotations are set to true.
@@ -405,35 +974,53 @@ type checkRebootOptions struct {
 // If there is an error getting the list of nodes or updating any of them, an
 // error is immediately returned.
 func (k *Kontroller) checkReboot(ctx context.Context, opt checkRebootOptions) error {
-	nodelist, err := k.nc.List(ctx, metav1.ListOptions{})
+	logger := klog.FromContext(ctx)
+
+	nodelist, err := k.listNodes(ctx)
 	if err != nil {
-		return fmt.Errorf("listing nodes: %w", err)
+		return err
 	}
 
 	nodes := k8sutil.FilterNodesByRequirement(nodelist.Items, opt.req)
 
+	k.reportFailedChecks(ctx, opt, nodes)
+
 	for _, node := range nodes {
 		if !hasAllAnnotations(node, opt.annotations) {
 			continue
 		}
 
-		klog.V(4).Infof("Deleting label %q for %q", opt.label, node.Name)
-		klog.V(4).Infof("Setting annotation %q to %q for %q",
-			constants.AnnotationOkToReboot, opt.okToReboot, node.Name)
+		logger.V(4).Info("Deleting label and annotations, setting ok-to-reboot annotation", logging.KeyNode, node.Name,
+			"label", opt.label, "annotations", opt.annotations, "okToReboot", opt.okToReboot)
 
-		if err := k8sutil.UpdateNodeRetry(ctx, k.nc, node.Name, func(node *corev1.Node) {
-			delete(node.Labels, opt.label)
+		annotations := map[string]string{}
 
-			// Cleanup the annotations.
-			for _, annotation := range opt.annotations {
-				klog.V(4).Infof("Deleting annotation %q from node %q", annotation, node.Name)
-				delete(node.Annotations, annotation)
-			}
+		for k, v := range opt.extraAnnotations {
+			annotations[k] = v
+		}
 
-			node.Annotations[constants.AnnotationOkToReboot] = opt.okToReboot
-		}); err != nil {
+		annotations[constants.AnnotationOkToReboot] = opt.okToReboot
+
+		patch := k8sutil.NodeMetadataPatch{
+			Annotations:       annotations,
+			RemoveAnnotations: append(append([]string{}, opt.annotations...), opt.cleanupAnnotations...),
+			RemoveLabels:      []string{opt.label},
+		}
+
+		if err := k8sutil.PatchNodeAnnotationsLabels(ctx, k.nc, node.Name, patch); err != nil {
 			return fmt.Errorf("updating node %q: %w", node.Name, err)
 		}
+
+		eventType, eventReason, eventMessage := corev1.EventTypeNormal, opt.eventReason, opt.eventMessage
+		if opt.eventF != nil {
+			eventType, eventReason, eventMessage = opt.eventF(&node)
+		}
+
+		k.nodeEventf(node.Name, eventType, eventReason, eventMessage)
+
+		if opt.updatedF != nil {
+			opt.updatedF(ctx, &node)
+		}
 	}
 
 	return nil
@@ -447,10 +1034,17 @@ func (k *Kontroller) checkReboot(ctx context.Context, opt checkRebootOptions) er
 // error is immediately returned.
 func (k *Kontroller) checkBeforeReboot(ctx context.Context) error {
 	opt := checkRebootOptions{
-		req:         beforeRebootReq,
-		annotations: k.beforeRebootAnnotations,
-		label:       constants.LabelBeforeReboot,
-		okToReboot:  constants.True,
+		req:               beforeRebootReq,
+		annotations:       k.beforeRebootAnnotations,
+		checksType:        "before-reboot",
+		checkFailedReason: EventReasonBeforeRebootCheckFailed,
+		label:             constants.LabelBeforeReboot,
+		okToReboot:        constants.True,
+		eventReason:       EventReasonRebootApproved,
+		eventMessage:      "All before-reboot checks passed, approving reboot",
+		extraAnnotations: map[string]string{
+			constants.AnnotationRebootApprovedTime: time.Now().UTC().Format(time.RFC3339),
+		},
 	}
 
 	return k.checkReboot(ctx, opt)
@@ -464,15 +1058,80 @@ func (k *Kontroller) checkBeforeReboot(ctx context.Context) error {
 // error is immediately returned.
 func (k *Kontroller) checkAfterReboot(ctx context.Context) error {
 	opt := checkRebootOptions{
-		req:         afterRebootReq,
-		annotations: k.afterRebootAnnotations,
-		label:       constants.LabelAfterReboot,
-		okToReboot:  constants.False,
+		req:               afterRebootReq,
+		annotations:       k.afterRebootAnnotations,
+		checksType:        "after-reboot",
+		checkFailedReason: EventReasonAfterRebootCheckFailed,
+		label:             constants.LabelAfterReboot,
+		okToReboot:        constants.False,
+		eventReason:       EventReasonRebootCompleted,
+		eventMessage:      "All after-reboot checks passed, reboot process completed",
+		cleanupAnnotations: []string{
+			constants.AnnotationRebootStartedTime,
+			constants.AnnotationVersionBeforeReboot,
+			constants.AnnotationRebootNeededSinceBeforeReboot,
+			constants.AnnotationRebootApprovedTime,
+			constants.AnnotationRebootFastPath,
+		},
+		eventF:   afterRebootEvent,
+		updatedF: k.rebootFinished,
 	}
 
 	return k.checkReboot(ctx, opt)
 }
 
+// rebootDetails returns annotations describing the reboot process given node is being scheduled for.
+func rebootDetails(node *corev1.Node) map[string]string {
+	details := map[string]string{
+		constants.AnnotationRebootStartedTime:   time.Now().UTC().Format(time.RFC3339),
+		constants.AnnotationVersionBeforeReboot: node.Labels[constants.LabelVersion],
+	}
+
+	if since, ok := node.Annotations[constants.AnnotationRebootNeededSince]; ok {
+		details[constants.AnnotationRebootNeededSinceBeforeReboot] = since
+	}
+
+	return details
+}
+
+// afterRebootEvent returns type, reason and message of the event emitted when given node finishes
+// the reboot process. Nodes which rolled back the update are not reported as successfully updated.
+func afterRebootEvent(node *corev1.Node) (string, string, string) {
+	if rolledBack(node) {
+		return corev1.EventTypeWarning, EventReasonUpdateRolledBack,
+			"All after-reboot checks passed, but node booted the previous OS version, update has been rolled back"
+	}
+
+	return corev1.EventTypeNormal, EventReasonRebootCompleted, "All after-reboot checks passed, reboot process completed"
+}
+
+// rolledBack checks if agent reported that given node rolled back the update it was rebooted for.
+func rolledBack(node *corev1.Node) bool {
+	return node.Annotations[constants.AnnotationRolledBack] == constants.True
+}
+
+// rebootFinished is called when node finishes the reboot process.
+func (k *Kontroller) rebootFinished(ctx context.Context, node *corev1.Node) {
+	k.metrics.rebootsCompleted.Inc()
+
+	if rolledBack(node) {
+		k.metrics.updatesRolledBack.Inc()
+	}
+
+	// Nodes scheduled for rebooting before the request time has been preserved are not observed.
+	if latency := k.timeSinceAnnotation(ctx, node, constants.AnnotationRebootNeededSinceBeforeReboot); latency > 0 {
+		k.metrics.rebootLatency.Observe(latency.Seconds())
+	}
+
+	if k.rebootHistoryConfigMap == "" {
+		return
+	}
+
+	if err := k.recordReboot(ctx, newRebootRecord(ctx, node, time.Now().UTC())); err != nil {
+		klog.FromContext(ctx).Error(err, "Failed recording reboot in history", logging.KeyNode, node.Name)
+	}
+}
+
 // insideRebootWindow checks if process is inside reboot window at the time
 // of calling this function.
 //
@@ -488,80 +1147,444 @@ func (k *Kontroller) insideRebootWindow() bool {
 	return time.Now().Before(mostRecentRebootWindow.End)
 }
 
-// remainingRebootingCapacity calculates how many more nodes can be rebooted at a time based
-// on a given list of nodes.
+// insideBlackoutWindow checks if process is inside any of configured blackout windows
+// at the time of calling this function.
+func (k *Kontroller) insideBlackoutWindow() bool {
+	now := time.Now()
+
+	for _, window := range k.blackoutWindows {
+		if window.Contains(now) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pool returns name of the pool given node belongs to.
+//
+// If pool label is not configured, all nodes belong to the same pool with an empty name.
+func (k *Kontroller) pool(node *corev1.Node) string {
+	if k.poolLabel == "" {
+		return ""
+	}
+
+	return node.Labels[k.poolLabel]
+}
+
+// maxRebootingNodesInPool returns maximum number of nodes which may reboot in parallel in a given pool.
+func (k *Kontroller) maxRebootingNodesInPool(pool string) int {
+	if maxNodes, ok := k.maxRebootingNodesPerPool[pool]; ok {
+		return maxNodes
+	}
+
+	return k.maxRebootingNodes
+}
+
+// remainingRebootingCapacity calculates how many more nodes can be rebooted at a time in each pool
+// based on a given list of nodes.
+//
+// Pools with no rebooting nodes are not included in the result and have full capacity available.
 //
-// If maximum capacity is reached, it is logged and list of rebooting nodes is logged as well.
-func (k *Kontroller) remainingRebootingCapacity(nodelist *corev1.NodeList) int {
-	rebootingNodes := k8sutil.FilterNodesByAnnotation(nodelist.Items, stillRebootingSelector)
+// If maximum capacity is reached for a pool, it is logged and list of rebooting nodes is logged as well.
+func (k *Kontroller) remainingRebootingCapacity(ctx context.Context, nodelist *corev1.NodeList) map[string]int {
+	logger := klog.FromContext(ctx)
 
-	// Nodes running before and after reboot checks are still considered to be "rebooting" to us.
-	beforeRebootNodes := k8sutil.FilterNodesByRequirement(nodelist.Items, beforeRebootReq)
-	afterRebootNodes := k8sutil.FilterNodesByRequirement(nodelist.Items, afterRebootReq)
+	rebootingNodesByPool := map[string][]corev1.Node{}
 
-	rebootingNodes = append(append(rebootingNodes, beforeRebootNodes...), afterRebootNodes...)
+	for _, n := range capacityConsumingNodes(nodelist) {
+		pool := k.pool(&n)
+		rebootingNodesByPool[pool] = append(rebootingNodesByPool[pool], n)
+	}
+
+	remainingCapacity := map[string]int{}
+
+	for pool, nodes := range rebootingNodesByPool {
+		maxRebootingNodes := k.maxRebootingNodesInPool(pool)
+
+		remainingCapacity[pool] = maxRebootingNodes - len(nodes)
+
+		if remainingCapacity[pool] > 0 {
+			continue
+		}
+
+		for _, n := range nodes {
+			logger.Info("Found node still rebooting, waiting", logging.KeyNode, n.Name)
+		}
 
-	remainingCapacity := k.maxRebootingNodes - len(rebootingNodes)
+		if k.poolLabel == "" {
+			logger.Info("Found maximum of rebooting nodes; waiting for completion",
+				"count", len(nodes), "max", maxRebootingNodes)
 
-	if remainingCapacity == 0 {
-		for _, n := range rebootingNodes {
-			klog.Infof("Found node %q still rebooting, waiting", n.Name)
+			continue
 		}
 
-		klog.Infof("Found %d (of max %d) rebooting nodes; waiting for completion", len(rebootingNodes), k.maxRebootingNodes)
+		logger.Info("Found maximum of rebooting nodes in pool; waiting for completion",
+			"pool", pool, "count", len(nodes), "max", maxRebootingNodes)
 	}
 
 	return remainingCapacity
 }
 
 // nodesRequiringReboot filters given list of nodes and returns ones which requires a reboot.
+//
+// Nodes are ordered by the time they started requesting a reboot, so nodes waiting the longest
+// are rebooted first. Nodes without known request time come last. Remaining ties are broken by
+// node name, so the order does not depend on the order of given list.
 func (k *Kontroller) nodesRequiringReboot(nodelist *corev1.NodeList) []corev1.Node {
-	rebootableNodes := k8sutil.FilterNodesByAnnotation(nodelist.Items, rebootableSelector)
+	nodes := k8sutil.FilterNodes(nodelist.Items, rebootableNodeSelector)
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		iSince, iKnown := rebootNeededSince(&nodes[i])
+		jSince, jKnown := rebootNeededSince(&nodes[j])
+
+		switch {
+		case iKnown != jKnown:
+			return iKnown
+		case iKnown && !iSince.Equal(jSince):
+			return iSince.Before(jSince)
+		default:
+			return nodes[i].Name < nodes[j].Name
+		}
+	})
+
+	return nodes
+}
+
+// rebootNeededSince returns the time since which given node requests a reboot.
+//
+// If the time is not known, false is returned.
+func rebootNeededSince(node *corev1.Node) (time.Time, bool) {
+	since, err := time.Parse(time.RFC3339, node.Annotations[constants.AnnotationRebootNeededSince])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return since, true
+}
+
+// rebootDeadlineExceeded checks if given node has been requesting a reboot for longer than
+// configured reboot deadline for given node.
+//
+// If reboot deadline is not configured, false is always returned.
+func (k *Kontroller) rebootDeadlineExceeded(ctx context.Context, node *corev1.Node) bool {
+	deadline := k.rebootDeadline(node)
+	if deadline == 0 {
+		return false
+	}
+
+	return k.timeSinceAnnotation(ctx, node, constants.AnnotationRebootNeededSince) > deadline
+}
+
+// rebootDeadline returns a reboot deadline which applies to given node. Security reboot deadline
+// applies to nodes requesting a reboot to apply security update, if it is configured and shorter
+// than force reboot deadline.
+func (k *Kontroller) rebootDeadline(node *corev1.Node) time.Duration {
+	if node.Annotations[constants.AnnotationSecurityUpdate] != constants.True || k.securityRebootDeadline == 0 {
+		return k.forceRebootDeadline
+	}
+
+	if k.forceRebootDeadline != 0 && k.forceRebootDeadline < k.securityRebootDeadline {
+		return k.forceRebootDeadline
+	}
+
+	return k.securityRebootDeadline
+}
+
+// listNodes lists nodes managed by the operator, which additionally match given requirements.
+func (k *Kontroller) listNodes(ctx context.Context, reqs ...labels.Requirement) (*corev1.NodeList, error) {
+	nodelist, err := k.nc.List(ctx, metav1.ListOptions{
+		LabelSelector: k.nodeSelector.Add(reqs...).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing nodes: %w", err)
+	}
+
+	return nodelist, nil
+}
+
+// beforeRebootTimeoutExceeded checks if given node has been waiting for before-reboot annotations
+// for longer than configured before-reboot timeout.
+//
+// If before-reboot timeout is not configured, false is always returned.
+func (k *Kontroller) beforeRebootTimeoutExceeded(ctx context.Context, node *corev1.Node) bool {
+	if k.beforeRebootTimeout == 0 || hasAllAnnotations(*node, k.beforeRebootAnnotations) {
+		return false
+	}
+
+	return k.timeSinceAnnotation(ctx, node, constants.AnnotationRebootStartedTime) > k.beforeRebootTimeout
+}
+
+// waitingForBeforeRebootRetry checks if given node recently exceeded before-reboot timeout
+// and should not be scheduled for rebooting yet.
+func (k *Kontroller) waitingForBeforeRebootRetry(ctx context.Context, node *corev1.Node) bool {
+	if _, ok := node.Annotations[constants.AnnotationBeforeRebootTimedOutTime]; !ok {
+		return false
+	}
+
+	return k.timeSinceAnnotation(ctx, node, constants.AnnotationBeforeRebootTimedOutTime) <= k.beforeRebootTimeout
+}
+
+// timeSinceAnnotation returns time elapsed since the RFC 3339 timestamp stored in a given node annotation.
+//
+// If annotation is missing or cannot be parsed, zero is returned.
+func (k *Kontroller) timeSinceAnnotation(ctx context.Context, node *corev1.Node, annotation string) time.Duration {
+	value, ok := node.Annotations[annotation]
+	if !ok {
+		return 0
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "Failed parsing annotation", logging.KeyNode, node.Name, "annotation", annotation)
+
+		return 0
+	}
+
+	return time.Since(timestamp)
+}
+
+// excludedTaint returns key of the first taint of a given node, which excludes node from being
+// scheduled for rebooting.
+//
+// If node has no excluded taints, empty string is returned.
+func (k *Kontroller) excludedTaint(node *corev1.Node) string {
+	for _, taint := range node.Spec.Taints {
+		for _, excludedTaint := range k.excludedTaints {
+			if taint.Key == excludedTaint {
+				return taint.Key
+			}
+		}
+	}
 
-	return k8sutil.FilterNodesByRequirement(rebootableNodes, notBeforeRebootReq)
+	return ""
 }
 
-// rebootableNodes returns list of nodes which can be marked for rebooting based on remaining capacity.
-func (k *Kontroller) rebootableNodes(nodelist *corev1.NodeList) []*corev1.Node {
-	remainingCapacity := k.remainingRebootingCapacity(nodelist)
+// rebootableNodes returns list of nodes which can be marked for rebooting based on remaining capacity
+// of the pools and topology domains they belong to.
+//
+// When outside reboot window, only nodes which exceeded force reboot deadline are considered.
+// Nodes marked for scale-down by cluster-autoscaler are never considered.
+//
+// If disruption budgets are given, nodes which cannot be drained without violating them are skipped.
+func (k *Kontroller) rebootableNodes(
+	ctx context.Context, nodelist *corev1.NodeList, insideRebootWindow bool, budgets *disruptionBudgets,
+) []*corev1.Node {
+	logger := klog.FromContext(ctx)
+
+	remainingCapacity := k.remainingRebootingCapacity(ctx, nodelist)
+	remainingTopologyCapacity := k.remainingTopologyCapacity(ctx, nodelist)
 
 	nodesRequiringReboot := k.nodesRequiringReboot(nodelist)
 
-	chosenNodes := make([]*corev1.Node, 0, remainingCapacity)
-	for i := 0; i < remainingCapacity && i < len(nodesRequiringReboot); i++ {
-		chosenNodes = append(chosenNodes, &nodesRequiringReboot[i])
+	chosenNodes := []*corev1.Node{}
+
+	for i := range nodesRequiringReboot {
+		node := &nodesRequiringReboot[i]
+
+		if !insideRebootWindow && !k.rebootDeadlineExceeded(ctx, node) {
+			k.recordDecision(node.Name, DecisionNotScheduled, DecisionReasonOutsideRebootWindow,
+				"Reboot window is closed and reboot deadline has not been exceeded")
+
+			continue
+		}
+
+		if taint := k.excludedTaint(node); taint != "" {
+			logger.V(4).Info("Node has excluded taint, not scheduling it for rebooting",
+				logging.KeyNode, node.Name, "taint", taint)
+
+			k.recordDecision(node.Name, DecisionNotScheduled, DecisionReasonExcludedTaint,
+				fmt.Sprintf("Node has excluded taint %q", taint))
+
+			continue
+		}
+
+		if taint := scaleDownTaint(node); taint != "" {
+			logger.V(4).Info("Node is marked for scale-down, not scheduling it for rebooting",
+				logging.KeyNode, node.Name, "taint", taint)
+
+			k.recordDecision(node.Name, DecisionNotScheduled, DecisionReasonScaleDown,
+				fmt.Sprintf("Node is marked for scale-down with taint %q", taint))
+
+			continue
+		}
+
+		if k.waitingForBeforeRebootRetry(ctx, node) {
+			logger.V(4).Info("Node recently exceeded before-reboot timeout, not scheduling it for rebooting yet",
+				logging.KeyNode, node.Name)
+
+			k.recordDecision(node.Name, DecisionNotScheduled, DecisionReasonBeforeRebootRetry,
+				"Node recently exceeded before-reboot timeout")
+
+			continue
+		}
+
+		if k.waitingForApprovalRetry(ctx, node) {
+			logger.V(4).Info("Reboot approval of node has been recently revoked, not scheduling it for rebooting yet",
+				logging.KeyNode, node.Name)
+
+			k.recordDecision(node.Name, DecisionNotScheduled, DecisionReasonApprovalRetry,
+				"Reboot approval of node has been recently revoked")
+
+			continue
+		}
+
+		pool := k.pool(node)
+
+		capacity, ok := remainingCapacity[pool]
+		if !ok {
+			capacity = k.maxRebootingNodesInPool(pool)
+		}
+
+		if capacity <= 0 {
+			k.recordDecision(node.Name, DecisionNotScheduled, DecisionReasonNoRebootingCapacity,
+				fmt.Sprintf("Maximum number of rebooting nodes in pool %q has been reached", pool))
+
+			continue
+		}
+
+		if domain := k.exhaustedTopologyDomain(remainingTopologyCapacity, node); domain != "" {
+			logger.V(4).Info("No rebooting capacity left in topology domain, not scheduling node for rebooting",
+				logging.KeyNode, node.Name, "domain", domain)
+
+			k.recordDecision(node.Name, DecisionNotScheduled, DecisionReasonNoTopologyCapacity,
+				fmt.Sprintf("No rebooting capacity left in topology domain %q", domain))
+
+			continue
+		}
+
+		if budgets != nil {
+			if pdb, ok := budgets.reserve(node); !ok {
+				logger.Info("Draining node would violate PodDisruptionBudget, not scheduling it for rebooting yet",
+					logging.KeyNode, node.Name, "podDisruptionBudget", pdb)
+
+				k.recordDecision(node.Name, DecisionNotScheduled, DecisionReasonPodDisruptionBudget,
+					fmt.Sprintf("Draining node would violate PodDisruptionBudget %q", pdb))
+
+				continue
+			}
+		}
+
+		remainingCapacity[pool] = capacity - 1
+		k.reserveTopologyCapacity(remainingTopologyCapacity, node)
+
+		if !insideRebootWindow {
+			logger.Info("Node exceeded reboot deadline, scheduling reboot outside reboot window",
+				logging.KeyNode, node.Name, "deadline", k.rebootDeadline(node))
+		}
+
+		chosenNodes = append(chosenNodes, node)
 	}
 
-	klog.Infof("Found %d nodes that need a reboot", len(chosenNodes))
+	logger.Info("Found nodes that need a reboot", "count", len(chosenNodes))
 
 	return chosenNodes
 }
 
+// previousAttemptAnnotations are annotations left over from previous attempts to reboot the node.
+var previousAttemptAnnotations = []string{
+	constants.AnnotationBeforeRebootTimedOutTime,
+	constants.AnnotationRebootApprovalRevokedTime,
+	constants.AnnotationRebootFastPath,
+}
+
 // markBeforeReboot gets nodes which want to reboot and marks them with the
 // before-reboot=true label. This is considered the beginning of the reboot
 // process from the perspective of the update-operator. It will only mark
 // nodes with this label up to the maximum number of concurrently rebootable
 // nodes as configured with the maxRebootingNodes constant. It also checks if
-// we are inside the reboot window.
+// we are inside the reboot window, unless node exceeded the force reboot deadline,
+// that we are outside of all blackout windows and that operator is not paused.
+// When configured, it also skips nodes which cannot be drained without violating
+// any PodDisruptionBudget.
+// When configured, cordoned nodes without workload are marked regardless of reboot
+// window and remaining rebooting capacity.
 // It cleans up the before-reboot annotations before it applies the label, in
 // case there are any left over from the last reboot.
 // If there is an error getting the list of nodes or updating any of them, an
 // error is immediately returned.
 func (k *Kontroller) markBeforeReboot(ctx context.Context) error {
-	nodelist, err := k.nc.List(ctx, metav1.ListOptions{})
+	logger := klog.FromContext(ctx)
+
+	nodelist, err := k.listNodes(ctx)
 	if err != nil {
-		return fmt.Errorf("listing nodes: %w", err)
+		return err
 	}
 
-	if !k.insideRebootWindow() {
-		klog.V(4).Info("We are outside the reboot window; not labeling rebootable nodes for now")
+	if k.insideBlackoutWindow() {
+		logger.V(4).Info("We are inside a blackout window; not labeling rebootable nodes for now")
+
+		k.notScheduled(k.nodesRequiringReboot(nodelist), DecisionReasonBlackoutWindow, "Blackout window is active")
 
 		return nil
 	}
 
+	if k.paused {
+		logger.V(4).Info("Operator is paused; not labeling rebootable nodes for now")
+
+		k.notScheduled(k.nodesRequiringReboot(nodelist), DecisionReasonPaused, "Operator is paused")
+
+		return nil
+	}
+
+	if k.fastPathCordonedNodes {
+		if nodelist, err = k.markFastPathNodes(ctx, nodelist); err != nil {
+			return err
+		}
+	}
+
+	insideRebootWindow := k.insideRebootWindow()
+
+	if !insideRebootWindow && k.forceRebootDeadline == 0 && k.securityRebootDeadline == 0 {
+		logger.V(4).Info("We are outside the reboot window; not labeling rebootable nodes for now")
+
+		k.notScheduled(k.nodesRequiringReboot(nodelist), DecisionReasonOutsideRebootWindow,
+			"Reboot window is closed")
+
+		return nil
+	}
+
+	if k.deferRebootsDuringAutoscaling {
+		reason, err := k.clusterScaling(ctx, nodelist)
+		if err != nil {
+			return fmt.Errorf("checking if cluster is scaling: %w", err)
+		}
+
+		if reason != "" {
+			logger.Info("Cluster is scaling, not labeling rebootable nodes for now", "reason", reason)
+
+			k.notScheduled(k.nodesRequiringReboot(nodelist), DecisionReasonClusterScaling,
+				fmt.Sprintf("Cluster is scaling: %s", reason))
+
+			return nil
+		}
+	}
+
+	var budgets *disruptionBudgets
+
+	if k.respectPodDisruptionBudgets {
+		if budgets, err = k.disruptionBudgets(ctx, rebootingNodes(nodelist)); err != nil {
+			return fmt.Errorf("evaluating PodDisruptionBudgets: %w", err)
+		}
+	}
+
 	// Set before-reboot=true for the chosen nodes.
-	for _, n := range k.rebootableNodes(nodelist) {
-		err = k.mark(ctx, n.Name, constants.LabelBeforeReboot, "before-reboot", k.beforeRebootAnnotations)
+	for _, n := range k.rebootableNodes(ctx, nodelist, insideRebootWindow, budgets) {
+		eventMessage := "Node scheduled for rebooting inside reboot window, running before-reboot checks"
+		if !insideRebootWindow {
+			eventMessage = fmt.Sprintf("Node exceeded reboot deadline of %v, scheduled for rebooting outside "+
+				"reboot window, running before-reboot checks", k.rebootDeadline(n))
+		}
+
+		err = k.mark(ctx, n.Name, markOptions{
+			label:             constants.LabelBeforeReboot,
+			annotationsType:   "before-reboot",
+			annotations:       k.beforeRebootAnnotations,
+			removeAnnotations: previousAttemptAnnotations,
+			extraAnnotations:  rebootDetails(n),
+			eventReason:       EventReasonScheduledForReboot,
+			eventMessage:      eventMessage,
+		})
 		if err != nil {
 			return fmt.Errorf("labeling node for before reboot checks: %w", err)
 		}
@@ -579,22 +1602,26 @@ func (k *Kontroller) markBeforeReboot(ctx context.Context) error {
 // If there is an error getting the list of nodes or updating any of them, an
 // error is immediately returned.
 func (k *Kontroller) markAfterReboot(ctx context.Context) error {
-	nodelist, err := k.nc.List(ctx, metav1.ListOptions{
-		// Filter out any nodes that are already labeled with after-reboot=true.
-		LabelSelector: fmt.Sprintf("%s!=%s", constants.LabelAfterReboot, constants.True),
-	})
+	// Filter out any nodes that are already labeled with after-reboot=true.
+	nodelist, err := k.listNodes(ctx, *notAfterRebootReq)
 	if err != nil {
-		return fmt.Errorf("listing nodes: %w", err)
+		return err
 	}
 
 	// Find nodes which just rebooted.
 	justRebootedNodes := k8sutil.FilterNodesByAnnotation(nodelist.Items, justRebootedSelector)
 
-	klog.Infof("Found %d rebooted nodes", len(justRebootedNodes))
+	klog.FromContext(ctx).Info("Found rebooted nodes", "count", len(justRebootedNodes))
 
 	// For all the nodes which just rebooted, remove any old annotations and add the after-reboot=true label.
 	for _, n := range justRebootedNodes {
-		err = k.mark(ctx, n.Name, constants.LabelAfterReboot, "after-reboot", k.afterRebootAnnotations)
+		err = k.mark(ctx, n.Name, markOptions{
+			label:           constants.LabelAfterReboot,
+			annotationsType: "after-reboot",
+			annotations:     k.afterRebootAnnotations,
+			eventReason:     EventReasonRebooted,
+			eventMessage:    "Node rebooted, running after-reboot checks",
+		})
 		if err != nil {
 			return fmt.Errorf("labeling node for after reboot checks: %w", err)
 		}
@@ -603,27 +1630,51 @@ func (k *Kontroller) markAfterReboot(ctx context.Context) error {
 	return nil
 }
 
-func (k *Kontroller) mark(ctx context.Context, nodeName, label, annotationsType string, annotations []string) error {
-	klog.V(4).Infof("Deleting annotations %v for %q", annotations, nodeName)
-	klog.V(4).Infof("Setting label %q to %q for node %q", label, constants.True, nodeName)
+type markOptions struct {
+	label           string
+	annotationsType string
+	// Annotations to remove, which node is waiting for.
+	annotations []string
+	// Additional annotations to remove.
+	removeAnnotations []string
+	// Annotations to set.
+	extraAnnotations map[string]string
+	// Reason and message of the event emitted when node is marked.
+	eventReason  string
+	eventMessage string
+}
 
-	err := k8sutil.UpdateNodeRetry(ctx, k.nc, nodeName, func(node *corev1.Node) {
-		for _, annotation := range annotations {
-			delete(node.Annotations, annotation)
-		}
-		node.Labels[label] = constants.True
-	})
-	if err != nil {
-		return fmt.Errorf("setting label %q to %q on node %q: %w", label, constants.True, nodeName, err)
+// mark removes given annotations from the node, sets given label to true and sets given extra annotations.
+func (k *Kontroller) mark(ctx context.Context, nodeName string, opt markOptions) error {
+	logger := klog.FromContext(ctx).WithValues(logging.KeyNode, nodeName)
+
+	logger.V(4).Info("Deleting annotations and setting label", "annotations", opt.annotations,
+		"label", opt.label, "value", constants.True)
+
+	patch := k8sutil.NodeMetadataPatch{
+		Annotations:       opt.extraAnnotations,
+		Labels:            map[string]string{opt.label: constants.True},
+		RemoveAnnotations: append(append([]string{}, opt.annotations...), opt.removeAnnotations...),
+	}
+
+	if err := k8sutil.PatchNodeAnnotationsLabels(ctx, k.nc, nodeName, patch); err != nil {
+		return fmt.Errorf("setting label %q to %q on node %q: %w", opt.label, constants.True, nodeName, err)
 	}
 
-	if len(annotations) > 0 {
-		klog.Infof("Waiting for %s annotations on node %q: %v", annotationsType, nodeName, annotations)
+	k.nodeEventf(nodeName, corev1.EventTypeNormal, opt.eventReason, opt.eventMessage)
+
+	if len(opt.annotations) > 0 {
+		logger.Info("Waiting for annotations", "type", opt.annotationsType, "annotations", opt.annotations)
 	}
 
 	return nil
 }
 
+// updateNode updates given Node object using updateF, retrying conflicts according to configured backoff.
+func (k *Kontroller) updateNode(ctx context.Context, nodeName string, updateF k8sutil.UpdateNode) error {
+	return k8sutil.UpdateNodeRetryWithBackoff(ctx, k.nc, nodeName, k.nodeUpdateBackoff, updateF)
+}
+
 func hasAllAnnotations(node corev1.Node, annotations []string) bool {
 	nodeAnnotations := node.GetAnnotations()
 
@@ -6,18 +6,35 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/klog/v2"
 
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8stest"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/notify"
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/operator"
 )
 
@@ -27,6 +44,12 @@ const (
 	testAfterRebootAnnotation         = "test-after-annotation"
 	testAnotherAfterRebootAnnotation  = "test-another-after-annotation"
 	testNamespace                     = "default"
+	defaultLeaderElectionLeaseSeconds = 90
+	testPoolLabel                     = "test-pool"
+	testZoneLabel                     = "topology.kubernetes.io/zone"
+	testVersion                       = "1.2.3"
+	testNewVersion                    = "1.2.4"
+	testConfigName                    = "test-fluo-config"
 )
 
 //nolint:funlen // Just many test cases.
@@ -100,6 +123,183 @@ func Test_Creating_new_operator(t *testing.T) {
 			}
 		})
 
+		t.Run("maximum_rebooting_nodes_per_pool_is_configured_without_pool_label", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.MaxRebootingNodesPerPool = map[string]int{"foo": 1}
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
+		t.Run("negative_maximum_rebooting_nodes_per_topology_domain_is_configured", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.MaxRebootingNodesPerTopology = map[string]int{testZoneLabel: -1}
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
+		t.Run("negative_maximum_rebooting_nodes_per_pool_is_configured", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.PoolLabel = testPoolLabel
+			config.MaxRebootingNodesPerPool = map[string]int{"foo": -1}
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
+		t.Run("negative_force_reboot_deadline_is_configured", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.ForceRebootDeadline = -time.Hour
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
+		t.Run("negative_security_reboot_deadline_is_configured", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.SecurityRebootDeadline = -time.Hour
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
+		t.Run("negative_before_reboot_timeout_is_configured", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.BeforeRebootTimeout = -time.Hour
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
+		t.Run("negative_reboot_approval_timeout_is_configured", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.RebootApprovalTimeout = -1
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
+		t.Run("invalid_node_update_backoff_is_configured", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.NodeUpdateBackoff = &k8sutil.Backoff{MaxRetries: -1, Factor: 1}
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
+		t.Run("invalid_cluster_autoscaler_status_configmap_is_configured", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.ClusterAutoscalerStatusConfigMap = "cluster-autoscaler-status"
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
+		t.Run("negative_stuck_reboot_threshold_is_configured", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.StuckRebootThreshold = -time.Hour
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
+		t.Run("negative_stale_agent_threshold_is_configured", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.StaleAgentThreshold = -time.Hour
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
+		t.Run("invalid_blackout_window_is_configured", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.BlackoutWindows = []string{"2023-11-20"}
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
+		t.Run("invalid_node_selector_is_configured", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.NodeSelector = "foo in ("
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
+		t.Run("negative_reboot_history_limit_is_configured", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.RebootHistoryLimit = -1
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
+		t.Run("negative_reboot_decisions_limit_is_configured", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.RebootDecisionsLimit = -1
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
+		t.Run("FluoConfig_name_is_configured_without_dynamic_client", func(t *testing.T) {
+			t.Parallel()
+
+			config := validOperatorConfig()
+			config.ConfigName = testConfigName
+
+			if _, err := operator.New(config); err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+
 		t.Run("invalid_reboot_window_is_configured", func(t *testing.T) {
 			t.Parallel()
 
@@ -205,6 +405,112 @@ func Test_Operator_shuts_down_leader_election_process_when_user_requests_shutdow
 	}
 }
 
+func Test_Operator_is_ready(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not_before_leader_is_elected", func(t *testing.T) {
+		t.Parallel()
+
+		config, _ := testConfig()
+
+		if err := kontrollerWithObjects(t, config).Ready(); err == nil {
+			t.Fatalf("Expected operator to not be ready")
+		}
+	})
+
+	t.Run("after_leader_is_elected", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := contextWithDeadline(t)
+
+		config, _ := testConfig()
+		testKontroller := kontrollerWithObjects(t, config)
+
+		stop := make(chan struct{})
+
+		t.Cleanup(func() {
+			close(stop)
+		})
+
+		runOperator(ctx, t, testKontroller, stop)
+
+		// Leader is observed asynchronously, so poll for readiness.
+		for err := testKontroller.Ready(); err != nil; err = testKontroller.Ready() {
+			select {
+			case <-ctx.Done():
+				t.Fatalf("Expected operator to become ready, got: %v", err)
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+
+		if err := testKontroller.Healthz(); err != nil {
+			t.Fatalf("Expected operator to be healthy, got: %v", err)
+		}
+	})
+}
+
+func Test_Operator_uses_for_leader_election(t *testing.T) {
+	t.Parallel()
+
+	t.Run("both_ConfigMap_and_Lease_by_default", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := contextWithDeadline(t)
+
+		config, fakeClient := testConfig()
+
+		<-process(ctx, t, config, fakeClient)
+
+		if _, err := config.Client.CoordinationV1().Leases(config.Namespace).Get(ctx,
+			"flatcar-linux-update-operator-lock", metav1.GetOptions{}); err != nil {
+			t.Fatalf("Expected lease to be created: %v", err)
+		}
+
+		if _, err := config.Client.CoreV1().ConfigMaps(config.Namespace).Get(ctx,
+			"flatcar-linux-update-operator-lock", metav1.GetOptions{}); err != nil {
+			t.Fatalf("Expected ConfigMap to be created: %v", err)
+		}
+	})
+
+	t.Run("only_Lease_when_configured", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := contextWithDeadline(t)
+
+		config, fakeClient := testConfig()
+		config.LockType = operator.LockTypeLeases
+
+		<-process(ctx, t, config, fakeClient)
+
+		if _, err := config.Client.CoordinationV1().Leases(config.Namespace).Get(ctx,
+			"flatcar-linux-update-operator-lock", metav1.GetOptions{}); err != nil {
+			t.Fatalf("Expected lease to be created: %v", err)
+		}
+
+		_, err := config.Client.CoreV1().ConfigMaps(config.Namespace).Get(ctx,
+			"flatcar-linux-update-operator-lock", metav1.GetOptions{})
+		if !apierrors.IsNotFound(err) {
+			t.Fatalf("Expected ConfigMap to not be created, got: %v", err)
+		}
+	})
+}
+
+func Test_Operator_uses_configured_lock_name_for_leader_election(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	config, fakeClient := testConfig()
+	config.LockName = "test-lock-name"
+
+	<-process(ctx, t, config, fakeClient)
+
+	if _, err := config.Client.CoordinationV1().Leases(config.Namespace).Get(ctx, config.LockName,
+		metav1.GetOptions{}); err != nil {
+		t.Fatalf("Expected lease %q to be created: %v", config.LockName, err)
+	}
+}
+
 func Test_Operator_emits_events_about_leader_election_to_configured_namespace(t *testing.T) {
 	t.Parallel()
 
@@ -222,6 +528,69 @@ func Test_Operator_emits_events_about_leader_election_to_configured_namespace(t
 	}
 }
 
+func Test_Operator_releases_leader_election_lock_before_returning_when_terminated(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	config, fakeClient := testConfig()
+	config.LockType = operator.LockTypeLeases
+
+	reconcileCycleCh := make(chan struct{}, 1)
+
+	fakeClient.PrependReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		select {
+		case reconcileCycleCh <- struct{}{}:
+		default:
+		}
+
+		return false, nil, nil
+	})
+
+	testKontroller := kontrollerWithObjects(t, config)
+
+	// Stop the operator the same way update-operator binary does.
+	signalCtx, stopNotifying := signal.NotifyContext(ctx, syscall.SIGTERM)
+	t.Cleanup(stopNotifying)
+
+	runErrCh := make(chan error, 1)
+
+	go func() {
+		runErrCh <- testKontroller.Run(signalCtx.Done())
+	}()
+
+	// Wait for operator to start leading.
+	select {
+	case <-reconcileCycleCh:
+	case <-ctx.Done():
+		t.Fatalf("Timed out waiting for operator to start leading")
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("Sending SIGTERM: %v", err)
+	}
+
+	select {
+	case err := <-runErrCh:
+		if err != nil {
+			t.Fatalf("Expected operator to stop without error, got: %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatalf("Timed out waiting for operator to stop")
+	}
+
+	// Lock must be already released when Run returns, as the process exits right after.
+	lease, err := config.Client.CoordinationV1().Leases(config.Namespace).Get(ctx,
+		"flatcar-linux-update-operator-lock", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Getting lease: %v", err)
+	}
+
+	if holder := lease.Spec.HolderIdentity; holder != nil && *holder != "" {
+		t.Fatalf("Expected lease to be released, got holder %q", *holder)
+	}
+}
+
 func Test_Operator_returns_error_when_leadership_is_lost(t *testing.T) {
 	t.Parallel()
 
@@ -285,25 +654,82 @@ func Test_Operator_returns_error_when_leadership_is_lost(t *testing.T) {
 	}
 }
 
-func stealLeaderElection(ctx context.Context, t *testing.T, config operator.Config) {
-	t.Helper()
-
-	configMapClient := config.Client.CoreV1().ConfigMaps(config.Namespace)
+func Test_Operator_exposes_leader_election_metrics(t *testing.T) {
+	t.Parallel()
 
-	configMaps, err := configMapClient.List(ctx, metav1.ListOptions{})
-	if err != nil {
-		t.Fatalf("Failed listing ConfigMaps: %v", err)
-	}
+	ctx := contextWithDeadline(t)
 
-	if c := len(configMaps.Items); c != 1 {
-		t.Fatalf("Expected exactly one ConfigMap to exist, got %d", c)
-	}
+	registry := prometheus.NewRegistry()
 
-	lock := configMaps.Items[0]
+	config, _ := testConfig()
+	config.LeaderElectionLease = 2 * time.Second
+	config.MetricsRegisterer = registry
+	testKontroller := kontrollerWithObjects(t, config)
 
-	leaderAnnotation := "control-plane.alpha.kubernetes.io/leader"
+	stop := make(chan struct{})
 
-	leader, ok := lock.Annotations[leaderAnnotation]
+	t.Cleanup(func() {
+		close(stop)
+	})
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- testKontroller.Run(stop)
+	}()
+
+	// Leader is observed asynchronously, so poll for readiness.
+	for err := testKontroller.Ready(); err != nil; err = testKontroller.Ready() {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Expected operator to become ready, got: %v", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	acquisitions := "flatcar_linux_update_operator_leader_election_acquisitions_total"
+	if v := metricValue(t, registry, acquisitions, nil); v != 1 {
+		t.Fatalf("Expected metric %q to be 1, got %v", acquisitions, v)
+	}
+
+	leader := "flatcar_linux_update_operator_leader_election_leader"
+	if v := metricValue(t, registry, leader, map[string]string{"identity": config.LockID}); v != 1 {
+		t.Fatalf("Expected metric %q for identity %q to be 1, got %v", leader, config.LockID, v)
+	}
+
+	stealLeaderElection(ctx, t, config)
+
+	select {
+	case <-ctx.Done():
+		t.Fatalf("Timed out waiting for operator to lose leadership")
+	case <-errCh:
+	}
+
+	losses := "flatcar_linux_update_operator_leader_election_losses_total"
+	if v := metricValue(t, registry, losses, nil); v != 1 {
+		t.Fatalf("Expected metric %q to be 1, got %v", losses, v)
+	}
+}
+
+func stealLeaderElection(ctx context.Context, t *testing.T, config operator.Config) {
+	t.Helper()
+
+	configMapClient := config.Client.CoreV1().ConfigMaps(config.Namespace)
+
+	configMaps, err := configMapClient.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed listing ConfigMaps: %v", err)
+	}
+
+	if c := len(configMaps.Items); c != 1 {
+		t.Fatalf("Expected exactly one ConfigMap to exist, got %d", c)
+	}
+
+	lock := configMaps.Items[0]
+
+	leaderAnnotation := "control-plane.alpha.kubernetes.io/leader"
+
+	leader, ok := lock.Annotations[leaderAnnotation]
 	if !ok {
 		t.Fatalf("Expected annotation %q not found", leaderAnnotation)
 	}
@@ -337,59 +763,44 @@ func stealLeaderElection(ctx context.Context, t *testing.T, config operator.Conf
 func Test_Operator_waits_for_leader_election_before_reconciliation(t *testing.T) {
 	t.Parallel()
 
-	rebootCancelledNode := rebootCancelledNode()
-
-	config, _ := testConfig(rebootCancelledNode)
-	config.BeforeRebootAnnotations = []string{testBeforeRebootAnnotation}
-	config.ReconciliationPeriod = 1 * time.Second
-	testKontroller := kontrollerWithObjects(t, config)
-
-	stop := make(chan struct{})
-	stopped := make(chan struct{})
-
-	go func() {
-		if err := testKontroller.Run(stop); err != nil {
-			fmt.Printf("Error running operator: %v\n", err)
-			t.Fail()
-		}
-		stopped <- struct{}{}
-	}()
-
-	time.Sleep(config.ReconciliationPeriod)
-
-	close(stop)
-
-	<-stopped
-
 	ctx := contextWithDeadline(t)
-	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootCancelledNode.Name)
 
-	if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
-		t.Fatalf("Expected label %q to be removed from Node after waiting the reconciliation period",
-			constants.LabelBeforeReboot)
-	}
+	rebootCancelledNode := rebootCancelledNode()
 
-	updatedNode.Labels[constants.LabelBeforeReboot] = constants.True
-	updatedNode.Annotations[testBeforeRebootAnnotation] = constants.True
+	holderIdentity := "other-operator"
+	leaseDurationSeconds := int32(defaultLeaderElectionLeaseSeconds)
+	now := metav1.NewMicroTime(time.Now())
 
-	if _, err := config.Client.CoreV1().Nodes().Update(ctx, updatedNode, metav1.UpdateOptions{}); err != nil {
-		t.Fatalf("Updating Node object: %v", err)
+	// Lock held by other operator instance.
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "flatcar-linux-update-operator-lock",
+			Namespace: testNamespace,
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holderIdentity,
+			LeaseDurationSeconds: &leaseDurationSeconds,
+			AcquireTime:          &now,
+			RenewTime:            &now,
+		},
 	}
 
-	config.LockID = "bar"
-	parallelKontroller := kontrollerWithObjects(t, config)
+	config, _ := testConfig(rebootCancelledNode, lease)
+	config.BeforeRebootAnnotations = []string{testBeforeRebootAnnotation}
+	config.ReconciliationPeriod = 1 * time.Second
+	config.LockType = operator.LockTypeLeases
 
-	stop = make(chan struct{})
+	stop := make(chan struct{})
 
 	t.Cleanup(func() {
 		close(stop)
 	})
 
-	runOperator(ctx, t, parallelKontroller, stop)
+	runOperator(ctx, t, kontrollerWithObjects(t, config), stop)
 
 	time.Sleep(config.ReconciliationPeriod)
 
-	updatedNode = node(ctx, t, config.Client.CoreV1().Nodes(), rebootCancelledNode.Name)
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootCancelledNode.Name)
 
 	if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
 		t.Fatalf("Expected label %q to remain on Node", constants.LabelBeforeReboot)
@@ -612,6 +1023,7 @@ func Test_Operator_counts_nodes_as_rebooting_which(t *testing.T) {
 		"has_reboot_approved":              rebootNotConfirmedNode(),
 		"are_rebooting":                    rebootingNode(),
 		"just_rebooted":                    justRebootedNode(),
+		"are_rebooting_in_emergency_mode":  emergencyRebootingNode(),
 	}
 
 	for name, extraNode := range cases {
@@ -656,6 +1068,15 @@ func Test_Operator_does_not_count_nodes_as_rebootable_which(t *testing.T) {
 		"has_reboot_paused": func(updatedNode *corev1.Node) {
 			updatedNode.Annotations[constants.AnnotationRebootPaused] = constants.True
 		},
+		"has_reboot_paused_by_agent": func(updatedNode *corev1.Node) {
+			updatedNode.Annotations[constants.AnnotationAgentRebootPaused] = constants.True
+		},
+		"has_reboots_disabled_by_reboot_strategy": func(updatedNode *corev1.Node) {
+			updatedNode.Annotations[constants.AnnotationRebootStrategy] = "off"
+		},
+		"has_emergency_reboot_requested": func(updatedNode *corev1.Node) {
+			updatedNode.Annotations[constants.AnnotationEmergencyReboot] = constants.True
+		},
 		"has_reboot_already_scheduled": func(updatedNode *corev1.Node) {
 			updatedNode.Labels[constants.LabelBeforeReboot] = constants.True
 			updatedNode.Annotations[testAnotherBeforeRebootAnnotation] = constants.False
@@ -730,250 +1151,2397 @@ func Test_Operator_does_not_schedules_reboot_process_outside_reboot_window(t *te
 	}
 }
 
-// To schedule pre-reboot hooks.
-//
-//nolint:funlen // Just many test cases.
-func Test_Operator_schedules_reboot_process(t *testing.T) {
+//nolint:funlen // Just many sub-tests.
+func Test_Operator_with_fast_path_for_cordoned_nodes_configured(t *testing.T) {
 	t.Parallel()
 
 	ctx := contextWithDeadline(t)
 
-	t.Run("only_during_reboot_window", func(t *testing.T) {
+	t.Run("schedules_reboot_for_cordoned_node_without_workload_outside_reboot_window", func(t *testing.T) {
 		t.Parallel()
 
-		rebootableNode := rebootableNode()
+		cordonedNode := rebootableNode()
+		cordonedNode.Spec.Unschedulable = true
 
-		config, fakeClient := testConfig(rebootableNode)
-		config.RebootWindowStart = "Mon 00:00"
-		config.RebootWindowLength = fmt.Sprintf("%ds", (7*24*60*60)-1)
+		daemonSetPod := testPod("daemonset-pod", cordonedNode.Name)
+		daemonSetPod.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: "apps/v1",
+				Kind:       "DaemonSet",
+				Name:       "foo",
+				Controller: &[]bool{true}[0],
+			},
+		}
+
+		completedPod := testPod("completed-pod", cordonedNode.Name)
+		completedPod.Status.Phase = corev1.PodSucceeded
+
+		config, fakeClient := testConfig(cordonedNode, daemonSetPod, completedPod)
+		config.RebootWindowStart = "Mon 14:00"
+		config.RebootWindowLength = "0s"
+		config.FastPathCordonedNodes = true
 
 		nodeUpdated := nodeUpdatedNTimes(fakeClient, 1)
 		<-process(ctx, t, config, fakeClient)
 		<-nodeUpdated
 
-		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), cordonedNode.Name)
 		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
-			t.Fatalf("Expected node %q to be scheduled for reboot", rebootableNode.Name)
+			t.Fatalf("Expected node %q to be scheduled for reboot", cordonedNode.Name)
+		}
+
+		if v := updatedNode.Annotations[constants.AnnotationRebootFastPath]; v != constants.True {
+			t.Fatalf("Expected annotation %q to be %q, got %q", constants.AnnotationRebootFastPath, constants.True, v)
 		}
 	})
 
-	t.Run("only_for_maximum_number_of_rebooting_nodes_in_parallel", func(t *testing.T) {
+	t.Run("does_not_schedule_reboot_for_cordoned_node_running_workload_outside_reboot_window", func(t *testing.T) {
+		t.Parallel()
+
+		cordonedNode := rebootableNode()
+		cordonedNode.Spec.Unschedulable = true
+
+		config, fakeClient := testConfig(cordonedNode, testPod("foo", cordonedNode.Name))
+		config.RebootWindowStart = "Mon 14:00"
+		config.RebootWindowLength = "0s"
+		config.FastPathCordonedNodes = true
+
+		<-process(ctx, t, config, fakeClient)
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), cordonedNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+			t.Fatalf("Unexpected node %q scheduled for reboot", cordonedNode.Name)
+		}
+	})
+
+	t.Run("does_not_count_nodes_rebooting_using_fast_path_against_maximum_rebooting_nodes", func(t *testing.T) {
 		t.Parallel()
 
 		rebootableNode := rebootableNode()
 
-		config, fakeClient := testConfig(rebootableNode, rebootNotConfirmedNode())
+		fastPathNode := rebootingNode()
+		fastPathNode.Annotations[constants.AnnotationRebootFastPath] = constants.True
 
+		config, fakeClient := testConfig(rebootableNode, fastPathNode)
+		config.FastPathCordonedNodes = true
+
+		nodeUpdated := nodeUpdatedNTimes(fakeClient, 2)
 		<-process(ctx, t, config, fakeClient)
+		<-nodeUpdated
 
 		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
-		if v, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok && v == constants.True {
-			t.Fatalf("Unexpected node %q scheduled for reboot", rebootableNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
+			t.Fatalf("Expected node %q to be scheduled for reboot", rebootableNode.Name)
 		}
 	})
+}
 
-	t.Run("for_nodes_which_are_rebootable", func(t *testing.T) {
+//nolint:funlen // Just many test cases.
+func Test_Operator_enforces_maximum_number_of_rebooting_nodes_per_pool(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	t.Run("by_not_scheduling_reboot_for_nodes_in_pool_without_remaining_capacity", func(t *testing.T) {
 		t.Parallel()
 
-		scheduledForRebootNode := scheduledForRebootNode()
+		rebootableNode := rebootableNode()
+		rebootableNode.Labels[testPoolLabel] = "foo"
 
-		config, fakeClient := testConfig(scheduledForRebootNode)
+		rebootingNode := rebootNotConfirmedNode()
+		rebootingNode.Labels[testPoolLabel] = "foo"
 
-		<-process(ctx, t, config, fakeClient)
+		config, fakeClient := testConfig(rebootableNode, rebootingNode)
+		config.PoolLabel = testPoolLabel
+		config.MaxRebootingNodes = 5
+		config.MaxRebootingNodesPerPool = map[string]int{"foo": 1}
 
-		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), scheduledForRebootNode.Name)
+		<-process(ctx, t, config, fakeClient)
 
-		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
-			t.Fatalf("Unexpected node %q scheduled for reboot", updatedNode.Name)
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if v, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok && v == constants.True {
+			t.Fatalf("Unexpected node %q scheduled for reboot", rebootableNode.Name)
 		}
 	})
 
-	t.Run("by", func(t *testing.T) {
+	t.Run("by_scheduling_reboot_for_nodes_in_pool_with_remaining_capacity", func(t *testing.T) {
 		t.Parallel()
 
 		rebootableNode := rebootableNode()
-		rebootableNode.Annotations[testBeforeRebootAnnotation] = constants.True
+		rebootableNode.Labels[testPoolLabel] = "bar"
 
-		config, fakeClient := testConfig(rebootableNode)
-		config.BeforeRebootAnnotations = []string{testBeforeRebootAnnotation}
+		rebootingNode := rebootNotConfirmedNode()
+		rebootingNode.Labels[testPoolLabel] = "foo"
+
+		config, fakeClient := testConfig(rebootableNode, rebootingNode)
+		config.PoolLabel = testPoolLabel
+		config.MaxRebootingNodesPerPool = map[string]int{"foo": 1}
 
 		nodeUpdated := nodeUpdatedNTimes(fakeClient, 1)
 		<-process(ctx, t, config, fakeClient)
 		<-nodeUpdated
 
 		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
+			t.Fatalf("Expected node %q to be scheduled for reboot", rebootableNode.Name)
+		}
+	})
 
-		t.Run("removing_all_before_reboot_annotations", func(t *testing.T) {
-			t.Parallel()
+	t.Run("by_scheduling_reboot_for_multiple_nodes_in_pool_with_higher_limit", func(t *testing.T) {
+		t.Parallel()
 
-			if _, ok := updatedNode.Annotations[testBeforeRebootAnnotation]; ok {
-				t.Fatalf("Unexpected annotation %q found", testBeforeRebootAnnotation)
-			}
-		})
+		rebootableNode := rebootableNode()
+		rebootableNode.Labels[testPoolLabel] = "batch"
 
-		t.Run("setting_before_reboot_label_to_true", func(t *testing.T) {
-			t.Parallel()
+		anotherRebootableNode := rebootableNode.DeepCopy()
+		anotherRebootableNode.Name = "another-rebootable"
 
-			beforeReboot, ok := updatedNode.Labels[constants.LabelBeforeReboot]
-			if !ok {
-				t.Fatalf("Expected label %q not found, got %v instead", constants.LabelBeforeReboot, updatedNode.Labels)
-			}
+		config, fakeClient := testConfig(rebootableNode, anotherRebootableNode)
+		config.PoolLabel = testPoolLabel
+		config.MaxRebootingNodesPerPool = map[string]int{"batch": 2}
 
-			if beforeReboot != constants.True {
-				t.Fatalf("Unexpected label value: %q", beforeReboot)
+		nodeUpdated := nodeUpdatedNTimes(fakeClient, 2)
+		<-process(ctx, t, config, fakeClient)
+		<-nodeUpdated
+
+		for _, name := range []string{rebootableNode.Name, anotherRebootableNode.Name} {
+			updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), name)
+			if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
+				t.Fatalf("Expected node %q to be scheduled for reboot", name)
 			}
-		})
+		}
 	})
 }
 
-func Test_Operator_approves_reboot_process_for_nodes_which_have(t *testing.T) {
+//nolint:funlen // Just many test cases.
+func Test_Operator_enforces_maximum_number_of_rebooting_nodes_per_topology_domain(t *testing.T) {
 	t.Parallel()
 
 	ctx := contextWithDeadline(t)
 
-	cases := map[string]struct {
-		mutateF        func(*corev1.Node)
-		expectRebootOK bool
-	}{
-		"all_conditions_met": {
-			// Node without mutation should get ok-to-reboot.
-			expectRebootOK: true,
-		},
-		"before_reboot_label": {
-			mutateF: func(updatedNode *corev1.Node) {
-				// Node without before-reboot label won't get ok-to-reboot.
-				delete(updatedNode.Labels, constants.LabelBeforeReboot)
-			},
-		},
-		"all_before_reboot_annotations_set_to_true": {
-			mutateF: func(updatedNode *corev1.Node) {
-				// Node without all before reboot annotations won't get ok-to-reboot.
-				updatedNode.Annotations[testBeforeRebootAnnotation] = constants.False
-			},
-		},
-	}
+	t.Run("by_not_scheduling_reboot_for_nodes_in_domain_without_remaining_capacity", func(t *testing.T) {
+		t.Parallel()
 
-	for name, testCase := range cases {
-		testCase := testCase
+		rebootableNode := rebootableNode()
+		rebootableNode.Labels[testZoneLabel] = "a"
 
-		t.Run(name, func(t *testing.T) {
-			t.Parallel()
+		rebootingNode := rebootNotConfirmedNode()
+		rebootingNode.Labels[testZoneLabel] = "a"
 
-			readyToRebootNode := readyToRebootNode()
-			if testCase.mutateF != nil {
-				testCase.mutateF(readyToRebootNode)
-			}
+		config, fakeClient := testConfig(rebootableNode, rebootingNode)
+		config.MaxRebootingNodes = 5
+		config.MaxRebootingNodesPerTopology = map[string]int{testZoneLabel: 1}
 
-			config, fakeClient := testConfig(readyToRebootNode)
+		<-process(ctx, t, config, fakeClient)
 
-			// Use beforeRebootAnnotations to be able to test moment when node has before-reboot
-			// label, but it cannot be removed yet.
-			config.BeforeRebootAnnotations = []string{testBeforeRebootAnnotation}
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+			t.Fatalf("Unexpected node %q scheduled for reboot", rebootableNode.Name)
+		}
+	})
 
-			<-process(ctx, t, config, fakeClient)
+	t.Run("by_not_scheduling_reboot_for_nodes_in_domain_with_zero_capacity", func(t *testing.T) {
+		t.Parallel()
 
-			updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), readyToRebootNode.Name)
+		rebootableNode := rebootableNode()
+		rebootableNode.Labels[testZoneLabel] = "a"
 
-			v, ok := updatedNode.Annotations[constants.AnnotationOkToReboot]
-			if testCase.expectRebootOK && (!ok || v != constants.True) {
-				t.Fatalf("Expected reboot-ok annotation, got %v", updatedNode.Annotations)
-			}
+		config, fakeClient := testConfig(rebootableNode)
+		config.MaxRebootingNodes = 5
+		config.MaxRebootingNodesPerTopology = map[string]int{testZoneLabel: 0}
 
-			if !testCase.expectRebootOK && ok && v == constants.True {
-				t.Fatalf("Unexpected reboot-ok annotation")
-			}
-		})
-	}
-}
+		<-process(ctx, t, config, fakeClient)
 
-// To inform agent it can proceed with node draining and rebooting.
-func Test_Operator_approves_reboot_process_by(t *testing.T) {
-	t.Parallel()
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+			t.Fatalf("Unexpected node %q scheduled for reboot", rebootableNode.Name)
+		}
+	})
 
-	readyToRebootNode := readyToRebootNode()
+	t.Run("by_scheduling_reboot_for_nodes_in_domain_with_remaining_capacity", func(t *testing.T) {
+		t.Parallel()
 
-	config, fakeClient := testConfig(readyToRebootNode)
-	config.BeforeRebootAnnotations = []string{testBeforeRebootAnnotation}
+		rebootableNode := rebootableNode()
+		rebootableNode.Labels[testZoneLabel] = "b"
 
-	ctx := contextWithDeadline(t)
+		rebootingNode := rebootNotConfirmedNode()
+		rebootingNode.Labels[testZoneLabel] = "a"
 
-	<-process(ctx, t, config, fakeClient)
+		config, fakeClient := testConfig(rebootableNode, rebootingNode)
+		config.MaxRebootingNodes = 5
+		config.MaxRebootingNodesPerTopology = map[string]int{testZoneLabel: 1}
 
-	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), readyToRebootNode.Name)
+		nodeUpdated := nodeUpdatedNTimes(fakeClient, 2)
+		<-process(ctx, t, config, fakeClient)
+		<-nodeUpdated
 
-	// To de-schedule hook pods.
-	t.Run("removing_before_reboot_label", func(t *testing.T) {
-		t.Parallel()
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
+			t.Fatalf("Expected node %q to be scheduled for reboot", rebootableNode.Name)
+		}
+	})
+
+	t.Run("by_scheduling_reboot_for_single_node_in_domain_at_a_time", func(t *testing.T) {
+		t.Parallel()
+
+		rebootableNode := rebootableNode()
+		rebootableNode.Labels[testZoneLabel] = "a"
+
+		anotherRebootableNode := rebootableNode.DeepCopy()
+		anotherRebootableNode.Name = "another-rebootable"
+
+		config, fakeClient := testConfig(rebootableNode, anotherRebootableNode)
+		config.MaxRebootingNodes = 5
+		config.MaxRebootingNodesPerTopology = map[string]int{testZoneLabel: 1}
+
+		nodeUpdated := nodeUpdatedNTimes(fakeClient, 2)
+		<-process(ctx, t, config, fakeClient)
+		<-nodeUpdated
+
+		scheduledNodes := 0
+
+		for _, name := range []string{rebootableNode.Name, anotherRebootableNode.Name} {
+			updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), name)
+			if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+				scheduledNodes++
+			}
+		}
+
+		if scheduledNodes != 1 {
+			t.Fatalf("Expected exactly one node to be scheduled for reboot, got %d", scheduledNodes)
+		}
+	})
+}
+
+func Test_Operator_does_not_schedule_reboot_process_inside_blackout_window(t *testing.T) {
+	t.Parallel()
+
+	rebootableNode := rebootableNode()
+	rebootableNode.Annotations[constants.AnnotationRebootNeededSince] = time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+
+	now := time.Now().UTC()
+
+	config, fakeClient := testConfig(rebootableNode)
+	config.BlackoutWindows = []string{
+		fmt.Sprintf("%s/%s", now.Add(-time.Hour).Format(time.RFC3339), now.Add(time.Hour).Format(time.RFC3339)),
+	}
+	// Blackout window should take precedence over force reboot deadline.
+	config.ForceRebootDeadline = time.Hour
+
+	ctx := contextWithDeadline(t)
+
+	<-process(ctx, t, config, fakeClient)
+
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+	if v, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok && v == constants.True {
+		t.Fatalf("Unexpected node %q scheduled for reboot", rebootableNode.Name)
+	}
+}
+
+func Test_Operator_schedules_reboot_process_outside_reboot_window_for_nodes_which_exceeded_force_reboot_deadline(
+	t *testing.T,
+) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	rebootableNode := rebootableNode()
+	rebootableNode.Annotations[constants.AnnotationRebootNeededSince] = time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+
+	notOverdueNode := rebootableNode.DeepCopy()
+	notOverdueNode.Name = "not-overdue"
+	notOverdueNode.Annotations[constants.AnnotationRebootNeededSince] = time.Now().Format(time.RFC3339)
+
+	config, fakeClient := testConfig(notOverdueNode, rebootableNode)
+	config.RebootWindowStart = "Mon 14:00"
+	config.RebootWindowLength = "0s"
+	config.ForceRebootDeadline = time.Hour
+
+	nodeUpdated := nodeUpdatedNTimes(fakeClient, 1)
+	<-process(ctx, t, config, fakeClient)
+	<-nodeUpdated
+
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+	if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
+		t.Fatalf("Expected node %q to be scheduled for reboot", rebootableNode.Name)
+	}
+
+	updatedNode = node(ctx, t, config.Client.CoreV1().Nodes(), notOverdueNode.Name)
+	if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+		t.Fatalf("Unexpected node %q scheduled for reboot", notOverdueNode.Name)
+	}
+}
+
+func Test_Operator_schedules_reboot_process_outside_reboot_window_for_security_updates_exceeding_security_deadline(
+	t *testing.T,
+) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	securityUpdateNode := rebootableNode()
+	securityUpdateNode.Annotations[constants.AnnotationRebootNeededSince] = time.Now().Add(-2 * time.Hour).Format(
+		time.RFC3339)
+	securityUpdateNode.Annotations[constants.AnnotationSecurityUpdate] = constants.True
+
+	regularUpdateNode := securityUpdateNode.DeepCopy()
+	regularUpdateNode.Name = "regular-update"
+	regularUpdateNode.Annotations[constants.AnnotationSecurityUpdate] = constants.False
+
+	config, fakeClient := testConfig(regularUpdateNode, securityUpdateNode)
+	config.RebootWindowStart = "Mon 14:00"
+	config.RebootWindowLength = "0s"
+	config.ForceRebootDeadline = 24 * time.Hour
+	config.SecurityRebootDeadline = time.Hour
+
+	nodeUpdated := nodeUpdatedNTimes(fakeClient, 1)
+	<-process(ctx, t, config, fakeClient)
+	<-nodeUpdated
+
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), securityUpdateNode.Name)
+	if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
+		t.Fatalf("Expected node %q to be scheduled for reboot", securityUpdateNode.Name)
+	}
+
+	updatedNode = node(ctx, t, config.Client.CoreV1().Nodes(), regularUpdateNode.Name)
+	if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+		t.Fatalf("Unexpected node %q scheduled for reboot", regularUpdateNode.Name)
+	}
+}
+
+func Test_Operator_unschedules_reboot_process_for_nodes_which_exceeded_before_reboot_timeout(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	scheduledForRebootNode := scheduledForRebootNode()
+	scheduledForRebootNode.Annotations[constants.AnnotationRebootStartedTime] = time.Now().Add(-2 * time.Hour).Format(
+		time.RFC3339)
+
+	config, fakeClient := testConfig(scheduledForRebootNode)
+	config.BeforeRebootAnnotations = []string{testBeforeRebootAnnotation}
+	config.BeforeRebootTimeout = time.Hour
+
+	<-process(ctx, t, config, fakeClient)
+
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), scheduledForRebootNode.Name)
+
+	t.Run("by_removing_before_reboot_label", func(t *testing.T) {
+		t.Parallel()
 
 		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
 			t.Fatalf("Unexpected label %q found", constants.LabelBeforeReboot)
 		}
 	})
 
-	t.Run("removing_all_before_reboot_annotations", func(t *testing.T) {
+	t.Run("by_recording_timeout_time", func(t *testing.T) {
 		t.Parallel()
 
-		if _, ok := updatedNode.Annotations[testBeforeRebootAnnotation]; ok {
-			t.Fatalf("Unexpected annotation %q found", testBeforeRebootAnnotation)
+		value := updatedNode.Annotations[constants.AnnotationBeforeRebootTimedOutTime]
+
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			t.Fatalf("Expected annotation %q to be a valid RFC 3339 timestamp, got %q: %v",
+				constants.AnnotationBeforeRebootTimedOutTime, value, err)
+		}
+	})
+
+	t.Run("by_emitting_warning_event", func(t *testing.T) {
+		t.Parallel()
+
+		event := nodeEvent(ctx, t, config.Client, scheduledForRebootNode.Name, operator.EventReasonBeforeRebootTimedOut)
+
+		if event.Type != corev1.EventTypeWarning {
+			t.Fatalf("Expected event type %q, got %q", corev1.EventTypeWarning, event.Type)
+		}
+	})
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Operator_revokes_reboot_approval_for_nodes_which_did_not_start_rebooting_within_timeout(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	approvedNode := rebootNotConfirmedNode()
+	approvedNode.Annotations[constants.AnnotationRebootApprovedTime] = time.Now().Add(-2 * time.Hour).Format(
+		time.RFC3339)
+
+	rebootableNode := rebootableNode()
+
+	config, fakeClient := testConfig(approvedNode, rebootableNode)
+	config.RebootApprovalTimeout = time.Hour
+
+	nodeUpdated := nodeUpdatedNTimes(fakeClient, 3)
+	<-process(ctx, t, config, fakeClient)
+	<-nodeUpdated
+
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), approvedNode.Name)
+
+	t.Run("by_informing_agent_to_not_proceed_with_reboot_process", func(t *testing.T) {
+		t.Parallel()
+
+		if v := updatedNode.Annotations[constants.AnnotationOkToReboot]; v != constants.False {
+			t.Fatalf("Expected annotation %q value to be %q, got %q", constants.AnnotationOkToReboot, constants.False, v)
+		}
+	})
+
+	t.Run("by_recording_revocation_time", func(t *testing.T) {
+		t.Parallel()
+
+		value := updatedNode.Annotations[constants.AnnotationRebootApprovalRevokedTime]
+
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			t.Fatalf("Expected annotation %q to be a valid RFC 3339 timestamp, got %q: %v",
+				constants.AnnotationRebootApprovalRevokedTime, value, err)
+		}
+	})
+
+	t.Run("by_not_scheduling_revoked_node_for_reboot_right_away", func(t *testing.T) {
+		t.Parallel()
+
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+			t.Fatalf("Unexpected node %q scheduled for reboot", approvedNode.Name)
+		}
+	})
+
+	t.Run("by_returning_rebooting_capacity_to_other_nodes", func(t *testing.T) {
+		t.Parallel()
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
+			t.Fatalf("Expected node %q to be scheduled for reboot", rebootableNode.Name)
+		}
+	})
+
+	t.Run("by_emitting_warning_event", func(t *testing.T) {
+		t.Parallel()
+
+		event := nodeEvent(ctx, t, config.Client, approvedNode.Name, operator.EventReasonRebootApprovalRevoked)
+
+		if event.Type != corev1.EventTypeWarning {
+			t.Fatalf("Expected event type %q, got %q", corev1.EventTypeWarning, event.Type)
+		}
+	})
+}
+
+func Test_Operator_does_not_revoke_reboot_approval_for_nodes_within_timeout(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	approvedNode := rebootNotConfirmedNode()
+	approvedNode.Annotations[constants.AnnotationRebootApprovedTime] = time.Now().Format(time.RFC3339)
+
+	config, fakeClient := testConfig(approvedNode)
+	config.RebootApprovalTimeout = time.Hour
+
+	<-process(ctx, t, config, fakeClient)
+
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), approvedNode.Name)
+	if v := updatedNode.Annotations[constants.AnnotationOkToReboot]; v != constants.True {
+		t.Fatalf("Expected annotation %q value to be %q, got %q", constants.AnnotationOkToReboot, constants.True, v)
+	}
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Operator_revokes_reboot_approval_for_nodes_with_scheduled_reboot_when_rebooting_is_no_longer_allowed(
+	t *testing.T,
+) {
+	t.Parallel()
+
+	now := time.Now().UTC()
+
+	scheduledRebootNode := func() *corev1.Node {
+		node := rebootingNode()
+		node.Annotations[constants.AnnotationRebootScheduledTime] = now.Add(time.Hour).Format(time.RFC3339)
+
+		return node
+	}
+
+	blackoutWindow := fmt.Sprintf("%s/%s", now.Add(-time.Hour).Format(time.RFC3339),
+		now.Add(time.Hour).Format(time.RFC3339))
+
+	t.Run("when_inside_blackout_window", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := contextWithDeadline(t)
+
+		scheduledRebootNode := scheduledRebootNode()
+
+		config, fakeClient := testConfig(scheduledRebootNode)
+		config.BlackoutWindows = []string{blackoutWindow}
+
+		<-process(ctx, t, config, fakeClient)
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), scheduledRebootNode.Name)
+
+		if v := updatedNode.Annotations[constants.AnnotationOkToReboot]; v != constants.False {
+			t.Fatalf("Expected annotation %q value to be %q, got %q", constants.AnnotationOkToReboot, constants.False, v)
+		}
+
+		value := updatedNode.Annotations[constants.AnnotationRebootApprovalRevokedTime]
+
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			t.Fatalf("Expected annotation %q to be a valid RFC 3339 timestamp, got %q: %v",
+				constants.AnnotationRebootApprovalRevokedTime, value, err)
+		}
+
+		event := nodeEvent(ctx, t, config.Client, scheduledRebootNode.Name, operator.EventReasonRebootApprovalRevoked)
+
+		if event.Type != corev1.EventTypeNormal {
+			t.Fatalf("Expected event type %q, got %q", corev1.EventTypeNormal, event.Type)
+		}
+	})
+
+	t.Run("when_rebooting_the_node_is_paused", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := contextWithDeadline(t)
+
+		scheduledRebootNode := scheduledRebootNode()
+		scheduledRebootNode.Annotations[constants.AnnotationRebootPaused] = constants.True
+
+		config, fakeClient := testConfig(scheduledRebootNode)
+
+		<-process(ctx, t, config, fakeClient)
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), scheduledRebootNode.Name)
+
+		if v := updatedNode.Annotations[constants.AnnotationOkToReboot]; v != constants.False {
+			t.Fatalf("Expected annotation %q value to be %q, got %q", constants.AnnotationOkToReboot, constants.False, v)
+		}
+	})
+
+	t.Run("unless_scheduled_reboot_time_has_passed", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := contextWithDeadline(t)
+
+		scheduledRebootNode := scheduledRebootNode()
+		scheduledRebootNode.Annotations[constants.AnnotationRebootScheduledTime] = now.Add(-time.Minute).Format(
+			time.RFC3339)
+
+		config, fakeClient := testConfig(scheduledRebootNode)
+		config.BlackoutWindows = []string{blackoutWindow}
+
+		<-process(ctx, t, config, fakeClient)
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), scheduledRebootNode.Name)
+
+		if v := updatedNode.Annotations[constants.AnnotationOkToReboot]; v != constants.True {
+			t.Fatalf("Expected annotation %q value to be %q, got %q", constants.AnnotationOkToReboot, constants.True, v)
+		}
+	})
+
+	t.Run("unless_reboot_is_not_scheduled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := contextWithDeadline(t)
+
+		rebootingNode := rebootingNode()
+
+		config, fakeClient := testConfig(rebootingNode)
+		config.BlackoutWindows = []string{blackoutWindow}
+
+		<-process(ctx, t, config, fakeClient)
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootingNode.Name)
+
+		if v := updatedNode.Annotations[constants.AnnotationOkToReboot]; v != constants.True {
+			t.Fatalf("Expected annotation %q value to be %q, got %q", constants.AnnotationOkToReboot, constants.True, v)
+		}
+	})
+
+	t.Run("unless_rebooting_is_allowed", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := contextWithDeadline(t)
+
+		scheduledRebootNode := scheduledRebootNode()
+
+		config, fakeClient := testConfig(scheduledRebootNode)
+
+		<-process(ctx, t, config, fakeClient)
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), scheduledRebootNode.Name)
+
+		if v := updatedNode.Annotations[constants.AnnotationOkToReboot]; v != constants.True {
+			t.Fatalf("Expected annotation %q value to be %q, got %q", constants.AnnotationOkToReboot, constants.True, v)
 		}
 	})
+}
+
+func Test_Operator_retries_scheduling_reboot_process_for_nodes_which_exceeded_before_reboot_timeout(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	rebootableNode := rebootableNode()
+	rebootableNode.Annotations[constants.AnnotationBeforeRebootTimedOutTime] = time.Now().Add(-2 * time.Hour).Format(
+		time.RFC3339)
+
+	recentlyTimedOutNode := rebootableNode.DeepCopy()
+	recentlyTimedOutNode.Name = "recently-timed-out"
+	recentlyTimedOutNode.Annotations[constants.AnnotationBeforeRebootTimedOutTime] = time.Now().Format(time.RFC3339)
+
+	config, fakeClient := testConfig(recentlyTimedOutNode, rebootableNode)
+	config.BeforeRebootTimeout = time.Hour
+
+	nodeUpdated := nodeUpdatedNTimes(fakeClient, 1)
+	<-process(ctx, t, config, fakeClient)
+	<-nodeUpdated
+
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+	if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
+		t.Fatalf("Expected node %q to be scheduled for reboot", rebootableNode.Name)
+	}
+
+	if _, ok := updatedNode.Annotations[constants.AnnotationBeforeRebootTimedOutTime]; ok {
+		t.Fatalf("Unexpected annotation %q found", constants.AnnotationBeforeRebootTimedOutTime)
+	}
+
+	updatedNode = node(ctx, t, config.Client.CoreV1().Nodes(), recentlyTimedOutNode.Name)
+	if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+		t.Fatalf("Unexpected node %q scheduled for reboot", recentlyTimedOutNode.Name)
+	}
+}
+
+// To schedule pre-reboot hooks.
+//
+//nolint:funlen // Just many test cases.
+//nolint:funlen // Just subtests.
+func Test_Operator_retries_node_state_transitions_conflicting_with_concurrent_node_updates(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	conflictingUpdates := 2
+
+	t.Run("when_scheduling_reboot_process", func(t *testing.T) {
+		t.Parallel()
+
+		rebootableNode := rebootableNode()
+
+		client := k8stest.NewClientset(rebootableNode)
+		injected := client.FailNodeUpdates(conflictingUpdates, k8stest.ConflictError("nodes", rebootableNode.Name))
+
+		config, _ := testConfig()
+		config.Client = client
+
+		<-process(ctx, t, config, &client.Fake)
+
+		if failed := injected.Failed(); failed != conflictingUpdates {
+			t.Fatalf("Expected %d conflicting node updates, got %d", conflictingUpdates, failed)
+		}
+
+		updatedNode := node(ctx, t, client.CoreV1().Nodes(), rebootableNode.Name)
+		if v := updatedNode.Labels[constants.LabelBeforeReboot]; v != constants.True {
+			t.Fatalf("Expected node %q to be scheduled for reboot, got labels %v", rebootableNode.Name, updatedNode.Labels)
+		}
+	})
+
+	t.Run("when_approving_reboot", func(t *testing.T) {
+		t.Parallel()
+
+		readyToRebootNode := readyToRebootNode()
+
+		client := k8stest.NewClientset(readyToRebootNode)
+		injected := client.FailNodeUpdates(conflictingUpdates, k8stest.ConflictError("nodes", readyToRebootNode.Name))
+
+		config, _ := testConfig()
+		config.Client = client
+		config.BeforeRebootAnnotations = []string{testBeforeRebootAnnotation}
+
+		<-process(ctx, t, config, &client.Fake)
+
+		if failed := injected.Failed(); failed != conflictingUpdates {
+			t.Fatalf("Expected %d conflicting node updates, got %d", conflictingUpdates, failed)
+		}
+
+		updatedNode := node(ctx, t, client.CoreV1().Nodes(), readyToRebootNode.Name)
+		if v := updatedNode.Annotations[constants.AnnotationOkToReboot]; v != constants.True {
+			t.Fatalf("Expected node %q reboot to be approved, got annotations %v",
+				readyToRebootNode.Name, updatedNode.Annotations)
+		}
+	})
+}
+
+func Test_Operator_schedules_reboot_process(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	t.Run("only_during_reboot_window", func(t *testing.T) {
+		t.Parallel()
+
+		rebootableNode := rebootableNode()
+
+		config, fakeClient := testConfig(rebootableNode)
+		config.RebootWindowStart = "Mon 00:00"
+		config.RebootWindowLength = fmt.Sprintf("%ds", (7*24*60*60)-1)
+
+		nodeUpdated := nodeUpdatedNTimes(fakeClient, 1)
+		<-process(ctx, t, config, fakeClient)
+		<-nodeUpdated
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
+			t.Fatalf("Expected node %q to be scheduled for reboot", rebootableNode.Name)
+		}
+	})
+
+	t.Run("only_for_maximum_number_of_rebooting_nodes_in_parallel", func(t *testing.T) {
+		t.Parallel()
+
+		rebootableNode := rebootableNode()
+
+		config, fakeClient := testConfig(rebootableNode, rebootNotConfirmedNode())
+
+		<-process(ctx, t, config, fakeClient)
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if v, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok && v == constants.True {
+			t.Fatalf("Unexpected node %q scheduled for reboot", rebootableNode.Name)
+		}
+	})
+
+	t.Run("for_nodes_which_are_rebootable", func(t *testing.T) {
+		t.Parallel()
+
+		scheduledForRebootNode := scheduledForRebootNode()
+
+		config, fakeClient := testConfig(scheduledForRebootNode)
+
+		<-process(ctx, t, config, fakeClient)
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), scheduledForRebootNode.Name)
+
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+			t.Fatalf("Unexpected node %q scheduled for reboot", updatedNode.Name)
+		}
+	})
+
+	t.Run("by", func(t *testing.T) {
+		t.Parallel()
+
+		rebootNeededSince := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+		rebootableNode := rebootableNode()
+		rebootableNode.Annotations[testBeforeRebootAnnotation] = constants.True
+		rebootableNode.Annotations[constants.AnnotationRebootNeededSince] = rebootNeededSince
+		rebootableNode.Labels[constants.LabelVersion] = testVersion
+
+		config, fakeClient := testConfig(rebootableNode)
+		config.BeforeRebootAnnotations = []string{testBeforeRebootAnnotation}
+
+		nodeUpdated := nodeUpdatedNTimes(fakeClient, 1)
+		<-process(ctx, t, config, fakeClient)
+		<-nodeUpdated
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+
+		t.Run("removing_all_before_reboot_annotations", func(t *testing.T) {
+			t.Parallel()
+
+			if _, ok := updatedNode.Annotations[testBeforeRebootAnnotation]; ok {
+				t.Fatalf("Unexpected annotation %q found", testBeforeRebootAnnotation)
+			}
+		})
+
+		t.Run("setting_before_reboot_label_to_true", func(t *testing.T) {
+			t.Parallel()
+
+			beforeReboot, ok := updatedNode.Labels[constants.LabelBeforeReboot]
+			if !ok {
+				t.Fatalf("Expected label %q not found, got %v instead", constants.LabelBeforeReboot, updatedNode.Labels)
+			}
+
+			if beforeReboot != constants.True {
+				t.Fatalf("Unexpected label value: %q", beforeReboot)
+			}
+		})
+
+		t.Run("recording_reboot_start_time", func(t *testing.T) {
+			t.Parallel()
+
+			value := updatedNode.Annotations[constants.AnnotationRebootStartedTime]
+
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				t.Fatalf("Expected annotation %q to be a valid RFC 3339 timestamp, got %q: %v",
+					constants.AnnotationRebootStartedTime, value, err)
+			}
+		})
+
+		t.Run("recording_current_OS_version", func(t *testing.T) {
+			t.Parallel()
+
+			if v := updatedNode.Annotations[constants.AnnotationVersionBeforeReboot]; v != testVersion {
+				t.Fatalf("Expected annotation %q value %q, got %q", constants.AnnotationVersionBeforeReboot, testVersion, v)
+			}
+		})
+
+		t.Run("preserving_time_since_node_needs_reboot", func(t *testing.T) {
+			t.Parallel()
+
+			annotation := constants.AnnotationRebootNeededSinceBeforeReboot
+
+			if v := updatedNode.Annotations[annotation]; v != rebootNeededSince {
+				t.Fatalf("Expected annotation %q value %q, got %q", annotation, rebootNeededSince, v)
+			}
+		})
+	})
+}
+
+func Test_Operator_schedules_reboot_process_first_for_nodes_which_requested_reboot_earliest(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	recentlyRebootNeededNode := rebootableNode()
+	recentlyRebootNeededNode.Name = "a-recent"
+	recentlyRebootNeededNode.Annotations[constants.AnnotationRebootNeededSince] = time.Now().Add(-time.Hour).Format(
+		time.RFC3339)
+
+	unknownRebootNeededNode := rebootableNode()
+	unknownRebootNeededNode.Name = "b-unknown"
+
+	earliestRebootNeededNode := rebootableNode()
+	earliestRebootNeededNode.Name = "c-earliest"
+	earliestRebootNeededNode.Annotations[constants.AnnotationRebootNeededSince] = time.Now().Add(-2 * time.Hour).Format(
+		time.RFC3339)
+
+	config, fakeClient := testConfig(recentlyRebootNeededNode, unknownRebootNeededNode, earliestRebootNeededNode)
+
+	nodeUpdated := nodeUpdatedNTimes(fakeClient, 3)
+	<-process(ctx, t, config, fakeClient)
+	<-nodeUpdated
+
+	for _, name := range []string{recentlyRebootNeededNode.Name, unknownRebootNeededNode.Name} {
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+			t.Fatalf("Unexpected node %q scheduled for reboot", name)
+		}
+	}
+
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), earliestRebootNeededNode.Name)
+	if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
+		t.Fatalf("Expected node %q to be scheduled for reboot", earliestRebootNeededNode.Name)
+	}
+}
+
+func Test_Operator_approves_reboot_process_for_nodes_which_have(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	cases := map[string]struct {
+		mutateF        func(*corev1.Node)
+		expectRebootOK bool
+	}{
+		"all_conditions_met": {
+			// Node without mutation should get ok-to-reboot.
+			expectRebootOK: true,
+		},
+		"before_reboot_label": {
+			mutateF: func(updatedNode *corev1.Node) {
+				// Node without before-reboot label won't get ok-to-reboot.
+				delete(updatedNode.Labels, constants.LabelBeforeReboot)
+			},
+		},
+		"all_before_reboot_annotations_set_to_true": {
+			mutateF: func(updatedNode *corev1.Node) {
+				// Node without all before reboot annotations won't get ok-to-reboot.
+				updatedNode.Annotations[testBeforeRebootAnnotation] = constants.False
+			},
+		},
+	}
+
+	for name, testCase := range cases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			readyToRebootNode := readyToRebootNode()
+			if testCase.mutateF != nil {
+				testCase.mutateF(readyToRebootNode)
+			}
+
+			config, fakeClient := testConfig(readyToRebootNode)
+
+			// Use beforeRebootAnnotations to be able to test moment when node has before-reboot
+			// label, but it cannot be removed yet.
+			config.BeforeRebootAnnotations = []string{testBeforeRebootAnnotation}
+
+			<-process(ctx, t, config, fakeClient)
+
+			updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), readyToRebootNode.Name)
+
+			v, ok := updatedNode.Annotations[constants.AnnotationOkToReboot]
+			if testCase.expectRebootOK && (!ok || v != constants.True) {
+				t.Fatalf("Expected reboot-ok annotation, got %v", updatedNode.Annotations)
+			}
+
+			if !testCase.expectRebootOK && ok && v == constants.True {
+				t.Fatalf("Unexpected reboot-ok annotation")
+			}
+		})
+	}
+}
+
+// To inform agent it can proceed with node draining and rebooting.
+func Test_Operator_approves_reboot_process_by(t *testing.T) {
+	t.Parallel()
+
+	readyToRebootNode := readyToRebootNode()
+
+	config, fakeClient := testConfig(readyToRebootNode)
+	config.BeforeRebootAnnotations = []string{testBeforeRebootAnnotation}
+
+	ctx := contextWithDeadline(t)
+
+	<-process(ctx, t, config, fakeClient)
+
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), readyToRebootNode.Name)
+
+	// To de-schedule hook pods.
+	t.Run("removing_before_reboot_label", func(t *testing.T) {
+		t.Parallel()
+
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+			t.Fatalf("Unexpected label %q found", constants.LabelBeforeReboot)
+		}
+	})
+
+	t.Run("removing_all_before_reboot_annotations", func(t *testing.T) {
+		t.Parallel()
+
+		if _, ok := updatedNode.Annotations[testBeforeRebootAnnotation]; ok {
+			t.Fatalf("Unexpected annotation %q found", testBeforeRebootAnnotation)
+		}
+	})
+
+	// To inform agent that all hooks are executed and it can proceed with the reboot.
+	// Right now by setting ok-to-reboot label to true.
+	t.Run("informing_agent_to_proceed_with_reboot_process", func(t *testing.T) {
+		t.Parallel()
+
+		okToReboot, ok := updatedNode.Annotations[constants.AnnotationOkToReboot]
+
+		if !ok {
+			t.Fatalf("Expected annotation %q not found, got %v", constants.AnnotationOkToReboot, updatedNode.Annotations)
+		}
+
+		if okToReboot != constants.True {
+			t.Fatalf("Expected annotation %q value to be %q, got %q",
+				constants.AnnotationOkToReboot, constants.True, okToReboot)
+		}
+	})
+}
+
+// To detect agents which never act upon the approval.
+func Test_Operator_records_reboot_approval_time(t *testing.T) {
+	t.Parallel()
+
+	readyToRebootNode := readyToRebootNode()
+
+	config, fakeClient := testConfig(readyToRebootNode)
+	config.BeforeRebootAnnotations = []string{testBeforeRebootAnnotation}
+
+	ctx := contextWithDeadline(t)
+
+	<-process(ctx, t, config, fakeClient)
+
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), readyToRebootNode.Name)
+
+	value := updatedNode.Annotations[constants.AnnotationRebootApprovedTime]
+
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		t.Fatalf("Expected annotation %q to be a valid RFC 3339 timestamp, got %q: %v",
+			constants.AnnotationRebootApprovedTime, value, err)
+	}
+}
+
+// Test opposite conditions starting from base to make sure all cases are covered.
+//
+//nolint:funlen,cyclop // Just many test cases.
+func Test_Operator_counts_nodes_as_just_rebooted_which(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	cases := map[string]struct {
+		mutateF            func(*corev1.Node)
+		expectJustRebooted bool
+	}{
+		"has_all_conditions_met": {
+			expectJustRebooted: true,
+		},
+		// Nodes which we allowed to reboot.
+		"has_reboot_approved": {
+			mutateF: func(updatedNode *corev1.Node) {
+				updatedNode.Annotations[constants.AnnotationOkToReboot] = constants.False
+			},
+		},
+		// Nodes which already rebooted.
+		"does_not_need_a_reboot": {
+			mutateF: func(updatedNode *corev1.Node) {
+				updatedNode.Annotations[constants.AnnotationRebootNeeded] = constants.True
+			},
+		},
+		// Nodes which already reported that they are back from rebooting.
+		"which_finished_the_reboot": {
+			mutateF: func(updatedNode *corev1.Node) {
+				updatedNode.Annotations[constants.AnnotationRebootInProgress] = constants.True
+			},
+		},
+		// Nodes which do not have hooks scheduled yet.
+		"has_no_after_reboot_label": {
+			mutateF: func(updatedNode *corev1.Node) {
+				updatedNode.Labels[constants.LabelAfterReboot] = constants.True
+			},
+		},
+	}
+
+	for name, testCase := range cases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			justRebootedNode := justRebootedNode()
+			if testCase.mutateF != nil {
+				testCase.mutateF(justRebootedNode)
+			}
+
+			config, fakeClient := testConfig(justRebootedNode)
+			config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+
+			<-process(ctx, t, config, fakeClient)
+
+			updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), justRebootedNode.Name)
+
+			v, ok := updatedNode.Labels[constants.LabelAfterReboot]
+			if testCase.expectJustRebooted {
+				if !ok || v != constants.True {
+					t.Errorf("Expected after reboot label, got %v", updatedNode.Labels)
+				}
+
+				if _, ok := updatedNode.Annotations[testAfterRebootAnnotation]; ok {
+					t.Errorf("Expected annotation %q to be removed", testAfterRebootAnnotation)
+				}
+
+				if _, ok := updatedNode.Annotations[testAnotherAfterRebootAnnotation]; ok {
+					t.Errorf("Expected annotation %q to be removed", testAnotherAfterRebootAnnotation)
+				}
+			}
+
+			if !testCase.expectJustRebooted {
+				v, ok := updatedNode.Annotations[testAfterRebootAnnotation]
+				if !ok || v != constants.False {
+					t.Fatalf("Expected annotation %q to be left untouched", testAfterRebootAnnotation)
+				}
+			}
+		})
+	}
+}
+
+// To schedule post-reboot hooks.
+func Test_Operator_confirms_reboot_process_by(t *testing.T) {
+	t.Parallel()
+
+	justRebootedNode := justRebootedNode()
+	justRebootedNode.Annotations[testAfterRebootAnnotation] = constants.True
+	justRebootedNode.Annotations[testAnotherAfterRebootAnnotation] = constants.True
+
+	config, fakeClient := testConfig(justRebootedNode)
+	config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+
+	ctx := contextWithDeadline(t)
+
+	<-process(ctx, t, config, fakeClient)
+
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), justRebootedNode.Name)
+
+	// To ensure all annotations are freshly set.
+	t.Run("removing_all_after_reboot_annotations", func(t *testing.T) {
+		t.Parallel()
+
+		if _, ok := updatedNode.Annotations[testAfterRebootAnnotation]; ok {
+			t.Fatalf("Unexpected annotation %q found", testAfterRebootAnnotation)
+		}
+
+		if _, ok := updatedNode.Annotations[testAnotherAfterRebootAnnotation]; ok {
+			t.Fatalf("Unexpected annotation %q found", testAnotherAfterRebootAnnotation)
+		}
+	})
+
+	// To schedule after-reboot hook pods.
+	t.Run("setting_after_reboot_label_to_true", func(t *testing.T) {
+		t.Parallel()
+
+		afterReboot, ok := updatedNode.Labels[constants.LabelAfterReboot]
+		if !ok {
+			t.Fatalf("Expected label %q not found, not %v", constants.LabelAfterReboot, updatedNode.Labels)
+		}
+
+		if afterReboot != constants.True {
+			t.Fatalf("Expected label value %q, got %q", constants.True, afterReboot)
+		}
+	})
+}
+
+// Test opposite conditions starting from base to make sure all cases are covered.
+func Test_Operator_counts_nodes_as_which_finished_rebooting_which_has(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	cases := map[string]struct {
+		mutateF                 func(*corev1.Node)
+		expectFinishedRebooting bool
+	}{
+		"all_conditions_met": {
+			expectFinishedRebooting: true,
+		},
+		// Only consider nodes which runs the after-reboot hooks.
+		"after_reboot_label_set": {
+			mutateF: func(updatedNode *corev1.Node) {
+				delete(updatedNode.Labels, constants.LabelAfterReboot)
+			},
+		},
+		// To verify all hooks executed successfully.
+		"all_after_reboot_annotations_set_to_true": {
+			mutateF: func(updatedNode *corev1.Node) {
+				updatedNode.Annotations[testAfterRebootAnnotation] = constants.False
+			},
+		},
+	}
+
+	for name, testCase := range cases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			finishedRebootingNode := finishedRebootingNode()
+			if testCase.mutateF != nil {
+				testCase.mutateF(finishedRebootingNode)
+			}
+
+			config, fakeClient := testConfig(finishedRebootingNode)
+			config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+
+			<-process(ctx, t, config, fakeClient)
+
+			updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), finishedRebootingNode.Name)
+
+			v, ok := updatedNode.Annotations[constants.AnnotationOkToReboot]
+			if !testCase.expectFinishedRebooting && ok && v != constants.True {
+				t.Fatalf("Expected after reboot label, got %v", updatedNode.Labels)
+			}
+
+			if testCase.expectFinishedRebooting && ok && v == constants.True {
+				t.Fatalf("Unexpected after reboot label")
+			}
+		})
+	}
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Operator_stops_current_reconciliation_when(t *testing.T) {
+	t.Parallel()
+
+	for name, testCase := range map[string]struct {
+		node                  *corev1.Node
+		failingListCall       int
+		failingUpdateCall     int
+		expectedNodeCondition func(*corev1.Node) bool
+	}{
+		"cleaning_up_node_state_fails_because": {
+			node:              rebootCancelledNode(),
+			failingListCall:   0,
+			failingUpdateCall: 0,
+			expectedNodeCondition: func(node *corev1.Node) bool {
+				_, ok := node.Labels[constants.LabelBeforeReboot]
+
+				return ok
+			},
+		},
+		"evaluating_nodes_which_finished_rebooting_fails_because": {
+			node:              finishedRebootingNode(),
+			failingListCall:   1,
+			failingUpdateCall: 0,
+			expectedNodeCondition: func(node *corev1.Node) bool {
+				_, ok := node.Labels[constants.LabelAfterReboot]
+
+				return ok
+			},
+		},
+		"evaluating_nodes_which_just_rebooted_fails_because": {
+			node:              justRebootedNode(),
+			failingListCall:   2,
+			failingUpdateCall: 1,
+			expectedNodeCondition: func(node *corev1.Node) bool {
+				_, ok := node.Labels[constants.LabelAfterReboot]
+
+				return !ok
+			},
+		},
+		"evaluating_nodes_which_are_ready_to_reboot_fails_because": {
+			node:              readyToRebootNode(),
+			failingListCall:   3,
+			failingUpdateCall: 1,
+			expectedNodeCondition: func(node *corev1.Node) bool {
+				v, ok := node.Labels[constants.LabelBeforeReboot]
+
+				return ok || v != constants.True
+			},
+		},
+		"evaluating_nodes_which_needs_to_reboot_fails_because": {
+			node:              rebootableNode(),
+			failingListCall:   4,
+			failingUpdateCall: 1,
+			expectedNodeCondition: func(node *corev1.Node) bool {
+				v, ok := node.Labels[constants.LabelBeforeReboot]
+
+				return ok || v != constants.True
+			},
+		},
+	} {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for subName, subTestCase := range map[string]struct {
+				failingCall int
+				verb        string
+			}{
+				"listing_node_objects_fails": {
+					failingCall: testCase.failingListCall,
+					verb:        "list",
+				},
+				"updating_node_fails": {
+					failingCall: testCase.failingUpdateCall,
+					verb:        "update",
+				},
+			} {
+				subTestCase := subTestCase
+
+				t.Run(subName, func(t *testing.T) {
+					t.Parallel()
+
+					config, fakeClient := testConfig(testCase.node)
+					requestFailed, failRequest := failOnNthCall(subTestCase.failingCall, fmt.Errorf(t.Name()))
+					fakeClient.PrependReactor(subTestCase.verb, "nodes", failRequest)
+
+					if subTestCase.verb == "update" {
+						// Nodes are either updated or patched, both count as node updates.
+						fakeClient.PrependReactor("patch", "nodes", failRequest)
+					}
+
+					ctx, cancel := context.WithTimeout(contextWithDeadline(t), 5*time.Second)
+					t.Cleanup(cancel)
+
+					process(ctx, t, config, fakeClient)
+
+					select {
+					case <-requestFailed:
+					case <-ctx.Done():
+						t.Fatalf("Timed out waiting for request to fail")
+					}
+
+					if !testCase.expectedNodeCondition(node(ctx, t, config.Client.CoreV1().Nodes(), testCase.node.Name)) {
+						t.Fatalf("Expected condition not met")
+					}
+				})
+			}
+		})
+	}
+}
+
+// To de-schedule post-reboot hooks.
+func Test_Operator_finishes_reboot_process_by(t *testing.T) {
+	t.Parallel()
+
+	finishedRebootingNode := finishedRebootingNode()
+
+	config, fakeClient := testConfig(finishedRebootingNode)
+	config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+
+	ctx := contextWithDeadline(t)
+	<-process(ctx, t, config, fakeClient)
+
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), finishedRebootingNode.Name)
+
+	// To de-schedule hook pods.
+	t.Run("removing_after_reboot_label", func(t *testing.T) {
+		t.Parallel()
+
+		if _, ok := updatedNode.Labels[constants.LabelAfterReboot]; ok {
+			t.Fatalf("Unexpected after reboot label found")
+		}
+	})
+
+	// To cleanup the state before next runs.
+	t.Run("removing_all_after_reboot_annotations", func(t *testing.T) {
+		t.Parallel()
+
+		if _, ok := updatedNode.Annotations[testAfterRebootAnnotation]; ok {
+			t.Fatalf("Unexpected after reboot annotation %q found", testAfterRebootAnnotation)
+		}
+
+		if _, ok := updatedNode.Annotations[testAnotherAfterRebootAnnotation]; ok {
+			t.Fatalf("Unexpected after reboot annotation %q found", testAnotherAfterRebootAnnotation)
+		}
+	})
+
+	// To finalize reboot process. Implementation detail! setting ok-to-reboot label to false.
+	t.Run("informing_agent_to_not_proceed_with_reboot_process", func(t *testing.T) {
+		t.Parallel()
+
+		okToReboot, ok := updatedNode.Annotations[constants.AnnotationOkToReboot]
+		if !ok {
+			t.Fatalf("Expected annotation %q not found, got %v", constants.AnnotationOkToReboot, updatedNode.Labels)
+		}
+
+		if okToReboot != constants.False {
+			t.Fatalf("Expected annotation %q value %q, got %q", constants.AnnotationOkToReboot, constants.False, okToReboot)
+		}
+	})
+}
+
+//nolint:funlen // Just many subtests.
+func Test_Operator_records_finished_reboot_in_history(t *testing.T) {
+	t.Parallel()
+
+	startTime := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	finishedRebootingNode := finishedRebootingNode()
+	finishedRebootingNode.Labels[constants.LabelVersion] = testNewVersion
+	finishedRebootingNode.Annotations[constants.AnnotationRebootStartedTime] = startTime.Format(time.RFC3339)
+	finishedRebootingNode.Annotations[constants.AnnotationVersionBeforeReboot] = testVersion
+	finishedRebootingNode.Annotations[constants.AnnotationRebootNeededSinceBeforeReboot] = startTime.Format(time.RFC3339)
+
+	historyConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      operator.DefaultRebootHistoryConfigMap,
+			Namespace: testNamespace,
+		},
+		Data: map[string]string{
+			finishedRebootingNode.Name: `[{"node":"finished-rebooting"},{"node":"finished-rebooting"}]`,
+		},
+	}
+
+	config, fakeClient := testConfig(finishedRebootingNode, historyConfigMap)
+	config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+	config.RebootHistoryConfigMap = operator.DefaultRebootHistoryConfigMap
+	config.RebootHistoryLimit = 2
+
+	ctx := contextWithDeadline(t)
+	<-process(ctx, t, config, fakeClient)
+
+	configMap, err := config.Client.CoreV1().ConfigMaps(testNamespace).Get(ctx, historyConfigMap.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed getting reboot history ConfigMap: %v", err)
+	}
+
+	records := []operator.RebootRecord{}
+
+	if err := json.Unmarshal([]byte(configMap.Data[finishedRebootingNode.Name]), &records); err != nil {
+		t.Fatalf("Failed decoding reboot history: %v", err)
+	}
+
+	t.Run("keeping_only_configured_number_of_records", func(t *testing.T) {
+		t.Parallel()
+
+		if len(records) != config.RebootHistoryLimit {
+			t.Fatalf("Expected %d records, got %d: %v", config.RebootHistoryLimit, len(records), records)
+		}
+	})
+
+	t.Run("with_reboot_details", func(t *testing.T) {
+		t.Parallel()
+
+		record := records[len(records)-1]
+
+		if !record.Started.Equal(startTime) {
+			t.Fatalf("Expected start time %v, got %v", startTime, record.Started)
+		}
+
+		if record.Finished.Before(startTime) {
+			t.Fatalf("Expected finish time %v to be after start time %v", record.Finished, startTime)
+		}
+
+		if record.VersionBefore != testVersion {
+			t.Fatalf("Expected version before reboot %q, got %q", testVersion, record.VersionBefore)
+		}
+
+		if record.VersionAfter != testNewVersion {
+			t.Fatalf("Expected version after reboot %q, got %q", testNewVersion, record.VersionAfter)
+		}
+	})
+
+	t.Run("removing_reboot_details_from_node", func(t *testing.T) {
+		t.Parallel()
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), finishedRebootingNode.Name)
+
+		for _, annotation := range []string{
+			constants.AnnotationRebootStartedTime,
+			constants.AnnotationVersionBeforeReboot,
+			constants.AnnotationRebootNeededSinceBeforeReboot,
+		} {
+			if _, ok := updatedNode.Annotations[annotation]; ok {
+				t.Fatalf("Unexpected annotation %q found", annotation)
+			}
+		}
+	})
+}
+
+func Test_Operator_exposes_metric_with_time_of_last_reboot_of_nodes_recorded_in_history(t *testing.T) {
+	t.Parallel()
+
+	lastReboot := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	rebootedNode := idleNode()
+	notRebootedNode := idleNode()
+	notRebootedNode.Name = "not-rebooted"
+
+	historyConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      operator.DefaultRebootHistoryConfigMap,
+			Namespace: testNamespace,
+		},
+		Data: map[string]string{
+			rebootedNode.Name: fmt.Sprintf(`[{"finished":%q},{"finished":%q}]`,
+				lastReboot.Add(-24*time.Hour).Format(time.RFC3339), lastReboot.Format(time.RFC3339)),
+		},
+	}
+
+	registry := prometheus.NewRegistry()
+
+	config, fakeClient := testConfig(rebootedNode, notRebootedNode, historyConfigMap)
+	config.RebootHistoryConfigMap = operator.DefaultRebootHistoryConfigMap
+	config.MetricsRegisterer = registry
+
+	ctx := contextWithDeadline(t)
+	<-process(ctx, t, config, fakeClient)
+
+	metricName := "fluo_node_last_reboot_timestamp_seconds"
+
+	t.Run("using_most_recent_reboot_of_node", func(t *testing.T) {
+		t.Parallel()
+
+		v := metricValue(t, registry, metricName, map[string]string{"node": rebootedNode.Name})
+		if v != float64(lastReboot.Unix()) {
+			t.Fatalf("Expected metric %q for node %q to be %d, got %v", metricName, rebootedNode.Name, lastReboot.Unix(), v)
+		}
+	})
+
+	t.Run("without_reporting_nodes_without_recorded_reboots", func(t *testing.T) {
+		t.Parallel()
+
+		if v := metricValue(t, registry, metricName, map[string]string{"node": notRebootedNode.Name}); v != 0 {
+			t.Fatalf("Expected metric %q for node %q not to be reported, got %v", metricName, notRebootedNode.Name, v)
+		}
+	})
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Operator_emits_node_event_when(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	cases := map[string]struct {
+		node           *corev1.Node
+		expectedReason string
+	}{
+		"node_is_scheduled_for_reboot": {
+			node:           rebootableNode(),
+			expectedReason: operator.EventReasonScheduledForReboot,
+		},
+		"node_reboot_is_cancelled": {
+			node:           rebootCancelledNode(),
+			expectedReason: operator.EventReasonRebootCancelled,
+		},
+		"node_reboot_is_approved": {
+			node:           readyToRebootNode(),
+			expectedReason: operator.EventReasonRebootApproved,
+		},
+		"node_finished_rebooting": {
+			node:           justRebootedNode(),
+			expectedReason: operator.EventReasonRebooted,
+		},
+		"node_reboot_process_is_completed": {
+			node:           finishedRebootingNode(),
+			expectedReason: operator.EventReasonRebootCompleted,
+		},
+	}
+
+	for name, testCase := range cases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config, fakeClient := testConfig(testCase.node)
+			config.BeforeRebootAnnotations = []string{testBeforeRebootAnnotation}
+			config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+
+			<-process(ctx, t, config, fakeClient)
+
+			event := nodeEvent(ctx, t, config.Client, testCase.node.Name, testCase.expectedReason)
+
+			if event.Type != corev1.EventTypeNormal {
+				t.Fatalf("Expected event type %q, got %q", corev1.EventTypeNormal, event.Type)
+			}
+
+			if event.InvolvedObject.Kind != "Node" {
+				t.Fatalf("Expected event for object of kind %q, got %q", "Node", event.InvolvedObject.Kind)
+			}
+		})
+	}
+}
+
+func Test_Operator_does_not_report_node_which_rolled_back_update_as_successfully_updated(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	rolledBackNode := finishedRebootingNode()
+	rolledBackNode.Annotations[constants.AnnotationRolledBack] = constants.True
+
+	registry := prometheus.NewRegistry()
+
+	config, fakeClient := testConfig(rolledBackNode)
+	config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+	config.MetricsRegisterer = registry
+
+	<-process(ctx, t, config, fakeClient)
+
+	t.Run("emitting_warning_event", func(t *testing.T) {
+		t.Parallel()
+
+		event := nodeEvent(ctx, t, config.Client, rolledBackNode.Name, operator.EventReasonUpdateRolledBack)
+
+		if event.Type != corev1.EventTypeWarning {
+			t.Fatalf("Expected event type %q, got %q", corev1.EventTypeWarning, event.Type)
+		}
+	})
+
+	t.Run("counting_rolled_back_update", func(t *testing.T) {
+		t.Parallel()
+
+		metricName := "flatcar_linux_update_operator_updates_rolled_back_total"
+
+		if v := metricValue(t, registry, metricName, nil); v != 1 {
+			t.Fatalf("Expected metric %q to be %v, got %v", metricName, 1, v)
+		}
+	})
+}
+
+func Test_Operator_sends_notification_when(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	timedOutNode := scheduledForRebootNode()
+	timedOutNode.Annotations[constants.AnnotationRebootStartedTime] = time.Now().Add(-2 * time.Hour).Format(
+		time.RFC3339)
+
+	cases := map[string]struct {
+		node         *corev1.Node
+		expectedType notify.Type
+	}{
+		"node_is_scheduled_for_reboot": {
+			node:         rebootableNode(),
+			expectedType: notify.TypeRebootScheduled,
+		},
+		"node_reboot_process_is_completed": {
+			node:         finishedRebootingNode(),
+			expectedType: notify.TypeRebootCompleted,
+		},
+		"node_reboot_process_runs_into_problems": {
+			node:         timedOutNode,
+			expectedType: notify.TypeRebootFailed,
+		},
+	}
+
+	for name, testCase := range cases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			notifier := &testNotifier{notifications: make(chan notify.Notification, 10)}
+
+			config, fakeClient := testConfig(testCase.node)
+			config.BeforeRebootAnnotations = []string{testBeforeRebootAnnotation}
+			config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+			config.BeforeRebootTimeout = time.Hour
+			config.Notifier = notifier
+
+			<-process(ctx, t, config, fakeClient)
+
+			for {
+				select {
+				case <-ctx.Done():
+					t.Fatalf("Timed out waiting for %q notification", testCase.expectedType)
+				case notification := <-notifier.notifications:
+					if notification.Type != testCase.expectedType {
+						continue
+					}
+
+					if notification.Node != testCase.node.Name {
+						t.Fatalf("Expected notification for node %q, got %q", testCase.node.Name, notification.Node)
+					}
+
+					return
+				}
+			}
+		})
+	}
+}
+
+func Test_Operator_reports_nodes_which_are_rebooting_for_longer_than_configured_threshold(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	rebootingNode := rebootingNode()
+	registry := prometheus.NewRegistry()
+
+	config, fakeClient := testConfig(rebootingNode)
+	config.StuckRebootThreshold = time.Nanosecond
+	config.ReconciliationPeriod = 10 * time.Millisecond
+	config.MetricsRegisterer = registry
+
+	reconcileCycleCh := process(ctx, t, config, fakeClient)
+
+	// Node must be observed in rebooting state at least twice to exceed the threshold.
+	<-reconcileCycleCh
+	<-reconcileCycleCh
+
+	// Keep consuming reconciliation cycles, so operator does not get blocked.
+	drainCtx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+
+	go func() {
+		for {
+			select {
+			case <-reconcileCycleCh:
+			case <-drainCtx.Done():
+				return
+			}
+		}
+	}()
+
+	t.Run("by_emitting_warning_event", func(t *testing.T) {
+		t.Parallel()
+
+		event := nodeEvent(ctx, t, config.Client, rebootingNode.Name, operator.EventReasonRebootStuck)
+
+		if event.Type != corev1.EventTypeWarning {
+			t.Fatalf("Expected event type %q, got %q", corev1.EventTypeWarning, event.Type)
+		}
+	})
+
+	t.Run("by_exposing_metric", func(t *testing.T) {
+		t.Parallel()
+
+		metricName := "flatcar_linux_update_operator_node_stuck_rebooting"
+
+		if v := metricValue(t, registry, metricName, map[string]string{"node": rebootingNode.Name}); v != 1 {
+			t.Fatalf("Expected metric %q for node %q to be 1, got %v", metricName, rebootingNode.Name, v)
+		}
+	})
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Operator_reports_nodes_which_agent_did_not_report_heartbeat_for_longer_than_configured_threshold(
+	t *testing.T,
+) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	staleNode := idleNode()
+	staleNode.Name = "stale"
+
+	missingHeartbeatNode := idleNode()
+	missingHeartbeatNode.Name = "missing-heartbeat"
+
+	aliveNode := idleNode()
+	aliveNode.Name = "alive"
+
+	rebootingNode := rebootingNode()
+
+	heartbeatLease := func(nodeName string, renewTime time.Time) *coordinationv1.Lease {
+		return &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      k8sutil.HeartbeatLeaseName(nodeName),
+				Namespace: testNamespace,
+			},
+			Spec: coordinationv1.LeaseSpec{
+				RenewTime: &metav1.MicroTime{Time: renewTime},
+			},
+		}
+	}
+
+	registry := prometheus.NewRegistry()
+
+	config, fakeClient := testConfig(
+		staleNode,
+		missingHeartbeatNode,
+		aliveNode,
+		rebootingNode,
+		heartbeatLease(staleNode.Name, time.Now().Add(-2*time.Hour)),
+		heartbeatLease(aliveNode.Name, time.Now()),
+	)
+	config.StaleAgentThreshold = time.Hour
+	config.MetricsRegisterer = registry
+
+	<-process(ctx, t, config, fakeClient)
+
+	metricName := "flatcar_linux_update_operator_node_agent_stale"
+
+	for _, nodeName := range []string{staleNode.Name, missingHeartbeatNode.Name} {
+		nodeName := nodeName
+
+		t.Run("by_emitting_warning_event_for_"+strings.ReplaceAll(nodeName, "-", "_")+"_node", func(t *testing.T) {
+			t.Parallel()
+
+			event := nodeEvent(ctx, t, config.Client, nodeName, operator.EventReasonAgentStale)
+
+			if event.Type != corev1.EventTypeWarning {
+				t.Fatalf("Expected event type %q, got %q", corev1.EventTypeWarning, event.Type)
+			}
+		})
+
+		t.Run("by_exposing_metric_for_"+strings.ReplaceAll(nodeName, "-", "_")+"_node", func(t *testing.T) {
+			t.Parallel()
+
+			if v := metricValue(t, registry, metricName, map[string]string{"node": nodeName}); v != 1 {
+				t.Fatalf("Expected metric %q for node %q to be 1, got %v", metricName, nodeName, v)
+			}
+		})
+	}
+
+	t.Run("except_nodes_which_agent_reported_heartbeat_recently_or_which_are_rebooting", func(t *testing.T) {
+		t.Parallel()
+
+		for _, nodeName := range []string{aliveNode.Name, rebootingNode.Name} {
+			if v := metricValue(t, registry, metricName, map[string]string{"node": nodeName}); v != 0 {
+				t.Fatalf("Expected metric %q for node %q to be 0, got %v", metricName, nodeName, v)
+			}
+		}
+	})
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Operator_mirrors_reboot_state_of_nodes_for_kured_compatibility_when_enabled(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	approvedTime := "2023-08-01T12:00:00Z"
+	neededSince := "2023-08-01T10:00:00Z"
+
+	rebootingNode := rebootingNode()
+	rebootingNode.Annotations[constants.AnnotationRebootApprovedTime] = approvedTime
+	rebootingNode.Annotations[constants.AnnotationRebootNeededSince] = neededSince
+
+	rebootedNode := idleNode()
+	rebootedNode.Annotations["weave.works/kured-reboot-in-progress"] = approvedTime
+	rebootedNode.Annotations["weave.works/kured-most-recent-reboot-needed"] = neededSince
+
+	registry := prometheus.NewRegistry()
+
+	config, fakeClient := testConfig(rebootingNode, rebootedNode)
+	config.KuredCompatibility = true
+	config.MetricsRegisterer = registry
+
+	<-process(ctx, t, config, fakeClient)
+
+	t.Run("by_annotating_nodes_approved_for_rebooting_as_rebooting", func(t *testing.T) {
+		t.Parallel()
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootingNode.Name)
+
+		expectedAnnotations := map[string]string{
+			"weave.works/kured-reboot-in-progress":        approvedTime,
+			"weave.works/kured-most-recent-reboot-needed": neededSince,
+		}
+
+		for key, expectedValue := range expectedAnnotations {
+			if v := updatedNode.Annotations[key]; v != expectedValue {
+				t.Fatalf("Expected annotation %q to be %q, got %q", key, expectedValue, v)
+			}
+		}
+	})
+
+	t.Run("by_removing_rebooting_annotation_from_nodes_which_finished_rebooting", func(t *testing.T) {
+		t.Parallel()
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootedNode.Name)
+
+		if v, ok := updatedNode.Annotations["weave.works/kured-reboot-in-progress"]; ok {
+			t.Fatalf("Expected rebooting annotation to be removed, got %q", v)
+		}
+
+		key := "weave.works/kured-most-recent-reboot-needed"
+		if v := updatedNode.Annotations[key]; v != neededSince {
+			t.Fatalf("Expected annotation %q to be kept with value %q, got %q", key, neededSince, v)
+		}
+	})
+
+	t.Run("by_exposing_metric_with_nodes_requiring_reboot", func(t *testing.T) {
+		t.Parallel()
+
+		metricName := "kured_reboot_required"
+
+		expectedValues := map[string]float64{
+			rebootingNode.Name: 1,
+			rebootedNode.Name:  0,
+		}
+
+		for nodeName, expectedValue := range expectedValues {
+			if v := metricValue(t, registry, metricName, map[string]string{"node": nodeName}); v != expectedValue {
+				t.Fatalf("Expected metric %q for node %q to be %v, got %v", metricName, nodeName, expectedValue, v)
+			}
+		}
+	})
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Operator_records_reboot_decisions(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	rebootableNode := rebootableNode()
+
+	taintedNode := rebootableNode.DeepCopy()
+	taintedNode.Name = "tainted"
+	taintedNode.Spec.Taints = []corev1.Taint{
+		{
+			Key:    "node.kubernetes.io/out-of-service",
+			Effect: corev1.TaintEffectNoExecute,
+		},
+	}
+
+	config, fakeClient := testConfig(rebootableNode, taintedNode)
+	config.ExcludedTaints = []string{"node.kubernetes.io/out-of-service"}
+	config.RebootDecisionsConfigMap = "reboot-decisions"
+	config.ReconciliationPeriod = 10 * time.Millisecond
+
+	reconcileCycleCh := process(ctx, t, config, fakeClient)
+
+	// Node must be skipped at least twice to verify repeated decisions are recorded only once.
+	<-reconcileCycleCh
+	<-reconcileCycleCh
+
+	// Keep consuming reconciliation cycles, so operator does not get blocked.
+	drainCtx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+
+	go func() {
+		for {
+			select {
+			case <-reconcileCycleCh:
+			case <-drainCtx.Done():
+				return
+			}
+		}
+	}()
+
+	decisions := func(t *testing.T, nodeName string) []operator.RebootDecision {
+		t.Helper()
+
+		configMaps := config.Client.CoreV1().ConfigMaps(testNamespace)
+
+		configMap, err := configMaps.Get(ctx, config.RebootDecisionsConfigMap, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed getting reboot decisions ConfigMap: %v", err)
+		}
+
+		decisions := []operator.RebootDecision{}
+
+		if err := json.Unmarshal([]byte(configMap.Data[nodeName]), &decisions); err != nil {
+			t.Fatalf("Failed decoding reboot decisions of node %q: %v", nodeName, err)
+		}
+
+		return decisions
+	}
+
+	t.Run("when_node_gets_scheduled_for_rebooting_with_explanation", func(t *testing.T) {
+		t.Parallel()
+
+		for _, decision := range decisions(t, rebootableNode.Name) {
+			if decision.Decision != operator.EventReasonScheduledForReboot {
+				continue
+			}
+
+			if !strings.Contains(decision.Message, "inside reboot window") {
+				t.Fatalf("Expected decision message to explain why node has been scheduled, got %q", decision.Message)
+			}
+
+			return
+		}
+
+		t.Fatalf("Expected %q decision for node %q", operator.EventReasonScheduledForReboot, rebootableNode.Name)
+	})
+
+	t.Run("when_node_is_not_scheduled_for_rebooting_only_once_with_reason", func(t *testing.T) {
+		t.Parallel()
+
+		recorded := decisions(t, taintedNode.Name)
+
+		if len(recorded) != 1 {
+			t.Fatalf("Expected exactly one decision for node %q, got %v", taintedNode.Name, recorded)
+		}
+
+		if recorded[0].Decision != operator.DecisionNotScheduled {
+			t.Errorf("Expected decision %q, got %q", operator.DecisionNotScheduled, recorded[0].Decision)
+		}
+
+		if recorded[0].Reason != operator.DecisionReasonExcludedTaint {
+			t.Errorf("Expected reason %q, got %q", operator.DecisionReasonExcludedTaint, recorded[0].Reason)
+		}
+	})
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Operator_reports_failed_reboot_checks(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	scheduledForRebootNode := scheduledForRebootNode()
+	scheduledForRebootNode.Annotations[testBeforeRebootAnnotation] = constants.False
+
+	finishedRebootingNode := finishedRebootingNode()
+	finishedRebootingNode.Annotations[testAfterRebootAnnotation] = constants.False
+
+	registry := prometheus.NewRegistry()
+
+	config, fakeClient := testConfig(scheduledForRebootNode, finishedRebootingNode)
+	config.BeforeRebootAnnotations = []string{testBeforeRebootAnnotation}
+	config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+	config.ReconciliationPeriod = 10 * time.Millisecond
+	config.MetricsRegisterer = registry
+
+	reconcileCycleCh := process(ctx, t, config, fakeClient)
+
+	// Failed checks must be observed at least twice to verify they are reported only once.
+	<-reconcileCycleCh
+	<-reconcileCycleCh
+
+	// Keep consuming reconciliation cycles, so operator does not get blocked.
+	drainCtx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+
+	go func() {
+		for {
+			select {
+			case <-reconcileCycleCh:
+			case <-drainCtx.Done():
+				return
+			}
+		}
+	}()
+
+	metricName := "flatcar_linux_update_operator_reboot_check_failures_total"
+
+	for name, testCase := range map[string]struct {
+		nodeName   string
+		reason     string
+		checksType string
+		annotation string
+	}{
+		"before_reboot": {
+			nodeName:   scheduledForRebootNode.Name,
+			reason:     operator.EventReasonBeforeRebootCheckFailed,
+			checksType: "before-reboot",
+			annotation: testBeforeRebootAnnotation,
+		},
+		"after_reboot": {
+			nodeName:   finishedRebootingNode.Name,
+			reason:     operator.EventReasonAfterRebootCheckFailed,
+			checksType: "after-reboot",
+			annotation: testAfterRebootAnnotation,
+		},
+	} {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("by_emitting_warning_event", func(t *testing.T) {
+				t.Parallel()
+
+				event := nodeEvent(ctx, t, config.Client, testCase.nodeName, testCase.reason)
+
+				if event.Type != corev1.EventTypeWarning {
+					t.Fatalf("Expected event type %q, got %q", corev1.EventTypeWarning, event.Type)
+				}
+
+				if !strings.Contains(event.Message, testCase.annotation) {
+					t.Fatalf("Expected event message to mention annotation %q, got %q", testCase.annotation, event.Message)
+				}
+			})
+
+			t.Run("by_incrementing_metric_once", func(t *testing.T) {
+				t.Parallel()
+
+				labels := map[string]string{"type": testCase.checksType, "annotation": testCase.annotation}
+
+				if v := metricValue(t, registry, metricName, labels); v != 1 {
+					t.Fatalf("Expected metric %q with labels %v to be 1, got %v", metricName, labels, v)
+				}
+			})
+		})
+	}
+
+	t.Run("without_reporting_checks_which_did_not_fail", func(t *testing.T) {
+		t.Parallel()
+
+		labels := map[string]string{"type": "after-reboot", "annotation": testAnotherAfterRebootAnnotation}
+
+		if v := metricValue(t, registry, metricName, labels); v != 0 {
+			t.Fatalf("Expected metric %q with labels %v to be 0, got %v", metricName, labels, v)
+		}
+	})
+
+	t.Run("without_approving_reboot", func(t *testing.T) {
+		t.Parallel()
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), scheduledForRebootNode.Name)
+
+		if v := updatedNode.Annotations[constants.AnnotationOkToReboot]; v != constants.False {
+			t.Fatalf("Expected annotation %q to be %q, got %q", constants.AnnotationOkToReboot, constants.False, v)
+		}
+	})
+}
+
+func Test_Operator_manages_only_nodes_matching_configured_node_selector(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	managedNode := rebootableNode()
+	managedNode.Labels["shard"] = "a"
+
+	unmanagedNode := rebootableNode()
+	unmanagedNode.Name = "unmanaged"
+	unmanagedNode.Labels["shard"] = "b"
+
+	// Node from other shard should not consume rebooting capacity.
+	unmanagedRebootingNode := rebootNotConfirmedNode()
+	unmanagedRebootingNode.Labels["shard"] = "b"
+
+	config, fakeClient := testConfig(managedNode, unmanagedNode, unmanagedRebootingNode)
+	config.NodeSelector = "shard=a"
+	config.MaxRebootingNodes = 2
+
+	nodeUpdated := nodeUpdatedNTimes(fakeClient, 1)
+	<-process(ctx, t, config, fakeClient)
+	<-nodeUpdated
+
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), managedNode.Name)
+	if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
+		t.Fatalf("Expected node %q to be scheduled for reboot", managedNode.Name)
+	}
+
+	updatedNode = node(ctx, t, config.Client.CoreV1().Nodes(), unmanagedNode.Name)
+	if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+		t.Fatalf("Unexpected node %q scheduled for reboot", unmanagedNode.Name)
+	}
+}
+
+func Test_Operator_does_not_schedule_reboot_process_for_nodes_with_excluded_taints(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	taintedNode := rebootableNode()
+	taintedNode.Spec.Taints = []corev1.Taint{
+		{
+			Key:    "node.kubernetes.io/out-of-service",
+			Effect: corev1.TaintEffectNoExecute,
+		},
+	}
+
+	config, fakeClient := testConfig(taintedNode)
+	config.ExcludedTaints = []string{"node.kubernetes.io/out-of-service"}
+
+	<-process(ctx, t, config, fakeClient)
+
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), taintedNode.Name)
+	if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+		t.Fatalf("Unexpected node %q scheduled for reboot", taintedNode.Name)
+	}
+}
+
+func Test_Operator_does_not_schedule_reboot_process_for_nodes_marked_for_scale_down_by(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	cases := map[string]corev1.Taint{
+		"to_be_deleted_taint": {
+			Key:    "ToBeDeletedByClusterAutoscaler",
+			Effect: corev1.TaintEffectNoSchedule,
+		},
+		"deletion_candidate_taint": {
+			Key:    "DeletionCandidateOfClusterAutoscaler",
+			Effect: corev1.TaintEffectPreferNoSchedule,
+		},
+	}
+
+	for name, taint := range cases {
+		taint := taint
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			taintedNode := rebootableNode()
+			taintedNode.Spec.Taints = []corev1.Taint{taint}
+
+			config, fakeClient := testConfig(taintedNode)
+
+			<-process(ctx, t, config, fakeClient)
+
+			updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), taintedNode.Name)
+			if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+				t.Fatalf("Unexpected node %q scheduled for reboot", taintedNode.Name)
+			}
+		})
+	}
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Operator_respects_PodDisruptionBudgets_when_configured_by(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	t.Run("scheduling_reboot_only_for_nodes_which_can_be_drained_together", func(t *testing.T) {
+		t.Parallel()
+
+		rebootableNode := rebootableNode()
+
+		anotherRebootableNode := rebootableNode.DeepCopy()
+		anotherRebootableNode.Name = "another-rebootable"
+
+		config, fakeClient := testConfig(
+			rebootableNode,
+			anotherRebootableNode,
+			testPod("foo-1", rebootableNode.Name),
+			testPod("foo-2", anotherRebootableNode.Name),
+			testPodDisruptionBudget(1),
+		)
+		config.MaxRebootingNodes = 2
+		config.RespectPodDisruptionBudgets = true
+
+		nodeUpdated := nodeUpdatedNTimes(fakeClient, 2)
+		<-process(ctx, t, config, fakeClient)
+		<-nodeUpdated
+
+		scheduledNodes := 0
+
+		for _, name := range []string{rebootableNode.Name, anotherRebootableNode.Name} {
+			updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), name)
+			if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+				scheduledNodes++
+			}
+		}
+
+		if scheduledNodes != 1 {
+			t.Fatalf("Expected exactly one node to be scheduled for reboot, got %d", scheduledNodes)
+		}
+	})
+
+	t.Run("not_scheduling_reboot_for_node_when_budget_is_used_by_rebooting_nodes", func(t *testing.T) {
+		t.Parallel()
+
+		rebootableNode := rebootableNode()
+		rebootingNode := rebootingNode()
+
+		config, fakeClient := testConfig(
+			rebootableNode,
+			rebootingNode,
+			testPod("foo-1", rebootableNode.Name),
+			testPod("foo-2", rebootingNode.Name),
+			testPodDisruptionBudget(1),
+		)
+		config.MaxRebootingNodes = 2
+		config.RespectPodDisruptionBudgets = true
+
+		<-process(ctx, t, config, fakeClient)
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+			t.Fatalf("Unexpected node %q scheduled for reboot", rebootableNode.Name)
+		}
+	})
+
+	t.Run("scheduling_reboot_for_nodes_not_hosting_pods_covered_by_budget", func(t *testing.T) {
+		t.Parallel()
+
+		rebootableNode := rebootableNode()
+
+		unrelatedPod := testPod("bar", rebootableNode.Name)
+		unrelatedPod.Labels = map[string]string{"app": "bar"}
+
+		config, fakeClient := testConfig(rebootableNode, unrelatedPod, testPodDisruptionBudget(0))
+		config.RespectPodDisruptionBudgets = true
+
+		nodeUpdated := nodeUpdatedNTimes(fakeClient, 1)
+		<-process(ctx, t, config, fakeClient)
+		<-nodeUpdated
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
+			t.Fatalf("Expected node %q to be scheduled for reboot", rebootableNode.Name)
+		}
+	})
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Operator_defers_scheduling_reboots_when_configured_and(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	t.Run("cluster_autoscaler_reports_scale_up_in_progress", func(t *testing.T) {
+		t.Parallel()
+
+		rebootableNode := rebootableNode()
+
+		config, fakeClient := testConfig(rebootableNode, clusterAutoscalerStatus("InProgress", "NoCandidates"))
+		config.DeferRebootsDuringAutoscaling = true
+
+		<-process(ctx, t, config, fakeClient)
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+			t.Fatalf("Unexpected node %q scheduled for reboot", rebootableNode.Name)
+		}
+	})
+
+	t.Run("cluster_autoscaler_reports_scale_down_candidates", func(t *testing.T) {
+		t.Parallel()
+
+		rebootableNode := rebootableNode()
+
+		config, fakeClient := testConfig(rebootableNode, clusterAutoscalerStatus("NoActivity", "CandidatesPresent"))
+		config.DeferRebootsDuringAutoscaling = true
+
+		<-process(ctx, t, config, fakeClient)
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+			t.Fatalf("Unexpected node %q scheduled for reboot", rebootableNode.Name)
+		}
+	})
+
+	t.Run("node_has_been_registered_recently", func(t *testing.T) {
+		t.Parallel()
+
+		rebootableNode := rebootableNode()
+
+		newNode := idleNode()
+		newNode.CreationTimestamp = metav1.Now()
+
+		config, fakeClient := testConfig(rebootableNode, newNode)
+		config.DeferRebootsDuringAutoscaling = true
+
+		<-process(ctx, t, config, fakeClient)
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+			t.Fatalf("Unexpected node %q scheduled for reboot", rebootableNode.Name)
+		}
+	})
+}
+
+func Test_Operator_schedules_reboots_when_deferring_reboots_during_autoscaling_is_configured_and(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	cases := map[string][]runtime.Object{
+		"cluster_autoscaler_reports_no_activity":   {clusterAutoscalerStatus("NoActivity", "NoCandidates")},
+		"cluster_autoscaler_status_does_not_exist": {},
+	}
 
-	// To inform agent that all hooks are executed and it can proceed with the reboot.
-	// Right now by setting ok-to-reboot label to true.
-	t.Run("informing_agent_to_proceed_with_reboot_process", func(t *testing.T) {
-		t.Parallel()
+	for name, objects := range cases {
+		objects := objects
 
-		okToReboot, ok := updatedNode.Annotations[constants.AnnotationOkToReboot]
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
 
-		if !ok {
-			t.Fatalf("Expected annotation %q not found, got %v", constants.AnnotationOkToReboot, updatedNode.Annotations)
-		}
+			rebootableNode := rebootableNode()
 
-		if okToReboot != constants.True {
-			t.Fatalf("Expected annotation %q value to be %q, got %q",
-				constants.AnnotationOkToReboot, constants.True, okToReboot)
-		}
-	})
+			config, fakeClient := testConfig(append(objects, rebootableNode)...)
+			config.DeferRebootsDuringAutoscaling = true
+
+			nodeUpdated := nodeUpdatedNTimes(fakeClient, 1)
+			<-process(ctx, t, config, fakeClient)
+			<-nodeUpdated
+
+			updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+			if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
+				t.Fatalf("Expected node %q to be scheduled for reboot", rebootableNode.Name)
+			}
+		})
+	}
 }
 
-// Test opposite conditions starting from base to make sure all cases are covered.
-//
-//nolint:funlen,cyclop // Just many test cases.
-func Test_Operator_counts_nodes_as_just_rebooted_which(t *testing.T) {
+//nolint:funlen // Just many sub-tests.
+func Test_Operator_exposes_metric_with_number_of(t *testing.T) {
 	t.Parallel()
 
 	ctx := contextWithDeadline(t)
 
+	registry := prometheus.NewRegistry()
+
+	config, fakeClient := testConfig(rebootableNode(), rebootingNode(), finishedRebootingNode(), scheduledForRebootNode())
+	config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+	config.MaxRebootingNodes = 4
+	config.MetricsRegisterer = registry
+
+	// Metrics describing nodes are recorded at the beginning of reconciliation cycle,
+	// so they reflect the state from before the cycle.
+	<-process(ctx, t, config, fakeClient)
+
 	cases := map[string]struct {
-		mutateF            func(*corev1.Node)
-		expectJustRebooted bool
+		metricName    string
+		expectedValue float64
 	}{
-		"has_all_conditions_met": {
-			expectJustRebooted: true,
+		"nodes_needing_reboot": {
+			metricName:    "fluo_nodes_reboot_needed",
+			expectedValue: 1,
 		},
-		// Nodes which we allowed to reboot.
-		"has_reboot_approved": {
-			mutateF: func(updatedNode *corev1.Node) {
-				updatedNode.Annotations[constants.AnnotationOkToReboot] = constants.False
-			},
+		"rebooting_nodes": {
+			metricName:    "fluo_nodes_rebooting",
+			expectedValue: 3,
 		},
-		// Nodes which already rebooted.
-		"does_not_need_a_reboot": {
-			mutateF: func(updatedNode *corev1.Node) {
-				updatedNode.Annotations[constants.AnnotationRebootNeeded] = constants.True
-			},
+		"nodes_running_before_reboot_hooks": {
+			metricName:    "fluo_nodes_before_reboot_hooks",
+			expectedValue: 1,
 		},
-		// Nodes which already reported that they are back from rebooting.
-		"which_finished_the_reboot": {
-			mutateF: func(updatedNode *corev1.Node) {
-				updatedNode.Annotations[constants.AnnotationRebootInProgress] = constants.True
-			},
+		"nodes_running_after_reboot_hooks": {
+			metricName:    "fluo_nodes_after_reboot_hooks",
+			expectedValue: 1,
 		},
-		// Nodes which do not have hooks scheduled yet.
-		"has_no_after_reboot_label": {
-			mutateF: func(updatedNode *corev1.Node) {
-				updatedNode.Labels[constants.LabelAfterReboot] = constants.True
-			},
+		"completed_reboots": {
+			metricName:    "flatcar_linux_update_operator_reboots_completed_total",
+			expectedValue: 1,
 		},
 	}
 
@@ -983,300 +3551,426 @@ func Test_Operator_counts_nodes_as_just_rebooted_which(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			justRebootedNode := justRebootedNode()
-			if testCase.mutateF != nil {
-				testCase.mutateF(justRebootedNode)
+			if v := metricValue(t, registry, testCase.metricName, nil); v != testCase.expectedValue {
+				t.Fatalf("Expected metric %q to be %v, got %v", testCase.metricName, testCase.expectedValue, v)
 			}
+		})
+	}
+}
 
-			config, fakeClient := testConfig(justRebootedNode)
-			config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+func Test_Operator_exposes_metric_with_time_from_node_needing_reboot_to_finishing_reboot_process(t *testing.T) {
+	t.Parallel()
 
-			<-process(ctx, t, config, fakeClient)
+	ctx := contextWithDeadline(t)
 
-			updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), justRebootedNode.Name)
+	registry := prometheus.NewRegistry()
 
-			v, ok := updatedNode.Labels[constants.LabelAfterReboot]
-			if testCase.expectJustRebooted {
-				if !ok || v != constants.True {
-					t.Errorf("Expected after reboot label, got %v", updatedNode.Labels)
-				}
+	rebootNeededSince := time.Now().Add(-2 * time.Hour).UTC()
 
-				if _, ok := updatedNode.Annotations[testAfterRebootAnnotation]; ok {
-					t.Errorf("Expected annotation %q to be removed", testAfterRebootAnnotation)
-				}
+	finishedRebootingNode := finishedRebootingNode()
+	finishedRebootingNode.Annotations[constants.AnnotationRebootNeededSinceBeforeReboot] = rebootNeededSince.Format(
+		time.RFC3339)
 
-				if _, ok := updatedNode.Annotations[testAnotherAfterRebootAnnotation]; ok {
-					t.Errorf("Expected annotation %q to be removed", testAnotherAfterRebootAnnotation)
-				}
-			}
+	unknownRebootNeededNode := finishedRebootingNode.DeepCopy()
+	unknownRebootNeededNode.Name = "unknown-reboot-needed"
+	delete(unknownRebootNeededNode.Annotations, constants.AnnotationRebootNeededSinceBeforeReboot)
 
-			if !testCase.expectJustRebooted {
-				v, ok := updatedNode.Annotations[testAfterRebootAnnotation]
-				if !ok || v != constants.False {
-					t.Fatalf("Expected annotation %q to be left untouched", testAfterRebootAnnotation)
-				}
-			}
-		})
+	config, fakeClient := testConfig(finishedRebootingNode, unknownRebootNeededNode)
+	config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+	config.MetricsRegisterer = registry
+
+	<-process(ctx, t, config, fakeClient)
+
+	metricName := "flatcar_linux_update_operator_reboot_latency_seconds"
+
+	metricFamilies, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed gathering metrics: %v", err)
 	}
+
+	for _, metricFamily := range metricFamilies {
+		if metricFamily.GetName() != metricName {
+			continue
+		}
+
+		histogram := metricFamily.GetMetric()[0].GetHistogram()
+
+		if count := histogram.GetSampleCount(); count != 1 {
+			t.Fatalf("Expected only reboot of node with known reboot request time to be observed, got %d", count)
+		}
+
+		if sum := histogram.GetSampleSum(); sum < (2 * time.Hour).Seconds() {
+			t.Fatalf("Expected observed latency to be at least 2 hours, got %v seconds", sum)
+		}
+
+		return
+	}
+
+	t.Fatalf("Metric %q not found", metricName)
 }
 
-// To schedule post-reboot hooks.
-func Test_Operator_confirms_reboot_process_by(t *testing.T) {
+func Test_Operator_exposes_node_metrics_by_node_pool(t *testing.T) {
 	t.Parallel()
 
-	justRebootedNode := justRebootedNode()
-	justRebootedNode.Annotations[testAfterRebootAnnotation] = constants.True
-	justRebootedNode.Annotations[testAnotherAfterRebootAnnotation] = constants.True
+	ctx := contextWithDeadline(t)
 
-	config, fakeClient := testConfig(justRebootedNode)
-	config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+	registry := prometheus.NewRegistry()
 
-	ctx := contextWithDeadline(t)
+	rebootableNodeInPoolA := rebootableNode()
+	rebootableNodeInPoolA.Name = "rebootable-a"
+	rebootableNodeInPoolA.Labels[testPoolLabel] = "a"
+
+	rebootableNodeInPoolB := rebootableNode()
+	rebootableNodeInPoolB.Name = "rebootable-b"
+	rebootableNodeInPoolB.Labels[testPoolLabel] = "b"
+
+	anotherRebootableNodeInPoolB := rebootableNode()
+	anotherRebootableNodeInPoolB.Name = "another-rebootable-b"
+	anotherRebootableNodeInPoolB.Labels[testPoolLabel] = "b"
+
+	config, fakeClient := testConfig(rebootableNodeInPoolA, rebootableNodeInPoolB, anotherRebootableNodeInPoolB)
+	config.PoolLabel = testPoolLabel
+	config.MetricsRegisterer = registry
 
 	<-process(ctx, t, config, fakeClient)
 
-	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), justRebootedNode.Name)
+	metricName := "fluo_nodes_reboot_needed"
 
-	// To ensure all annotations are freshly set.
-	t.Run("removing_all_after_reboot_annotations", func(t *testing.T) {
-		t.Parallel()
+	for pool, expectedValue := range map[string]float64{"a": 1, "b": 2} {
+		labels := map[string]string{"pool": pool}
 
-		if _, ok := updatedNode.Annotations[testAfterRebootAnnotation]; ok {
-			t.Fatalf("Unexpected annotation %q found", testAfterRebootAnnotation)
+		if v := metricValue(t, registry, metricName, labels); v != expectedValue {
+			t.Errorf("Expected metric %q with labels %v to be %v, got %v", metricName, labels, expectedValue, v)
 		}
+	}
+}
 
-		if _, ok := updatedNode.Annotations[testAnotherAfterRebootAnnotation]; ok {
-			t.Fatalf("Unexpected annotation %q found", testAnotherAfterRebootAnnotation)
+func Test_Operator_records_reconciliation_steps_as_spans(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	recorder := tracetest.NewSpanRecorder()
+
+	config, fakeClient := testConfig(rebootableNode())
+	config.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	<-process(ctx, t, config, fakeClient)
+
+	var reconcileSpanID oteltrace.SpanID
+
+	stepParents := map[string]oteltrace.SpanID{}
+
+	for _, span := range recorder.Ended() {
+		if span.Name() == "reconcile" {
+			reconcileSpanID = span.SpanContext().SpanID()
+
+			break
 		}
-	})
 
-	// To schedule after-reboot hook pods.
-	t.Run("setting_after_reboot_label_to_true", func(t *testing.T) {
-		t.Parallel()
+		stepParents[span.Name()] = span.Parent().SpanID()
+	}
 
-		afterReboot, ok := updatedNode.Labels[constants.LabelAfterReboot]
+	if !reconcileSpanID.IsValid() {
+		t.Fatalf("Expected reconciliation span to be recorded")
+	}
+
+	for _, step := range []string{
+		"reload_config", "cleanup_state", "check_after_reboot", "mark_after_reboot", "check_before_reboot",
+		"mark_before_reboot",
+	} {
+		parent, ok := stepParents[step]
 		if !ok {
-			t.Fatalf("Expected label %q not found, not %v", constants.LabelAfterReboot, updatedNode.Labels)
+			t.Errorf("Expected span for step %q to be recorded", step)
+
+			continue
 		}
 
-		if afterReboot != constants.True {
-			t.Fatalf("Expected label value %q, got %q", constants.True, afterReboot)
+		if parent != reconcileSpanID {
+			t.Errorf("Expected span for step %q to be a child of reconciliation span", step)
 		}
-	})
+	}
 }
 
-// Test opposite conditions starting from base to make sure all cases are covered.
-func Test_Operator_counts_nodes_as_which_finished_rebooting_which_has(t *testing.T) {
+func Test_Operator_exposes_metrics_with_duration_of_reconciliation_and_each_reconciliation_step(t *testing.T) {
 	t.Parallel()
 
 	ctx := contextWithDeadline(t)
 
-	cases := map[string]struct {
-		mutateF                 func(*corev1.Node)
-		expectFinishedRebooting bool
-	}{
-		"all_conditions_met": {
-			expectFinishedRebooting: true,
-		},
-		// Only consider nodes which runs the after-reboot hooks.
-		"after_reboot_label_set": {
-			mutateF: func(updatedNode *corev1.Node) {
-				delete(updatedNode.Labels, constants.LabelAfterReboot)
-			},
-		},
-		// To verify all hooks executed successfully.
-		"all_after_reboot_annotations_set_to_true": {
-			mutateF: func(updatedNode *corev1.Node) {
-				updatedNode.Annotations[testAfterRebootAnnotation] = constants.False
-			},
-		},
+	registry := prometheus.NewRegistry()
+
+	config, _ := testConfig(idleNode())
+	config.MetricsRegisterer = registry
+
+	runOperator(ctx, t, kontrollerWithObjects(t, config), make(chan struct{}))
+
+	type expectedMetric struct {
+		name   string
+		labels map[string]string
 	}
 
-	for name, testCase := range cases {
-		testCase := testCase
+	expectedMetrics := []expectedMetric{{name: "flatcar_linux_update_operator_reconciliation_duration_seconds"}}
 
-		t.Run(name, func(t *testing.T) {
-			t.Parallel()
+	for _, step := range []string{
+		"reload_config", "cleanup_state", "check_after_reboot", "mark_after_reboot", "check_before_reboot",
+		"mark_before_reboot",
+	} {
+		expectedMetrics = append(expectedMetrics, expectedMetric{
+			name:   "flatcar_linux_update_operator_reconciliation_step_duration_seconds",
+			labels: map[string]string{"step": step},
+		})
+	}
 
-			finishedRebootingNode := finishedRebootingNode()
-			if testCase.mutateF != nil {
-				testCase.mutateF(finishedRebootingNode)
+	for _, metric := range expectedMetrics {
+		// Metrics are observed asynchronously to the test, so poll for them.
+		for metricValue(t, registry, metric.name, metric.labels) < 1 {
+			select {
+			case <-ctx.Done():
+				t.Fatalf("Timed out waiting for metric %q with labels %v to be observed", metric.name, metric.labels)
+			case <-time.After(100 * time.Millisecond):
 			}
+		}
+	}
+}
 
-			config, fakeClient := testConfig(finishedRebootingNode)
-			config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+func Test_Operator_exposes_metric_with_number_of_reconciliation_errors(t *testing.T) {
+	t.Parallel()
 
-			<-process(ctx, t, config, fakeClient)
+	ctx := contextWithDeadline(t)
 
-			updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), finishedRebootingNode.Name)
+	registry := prometheus.NewRegistry()
 
-			v, ok := updatedNode.Annotations[constants.AnnotationOkToReboot]
-			if !testCase.expectFinishedRebooting && ok && v != constants.True {
-				t.Fatalf("Expected after reboot label, got %v", updatedNode.Labels)
-			}
+	config, fakeClient := testConfig(idleNode())
+	config.MetricsRegisterer = registry
 
-			if testCase.expectFinishedRebooting && ok && v == constants.True {
-				t.Fatalf("Unexpected after reboot label")
-			}
-		})
+	requestFailed, failRequest := failOnNthCall(0, fmt.Errorf(t.Name()))
+	fakeClient.PrependReactor("list", "nodes", failRequest)
+
+	runOperator(ctx, t, kontrollerWithObjects(t, config), make(chan struct{}))
+
+	<-requestFailed
+
+	metricName := "flatcar_linux_update_operator_reconciliation_errors_total"
+	labels := map[string]string{"step": "cleanup_state"}
+
+	// Metric is incremented after the request fails, so poll for it.
+	for metricValue(t, registry, metricName, labels) != 1 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for metric %q to be incremented", metricName)
+		case <-time.After(100 * time.Millisecond):
+		}
 	}
 }
 
-//nolint:funlen // Just many sub-tests.
-func Test_Operator_stops_current_reconciliation_when(t *testing.T) {
-	t.Parallel()
+//nolint:funlen // Just many sub-tests.
+func Test_Operator_applies_configuration_from_FluoConfig(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDeadline(t)
+
+	t.Run("pausing_scheduling_reboot_process", func(t *testing.T) {
+		t.Parallel()
+
+		rebootableNode := rebootableNode()
+
+		config, fakeClient := testConfig(rebootableNode)
+		config.ConfigName = testConfigName
+		config.DynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), fluoConfig(map[string]interface{}{
+			"paused": true,
+		}))
+
+		<-process(ctx, t, config, fakeClient)
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+			t.Fatalf("Unexpected node %q scheduled for reboot", rebootableNode.Name)
+		}
+	})
+
+	t.Run("reboot_window_taking_precedence_over_operator_configuration", func(t *testing.T) {
+		t.Parallel()
+
+		rebootableNode := rebootableNode()
+
+		config, fakeClient := testConfig(rebootableNode)
+		config.ConfigName = testConfigName
+		config.DynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), fluoConfig(map[string]interface{}{
+			"rebootWindowStart":  time.Now().Add(2 * time.Hour).Format("15:04"),
+			"rebootWindowLength": "1h",
+		}))
+
+		<-process(ctx, t, config, fakeClient)
+
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+			t.Fatalf("Unexpected node %q scheduled for reboot", rebootableNode.Name)
+		}
+	})
+
+	t.Run("maximum_number_of_rebooting_nodes", func(t *testing.T) {
+		t.Parallel()
+
+		rebootableNode := rebootableNode()
+
+		anotherRebootableNode := rebootableNode.DeepCopy()
+		anotherRebootableNode.Name = "another-rebootable"
+
+		config, fakeClient := testConfig(rebootableNode, anotherRebootableNode)
+		config.ConfigName = testConfigName
+		config.DynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), fluoConfig(map[string]interface{}{
+			"maxRebootingNodes": int64(2),
+		}))
+
+		nodeUpdated := nodeUpdatedNTimes(fakeClient, 3)
+		<-process(ctx, t, config, fakeClient)
+		<-nodeUpdated
+
+		for _, name := range []string{rebootableNode.Name, anotherRebootableNode.Name} {
+			updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), name)
+			if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
+				t.Fatalf("Expected node %q to be scheduled for reboot", name)
+			}
+		}
+	})
+
+	t.Run("falling_back_to_operator_configuration_when_FluoConfig_does_not_exist", func(t *testing.T) {
+		t.Parallel()
 
-	for name, testCase := range map[string]struct {
-		node                  *corev1.Node
-		failingListCall       int
-		failingUpdateCall     int
-		expectedNodeCondition func(*corev1.Node) bool
-	}{
-		"cleaning_up_node_state_fails_because": {
-			node:              rebootCancelledNode(),
-			failingListCall:   0,
-			failingUpdateCall: 0,
-			expectedNodeCondition: func(node *corev1.Node) bool {
-				_, ok := node.Labels[constants.LabelBeforeReboot]
+		rebootableNode := rebootableNode()
 
-				return ok
-			},
-		},
-		"evaluating_nodes_which_finished_rebooting_fails_because": {
-			node:              finishedRebootingNode(),
-			failingListCall:   1,
-			failingUpdateCall: 0,
-			expectedNodeCondition: func(node *corev1.Node) bool {
-				_, ok := node.Labels[constants.LabelAfterReboot]
+		config, fakeClient := testConfig(rebootableNode)
+		config.ConfigName = testConfigName
+		config.DynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
 
-				return ok
-			},
-		},
-		"evaluating_nodes_which_just_rebooted_fails_because": {
-			node:              justRebootedNode(),
-			failingListCall:   2,
-			failingUpdateCall: 1,
-			expectedNodeCondition: func(node *corev1.Node) bool {
-				_, ok := node.Labels[constants.LabelAfterReboot]
+		nodeUpdated := nodeUpdatedNTimes(fakeClient, 1)
+		<-process(ctx, t, config, fakeClient)
+		<-nodeUpdated
 
-				return !ok
-			},
-		},
-		"evaluating_nodes_which_are_ready_to_reboot_fails_because": {
-			node:              readyToRebootNode(),
-			failingListCall:   3,
-			failingUpdateCall: 1,
-			expectedNodeCondition: func(node *corev1.Node) bool {
-				v, ok := node.Labels[constants.LabelBeforeReboot]
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; !ok {
+			t.Fatalf("Expected node %q to be scheduled for reboot", rebootableNode.Name)
+		}
+	})
+}
 
-				return ok || v != constants.True
-			},
-		},
-		"evaluating_nodes_which_needs_to_reboot_fails_because": {
-			node:              rebootableNode(),
-			failingListCall:   4,
-			failingUpdateCall: 1,
-			expectedNodeCondition: func(node *corev1.Node) bool {
-				v, ok := node.Labels[constants.LabelBeforeReboot]
+func Test_Operator_applies_FluoConfig_changes_without_restart(t *testing.T) {
+	t.Parallel()
 
-				return ok || v != constants.True
-			},
-		},
-	} {
-		testCase := testCase
+	ctx := contextWithDeadline(t)
 
-		t.Run(name, func(t *testing.T) {
-			t.Parallel()
+	rebootableNode := rebootableNode()
 
-			for subName, subTestCase := range map[string]struct {
-				failingCall int
-				verb        string
-			}{
-				"listing_node_objects_fails": {
-					failingCall: testCase.failingListCall,
-					verb:        "list",
-				},
-				"updating_node_fails": {
-					failingCall: testCase.failingUpdateCall,
-					verb:        "update",
-				},
-			} {
-				subTestCase := subTestCase
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), fluoConfig(map[string]interface{}{
+		"paused": true,
+	}))
 
-				t.Run(subName, func(t *testing.T) {
-					t.Parallel()
+	config, fakeClient := testConfig(rebootableNode)
+	config.ConfigName = testConfigName
+	config.DynamicClient = dynamicClient
+	config.ReconciliationPeriod = 10 * time.Millisecond
 
-					config, fakeClient := testConfig(testCase.node)
-					requestFailed, failRequest := failOnNthCall(subTestCase.failingCall, fmt.Errorf(t.Name()))
-					fakeClient.PrependReactor(subTestCase.verb, "nodes", failRequest)
+	reconcileCycleCh := process(ctx, t, config, fakeClient)
 
-					ctx, cancel := context.WithTimeout(contextWithDeadline(t), 5*time.Second)
-					t.Cleanup(cancel)
+	<-reconcileCycleCh
 
-					process(ctx, t, config, fakeClient)
+	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+	if _, ok := updatedNode.Labels[constants.LabelBeforeReboot]; ok {
+		t.Fatalf("Unexpected node %q scheduled for reboot while paused", rebootableNode.Name)
+	}
 
-					select {
-					case <-requestFailed:
-					case <-ctx.Done():
-						t.Fatalf("Timed out waiting for request to fail")
-					}
+	_, err := dynamicClient.Resource(operator.FluoConfigResource).Namespace(testNamespace).Update(
+		ctx, fluoConfig(map[string]interface{}{"paused": false}), metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Failed updating FluoConfig: %v", err)
+	}
 
-					if !testCase.expectedNodeCondition(node(ctx, t, config.Client.CoreV1().Nodes(), testCase.node.Name)) {
-						t.Fatalf("Expected condition not met")
-					}
-				})
+	// Keep consuming reconciliation cycles, so operator does not get blocked.
+	drainCtx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+
+	go func() {
+		for {
+			select {
+			case <-reconcileCycleCh:
+			case <-drainCtx.Done():
+				return
 			}
-		})
+		}
+	}()
+
+	// Node may quickly proceed with the reboot process, so check for reboot start time rather than the label.
+	for {
+		updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), rebootableNode.Name)
+		if _, ok := updatedNode.Annotations[constants.AnnotationRebootStartedTime]; ok {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for node %q to be scheduled for reboot", rebootableNode.Name)
+		case <-time.After(100 * time.Millisecond):
+		}
 	}
 }
 
-// To de-schedule post-reboot hooks.
-func Test_Operator_finishes_reboot_process_by(t *testing.T) {
+//nolint:funlen // Just many sub-tests.
+func Test_Operator_publishes_status(t *testing.T) {
 	t.Parallel()
 
-	finishedRebootingNode := finishedRebootingNode()
+	ctx := contextWithDeadline(t)
 
-	config, fakeClient := testConfig(finishedRebootingNode)
-	config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+	t.Run("with_leader_identity_and_number_of_nodes_in_each_phase", func(t *testing.T) {
+		t.Parallel()
 
-	ctx := contextWithDeadline(t)
-	<-process(ctx, t, config, fakeClient)
+		config, fakeClient := testConfig(idleNode(), rebootableNode(), rebootingNode(), finishedRebootingNode())
+		config.AfterRebootAnnotations = []string{testAfterRebootAnnotation, testAnotherAfterRebootAnnotation}
+		config.StatusConfigMap = operator.DefaultStatusConfigMap
 
-	updatedNode := node(ctx, t, config.Client.CoreV1().Nodes(), finishedRebootingNode.Name)
+		<-process(ctx, t, config, fakeClient)
 
-	// To de-schedule hook pods.
-	t.Run("removing_after_reboot_label", func(t *testing.T) {
-		t.Parallel()
+		status := operatorStatus(ctx, t, config.Client)
 
-		if _, ok := updatedNode.Labels[constants.LabelAfterReboot]; ok {
-			t.Fatalf("Unexpected after reboot label found")
+		if status.Leader != config.LockID {
+			t.Fatalf("Expected leader %q, got %q", config.LockID, status.Leader)
 		}
-	})
 
-	// To cleanup the state before next runs.
-	t.Run("removing_all_after_reboot_annotations", func(t *testing.T) {
-		t.Parallel()
+		if status.LastReconcileTime.IsZero() {
+			t.Fatalf("Expected last reconcile time to be set")
+		}
 
-		if _, ok := updatedNode.Annotations[testAfterRebootAnnotation]; ok {
-			t.Fatalf("Unexpected after reboot annotation %q found", testAfterRebootAnnotation)
+		expectedNodes := map[string]int{
+			operator.NodePhaseIdle:         1,
+			operator.NodePhaseRebootNeeded: 1,
+			operator.NodePhaseBeforeReboot: 0,
+			operator.NodePhaseRebooting:    1,
+			operator.NodePhaseAfterReboot:  1,
 		}
 
-		if _, ok := updatedNode.Annotations[testAnotherAfterRebootAnnotation]; ok {
-			t.Fatalf("Unexpected after reboot annotation %q found", testAnotherAfterRebootAnnotation)
+		for phase, expectedCount := range expectedNodes {
+			if count := status.Nodes[phase]; count != expectedCount {
+				t.Fatalf("Expected %d nodes in phase %q, got %d: %v", expectedCount, phase, count, status.Nodes)
+			}
 		}
 	})
 
-	// To finalize reboot process. Implementation detail! setting ok-to-reboot label to false.
-	t.Run("informing_agent_to_not_proceed_with_reboot_process", func(t *testing.T) {
+	t.Run("with_last_reconciliation_error", func(t *testing.T) {
 		t.Parallel()
 
-		okToReboot, ok := updatedNode.Annotations[constants.AnnotationOkToReboot]
-		if !ok {
-			t.Fatalf("Expected annotation %q not found, got %v", constants.AnnotationOkToReboot, updatedNode.Labels)
-		}
+		config, fakeClient := testConfig(idleNode())
+		config.StatusConfigMap = operator.DefaultStatusConfigMap
 
-		if okToReboot != constants.False {
-			t.Fatalf("Expected annotation %q value %q, got %q", constants.AnnotationOkToReboot, constants.False, okToReboot)
+		requestFailed, failRequest := failOnNthCall(0, fmt.Errorf(t.Name()))
+		fakeClient.PrependReactor("list", "nodes", failRequest)
+
+		runOperator(ctx, t, kontrollerWithObjects(t, config), make(chan struct{}))
+
+		<-requestFailed
+
+		status := operatorStatus(ctx, t, config.Client)
+
+		if status.LastError == "" || status.LastErrorTime == nil {
+			t.Fatalf("Expected last error to be set, got %+v", status)
 		}
 	})
 }
@@ -1351,6 +4045,89 @@ func kontrollerWithObjects(t *testing.T, config operator.Config) *operator.Kontr
 	return kontroller
 }
 
+// FluoConfig object with a given spec.
+func fluoConfig(spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": operator.FluoConfigResource.GroupVersion().String(),
+			"kind":       "FluoConfig",
+			"metadata": map[string]interface{}{
+				"name":      testConfigName,
+				"namespace": testNamespace,
+			},
+			"spec": spec,
+		},
+	}
+}
+
+// testNotifier passes all notifications to a channel.
+type testNotifier struct {
+	notifications chan notify.Notification
+}
+
+func (n *testNotifier) Notify(_ context.Context, notification notify.Notification) error {
+	n.notifications <- notification
+
+	return nil
+}
+
+// Running pod with app=foo label scheduled on a given node.
+func testPod(name, nodeName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels:    map[string]string{"app": "foo"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+		},
+	}
+}
+
+// PodDisruptionBudget covering pods with app=foo label, which allows given number of disruptions.
+func testPodDisruptionBudget(disruptionsAllowed int32) *policyv1.PodDisruptionBudget {
+	minAvailable := intstr.FromInt(1)
+
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: testNamespace,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "foo"},
+			},
+		},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			DisruptionsAllowed: disruptionsAllowed,
+		},
+	}
+}
+
+func clusterAutoscalerStatus(scaleUp, scaleDown string) *corev1.ConfigMap {
+	status := fmt.Sprintf(`Cluster-autoscaler status at 2023-08-01 12:00:00.000000000 +0000 UTC:
+Cluster-wide:
+  Health:      Healthy (ready=2 unready=0 notStarted=0 longNotStarted=0 registered=2 longUnregistered=0)
+  ScaleUp:     %s (ready=2 registered=2)
+  ScaleDown:   %s (candidates=0)
+`, scaleUp, scaleDown)
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-autoscaler-status",
+			Namespace: "kube-system",
+		},
+		Data: map[string]string{
+			operator.ClusterAutoscalerStatusConfigMapKey: status,
+		},
+	}
+}
+
 // Node with no need for rebooting.
 func idleNode() *corev1.Node {
 	return &corev1.Node{
@@ -1464,6 +4241,22 @@ func rebootingNode() *corev1.Node {
 	}
 }
 
+// Node which agent reboots in emergency mode, without approval.
+func emergencyRebootingNode() *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "emergency-rebooting",
+			Labels: map[string]string{},
+			Annotations: map[string]string{
+				constants.AnnotationOkToReboot:       constants.False,
+				constants.AnnotationRebootNeeded:     constants.False,
+				constants.AnnotationRebootInProgress: constants.True,
+				constants.AnnotationEmergencyReboot:  constants.True,
+			},
+		},
+	}
+}
+
 // Node which agent just finished rebooting.
 func justRebootedNode() *corev1.Node {
 	return &corev1.Node{
@@ -1512,6 +4305,108 @@ func node(ctx context.Context, t *testing.T, nodeClient corev1client.NodeInterfa
 	return node
 }
 
+// nodeEvent waits until event with given reason is emitted for a given node and returns it.
+func nodeEvent(ctx context.Context, t *testing.T, client kubernetes.Interface, nodeName, reason string) *corev1.Event {
+	t.Helper()
+
+	// Events are published asynchronously, so poll for them.
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		events, err := client.CoreV1().Events(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("Listing events: %v", err)
+		}
+
+		for i, event := range events.Items {
+			if event.InvolvedObject.Name == nodeName && event.Reason == reason {
+				return &events.Items[i]
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for event %q for node %q, got: %v", reason, nodeName, events.Items)
+		case <-ticker.C:
+		}
+	}
+}
+
+// metricValue returns value of a gauge or counter metric with a given name and labels from a given registry.
+// For histograms, number of observations is returned.
+//
+// If metric is not found, zero is returned.
+func metricValue(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) float64 {
+	t.Helper()
+
+	metricFamilies, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed gathering metrics: %v", err)
+	}
+
+	for _, metricFamily := range metricFamilies {
+		if metricFamily.GetName() != name {
+			continue
+		}
+
+		for _, metric := range metricFamily.GetMetric() {
+			matchingLabels := 0
+
+			for _, label := range metric.GetLabel() {
+				if v, ok := labels[label.GetName()]; ok && v == label.GetValue() {
+					matchingLabels++
+				}
+			}
+
+			if matchingLabels != len(labels) {
+				continue
+			}
+
+			if metric.GetCounter() != nil {
+				return metric.GetCounter().GetValue()
+			}
+
+			if metric.GetHistogram() != nil {
+				return float64(metric.GetHistogram().GetSampleCount())
+			}
+
+			return metric.GetGauge().GetValue()
+		}
+	}
+
+	return 0
+}
+
+// operatorStatus waits for the operator status to be published and returns it.
+func operatorStatus(ctx context.Context, t *testing.T, client kubernetes.Interface) operator.Status {
+	t.Helper()
+
+	for {
+		configMap, err := client.CoreV1().ConfigMaps(testNamespace).Get(ctx, operator.DefaultStatusConfigMap,
+			metav1.GetOptions{})
+
+		switch {
+		case err == nil:
+			status := operator.Status{}
+
+			if err := json.Unmarshal([]byte(configMap.Data[operator.StatusConfigMapKey]), &status); err != nil {
+				t.Fatalf("Failed decoding operator status: %v", err)
+			}
+
+			return status
+		case !apierrors.IsNotFound(err):
+			t.Fatalf("Failed getting status ConfigMap: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for operator status to be published")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
 func process(ctx context.Context, t *testing.T, config operator.Config, fakeClient *k8stesting.Fake) chan struct{} {
 	t.Helper()
 
@@ -1549,9 +4444,19 @@ func nodeUpdatedNTimes(fakeClient *k8stesting.Fake, expectedUpdateCalls int) cha
 	updateCallsCount := 0
 	nodeUpdatedCh := make(chan struct{}, 1)
 
-	fakeClient.PrependReactor("update", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+	// Nodes are either updated or patched, depending on whether new state depends on the old one.
+	fakeClient.PrependReactor("*", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if verb := action.GetVerb(); verb != "update" && verb != "patch" {
+			return false, nil, nil
+		}
+
 		if updateCallsCount == expectedUpdateCalls {
-			nodeUpdatedCh <- struct{}{}
+			// Do not block when nobody waits for more updates, as reactors are called with
+			// fake client lock held.
+			select {
+			case nodeUpdatedCh <- struct{}{}:
+			default:
+			}
 
 			updateCallsCount = 0
 
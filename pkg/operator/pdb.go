@@ -0,0 +1,117 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// disruptionBudget tracks how many more pods covered by a PodDisruptionBudget may be disrupted.
+type disruptionBudget struct {
+	name      string
+	namespace string
+	selector  labels.Selector
+	remaining int
+}
+
+// disruptionBudgets tracks disruptions caused by draining nodes selected for rebooting.
+type disruptionBudgets struct {
+	budgets    []*disruptionBudget
+	podsByNode map[string][]corev1.Pod
+}
+
+// disruptionBudgets lists pods and PodDisruptionBudgets in the cluster and calculates how many
+// more pods may be disrupted for each budget, accounting for pods which still run on given rebooting
+// nodes, as those are about to be drained.
+func (k *Kontroller) disruptionBudgets(ctx context.Context, rebootingNodes []corev1.Node) (*disruptionBudgets, error) {
+	pdbs, err := k.kc.PolicyV1().PodDisruptionBudgets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing PodDisruptionBudgets: %w", err)
+	}
+
+	pods, err := k.kc.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+
+	result := &disruptionBudgets{
+		podsByNode: map[string][]corev1.Pod{},
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		result.podsByNode[pod.Spec.NodeName] = append(result.podsByNode[pod.Spec.NodeName], pod)
+	}
+
+	for i := range pdbs.Items {
+		budget, err := newDisruptionBudget(&pdbs.Items[i])
+		if err != nil {
+			return nil, err
+		}
+
+		result.budgets = append(result.budgets, budget)
+	}
+
+	for i := range rebootingNodes {
+		for _, budget := range result.budgets {
+			budget.remaining -= budget.matchingPods(result.podsByNode[rebootingNodes[i].Name])
+		}
+	}
+
+	return result, nil
+}
+
+// newDisruptionBudget creates disruption budget from a given PodDisruptionBudget.
+func newDisruptionBudget(pdb *policyv1.PodDisruptionBudget) (*disruptionBudget, error) {
+	selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("parsing selector of PodDisruptionBudget %s/%s: %w", pdb.Namespace, pdb.Name, err)
+	}
+
+	return &disruptionBudget{
+		name:      pdb.Name,
+		namespace: pdb.Namespace,
+		selector:  selector,
+		remaining: int(pdb.Status.DisruptionsAllowed),
+	}, nil
+}
+
+// matchingPods returns number of given pods covered by the budget.
+func (b *disruptionBudget) matchingPods(pods []corev1.Pod) int {
+	count := 0
+
+	for _, pod := range pods {
+		if pod.Namespace == b.namespace && b.selector.Matches(labels.Set(pod.Labels)) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// reserve checks if draining a given node would violate any of the budgets. If not, disruptions
+// caused by draining the node are subtracted from the budgets.
+//
+// If draining the node would violate a budget, its namespaced name is returned.
+func (b *disruptionBudgets) reserve(node *corev1.Node) (string, bool) {
+	pods := b.podsByNode[node.Name]
+
+	for _, budget := range b.budgets {
+		if matching := budget.matchingPods(pods); matching > 0 && matching > budget.remaining {
+			return budget.namespace + "/" + budget.name, false
+		}
+	}
+
+	for _, budget := range b.budgets {
+		budget.remaining -= budget.matchingPods(pods)
+	}
+
+	return "", true
+}
@@ -0,0 +1,78 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logging"
+)
+
+// detectStaleAgents reports nodes, which agent has not renewed its heartbeat lease for longer than configured
+// threshold, using a metric and a Warning event. Agent of such node is most likely not running, so the node would
+// silently never get updated. Nodes without heartbeat lease are reported once they exist for longer than the
+// threshold. Rebooting nodes are skipped, as their agents are expected to be down for a while.
+//
+// If stale agent threshold is not configured, nothing is done.
+func (k *Kontroller) detectStaleAgents(ctx context.Context, nodelist *corev1.NodeList) error {
+	if k.staleAgentThreshold == 0 {
+		return nil
+	}
+
+	leases, err := k.kc.CoordinationV1().Leases(k.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("listing heartbeat leases: %w", err)
+	}
+
+	lastHeartbeats := map[string]time.Time{}
+
+	for i := range leases.Items {
+		if renewTime := leases.Items[i].Spec.RenewTime; renewTime != nil {
+			lastHeartbeats[leases.Items[i].Name] = renewTime.Time
+		}
+	}
+
+	now := time.Now()
+	staleAgents := map[string]struct{}{}
+
+	// Reset to remove metrics of deleted nodes.
+	k.metrics.staleAgentNodes.Reset()
+
+	for _, node := range nodelist.Items {
+		lastHeartbeat, ok := lastHeartbeats[k8sutil.HeartbeatLeaseName(node.Name)]
+		if !ok {
+			lastHeartbeat = node.CreationTimestamp.Time
+		}
+
+		if now.Sub(lastHeartbeat) <= k.staleAgentThreshold ||
+			stillRebootingSelector.Matches(fields.Set(node.Annotations)) {
+			k.metrics.staleAgentNodes.WithLabelValues(node.Name).Set(0)
+
+			continue
+		}
+
+		staleAgents[node.Name] = struct{}{}
+
+		k.metrics.staleAgentNodes.WithLabelValues(node.Name).Set(1)
+
+		if _, reported := k.staleAgents[node.Name]; reported {
+			continue
+		}
+
+		klog.FromContext(ctx).Info("Agent of node did not report heartbeat within threshold",
+			logging.KeyNode, node.Name, "lastHeartbeat", lastHeartbeat, "threshold", k.staleAgentThreshold)
+
+		k.nodeEventf(node.Name, corev1.EventTypeWarning, EventReasonAgentStale,
+			"Agent did not report heartbeat for more than %v, node will not get updated", k.staleAgentThreshold)
+	}
+
+	k.staleAgents = staleAgents
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/rebootstate"
+)
+
+const (
+	// DefaultStatusConfigMap is a default name of ConfigMap where operator status is published.
+	DefaultStatusConfigMap = "flatcar-linux-update-operator-status"
+
+	// StatusConfigMapKey is a key in status ConfigMap under which operator status is stored.
+	StatusConfigMapKey = "status"
+)
+
+// Node phases reported in operator status.
+const (
+	NodePhaseIdle         = "idle"
+	NodePhaseRebootNeeded = "rebootNeeded"
+	NodePhaseBeforeReboot = "beforeReboot"
+	NodePhaseRebooting    = "rebooting"
+	NodePhaseAfterReboot  = "afterReboot"
+)
+
+// Status describes the state of the operator, published after each reconciliation cycle,
+// so external tooling can verify the operator is healthy and making progress.
+type Status struct {
+	Leader            string         `json:"leader"`
+	LastReconcileTime time.Time      `json:"lastReconcileTime"`
+	Nodes             map[string]int `json:"nodes"`
+	LastError         string         `json:"lastError,omitempty"`
+	LastErrorTime     *time.Time     `json:"lastErrorTime,omitempty"`
+}
+
+// nodePhases counts given nodes in each phase of the reboot process.
+func nodePhases(nodelist *corev1.NodeList) map[string]int {
+	phases := map[string]int{
+		NodePhaseIdle:         0,
+		NodePhaseRebootNeeded: 0,
+		NodePhaseBeforeReboot: 0,
+		NodePhaseRebooting:    0,
+		NodePhaseAfterReboot:  0,
+	}
+
+	for i := range nodelist.Items {
+		node := &nodelist.Items[i]
+		state := rebootstate.FromNode(node)
+		annotations := fields.Set(node.Annotations)
+
+		switch {
+		case rebootstate.IsTrue(state.AfterReboot):
+			phases[NodePhaseAfterReboot]++
+		case rebootstate.IsTrue(state.BeforeReboot):
+			phases[NodePhaseBeforeReboot]++
+		case stillRebootingSelector.Matches(annotations), emergencyRebootSelector.Matches(annotations):
+			phases[NodePhaseRebooting]++
+		case rebootableSelector.Matches(annotations):
+			phases[NodePhaseRebootNeeded]++
+		default:
+			phases[NodePhaseIdle]++
+		}
+	}
+
+	return phases
+}
+
+// reconciliationFailed records failure of a given reconciliation step.
+func (k *Kontroller) reconciliationFailed(step string, err error) {
+	k.metrics.reconciliationErrors.WithLabelValues(step).Inc()
+
+	now := time.Now().UTC()
+
+	k.status.LastError = fmt.Sprintf("%s: %v", step, err)
+	k.status.LastErrorTime = &now
+}
+
+// publishStatus stores current operator status in the status ConfigMap.
+func (k *Kontroller) publishStatus(ctx context.Context) {
+	if k.statusConfigMap == "" {
+		return
+	}
+
+	// Reconciliation only runs while leading.
+	k.status.Leader = k.resourceLock.Identity()
+	k.status.LastReconcileTime = time.Now().UTC()
+
+	value, err := json.Marshal(k.status)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "Failed encoding operator status")
+
+		return
+	}
+
+	configMaps := k.kc.CoreV1().ConfigMaps(k.namespace)
+
+	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		configMap, err := configMaps.Get(ctx, k.statusConfigMap, metav1.GetOptions{})
+
+		switch {
+		case apierrors.IsNotFound(err):
+			_, err = configMaps.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      k.statusConfigMap,
+					Namespace: k.namespace,
+				},
+				Data: map[string]string{StatusConfigMapKey: string(value)},
+			}, metav1.CreateOptions{})
+
+			return err
+		case err != nil:
+			return fmt.Errorf("getting ConfigMap %q: %w", k.statusConfigMap, err)
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+
+		configMap.Data[StatusConfigMapKey] = string(value)
+
+		_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+
+		return err
+	})
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "Failed publishing operator status")
+	}
+}
@@ -0,0 +1,72 @@
+package operator
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/logging"
+)
+
+// rebootingNode tracks a node which is in the process of rebooting.
+type rebootingNode struct {
+	since    time.Time
+	reported bool
+}
+
+// detectStuckReboots tracks how long given nodes are rebooting and reports nodes which are
+// rebooting for longer than configured threshold using a metric and a Warning event.
+//
+// If stuck reboot threshold is not configured, nothing is done.
+func (k *Kontroller) detectStuckReboots(ctx context.Context, nodelist *corev1.NodeList) {
+	if k.stuckRebootThreshold == 0 {
+		return
+	}
+
+	now := time.Now()
+	stillRebooting := map[string]struct{}{}
+
+	for _, node := range k8sutil.FilterNodesByAnnotation(nodelist.Items, stillRebootingSelector) {
+		stillRebooting[node.Name] = struct{}{}
+
+		tracked, ok := k.rebootingNodes[node.Name]
+		if !ok {
+			tracked = &rebootingNode{since: now}
+			k.rebootingNodes[node.Name] = tracked
+		}
+
+		rebootingFor := now.Sub(tracked.since)
+
+		if rebootingFor <= k.stuckRebootThreshold {
+			k.metrics.stuckRebootingNodes.WithLabelValues(node.Name).Set(0)
+
+			continue
+		}
+
+		k.metrics.stuckRebootingNodes.WithLabelValues(node.Name).Set(1)
+
+		if tracked.reported {
+			continue
+		}
+
+		tracked.reported = true
+
+		klog.FromContext(ctx).Info("Node has been rebooting for longer than threshold", logging.KeyNode, node.Name,
+			"rebootingFor", rebootingFor.Round(time.Second), "threshold", k.stuckRebootThreshold)
+
+		k.nodeEventf(node.Name, corev1.EventTypeWarning, EventReasonRebootStuck,
+			"Node has been rebooting for more than %v", k.stuckRebootThreshold)
+	}
+
+	for nodeName := range k.rebootingNodes {
+		if _, ok := stillRebooting[nodeName]; ok {
+			continue
+		}
+
+		delete(k.rebootingNodes, nodeName)
+		k.metrics.stuckRebootingNodes.DeleteLabelValues(nodeName)
+	}
+}
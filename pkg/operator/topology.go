@@ -0,0 +1,78 @@
+package operator
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// topologyCapacity tracks how many more nodes may reboot in parallel in each topology domain,
+// keyed by topology label key and then by label value.
+type topologyCapacity map[string]map[string]int
+
+// remainingTopologyCapacity calculates how many more nodes can be rebooted at a time in each domain
+// of configured topology keys based on a given list of nodes.
+//
+// Nodes without a given topology label are considered to be in a single domain with an empty name.
+func (k *Kontroller) remainingTopologyCapacity(ctx context.Context, nodelist *corev1.NodeList) topologyCapacity {
+	remainingCapacity := topologyCapacity{}
+
+	for key, maxRebootingNodes := range k.maxRebootingNodesPerTopology {
+		remainingCapacity[key] = map[string]int{}
+
+		for _, n := range capacityConsumingNodes(nodelist) {
+			domain := n.Labels[key]
+
+			if _, ok := remainingCapacity[key][domain]; !ok {
+				remainingCapacity[key][domain] = maxRebootingNodes
+			}
+
+			remainingCapacity[key][domain]--
+		}
+
+		for domain, capacity := range remainingCapacity[key] {
+			if capacity <= 0 {
+				klog.FromContext(ctx).Info("Found maximum of rebooting nodes in topology domain; waiting for completion",
+					"domain", key+"="+domain, "max", maxRebootingNodes)
+			}
+		}
+	}
+
+	return remainingCapacity
+}
+
+// exhaustedTopologyDomain returns topology domain given node belongs to, which has no remaining
+// rebooting capacity, formatted as key=value.
+//
+// If node can be rebooted without exceeding capacity of any domain, empty string is returned.
+func (k *Kontroller) exhaustedTopologyDomain(capacity topologyCapacity, node *corev1.Node) string {
+	for key, maxRebootingNodes := range k.maxRebootingNodesPerTopology {
+		domain := node.Labels[key]
+
+		// Domains without rebooting nodes have full capacity, which may also be 0.
+		remaining, ok := capacity[key][domain]
+		if !ok {
+			remaining = maxRebootingNodes
+		}
+
+		if remaining <= 0 {
+			return key + "=" + domain
+		}
+	}
+
+	return ""
+}
+
+// reserveTopologyCapacity decreases remaining capacity of all topology domains given node belongs to.
+func (k *Kontroller) reserveTopologyCapacity(capacity topologyCapacity, node *corev1.Node) {
+	for key, maxRebootingNodes := range k.maxRebootingNodesPerTopology {
+		domain := node.Labels[key]
+
+		if _, ok := capacity[key][domain]; !ok {
+			capacity[key][domain] = maxRebootingNodes
+		}
+
+		capacity[key][domain]--
+	}
+}
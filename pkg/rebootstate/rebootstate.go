@@ -0,0 +1,131 @@
+// Package rebootstate provides typed access to node labels and annotations used by the update-agent and
+// update-operator to coordinate reboots.
+package rebootstate
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/k8sutil"
+)
+
+// NodeRebootState represents reboot coordination state of a node.
+//
+// Nil fields represent values missing on the node when read from it and are left untouched when state is
+// applied to the node, so each component can change only the fields it owns.
+type NodeRebootState struct {
+	// Whether the update-agent requests a reboot. Stored both as annotation and label, so nodes can be
+	// selected by it.
+	RebootNeeded *bool
+	// Whether the update-agent drains and reboots the node.
+	RebootInProgress *bool
+	// Whether the update-operator permits the update-agent to reboot the node.
+	OkToReboot *bool
+	// Whether the update-operator waits for before-reboot checks. Label is removed when false.
+	BeforeReboot *bool
+	// Whether the update-operator waits for after-reboot checks. Label is removed when false.
+	AfterReboot *bool
+}
+
+// Bool returns a pointer to given value, for setting NodeRebootState fields.
+func Bool(value bool) *bool {
+	return &value
+}
+
+// IsTrue returns true if given NodeRebootState field is set to true.
+func IsTrue(value *bool) bool {
+	return value != nil && *value
+}
+
+// FromNode reads reboot state from labels and annotations of given node. Values other than "true" are
+// treated as false.
+func FromNode(node *corev1.Node) NodeRebootState {
+	return NodeRebootState{
+		RebootNeeded:     parse(node.Annotations, constants.AnnotationRebootNeeded),
+		RebootInProgress: parse(node.Annotations, constants.AnnotationRebootInProgress),
+		OkToReboot:       parse(node.Annotations, constants.AnnotationOkToReboot),
+		BeforeReboot:     parse(node.Labels, constants.LabelBeforeReboot),
+		AfterReboot:      parse(node.Labels, constants.LabelAfterReboot),
+	}
+}
+
+// ApplyTo writes non-nil fields of the state to labels and annotations of given node.
+func (s NodeRebootState) ApplyTo(node *corev1.Node) {
+	patch := s.Patch()
+
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+
+	if node.Labels == nil {
+		node.Labels = map[string]string{}
+	}
+
+	for _, key := range patch.RemoveLabels {
+		delete(node.Labels, key)
+	}
+
+	for key, value := range patch.Annotations {
+		node.Annotations[key] = value
+	}
+
+	for key, value := range patch.Labels {
+		node.Labels[key] = value
+	}
+}
+
+// Patch returns node metadata patch writing non-nil fields of the state.
+func (s NodeRebootState) Patch() k8sutil.NodeMetadataPatch {
+	patch := k8sutil.NodeMetadataPatch{
+		Annotations: map[string]string{},
+		Labels:      map[string]string{},
+	}
+
+	if s.RebootNeeded != nil {
+		patch.Annotations[constants.AnnotationRebootNeeded] = format(*s.RebootNeeded)
+		patch.Labels[constants.LabelRebootNeeded] = format(*s.RebootNeeded)
+	}
+
+	if s.RebootInProgress != nil {
+		patch.Annotations[constants.AnnotationRebootInProgress] = format(*s.RebootInProgress)
+	}
+
+	if s.OkToReboot != nil {
+		patch.Annotations[constants.AnnotationOkToReboot] = format(*s.OkToReboot)
+	}
+
+	for _, label := range []struct {
+		key   string
+		value *bool
+	}{
+		{key: constants.LabelBeforeReboot, value: s.BeforeReboot},
+		{key: constants.LabelAfterReboot, value: s.AfterReboot},
+	} {
+		switch {
+		case label.value == nil:
+		case *label.value:
+			patch.Labels[label.key] = constants.True
+		default:
+			patch.RemoveLabels = append(patch.RemoveLabels, label.key)
+		}
+	}
+
+	return patch
+}
+
+func parse(values map[string]string, key string) *bool {
+	value, ok := values[key]
+	if !ok {
+		return nil
+	}
+
+	return Bool(value == constants.True)
+}
+
+func format(value bool) string {
+	if value {
+		return constants.True
+	}
+
+	return constants.False
+}
@@ -0,0 +1,133 @@
+package rebootstate_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/constants"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/rebootstate"
+)
+
+//nolint:funlen // Just subtests.
+func Test_Node_reboot_state(t *testing.T) {
+	t.Parallel()
+
+	t.Run("is_read_from_node_labels_and_annotations", func(t *testing.T) {
+		t.Parallel()
+
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					constants.AnnotationRebootNeeded:     constants.True,
+					constants.AnnotationRebootInProgress: constants.False,
+					constants.AnnotationOkToReboot:       "foo",
+				},
+				Labels: map[string]string{
+					constants.LabelBeforeReboot: constants.True,
+				},
+			},
+		}
+
+		expected := rebootstate.NodeRebootState{
+			RebootNeeded:     rebootstate.Bool(true),
+			RebootInProgress: rebootstate.Bool(false),
+			OkToReboot:       rebootstate.Bool(false),
+			BeforeReboot:     rebootstate.Bool(true),
+		}
+
+		if diff := cmp.Diff(expected, rebootstate.FromNode(node)); diff != "" {
+			t.Fatalf("Unexpected reboot state: %s", diff)
+		}
+	})
+
+	t.Run("is_read_from_node_without_labels_and_annotations", func(t *testing.T) {
+		t.Parallel()
+
+		state := rebootstate.FromNode(&corev1.Node{})
+
+		if diff := cmp.Diff(rebootstate.NodeRebootState{}, state); diff != "" {
+			t.Fatalf("Unexpected reboot state: %s", diff)
+		}
+
+		if rebootstate.IsTrue(state.RebootNeeded) {
+			t.Fatalf("Expected missing value not to be true")
+		}
+	})
+
+	t.Run("is_applied_to_node_leaving_unset_fields_untouched", func(t *testing.T) {
+		t.Parallel()
+
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					constants.AnnotationOkToReboot: constants.True,
+					"foo":                          "bar",
+				},
+				Labels: map[string]string{
+					constants.LabelAfterReboot: constants.True,
+				},
+			},
+		}
+
+		rebootstate.NodeRebootState{
+			RebootNeeded:     rebootstate.Bool(true),
+			RebootInProgress: rebootstate.Bool(false),
+			BeforeReboot:     rebootstate.Bool(true),
+			AfterReboot:      rebootstate.Bool(false),
+		}.ApplyTo(node)
+
+		expectedAnnotations := map[string]string{
+			constants.AnnotationRebootNeeded:     constants.True,
+			constants.AnnotationRebootInProgress: constants.False,
+			constants.AnnotationOkToReboot:       constants.True,
+			"foo":                                "bar",
+		}
+
+		if diff := cmp.Diff(expectedAnnotations, node.Annotations); diff != "" {
+			t.Errorf("Unexpected annotations: %s", diff)
+		}
+
+		expectedLabels := map[string]string{
+			constants.LabelRebootNeeded: constants.True,
+			constants.LabelBeforeReboot: constants.True,
+		}
+
+		if diff := cmp.Diff(expectedLabels, node.Labels); diff != "" {
+			t.Errorf("Unexpected labels: %s", diff)
+		}
+	})
+
+	t.Run("is_applied_to_node_without_labels_and_annotations", func(t *testing.T) {
+		t.Parallel()
+
+		node := &corev1.Node{}
+
+		rebootstate.NodeRebootState{OkToReboot: rebootstate.Bool(false)}.ApplyTo(node)
+
+		if v := node.Annotations[constants.AnnotationOkToReboot]; v != constants.False {
+			t.Fatalf("Expected ok to reboot annotation to be %q, got %q", constants.False, v)
+		}
+	})
+
+	t.Run("converts_to_node_metadata_patch", func(t *testing.T) {
+		t.Parallel()
+
+		patch := rebootstate.NodeRebootState{
+			OkToReboot:  rebootstate.Bool(true),
+			AfterReboot: rebootstate.Bool(false),
+		}.Patch()
+
+		expectedAnnotations := map[string]string{constants.AnnotationOkToReboot: constants.True}
+
+		if diff := cmp.Diff(expectedAnnotations, patch.Annotations); diff != "" {
+			t.Errorf("Unexpected annotations: %s", diff)
+		}
+
+		if diff := cmp.Diff([]string{constants.LabelAfterReboot}, patch.RemoveLabels); diff != "" {
+			t.Errorf("Unexpected removed labels: %s", diff)
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package standalone
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"k8s.io/klog/v2"
+)
+
+// lockFileName is a name of the file in semaphore directory used to serialize access to the semaphore.
+const lockFileName = ".lock"
+
+// Semaphore limits number of machines rebooting at the same time. Holder keeps its slot across the reboot
+// and releases it once it comes back up.
+type Semaphore interface {
+	// Acquire takes a slot for a given holder. It returns false when no slot is available. Acquiring
+	// a slot already held by the holder succeeds.
+	Acquire(holder string) (bool, error)
+	// Release frees a slot held by a given holder. Releasing a slot which is not held succeeds.
+	Release(holder string) error
+}
+
+// FileSemaphore is a Semaphore stored in a directory shared by all machines, e.g. using NFS. Each
+// holder is represented by a file named after the holder.
+type FileSemaphore struct {
+	directory  string
+	maxHolders int
+}
+
+// NewFileSemaphore creates semaphore stored in a given directory allowing given number of holders.
+func NewFileSemaphore(directory string, maxHolders int) (*FileSemaphore, error) {
+	if directory == "" {
+		return nil, fmt.Errorf("directory can't be empty")
+	}
+
+	if maxHolders < 1 {
+		return nil, fmt.Errorf("maximum number of holders must be at least 1, got %d", maxHolders)
+	}
+
+	if err := os.MkdirAll(directory, 0o755); err != nil { //nolint:gomnd // Standard directory permissions.
+		return nil, fmt.Errorf("creating directory %q: %w", directory, err)
+	}
+
+	return &FileSemaphore{
+		directory:  directory,
+		maxHolders: maxHolders,
+	}, nil
+}
+
+// Acquire implements Semaphore interface.
+func (s *FileSemaphore) Acquire(holder string) (bool, error) {
+	if err := validHolder(holder); err != nil {
+		return false, err
+	}
+
+	acquired := false
+
+	err := s.withLock(func() error {
+		holders, err := s.holders()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := holders[holder]; ok {
+			acquired = true
+
+			return nil
+		}
+
+		if len(holders) >= s.maxHolders {
+			klog.V(4).Infof("Semaphore is full, held by %v", holderNames(holders))
+
+			return nil
+		}
+
+		if err := os.WriteFile(filepath.Join(s.directory, holder), nil, 0o644); err != nil { //nolint:gosec,gomnd
+			return fmt.Errorf("creating holder file: %w", err)
+		}
+
+		acquired = true
+
+		return nil
+	})
+
+	return acquired, err
+}
+
+// Release implements Semaphore interface.
+func (s *FileSemaphore) Release(holder string) error {
+	if err := validHolder(holder); err != nil {
+		return err
+	}
+
+	return s.withLock(func() error {
+		if err := os.Remove(filepath.Join(s.directory, holder)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing holder file: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// withLock runs given function while holding exclusive lock on the semaphore directory.
+func (s *FileSemaphore) withLock(f func() error) error {
+	path := filepath.Join(s.directory, lockFileName)
+
+	lockFile, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644) //nolint:gomnd // Standard file permissions.
+	if err != nil {
+		return fmt.Errorf("opening lock file %q: %w", path, err)
+	}
+
+	defer func() {
+		if err := lockFile.Close(); err != nil {
+			klog.Warningf("Failed closing lock file %q: %v", path, err)
+		}
+	}()
+
+	if err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("locking %q: %w", path, err)
+	}
+
+	return f()
+}
+
+// holders returns set of current semaphore holders.
+func (s *FileSemaphore) holders() (map[string]struct{}, error) {
+	entries, err := os.ReadDir(s.directory)
+	if err != nil {
+		return nil, fmt.Errorf("reading directory %q: %w", s.directory, err)
+	}
+
+	holders := map[string]struct{}{}
+
+	for _, entry := range entries {
+		if entry.Name() == lockFileName || !entry.Type().IsRegular() {
+			continue
+		}
+
+		holders[entry.Name()] = struct{}{}
+	}
+
+	return holders, nil
+}
+
+func holderNames(holders map[string]struct{}) []string {
+	names := []string{}
+
+	for name := range holders {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// validHolder checks if given holder name can be used as a file name.
+func validHolder(holder string) error {
+	if holder == "" || holder == lockFileName || holder == "." || holder == ".." || strings.ContainsRune(holder, '/') {
+		return fmt.Errorf("invalid holder name %q", holder)
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+// Package standalone provides an agent coordinating reboots of machines which are not Kubernetes nodes.
+// Instead of node annotations and the operator, machines coordinate using a shared Semaphore.
+package standalone
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/agent"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/updateengine"
+)
+
+const defaultPollInterval = 10 * time.Second
+
+// Config represents configurable options for the standalone agent.
+type Config struct {
+	// Name identifying the machine in the semaphore, e.g. the hostname.
+	MachineName    string
+	Semaphore      Semaphore
+	StatusReceiver agent.StatusReceiver
+	Rebooter       agent.Rebooter
+	// How often acquiring the semaphore is retried. Defaults to 10 seconds.
+	PollInterval time.Duration
+}
+
+// Agent reboots the machine when update source indicates that a reboot is needed and a slot
+// in the semaphore can be acquired.
+type Agent struct {
+	machineName  string
+	semaphore    Semaphore
+	ue           agent.StatusReceiver
+	lc           agent.Rebooter
+	pollInterval time.Duration
+}
+
+// New returns initialized standalone agent.
+func New(config *Config) (*Agent, error) {
+	if config.MachineName == "" {
+		return nil, fmt.Errorf("machine name can't be empty")
+	}
+
+	if config.Semaphore == nil {
+		return nil, fmt.Errorf("no semaphore configured")
+	}
+
+	if config.StatusReceiver == nil {
+		return nil, fmt.Errorf("no status receiver configured")
+	}
+
+	if config.Rebooter == nil {
+		return nil, fmt.Errorf("no rebooter given")
+	}
+
+	pollInterval := config.PollInterval
+	if pollInterval == 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	return &Agent{
+		machineName:  config.MachineName,
+		semaphore:    config.Semaphore,
+		ue:           config.StatusReceiver,
+		lc:           config.Rebooter,
+		pollInterval: pollInterval,
+	}, nil
+}
+
+// Run releases the semaphore slot possibly held since before the reboot, then waits for the update source
+// to indicate that a reboot is needed, acquires a slot and reboots the machine. It returns when given
+// context is cancelled or after requesting the reboot.
+func (a *Agent) Run(ctx context.Context) error {
+	klog.Infof("Releasing semaphore held by %q, if any", a.machineName)
+
+	//nolint:staticcheck // New equivalent is buggy: https://github.com/kubernetes/kubernetes/issues/119533.
+	err := wait.PollImmediateUntilWithContext(ctx, a.pollInterval, func(ctx context.Context) (bool, error) {
+		if err := a.semaphore.Release(a.machineName); err != nil {
+			klog.Errorf("Failed releasing semaphore: %v", err)
+
+			return false, nil
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("releasing semaphore: %w", err)
+	}
+
+	if !a.waitForRebootNeeded(ctx) {
+		return nil
+	}
+
+	klog.Info("Reboot needed, acquiring semaphore")
+
+	//nolint:staticcheck // New equivalent is buggy: https://github.com/kubernetes/kubernetes/issues/119533.
+	err = wait.PollImmediateUntilWithContext(ctx, a.pollInterval, func(ctx context.Context) (bool, error) {
+		acquired, err := a.semaphore.Acquire(a.machineName)
+		if err != nil {
+			klog.Errorf("Failed acquiring semaphore: %v", err)
+
+			return false, nil
+		}
+
+		if !acquired {
+			klog.Infof("Semaphore is full, retrying in %v", a.pollInterval)
+		}
+
+		return acquired, nil
+	})
+	if err != nil {
+		return fmt.Errorf("acquiring semaphore: %w", err)
+	}
+
+	klog.Info("Semaphore acquired, rebooting")
+
+	if err := a.lc.Reboot(false); err != nil {
+		return fmt.Errorf("rebooting: %w", err)
+	}
+
+	return nil
+}
+
+// waitForRebootNeeded blocks until update source indicates that a reboot is needed. It returns false
+// when given context gets cancelled before.
+func (a *Agent) waitForRebootNeeded(ctx context.Context) bool {
+	klog.Info("Beginning to watch update status")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	statuses := make(chan updateengine.Status, 1)
+
+	go a.ue.ReceiveStatuses(statuses, ctx.Done())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case status := <-statuses:
+			klog.V(4).Infof("Received status: %s", status.String())
+
+			if status.CurrentOperation == updateengine.UpdateStatusUpdatedNeedReboot {
+				return true
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package standalone_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/standalone"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/updateengine"
+)
+
+const testTimeout = 5 * time.Second
+
+func Test_File_semaphore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("limits_number_of_holders", func(t *testing.T) {
+		t.Parallel()
+
+		semaphore := testSemaphore(t, 1)
+
+		assertAcquired(t, semaphore, "foo", true)
+		assertAcquired(t, semaphore, "bar", false)
+	})
+
+	t.Run("can_be_acquired_again_by_the_same_holder", func(t *testing.T) {
+		t.Parallel()
+
+		semaphore := testSemaphore(t, 1)
+
+		assertAcquired(t, semaphore, "foo", true)
+		assertAcquired(t, semaphore, "foo", true)
+	})
+
+	t.Run("can_be_acquired_by_other_holder_after_release", func(t *testing.T) {
+		t.Parallel()
+
+		semaphore := testSemaphore(t, 1)
+
+		assertAcquired(t, semaphore, "foo", true)
+
+		if err := semaphore.Release("foo"); err != nil {
+			t.Fatalf("Unexpected error releasing: %v", err)
+		}
+
+		assertAcquired(t, semaphore, "bar", true)
+	})
+
+	t.Run("can_be_released_when_not_held", func(t *testing.T) {
+		t.Parallel()
+
+		if err := testSemaphore(t, 1).Release("foo"); err != nil {
+			t.Fatalf("Unexpected error releasing: %v", err)
+		}
+	})
+
+	t.Run("rejects_holder_names_which_are_not_valid_file_names", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := testSemaphore(t, 1).Acquire("../foo"); err == nil {
+			t.Fatalf("Expected error acquiring with invalid holder name")
+		}
+	})
+}
+
+//nolint:funlen // Just many subtests.
+func Test_Running_standalone_agent(t *testing.T) {
+	t.Parallel()
+
+	t.Run("releases_semaphore_held_since_before_reboot", func(t *testing.T) {
+		t.Parallel()
+
+		semaphore := testSemaphore(t, 1)
+
+		assertAcquired(t, semaphore, "foo", true)
+
+		config := testConfig(semaphore, make(chan updateengine.Status), &mockRebooter{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// Release is attempted at least once, even with context already cancelled.
+		if err := runStandaloneAgent(ctx, t, config); err != nil {
+			t.Fatalf("Unexpected error running agent: %v", err)
+		}
+
+		assertAcquired(t, semaphore, "bar", true)
+	})
+
+	t.Run("reboots_when_reboot_is_needed_and_semaphore_is_acquired", func(t *testing.T) {
+		t.Parallel()
+
+		semaphore := testSemaphore(t, 1)
+		statuses := make(chan updateengine.Status, 1)
+		rebooter := &mockRebooter{}
+
+		statuses <- updateengine.Status{CurrentOperation: updateengine.UpdateStatusUpdatedNeedReboot}
+
+		ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+		t.Cleanup(cancel)
+
+		if err := runStandaloneAgent(ctx, t, testConfig(semaphore, statuses, rebooter)); err != nil {
+			t.Fatalf("Unexpected error running agent: %v", err)
+		}
+
+		if !rebooter.rebooted {
+			t.Fatalf("Expected machine to be rebooted")
+		}
+
+		assertAcquired(t, semaphore, "bar", false)
+	})
+
+	t.Run("does_not_reboot_while_semaphore_is_held_by_other_machine", func(t *testing.T) {
+		t.Parallel()
+
+		semaphore := testSemaphore(t, 1)
+		statuses := make(chan updateengine.Status, 1)
+		rebooter := &mockRebooter{}
+
+		assertAcquired(t, semaphore, "bar", true)
+
+		statuses <- updateengine.Status{CurrentOperation: updateengine.UpdateStatusUpdatedNeedReboot}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		t.Cleanup(cancel)
+
+		if err := runStandaloneAgent(ctx, t, testConfig(semaphore, statuses, rebooter)); err == nil {
+			t.Fatalf("Expected error acquiring semaphore")
+		}
+
+		if rebooter.rebooted {
+			t.Fatalf("Expected machine not to be rebooted")
+		}
+	})
+}
+
+func runStandaloneAgent(ctx context.Context, t *testing.T, config *standalone.Config) error {
+	t.Helper()
+
+	standaloneAgent, err := standalone.New(config)
+	if err != nil {
+		t.Fatalf("Unexpected error creating agent: %v", err)
+	}
+
+	return standaloneAgent.Run(ctx)
+}
+
+func testConfig(
+	semaphore standalone.Semaphore, statuses chan updateengine.Status, rebooter *mockRebooter,
+) *standalone.Config {
+	return &standalone.Config{
+		MachineName:    "foo",
+		Semaphore:      semaphore,
+		StatusReceiver: &mockStatusReceiver{statuses: statuses},
+		Rebooter:       rebooter,
+		PollInterval:   10 * time.Millisecond,
+	}
+}
+
+func testSemaphore(t *testing.T, maxHolders int) *standalone.FileSemaphore {
+	t.Helper()
+
+	semaphore, err := standalone.NewFileSemaphore(filepath.Join(t.TempDir(), "semaphore"), maxHolders)
+	if err != nil {
+		t.Fatalf("Unexpected error creating semaphore: %v", err)
+	}
+
+	return semaphore
+}
+
+func assertAcquired(t *testing.T, semaphore standalone.Semaphore, holder string, expected bool) {
+	t.Helper()
+
+	acquired, err := semaphore.Acquire(holder)
+	if err != nil {
+		t.Fatalf("Unexpected error acquiring: %v", err)
+	}
+
+	if acquired != expected {
+		t.Fatalf("Expected acquired to be %t for holder %q, got %t", expected, holder, acquired)
+	}
+}
+
+type mockStatusReceiver struct {
+	statuses chan updateengine.Status
+}
+
+func (m *mockStatusReceiver) ReceiveStatuses(rcvr chan<- updateengine.Status, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case status := <-m.statuses:
+			select {
+			case rcvr <- status:
+			case <-stop:
+				return
+			}
+		}
+	}
+}
+
+type mockRebooter struct {
+	rebooted bool
+}
+
+func (m *mockRebooter) Reboot(bool) error {
+	m.rebooted = true
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package sysupdate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/updateengine"
+)
+
+const (
+	// DefaultCommand is a default command used to execute systemd-sysupdate.
+	DefaultCommand = "systemd-sysupdate"
+
+	// PendingVerb is a systemd-sysupdate verb checking whether a newer version of the OS is installed
+	// than the one currently booted.
+	PendingVerb = "pending"
+
+	// ExitCodeNoPendingUpdate is an exit code returned by systemd-sysupdate pending verb when no newer
+	// version is installed.
+	ExitCodeNoPendingUpdate = 77
+
+	defaultPollInterval = time.Minute
+)
+
+// Config represents configurable options for the client.
+type Config struct {
+	// Command used to execute systemd-sysupdate, e.g. 'chroot /host systemd-sysupdate'.
+	// Defaults to DefaultCommand.
+	Command []string
+	// How often systemd-sysupdate state is checked. Defaults to 1 minute.
+	PollInterval time.Duration
+}
+
+// Client allows reading systemd-sysupdate state.
+type Client interface {
+	// ReceiveStatuses periodically checks systemd-sysupdate state and converts it to Statuses
+	// emitted into a given channel. It returns when stop channel gets closed.
+	ReceiveStatuses(rcvr chan<- updateengine.Status, stop <-chan struct{})
+}
+
+type client struct {
+	command      []string
+	pollInterval time.Duration
+}
+
+// New creates new instance of Client.
+func New(config *Config) (Client, error) {
+	command := config.Command
+	if len(command) == 0 {
+		command = []string{DefaultCommand}
+	}
+
+	if command[0] == "" {
+		return nil, fmt.Errorf("command can't be empty")
+	}
+
+	pollInterval := config.PollInterval
+	if pollInterval == 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	return &client{
+		command:      command,
+		pollInterval: pollInterval,
+	}, nil
+}
+
+// ReceiveStatuses sends current systemd-sysupdate state as Status on the rcvr channel every poll interval,
+// until the stop channel is closed. When state can't be read, error is logged and no status is sent.
+//
+// Status has UpdateStatusUpdatedNeedReboot operation when newer version is installed than the one currently
+// booted and UpdateStatusIdle operation otherwise.
+func (c *client) ReceiveStatuses(rcvr chan<- updateengine.Status, stop <-chan struct{}) {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		status, err := c.getStatus()
+		if err != nil {
+			klog.Errorf("Failed getting systemd-sysupdate status: %v", err)
+		} else {
+			select {
+			case rcvr <- status:
+			case <-stop:
+				return
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// getStatus checks whether newer version of the OS is installed than the one currently booted.
+func (c *client) getStatus() (updateengine.Status, error) {
+	args := append(append([]string{}, c.command[1:]...), PendingVerb)
+
+	output := &bytes.Buffer{}
+
+	cmd := exec.Command(c.command[0], args...) //nolint:gosec // Command is configured by the administrator.
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	status := updateengine.Status{
+		LastCheckedTime:  time.Now().Unix(),
+		CurrentOperation: updateengine.UpdateStatusUpdatedNeedReboot,
+	}
+
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+
+	switch {
+	case err == nil:
+		return status, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == ExitCodeNoPendingUpdate:
+		status.CurrentOperation = updateengine.UpdateStatusIdle
+
+		return status, nil
+	default:
+		return updateengine.Status{}, fmt.Errorf("running %q: %w, output: %s",
+			strings.Join(cmd.Args, " "), err, strings.TrimSpace(output.String()))
+	}
+}
@@ -0,0 +1,132 @@
+package sysupdate_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/sysupdate"
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/updateengine"
+)
+
+const testPollInterval = 100 * time.Millisecond
+
+//nolint:funlen // Just many sub-tests.
+func Test_Receiving_status(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reports_reboot_is_needed_when_newer_version_is_pending", func(t *testing.T) {
+		t.Parallel()
+
+		// Verify that pending verb is passed to configured command.
+		statusCh := receiveStatuses(t, `test "$1" = pending`)
+
+		if status := nextStatus(t, statusCh); status.CurrentOperation != updateengine.UpdateStatusUpdatedNeedReboot {
+			t.Fatalf("Expected operation %q, got %q", updateengine.UpdateStatusUpdatedNeedReboot, status.CurrentOperation)
+		}
+	})
+
+	t.Run("reports_idle_status_when_no_newer_version_is_pending", func(t *testing.T) {
+		t.Parallel()
+
+		statusCh := receiveStatuses(t, "exit 77")
+
+		status := nextStatus(t, statusCh)
+
+		if status.CurrentOperation != updateengine.UpdateStatusIdle {
+			t.Fatalf("Expected operation %q, got %q", updateengine.UpdateStatusIdle, status.CurrentOperation)
+		}
+
+		if status.LastCheckedTime == 0 {
+			t.Fatalf("Expected last checked time to be set")
+		}
+	})
+
+	t.Run("periodically_reports_current_status", func(t *testing.T) {
+		t.Parallel()
+
+		exitCodeFile := filepath.Join(t.TempDir(), "exit-code")
+
+		writeExitCode(t, exitCodeFile, "77")
+
+		statusCh := receiveStatuses(t, "exit $(cat "+exitCodeFile+")")
+
+		if status := nextStatus(t, statusCh); status.CurrentOperation != updateengine.UpdateStatusIdle {
+			t.Fatalf("Expected operation %q, got %q", updateengine.UpdateStatusIdle, status.CurrentOperation)
+		}
+
+		writeExitCode(t, exitCodeFile, "0")
+
+		for {
+			if nextStatus(t, statusCh).CurrentOperation == updateengine.UpdateStatusUpdatedNeedReboot {
+				return
+			}
+		}
+	})
+
+	t.Run("sends_no_status_when_checking_status_fails", func(t *testing.T) {
+		t.Parallel()
+
+		statusCh := receiveStatuses(t, "exit 1")
+
+		select {
+		case status := <-statusCh:
+			t.Fatalf("Expected no status, got %v", status)
+		case <-time.After(3 * testPollInterval):
+		}
+	})
+}
+
+func Test_Creating_client_fails_when_configured_command_is_empty(t *testing.T) {
+	t.Parallel()
+
+	if _, err := sysupdate.New(&sysupdate.Config{Command: []string{""}}); err == nil {
+		t.Fatalf("Expected error creating client")
+	}
+}
+
+func receiveStatuses(t *testing.T, script string) <-chan updateengine.Status {
+	t.Helper()
+
+	client, err := sysupdate.New(&sysupdate.Config{
+		Command:      []string{"/bin/sh", "-c", script, "sh"},
+		PollInterval: testPollInterval,
+	})
+	if err != nil {
+		t.Fatalf("Got unexpected error while creating client: %v", err)
+	}
+
+	stop := make(chan struct{})
+
+	t.Cleanup(func() {
+		close(stop)
+	})
+
+	statusCh := make(chan updateengine.Status, 1)
+
+	go client.ReceiveStatuses(statusCh, stop)
+
+	return statusCh
+}
+
+func nextStatus(t *testing.T, statusCh <-chan updateengine.Status) updateengine.Status {
+	t.Helper()
+
+	select {
+	case status := <-statusCh:
+		return status
+	case <-time.After(5 * time.Second):
+		t.Fatal("Failed getting status within expected timeframe")
+	}
+
+	return updateengine.Status{}
+}
+
+func writeExitCode(t *testing.T, path, exitCode string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(exitCode), 0o600); err != nil {
+		t.Fatalf("Failed writing exit code file: %v", err)
+	}
+}
@@ -0,0 +1,3 @@
+// Package sysupdate provides an interface for reading update state from
+// systemd-sysupdate on the host.
+package sysupdate
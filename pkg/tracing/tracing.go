@@ -0,0 +1,62 @@
+// Package tracing configures OpenTelemetry tracing of FLUO components.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+// NewProvider returns tracer provider exporting spans in batches using OTLP over HTTP to given endpoint URL,
+// e.g. "http://otel-collector:4318". Endpoints with "http" scheme are used without TLS. Other exporter options
+// can be configured using standard OTEL_EXPORTER_OTLP_* environment variables.
+//
+// Spans are attributed to service with given name and version. Returned provider must be shut down to flush
+// pending spans.
+func NewProvider(ctx context.Context, endpoint, serviceName, serviceVersion string) (*sdktrace.TracerProvider, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parsing endpoint URL %q: %w", endpoint, err)
+	}
+
+	switch endpointURL.Scheme {
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("unsupported endpoint URL scheme %q, expected either %q or %q",
+			endpointURL.Scheme, "http", "https")
+	}
+
+	if endpointURL.Host == "" {
+		return nil, fmt.Errorf("endpoint URL %q has no host", endpoint)
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpointURL.Host)}
+
+	if endpointURL.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	if endpointURL.Path != "" && endpointURL.Path != "/" {
+		opts = append(opts, otlptracehttp.WithURLPath(endpointURL.Path))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceNameKey.String(serviceName),
+		semconv.ServiceVersionKey.String(serviceVersion),
+	)
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	), nil
+}
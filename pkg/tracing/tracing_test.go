@@ -0,0 +1,43 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/flatcar/flatcar-linux-update-operator/pkg/tracing"
+)
+
+func Test_Creating_tracer_provider(t *testing.T) {
+	t.Parallel()
+
+	t.Run("succeeds_with_valid_endpoint_URL", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+
+		tracerProvider, err := tracing.NewProvider(ctx, "http://localhost:4318", "test", "1.2.3")
+		if err != nil {
+			t.Fatalf("Unexpected error creating tracer provider: %v", err)
+		}
+
+		if err := tracerProvider.Shutdown(ctx); err != nil {
+			t.Fatalf("Unexpected error shutting down tracer provider: %v", err)
+		}
+	})
+
+	for name, endpoint := range map[string]string{
+		"endpoint_URL_has_unsupported_scheme": "grpc://localhost:4317",
+		"endpoint_URL_has_no_host":            "http://",
+		"endpoint_URL_is_invalid":             "http://local host:4318",
+	} {
+		endpoint := endpoint
+
+		t.Run("fails_when_"+name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := tracing.NewProvider(context.Background(), endpoint, "test", "1.2.3"); err == nil {
+				t.Fatalf("Expected error creating tracer provider with endpoint %q", endpoint)
+			}
+		})
+	}
+}
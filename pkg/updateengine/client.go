@@ -15,9 +15,13 @@
 package updateengine
 
 import (
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	godbus "github.com/godbus/dbus/v5"
+	"k8s.io/klog/v2"
 
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/dbus"
 )
@@ -33,8 +37,39 @@ const (
 	DBusSignalNameStatusUpdate = "StatusUpdate"
 	// DBusMethodNameGetStatus is a name of the method to get current update_engine status.
 	DBusMethodNameGetStatus = "GetStatus"
+	// DBusMethodNameAttemptUpdate is a name of the method to trigger an update check by update_engine.
+	DBusMethodNameAttemptUpdate = "AttemptUpdate"
+	// DBusMethodNameGetLastAttemptError is a name of the method to get error code of the last failed
+	// update attempt.
+	DBusMethodNameGetLastAttemptError = "GetLastAttemptError"
+	// DBusMethodNameGetChannel is a name of the method to get the update channel used by update_engine.
+	DBusMethodNameGetChannel = "GetChannel"
+	// DBusMethodNameSetChannel is a name of the method to change the update channel used by update_engine.
+	DBusMethodNameSetChannel = "SetChannel"
+	// DBusMethodNameResetStatus is a name of the method to reset update_engine status back to idle.
+	DBusMethodNameResetStatus = "ResetStatus"
+	// DBusMethodNameAttemptRollback is a name of the method to roll back to the previously booted partition.
+	DBusMethodNameAttemptRollback = "AttemptRollback"
 
 	signalBuffer = 32 // TODO(bp): What is a reasonable value here?
+
+	defaultReconnectInterval    = time.Second
+	defaultMaxReconnectInterval = time.Minute
+)
+
+var (
+	// ErrNotConnected is wrapped by errors caused by closed D-Bus connection, see dbus.ErrNotConnected.
+	ErrNotConnected = dbus.ErrNotConnected
+	// ErrServiceUnavailable is wrapped by errors caused by update_engine not running or not replying,
+	// see dbus.ErrServiceUnavailable.
+	ErrServiceUnavailable = dbus.ErrServiceUnavailable
+	// ErrPermissionDenied is wrapped by errors caused by D-Bus policy denying access to update_engine,
+	// see dbus.ErrPermissionDenied.
+	ErrPermissionDenied = dbus.ErrPermissionDenied
+
+	// ErrDisconnected is reported when D-Bus connection used for receiving statuses has been closed.
+	// It wraps ErrNotConnected.
+	ErrDisconnected = fmt.Errorf("%w: connection closed", ErrNotConnected)
 )
 
 // Client allows reading update_engine status using D-Bus.
@@ -48,6 +83,36 @@ type Client interface {
 	//
 	// Receive statuses call must be stopped before closing the connection.
 	Close() error
+
+	// Healthz returns an error when D-Bus connection used for receiving statuses has been closed
+	// and has not been re-established yet.
+	Healthz() error
+
+	// Reconnects returns how many times the D-Bus connection has been re-established after getting closed.
+	Reconnects() uint64
+
+	// AttemptUpdate asks update_engine to check for an update immediately. Resulting status changes
+	// are received using ReceiveStatuses.
+	AttemptUpdate() error
+
+	// GetLastAttemptError returns error code of the last update attempt as reported by update_engine,
+	// e.g. payload hash mismatch. Zero means the last attempt did not fail.
+	GetLastAttemptError() (int32, error)
+
+	// GetChannel returns the update channel, e.g. "stable", which update_engine uses for following
+	// update checks.
+	GetChannel() (string, error)
+
+	// SetChannel changes the update channel used by update_engine for following update checks.
+	SetChannel(channel string) error
+
+	// ResetStatus resets update_engine status back to idle, discarding the update pending a reboot.
+	// Resulting status change is received using ReceiveStatuses.
+	ResetStatus() error
+
+	// AttemptRollback asks update_engine to make the previously booted partition active again, so it is
+	// booted on the next reboot. Resulting status changes are received using ReceiveStatuses.
+	AttemptRollback() error
 }
 
 // DBusConnection is set of methods which client expects D-Bus connection to implement.
@@ -62,17 +127,99 @@ type caller interface {
 	Call(method string, flags godbus.Flags, args ...interface{}) *godbus.Call
 }
 
+// Config represents configurable options for the client.
+type Config struct {
+	Connector dbus.Connector
+	// Initial interval between attempts to reconnect to D-Bus when the connection gets closed, e.g. because
+	// dbus-daemon has been restarted. The interval is doubled after each failed attempt, up to
+	// MaxReconnectInterval. Defaults to 1 second.
+	ReconnectInterval time.Duration
+	// Maximum interval between attempts to reconnect to D-Bus. Defaults to 1 minute.
+	MaxReconnectInterval time.Duration
+	// Optional channel on which errors encountered while receiving statuses are sent, e.g. when D-Bus
+	// connection gets closed, reconnecting fails or a malformed status is received. Receiving statuses
+	// continues after errors. Errors are dropped when the channel is not ready to receive them.
+	Errors chan<- error
+	// When true, only statuses describing transitions between update operations are delivered, e.g. from
+	// downloading to verifying. Frequent progress updates and duplicate statuses are dropped.
+	OnlyTransitions bool
+	// When OnlyTransitions is set, statuses updating only the progress of the current operation are delivered
+	// at most once per given interval. Disabled by default.
+	ProgressSamplingInterval time.Duration
+	// When set, current status is polled from update_engine using GetStatus method with given interval,
+	// instead of subscribing to status signals, e.g. on hosts restricting D-Bus signals. Unchanged statuses
+	// are not delivered.
+	StatusPollInterval time.Duration
+	// Optional monitor recording health of the D-Bus connection and update_engine method calls.
+	Monitor *dbus.Monitor
+}
+
 type client struct {
-	conn   DBusConnection
-	object caller
-	ch     chan *godbus.Signal
+	connector            dbus.Connector
+	reconnectInterval    time.Duration
+	maxReconnectInterval time.Duration
+	errors               chan<- error
+
+	onlyTransitions          bool
+	progressSamplingInterval time.Duration
+	statusPollInterval       time.Duration
+	monitor                  *dbus.Monitor
+
+	connLock     sync.RWMutex
+	conn         DBusConnection
+	object       caller
+	ch           chan *godbus.Signal
+	disconnected bool
+	reconnects   uint64
 }
 
-// New creates new instance of Client and initializes it.
+// New creates new instance of Client using given connector and default options and initializes it.
 func New(connector dbus.Connector) (Client, error) {
+	return NewWithConfig(&Config{Connector: connector})
+}
+
+// NewWithConfig creates new instance of Client using given configuration and initializes it.
+func NewWithConfig(config *Config) (Client, error) {
+	reconnectInterval := config.ReconnectInterval
+	if reconnectInterval == 0 {
+		reconnectInterval = defaultReconnectInterval
+	}
+
+	maxReconnectInterval := config.MaxReconnectInterval
+	if maxReconnectInterval == 0 {
+		maxReconnectInterval = defaultMaxReconnectInterval
+	}
+
+	conn, ch, err := connect(config.Connector, config.StatusPollInterval == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client{
+		connector:            config.Connector,
+		reconnectInterval:    reconnectInterval,
+		maxReconnectInterval: maxReconnectInterval,
+		errors:               config.Errors,
+		ch:                   ch,
+		conn:                 conn,
+		object:               config.Monitor.InstrumentObject(conn.Object(DBusDestination, godbus.ObjectPath(DBusPath))),
+
+		onlyTransitions:          config.OnlyTransitions,
+		progressSamplingInterval: config.ProgressSamplingInterval,
+		statusPollInterval:       config.StatusPollInterval,
+		monitor:                  config.Monitor,
+	}, nil
+}
+
+// connect opens new D-Bus connection and if requested, subscribes to status signals from update_engine.
+func connect(connector dbus.Connector, subscribe bool) (DBusConnection, chan *godbus.Signal, error) {
 	conn, err := dbus.New(connector)
 	if err != nil {
-		return nil, fmt.Errorf("creating D-Bus client: %w", err)
+		return nil, nil, fmt.Errorf("creating D-Bus client: %w", err)
+	}
+
+	if !subscribe {
+		return conn, nil, nil
 	}
 
 	matchOptions := []godbus.MatchOption{
@@ -81,43 +228,215 @@ func New(connector dbus.Connector) (Client, error) {
 	}
 
 	if err := conn.AddMatchSignal(matchOptions...); err != nil {
-		return nil, fmt.Errorf("adding filter: %w", err)
+		return nil, nil, fmt.Errorf("adding filter: %w", dbus.ClassifyError(err))
 	}
 
 	ch := make(chan *godbus.Signal, signalBuffer)
 	conn.Signal(ch)
 
-	return &client{
-		ch:     ch,
-		conn:   conn,
-		object: conn.Object(DBusDestination, godbus.ObjectPath(DBusPath)),
-	}, nil
+	return conn, ch, nil
 }
 
 // ReceiveStatuses receives signal messages from dbus and sends them as Statues
 // on the rcvr channel, until the stop channel is closed. An attempt is made to
 // get the initial status and send it on the rcvr channel before receiving
 // starts.
+//
+// When D-Bus connection gets closed, the client is reported as unhealthy until the connection is
+// re-established. Current status is sent again after reconnecting, as signals might have been missed.
+//
+// When configured, statuses not describing transitions between update operations are filtered out and
+// statuses are polled instead of received using signals.
 func (c *client) ReceiveStatuses(rcvr chan<- Status, stop <-chan struct{}) {
-	// If there is an error getting the current status, ignore it and just
-	// move onto the main loop.
-	//
-	//nolint:errcheck // TODO: This will be fixed once we introduce error handling to receiving statuses.
-	st, _ := c.getStatus()
-	rcvr <- st
+	filter := &statusFilter{
+		onlyTransitions:          c.onlyTransitions,
+		progressSamplingInterval: c.progressSamplingInterval,
+	}
+
+	if c.statusPollInterval != 0 {
+		c.pollStatuses(rcvr, filter, stop)
+
+		return
+	}
+
+	c.sendStatus(rcvr, filter)
 
 	for {
 		select {
 		case <-stop:
 			return
-		case signal := <-c.ch:
-			rcvr <- NewStatus(signal.Body)
+		case signal, ok := <-c.signals():
+			if ok {
+				c.forwardStatus(rcvr, filter, signal)
+
+				continue
+			}
+
+			c.setDisconnected()
+
+			if !c.reconnect(stop) {
+				return
+			}
+
+			c.sendStatus(rcvr, filter)
+		}
+	}
+}
+
+// pollStatuses periodically gets the current status and sends it on the rcvr channel when it changes,
+// until the stop channel is closed. Closed D-Bus connection is re-established like when receiving signals.
+func (c *client) pollStatuses(rcvr chan<- Status, filter *statusFilter, stop <-chan struct{}) {
+	ticker := time.NewTicker(c.statusPollInterval)
+	defer ticker.Stop()
+
+	previous, polled := Status{}, false
+
+	for {
+		status, err := c.getStatus()
+
+		switch {
+		case errors.Is(err, ErrNotConnected):
+			c.setDisconnected()
+
+			if !c.reconnect(stop) {
+				return
+			}
+
+			continue
+		case err != nil:
+			c.reportError(fmt.Errorf("polling status: %w", err))
+		case polled && status == previous:
+		default:
+			previous, polled = status, true
+
+			if filter.deliver(status, time.Now()) {
+				rcvr <- status
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// forwardStatus sends status carried by given signal on the rcvr channel, unless it gets filtered out.
+// Malformed signals are ignored.
+func (c *client) forwardStatus(rcvr chan<- Status, filter *statusFilter, signal *godbus.Signal) {
+	status, err := NewStatus(signal.Body)
+	if err != nil {
+		c.reportError(fmt.Errorf("ignoring malformed status signal: %w", err))
+
+		return
+	}
+
+	if filter.deliver(status, time.Now()) {
+		rcvr <- status
+	}
+}
+
+// sendStatus gets the current status and sends it on the rcvr channel, unless it gets filtered out.
+func (c *client) sendStatus(rcvr chan<- Status, filter *statusFilter) {
+	// If there is an error getting the current status, report it and send empty status,
+	// then move onto the main loop.
+	st, err := c.getStatus()
+	if err != nil {
+		c.reportError(fmt.Errorf("getting current status: %w", err))
+	}
+
+	if filter.deliver(st, time.Now()) {
+		rcvr <- st
+	}
+}
+
+// reportError logs given error and sends it on the configured errors channel, if any, without blocking.
+func (c *client) reportError(err error) {
+	klog.Error(err)
+
+	if c.errors == nil {
+		return
+	}
+
+	select {
+	case c.errors <- err:
+	default:
+	}
+}
+
+func (c *client) signals() <-chan *godbus.Signal {
+	c.connLock.RLock()
+	defer c.connLock.RUnlock()
+
+	return c.ch
+}
+
+func (c *client) setDisconnected() {
+	c.reportError(fmt.Errorf("%w, reconnecting", ErrDisconnected))
+
+	c.monitor.SetConnected(false)
+
+	c.connLock.Lock()
+	defer c.connLock.Unlock()
+
+	c.disconnected = true
+}
+
+// reconnect attempts to re-establish D-Bus connection with exponential backoff until it succeeds
+// or until stop channel gets closed, in which case false is returned.
+func (c *client) reconnect(stop <-chan struct{}) bool {
+	interval := c.reconnectInterval
+
+	for {
+		select {
+		case <-stop:
+			return false
+		case <-time.After(interval):
+		}
+
+		conn, ch, err := connect(c.connector, c.statusPollInterval == 0)
+		if err != nil {
+			c.reportError(fmt.Errorf("reconnecting to D-Bus, retrying in %v: %w", interval, err))
+
+			if interval *= 2; interval > c.maxReconnectInterval {
+				interval = c.maxReconnectInterval
+			}
+
+			continue
 		}
+
+		c.replaceConnection(conn, ch)
+
+		klog.Info("Reconnected to D-Bus")
+
+		return true
+	}
+}
+
+// replaceConnection closes the current D-Bus connection and replaces it with given one.
+func (c *client) replaceConnection(conn DBusConnection, ch chan *godbus.Signal) {
+	c.connLock.Lock()
+	defer c.connLock.Unlock()
+
+	if err := c.conn.Close(); err != nil {
+		klog.V(4).Infof("Failed closing previous D-Bus connection: %v", err)
 	}
+
+	c.conn = conn
+	c.ch = ch
+	c.object = c.monitor.InstrumentObject(conn.Object(DBusDestination, godbus.ObjectPath(DBusPath)))
+	c.disconnected = false
+	c.reconnects++
+
+	c.monitor.SetConnected(true)
 }
 
 // Close closes internal D-Bus connection.
 func (c *client) Close() error {
+	c.connLock.RLock()
+	defer c.connLock.RUnlock()
+
 	if c.conn != nil {
 		return c.conn.Close()
 	}
@@ -125,12 +444,118 @@ func (c *client) Close() error {
 	return nil
 }
 
+// Healthz implements Client interface.
+func (c *client) Healthz() error {
+	c.connLock.RLock()
+	defer c.connLock.RUnlock()
+
+	if c.disconnected {
+		return ErrDisconnected
+	}
+
+	return nil
+}
+
+// Reconnects implements Client interface.
+func (c *client) Reconnects() uint64 {
+	c.connLock.RLock()
+	defer c.connLock.RUnlock()
+
+	return c.reconnects
+}
+
+// AttemptUpdate implements Client interface.
+func (c *client) AttemptUpdate() error {
+	if call := c.caller().Call(DBusInterface+"."+DBusMethodNameAttemptUpdate, 0); call.Err != nil {
+		return fmt.Errorf("calling %s: %w", DBusMethodNameAttemptUpdate, dbus.ClassifyError(call.Err))
+	}
+
+	return nil
+}
+
+// ResetStatus implements Client interface.
+func (c *client) ResetStatus() error {
+	if call := c.caller().Call(DBusInterface+"."+DBusMethodNameResetStatus, 0); call.Err != nil {
+		return fmt.Errorf("calling %s: %w", DBusMethodNameResetStatus, dbus.ClassifyError(call.Err))
+	}
+
+	return nil
+}
+
+// AttemptRollback implements Client interface.
+func (c *client) AttemptRollback() error {
+	// Rolling back must never wipe the machine.
+	isPowerwashAllowed := false
+
+	call := c.caller().Call(DBusInterface+"."+DBusMethodNameAttemptRollback, 0, isPowerwashAllowed)
+	if call.Err != nil {
+		return fmt.Errorf("calling %s: %w", DBusMethodNameAttemptRollback, dbus.ClassifyError(call.Err))
+	}
+
+	return nil
+}
+
+// GetLastAttemptError implements Client interface.
+func (c *client) GetLastAttemptError() (int32, error) {
+	call := c.caller().Call(DBusInterface+"."+DBusMethodNameGetLastAttemptError, 0)
+	if call.Err != nil {
+		return 0, fmt.Errorf("calling %s: %w", DBusMethodNameGetLastAttemptError, dbus.ClassifyError(call.Err))
+	}
+
+	var code int32
+
+	if err := godbus.Store(call.Body, &code); err != nil {
+		return 0, fmt.Errorf("decoding %s reply: %w", DBusMethodNameGetLastAttemptError, err)
+	}
+
+	return code, nil
+}
+
+// GetChannel implements Client interface.
+func (c *client) GetChannel() (string, error) {
+	// Target channel is requested, as it is the one used for following update checks.
+	getCurrentChannel := false
+
+	call := c.caller().Call(DBusInterface+"."+DBusMethodNameGetChannel, 0, getCurrentChannel)
+	if call.Err != nil {
+		return "", fmt.Errorf("calling %s: %w", DBusMethodNameGetChannel, dbus.ClassifyError(call.Err))
+	}
+
+	channel := ""
+
+	if err := godbus.Store(call.Body, &channel); err != nil {
+		return "", fmt.Errorf("decoding %s reply: %w", DBusMethodNameGetChannel, err)
+	}
+
+	return channel, nil
+}
+
+// SetChannel implements Client interface.
+func (c *client) SetChannel(channel string) error {
+	// Changing channel must never wipe the machine.
+	isPowerwashAllowed := false
+
+	call := c.caller().Call(DBusInterface+"."+DBusMethodNameSetChannel, 0, channel, isPowerwashAllowed)
+	if call.Err != nil {
+		return fmt.Errorf("calling %s: %w", DBusMethodNameSetChannel, dbus.ClassifyError(call.Err))
+	}
+
+	return nil
+}
+
+func (c *client) caller() caller {
+	c.connLock.RLock()
+	defer c.connLock.RUnlock()
+
+	return c.object
+}
+
 // getStatus gets the current status from update_engine.
 func (c *client) getStatus() (Status, error) {
-	call := c.object.Call(DBusInterface+"."+DBusMethodNameGetStatus, 0)
+	call := c.caller().Call(DBusInterface+"."+DBusMethodNameGetStatus, 0)
 	if call.Err != nil {
-		return Status{}, call.Err
+		return Status{}, dbus.ClassifyError(call.Err)
 	}
 
-	return NewStatus(call.Body), nil
+	return NewStatus(call.Body)
 }
@@ -9,6 +9,7 @@ import (
 
 	godbus "github.com/godbus/dbus/v5"
 	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/dbus"
 	"github.com/flatcar/flatcar-linux-update-operator/pkg/updateengine"
@@ -161,6 +162,112 @@ func Test_Receiving_status(t *testing.T) {
 		}
 	})
 
+	t.Run("ignores_malformed_status_updates_received_from_update_engine", func(t *testing.T) {
+		t.Parallel()
+
+		expectedStatus := testStatus()
+
+		mockConnection := &dbus.MockConnection{
+			ObjectF: func(string, godbus.ObjectPath) godbus.BusObject {
+				return &dbus.MockObject{
+					CallF: func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+						return &godbus.Call{
+							Body: statusToSignalBody(updateengine.Status{}),
+						}
+					},
+				}
+			},
+			SignalF: func(ch chan<- *godbus.Signal) {
+				ch <- &godbus.Signal{
+					Body: []interface{}{"not a timestamp", 0.5, updateengine.UpdateStatusDownloading, "1.2.3", int64(30)},
+				}
+				ch <- &godbus.Signal{
+					Body: statusToSignalBody(expectedStatus)[:3],
+				}
+				ch <- &godbus.Signal{
+					Body: statusToSignalBody(expectedStatus),
+				}
+			},
+		}
+
+		client, err := updateengine.New(func() (dbus.Connection, error) { return mockConnection, nil })
+		if err != nil {
+			t.Fatalf("Got unexpected error while creating client: %v", err)
+		}
+
+		stop := make(chan struct{})
+
+		t.Cleanup(func() {
+			close(stop)
+		})
+
+		statusCh := make(chan updateengine.Status, 1)
+
+		go client.ReceiveStatuses(statusCh, stop)
+
+		timeout := time.NewTimer(time.Second)
+
+		select {
+		case <-statusCh:
+		case <-timeout.C:
+			t.Fatal("Failed getting initial status within expected timeframe")
+		}
+
+		timeout.Reset(time.Second)
+
+		select {
+		case status := <-statusCh:
+			if diff := cmp.Diff(expectedStatus, status); diff != "" {
+				t.Fatalf("Unexpectected status values received (-expected/+got):\n%s", diff)
+			}
+		case <-timeout.C:
+			t.Fatal("Failed getting status within expected timeframe")
+		}
+	})
+
+	t.Run("reports_error_on_configured_errors_channel_when_getting_initial_status_fails", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := fmt.Errorf("some error")
+
+		connector := func() (dbus.Connection, error) {
+			return &dbus.MockConnection{
+				ObjectF: func(string, godbus.ObjectPath) godbus.BusObject {
+					return &dbus.MockObject{
+						CallF: func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+							return &godbus.Call{Err: expectedErr}
+						},
+					}
+				},
+			}, nil
+		}
+
+		errCh := make(chan error, 1)
+
+		config := testConfig(connector)
+		config.Errors = errCh
+
+		client, err := updateengine.NewWithConfig(config)
+		if err != nil {
+			t.Fatalf("Got unexpected error while creating client: %v", err)
+		}
+
+		stop := make(chan struct{})
+
+		t.Cleanup(func() { close(stop) })
+
+		go client.ReceiveStatuses(make(chan updateengine.Status, 1), stop)
+
+		select {
+		case err := <-errCh:
+			if !errors.Is(err, expectedErr) {
+				t.Fatalf("Expected error %q, got %q", expectedErr, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected error to be reported within expected timeframe")
+		}
+	})
+
 	t.Run("returns_empty_status_when_getting_initial_status_fails", func(t *testing.T) {
 		t.Parallel()
 
@@ -352,6 +459,729 @@ func Test_Closing_client(t *testing.T) {
 	})
 }
 
+func Test_Client_health(t *testing.T) {
+	t.Parallel()
+
+	t.Run("is_healthy_while_D-Bus_connection_is_open", func(t *testing.T) {
+		t.Parallel()
+
+		client, err := updateengine.New(func() (dbus.Connection, error) { return &dbus.MockConnection{}, nil })
+		if err != nil {
+			t.Fatalf("Got unexpected error while creating client: %v", err)
+		}
+
+		if err := client.Healthz(); err != nil {
+			t.Fatalf("Expected client to be healthy, got: %v", err)
+		}
+	})
+
+	t.Run("is_not_healthy_while_D-Bus_connection_is_closed", func(t *testing.T) {
+		t.Parallel()
+
+		connected := false
+
+		connector := func() (dbus.Connection, error) {
+			if connected {
+				return nil, fmt.Errorf("connection refused")
+			}
+
+			connected = true
+
+			return closingConnection(), nil
+		}
+
+		client, err := updateengine.NewWithConfig(testConfig(connector))
+		if err != nil {
+			t.Fatalf("Got unexpected error while creating client: %v", err)
+		}
+
+		stop := make(chan struct{})
+		done := make(chan struct{})
+
+		go func() {
+			client.ReceiveStatuses(make(chan updateengine.Status, 1), stop)
+			close(done)
+		}()
+
+		deadline := time.Now().Add(time.Second)
+
+		for client.Healthz() == nil {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected client to not be healthy")
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		close(stop)
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Expected receiving statuses to stop when stop channel gets closed while reconnecting")
+		}
+	})
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Filtering_statuses(t *testing.T) {
+	t.Parallel()
+
+	idle := updateengine.Status{CurrentOperation: updateengine.UpdateStatusIdle}
+	downloadStarted := updateengine.Status{
+		CurrentOperation: updateengine.UpdateStatusDownloading,
+		NewVersion:       "0.0.0",
+		NewSize:          1,
+		Progress:         0.1,
+	}
+	downloadProgressed := downloadStarted
+	downloadProgressed.Progress = 0.5
+	verifying := downloadProgressed
+	verifying.CurrentOperation = updateengine.UpdateStatusVerifying
+
+	signals := []updateengine.Status{downloadStarted, downloadStarted, downloadProgressed, verifying, verifying}
+
+	t.Run("delivers_all_statuses_by_default", func(t *testing.T) {
+		t.Parallel()
+
+		expectedStatuses := append([]updateengine.Status{idle}, signals...)
+
+		if diff := cmp.Diff(expectedStatuses, receiveStatuses(t, &updateengine.Config{}, idle, signals)); diff != "" {
+			t.Fatalf("Unexpected statuses received (-expected/+got):\n%s", diff)
+		}
+	})
+
+	t.Run("delivers_only_transitions_between_operations_when_enabled", func(t *testing.T) {
+		t.Parallel()
+
+		config := &updateengine.Config{OnlyTransitions: true}
+		expectedStatuses := []updateengine.Status{idle, downloadStarted, verifying}
+
+		if diff := cmp.Diff(expectedStatuses, receiveStatuses(t, config, idle, signals)); diff != "" {
+			t.Fatalf("Unexpected statuses received (-expected/+got):\n%s", diff)
+		}
+	})
+
+	t.Run("delivers_sampled_progress_updates_without_duplicates_when_sampling_interval_is_configured",
+		func(t *testing.T) {
+			t.Parallel()
+
+			config := &updateengine.Config{OnlyTransitions: true, ProgressSamplingInterval: time.Nanosecond}
+			expectedStatuses := []updateengine.Status{idle, downloadStarted, downloadProgressed, verifying}
+
+			if diff := cmp.Diff(expectedStatuses, receiveStatuses(t, config, idle, signals)); diff != "" {
+				t.Fatalf("Unexpected statuses received (-expected/+got):\n%s", diff)
+			}
+		})
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Polling_statuses(t *testing.T) {
+	t.Parallel()
+
+	t.Run("delivers_changed_statuses_without_subscribing_to_status_signals", func(t *testing.T) {
+		t.Parallel()
+
+		idle := updateengine.Status{CurrentOperation: updateengine.UpdateStatusIdle}
+		needReboot := updateengine.Status{CurrentOperation: updateengine.UpdateStatusUpdatedNeedReboot}
+
+		statuses := make(chan updateengine.Status, 3)
+		statuses <- idle
+		statuses <- idle
+		statuses <- needReboot
+
+		connector := func() (dbus.Connection, error) {
+			return &dbus.MockConnection{
+				AddMatchSignalF: func(...godbus.MatchOption) error {
+					return fmt.Errorf("signals are not allowed")
+				},
+				ObjectF: func(string, godbus.ObjectPath) godbus.BusObject {
+					return &dbus.MockObject{
+						CallF: func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+							select {
+							case status := <-statuses:
+								return &godbus.Call{Body: statusToSignalBody(status)}
+							default:
+								return &godbus.Call{Body: statusToSignalBody(needReboot)}
+							}
+						},
+					}
+				},
+			}, nil
+		}
+
+		config := testConfig(connector)
+		config.StatusPollInterval = time.Millisecond
+
+		client, err := updateengine.NewWithConfig(config)
+		if err != nil {
+			t.Fatalf("Got unexpected error while creating client: %v", err)
+		}
+
+		statusCh := make(chan updateengine.Status, 10)
+		stop := make(chan struct{})
+
+		t.Cleanup(func() { close(stop) })
+
+		go client.ReceiveStatuses(statusCh, stop)
+
+		received := []updateengine.Status{}
+		timeout := time.After(100 * time.Millisecond)
+
+		for done := false; !done; {
+			select {
+			case status := <-statusCh:
+				received = append(received, status)
+			case <-timeout:
+				done = true
+			}
+		}
+
+		if diff := cmp.Diff([]updateengine.Status{idle, needReboot}, received); diff != "" {
+			t.Fatalf("Unexpected statuses received (-expected/+got):\n%s", diff)
+		}
+	})
+
+	t.Run("reconnects_when_D-Bus_connection_gets_closed", func(t *testing.T) {
+		t.Parallel()
+
+		expectedStatus := testStatus()
+		connections := 0
+
+		connector := func() (dbus.Connection, error) {
+			connections++
+
+			callErr := error(nil)
+			if connections == 1 {
+				callErr = godbus.ErrClosed
+			}
+
+			return &dbus.MockConnection{
+				ObjectF: func(string, godbus.ObjectPath) godbus.BusObject {
+					return &dbus.MockObject{
+						CallF: func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+							return &godbus.Call{Body: statusToSignalBody(expectedStatus), Err: callErr}
+						},
+					}
+				},
+			}, nil
+		}
+
+		config := testConfig(connector)
+		config.StatusPollInterval = time.Millisecond
+
+		client, err := updateengine.NewWithConfig(config)
+		if err != nil {
+			t.Fatalf("Got unexpected error while creating client: %v", err)
+		}
+
+		statusCh := make(chan updateengine.Status, 1)
+		stop := make(chan struct{})
+
+		t.Cleanup(func() { close(stop) })
+
+		go client.ReceiveStatuses(statusCh, stop)
+
+		select {
+		case status := <-statusCh:
+			if diff := cmp.Diff(expectedStatus, status); diff != "" {
+				t.Fatalf("Unexpected status received (-expected/+got):\n%s", diff)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected status to be polled using re-established connection")
+		}
+
+		if reconnects := client.Reconnects(); reconnects != 1 {
+			t.Fatalf("Expected 1 reconnect, got %d", reconnects)
+		}
+	})
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Reconnecting(t *testing.T) {
+	t.Parallel()
+
+	t.Run("resubscribes_to_status_signals_when_D-Bus_connection_gets_closed", func(t *testing.T) {
+		t.Parallel()
+
+		expectedStatus := testStatus()
+		connections := 0
+
+		connector := func() (dbus.Connection, error) {
+			connections++
+
+			switch connections {
+			case 1:
+				return closingConnection(), nil
+			case 2:
+				return nil, fmt.Errorf("connection refused")
+			}
+
+			return &dbus.MockConnection{
+				ObjectF: func(string, godbus.ObjectPath) godbus.BusObject {
+					return &dbus.MockObject{
+						CallF: func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+							return &godbus.Call{Body: statusToSignalBody(updateengine.Status{})}
+						},
+					}
+				},
+				SignalF: func(ch chan<- *godbus.Signal) {
+					ch <- &godbus.Signal{Body: statusToSignalBody(expectedStatus)}
+				},
+			}, nil
+		}
+
+		client, err := updateengine.NewWithConfig(testConfig(connector))
+		if err != nil {
+			t.Fatalf("Got unexpected error while creating client: %v", err)
+		}
+
+		statusCh := make(chan updateengine.Status, 1)
+		stop := make(chan struct{})
+
+		t.Cleanup(func() { close(stop) })
+
+		go client.ReceiveStatuses(statusCh, stop)
+
+		timeout := time.After(time.Second)
+
+		for {
+			select {
+			case status := <-statusCh:
+				if !reflect.DeepEqual(status, expectedStatus) {
+					continue
+				}
+			case <-timeout:
+				t.Fatal("Expected status from re-established connection to be received")
+			}
+
+			break
+		}
+
+		if err := client.Healthz(); err != nil {
+			t.Fatalf("Expected client to be healthy after reconnecting, got: %v", err)
+		}
+
+		if reconnects := client.Reconnects(); reconnects != 1 {
+			t.Fatalf("Expected 1 reconnect, got %d", reconnects)
+		}
+	})
+
+	t.Run("reports_disconnection_and_failed_reconnect_attempts_on_configured_errors_channel", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := fmt.Errorf("connection refused")
+		connections := 0
+
+		connector := func() (dbus.Connection, error) {
+			connections++
+
+			switch connections {
+			case 1:
+				return closingConnection(), nil
+			case 2:
+				return nil, expectedErr
+			}
+
+			return &dbus.MockConnection{
+				ObjectF: func(string, godbus.ObjectPath) godbus.BusObject {
+					return &dbus.MockObject{
+						CallF: func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+							return &godbus.Call{Body: statusToSignalBody(updateengine.Status{})}
+						},
+					}
+				},
+			}, nil
+		}
+
+		errCh := make(chan error, 10)
+
+		config := testConfig(connector)
+		config.Errors = errCh
+
+		client, err := updateengine.NewWithConfig(config)
+		if err != nil {
+			t.Fatalf("Got unexpected error while creating client: %v", err)
+		}
+
+		statusCh := make(chan updateengine.Status, 1)
+		stop := make(chan struct{})
+
+		t.Cleanup(func() { close(stop) })
+
+		go client.ReceiveStatuses(statusCh, stop)
+
+		timeout := time.After(time.Second)
+
+		for _, expected := range []error{updateengine.ErrDisconnected, expectedErr} {
+			select {
+			case err := <-errCh:
+				if !errors.Is(err, expected) {
+					t.Fatalf("Expected error %q, got %q", expected, err)
+				}
+			case <-timeout:
+				t.Fatalf("Expected error %q to be reported", expected)
+			}
+		}
+	})
+}
+
+//nolint:funlen // Just many sub-tests.
+func Test_Attempting_update(t *testing.T) {
+	t.Parallel()
+
+	t.Run("calls_update_engine_attempt_update_method", func(t *testing.T) {
+		t.Parallel()
+
+		calledMethods := make(chan string, 1)
+
+		mockConnection := &dbus.MockConnection{
+			ObjectF: func(string, godbus.ObjectPath) godbus.BusObject {
+				return &dbus.MockObject{
+					CallF: func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+						calledMethods <- method
+
+						return &godbus.Call{}
+					},
+				}
+			},
+		}
+
+		client, err := updateengine.New(func() (dbus.Connection, error) { return mockConnection, nil })
+		if err != nil {
+			t.Fatalf("Got unexpected error while creating client: %v", err)
+		}
+
+		if err := client.AttemptUpdate(); err != nil {
+			t.Fatalf("Unexpected error attempting update: %v", err)
+		}
+
+		expectedMethod := updateengine.DBusInterface + "." + updateengine.DBusMethodNameAttemptUpdate
+
+		if method := <-calledMethods; method != expectedMethod {
+			t.Fatalf("Expected method %q to be called, got %q", expectedMethod, method)
+		}
+	})
+
+	t.Run("returns_error_when_calling_attempt_update_method_fails", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("update check already in progress")
+
+		mockConnection := &dbus.MockConnection{
+			ObjectF: func(string, godbus.ObjectPath) godbus.BusObject {
+				return &dbus.MockObject{
+					CallF: func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+						return &godbus.Call{Err: expectedErr}
+					},
+				}
+			},
+		}
+
+		client, err := updateengine.New(func() (dbus.Connection, error) { return mockConnection, nil })
+		if err != nil {
+			t.Fatalf("Got unexpected error while creating client: %v", err)
+		}
+
+		if err := client.AttemptUpdate(); !errors.Is(err, expectedErr) {
+			t.Fatalf("Expected error %q, got %v", expectedErr, err)
+		}
+	})
+
+	t.Run("returns_error_indicating_unavailable_service_when_update_engine_is_not_running", func(t *testing.T) {
+		t.Parallel()
+
+		client := clientWithCallF(t, func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+			return &godbus.Call{Err: godbus.Error{Name: "org.freedesktop.DBus.Error.ServiceUnknown"}}
+		})
+
+		if err := client.AttemptUpdate(); !errors.Is(err, updateengine.ErrServiceUnavailable) {
+			t.Fatalf("Expected error %q, got %v", updateengine.ErrServiceUnavailable, err)
+		}
+	})
+}
+
+func Test_Monitoring_client(t *testing.T) {
+	t.Parallel()
+
+	t.Run("records_method_calls_using_configured_monitor", func(t *testing.T) {
+		t.Parallel()
+
+		registry := prometheus.NewRegistry()
+
+		monitor, err := dbus.NewMonitor(registry, "test")
+		if err != nil {
+			t.Fatalf("Unexpected error creating monitor: %v", err)
+		}
+
+		config := testConfig(func() (dbus.Connection, error) {
+			return &dbus.MockConnection{
+				ObjectF: func(string, godbus.ObjectPath) godbus.BusObject {
+					return &dbus.MockObject{
+						CallF: func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+							return &godbus.Call{Err: fmt.Errorf("update check already in progress")}
+						},
+					}
+				},
+			}, nil
+		})
+		config.Monitor = monitor
+
+		client, err := updateengine.NewWithConfig(config)
+		if err != nil {
+			t.Fatalf("Got unexpected error while creating client: %v", err)
+		}
+
+		if err := client.AttemptUpdate(); err == nil {
+			t.Fatalf("Expected error attempting update")
+		}
+
+		metricFamilies, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("Failed gathering metrics: %v", err)
+		}
+
+		for _, metricFamily := range metricFamilies {
+			if metricFamily.GetName() == "test_dbus_call_errors_total" {
+				return
+			}
+		}
+
+		t.Fatalf("Expected failed call to be recorded")
+	})
+}
+
+func Test_Resetting_status(t *testing.T) {
+	t.Parallel()
+
+	t.Run("calls_update_engine_reset_status_method", func(t *testing.T) {
+		t.Parallel()
+
+		calledMethods := make(chan string, 1)
+
+		client := clientWithCallF(t, func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+			calledMethods <- method
+
+			return &godbus.Call{}
+		})
+
+		if err := client.ResetStatus(); err != nil {
+			t.Fatalf("Unexpected error resetting status: %v", err)
+		}
+
+		expectedMethod := updateengine.DBusInterface + "." + updateengine.DBusMethodNameResetStatus
+
+		if method := <-calledMethods; method != expectedMethod {
+			t.Fatalf("Expected method %q to be called, got %q", expectedMethod, method)
+		}
+	})
+
+	t.Run("returns_error_when_calling_reset_status_method_fails", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("access denied")
+
+		client := clientWithCallF(t, func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+			return &godbus.Call{Err: expectedErr}
+		})
+
+		if err := client.ResetStatus(); !errors.Is(err, expectedErr) {
+			t.Fatalf("Expected error %q, got %v", expectedErr, err)
+		}
+	})
+}
+
+func Test_Attempting_rollback(t *testing.T) {
+	t.Parallel()
+
+	t.Run("calls_update_engine_attempt_rollback_method_without_allowing_powerwash", func(t *testing.T) {
+		t.Parallel()
+
+		calls := make(chan []interface{}, 1)
+
+		client := clientWithCallF(t, func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+			if method == updateengine.DBusInterface+"."+updateengine.DBusMethodNameAttemptRollback {
+				calls <- args
+			}
+
+			return &godbus.Call{}
+		})
+
+		if err := client.AttemptRollback(); err != nil {
+			t.Fatalf("Unexpected error attempting rollback: %v", err)
+		}
+
+		select {
+		case args := <-calls:
+			if diff := cmp.Diff([]interface{}{false}, args); diff != "" {
+				t.Fatalf("Unexpected arguments (-expected/+got):\n%s", diff)
+			}
+		default:
+			t.Fatalf("Expected method %q to be called", updateengine.DBusMethodNameAttemptRollback)
+		}
+	})
+
+	t.Run("returns_error_when_calling_attempt_rollback_method_fails", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("no partition to roll back to")
+
+		client := clientWithCallF(t, func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+			return &godbus.Call{Err: expectedErr}
+		})
+
+		if err := client.AttemptRollback(); !errors.Is(err, expectedErr) {
+			t.Fatalf("Expected error %q, got %v", expectedErr, err)
+		}
+	})
+}
+
+func Test_Getting_last_attempt_error(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns_error_code_reported_by_update_engine", func(t *testing.T) {
+		t.Parallel()
+
+		client := clientWithCallF(t, func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+			if method != updateengine.DBusInterface+"."+updateengine.DBusMethodNameGetLastAttemptError {
+				return &godbus.Call{Err: fmt.Errorf("unexpected method %q", method)}
+			}
+
+			return &godbus.Call{Body: []interface{}{int32(10)}}
+		})
+
+		code, err := client.GetLastAttemptError()
+		if err != nil {
+			t.Fatalf("Unexpected error getting last attempt error: %v", err)
+		}
+
+		if code != 10 {
+			t.Fatalf("Expected error code %d, got %d", 10, code)
+		}
+	})
+
+	t.Run("returns_error_when_calling_update_engine_fails", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("method not supported")
+
+		client := clientWithCallF(t, func(string, godbus.Flags, ...interface{}) *godbus.Call {
+			return &godbus.Call{Err: expectedErr}
+		})
+
+		if _, err := client.GetLastAttemptError(); !errors.Is(err, expectedErr) {
+			t.Fatalf("Expected error %q, got %v", expectedErr, err)
+		}
+	})
+}
+
+//nolint:funlen // Just many subtests.
+func Test_Update_channel(t *testing.T) {
+	t.Parallel()
+
+	t.Run("is_read_from_update_engine_as_target_channel", func(t *testing.T) {
+		t.Parallel()
+
+		calledArgs := make(chan []interface{}, 1)
+
+		client := clientWithCallF(t, func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+			if method != updateengine.DBusInterface+"."+updateengine.DBusMethodNameGetChannel {
+				return &godbus.Call{Err: fmt.Errorf("unexpected method %q", method)}
+			}
+
+			calledArgs <- args
+
+			return &godbus.Call{Body: []interface{}{"beta"}}
+		})
+
+		channel, err := client.GetChannel()
+		if err != nil {
+			t.Fatalf("Unexpected error getting channel: %v", err)
+		}
+
+		if channel != "beta" {
+			t.Fatalf("Expected channel %q, got %q", "beta", channel)
+		}
+
+		if diff := cmp.Diff([]interface{}{false}, <-calledArgs); diff != "" {
+			t.Fatalf("Unexpected method arguments (-expected/+got):\n%s", diff)
+		}
+	})
+
+	t.Run("is_changed_in_update_engine_without_allowing_powerwash", func(t *testing.T) {
+		t.Parallel()
+
+		calledArgs := make(chan []interface{}, 1)
+
+		client := clientWithCallF(t, func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+			if method != updateengine.DBusInterface+"."+updateengine.DBusMethodNameSetChannel {
+				return &godbus.Call{Err: fmt.Errorf("unexpected method %q", method)}
+			}
+
+			calledArgs <- args
+
+			return &godbus.Call{}
+		})
+
+		if err := client.SetChannel("stable"); err != nil {
+			t.Fatalf("Unexpected error setting channel: %v", err)
+		}
+
+		if diff := cmp.Diff([]interface{}{"stable", false}, <-calledArgs); diff != "" {
+			t.Fatalf("Unexpected method arguments (-expected/+got):\n%s", diff)
+		}
+	})
+
+	t.Run("returns_error_when_calling_update_engine_fails", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("method not supported")
+
+		client := clientWithCallF(t, func(string, godbus.Flags, ...interface{}) *godbus.Call {
+			return &godbus.Call{Err: expectedErr}
+		})
+
+		if _, err := client.GetChannel(); !errors.Is(err, expectedErr) {
+			t.Fatalf("Expected error %q getting channel, got %v", expectedErr, err)
+		}
+
+		if err := client.SetChannel("stable"); !errors.Is(err, expectedErr) {
+			t.Fatalf("Expected error %q setting channel, got %v", expectedErr, err)
+		}
+	})
+
+	t.Run("returns_error_when_update_engine_returns_no_channel", func(t *testing.T) {
+		t.Parallel()
+
+		client := clientWithCallF(t, func(string, godbus.Flags, ...interface{}) *godbus.Call {
+			return &godbus.Call{Body: []interface{}{}}
+		})
+
+		if _, err := client.GetChannel(); err == nil {
+			t.Fatalf("Expected error getting channel")
+		}
+	})
+}
+
+func clientWithCallF(
+	t *testing.T, callF func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call,
+) updateengine.Client {
+	t.Helper()
+
+	mockConnection := &dbus.MockConnection{
+		ObjectF: func(string, godbus.ObjectPath) godbus.BusObject {
+			return &dbus.MockObject{CallF: callF}
+		},
+	}
+
+	client, err := updateengine.New(func() (dbus.Connection, error) { return mockConnection, nil })
+	if err != nil {
+		t.Fatalf("Got unexpected error while creating client: %v", err)
+	}
+
+	return client
+}
+
 func testStatus() updateengine.Status {
 	return updateengine.Status{
 		LastCheckedTime:  10,
@@ -365,3 +1195,81 @@ func testStatus() updateengine.Status {
 func statusToSignalBody(s updateengine.Status) []interface{} {
 	return []interface{}{s.LastCheckedTime, s.Progress, s.CurrentOperation, s.NewVersion, s.NewSize}
 }
+
+// receiveStatuses returns statuses delivered by client created using given config, which receives given
+// initial status and status signals from update_engine.
+func receiveStatuses(
+	t *testing.T, config *updateengine.Config, initial updateengine.Status, signals []updateengine.Status,
+) []updateengine.Status {
+	t.Helper()
+
+	config.Connector = func() (dbus.Connection, error) {
+		return &dbus.MockConnection{
+			ObjectF: func(string, godbus.ObjectPath) godbus.BusObject {
+				return &dbus.MockObject{
+					CallF: func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+						return &godbus.Call{Body: statusToSignalBody(initial)}
+					},
+				}
+			},
+			SignalF: func(ch chan<- *godbus.Signal) {
+				for _, status := range signals {
+					ch <- &godbus.Signal{Body: statusToSignalBody(status)}
+				}
+			},
+		}, nil
+	}
+
+	client, err := updateengine.NewWithConfig(config)
+	if err != nil {
+		t.Fatalf("Got unexpected error while creating client: %v", err)
+	}
+
+	statusCh := make(chan updateengine.Status, len(signals)+1)
+	stop := make(chan struct{})
+
+	t.Cleanup(func() { close(stop) })
+
+	go client.ReceiveStatuses(statusCh, stop)
+
+	statuses := []updateengine.Status{}
+
+	// All signals are buffered before receiving starts, so they are processed quickly.
+	timeout := time.After(100 * time.Millisecond)
+
+	for {
+		select {
+		case status := <-statusCh:
+			statuses = append(statuses, status)
+		case <-timeout:
+			return statuses
+		}
+	}
+}
+
+func testConfig(connector dbus.Connector) *updateengine.Config {
+	return &updateengine.Config{
+		Connector:            connector,
+		ReconnectInterval:    time.Millisecond,
+		MaxReconnectInterval: 10 * time.Millisecond,
+	}
+}
+
+// closingConnection returns connection which gets closed immediately after subscribing to signals.
+func closingConnection() *dbus.MockConnection {
+	return &dbus.MockConnection{
+		ObjectF: func(string, godbus.ObjectPath) godbus.BusObject {
+			return &dbus.MockObject{
+				CallF: func(method string, flags godbus.Flags, args ...interface{}) *godbus.Call {
+					return &godbus.Call{
+						Body: statusToSignalBody(updateengine.Status{}),
+					}
+				},
+			}
+		},
+		// Closed connection closes all signal channels.
+		SignalF: func(ch chan<- *godbus.Signal) {
+			close(ch)
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package updateengine
+
+import (
+	"time"
+)
+
+// statusFilter decides which statuses received from update_engine are delivered to the receiver.
+type statusFilter struct {
+	onlyTransitions          bool
+	progressSamplingInterval time.Duration
+
+	delivered     bool
+	lastStatus    Status
+	lastDelivered time.Time
+}
+
+// deliver returns true when given status should be delivered to the receiver.
+//
+// With filtering enabled, status is delivered when it is the first status, when the operation, new version
+// or new size changes and, if progress sampling is configured, when other values like progress change, but not
+// more often than once per sampling interval. Duplicates of the last delivered status are never delivered.
+func (f *statusFilter) deliver(status Status, now time.Time) bool {
+	if !f.onlyTransitions {
+		return true
+	}
+
+	switch {
+	case !f.delivered, isTransition(f.lastStatus, status):
+	case status == f.lastStatus:
+		return false
+	case f.progressSamplingInterval == 0 || now.Sub(f.lastDelivered) < f.progressSamplingInterval:
+		return false
+	}
+
+	f.delivered = true
+	f.lastStatus = status
+	f.lastDelivered = now
+
+	return true
+}
+
+// isTransition returns true when given statuses describe different update operations.
+func isTransition(previous, current Status) bool {
+	return previous.CurrentOperation != current.CurrentOperation ||
+		previous.NewVersion != current.NewVersion ||
+		previous.NewSize != current.NewSize
+}
@@ -16,6 +16,8 @@ package updateengine
 
 import (
 	"fmt"
+
+	godbus "github.com/godbus/dbus/v5"
 )
 
 // The possible update statuses returned from the update engine
@@ -35,24 +37,35 @@ const (
 
 // Status represents status received from update-engine.
 type Status struct {
-	LastCheckedTime  int64
-	Progress         float64
+	// Unix time of the last update check.
+	LastCheckedTime int64
+	// Progress of the current operation, e.g. downloading, from 0 to 1.
+	Progress float64
+	// One of UpdateStatus* constants.
 	CurrentOperation string
-	NewVersion       string
-	NewSize          int64
+	// Version of the available or downloaded update. Opaque string, but usually semver.
+	NewVersion string
+	// Size of the available or downloaded update in bytes.
+	NewSize int64
 }
 
-// NewStatus constructs status from received D-Bus signal body.
-//
-//nolint:forcetypeassert // TODO: To be refactored to return errors.
-func NewStatus(body []interface{}) Status {
-	return Status{
-		LastCheckedTime:  body[0].(int64),
-		Progress:         body[1].(float64),
-		CurrentOperation: body[2].(string),
-		NewVersion:       body[3].(string),
-		NewSize:          body[4].(int64),
+// NewStatus constructs status from received D-Bus signal body or from the reply to GetStatus method call,
+// which both carry the same values. An error is returned when the body does not match the format used by
+// update_engine.
+func NewStatus(body []interface{}) (Status, error) {
+	status := Status{}
+
+	if err := godbus.Store(body,
+		&status.LastCheckedTime,
+		&status.Progress,
+		&status.CurrentOperation,
+		&status.NewVersion,
+		&status.NewSize,
+	); err != nil {
+		return Status{}, fmt.Errorf("decoding status: %w", err)
 	}
+
+	return status, nil
 }
 
 // String implements Stringer interface for Status.